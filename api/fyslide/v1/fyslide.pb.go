@@ -0,0 +1,987 @@
+// The FySlide tag service: remote access to the operations of the tag
+// database and the image files it describes, served by "fyslide-cli serve-grpc".
+//
+// Image paths are absolute paths on the server's filesystem, the same keys the
+// tag database uses. Tags are case-insensitive and stored in lowercase.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: fyslide/v1/fyslide.proto
+
+package fyslidev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AddTagsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Tags          []string               `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddTagsRequest) Reset() {
+	*x = AddTagsRequest{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddTagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddTagsRequest) ProtoMessage() {}
+
+func (x *AddTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddTagsRequest.ProtoReflect.Descriptor instead.
+func (*AddTagsRequest) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AddTagsRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *AddTagsRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type RemoveTagsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Tags          []string               `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveTagsRequest) Reset() {
+	*x = RemoveTagsRequest{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveTagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveTagsRequest) ProtoMessage() {}
+
+func (x *RemoveTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveTagsRequest.ProtoReflect.Descriptor instead.
+func (*RemoveTagsRequest) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RemoveTagsRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *RemoveTagsRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type GetTagsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTagsRequest) Reset() {
+	*x = GetTagsRequest{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTagsRequest) ProtoMessage() {}
+
+func (x *GetTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTagsRequest.ProtoReflect.Descriptor instead.
+func (*GetTagsRequest) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetTagsRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type ImageTags struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Tags          []string               `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImageTags) Reset() {
+	*x = ImageTags{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImageTags) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageTags) ProtoMessage() {}
+
+func (x *ImageTags) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageTags.ProtoReflect.Descriptor instead.
+func (*ImageTags) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ImageTags) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ImageTags) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type ListTagsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTagsRequest) Reset() {
+	*x = ListTagsRequest{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTagsRequest) ProtoMessage() {}
+
+func (x *ListTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTagsRequest.ProtoReflect.Descriptor instead.
+func (*ListTagsRequest) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{4}
+}
+
+type TagCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TagCount) Reset() {
+	*x = TagCount{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TagCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TagCount) ProtoMessage() {}
+
+func (x *TagCount) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TagCount.ProtoReflect.Descriptor instead.
+func (*TagCount) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TagCount) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TagCount) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type ListTagsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tags          []*TagCount            `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTagsResponse) Reset() {
+	*x = ListTagsResponse{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTagsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTagsResponse) ProtoMessage() {}
+
+func (x *ListTagsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTagsResponse.ProtoReflect.Descriptor instead.
+func (*ListTagsResponse) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListTagsResponse) GetTags() []*TagCount {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type FindImagesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// At least one tag is required.
+	Tags          []string `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindImagesRequest) Reset() {
+	*x = FindImagesRequest{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindImagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindImagesRequest) ProtoMessage() {}
+
+func (x *FindImagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindImagesRequest.ProtoReflect.Descriptor instead.
+func (*FindImagesRequest) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FindImagesRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type FindImagesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Paths         []string               `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindImagesResponse) Reset() {
+	*x = FindImagesResponse{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindImagesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindImagesResponse) ProtoMessage() {}
+
+func (x *FindImagesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindImagesResponse.ProtoReflect.Descriptor instead.
+func (*FindImagesResponse) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FindImagesResponse) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+type RenameImageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OldPath       string                 `protobuf:"bytes,1,opt,name=old_path,json=oldPath,proto3" json:"old_path,omitempty"`
+	NewPath       string                 `protobuf:"bytes,2,opt,name=new_path,json=newPath,proto3" json:"new_path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenameImageRequest) Reset() {
+	*x = RenameImageRequest{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenameImageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameImageRequest) ProtoMessage() {}
+
+func (x *RenameImageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameImageRequest.ProtoReflect.Descriptor instead.
+func (*RenameImageRequest) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RenameImageRequest) GetOldPath() string {
+	if x != nil {
+		return x.OldPath
+	}
+	return ""
+}
+
+func (x *RenameImageRequest) GetNewPath() string {
+	if x != nil {
+		return x.NewPath
+	}
+	return ""
+}
+
+type RenameImageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenameImageResponse) Reset() {
+	*x = RenameImageResponse{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenameImageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameImageResponse) ProtoMessage() {}
+
+func (x *RenameImageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameImageResponse.ProtoReflect.Descriptor instead.
+func (*RenameImageResponse) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{10}
+}
+
+type DeleteImageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteImageRequest) Reset() {
+	*x = DeleteImageRequest{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteImageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteImageRequest) ProtoMessage() {}
+
+func (x *DeleteImageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteImageRequest.ProtoReflect.Descriptor instead.
+func (*DeleteImageRequest) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DeleteImageRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type DeleteImageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteImageResponse) Reset() {
+	*x = DeleteImageResponse{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteImageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteImageResponse) ProtoMessage() {}
+
+func (x *DeleteImageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteImageResponse.ProtoReflect.Descriptor instead.
+func (*DeleteImageResponse) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{12}
+}
+
+type BatchTagsRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Directory string                 `protobuf:"bytes,1,opt,name=directory,proto3" json:"directory,omitempty"`
+	Tags      []string               `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Report what would change without changing anything.
+	DryRun        bool `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchTagsRequest) Reset() {
+	*x = BatchTagsRequest{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchTagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchTagsRequest) ProtoMessage() {}
+
+func (x *BatchTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchTagsRequest.ProtoReflect.Descriptor instead.
+func (*BatchTagsRequest) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *BatchTagsRequest) GetDirectory() string {
+	if x != nil {
+		return x.Directory
+	}
+	return ""
+}
+
+func (x *BatchTagsRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *BatchTagsRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type BatchTagsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Supported images found in the directory.
+	Images int32 `protobuf:"varint,1,opt,name=images,proto3" json:"images,omitempty"`
+	// Tag instances added or removed (or that would be, in a dry run).
+	Changes int32 `protobuf:"varint,2,opt,name=changes,proto3" json:"changes,omitempty"`
+	// One message per image and tag that failed; the rest are still processed.
+	Errors        []string `protobuf:"bytes,3,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchTagsResponse) Reset() {
+	*x = BatchTagsResponse{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchTagsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchTagsResponse) ProtoMessage() {}
+
+func (x *BatchTagsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchTagsResponse.ProtoReflect.Descriptor instead.
+func (*BatchTagsResponse) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *BatchTagsResponse) GetImages() int32 {
+	if x != nil {
+		return x.Images
+	}
+	return 0
+}
+
+func (x *BatchTagsResponse) GetChanges() int32 {
+	if x != nil {
+		return x.Changes
+	}
+	return 0
+}
+
+func (x *BatchTagsResponse) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+type CleanRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Report what would be removed without removing anything.
+	DryRun        bool `protobuf:"varint,1,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CleanRequest) Reset() {
+	*x = CleanRequest{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CleanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CleanRequest) ProtoMessage() {}
+
+func (x *CleanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CleanRequest.ProtoReflect.Descriptor instead.
+func (*CleanRequest) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CleanRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type CleanResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Images whose files no longer exist and whose tags were (or would be) removed.
+	MissingImages []string `protobuf:"bytes,1,rep,name=missing_images,json=missingImages,proto3" json:"missing_images,omitempty"`
+	// Tags without images that were (or would be) removed.
+	OrphanedTags  []string `protobuf:"bytes,2,rep,name=orphaned_tags,json=orphanedTags,proto3" json:"orphaned_tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CleanResponse) Reset() {
+	*x = CleanResponse{}
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CleanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CleanResponse) ProtoMessage() {}
+
+func (x *CleanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fyslide_v1_fyslide_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CleanResponse.ProtoReflect.Descriptor instead.
+func (*CleanResponse) Descriptor() ([]byte, []int) {
+	return file_fyslide_v1_fyslide_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CleanResponse) GetMissingImages() []string {
+	if x != nil {
+		return x.MissingImages
+	}
+	return nil
+}
+
+func (x *CleanResponse) GetOrphanedTags() []string {
+	if x != nil {
+		return x.OrphanedTags
+	}
+	return nil
+}
+
+var File_fyslide_v1_fyslide_proto protoreflect.FileDescriptor
+
+const file_fyslide_v1_fyslide_proto_rawDesc = "" +
+	"\n" +
+	"\x18fyslide/v1/fyslide.proto\x12\n" +
+	"fyslide.v1\"8\n" +
+	"\x0eAddTagsRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04tags\x18\x02 \x03(\tR\x04tags\";\n" +
+	"\x11RemoveTagsRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04tags\x18\x02 \x03(\tR\x04tags\"$\n" +
+	"\x0eGetTagsRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"3\n" +
+	"\tImageTags\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04tags\x18\x02 \x03(\tR\x04tags\"\x11\n" +
+	"\x0fListTagsRequest\"4\n" +
+	"\bTagCount\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\"<\n" +
+	"\x10ListTagsResponse\x12(\n" +
+	"\x04tags\x18\x01 \x03(\v2\x14.fyslide.v1.TagCountR\x04tags\"'\n" +
+	"\x11FindImagesRequest\x12\x12\n" +
+	"\x04tags\x18\x01 \x03(\tR\x04tags\"*\n" +
+	"\x12FindImagesResponse\x12\x14\n" +
+	"\x05paths\x18\x01 \x03(\tR\x05paths\"J\n" +
+	"\x12RenameImageRequest\x12\x19\n" +
+	"\bold_path\x18\x01 \x01(\tR\aoldPath\x12\x19\n" +
+	"\bnew_path\x18\x02 \x01(\tR\anewPath\"\x15\n" +
+	"\x13RenameImageResponse\"(\n" +
+	"\x12DeleteImageRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"\x15\n" +
+	"\x13DeleteImageResponse\"]\n" +
+	"\x10BatchTagsRequest\x12\x1c\n" +
+	"\tdirectory\x18\x01 \x01(\tR\tdirectory\x12\x12\n" +
+	"\x04tags\x18\x02 \x03(\tR\x04tags\x12\x17\n" +
+	"\adry_run\x18\x03 \x01(\bR\x06dryRun\"]\n" +
+	"\x11BatchTagsResponse\x12\x16\n" +
+	"\x06images\x18\x01 \x01(\x05R\x06images\x12\x18\n" +
+	"\achanges\x18\x02 \x01(\x05R\achanges\x12\x16\n" +
+	"\x06errors\x18\x03 \x03(\tR\x06errors\"'\n" +
+	"\fCleanRequest\x12\x17\n" +
+	"\adry_run\x18\x01 \x01(\bR\x06dryRun\"[\n" +
+	"\rCleanResponse\x12%\n" +
+	"\x0emissing_images\x18\x01 \x03(\tR\rmissingImages\x12#\n" +
+	"\rorphaned_tags\x18\x02 \x03(\tR\forphanedTags2\xdb\x05\n" +
+	"\n" +
+	"TagService\x12<\n" +
+	"\aAddTags\x12\x1a.fyslide.v1.AddTagsRequest\x1a\x15.fyslide.v1.ImageTags\x12B\n" +
+	"\n" +
+	"RemoveTags\x12\x1d.fyslide.v1.RemoveTagsRequest\x1a\x15.fyslide.v1.ImageTags\x12<\n" +
+	"\aGetTags\x12\x1a.fyslide.v1.GetTagsRequest\x1a\x15.fyslide.v1.ImageTags\x12E\n" +
+	"\bListTags\x12\x1b.fyslide.v1.ListTagsRequest\x1a\x1c.fyslide.v1.ListTagsResponse\x12K\n" +
+	"\n" +
+	"FindImages\x12\x1d.fyslide.v1.FindImagesRequest\x1a\x1e.fyslide.v1.FindImagesResponse\x12N\n" +
+	"\vRenameImage\x12\x1e.fyslide.v1.RenameImageRequest\x1a\x1f.fyslide.v1.RenameImageResponse\x12N\n" +
+	"\vDeleteImage\x12\x1e.fyslide.v1.DeleteImageRequest\x1a\x1f.fyslide.v1.DeleteImageResponse\x12K\n" +
+	"\fBatchAddTags\x12\x1c.fyslide.v1.BatchTagsRequest\x1a\x1d.fyslide.v1.BatchTagsResponse\x12N\n" +
+	"\x0fBatchRemoveTags\x12\x1c.fyslide.v1.BatchTagsRequest\x1a\x1d.fyslide.v1.BatchTagsResponse\x12<\n" +
+	"\x05Clean\x12\x18.fyslide.v1.CleanRequest\x1a\x19.fyslide.v1.CleanResponseB\"Z fyslide/api/fyslide/v1;fyslidev1b\x06proto3"
+
+var (
+	file_fyslide_v1_fyslide_proto_rawDescOnce sync.Once
+	file_fyslide_v1_fyslide_proto_rawDescData []byte
+)
+
+func file_fyslide_v1_fyslide_proto_rawDescGZIP() []byte {
+	file_fyslide_v1_fyslide_proto_rawDescOnce.Do(func() {
+		file_fyslide_v1_fyslide_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_fyslide_v1_fyslide_proto_rawDesc), len(file_fyslide_v1_fyslide_proto_rawDesc)))
+	})
+	return file_fyslide_v1_fyslide_proto_rawDescData
+}
+
+var file_fyslide_v1_fyslide_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_fyslide_v1_fyslide_proto_goTypes = []any{
+	(*AddTagsRequest)(nil),      // 0: fyslide.v1.AddTagsRequest
+	(*RemoveTagsRequest)(nil),   // 1: fyslide.v1.RemoveTagsRequest
+	(*GetTagsRequest)(nil),      // 2: fyslide.v1.GetTagsRequest
+	(*ImageTags)(nil),           // 3: fyslide.v1.ImageTags
+	(*ListTagsRequest)(nil),     // 4: fyslide.v1.ListTagsRequest
+	(*TagCount)(nil),            // 5: fyslide.v1.TagCount
+	(*ListTagsResponse)(nil),    // 6: fyslide.v1.ListTagsResponse
+	(*FindImagesRequest)(nil),   // 7: fyslide.v1.FindImagesRequest
+	(*FindImagesResponse)(nil),  // 8: fyslide.v1.FindImagesResponse
+	(*RenameImageRequest)(nil),  // 9: fyslide.v1.RenameImageRequest
+	(*RenameImageResponse)(nil), // 10: fyslide.v1.RenameImageResponse
+	(*DeleteImageRequest)(nil),  // 11: fyslide.v1.DeleteImageRequest
+	(*DeleteImageResponse)(nil), // 12: fyslide.v1.DeleteImageResponse
+	(*BatchTagsRequest)(nil),    // 13: fyslide.v1.BatchTagsRequest
+	(*BatchTagsResponse)(nil),   // 14: fyslide.v1.BatchTagsResponse
+	(*CleanRequest)(nil),        // 15: fyslide.v1.CleanRequest
+	(*CleanResponse)(nil),       // 16: fyslide.v1.CleanResponse
+}
+var file_fyslide_v1_fyslide_proto_depIdxs = []int32{
+	5,  // 0: fyslide.v1.ListTagsResponse.tags:type_name -> fyslide.v1.TagCount
+	0,  // 1: fyslide.v1.TagService.AddTags:input_type -> fyslide.v1.AddTagsRequest
+	1,  // 2: fyslide.v1.TagService.RemoveTags:input_type -> fyslide.v1.RemoveTagsRequest
+	2,  // 3: fyslide.v1.TagService.GetTags:input_type -> fyslide.v1.GetTagsRequest
+	4,  // 4: fyslide.v1.TagService.ListTags:input_type -> fyslide.v1.ListTagsRequest
+	7,  // 5: fyslide.v1.TagService.FindImages:input_type -> fyslide.v1.FindImagesRequest
+	9,  // 6: fyslide.v1.TagService.RenameImage:input_type -> fyslide.v1.RenameImageRequest
+	11, // 7: fyslide.v1.TagService.DeleteImage:input_type -> fyslide.v1.DeleteImageRequest
+	13, // 8: fyslide.v1.TagService.BatchAddTags:input_type -> fyslide.v1.BatchTagsRequest
+	13, // 9: fyslide.v1.TagService.BatchRemoveTags:input_type -> fyslide.v1.BatchTagsRequest
+	15, // 10: fyslide.v1.TagService.Clean:input_type -> fyslide.v1.CleanRequest
+	3,  // 11: fyslide.v1.TagService.AddTags:output_type -> fyslide.v1.ImageTags
+	3,  // 12: fyslide.v1.TagService.RemoveTags:output_type -> fyslide.v1.ImageTags
+	3,  // 13: fyslide.v1.TagService.GetTags:output_type -> fyslide.v1.ImageTags
+	6,  // 14: fyslide.v1.TagService.ListTags:output_type -> fyslide.v1.ListTagsResponse
+	8,  // 15: fyslide.v1.TagService.FindImages:output_type -> fyslide.v1.FindImagesResponse
+	10, // 16: fyslide.v1.TagService.RenameImage:output_type -> fyslide.v1.RenameImageResponse
+	12, // 17: fyslide.v1.TagService.DeleteImage:output_type -> fyslide.v1.DeleteImageResponse
+	14, // 18: fyslide.v1.TagService.BatchAddTags:output_type -> fyslide.v1.BatchTagsResponse
+	14, // 19: fyslide.v1.TagService.BatchRemoveTags:output_type -> fyslide.v1.BatchTagsResponse
+	16, // 20: fyslide.v1.TagService.Clean:output_type -> fyslide.v1.CleanResponse
+	11, // [11:21] is the sub-list for method output_type
+	1,  // [1:11] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_fyslide_v1_fyslide_proto_init() }
+func file_fyslide_v1_fyslide_proto_init() {
+	if File_fyslide_v1_fyslide_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_fyslide_v1_fyslide_proto_rawDesc), len(file_fyslide_v1_fyslide_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_fyslide_v1_fyslide_proto_goTypes,
+		DependencyIndexes: file_fyslide_v1_fyslide_proto_depIdxs,
+		MessageInfos:      file_fyslide_v1_fyslide_proto_msgTypes,
+	}.Build()
+	File_fyslide_v1_fyslide_proto = out.File
+	file_fyslide_v1_fyslide_proto_goTypes = nil
+	file_fyslide_v1_fyslide_proto_depIdxs = nil
+}
@@ -0,0 +1,493 @@
+// The FySlide tag service: remote access to the operations of the tag
+// database and the image files it describes, served by "fyslide-cli serve-grpc".
+//
+// Image paths are absolute paths on the server's filesystem, the same keys the
+// tag database uses. Tags are case-insensitive and stored in lowercase.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: fyslide/v1/fyslide.proto
+
+package fyslidev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TagService_AddTags_FullMethodName         = "/fyslide.v1.TagService/AddTags"
+	TagService_RemoveTags_FullMethodName      = "/fyslide.v1.TagService/RemoveTags"
+	TagService_GetTags_FullMethodName         = "/fyslide.v1.TagService/GetTags"
+	TagService_ListTags_FullMethodName        = "/fyslide.v1.TagService/ListTags"
+	TagService_FindImages_FullMethodName      = "/fyslide.v1.TagService/FindImages"
+	TagService_RenameImage_FullMethodName     = "/fyslide.v1.TagService/RenameImage"
+	TagService_DeleteImage_FullMethodName     = "/fyslide.v1.TagService/DeleteImage"
+	TagService_BatchAddTags_FullMethodName    = "/fyslide.v1.TagService/BatchAddTags"
+	TagService_BatchRemoveTags_FullMethodName = "/fyslide.v1.TagService/BatchRemoveTags"
+	TagService_Clean_FullMethodName           = "/fyslide.v1.TagService/Clean"
+)
+
+// TagServiceClient is the client API for TagService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TagServiceClient interface {
+	// AddTags adds tags to an image and returns the image's tags afterwards.
+	AddTags(ctx context.Context, in *AddTagsRequest, opts ...grpc.CallOption) (*ImageTags, error)
+	// RemoveTags removes tags from an image and returns the image's tags afterwards.
+	RemoveTags(ctx context.Context, in *RemoveTagsRequest, opts ...grpc.CallOption) (*ImageTags, error)
+	// GetTags returns the tags of an image.
+	GetTags(ctx context.Context, in *GetTagsRequest, opts ...grpc.CallOption) (*ImageTags, error)
+	// ListTags returns every tag in the database with its number of images.
+	ListTags(ctx context.Context, in *ListTagsRequest, opts ...grpc.CallOption) (*ListTagsResponse, error)
+	// FindImages returns the images carrying all of the given tags.
+	FindImages(ctx context.Context, in *FindImagesRequest, opts ...grpc.CallOption) (*FindImagesResponse, error)
+	// RenameImage renames an image file and moves its tags along. It fails with
+	// ALREADY_EXISTS instead of overwriting another file.
+	RenameImage(ctx context.Context, in *RenameImageRequest, opts ...grpc.CallOption) (*RenameImageResponse, error)
+	// DeleteImage deletes an image file and its tags.
+	DeleteImage(ctx context.Context, in *DeleteImageRequest, opts ...grpc.CallOption) (*DeleteImageResponse, error)
+	// BatchAddTags adds tags to every supported image directly within a directory.
+	BatchAddTags(ctx context.Context, in *BatchTagsRequest, opts ...grpc.CallOption) (*BatchTagsResponse, error)
+	// BatchRemoveTags removes tags from every supported image directly within a directory.
+	BatchRemoveTags(ctx context.Context, in *BatchTagsRequest, opts ...grpc.CallOption) (*BatchTagsResponse, error)
+	// Clean removes the tags of image files that no longer exist and tags no
+	// image carries any more.
+	Clean(ctx context.Context, in *CleanRequest, opts ...grpc.CallOption) (*CleanResponse, error)
+}
+
+type tagServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTagServiceClient(cc grpc.ClientConnInterface) TagServiceClient {
+	return &tagServiceClient{cc}
+}
+
+func (c *tagServiceClient) AddTags(ctx context.Context, in *AddTagsRequest, opts ...grpc.CallOption) (*ImageTags, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImageTags)
+	err := c.cc.Invoke(ctx, TagService_AddTags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tagServiceClient) RemoveTags(ctx context.Context, in *RemoveTagsRequest, opts ...grpc.CallOption) (*ImageTags, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImageTags)
+	err := c.cc.Invoke(ctx, TagService_RemoveTags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tagServiceClient) GetTags(ctx context.Context, in *GetTagsRequest, opts ...grpc.CallOption) (*ImageTags, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImageTags)
+	err := c.cc.Invoke(ctx, TagService_GetTags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tagServiceClient) ListTags(ctx context.Context, in *ListTagsRequest, opts ...grpc.CallOption) (*ListTagsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTagsResponse)
+	err := c.cc.Invoke(ctx, TagService_ListTags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tagServiceClient) FindImages(ctx context.Context, in *FindImagesRequest, opts ...grpc.CallOption) (*FindImagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindImagesResponse)
+	err := c.cc.Invoke(ctx, TagService_FindImages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tagServiceClient) RenameImage(ctx context.Context, in *RenameImageRequest, opts ...grpc.CallOption) (*RenameImageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenameImageResponse)
+	err := c.cc.Invoke(ctx, TagService_RenameImage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tagServiceClient) DeleteImage(ctx context.Context, in *DeleteImageRequest, opts ...grpc.CallOption) (*DeleteImageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteImageResponse)
+	err := c.cc.Invoke(ctx, TagService_DeleteImage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tagServiceClient) BatchAddTags(ctx context.Context, in *BatchTagsRequest, opts ...grpc.CallOption) (*BatchTagsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchTagsResponse)
+	err := c.cc.Invoke(ctx, TagService_BatchAddTags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tagServiceClient) BatchRemoveTags(ctx context.Context, in *BatchTagsRequest, opts ...grpc.CallOption) (*BatchTagsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchTagsResponse)
+	err := c.cc.Invoke(ctx, TagService_BatchRemoveTags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tagServiceClient) Clean(ctx context.Context, in *CleanRequest, opts ...grpc.CallOption) (*CleanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CleanResponse)
+	err := c.cc.Invoke(ctx, TagService_Clean_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TagServiceServer is the server API for TagService service.
+// All implementations must embed UnimplementedTagServiceServer
+// for forward compatibility.
+type TagServiceServer interface {
+	// AddTags adds tags to an image and returns the image's tags afterwards.
+	AddTags(context.Context, *AddTagsRequest) (*ImageTags, error)
+	// RemoveTags removes tags from an image and returns the image's tags afterwards.
+	RemoveTags(context.Context, *RemoveTagsRequest) (*ImageTags, error)
+	// GetTags returns the tags of an image.
+	GetTags(context.Context, *GetTagsRequest) (*ImageTags, error)
+	// ListTags returns every tag in the database with its number of images.
+	ListTags(context.Context, *ListTagsRequest) (*ListTagsResponse, error)
+	// FindImages returns the images carrying all of the given tags.
+	FindImages(context.Context, *FindImagesRequest) (*FindImagesResponse, error)
+	// RenameImage renames an image file and moves its tags along. It fails with
+	// ALREADY_EXISTS instead of overwriting another file.
+	RenameImage(context.Context, *RenameImageRequest) (*RenameImageResponse, error)
+	// DeleteImage deletes an image file and its tags.
+	DeleteImage(context.Context, *DeleteImageRequest) (*DeleteImageResponse, error)
+	// BatchAddTags adds tags to every supported image directly within a directory.
+	BatchAddTags(context.Context, *BatchTagsRequest) (*BatchTagsResponse, error)
+	// BatchRemoveTags removes tags from every supported image directly within a directory.
+	BatchRemoveTags(context.Context, *BatchTagsRequest) (*BatchTagsResponse, error)
+	// Clean removes the tags of image files that no longer exist and tags no
+	// image carries any more.
+	Clean(context.Context, *CleanRequest) (*CleanResponse, error)
+	mustEmbedUnimplementedTagServiceServer()
+}
+
+// UnimplementedTagServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTagServiceServer struct{}
+
+func (UnimplementedTagServiceServer) AddTags(context.Context, *AddTagsRequest) (*ImageTags, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddTags not implemented")
+}
+func (UnimplementedTagServiceServer) RemoveTags(context.Context, *RemoveTagsRequest) (*ImageTags, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveTags not implemented")
+}
+func (UnimplementedTagServiceServer) GetTags(context.Context, *GetTagsRequest) (*ImageTags, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTags not implemented")
+}
+func (UnimplementedTagServiceServer) ListTags(context.Context, *ListTagsRequest) (*ListTagsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTags not implemented")
+}
+func (UnimplementedTagServiceServer) FindImages(context.Context, *FindImagesRequest) (*FindImagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindImages not implemented")
+}
+func (UnimplementedTagServiceServer) RenameImage(context.Context, *RenameImageRequest) (*RenameImageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameImage not implemented")
+}
+func (UnimplementedTagServiceServer) DeleteImage(context.Context, *DeleteImageRequest) (*DeleteImageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteImage not implemented")
+}
+func (UnimplementedTagServiceServer) BatchAddTags(context.Context, *BatchTagsRequest) (*BatchTagsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchAddTags not implemented")
+}
+func (UnimplementedTagServiceServer) BatchRemoveTags(context.Context, *BatchTagsRequest) (*BatchTagsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchRemoveTags not implemented")
+}
+func (UnimplementedTagServiceServer) Clean(context.Context, *CleanRequest) (*CleanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Clean not implemented")
+}
+func (UnimplementedTagServiceServer) mustEmbedUnimplementedTagServiceServer() {}
+func (UnimplementedTagServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeTagServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TagServiceServer will
+// result in compilation errors.
+type UnsafeTagServiceServer interface {
+	mustEmbedUnimplementedTagServiceServer()
+}
+
+func RegisterTagServiceServer(s grpc.ServiceRegistrar, srv TagServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTagServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TagService_ServiceDesc, srv)
+}
+
+func _TagService_AddTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TagServiceServer).AddTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TagService_AddTags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TagServiceServer).AddTags(ctx, req.(*AddTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TagService_RemoveTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TagServiceServer).RemoveTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TagService_RemoveTags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TagServiceServer).RemoveTags(ctx, req.(*RemoveTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TagService_GetTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TagServiceServer).GetTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TagService_GetTags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TagServiceServer).GetTags(ctx, req.(*GetTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TagService_ListTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TagServiceServer).ListTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TagService_ListTags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TagServiceServer).ListTags(ctx, req.(*ListTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TagService_FindImages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindImagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TagServiceServer).FindImages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TagService_FindImages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TagServiceServer).FindImages(ctx, req.(*FindImagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TagService_RenameImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TagServiceServer).RenameImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TagService_RenameImage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TagServiceServer).RenameImage(ctx, req.(*RenameImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TagService_DeleteImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TagServiceServer).DeleteImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TagService_DeleteImage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TagServiceServer).DeleteImage(ctx, req.(*DeleteImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TagService_BatchAddTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TagServiceServer).BatchAddTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TagService_BatchAddTags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TagServiceServer).BatchAddTags(ctx, req.(*BatchTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TagService_BatchRemoveTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TagServiceServer).BatchRemoveTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TagService_BatchRemoveTags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TagServiceServer).BatchRemoveTags(ctx, req.(*BatchTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TagService_Clean_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CleanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TagServiceServer).Clean(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TagService_Clean_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TagServiceServer).Clean(ctx, req.(*CleanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TagService_ServiceDesc is the grpc.ServiceDesc for TagService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TagService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "fyslide.v1.TagService",
+	HandlerType: (*TagServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddTags",
+			Handler:    _TagService_AddTags_Handler,
+		},
+		{
+			MethodName: "RemoveTags",
+			Handler:    _TagService_RemoveTags_Handler,
+		},
+		{
+			MethodName: "GetTags",
+			Handler:    _TagService_GetTags_Handler,
+		},
+		{
+			MethodName: "ListTags",
+			Handler:    _TagService_ListTags_Handler,
+		},
+		{
+			MethodName: "FindImages",
+			Handler:    _TagService_FindImages_Handler,
+		},
+		{
+			MethodName: "RenameImage",
+			Handler:    _TagService_RenameImage_Handler,
+		},
+		{
+			MethodName: "DeleteImage",
+			Handler:    _TagService_DeleteImage_Handler,
+		},
+		{
+			MethodName: "BatchAddTags",
+			Handler:    _TagService_BatchAddTags_Handler,
+		},
+		{
+			MethodName: "BatchRemoveTags",
+			Handler:    _TagService_BatchRemoveTags_Handler,
+		},
+		{
+			MethodName: "Clean",
+			Handler:    _TagService_Clean_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "fyslide/v1/fyslide.proto",
+}
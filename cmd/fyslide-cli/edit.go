@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// editCmd edits the tags of one image interactively
+var editCmd = &cobra.Command{
+	Use:   "edit [image]",
+	Short: "Edit the tags of an image interactively",
+	Long: `Opens a prompt listing the tags of the image with numbers. At the prompt:
+
+  <n> [n...]   remove the tags with these numbers
+  <tag>        add a tag; end it with '*' to complete it against the existing tags
+  +<tag>       add a tag that looks like a number
+  ?<prefix>    list the existing tags starting with prefix
+  w            save the changes and quit
+  q            quit without saving (as does end of input)
+
+Nothing is written until 'w', so a session can be abandoned safely. Without an
+image argument the path is asked for first. Works over plain terminals such as SSH.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in := bufio.NewScanner(cmd.InOrStdin())
+		out := cmd.OutOrStdout()
+		filePath := ""
+		if len(args) == 1 {
+			filePath = args[0]
+		} else {
+			fmt.Fprint(out, "Image: ")
+			if !in.Scan() {
+				return fmt.Errorf("no image given")
+			}
+			filePath = strings.TrimSpace(in.Text())
+		}
+		absPath, err := filepath.Abs(filePath)
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for %s: %w", filePath, err)
+		}
+		current, err := tagDB.GetTags(cmd.Context(), absPath)
+		if err != nil {
+			return fmt.Errorf("error getting tags for %s: %w", absPath, err)
+		}
+		all, err := tagDB.GetAllTags(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("error getting all tags: %w", err)
+		}
+		known := make([]string, 0, len(all))
+		for _, tag := range all {
+			known = append(known, tag.Name)
+		}
+
+		edited, save := runTagEditor(in, out, absPath, current, known)
+		if !save {
+			cmd.Println("Aborted; no changes made.")
+			return nil
+		}
+		var firstError error
+		changes := 0
+		for _, tag := range current {
+			if slices.Contains(edited, tag) {
+				continue
+			}
+			if err := tagDB.RemoveTag(cmd.Context(), absPath, tag); err != nil {
+				cmd.PrintErrf("Error removing tag '%s' from %s: %v\n", tag, absPath, err)
+				if firstError == nil {
+					firstError = err
+				}
+				continue
+			}
+			cmd.Printf("Removed tag '%s' from %s\n", tag, absPath)
+			changes++
+		}
+		for _, tag := range edited {
+			if slices.Contains(current, tag) {
+				continue
+			}
+			if err := tagDB.AddTag(cmd.Context(), absPath, tag); err != nil {
+				cmd.PrintErrf("Error adding tag '%s' to %s: %v\n", tag, absPath, err)
+				if firstError == nil {
+					firstError = err
+				}
+				continue
+			}
+			cmd.Printf("Added tag '%s' to %s\n", tag, absPath)
+			changes++
+		}
+		if changes == 0 && firstError == nil {
+			cmd.Println("No changes.")
+		}
+		return firstError
+	},
+}
+
+// runTagEditor runs the prompt of the edit command on the tags of path,
+// completing new tags against known. It returns the edited tags and whether
+// the user asked to save them.
+func runTagEditor(in *bufio.Scanner, out io.Writer, path string, tags, known []string) ([]string, bool) {
+	tags = slices.Clone(tags)
+	printTags := func() {
+		fmt.Fprintf(out, "Tags of %s:\n", path)
+		if len(tags) == 0 {
+			fmt.Fprintln(out, "  (none)")
+		}
+		for i, tag := range tags {
+			fmt.Fprintf(out, "  %d. %s\n", i+1, tag)
+		}
+	}
+	printTags()
+	fmt.Fprintln(out, "Enter numbers to remove tags, a tag to add (end with * to complete, ?prefix to list), w to save, q to abort.")
+	for {
+		fmt.Fprint(out, "> ")
+		if !in.Scan() {
+			fmt.Fprintln(out)
+			return nil, false
+		}
+		line := strings.TrimSpace(in.Text())
+		switch {
+		case line == "":
+			continue
+		case line == "w":
+			return tags, true
+		case line == "q":
+			return nil, false
+		case strings.HasPrefix(line, "?"):
+			prefix := strings.ToLower(strings.TrimSpace(line[1:]))
+			matches := completions(known, prefix)
+			if len(matches) == 0 {
+				fmt.Fprintf(out, "No tags start with '%s'.\n", prefix)
+				continue
+			}
+			fmt.Fprintln(out, strings.Join(matches, "  "))
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(line, "+"); ok {
+			line = strings.TrimSpace(rest)
+		} else if numbers, ok := parseTagNumbers(line, len(tags)); ok {
+			var kept []string
+			for i, tag := range tags {
+				if !slices.Contains(numbers, i+1) {
+					kept = append(kept, tag)
+				}
+			}
+			tags = kept
+			printTags()
+			continue
+		}
+
+		tag := strings.ToLower(line) // Normalize tag to lowercase
+		if tag == "" {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(tag, "*"); ok {
+			matches := completions(known, prefix)
+			switch len(matches) {
+			case 0:
+				fmt.Fprintf(out, "No tags start with '%s'.\n", prefix)
+				continue
+			case 1:
+				tag = matches[0]
+			default:
+				fmt.Fprintln(out, strings.Join(matches, "  "))
+				continue
+			}
+		}
+		if strings.ContainsAny(tag, "*?") {
+			fmt.Fprintf(out, "Tags can't contain '*' or '?': %s\n", tag)
+			continue
+		}
+		if slices.Contains(tags, tag) {
+			fmt.Fprintf(out, "Already tagged '%s'.\n", tag)
+			continue
+		}
+		tags = append(tags, tag)
+		if !slices.Contains(known, tag) {
+			known = append(known, tag)
+		}
+		printTags()
+	}
+}
+
+// parseTagNumbers parses a line of 1-based tag numbers, all within 1..count.
+func parseTagNumbers(line string, count int) ([]int, bool) {
+	var numbers []int
+	for _, field := range strings.Fields(line) {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > count {
+			return nil, false
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, len(numbers) > 0
+}
+
+// completions returns the tags of known starting with prefix, sorted.
+func completions(known []string, prefix string) []string {
+	var matches []string
+	for _, tag := range known {
+		if strings.HasPrefix(tag, prefix) {
+			matches = append(matches, tag)
+		}
+	}
+	slices.Sort(matches)
+	return matches
+}
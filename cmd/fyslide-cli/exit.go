@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of fyslide-cli.
+const (
+	exitOK      = 0 // Success
+	exitFailure = 1 // The command ran but some or all of its work failed
+	exitUsage   = 2 // Bad arguments, flags or config; nothing was done
+	exitDB      = 3 // The tag database could not be opened
+
+	exitInterrupted = 130 // Stopped by Ctrl+C or SIGTERM; work done until then is kept
+)
+
+// usageError marks an error in how the command was invoked.
+type usageError struct{ err error }
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+// dbError marks a failure to open the tag database.
+type dbError struct{ err error }
+
+func (e dbError) Error() string { return e.err.Error() }
+func (e dbError) Unwrap() error { return e.err }
+
+// exitCode returns the exit code for the error a command returned.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var usage usageError
+	var db dbError
+	switch {
+	case errors.As(err, &db):
+		return exitDB
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	}
+	// Cobra's own errors for unknown commands and flags are plain strings.
+	msg := err.Error()
+	for _, prefix := range []string{"unknown command", "unknown flag", "unknown shorthand flag", "invalid argument"} {
+		if strings.HasPrefix(msg, prefix) {
+			return exitUsage
+		}
+	}
+	return exitFailure
+}
+
+// exitKinds names the exit codes in JSON error reports.
+var exitKinds = map[int]string{
+	exitFailure: "failure",
+	exitUsage:   "usage",
+	exitDB:      "database",
+
+	exitInterrupted: "interrupted",
+}
+
+// jsonError is the report --json-errors writes to stderr for a failed command.
+type jsonError struct {
+	Command string `json:"command"`
+	Error   string `json:"error"`
+	Kind    string `json:"kind"` // failure, usage, database or interrupted
+	Code    int    `json:"code"` // The exit code
+}
+
+// jsonItemError is the report --json-errors writes to stderr for each failed
+// image of a batch operation, one JSON object per line.
+type jsonItemError struct {
+	Command string `json:"command"`
+	Path    string `json:"path"`
+	Tag     string `json:"tag,omitempty"`
+	Error   string `json:"error"`
+}
+
+// writeJSONError writes err, returned by cmd, as a JSON line to w.
+func writeJSONError(w io.Writer, cmd *cobra.Command, err error) {
+	code := exitCode(err)
+	report := jsonError{Error: err.Error(), Kind: exitKinds[code], Code: code}
+	if cmd != nil {
+		report.Command = cmd.CommandPath()
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// printItemError reports the failure of a batch operation on one image and
+// tag, as a JSON line with --json-errors and as message otherwise.
+func printItemError(cmd *cobra.Command, path, tag string, err error, message string) {
+	if jsonErrorsFlag {
+		json.NewEncoder(cmd.ErrOrStderr()).Encode(jsonItemError{Command: cmd.CommandPath(), Path: path, Tag: tag, Error: err.Error()})
+		return
+	}
+	cmd.PrintErrln(message)
+}
+
+// markUsageErrors makes the argument validation errors of cmd and its
+// subcommands usage errors, as well as flag parsing errors.
+func markUsageErrors(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(c *cobra.Command, args []string) error {
+			if err := validate(c, args); err != nil {
+				return usageError{err}
+			}
+			return nil
+		}
+	}
+	cmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		return usageError{err}
+	})
+	for _, sub := range cmd.Commands() {
+		markUsageErrors(sub)
+	}
+}
+
+// wantsJSONErrors reports whether args ask for --json-errors. main checks
+// before parsing, so that errors in parsing itself are reported as JSON too.
+func wantsJSONErrors(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if arg == "--json-errors" || arg == "--json-errors=true" {
+			return true
+		}
+	}
+	return false
+}
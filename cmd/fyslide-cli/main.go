@@ -1,24 +1,149 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"fyslide/internal/autotag"
+	"fyslide/internal/availability"
+	"fyslide/internal/cardimport"
+	"fyslide/internal/config"
+	"fyslide/internal/dirtags"
+	"fyslide/internal/exporter"
+	"fyslide/internal/grpcapi"
+	"fyslide/internal/httpapi"
+	"fyslide/internal/imagesig"
+	"fyslide/internal/importer"
+	"fyslide/internal/metadata"
+	"fyslide/internal/ocr"
+	"fyslide/internal/paths"
+	"fyslide/internal/profile"
+	"fyslide/internal/query"
+	"fyslide/internal/scan"
+	"fyslide/internal/search"
+	"fyslide/internal/service"
 	"fyslide/internal/tagging"
+	"fyslide/internal/transform"
+	"image"
+	_ "image/gif" // Register decoders used by verify
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
 )
 
 var (
+	// configFlag names the config file read instead of the default one
+	configFlag string
+	// appConfig holds the settings of the config file and the environment, see config.Load
+	appConfig *config.Config
 	// dbPathFlag is used to store the value of the --dbpath flag
 	dbPathFlag string
+	// profileFlag selects the profile whose database is used when --dbpath is empty
+	profileFlag string
 	// tagDB is our global instance of the tag database
 	tagDB *tagging.TagDB
 	// Flags for batch operations
 	dryRunFlag bool
 	forceFlag  bool
+	// tagCorruptFlag makes verify tag undecodable files
+	tagCorruptFlag bool
+	// Flags for the import commands
+	conflictPolicyFlag string
+	matchRootFlag      string
+	// Flags for sync-metadata
+	syncIntervalFlag  time.Duration
+	syncPreferFlag    string
+	syncBatchSizeFlag int
+	syncStateFlag     string
+	// Flags for commands that leave out private images
+	privateTagFlag     string
+	includePrivateFlag bool
+	// Flags for history-log
+	historyTagFlag   string
+	historyPathFlag  string
+	historySinceFlag time.Duration
+	historyLimitFlag int
+	historyCSVFlag   bool
+
+	// Flags for usage-stats
+	usageDaysFlag int
+	usageCSVFlag  bool
+	usageJSONFlag bool
+	// mapPrefixFlag rewrites path prefixes of the database merged from
+	mapPrefixFlag []string
+	// diffJSONFlag prints db diff's report as JSON
+	diffJSONFlag bool
+	// Flags for apply-dir-defaults
+	recursiveFlag    bool
+	onlyUntaggedFlag bool
+	// Flags for serve-grpc
+	listenFlag string
+	// Flags for serve-http
+	httpListenFlag string
+	webFlag        bool
+	noAuthFlag     bool
+	readOnlyFlag   bool
+	// Flags for autotag
+	rulesFlag      string
+	namespacesFlag string
+	// analyzeTagFlag makes analyze tag the images it flags
+	analyzeTagFlag bool
+	// ocrEngineFlag and ocrLangFlag override the ocr settings of the config file
+	ocrEngineFlag string
+	ocrLangFlag   string
+	// allowProtectedFlag lets delete remove images carrying a protected tag
+	allowProtectedFlag bool
+	// Flags for delete
+	dbOnlyFlag      bool
+	filesOnlyFlag   bool
+	minCountFlag    int
+	confirmEachFlag bool
+	// trashOlderThanFlag limits trash empty to images deleted that many days ago
+	trashOlderThanFlag int
+	// Flags for transform
+	rotateFlag      int
+	deskewFlag      bool
+	cropBordersFlag bool
+	maxSkewFlag     float64
+	// Flags for import-card
+	cardTagsFlag   []string
+	cardDeleteFlag bool
+	// Flags for export-resized
+	exportTagsFlag    []string
+	exportMaxSizeFlag int
+	exportQualityFlag int
+	exportFormatFlag  string
+	stripMetadataFlag bool
+	autoLevelsFlag    bool
+	overwriteFlag     bool
+	workersFlag       int
+	// Flags for clean
+	libraryRootsFlag  []string
+	forceCleanFlag    bool
+	maxMissingPctFlag float64
+	// Flags for stats
+	statsJSONFlag      bool
+	statsTopFlag       int
+	statsSkipFilesFlag bool
+	// Flags for tags-for-dir
+	missingTagFlag string
+	// jsonErrorsFlag reports errors as JSON lines on stderr
+	jsonErrorsFlag bool
 )
 
 var supportedImageExtensions = map[string]bool{
@@ -35,22 +160,40 @@ var rootCmd = &cobra.Command{
 	Long: `fyslide-cli is a command-line tool to add, remove, list,
 and search tags associated with image files used by fyslide.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// Initialize the TagDB. If dbPathFlag is empty, NewTagDB uses its default.
+		// Settings precedence: flags, then the environment, then config.yaml
 		var err error
+		if appConfig, err = config.Load(configFlag); err != nil {
+			return usageError{err}
+		}
+		// Initialize the TagDB. If dbPathFlag is empty, NewTagDB uses its default.
 		// Define a logger function for the CLI context
 		cliLogger := func(message string) {
 			// log.Printf is suitable here for messages from the tagging package.
 			// It distinguishes these from direct command output via cmd.Printf.
 			log.Printf("TagDB: %s", message)
 		}
-		tagDB, err = tagging.NewTagDB(dbPathFlag, cliLogger)
+		if cmd.Annotations[skipDBAnnotation] != "" {
+			tagDB = nil
+			return nil
+		}
+		dbDir, err := databaseDir()
+		if err != nil {
+			return usageError{err}
+		}
+		if dbPathFlag == "" && profileFlag != "" {
+			if _, err := profile.Create(profileFlag); err != nil {
+				return dbError{err}
+			}
+		}
+		tagDB, err = tagging.NewTagDB(dbDir, cliLogger)
 		if err != nil {
-			return fmt.Errorf("failed to initialize tag database: %w", err)
+			return dbError{fmt.Errorf("failed to initialize tag database: %w", err)}
 		}
 		return nil
 	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
 		if tagDB != nil {
+			tagDB.CountUsage(context.Background(), map[string]int{tagging.UsageCommandPrefix + commandName(cmd): 1})
 			if err := tagDB.Close(); err != nil {
 				// Use log.Printf or cmd.PrintErrf as cobra might have already handled exit.
 				log.Printf("Error closing tag database: %v", err)
@@ -59,65 +202,335 @@ and search tags associated with image files used by fyslide.`,
 	},
 }
 
+// commandName returns the command line naming cmd without the program, e.g.
+// "trash list".
+func commandName(cmd *cobra.Command) string {
+	name := cmd.CommandPath()
+	if root := cmd.Root().Name() + " "; strings.HasPrefix(name, root) {
+		return name[len(root):]
+	}
+	return name
+}
+
+// skipDBAnnotation marks commands that run without opening the tag database.
+const skipDBAnnotation = "fyslide-skip-db"
+
+// databaseDir returns the tag database directory selected by --dbpath, then
+// --profile, then the config file and environment; "" means the default one,
+// which tagging.NewTagDB resolves and creates.
+func databaseDir() (string, error) {
+	switch {
+	case dbPathFlag != "":
+		return dbPathFlag, nil
+	case profileFlag != "":
+		return profile.Dir(profileFlag)
+	default:
+		return appConfig.DB.Path, nil
+	}
+}
+
+// pathsCmd prints where FySlide keeps its files
+var pathsCmd = &cobra.Command{
+	Use:   "paths",
+	Short: "Print the resolved locations of FySlide's files",
+	Long: `Prints where the config file, tag database, caches, log file and sync state
+are, after applying --config, --dbpath, --profile, the config file and the
+FYSLIDE_CONFIG_DIR, FYSLIDE_DB_PATH and XDG_* environment variables.
+Nothing is created.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{skipDBAnnotation: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configDir, err := paths.ConfigDir()
+		if err != nil {
+			return err
+		}
+		configFile, _, err := config.Path(configFlag)
+		if err != nil {
+			return err
+		}
+		profilesDir, err := profile.ProfilesDir()
+		if err != nil {
+			return err
+		}
+		dbDir, err := databaseDir()
+		if err == nil && dbDir == "" {
+			dbDir, err = paths.DBDir()
+		}
+		if err != nil {
+			return err
+		}
+		dataDir, err := paths.DataDir()
+		if err != nil {
+			return err
+		}
+		stateDir, err := paths.StateDir()
+		if err != nil {
+			return err
+		}
+		cacheDir, err := paths.CacheDir()
+		if err != nil {
+			return err
+		}
+		thumbDir, err := paths.ThumbnailDir()
+		if err != nil {
+			return err
+		}
+		logDir, err := paths.LogDir()
+		if err != nil {
+			return err
+		}
+		syncState, err := metadata.DefaultStatePath()
+		if err != nil {
+			return err
+		}
+		if dbPathFlag == "" && profileFlag != "" {
+			syncState = filepath.Join(dbDir, "metadata_sync.json") // As in sync-metadata
+		}
+		for _, row := range [][2]string{
+			{"Config directory", configDir},
+			{"Config file", configFile},
+			{"Profiles", profilesDir},
+			{"Tag database", filepath.Join(dbDir, tagging.DBFileName)},
+			{"Data directory", dataDir},
+			{"State directory", stateDir},
+			{"Cache directory", cacheDir},
+			{"Thumbnail cache", thumbDir},
+			{"Log file", filepath.Join(logDir, paths.LogFileName)},
+			{"Metadata sync state", syncState},
+		} {
+			cmd.Printf("%-20s %s\n", row[0]+":", row[1])
+		}
+		return nil
+	},
+}
+
 // addCmd represents the add command
 var addCmd = &cobra.Command{
-	Use:   "add <filepath> <tag1> [tag2...]",
-	Short: "Add one or more tags to a file",
-	Long:  "Adds the specified tags to the given image file. The filepath should be the path to the image.",
-	Args:  cobra.MinimumNArgs(2), // Requires filepath and at least one tag
+	Use:   "add <filepath|pattern>... [--] <tag1> [tag2...]",
+	Short: "Add one or more tags to files",
+	Long: `Adds the specified tags to the given image files. Paths may be glob patterns,
+quoted so the shell leaves them alone, where '**' matches any number of folders:
+
+  fyslide-cli add 'photos/2024/**/*.jpg' beach
+
+The first argument is always a path. The following arguments are paths too while they
+name existing files or are patterns; the rest are tags. Put '--' before the tags to
+make the split explicit. Each file is reported; the command fails if any file does.`,
+	Args: cobra.MinimumNArgs(2), // Requires filepath and at least one tag
 	RunE: func(cmd *cobra.Command, args []string) error {
-		filePath := args[0]
-		tagsToAdd := args[1:]
+		return tagPathArgs(cmd, args, false)
+	},
+}
 
-		absPath, err := filepath.Abs(filePath)
-		if err != nil {
-			return fmt.Errorf("error getting absolute path for %s: %w", filePath, err)
-		}
+// removeCmd represents the remove command
+var removeCmd = &cobra.Command{
+	Use:   "remove <filepath|pattern>... [--] <tag1> [tag2...]",
+	Short: "Remove one or more tags from files",
+	Long: `Removes the specified tags from the given image files. Paths and patterns are
+given as for add.`,
+	Args: cobra.MinimumNArgs(2), // Requires filepath and at least one tag
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tagPathArgs(cmd, args, true)
+	},
+}
 
-		var firstError error
-		for _, tagRaw := range tagsToAdd {
-			tag := strings.ToLower(tagRaw) // Normalize tag to lowercase
-			if err := tagDB.AddTag(absPath, tag); err != nil {
-				cmd.PrintErrf("Error adding tag '%s' to %s: %v\n", tag, absPath, err)
-				if firstError == nil {
-					firstError = err // Capture the first error to return
+// splitPathArgs splits the arguments of add and remove into paths and tags:
+// at dashAt if the user gave "--", else after the leading arguments that are
+// patterns or existing files, the first of which is always a path.
+func splitPathArgs(args []string, dashAt int) (paths, tags []string, err error) {
+	if dashAt >= 0 {
+		paths, tags = args[:dashAt], args[dashAt:]
+	} else {
+		n := 1
+		for n < len(args)-1 {
+			if !scan.HasGlobMeta(args[n]) {
+				if info, err := os.Stat(args[n]); err != nil || info.IsDir() {
+					break
 				}
-			} else {
-				cmd.Printf("Added tag '%s' to %s\n", tag, absPath)
 			}
+			n++
 		}
-		return firstError // Return the first error encountered, if any
-	},
+		paths, tags = args[:n], args[n:]
+	}
+	if len(paths) == 0 || len(tags) == 0 {
+		return nil, nil, usageError{fmt.Errorf("need at least one path and one tag")}
+	}
+	return paths, tags, nil
 }
 
-// removeCmd represents the remove command
-var removeCmd = &cobra.Command{
-	Use:   "remove <filepath> <tag1> [tag2...]",
-	Short: "Remove one or more tags from a file",
-	Long:  "Removes the specified tags from the given image file.",
-	Args:  cobra.MinimumNArgs(2), // Requires filepath and at least one tag
+// expandPathArgs makes paths absolute and expands the glob patterns among them,
+// dropping duplicates. Patterns that fail or match nothing are reported and
+// counted in failed.
+func expandPathArgs(cmd *cobra.Command, args []string) (paths []string, failed int) {
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		var matches []string
+		if scan.HasGlobMeta(arg) {
+			var err error
+			if matches, err = scan.Glob(arg); err != nil {
+				cmd.PrintErrf("Error: %v\n", err)
+				failed++
+				continue
+			}
+			if len(matches) == 0 {
+				cmd.PrintErrf("Error: no images match %s\n", arg)
+				failed++
+				continue
+			}
+		} else {
+			absPath, err := filepath.Abs(arg)
+			if err != nil {
+				cmd.PrintErrf("Error getting absolute path for %s: %v\n", arg, err)
+				failed++
+				continue
+			}
+			matches = []string{absPath}
+		}
+		for _, path := range matches {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, failed
+}
+
+// tagPathArgs adds or removes the tags in args to or from the paths in args,
+// reporting each file. A single image returns its first error; several return
+// a summary error if any file or pattern failed.
+func tagPathArgs(cmd *cobra.Command, args []string, remove bool) error {
+	pathArgs, tagArgs, err := splitPathArgs(args, cmd.ArgsLenAtDash())
+	if err != nil {
+		return err
+	}
+	var tags []string
+	for _, tagRaw := range tagArgs {
+		tags = append(tags, strings.ToLower(tagRaw)) // Normalize tag to lowercase
+	}
+	return tagPaths(cmd, pathArgs, tags, remove)
+}
+
+// tagPaths adds or removes tags to or from the images at pathArgs, paths or
+// patterns, reporting each file, as tagPathArgs does.
+func tagPaths(cmd *cobra.Command, pathArgs, tags []string, remove bool) error {
+	paths, failedArgs := expandPathArgs(cmd, pathArgs)
+
+	failedPaths := make(map[string]bool)
+	progress := func(path, tag string, err error) {
+		switch {
+		case err != nil && remove:
+			printItemError(cmd, path, tag, err, fmt.Sprintf("Error removing tag '%s' from %s: %v", tag, path, err))
+		case err != nil:
+			printItemError(cmd, path, tag, err, fmt.Sprintf("Error adding tag '%s' to %s: %v", tag, path, err))
+		case remove:
+			cmd.Printf("Removed tag '%s' from %s\n", tag, path)
+		default:
+			cmd.Printf("Added tag '%s' to %s\n", tag, path)
+		}
+		if err != nil {
+			failedPaths[path] = true
+		}
+	}
+	svc := service.New(tagDB)
+	var result service.BatchResult
+	if remove {
+		result = svc.RemoveTags(cmd.Context(), paths, tags, progress)
+	} else {
+		result = svc.AddTags(cmd.Context(), paths, tags, progress)
+	}
+
+	if len(pathArgs) == 1 && !scan.HasGlobMeta(pathArgs[0]) {
+		if err := batchError(result); err != nil {
+			return err // Return the first error encountered, if any
+		}
+		if failedArgs > 0 {
+			return fmt.Errorf("invalid path %s", pathArgs[0])
+		}
+		return nil
+	}
+	cmd.Printf("Processed %d image(s): %d succeeded, %d failed.\n", len(paths), len(paths)-len(failedPaths), len(failedPaths))
+	if len(failedPaths) > 0 || failedArgs > 0 {
+		return fmt.Errorf("%d of %d image(s) and %d of %d path argument(s) failed", len(failedPaths), len(paths), failedArgs, len(pathArgs))
+	}
+	return nil
+}
+
+// applySetCmd represents the apply-set command
+var applySetCmd = &cobra.Command{
+	Use:   "apply-set <set> <filepath|pattern>...",
+	Short: "Add the tags of a tag set to files",
+	Long: `Adds every tag of the named tag set to the given image files, e.g.
+
+  fyslide-cli apply-set beach-trip 'photos/2024/beach/*.jpg'
+
+Paths and patterns are given as for add. Tag sets are kept in the tag database and
+managed with "tag-set" or in the viewer's Edit > Tag Sets... dialog.`,
+	Args: cobra.MinimumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		filePath := args[0]
-		tagsToRemove := args[1:]
+		set, err := tagDB.GetTagSet(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		return tagPaths(cmd, args[1:], set.Tags, false)
+	},
+}
 
-		absPath, err := filepath.Abs(filePath)
+// tagSetCmd groups the commands managing tag sets
+var tagSetCmd = &cobra.Command{
+	Use:   "tag-set",
+	Short: "List, save and delete named sets of tags",
+	Long: `A tag set is a named group of tags applied together with apply-set or from the
+viewer, such as "beach-trip" for beach, family and 2024.`,
+}
+
+// tagSetListCmd represents the tag-set list command
+var tagSetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the tag sets with their tags",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sets, err := tagDB.TagSets(cmd.Context())
 		if err != nil {
-			return fmt.Errorf("error getting absolute path for %s: %w", filePath, err)
+			return fmt.Errorf("error reading tag sets: %w", err)
+		}
+		if len(sets) == 0 {
+			cmd.Println("No tag sets.")
+			return nil
+		}
+		for _, set := range sets {
+			cmd.Printf("%s: %s\n", set.Name, strings.Join(set.Tags, ", "))
+		}
+		return nil
+	},
+}
+
+// tagSetSaveCmd represents the tag-set save command
+var tagSetSaveCmd = &cobra.Command{
+	Use:   "save <name> <tag1> [tag2...]",
+	Short: "Save a tag set, replacing any set of the same name",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		set := tagging.TagSet{Name: args[0], Tags: args[1:], Created: time.Now()}
+		if err := tagDB.SaveTagSet(cmd.Context(), set); err != nil {
+			return err
 		}
+		cmd.Printf("Saved tag set '%s'\n", strings.TrimSpace(args[0]))
+		return nil
+	},
+}
 
-		var firstError error
-		for _, tagRaw := range tagsToRemove {
-			tag := strings.ToLower(tagRaw) // Normalize tag to lowercase
-			if err := tagDB.RemoveTag(absPath, tag); err != nil {
-				cmd.PrintErrf("Error removing tag '%s' from %s: %v\n", tag, absPath, err)
-				if firstError == nil {
-					firstError = err
-				}
-			} else {
-				cmd.Printf("Removed tag '%s' from %s\n", tag, absPath)
-			}
+// tagSetDeleteCmd represents the tag-set delete command
+var tagSetDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a tag set; the tags stay on the images",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := tagDB.DeleteTagSet(cmd.Context(), args[0]); err != nil {
+			return err
 		}
-		return firstError
+		cmd.Printf("Deleted tag set '%s'\n", args[0])
+		return nil
 	},
 }
 
@@ -134,7 +547,7 @@ var listCmd = &cobra.Command{
 			return fmt.Errorf("error getting absolute path for %s: %w", filePath, err)
 		}
 
-		tags, err := tagDB.GetTags(absPath)
+		tags, err := tagDB.GetTags(cmd.Context(), absPath)
 		if err != nil {
 			return fmt.Errorf("error listing tags for %s: %w", absPath, err)
 		}
@@ -157,7 +570,7 @@ var findByTagCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tagToFindRaw := args[0]
 		tagToFind := strings.ToLower(tagToFindRaw) // Normalize tag to lowercase
-		images, err := tagDB.GetImages(tagToFind)
+		images, err := tagDB.GetImages(cmd.Context(), tagToFind)
 		if err != nil {
 			return fmt.Errorf("error finding images for tag '%s': %w", tagToFind, err)
 		}
@@ -182,7 +595,7 @@ var listAllTagsCmd = &cobra.Command{
 	Long:  "Displays a list of all unique tags currently stored in the tag database.",
 	Args:  cobra.NoArgs, // Takes no arguments
 	RunE: func(cmd *cobra.Command, args []string) error {
-		tags, err := tagDB.GetAllTags()
+		tags, err := tagDB.GetAllTags(cmd.Context())
 		if err != nil {
 			return fmt.Errorf("error listing all tags: %w", err)
 		}
@@ -212,7 +625,7 @@ entirely in lowercase, it will be normalized. This involves:
 This ensures all tag references are consistently lowercase.`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		allTagsWithCounts, err := tagDB.GetAllTags() // Fetches []tagging.TagWithCount
+		allTagsWithCounts, err := tagDB.GetAllTags(cmd.Context()) // Fetches []tagging.TagWithCount
 		if err != nil {
 			return fmt.Errorf("error fetching all tags: %w", err)
 		}
@@ -248,7 +661,7 @@ This ensures all tag references are consistently lowercase.`,
 			cmd.Printf("Found mixed-case tag: '%s'. Normalizing to '%s'.\n", originalTag, lowerTag)
 			mixedCaseTagsFound++
 
-			imagePaths, errGetImages := tagDB.GetImages(originalTag)
+			imagePaths, errGetImages := tagDB.GetImages(cmd.Context(), originalTag)
 			if errGetImages != nil {
 				cmd.PrintErrf("  Error getting images for original tag '%s': %v. Skipping this tag.\n", originalTag, errGetImages)
 				if firstError == nil {
@@ -262,13 +675,13 @@ This ensures all tag references are consistently lowercase.`,
 					cmd.Printf("  DRY RUN: Would update tag for %s: '%s' -> '%s'\n", imgPath, originalTag, lowerTag)
 					imageTagUpdatesCount++
 				} else {
-					if err := tagDB.RemoveTag(imgPath, originalTag); err != nil {
+					if err := tagDB.RemoveTag(cmd.Context(), imgPath, originalTag); err != nil {
 						cmd.PrintErrf("  Error removing original tag '%s' from %s: %v\n", originalTag, imgPath, err)
 						if firstError == nil {
 							firstError = err
 						}
 					}
-					if err := tagDB.AddTag(imgPath, lowerTag); err != nil {
+					if err := tagDB.AddTag(cmd.Context(), imgPath, lowerTag); err != nil {
 						cmd.PrintErrf("  Error adding lowercase tag '%s' to %s: %v\n", lowerTag, imgPath, err)
 						if firstError == nil {
 							firstError = err
@@ -317,7 +730,7 @@ If, after normalization, oldTag and newTag are identical, no action is taken.`,
 			cmd.Println("DRY RUN: No changes will be made to the database.")
 		}
 
-		imagePaths, err := tagDB.GetImages(oldTag)
+		imagePaths, err := tagDB.GetImages(cmd.Context(), oldTag)
 		if err != nil {
 			return fmt.Errorf("error fetching images for old tag '%s': %w", oldTag, err)
 		}
@@ -336,14 +749,14 @@ If, after normalization, oldTag and newTag are identical, no action is taken.`,
 				cmd.Printf("  DRY RUN: Would replace tag on %s: remove '%s', add '%s'\n", imgPath, oldTag, newTag)
 				successfulReplacements++
 			} else {
-				if err := tagDB.RemoveTag(imgPath, oldTag); err != nil {
+				if err := tagDB.RemoveTag(cmd.Context(), imgPath, oldTag); err != nil {
 					cmd.PrintErrf("  Error removing old tag '%s' from %s: %v\n", oldTag, imgPath, err)
 					if firstError == nil {
 						firstError = err
 					}
 					continue // Skip adding new tag if old one couldn't be removed
 				}
-				if err := tagDB.AddTag(imgPath, newTag); err != nil {
+				if err := tagDB.AddTag(cmd.Context(), imgPath, newTag); err != nil {
 					cmd.PrintErrf("  Error adding new tag '%s' to %s: %v\n", newTag, imgPath, err)
 					if firstError == nil {
 						firstError = err
@@ -368,178 +781,625 @@ If, after normalization, oldTag and newTag are identical, no action is taken.`,
 
 // batchAddCmd represents the batch-add command
 var batchAddCmd = &cobra.Command{
-	Use:   "batch-add <directory> <tag1> [tag2...]",
+	Use:   "batch-add <directory|-> <tag1> [tag2...]",
 	Short: "Add one or more tags to all image files in a directory",
 	Long: `Adds the specified tags to all supported image files (jpg, jpeg, png, gif)
-found directly within the given directory. This command does not recurse into subdirectories.`,
+found directly within the given directory. This command does not recurse into subdirectories.
+Given '-' instead of a directory, it tags the newline-separated paths read from stdin,
+e.g. find photos -name '*.jpg' | fyslide-cli batch-add - beach`,
 	Args: cobra.MinimumNArgs(2), // Requires directory and at least one tag
 	RunE: func(cmd *cobra.Command, args []string) error {
-		dirPath := args[0]
-		absDirPath, err := filepath.Abs(dirPath)
+		paths, source, err := batchTargets(cmd, args[0])
 		if err != nil {
-			return fmt.Errorf("error getting absolute path for directory %s: %w", dirPath, err)
+			return err
 		}
 		tagsRaw := args[1:]
 		var tagsNormalized []string
 		for _, tRaw := range tagsRaw {
 			tagsNormalized = append(tagsNormalized, strings.ToLower(tRaw)) // Normalize tags
 		}
-		return processFilesInDirectory(cmd, absDirPath, tagsNormalized, tagDB.AddTag, "Added", "add", dryRunFlag, false /* no confirmation for add */, forceFlag)
+		return processImages(cmd, paths, source, tagsNormalized, false, dryRunFlag, false /* no confirmation for add */, forceFlag)
 	},
 }
 
 // batchRemoveCmd represents the batch-remove command
 var batchRemoveCmd = &cobra.Command{
-	Use:   "batch-remove <directory> <tag1> [tag2...]",
+	Use:   "batch-remove <directory|-> <tag1> [tag2...]",
 	Short: "Remove one or more tags from all image files in a directory",
 	Long: `Removes the specified tags from all supported image files (jpg, jpeg, png, gif)
-found directly within the given directory. This command does not recurse into subdirectories.`,
+found directly within the given directory. This command does not recurse into subdirectories.
+Given '-' instead of a directory, it works on the newline-separated paths read from stdin;
+as stdin then can't answer the confirmation, --force is required.`,
 	Args: cobra.MinimumNArgs(2), // Requires directory and at least one tag
 	RunE: func(cmd *cobra.Command, args []string) error {
-		dirPath := args[0]
 		tagsToRemoveRaw := args[1:]
 		var tagsToRemoveNormalized []string
 		for _, tRaw := range tagsToRemoveRaw {
 			tagsToRemoveNormalized = append(tagsToRemoveNormalized, strings.ToLower(tRaw)) // Normalize tags
 		}
 
-		absDirPath, err := filepath.Abs(dirPath)
+		paths, source, err := batchTargets(cmd, args[0])
 		if err != nil {
-			return fmt.Errorf("error getting absolute path for directory %s: %w", dirPath, err)
+			return err
 		}
 
-		return processFilesInDirectory(cmd, absDirPath, tagsToRemoveNormalized, tagDB.RemoveTag, "Removed", "remove", dryRunFlag, true /* needs confirmation */, forceFlag)
+		return processImages(cmd, paths, source, tagsToRemoveNormalized, true, dryRunFlag, true /* needs confirmation */, forceFlag)
 	},
 }
 
-// cleanCmd represents the database cleanup command
-var cleanCmd = &cobra.Command{
-	Use:   "clean",
-	Short: "Clean the tag database by removing stale entries",
-	Long: `Performs cleanup operations on the tag database:
-1. Removes tag entries for image files that no longer exist on the filesystem.
-2. Removes tags that are no longer associated with any images (orphaned tags).`,
-	Args: cobra.NoArgs,
+// deleteCmd deletes image files along with their tags, or only either
+var deleteCmd = &cobra.Command{
+	Use:   "delete <image|-> [image...]",
+	Short: "Delete image files and their tags",
+	Long: `Deletes the given image files from disk and removes their tags from the database.
+With --db-only it removes the tags and keeps the files; with --files-only it deletes
+the files for good, bypassing the trash, and keeps their tags, e.g. to move them to
+the images at a new place with rename-file. --dry-run previews each image with the
+tags it carries.
+Given '-', it deletes the newline-separated paths read from stdin, which requires
+--force as stdin then can't answer the confirmation. --confirm-each asks about every
+image in turn instead of once for all.
+Images carrying a protected tag, "keep" and "favorite" unless delete.protected_tags
+in the config file names others, are skipped unless --allow-protected is given,
+except with --db-only, which keeps the files anyway. --min-count N leaves out the
+images carrying fewer than N tags.`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cmd.Println("Starting database cleanup...")
-		if dryRunFlag {
-			cmd.Println("DRY RUN: No changes will be made to the database.")
+		if dbOnlyFlag && filesOnlyFlag {
+			return usageError{fmt.Errorf("--db-only and --files-only exclude each other")}
 		}
-
-		var firstError error
-		actualFilesCleaned := 0
-		actualTagsCleaned := 0
-		potentialFilesToClean := 0
-		potentialTagsToClean := 0
-
-		// Phase 1: Clean tags for non-existent image files
-		cmd.Println("\nPhase 1: Checking for non-existent image files and their tags...")
-		imagePathsFromDB, err := tagDB.GetAllImagePaths()
+		if confirmEachFlag && forceFlag {
+			return usageError{fmt.Errorf("--confirm-each and --force exclude each other")}
+		}
+		paths, fromStdin, err := imageArgs(cmd, args)
 		if err != nil {
-			cmd.PrintErrf("  Error reading image paths from DB: %v\n", err)
-			return fmt.Errorf("failed to get image paths for cleanup: %w", err)
+			return err
 		}
-
-		for _, imagePath := range imagePathsFromDB {
-			_, statErr := os.Stat(imagePath)
-			if os.IsNotExist(statErr) {
-				potentialFilesToClean++
-				if dryRunFlag {
-					cmd.Printf("  DRY RUN: Would remove all tags for non-existent file: %s\n", imagePath)
-				} else {
-					cmd.Printf("  Removing all tags for non-existent file: %s\n", imagePath)
-					if err := tagDB.RemoveAllTagsForImage(imagePath); err != nil {
-						cmd.PrintErrf("    Error removing tags for %s: %v\n", imagePath, err)
-						if firstError == nil {
-							firstError = err
-						}
-					} else {
-						cmd.Printf("    Successfully removed tags for %s.\n", imagePath)
-						actualFilesCleaned++
-					}
-				}
-			}
+		if confirmEachFlag && fromStdin {
+			return fmt.Errorf("paths read from stdin leave no way to confirm each image")
 		}
-
-		// Phase 2: Clean orphaned tags (tags with no images)
-		cmd.Println("\nPhase 2: Checking for orphaned tags...")
-		allTagsWithCounts, err := tagDB.GetAllTags() // This reads from TagsToImages
+		svc := newService()
+		previews, err := svc.PreviewDelete(cmd.Context(), paths)
 		if err != nil {
-			cmd.PrintErrf("  Error getting all tags for orphan check: %v\n", err)
-			if firstError == nil {
-				firstError = err
+			return err
+		}
+		protected, fewTags := 0, 0
+		previews = slices.DeleteFunc(previews, func(p service.DeletePreview) bool {
+			if len(p.Tags) < minCountFlag {
+				fewTags++
+				return true
 			}
-		} else {
-			for _, tagInfo := range allTagsWithCounts {
-				if tagInfo.Count == 0 { // Tag exists in TagsToImages but has an empty image list
-					potentialTagsToClean++
-					if dryRunFlag {
-						cmd.Printf("  DRY RUN: Would remove orphaned tag: %s\n", tagInfo.Name)
-					} else {
-						cmd.Printf("  Removing orphaned tag: %s\n", tagInfo.Name)
-						if errDel := tagDB.DeleteOrphanedTagKey(tagInfo.Name); errDel != nil {
-							cmd.PrintErrf("    Error removing orphaned tag '%s': %v\n", tagInfo.Name, errDel)
-							if firstError == nil {
-								firstError = errDel
-							}
-						} else {
-							cmd.Printf("    Successfully removed orphaned tag: %s\n", tagInfo.Name)
-							actualTagsCleaned++
-						}
-					}
-				}
+			if len(p.Protected) == 0 || dbOnlyFlag {
+				return false
+			}
+			protected++
+			if allowProtectedFlag {
+				return false
 			}
+			cmd.Printf("Skipping protected image %s (tagged %s); use --allow-protected to delete it\n", p.Path, strings.Join(p.Protected, ", "))
+			return true
+		})
+		if fewTags > 0 {
+			cmd.Printf("Skipping %d image(s) with fewer than %d tag(s).\n", fewTags, minCountFlag)
+		}
+		if len(previews) == 0 {
+			cmd.Println("No images to delete.")
+			return nil
+		}
+		what := "image file(s) and their tags"
+		switch {
+		case dbOnlyFlag:
+			what = "image(s) from the database, keeping the files"
+		case filesOnlyFlag:
+			what = "image file(s) for good, keeping their tags"
 		}
-
-		cmd.Printf("\nCleanup process complete.\n")
-		cmd.Printf("Summary:\n")
 		if dryRunFlag {
-			cmd.Printf("  Non-existent image file entries that would be processed: %d\n", potentialFilesToClean)
-			cmd.Printf("  Orphaned tags that would be removed: %d\n", potentialTagsToClean)
-		} else {
-			cmd.Printf("  Non-existent image file entries processed: %d\n", actualFilesCleaned)
-			cmd.Printf("  Orphaned tags removed: %d\n", actualTagsCleaned)
+			for _, p := range previews {
+				cmd.Printf("DRY RUN: Would delete %s (%s)\n", p.Path, describeTags(p.Tags))
+			}
+			cmd.Printf("DRY RUN: Finished simulation of delete. Would delete %d %s.\n", len(previews), what)
+			return nil
 		}
-		return firstError
+		paths = paths[:0]
+		for _, p := range previews {
+			paths = append(paths, p.Path)
+		}
+		switch {
+		case confirmEachFlag:
+			if paths = confirmEachImage(cmd, previews); len(paths) == 0 {
+				cmd.Println("No images to delete.")
+				return nil
+			}
+		case !forceFlag:
+			warning := fmt.Sprintf("WARNING: You are about to delete %d %s.", len(paths), what)
+			if protected > 0 {
+				warning += fmt.Sprintf("\n%d of them are protected by the tags %s.", protected, strings.Join(svc.ProtectedTags(), ", "))
+			}
+			ok, err := confirm(cmd, fromStdin, warning)
+			if !ok {
+				return err
+			}
+		}
+		progress := func(path string, err error) {
+			switch {
+			case err != nil:
+				printItemError(cmd, path, "", err, fmt.Sprintf("Error deleting %s: %v", path, err))
+			case dbOnlyFlag:
+				cmd.Printf("Removed the tags of %s\n", path)
+			case !filesOnlyFlag && svc.TrashDir() != "":
+				cmd.Printf("Moved %s to the trash\n", path)
+			default:
+				cmd.Printf("Deleted %s\n", path)
+			}
+		}
+		var result service.BatchResult
+		switch {
+		case dbOnlyFlag:
+			result = svc.ForgetImages(cmd.Context(), paths, progress)
+		case filesOnlyFlag:
+			result = svc.DeleteImageFiles(cmd.Context(), paths, allowProtectedFlag, progress)
+		default:
+			result = svc.DeleteImages(cmd.Context(), paths, allowProtectedFlag, progress)
+		}
+		cmd.Printf("Finished delete. Deleted %d of %d image(s).\n", result.Done, len(paths))
+		return batchError(result)
 	},
 }
 
-// addToTaggedCmd represents the command to add new tags to files already having a specific tag
-var addToTaggedCmd = &cobra.Command{
-	Use:   "add-to-tagged <initialTag> <tagToAdd1> [tagToAdd2...]",
-	Short: "Add new tags to all files that already have <initialTag>",
-	Long: `Finds all image files currently tagged with <initialTag>.
-Then, for each of these files, it adds <tagToAdd1>, <tagToAdd2>, etc.
-All tags are treated as case-insensitive (normalized to lowercase).`,
-	Args: cobra.MinimumNArgs(2), // Requires initialTag and at least one tagToAdd
-	RunE: func(cmd *cobra.Command, args []string) error {
-		initialTagRaw := args[0]
-		tagsToAddRaw := args[1:]
+// describeTags lists tags for a preview, or says there are none.
+func describeTags(tags []string) string {
+	if len(tags) == 0 {
+		return "no tags"
+	}
+	return "tags: " + strings.Join(tags, ", ")
+}
 
-		initialTag := strings.ToLower(initialTagRaw)
-		var tagsToAdd []string
-		for _, tRaw := range tagsToAddRaw {
-			tagsToAdd = append(tagsToAdd, strings.ToLower(tRaw))
+// confirmEachImage asks about each image of previews in turn and returns the
+// paths confirmed. "all" confirms the rest, "quit" or the end of input
+// declines it; any answer other than yes declines the image.
+func confirmEachImage(cmd *cobra.Command, previews []service.DeletePreview) []string {
+	var paths []string
+	for i, p := range previews {
+		cmd.Printf("Delete %s (%s)? (yes/no/all/quit): ", p.Path, describeTags(p.Tags))
+		var response string
+		if _, err := fmt.Fscanln(cmd.InOrStdin(), &response); err != nil && err.Error() != "unexpected newline" {
+			cmd.Println()
+			break
 		}
-
-		cmd.Printf("Identifying files with initial tag '%s' to add new tag(s): [%s]\n", initialTag, strings.Join(tagsToAdd, ", "))
-		if dryRunFlag {
-			cmd.Println("DRY RUN: No changes will be made to the database.")
+		switch strings.ToLower(strings.TrimSpace(response)) {
+		case "yes", "y":
+			paths = append(paths, p.Path)
+		case "all", "a":
+			for _, rest := range previews[i:] {
+				paths = append(paths, rest.Path)
+			}
+			return paths
+		case "quit", "q":
+			return paths
 		}
+	}
+	return paths
+}
+
+// batchError returns the error a batch command exits with: the interruption
+// that stopped it, else the first failure without the path it was reported with.
+func batchError(result service.BatchResult) error {
+	if result.Stopped != nil {
+		return result.Stopped
+	}
+	return errors.Unwrap(result.Err())
+}
+
+// newService returns a Service on tagDB protecting the images carrying the
+// protected tags of the config file from deletion.
+func newService() *service.Service {
+	svc := service.New(tagDB)
+	if appConfig != nil && appConfig.Delete.ProtectedTags != nil {
+		svc.SetProtectedTags(appConfig.Delete.ProtectedTags...)
+	}
+	svc.SetTrash(appConfig == nil || appConfig.Delete.TrashOn())
+	return svc
+}
+
+// trashCmd groups the commands working on deleted images kept in the trash
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List, restore and empty deleted images",
+	Long: `Unless delete.trash is false in the config file, delete moves images into the
+Trash folder next to the tag database, keeping their tags. These commands list
+them, put them back, and delete them for good. Running "trash empty --older-than N"
+from cron empties the trash on a schedule.`,
+}
 
-		imagePaths, err := tagDB.GetImages(initialTag)
+// trashListCmd represents the trash list command
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the images in the trash, the most recently deleted first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		items, err := newService().Trash(cmd.Context())
 		if err != nil {
-			return fmt.Errorf("error fetching images for initial tag '%s': %w", initialTag, err)
+			return err
 		}
-
-		if len(imagePaths) == 0 {
-			cmd.Printf("No images found with the initial tag '%s'. No new tags will be added.\n", initialTag)
+		if len(items) == 0 {
+			cmd.Println("The trash is empty.")
 			return nil
 		}
+		for _, item := range items {
+			cmd.Printf("%s\t%s\t%s", item.ID, item.Deleted.Format(time.DateTime), item.Path)
+			if len(item.Tags) > 0 {
+				cmd.Printf("\t%s", strings.Join(item.Tags, ", "))
+			}
+			cmd.Println()
+		}
+		return nil
+	},
+}
 
-		cmd.Printf("Found %d image(s) with tag '%s'. Proceeding to add new tags...\n", len(imagePaths), initialTag)
-		var firstError error
-		successfulAdditions := 0
+// trashRestoreCmd represents the trash restore command
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <id|path> [id|path...]",
+	Short: "Move images back out of the trash with their tags",
+	Long: `Moves the given images back to where they were deleted from and gives them
+their tags back. Each is named by its ID, as listed by "trash list", or by its
+original path, in which case the most recently deleted image of that path is restored.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := newService()
+		items, err := svc.Trash(cmd.Context())
+		if err != nil {
+			return err
+		}
+		var result service.BatchResult
+		for _, arg := range args {
+			id := trashID(items, arg)
+			if id == "" {
+				err := fmt.Errorf("%w: %s", tagging.ErrNotInTrash, arg)
+				printItemError(cmd, arg, "", err, fmt.Sprintf("Error restoring %s: %v", arg, err))
+				result.Errors = append(result.Errors, service.ImageError{Path: arg, Err: err})
+				continue
+			}
+			item, err := svc.RestoreImage(cmd.Context(), id)
+			if err != nil {
+				printItemError(cmd, item.Path, "", err, fmt.Sprintf("Error restoring %s: %v", arg, err))
+				result.Errors = append(result.Errors, service.ImageError{Path: arg, Err: err})
+				continue
+			}
+			cmd.Printf("Restored %s\n", item.Path)
+			result.Done++
+		}
+		cmd.Printf("Finished restore. Restored %d of %d image(s).\n", result.Done, len(args))
+		return batchError(result)
+	},
+}
+
+// trashID returns the ID of the trash item named by arg, its ID or original
+// path, or "" if there is none. items are the most recently deleted first.
+func trashID(items []tagging.TrashItem, arg string) string {
+	path, err := filepath.Abs(arg)
+	if err != nil {
+		path = arg
+	}
+	for _, item := range items {
+		if item.ID == arg || item.Path == path {
+			return item.ID
+		}
+	}
+	return ""
+}
+
+// trashEmptyCmd represents the trash empty command
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Delete the images in the trash for good",
+	Long: `Deletes the images in the trash for good, only those deleted more than
+--older-than days ago if it is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if trashOlderThanFlag < 0 {
+			return fmt.Errorf("--older-than must not be negative, got %d", trashOlderThanFlag)
+		}
+		if !forceFlag {
+			warning := "WARNING: You are about to delete every image in the trash for good."
+			if trashOlderThanFlag > 0 {
+				warning = fmt.Sprintf("WARNING: You are about to delete the images deleted over %d days ago for good.", trashOlderThanFlag)
+			}
+			ok, err := confirm(cmd, false, warning)
+			if !ok {
+				return err
+			}
+		}
+		olderThan := time.Duration(trashOlderThanFlag) * 24 * time.Hour
+		result := newService().EmptyTrash(cmd.Context(), olderThan, func(path string, err error) {
+			if err != nil {
+				printItemError(cmd, path, "", err, fmt.Sprintf("Error deleting %s: %v", path, err))
+			} else {
+				cmd.Printf("Deleted %s\n", path)
+			}
+		})
+		cmd.Printf("Finished emptying the trash. Deleted %d image(s).\n", result.Done)
+		return batchError(result)
+	},
+}
+
+// imageArgs returns the absolute paths of the image arguments, or the paths
+// read from stdin when the only argument is "-".
+func imageArgs(cmd *cobra.Command, args []string) (paths []string, fromStdin bool, err error) {
+	if len(args) == 1 && args[0] == stdinArg {
+		paths, err = readPathList(cmd.InOrStdin())
+		return paths, true, err
+	}
+	for _, arg := range args {
+		absPath, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, false, fmt.Errorf("error getting absolute path for %s: %w", arg, err)
+		}
+		paths = append(paths, absPath)
+	}
+	return paths, false, nil
+}
+
+// statsCmd prints an overview of the tag database
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Print an overview of the tag database",
+	Long: `Prints the number of tagged images and tags, the most used tags, how many tagged
+images each directory holds, the orphaned entries "clean" would remove, and the size
+of the database file. Counting missing image files stats every tagged file; skip it
+with --skip-files. Use --json for machine-readable output.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := service.New(tagDB)
+		svc.SetLibraryRoots(append(libraryRootsFlag, appConfig.Library.Roots...)...)
+		stats, err := svc.Stats(cmd.Context(), service.StatsOptions{TopTags: statsTopFlag, SkipFiles: statsSkipFilesFlag})
+		if err != nil {
+			return err
+		}
+		if statsJSONFlag {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(stats)
+		}
+
+		cmd.Printf("Database: %s (%d bytes)\n", stats.DBPath, stats.DBSize)
+		cmd.Printf("Tagged images: %d\n", stats.TaggedImages)
+		cmd.Printf("Tags: %d (%d taggings)\n", stats.Tags, stats.Taggings)
+		cmd.Printf("Orphaned tags: %d\n", stats.OrphanedTags)
+		if stats.FilesChecked {
+			cmd.Printf("Missing image files: %d\n", stats.MissingImages)
+			cmd.Printf("Unreachable image files: %d\n", stats.UnreachableImages)
+		}
+		if len(stats.TopTags) > 0 {
+			cmd.Printf("\nTop %d tags:\n", len(stats.TopTags))
+			for _, tc := range stats.TopTags {
+				cmd.Printf("%8d  %s\n", tc.Images, tc.Tag)
+			}
+		}
+		if len(stats.Directories) > 0 {
+			cmd.Println("\nImages per directory:")
+			for _, dc := range stats.Directories {
+				cmd.Printf("%8d  %s\n", dc.Images, dc.Dir)
+			}
+		}
+		return nil
+	},
+}
+
+// tagsForDirCmd lists the tags of the images under a folder
+var tagsForDirCmd = &cobra.Command{
+	Use:   "tags-for-dir <directory>",
+	Short: "List the tags of the images under a directory with counts",
+	Long: `Recursively scans the given directory and lists every tag applied to the images
+found, with the number of images carrying it, along with the images that have no tag
+at all. With --missing, the images lacking that tag are listed too, e.g. to check that
+a folder was fully tagged after importing an event.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		absDirPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for directory %s: %w", args[0], err)
+		}
+		if info, err := os.Stat(absDirPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a readable directory", absDirPath)
+		}
+		var paths []string
+		for item := range scanLibrary(absDirPath, func(message string) { log.Printf("Scan: %s", message) }) {
+			paths = append(paths, item.Path)
+		}
+		missingTag := strings.ToLower(missingTagFlag) // Normalize tag to lowercase
+		summary, err := service.New(tagDB).TagsOf(cmd.Context(), paths, missingTag)
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("%d image(s) under %s, %d untagged.\n", summary.Images, absDirPath, len(summary.Untagged))
+		if len(summary.Tags) > 0 {
+			cmd.Println("Tags:")
+			for _, tc := range summary.Tags {
+				cmd.Printf("%8d  %s\n", tc.Images, tc.Tag)
+			}
+		}
+		if len(summary.Untagged) > 0 {
+			cmd.Println("Untagged images:")
+			for _, path := range summary.Untagged {
+				cmd.Printf("  %s\n", path)
+			}
+		}
+		if missingTag != "" {
+			cmd.Printf("%d image(s) missing tag '%s':\n", len(summary.Missing), missingTag)
+			for _, path := range summary.Missing {
+				cmd.Printf("  %s\n", path)
+			}
+		}
+		return nil
+	},
+}
+
+// cleanCmd represents the database cleanup command
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Clean the tag database by removing stale entries",
+	Long: `Performs cleanup operations on the tag database:
+1. Removes tag entries for image files that no longer exist on the filesystem.
+2. Removes tags that are no longer associated with any images (orphaned tags).
+
+Files whose location can't be reached, such as a disconnected disk or an unmounted
+network share, are not treated as missing and keep their tags. Naming the library
+folders with --root makes every file under an unreachable one count as unreachable.
+
+If more than --max-missing-percent of the referenced files appear missing (and at
+least ten), the drive holding them is probably not mounted: clean then removes
+nothing, lists the files it would have removed and fails, unless --force-clean is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.Println("Starting database cleanup...")
+		if dryRunFlag {
+			cmd.Println("DRY RUN: No changes will be made to the database.")
+		}
+
+		var firstError error
+		actualFilesCleaned := 0
+		actualTagsCleaned := 0
+		potentialFilesToClean := 0
+		potentialTagsToClean := 0
+		unreachableFiles := 0
+
+		// Phase 1: Clean tags for non-existent image files
+		cmd.Println("\nPhase 1: Checking for non-existent image files and their tags...")
+		imagePathsFromDB, err := tagDB.GetAllImagePaths(cmd.Context())
+		if err != nil {
+			cmd.PrintErrf("  Error reading image paths from DB: %v\n", err)
+			return fmt.Errorf("failed to get image paths for cleanup: %w", err)
+		}
+
+		checker := availability.NewChecker(append(libraryRootsFlag, appConfig.Library.Roots...)...)
+		var missingFiles []string
+		for _, imagePath := range imagePathsFromDB {
+			switch checker.Check(imagePath) {
+			case availability.Unreachable:
+				unreachableFiles++
+				cmd.Printf("  Keeping tags of unreachable file (disk or share offline?): %s\n", imagePath)
+			case availability.Missing:
+				missingFiles = append(missingFiles, imagePath)
+			}
+		}
+		if service.TooManyMissing(len(missingFiles), len(imagePathsFromDB), maxMissingPctFlag) && !forceCleanFlag {
+			pct := 100 * float64(len(missingFiles)) / float64(len(imagePathsFromDB))
+			warning := fmt.Sprintf("%d of %d image files (%.0f%%) appear missing, more than the %.0f%% allowed by --max-missing-percent. Is a drive or share not mounted?",
+				len(missingFiles), len(imagePathsFromDB), pct, maxMissingPctFlag)
+			if !dryRunFlag {
+				cmd.PrintErrf("  WARNING: %s\n  Nothing was removed. Files that would lose their tags:\n", warning)
+				for _, imagePath := range missingFiles {
+					cmd.PrintErrf("    %s\n", imagePath)
+				}
+				return fmt.Errorf("cleanup aborted: %w; use --force-clean if the files were really deleted", service.ErrTooManyMissing)
+			}
+			cmd.Printf("  WARNING: %s\n  A real run would stop here unless --force-clean is given.\n", warning)
+		}
+		for _, imagePath := range missingFiles {
+			if err := cmd.Context().Err(); err != nil {
+				cmd.PrintErrf("  Interrupted; tags of %d file(s) removed, the rest kept.\n", actualFilesCleaned)
+				return err
+			}
+			potentialFilesToClean++
+			if dryRunFlag {
+				cmd.Printf("  DRY RUN: Would remove all tags for non-existent file: %s\n", imagePath)
+			} else {
+				cmd.Printf("  Removing all tags for non-existent file: %s\n", imagePath)
+				if err := tagDB.CleanImage(cmd.Context(), imagePath); err != nil {
+					cmd.PrintErrf("    Error removing tags for %s: %v\n", imagePath, err)
+					if firstError == nil {
+						firstError = err
+					}
+				} else {
+					cmd.Printf("    Successfully removed tags for %s.\n", imagePath)
+					actualFilesCleaned++
+				}
+			}
+		}
+
+		// Phase 2: Clean orphaned tags (tags with no images)
+		cmd.Println("\nPhase 2: Checking for orphaned tags...")
+		allTagsWithCounts, err := tagDB.GetAllTags(cmd.Context()) // This reads from TagsToImages
+		if err != nil {
+			cmd.PrintErrf("  Error getting all tags for orphan check: %v\n", err)
+			if firstError == nil {
+				firstError = err
+			}
+		} else {
+			for _, tagInfo := range allTagsWithCounts {
+				if tagInfo.Count == 0 { // Tag exists in TagsToImages but has an empty image list
+					potentialTagsToClean++
+					if dryRunFlag {
+						cmd.Printf("  DRY RUN: Would remove orphaned tag: %s\n", tagInfo.Name)
+					} else {
+						cmd.Printf("  Removing orphaned tag: %s\n", tagInfo.Name)
+						if errDel := tagDB.DeleteOrphanedTagKey(cmd.Context(), tagInfo.Name); errDel != nil {
+							cmd.PrintErrf("    Error removing orphaned tag '%s': %v\n", tagInfo.Name, errDel)
+							if firstError == nil {
+								firstError = errDel
+							}
+						} else {
+							cmd.Printf("    Successfully removed orphaned tag: %s\n", tagInfo.Name)
+							actualTagsCleaned++
+						}
+					}
+				}
+			}
+		}
+
+		cmd.Printf("\nCleanup process complete.\n")
+		cmd.Printf("Summary:\n")
+		if unreachableFiles > 0 {
+			cmd.Printf("  Unreachable image files kept: %d\n", unreachableFiles)
+		}
+		if dryRunFlag {
+			cmd.Printf("  Non-existent image file entries that would be processed: %d\n", potentialFilesToClean)
+			cmd.Printf("  Orphaned tags that would be removed: %d\n", potentialTagsToClean)
+		} else {
+			cmd.Printf("  Non-existent image file entries processed: %d\n", actualFilesCleaned)
+			cmd.Printf("  Orphaned tags removed: %d\n", actualTagsCleaned)
+		}
+		return firstError
+	},
+}
+
+// addToTaggedCmd represents the command to add new tags to files already having a specific tag
+var addToTaggedCmd = &cobra.Command{
+	Use:   "add-to-tagged <initialTag> <tagToAdd1> [tagToAdd2...]",
+	Short: "Add new tags to all files that already have <initialTag>",
+	Long: `Finds all image files currently tagged with <initialTag>.
+Then, for each of these files, it adds <tagToAdd1>, <tagToAdd2>, etc.
+All tags are treated as case-insensitive (normalized to lowercase).`,
+	Args: cobra.MinimumNArgs(2), // Requires initialTag and at least one tagToAdd
+	RunE: func(cmd *cobra.Command, args []string) error {
+		initialTagRaw := args[0]
+		tagsToAddRaw := args[1:]
+
+		initialTag := strings.ToLower(initialTagRaw)
+		var tagsToAdd []string
+		for _, tRaw := range tagsToAddRaw {
+			tagsToAdd = append(tagsToAdd, strings.ToLower(tRaw))
+		}
+
+		cmd.Printf("Identifying files with initial tag '%s' to add new tag(s): [%s]\n", initialTag, strings.Join(tagsToAdd, ", "))
+		if dryRunFlag {
+			cmd.Println("DRY RUN: No changes will be made to the database.")
+		}
+
+		imagePaths, err := tagDB.GetImages(cmd.Context(), initialTag)
+		if err != nil {
+			return fmt.Errorf("error fetching images for initial tag '%s': %w", initialTag, err)
+		}
+
+		if len(imagePaths) == 0 {
+			cmd.Printf("No images found with the initial tag '%s'. No new tags will be added.\n", initialTag)
+			return nil
+		}
+
+		cmd.Printf("Found %d image(s) with tag '%s'. Proceeding to add new tags...\n", len(imagePaths), initialTag)
+		var firstError error
+		successfulAdditions := 0
 
 		for _, imgPath := range imagePaths {
 			for _, tag := range tagsToAdd {
@@ -547,7 +1407,7 @@ All tags are treated as case-insensitive (normalized to lowercase).`,
 					cmd.Printf("  DRY RUN: Would add tag '%s' to %s (which has '%s')\n", tag, imgPath, initialTag)
 					successfulAdditions++ // Count as if it were applied for dry run summary
 				} else {
-					if err := tagDB.AddTag(imgPath, tag); err != nil {
+					if err := tagDB.AddTag(cmd.Context(), imgPath, tag); err != nil {
 						cmd.PrintErrf("  Error adding tag '%s' to %s: %v\n", tag, imgPath, err)
 						if firstError == nil {
 							firstError = err
@@ -564,27 +1424,1566 @@ All tags are treated as case-insensitive (normalized to lowercase).`,
 		if dryRunFlag {
 			summaryPrefix = "DRY RUN: Finished simulation of"
 		}
-		cmd.Printf("\n%s adding tags. Processed %d image files. Added %d new tag instances.\n", summaryPrefix, len(imagePaths), successfulAdditions)
-		if firstError != nil {
-			cmd.PrintErrf("Errors were encountered during the process. Please check the log. First error: %v\n", firstError)
+		cmd.Printf("\n%s adding tags. Processed %d image files. Added %d new tag instances.\n", summaryPrefix, len(imagePaths), successfulAdditions)
+		if firstError != nil {
+			cmd.PrintErrf("Errors were encountered during the process. Please check the log. First error: %v\n", firstError)
+		}
+		return firstError
+	},
+}
+
+// renameFileCmd represents the rename-file command
+var renameFileCmd = &cobra.Command{
+	Use:   "rename-file <old_path> <new_path>",
+	Short: "Rename an image file and keep its tags",
+	Long: `Renames (or moves) an image file on disk and moves its tags to the new path
+in the database. The command refuses to overwrite an existing file, and the new name
+must keep a supported image extension (jpg, jpeg, png, gif).`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		oldPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for %s: %w", args[0], err)
+		}
+		newPath, err := filepath.Abs(args[1])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for %s: %w", args[1], err)
+		}
+		if err := service.New(tagDB).RenameImage(cmd.Context(), oldPath, newPath); err != nil {
+			return err
+		}
+		cmd.Printf("Renamed %s to %s\n", oldPath, newPath)
+		return nil
+	},
+}
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify <directory>",
+	Short: "Find image files that cannot be decoded",
+	Long: `Recursively scans the given directory and fully decodes every supported image
+file (jpg, jpeg, png, gif), reporting those that are unreadable or corrupt.
+With --tag-corrupt, each failing file is tagged '` + tagging.CorruptTag + `' for later cleanup.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		absDirPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for directory %s: %w", args[0], err)
+		}
+		if info, err := os.Stat(absDirPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a readable directory", absDirPath)
+		}
+
+		scanLogger := func(message string) {
+			log.Printf("Scan: %s", message)
+		}
+
+		var firstError error
+		checked, corrupt := 0, 0
+		for item := range scanLibrary(absDirPath, scanLogger) {
+			checked++
+			decodeErr := verifyImageFile(item.Path)
+			if decodeErr == nil {
+				continue
+			}
+			corrupt++
+			cmd.Printf("CORRUPT: %s: %v\n", item.Path, decodeErr)
+			if !tagCorruptFlag {
+				continue
+			}
+			if dryRunFlag {
+				cmd.Printf("DRY RUN: Would add tag '%s' to %s\n", tagging.CorruptTag, item.Path)
+				continue
+			}
+			if err := tagDB.AddTag(cmd.Context(), item.Path, tagging.CorruptTag); err != nil {
+				cmd.PrintErrf("Error tagging %s as '%s': %v\n", item.Path, tagging.CorruptTag, err)
+				if firstError == nil {
+					firstError = err
+				}
+			}
+		}
+
+		cmd.Printf("Finished verify. Checked %d image files, %d could not be decoded.\n", checked, corrupt)
+		return firstError
+	},
+}
+
+// verifyImageFile fully decodes the image at path, returning any error encountered.
+// A full decode is used rather than DecodeConfig so truncated files are caught too.
+func verifyImageFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, _, err = image.Decode(file)
+	return err
+}
+
+// importCmd groups the importers for other photo managers' tags
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import tags from other photo managers",
+	Long: `Imports tags (keywords) kept by other photo managers into the tag database.
+Images are matched by their recorded path, or, when that no longer exists and
+--match-root is given, by file name and size among the images under that directory.
+--on-conflict decides what happens to images that already have tags:
+  merge    add the imported tags to the existing ones (default)
+  skip     leave such images untouched
+  replace  make the imported tags the image's only tags`,
+}
+
+// importDigiKamCmd represents the import digikam command
+var importDigiKamCmd = &cobra.Command{
+	Use:   "digikam <path/to/digikam4.db>",
+	Short: "Import tags from a digiKam database",
+	Long: `Reads the tags of every image from a digiKam database (digikam4.db) and merges
+them into the tag database. digiKam's internal tags (labels and the like) are ignored.
+The sqlite3 command-line shell must be installed; the database is opened read-only.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		records, err := importer.ReadDigiKam(args[0])
+		if err != nil {
+			return err
+		}
+		return runImport(cmd, records)
+	},
+}
+
+// importXMPCmd represents the import xmp command
+var importXMPCmd = &cobra.Command{
+	Use:     "xmp <directory>",
+	Aliases: []string{"lightroom"},
+	Short:   "Import keywords from XMP sidecar files (Lightroom, digiKam, darktable)",
+	Long: `Recursively finds .xmp sidecar files under the directory and merges their
+keywords into the tag database. Both "photo.xmp" (Lightroom) and "photo.jpg.xmp"
+(digiKam, darktable) naming schemes are recognized. Flat dc:subject keywords are used;
+hierarchical keywords contribute their last level only when no flat ones exist.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		absDirPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for directory %s: %w", args[0], err)
+		}
+		if info, err := os.Stat(absDirPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a readable directory", absDirPath)
+		}
+		records, err := importer.ReadXMPSidecars(absDirPath, func(message string) {
+			log.Printf("Import: %s", message)
+		})
+		if err != nil {
+			return err
+		}
+		return runImport(cmd, records)
+	},
+}
+
+// importTagSpacesCmd represents the import-tagspaces command
+var importTagSpacesCmd = &cobra.Command{
+	Use:   "import-tagspaces <directory>",
+	Short: "Import tags from TagSpaces sidecars and file names",
+	Long: `Recursively scans the directory for images and imports the tags TagSpaces keeps
+for them: sidecars in ".ts/<file>.json" next to the files, and tags embedded in file
+names such as "beach[summer family].jpg". Accepts the same --on-conflict, --match-root
+and --dry-run flags as the import commands.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		absDirPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for directory %s: %w", args[0], err)
+		}
+		if info, err := os.Stat(absDirPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a readable directory", absDirPath)
+		}
+		records := importer.ReadTagSpaces(absDirPath, func(message string) {
+			log.Printf("Import: %s", message)
+		})
+		return runImport(cmd, records)
+	},
+}
+
+// exportTagSpacesCmd represents the export-tagspaces command
+var exportTagSpacesCmd = &cobra.Command{
+	Use:   "export-tagspaces <directory>",
+	Short: "Write TagSpaces sidecars for tagged images",
+	Long: `Recursively scans the directory and, for every image with tags in the database,
+writes a TagSpaces sidecar (".ts/<file>.json" next to the file) so the folder can be
+browsed with its tags in TagSpaces. Existing sidecars keep their other fields; their tag
+list is replaced by the database's. Images carrying the private tag are left out unless
+--include-private is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		absDirPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for directory %s: %w", args[0], err)
+		}
+		if info, err := os.Stat(absDirPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a readable directory", absDirPath)
+		}
+
+		var firstError error
+		written, skippedPrivate := 0, 0
+		for item := range scanLibrary(absDirPath, func(message string) { log.Printf("Scan: %s", message) }) {
+			if importer.InTagSpacesDir(item.Path) {
+				continue
+			}
+			tags, err := tagDB.GetTags(cmd.Context(), item.Path)
+			if err != nil {
+				cmd.PrintErrf("Error getting tags for %s: %v\n", item.Path, err)
+				if firstError == nil {
+					firstError = err
+				}
+				continue
+			}
+			if len(tags) == 0 {
+				continue
+			}
+			if !includePrivateFlag && privateTagFlag != "" && slices.Contains(tags, privateTagFlag) {
+				skippedPrivate++
+				continue
+			}
+			if dryRunFlag {
+				cmd.Printf("DRY RUN: Would write %s with tags: %s\n", importer.TagSpacesSidecarPath(item.Path), strings.Join(tags, ", "))
+				written++
+				continue
+			}
+			if err := importer.WriteTagSpacesSidecar(item.Path, tags); err != nil {
+				cmd.PrintErrf("Error writing sidecar for %s: %v\n", item.Path, err)
+				if firstError == nil {
+					firstError = err
+				}
+				continue
+			}
+			written++
+		}
+
+		summaryPrefix := "Finished"
+		if dryRunFlag {
+			summaryPrefix = "DRY RUN: Finished simulation of"
+		}
+		cmd.Printf("%s export. Wrote %d TagSpaces sidecar(s).\n", summaryPrefix, written)
+		if skippedPrivate > 0 {
+			cmd.Printf("Skipped %d private image(s); use --include-private to export them.\n", skippedPrivate)
+		}
+		return firstError
+	},
+}
+
+// importCardCmd represents the import-card command
+var importCardCmd = &cobra.Command{
+	Use:   "import-card <card-directory> <library-directory>",
+	Short: "Copy images from a camera card into date folders of the library",
+	Long: `Copies every image on the card (a mounted SD card or its DCIM folder) into the
+library as <library>/YYYY/MM/DD/<name>, by the date the image was taken according to its
+EXIF data, else its modification time. Images whose content was imported before, or is
+already in its date folder, are skipped; a different file of the same name gets a numeric
+suffix. Each copy is verified against its original's SHA-256 hash. Copied images get the
+--tag tags. With --delete the originals are removed from the card once their copy is
+verified or found in the library. --dry-run lists where each image would go.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		card, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for directory %s: %w", args[0], err)
+		}
+		if info, err := os.Stat(card); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a readable directory", card)
+		}
+		var tags []string
+		for _, tag := range cardTagsFlag {
+			if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		sources, err := cardimport.Find(card)
+		if err != nil {
+			return fmt.Errorf("error reading card %s: %w", card, err)
+		}
+		opts := cardimport.Options{Library: args[1], DeleteFromCard: cardDeleteFlag, DryRun: dryRunFlag}
+		var firstError error
+		copied, skipped, deleted := 0, 0, 0
+		_, err = cardimport.Run(cmd.Context(), sources, opts, tagDB, func(_, _ int, r cardimport.Result) {
+			switch {
+			case r.Err != nil:
+				cmd.PrintErrf("Error importing %s: %v\n", r.Source, r.Err)
+				if firstError == nil {
+					firstError = r.Err
+				}
+				return
+			case r.Skipped:
+				skipped++
+				cmd.Printf("Skipped %s, already imported as %s\n", r.Source, r.Target)
+			case dryRunFlag:
+				copied++
+				cmd.Printf("DRY RUN: Would copy %s to %s\n", r.Source, r.Target)
+			default:
+				copied++
+				cmd.Printf("Copied %s to %s\n", r.Source, r.Target)
+				for _, tag := range tags {
+					if err := tagDB.AddTag(cmd.Context(), r.Target, tag); err != nil {
+						cmd.PrintErrf("Error adding tag '%s' to %s: %v\n", tag, r.Target, err)
+						if firstError == nil {
+							firstError = err
+						}
+					}
+				}
+			}
+			if r.Deleted {
+				deleted++
+			}
+		})
+		if err != nil {
+			return err
+		}
+		summaryPrefix := "Finished"
+		if dryRunFlag {
+			summaryPrefix = "DRY RUN: Finished simulation of"
+		}
+		cmd.Printf("%s card import. Found %d image(s): %d copied, %d already imported, %d deleted from the card.\n",
+			summaryPrefix, len(sources), copied, skipped, deleted)
+		return firstError
+	},
+}
+
+// exportResizedCmd represents the export-resized command
+var exportResizedCmd = &cobra.Command{
+	Use:   "export-resized <directory> <target-directory>",
+	Short: "Write resized and converted copies of images to a folder",
+	Long: `Recursively scans the directory and writes a copy of every image, or of those
+carrying all --tag tags, into the target directory: scaled down to at most --max-size
+pixels on the longest edge and converted to --format (keep, jpeg, png or gif). Copies
+are named after their originals; same-named images from different folders get a numeric
+suffix. JPEG copies of JPEGs keep their EXIF data unless --strip-metadata is given.
+--auto-levels stretches each copy's contrast from its histogram, like the viewer's
+auto-enhance preview, which helps badly exposed scans.
+Existing copies are skipped unless --overwrite is given. Images carrying the private
+tag are left out unless --include-private is given.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		absDirPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for directory %s: %w", args[0], err)
+		}
+		if info, err := os.Stat(absDirPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a readable directory", absDirPath)
+		}
+		opts := exportOptions(args[1])
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		var paths []string
+		for item := range scanLibrary(absDirPath, func(message string) { log.Printf("Scan: %s", message) }) {
+			paths = append(paths, item.Path)
+		}
+		return runExport(cmd, paths, opts)
+	},
+}
+
+// exportFilesCmd represents the export-files command
+var exportFilesCmd = &cobra.Command{
+	Use:   "export-files <target-directory> <image|-> [image...]",
+	Short: "Write resized and converted copies of the given images to a folder",
+	Long: `Like export-resized, but exports the given images instead of scanning a folder.
+Given '-', it exports the newline-separated paths read from stdin, e.g.
+fzf -m | fyslide-cli export-files /tmp/share -
+The flags of export-resized apply, including --tag and the private tag filter.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := exportOptions(args[0])
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		paths, _, err := imageArgs(cmd, args[1:])
+		if err != nil {
+			return err
+		}
+		return runExport(cmd, paths, opts)
+	},
+}
+
+// exportOptions returns the exporter options set by the export flags.
+func exportOptions(outDir string) exporter.Options {
+	return exporter.Options{
+		OutDir:        outDir,
+		MaxDimension:  exportMaxSizeFlag,
+		Quality:       exportQualityFlag,
+		Format:        exportFormatFlag,
+		StripMetadata: stripMetadataFlag,
+		AutoLevels:    autoLevelsFlag,
+		Overwrite:     overwriteFlag,
+		Workers:       workersFlag,
+	}
+}
+
+// runExport exports those of paths passing the --tag and private filters.
+func runExport(cmd *cobra.Command, paths []string, opts exporter.Options) error {
+	var firstError error
+	var sources []string
+	skippedPrivate := 0
+	for _, path := range paths {
+		if len(exportTagsFlag) == 0 && (includePrivateFlag || privateTagFlag == "") {
+			sources = append(sources, path)
+			continue
+		}
+		tags, err := tagDB.GetTags(cmd.Context(), path)
+		if err != nil {
+			cmd.PrintErrf("Error getting tags for %s: %v\n", path, err)
+			if firstError == nil {
+				firstError = err
+			}
+			continue
+		}
+		if !includePrivateFlag && privateTagFlag != "" && slices.Contains(tags, privateTagFlag) {
+			skippedPrivate++
+			continue
+		}
+		if !containsAll(tags, exportTagsFlag) {
+			continue
+		}
+		sources = append(sources, path)
+	}
+	slices.Sort(sources)
+
+	if dryRunFlag {
+		for _, job := range exporter.Plan(sources, opts) {
+			cmd.Printf("DRY RUN: Would write %s as %s\n", job.Source, job.Target)
+		}
+		cmd.Printf("DRY RUN: Finished simulation of export. Would write %d copy(ies).\n", len(sources))
+		return firstError
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	written, skipped, failed := 0, 0, 0
+	_, err := exporter.Run(ctx, sources, opts, func(done, total int, r exporter.Result) {
+		switch {
+		case r.Err != nil:
+			failed++
+			cmd.PrintErrf("[%d/%d] Error exporting %s: %v\n", done, total, r.Source, r.Err)
+			if firstError == nil {
+				firstError = r.Err
+			}
+		case r.Skipped:
+			skipped++
+			cmd.Printf("[%d/%d] Skipped %s: %s exists\n", done, total, r.Source, r.Target)
+		default:
+			written++
+			cmd.Printf("[%d/%d] Wrote %s\n", done, total, r.Target)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("export stopped after %d of %d image(s): %w", written+skipped+failed, len(sources), err)
+	}
+	cmd.Printf("Finished export. Wrote %d copy(ies), skipped %d existing, %d failed.\n", written, skipped, failed)
+	if skippedPrivate > 0 {
+		cmd.Printf("Skipped %d private image(s); use --include-private to export them.\n", skippedPrivate)
+	}
+	return firstError
+}
+
+// scanLibrary is scan.Run leaving out the images excluded by the config.
+func scanLibrary(dir string, logger scan.LoggerFunc) <-chan scan.FileItem {
+	items := scan.Run(dir, logger)
+	if appConfig == nil || len(appConfig.Library.Exclude) == 0 {
+		return items
+	}
+	out := make(chan scan.FileItem, cap(items))
+	go func() {
+		defer close(out)
+		for item := range items {
+			if !appConfig.Excluded(item.Path) {
+				out <- item
+			}
+		}
+	}()
+	return out
+}
+
+// containsAll reports whether tags includes every one of wanted.
+func containsAll(tags, wanted []string) bool {
+	for _, w := range wanted {
+		if !slices.Contains(tags, strings.ToLower(w)) {
+			return false
+		}
+	}
+	return true
+}
+
+// syncMetadataCmd represents the sync-metadata command
+var syncMetadataCmd = &cobra.Command{
+	Use:   "sync-metadata <directory>",
+	Short: "Keep embedded image keywords and the tag database in sync",
+	Long: `Synchronizes the keywords embedded in JPEG and PNG files (XMP dc:subject) under
+the directory with the tag database, in both directions. Tags changed in the database
+are written to the files, at most --batch-size files per pass; keywords edited by other
+programs (detected by a changed modification time) are imported into the database.
+When an image changed on both sides since the last pass, --prefer decides:
+  merge  keep the tags from both sides (default)
+  db     the database wins
+  file   the file's keywords win
+The first pass over an image merges both sides. Without --interval a single pass is
+made; with it the command keeps running, syncing at that interval until interrupted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		absDirPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for directory %s: %w", args[0], err)
+		}
+		if info, err := os.Stat(absDirPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a readable directory", absDirPath)
+		}
+		prefer, err := metadata.ParsePrefer(syncPreferFlag)
+		if err != nil {
+			return err
+		}
+		statePath := syncStateFlag
+		if statePath == "" && dbPathFlag == "" && profileFlag != "" {
+			// The state describes one database, so each profile keeps its own.
+			profileDir, err := profile.Dir(profileFlag)
+			if err != nil {
+				return err
+			}
+			statePath = filepath.Join(profileDir, "metadata_sync.json")
+		} else if statePath == "" {
+			if statePath, err = metadata.DefaultStatePath(); err != nil {
+				return err
+			}
+		}
+		syncer, err := metadata.NewSyncer(tagDB, statePath, metadata.SyncOptions{
+			Prefer:    prefer,
+			BatchSize: syncBatchSizeFlag,
+			DryRun:    dryRunFlag,
+			Logger:    func(message string) { cmd.Println(message) },
+		})
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		for {
+			stats, err := syncer.SyncDir(ctx, absDirPath)
+			if err != nil && ctx.Err() == nil {
+				return err
+			}
+			summaryPrefix := "Finished"
+			if dryRunFlag {
+				summaryPrefix = "DRY RUN: Finished simulation of"
+			}
+			cmd.Printf("%s sync pass. Checked %d image(s): %d file(s) written, %d database update(s), %d conflict(s), %d deferred, %d unsupported, %d error(s).\n",
+				summaryPrefix, stats.Checked, stats.ToFile, stats.ToDB, stats.Conflicts, stats.Deferred, stats.Unsupported, stats.Errors)
+			if ctx.Err() != nil {
+				cmd.Println("Stopping metadata sync.")
+				return nil
+			}
+			if syncIntervalFlag <= 0 {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				cmd.Println("Stopping metadata sync.")
+				return nil
+			case <-time.After(syncIntervalFlag):
+			}
+		}
+	},
+}
+
+// runImport matches imported records to images and applies their tags
+// according to --on-conflict, honoring --dry-run.
+func runImport(cmd *cobra.Command, records []importer.Record) error {
+	policy, err := importer.ParsePolicy(conflictPolicyFlag)
+	if err != nil {
+		return err
+	}
+	var candidates scan.FileItems
+	if matchRootFlag != "" {
+		absRoot, err := filepath.Abs(matchRootFlag)
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for %s: %w", matchRootFlag, err)
+		}
+		for item := range scanLibrary(absRoot, func(message string) { log.Printf("Scan: %s", message) }) {
+			candidates = append(candidates, item)
+		}
+	}
+	matcher := importer.NewMatcher(candidates)
+
+	var firstError error
+	unmatched, skipped, added, removed := 0, 0, 0, 0
+	for _, rec := range records {
+		imgPath, err := matcher.Match(rec)
+		if err != nil {
+			unmatched++
+			cmd.Printf("UNMATCHED: %v\n", err)
+			continue
+		}
+		existing, err := tagDB.GetTags(cmd.Context(), imgPath)
+		if err != nil {
+			cmd.PrintErrf("Error getting tags for %s: %v\n", imgPath, err)
+			if firstError == nil {
+				firstError = err
+			}
+			continue
+		}
+		toAdd, toRemove := importer.Plan(existing, rec.Tags, policy)
+		if len(toAdd) == 0 && len(toRemove) == 0 {
+			skipped++
+			continue
+		}
+		for _, tag := range toRemove {
+			if dryRunFlag {
+				cmd.Printf("DRY RUN: Would remove tag '%s' from %s\n", tag, imgPath)
+			} else if err := tagDB.RemoveTag(cmd.Context(), imgPath, tag); err != nil {
+				cmd.PrintErrf("Error removing tag '%s' from %s: %v\n", tag, imgPath, err)
+				if firstError == nil {
+					firstError = err
+				}
+				continue
+			}
+			removed++
+		}
+		for _, tag := range toAdd {
+			if dryRunFlag {
+				cmd.Printf("DRY RUN: Would add tag '%s' to %s\n", tag, imgPath)
+			} else if err := tagDB.AddTag(cmd.Context(), imgPath, tag); err != nil {
+				cmd.PrintErrf("Error adding tag '%s' to %s: %v\n", tag, imgPath, err)
+				if firstError == nil {
+					firstError = err
+				}
+				continue
+			}
+			added++
+		}
+	}
+
+	summaryPrefix := "Finished"
+	if dryRunFlag {
+		summaryPrefix = "DRY RUN: Finished simulation of"
+	}
+	cmd.Printf("%s import. %d image(s) in source, %d unmatched, %d unchanged. Added %d tag(s), removed %d tag(s).\n",
+		summaryPrefix, len(records), unmatched, skipped, added, removed)
+	return firstError
+}
+
+// historyLogCmd represents the history-log command
+var historyLogCmd = &cobra.Command{
+	Use:   "history-log",
+	Short: "Show the audit log of tag database changes",
+	Long: `Lists the changes recorded in the tag database's audit log: tags added and
+removed, images renamed or deleted, and entries removed by clean. Each entry shows
+when the change was made, by which user, and what it affected. Filter with --tag,
+--path (any part of the path) and --since; --csv writes the entries as CSV.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter := tagging.AuditFilter{
+			Tag:   strings.ToLower(strings.TrimSpace(historyTagFlag)),
+			Path:  historyPathFlag,
+			Limit: historyLimitFlag,
+		}
+		if historySinceFlag > 0 {
+			filter.Since = time.Now().Add(-historySinceFlag)
+		}
+		events, err := tagDB.AuditLog(cmd.Context(), filter)
+		if err != nil {
+			return fmt.Errorf("error reading the audit log: %w", err)
+		}
+		if historyCSVFlag {
+			return tagging.WriteAuditCSV(cmd.OutOrStdout(), events)
+		}
+		if len(events) == 0 {
+			cmd.Println("No matching changes recorded.")
+			return nil
+		}
+		for _, ev := range events {
+			cmd.Printf("%s  %-8s %-6s %s\n", ev.Time.Format("2006-01-02 15:04:05"), ev.User, ev.Action, ev.Summary())
+		}
+		return nil
+	},
+}
+
+// usageStatsCmd summarizes the usage statistics kept in the tag database
+var usageStatsCmd = &cobra.Command{
+	Use:   "usage-stats",
+	Short: "Show how you have used FySlide recently",
+	Long: `Summarizes the usage statistics FySlide keeps in the tag database: commands run,
+tags added and created, images processed by batch operations and images viewed in
+the slideshow, over the last --days days and per day. The statistics never leave
+this machine. --csv writes one row per day and counter, --json the days as JSON.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if usageDaysFlag < 1 {
+			return usageError{fmt.Errorf("--days must be at least 1")}
+		}
+		since := time.Now().AddDate(0, 0, 1-usageDaysFlag)
+		days, err := tagDB.UsageSince(cmd.Context(), since)
+		if err != nil {
+			return fmt.Errorf("error reading usage statistics: %w", err)
+		}
+		switch {
+		case usageCSVFlag:
+			return tagging.WriteUsageCSV(cmd.OutOrStdout(), days)
+		case usageJSONFlag:
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(days)
+		}
+
+		totals := tagging.UsageTotals(days)
+		cmd.Printf("Usage over the last %d day(s), active on %d:\n", usageDaysFlag, len(days))
+		cmd.Printf("Tags added: %d (%d new tags)\n", totals[tagging.UsageTagsAdded], totals[tagging.UsageTagsCreated])
+		cmd.Printf("Images processed: %d\n", totals[tagging.UsageImagesProcessed])
+		cmd.Printf("Images viewed: %d\n", totals[tagging.UsageImagesViewed])
+		if commands := tagging.UsageCommands(totals); len(commands) > 0 {
+			cmd.Println("\nCommands run:")
+			for _, command := range commands {
+				cmd.Printf("  %-24s %d\n", command.Name, command.Count)
+			}
+		}
+		if len(days) > 0 {
+			cmd.Println("\nPer day:")
+			for _, day := range days {
+				commands := 0
+				for _, command := range tagging.UsageCommands(day.Counts) {
+					commands += command.Count
+				}
+				cmd.Printf("  %s  %d command(s), %d tag(s) added, %d image(s) processed, %d viewed\n", day.Day.Format("2006-01-02"),
+					commands, day.Counts[tagging.UsageTagsAdded], day.Counts[tagging.UsageImagesProcessed], day.Counts[tagging.UsageImagesViewed])
+			}
+		}
+		return nil
+	},
+}
+
+// dbCmd groups maintenance commands working on whole tag databases
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Export, merge and compare whole tag databases",
+	Long: `Commands for keeping the tag databases of several machines in step. Copy a
+snapshot taken with "db export" to the other machine (or let a synced folder do it)
+and run "db merge" there; merging in both directions makes the databases converge.
+"db diff" shows what changed between two snapshots.`,
+}
+
+// dbExportCmd represents the db export command
+var dbExportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Write a consistent copy of the tag database",
+	Long: `Writes a snapshot of the tag database, including its audit log, to the file.
+Unlike copying the database file directly, this is safe while FySlide is running.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := os.Stat(args[0]); err == nil {
+			return fmt.Errorf("%s already exists", args[0])
+		}
+		if err := tagDB.Export(cmd.Context(), args[0]); err != nil {
+			return fmt.Errorf("error exporting the tag database: %w", err)
+		}
+		cmd.Printf("Exported the tag database to %s\n", args[0])
+		return nil
+	},
+}
+
+// dbMergeCmd represents the db merge command
+var dbMergeCmd = &cobra.Command{
+	Use:   "merge <other.db>",
+	Short: "Merge another tag database into this one",
+	Long: `Brings the tag changes recorded in another tag database (a file, or a directory
+holding one) into this one without losing either side's additions. An association
+present on only one side is decided by whichever side changed it last, according to
+both databases' audit logs: a tag removed on one machine after it was added on the
+other stays removed, and everything else is kept. The other database is not modified.
+Use --map-prefix from=to (repeatable) when the photo folder is mounted at a different
+path on the other machine.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		otherPath := args[0]
+		if info, err := os.Stat(otherPath); err != nil {
+			return fmt.Errorf("cannot read %s: %w", otherPath, err)
+		} else if info.IsDir() {
+			otherPath = filepath.Join(otherPath, tagging.DBFileName)
+		}
+		var mappings []tagging.PathMapping
+		for _, text := range mapPrefixFlag {
+			m, err := tagging.ParsePathMapping(text)
+			if err != nil {
+				return err
+			}
+			mappings = append(mappings, m)
+		}
+		other, err := tagging.OpenReadOnly(otherPath, func(message string) { log.Printf("Other TagDB: %s", message) })
+		if err != nil {
+			return err
+		}
+		defer other.Close()
+
+		stats, err := tagDB.Merge(cmd.Context(), other, tagging.MergeOptions{Source: otherPath, PathMap: mappings, DryRun: dryRunFlag})
+		if err != nil {
+			return fmt.Errorf("error merging %s: %w", otherPath, err)
+		}
+		prefix := ""
+		if dryRunFlag {
+			prefix = "DRY RUN: Would "
+		}
+		added := 0
+		for _, c := range stats.Changes {
+			verb := "remove"
+			if c.Action == tagging.ActionAdd {
+				verb = "add"
+				added++
+			}
+			if prefix == "" {
+				verb = strings.ToUpper(verb[:1]) + verb[1:]
+			}
+			cmd.Printf("%s%s '%s' on %s\n", prefix, verb, c.Tag, c.Path)
+		}
+
+		summaryPrefix := "Finished"
+		if dryRunFlag {
+			summaryPrefix = "DRY RUN: Finished simulation of"
+		}
+		cmd.Printf("%s merge. Tags added: %d, removed: %d, older changes ignored: %d.\n",
+			summaryPrefix, added, len(stats.Changes)-added, stats.Ignored)
+		return nil
+	},
+}
+
+// openDBFile opens the tag database in path, a file or a directory holding
+// one, read-only.
+func openDBFile(path string) (*tagging.TagDB, error) {
+	if info, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	} else if info.IsDir() {
+		path = filepath.Join(path, tagging.DBFileName)
+	}
+	return tagging.OpenReadOnly(path, func(message string) { log.Printf("TagDB %s: %s", path, message) })
+}
+
+// dbDiffCmd represents the db diff command
+var dbDiffCmd = &cobra.Command{
+	Use:   "diff <old.db> <new.db>",
+	Short: "Show the tag changes between two tag databases",
+	Long: `Compares two tag databases (files, or directories holding one), such as snapshots
+taken with "db export" before and after a bulk operation. Reports the tags that appeared
+or disappeared, the images whose tags moved to another path, and the images whose tags
+changed. A move is recognised from the rename recorded in the newer database's audit
+log, or when an image of the same file name with the same tags took the old one's place.
+Neither database is modified. Use --json for a machine-readable report.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		old, err := openDBFile(args[0])
+		if err != nil {
+			return err
+		}
+		defer old.Close()
+		new, err := openDBFile(args[1])
+		if err != nil {
+			return err
+		}
+		defer new.Close()
+
+		diff, err := tagging.Diff(cmd.Context(), old, new)
+		if err != nil {
+			return fmt.Errorf("error comparing %s with %s: %w", args[0], args[1], err)
+		}
+		if diffJSONFlag {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(diff)
+		}
+
+		if len(diff.AddedTags) > 0 {
+			cmd.Printf("New tags: %s\n", strings.Join(diff.AddedTags, ", "))
+		}
+		if len(diff.RemovedTags) > 0 {
+			cmd.Printf("Tags no longer used: %s\n", strings.Join(diff.RemovedTags, ", "))
+		}
+		for _, m := range diff.Moved {
+			cmd.Printf("Moved %s -> %s\n", m.From, m.To)
+		}
+		for _, r := range diff.Retagged {
+			var changes []string
+			for _, tag := range r.Added {
+				changes = append(changes, "+"+tag)
+			}
+			for _, tag := range r.Removed {
+				changes = append(changes, "-"+tag)
+			}
+			cmd.Printf("Retagged %s: %s\n", r.Path, strings.Join(changes, " "))
+		}
+		if diff.Empty() {
+			cmd.Println("The databases hold the same tags.")
+			return nil
+		}
+		cmd.Printf("Tags added: %d, removed: %d. Images moved: %d, retagged: %d.\n",
+			len(diff.AddedTags), len(diff.RemovedTags), len(diff.Moved), len(diff.Retagged))
+		return nil
+	},
+}
+
+// applyDirDefaultsCmd represents the apply-dir-defaults command
+var applyDirDefaultsCmd = &cobra.Command{
+	Use:   "apply-dir-defaults <directory>",
+	Short: "Tag images with their folder's default tags",
+	Long: `Adds the tags listed in a folder's ` + dirtags.FileName + ` file to the supported images
+directly within that folder. The file lists tags separated by commas or newlines; '#'
+starts a comment. With --recursive, subfolders are visited too, each using its own file.
+With --only-untagged, images that already have any tag are left alone.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		absDirPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for directory %s: %w", args[0], err)
+		}
+		changes, err := dirtags.Plan(cmd.Context(), absDirPath, tagDB.GetTags, dirtags.Options{Recursive: recursiveFlag, OnlyUntagged: onlyUntaggedFlag})
+		if err != nil {
+			return err
+		}
+
+		var firstError error
+		added := 0
+		for _, change := range changes {
+			for _, tag := range change.Tags {
+				if dryRunFlag {
+					cmd.Printf("DRY RUN: Would add tag '%s' to %s\n", tag, change.Path)
+					added++
+					continue
+				}
+				if err := tagDB.AddTag(cmd.Context(), change.Path, tag); err != nil {
+					cmd.PrintErrf("Error adding tag '%s' to %s: %v\n", tag, change.Path, err)
+					if firstError == nil {
+						firstError = err
+					}
+					continue
+				}
+				cmd.Printf("Added tag '%s' to %s\n", tag, change.Path)
+				added++
+			}
+		}
+
+		summaryPrefix := "Finished"
+		if dryRunFlag {
+			summaryPrefix = "DRY RUN: Finished simulation of"
+		}
+		cmd.Printf("%s applying folder defaults. %d image(s) needed tags, %d tag(s) added.\n", summaryPrefix, len(changes), added)
+		return firstError
+	},
+}
+
+// autotagCmd groups the commands that derive tags from the images themselves
+var autotagCmd = &cobra.Command{
+	Use:   "autotag",
+	Short: "Derive tags automatically from images",
+	Long: `Commands that work out tags from what is already known about images, such as
+their file names, EXIF data or colors. Run them with --dry-run first to preview the tags they would add.`,
+}
+
+// autotagFilenameCmd represents the autotag filename command
+var autotagFilenameCmd = &cobra.Command{
+	Use:   "filename <directory>",
+	Short: "Tag images by patterns in their file names",
+	Long: `Recursively scans the given directory and adds the tags that the rules in the
+--rules YAML file derive from each image's name. Every rule has a regular expression
+'pattern', matched against the file name without its extension, or against the whole
+path with 'match: path'. Without 'tags', each capture group's text becomes a tag;
+otherwise each entry of 'tags' does, with {group} replaced by that group's text:
+
+  rules:
+    - pattern: '(?P<year>\d{4})-(?P<event>[a-z]+)'
+    - pattern: '/Trips/(?P<place>[^/]+)/'
+      match: path
+      tags: ["trip", "{place}"]
+
+Tags are lowercased; ones an image already has are not added again.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if rulesFlag == "" {
+			return fmt.Errorf("--rules is required")
+		}
+		rules, err := autotag.LoadFilenameRules(rulesFlag)
+		if err != nil {
+			return fmt.Errorf("error loading rules: %w", err)
+		}
+		return applyAutotags(cmd, args[0], "filename", func(path string) ([]string, error) {
+			return rules.Tags(path), nil
+		})
+	},
+}
+
+// autotagEXIFCmd represents the autotag exif command
+var autotagEXIFCmd = &cobra.Command{
+	Use:   "exif <directory>",
+	Short: "Tag images with namespaced tags from their EXIF data",
+	Long: `Recursively scans the given directory and adds tags derived from each image's
+EXIF data, such as camera:PixelFold, year:2024, month:2024-06 or iso:high.
+--namespaces selects which kinds of tags are added, out of: ` + strings.Join(autotag.Namespaces, ", ") + `.
+Images without EXIF data, or without a given field, get no tag for it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		namespaces, err := autotag.ParseNamespaces(namespacesFlag)
+		if err != nil {
+			return err
+		}
+		if len(namespaces) == 0 {
+			return fmt.Errorf("--namespaces must name at least one namespace")
+		}
+		return applyAutotags(cmd, args[0], "EXIF", func(path string) ([]string, error) {
+			return autotag.EXIFTags(path, namespaces)
+		})
+	},
+}
+
+// autotagColorCmd represents the autotag color command
+var autotagColorCmd = &cobra.Command{
+	Use:   "color <directory>",
+	Short: "Tag images with their dominant colors",
+	Long: `Recursively scans the given directory and tags each image with its broad dominant
+colors, such as ` + imagesig.ColorTagPrefix + `red or ` + imagesig.ColorTagPrefix + `blue, or with ` + imagesig.BWTag + ` when it has hardly any color.
+The color signatures are cached, so later runs and the GUI's Find Similar Colors only
+decode the images that changed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := imagesig.OpenCache("")
+		if err != nil {
+			log.Printf("Signature cache unavailable, computing every signature: %v", err)
+		} else {
+			defer cache.Close()
+		}
+		return applyAutotags(cmd, args[0], "color", func(path string) ([]string, error) {
+			sig, err := cache.Get(path)
+			if err != nil {
+				return nil, err
+			}
+			return sig.ColorTags(), nil
+		})
+	},
+}
+
+// analyzeCmd represents the analyze command
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze <directory>",
+	Short: "Flag images that are likely blurry or badly exposed",
+	Long: `Recursively scans the given directory and lists the images that are likely blurry,
+judged by how little detail their edges show, or badly exposed, judged by how much of
+them is clipped to black or white. These are heuristics meant to speed up culling:
+check the flagged images before deleting them.
+With --tag the flagged images are tagged ` + imagesig.BlurryTag + `, ` + imagesig.UnderexposedTag + ` or ` + imagesig.OverexposedTag + `.
+The measurements are cached with the color signatures, so later runs only decode the
+images that changed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := imagesig.OpenCache("")
+		if err != nil {
+			log.Printf("Signature cache unavailable, computing every signature: %v", err)
+		} else {
+			defer cache.Close()
+		}
+		if analyzeTagFlag {
+			return applyAutotags(cmd, args[0], "quality", func(path string) ([]string, error) {
+				sig, err := cache.Get(path)
+				if err != nil {
+					return nil, err
+				}
+				return sig.QualityTags(), nil
+			})
+		}
+
+		absDirPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for directory %s: %w", args[0], err)
+		}
+		if info, err := os.Stat(absDirPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a readable directory", absDirPath)
+		}
+		var paths []string
+		for item := range scanLibrary(absDirPath, func(message string) { log.Printf("Scan: %s", message) }) {
+			paths = append(paths, item.Path)
+		}
+		slices.Sort(paths)
+
+		var firstError error
+		flagged := 0
+		for _, path := range paths {
+			sig, err := cache.Get(path)
+			if err != nil {
+				cmd.PrintErrf("Error reading %s: %v\n", path, err)
+				if firstError == nil {
+					firstError = err
+				}
+				continue
+			}
+			if len(sig.QualityTags()) > 0 {
+				cmd.Printf("%s: %s\n", path, sig.QualitySummary())
+				flagged++
+			}
+		}
+		cmd.Printf("Finished analysis. Scanned %d image(s), %d flagged.\n", len(paths), flagged)
+		return firstError
+	},
+}
+
+// ocrCmd represents the ocr command
+var ocrCmd = &cobra.Command{
+	Use:   "ocr <directory>",
+	Short: "Extract the text of scanned documents with OCR",
+	Long: `Recursively scans the given directory and reads the text of every image with OCR,
+storing it in the tag database, where the viewer shows and searches it and find-text
+finds it. The engine is tesseract on the PATH unless ocr.engine in the config file or
+--engine names another tesseract command or the http(s) URL of an OCR service, which
+is sent each image in a POST request and answers with the text.
+Images whose text was read since they last changed are skipped unless --force is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		absDirPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for directory %s: %w", args[0], err)
+		}
+		if info, err := os.Stat(absDirPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a readable directory", absDirPath)
+		}
+		engineSpec, lang := ocrEngineFlag, ocrLangFlag
+		if appConfig != nil {
+			if engineSpec == "" {
+				engineSpec = appConfig.OCR.Engine
+			}
+			if lang == "" {
+				lang = appConfig.OCR.Language
+			}
+		}
+		engine, err := ocr.New(engineSpec, lang)
+		if err != nil {
+			return err
+		}
+		stored, err := tagDB.OCRTexts(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("error loading extracted text: %w", err)
+		}
+
+		var items []tagging.OCRText
+		for item := range scanLibrary(absDirPath, func(message string) { log.Printf("Scan: %s", message) }) {
+			info := item.Info
+			if info == nil {
+				if info, err = os.Stat(item.Path); err != nil {
+					continue
+				}
+			}
+			if text, ok := stored[item.Path]; ok && !forceFlag && text.Current(info.ModTime(), info.Size()) {
+				continue
+			}
+			items = append(items, tagging.OCRText{Path: item.Path, ModTime: info.ModTime(), Size: info.Size()})
+		}
+		slices.SortFunc(items, func(a, b tagging.OCRText) int { return strings.Compare(a.Path, b.Path) })
+
+		var firstError error
+		read, withText := 0, 0
+		for _, item := range items {
+			if dryRunFlag {
+				cmd.Printf("DRY RUN: Would read the text of %s\n", item.Path)
+				continue
+			}
+			text, err := engine.Extract(cmd.Context(), item.Path)
+			if err == nil {
+				item.Text = text
+				err = tagDB.PutOCRText(cmd.Context(), []tagging.OCRText{item})
+			}
+			if err != nil {
+				cmd.PrintErrf("Error reading the text of %s: %v\n", item.Path, err)
+				if firstError == nil {
+					firstError = err
+				}
+				continue
+			}
+			read++
+			if text != "" {
+				withText++
+				cmd.Printf("%s: %d characters\n", item.Path, len([]rune(text)))
+			}
+		}
+		if dryRunFlag {
+			cmd.Printf("DRY RUN: Finished simulation of OCR. %d image(s) would be read.\n", len(items))
+			return nil
 		}
+		cmd.Printf("Finished OCR. Read %d of %d image(s), %d with text.\n", read, len(items), withText)
 		return firstError
 	},
 }
 
+// transformCmd represents the transform command
+var transformCmd = &cobra.Command{
+	Use:   "transform <directory>",
+	Short: "Rotate, deskew and crop the borders of scanned images in place",
+	Long: `Recursively scans the given directory and rewrites every JPEG and PNG image turned
+by --rotate degrees clockwise, straightened with --deskew, which finds the angle its
+straight edges lean by, and cropped with --crop, which trims the uniform borders a
+scanner leaves around film frames. JPEGs keep their EXIF data.
+Use --dry-run to list the skew and borders found without changing any file.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := transform.Options{
+			Rotate:  rotateFlag,
+			Deskew:  deskewFlag,
+			Crop:    cropBordersFlag,
+			MaxSkew: maxSkewFlag,
+			Quality: exportQualityFlag,
+		}
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		absDirPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("error getting absolute path for directory %s: %w", args[0], err)
+		}
+		if info, err := os.Stat(absDirPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a readable directory", absDirPath)
+		}
+
+		var paths []string
+		for item := range scanLibrary(absDirPath, func(message string) { log.Printf("Scan: %s", message) }) {
+			if transform.Supported(item.Path) {
+				paths = append(paths, item.Path)
+			}
+		}
+		slices.Sort(paths)
+
+		var firstError error
+		changed := 0
+		for _, path := range paths {
+			r, err := transform.ApplyFile(path, opts, dryRunFlag)
+			if err != nil {
+				cmd.PrintErrf("Error transforming %s: %v\n", path, err)
+				if firstError == nil {
+					firstError = err
+				}
+				continue
+			}
+			if !r.Changed {
+				continue
+			}
+			changed++
+			if dryRunFlag {
+				cmd.Printf("DRY RUN: Would transform %s: %s\n", path, r)
+			} else {
+				cmd.Printf("%s: %s\n", path, r)
+			}
+		}
+		if dryRunFlag {
+			cmd.Printf("DRY RUN: Finished simulation of transform. %d of %d image(s) would change.\n", changed, len(paths))
+			return firstError
+		}
+		cmd.Printf("Finished transform. Changed %d of %d image(s).\n", changed, len(paths))
+		return firstError
+	},
+}
+
+// findTextCmd represents the find-text command
+var findTextCmd = &cobra.Command{
+	Use:   "find-text <text>",
+	Short: "List images whose OCR text contains the given text",
+	Long: `Lists the images whose text, read by the ocr command or the viewer, contains the
+given text, ignoring case, with the line it was found on.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		texts, err := tagDB.OCRTexts(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("error loading extracted text: %w", err)
+		}
+		idx := search.New()
+		for path, text := range texts {
+			idx.Set(path, nil)
+			idx.SetText(path, text.Text)
+		}
+		c := query.Criteria{Text: args[0]}
+		found := 0
+		for _, r := range idx.Search(args[0], 0) {
+			if !c.MatchesText(texts[r.Path].Text) { // The index matches each word on its own
+				continue
+			}
+			found++
+			cmd.Printf("%s: %s\n", r.Path, r.Snippet)
+		}
+		if found == 0 {
+			cmd.Printf("No images found with text '%s'\n", args[0])
+		}
+		return nil
+	},
+}
+
+// applyAutotags adds the tags derive returns for every image under dir that
+// the image doesn't have yet, or only lists them with --dry-run.
+func applyAutotags(cmd *cobra.Command, dir, kind string, derive func(path string) ([]string, error)) error {
+	absDirPath, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("error getting absolute path for directory %s: %w", dir, err)
+	}
+	if info, err := os.Stat(absDirPath); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a readable directory", absDirPath)
+	}
+
+	var paths []string
+	for item := range scanLibrary(absDirPath, func(message string) { log.Printf("Scan: %s", message) }) {
+		paths = append(paths, item.Path)
+	}
+	slices.Sort(paths)
+
+	var firstError error
+	images, added := 0, 0
+	for _, path := range paths {
+		current, err := tagDB.GetTags(cmd.Context(), path)
+		if err != nil {
+			cmd.PrintErrf("Error getting tags for %s: %v\n", path, err)
+			if firstError == nil {
+				firstError = err
+			}
+			continue
+		}
+		tags, err := derive(path)
+		if err != nil {
+			cmd.PrintErrf("Error reading %s: %v\n", path, err)
+			if firstError == nil {
+				firstError = err
+			}
+			continue
+		}
+		changed := false
+		for _, tag := range tags {
+			if slices.Contains(current, tag) {
+				continue
+			}
+			changed = true
+			if dryRunFlag {
+				cmd.Printf("DRY RUN: Would add tag '%s' to %s\n", tag, path)
+				added++
+				continue
+			}
+			if err := tagDB.AddTag(cmd.Context(), path, tag); err != nil {
+				cmd.PrintErrf("Error adding tag '%s' to %s: %v\n", tag, path, err)
+				if firstError == nil {
+					firstError = err
+				}
+				continue
+			}
+			cmd.Printf("Added tag '%s' to %s\n", tag, path)
+			added++
+		}
+		if changed {
+			images++
+		}
+	}
+
+	summaryPrefix := "Finished"
+	if dryRunFlag {
+		summaryPrefix = "DRY RUN: Finished simulation of"
+	}
+	cmd.Printf("%s %s autotag. Scanned %d image(s), %d needed tags, %d tag(s) added.\n", summaryPrefix, kind, len(paths), images, added)
+	return firstError
+}
+
+// serveGRPCCmd represents the serve-grpc command
+var serveGRPCCmd = &cobra.Command{
+	Use:   "serve-grpc",
+	Short: "Serve the tag database over gRPC",
+	Long: `Serves the tag service defined in api/fyslide/v1/fyslide.proto until interrupted,
+for remote automation and other front-ends. Clients address images by their absolute
+paths on this machine. Changes go through the same checks as the CLI and the GUI;
+with --read-only every mutating call is refused and only queries and dry runs work.
+The server has no authentication, so it listens on localhost unless --listen says otherwise.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listener, err := net.Listen("tcp", listenFlag)
+		if err != nil {
+			return fmt.Errorf("cannot listen on %s: %w", listenFlag, err)
+		}
+		svc := service.New(tagDB)
+		svc.SetReadOnly(readOnlyFlag)
+		gs := grpc.NewServer()
+		grpcapi.NewServer(svc, tagDB).Register(gs)
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			gs.GracefulStop()
+		}()
+		mode := ""
+		if readOnlyFlag {
+			mode = " (read-only)"
+		}
+		cmd.Printf("Serving gRPC on %s%s\n", listener.Addr(), mode)
+		if err := gs.Serve(listener); err != nil {
+			return fmt.Errorf("gRPC server failed: %w", err)
+		}
+		cmd.Println("Stopped.")
+		return nil
+	},
+}
+
+// serveHTTPCmd represents the serve-http command
+var serveHTTPCmd = &cobra.Command{
+	Use:   "serve-http",
+	Short: "Serve the library over HTTP, optionally with a web front-end",
+	Long: `Serves the HTTP API until interrupted, for web front-ends and home dashboards:
+GET /api/v1/thumbnail and /api/v1/preview answer a JPEG of the image whose absolute
+path is given as ?path=, at most ?size= pixels on its longest edge. Responses carry an
+ETag for revalidation. /api/v1/tags, /api/v1/images and /api/v1/image/tags list tags
+and images and change the tags of an image, as JSON. With --web, a web front-end at /
+browses the library, finds images by tag, plays a slideshow and edits tags, e.g. from
+a phone. Only images under the library roots, given with --root or library.roots in
+the config file, are served, and never as their original files. Images carrying the
+private tag (--private-tag) are neither listed nor served unless --include-private is
+given. --read-only refuses tag changes. Users listed under api.users in the config file must sign in with their
+token, as a bearer token or as the password of basic auth; viewers may browse and
+play slideshows, editors may also change tags. Without users, the server only
+listens on localhost, unless --no-auth opens it to the network regardless.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		roots := append(libraryRootsFlag, appConfig.Library.Roots...)
+		if len(roots) == 0 {
+			return fmt.Errorf("no library to serve; give its folders with --root or library.roots in the config file")
+		}
+		users := appConfig.API.Users
+		if len(users) == 0 && !noAuthFlag && !loopbackAddress(httpListenFlag) {
+			return fmt.Errorf("refusing to serve %s without authentication; add api.users to the config file or pass --no-auth", httpListenFlag)
+		}
+		listener, err := net.Listen("tcp", httpListenFlag)
+		if err != nil {
+			return fmt.Errorf("cannot listen on %s: %w", httpListenFlag, err)
+		}
+		svc := newService()
+		svc.SetReadOnly(readOnlyFlag)
+		svc.SetLibraryRoots(roots...)
+		api := httpapi.NewServer(svc, tagDB, roots...)
+		api.SetWeb(webFlag)
+		api.SetUsers(users...)
+		api.SetPrivate(privateTagFlag, includePrivateFlag)
+		server := &http.Server{Handler: api.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			server.Shutdown(context.Background())
+		}()
+		mode := ""
+		if readOnlyFlag {
+			mode = " (read-only)"
+		}
+		if len(users) > 0 {
+			mode += fmt.Sprintf(" for %d user(s)", len(users))
+		}
+		cmd.Printf("Serving HTTP on %s for %s%s\n", listener.Addr(), strings.Join(roots, ", "), mode)
+		if webFlag {
+			cmd.Printf("Web front-end at http://%s/\n", listener.Addr())
+		}
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("HTTP server failed: %w", err)
+		}
+		cmd.Println("Stopped.")
+		return nil
+	},
+}
+
+// loopbackAddress reports whether the listen address addr only accepts
+// connections from this machine.
+func loopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 func init() {
 	// Add persistent flags to the root command (available to all subcommands)
 	// The default value for dbPathFlag is "", which means tagging.NewTagDB will use its internal default.
+	rootCmd.PersistentFlags().StringVar(&configFlag, "config", "", "Config file to read. If empty, uses $FYSLIDE_CONFIG or config.yaml in the default location.")
 	rootCmd.PersistentFlags().StringVar(&dbPathFlag, "dbpath", "", "Path to the tag database file (e.g., /path/to/tags.db). If empty, uses default location.")
+	rootCmd.PersistentFlags().BoolVar(&jsonErrorsFlag, "json-errors", false, "Report errors as JSON lines on stderr. Exit codes: 0 success, 1 failures, 2 usage error, 3 database error, 130 interrupted.")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Use the tag database of this profile. Ignored when --dbpath is given.")
 
 	// Add flags for batch commands
 	batchAddCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Simulate the batch add operation without making changes.")
 	batchRemoveCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Simulate the batch remove operation without making changes.")
 	batchRemoveCmd.Flags().BoolVar(&forceFlag, "force", false, "Force batch removal without confirmation.")
+	deleteCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "List the images that would be deleted without deleting them.")
+	deleteCmd.Flags().BoolVar(&forceFlag, "force", false, "Delete without confirmation.")
+	deleteCmd.Flags().BoolVar(&allowProtectedFlag, "allow-protected", false, "Also delete images carrying a protected tag.")
+	deleteCmd.Flags().BoolVar(&dbOnlyFlag, "db-only", false, "Remove the tags of the images from the database but keep the files.")
+	deleteCmd.Flags().BoolVar(&filesOnlyFlag, "files-only", false, "Delete the files for good but keep their tags in the database.")
+	deleteCmd.Flags().IntVar(&minCountFlag, "min-count", 0, "Only delete images carrying at least this many tags.")
+	deleteCmd.Flags().BoolVar(&confirmEachFlag, "confirm-each", false, "Ask about each image in turn instead of once for all.")
 	normalizeCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Simulate the normalization process without making changes.")
 	replaceTagCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Simulate the tag replacement process without making changes.")
 	cleanCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Simulate the cleanup process without making changes.")
+	cleanCmd.Flags().BoolVar(&forceCleanFlag, "force-clean", false, "Clean even when more files appear missing than --max-missing-percent allows.")
+	cleanCmd.Flags().Float64Var(&maxMissingPctFlag, "max-missing-percent", service.DefaultMaxMissingPercent, "Share of missing files, in percent, past which clean suspects an unmounted drive and refuses to run.")
+	cleanCmd.Flags().StringArrayVar(&libraryRootsFlag, "root", nil, "Library folder, e.g. a share's mount point. While it is unreachable, files under it keep their tags. Repeatable.")
 	addToTaggedCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Simulate adding new tags without making changes.")
+	verifyCmd.Flags().BoolVar(&tagCorruptFlag, "tag-corrupt", false, "Tag undecodable files as '"+tagging.CorruptTag+"'.")
+	verifyCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Report which files would be tagged without making changes.")
+	importCmd.PersistentFlags().BoolVar(&dryRunFlag, "dry-run", false, "Report the tag changes without making them.")
+	importCmd.PersistentFlags().StringVar(&conflictPolicyFlag, "on-conflict", string(importer.PolicyMerge), "What to do with images that already have tags: merge, skip or replace.")
+	importCmd.PersistentFlags().StringVar(&matchRootFlag, "match-root", "", "Directory of images to match by file name and size when a recorded path no longer exists.")
+	importTagSpacesCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Report the tag changes without making them.")
+	importTagSpacesCmd.Flags().StringVar(&conflictPolicyFlag, "on-conflict", string(importer.PolicyMerge), "What to do with images that already have tags: merge, skip or replace.")
+	importTagSpacesCmd.Flags().StringVar(&matchRootFlag, "match-root", "", "Directory of images to match by file name and size when a recorded path no longer exists.")
+	exportTagSpacesCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "List the sidecars that would be written without writing them.")
+	exportTagSpacesCmd.Flags().StringVar(&privateTagFlag, "private-tag", tagging.PrivateTag, "Tag marking private images, which are not exported by default.")
+	exportTagSpacesCmd.Flags().BoolVar(&includePrivateFlag, "include-private", false, "Also export images carrying the private tag.")
+	for _, exportCmd := range []*cobra.Command{exportResizedCmd, exportFilesCmd} {
+		exportCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "List the copies that would be written without writing them.")
+		exportCmd.Flags().StringArrayVar(&exportTagsFlag, "tag", nil, "Only export images carrying this tag. Repeat to require several tags.")
+		exportCmd.Flags().IntVar(&exportMaxSizeFlag, "max-size", 0, "Longest edge of the copies in pixels. 0 keeps the original size.")
+		exportCmd.Flags().IntVar(&exportQualityFlag, "quality", exporter.DefaultQuality, "JPEG quality of the copies, from 1 to 100.")
+		exportCmd.Flags().StringVar(&exportFormatFlag, "format", exporter.FormatKeep, "Format of the copies: "+strings.Join(exporter.Formats, ", ")+".")
+		exportCmd.Flags().BoolVar(&stripMetadataFlag, "strip-metadata", false, "Leave the EXIF data out of JPEG copies.")
+		exportCmd.Flags().BoolVar(&autoLevelsFlag, "auto-levels", false, "Stretch the levels of each copy from its histogram.")
+		exportCmd.Flags().BoolVar(&overwriteFlag, "overwrite", false, "Replace copies that already exist.")
+		exportCmd.Flags().IntVar(&workersFlag, "workers", 0, "Images converted at once. 0 uses one per CPU.")
+		exportCmd.Flags().StringVar(&privateTagFlag, "private-tag", tagging.PrivateTag, "Tag marking private images, which are not exported by default.")
+		exportCmd.Flags().BoolVar(&includePrivateFlag, "include-private", false, "Also export images carrying the private tag.")
+	}
+	syncMetadataCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Report the changes of one pass without making them.")
+	syncMetadataCmd.Flags().DurationVar(&syncIntervalFlag, "interval", 0, "Keep running and sync at this interval (e.g. 5m). 0 makes a single pass.")
+	syncMetadataCmd.Flags().StringVar(&syncPreferFlag, "prefer", string(metadata.PreferMerge), "Conflict rule when both sides changed: merge, db or file.")
+	syncMetadataCmd.Flags().IntVar(&syncBatchSizeFlag, "batch-size", metadata.DefaultBatchSize, "Maximum number of files rewritten per pass.")
+	syncMetadataCmd.Flags().StringVar(&syncStateFlag, "state", "", "Sync state file. If empty, uses metadata_sync.json in the default config location.")
+
+	historyLogCmd.Flags().StringVar(&historyTagFlag, "tag", "", "Only show changes involving this tag.")
+	historyLogCmd.Flags().StringVar(&historyPathFlag, "path", "", "Only show changes to paths containing this text.")
+	historyLogCmd.Flags().DurationVar(&historySinceFlag, "since", 0, "Only show changes made within this duration (e.g. 24h).")
+	historyLogCmd.Flags().IntVar(&historyLimitFlag, "limit", 0, "Only show the most recent N changes. 0 shows all.")
+	historyLogCmd.Flags().BoolVar(&historyCSVFlag, "csv", false, "Write the changes as CSV.")
+
+	dbMergeCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "List the changes without making them.")
+	dbMergeCmd.Flags().StringArrayVar(&mapPrefixFlag, "map-prefix", nil, "Rewrite paths of the other database starting with 'from' to start with 'to' (from=to).")
+
+	applyDirDefaultsCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "List the tags that would be added without adding them.")
+	applyDirDefaultsCmd.Flags().BoolVarP(&recursiveFlag, "recursive", "r", false, "Also apply the defaults files of subfolders.")
+	applyDirDefaultsCmd.Flags().BoolVar(&onlyUntaggedFlag, "only-untagged", false, "Only tag images that have no tags yet.")
+
+	autotagFilenameCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "List the tags that would be added without adding them.")
+	autotagFilenameCmd.Flags().StringVar(&rulesFlag, "rules", "", "YAML file with the filename rules.")
+	autotagEXIFCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "List the tags that would be added without adding them.")
+	autotagEXIFCmd.Flags().StringVar(&namespacesFlag, "namespaces", strings.Join(autotag.DefaultNamespaces, ","), "Comma-separated EXIF namespaces to add tags for.")
+	autotagColorCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "List the tags that would be added without adding them.")
+	analyzeCmd.Flags().BoolVar(&analyzeTagFlag, "tag", false, "Tag the flagged images with their quality warnings.")
+	analyzeCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "With --tag, list the tags that would be added without adding them.")
+	ocrCmd.Flags().StringVar(&ocrEngineFlag, "engine", "", "tesseract command or OCR service URL. If empty, uses ocr.engine of the config file, else tesseract.")
+	ocrCmd.Flags().StringVar(&ocrLangFlag, "lang", "", "Languages to read, e.g. eng+deu. If empty, uses ocr.language of the config file.")
+	ocrCmd.Flags().BoolVar(&forceFlag, "force", false, "Read the text of images again even if they haven't changed.")
+	ocrCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "List the images that would be read without reading them.")
+	transformCmd.Flags().IntVar(&rotateFlag, "rotate", 0, "Degrees to turn each image clockwise: 90, 180 or 270 (-90).")
+	transformCmd.Flags().BoolVar(&deskewFlag, "deskew", false, "Straighten a slight skew found from the image's straight edges.")
+	transformCmd.Flags().BoolVar(&cropBordersFlag, "crop", false, "Crop the uniform borders around each image.")
+	transformCmd.Flags().Float64Var(&maxSkewFlag, "max-skew", transform.DefaultMaxSkew, "Largest skew in degrees --deskew corrects.")
+	transformCmd.Flags().IntVar(&exportQualityFlag, "quality", exporter.DefaultQuality, "JPEG quality of the rewritten images, from 1 to 100.")
+	transformCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "List the changes that would be made without writing them.")
+
+	serveGRPCCmd.Flags().StringVar(&listenFlag, "listen", "localhost:50051", "Address to serve gRPC on.")
+	serveGRPCCmd.Flags().BoolVar(&readOnlyFlag, "read-only", false, "Refuse every call that would change images or tags.")
 
 	// Add subcommands to the root command
 	rootCmd.AddCommand(addCmd)
@@ -594,41 +2993,112 @@ func init() {
 	rootCmd.AddCommand(listAllTagsCmd)
 	rootCmd.AddCommand(batchAddCmd)
 	rootCmd.AddCommand(batchRemoveCmd)
+	rootCmd.AddCommand(deleteCmd)
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashEmptyCmd.Flags().IntVar(&trashOlderThanFlag, "older-than", 0, "Only delete the images deleted more than this many days ago.")
+	trashEmptyCmd.Flags().BoolVar(&forceFlag, "force", false, "Empty the trash without confirmation.")
+	trashCmd.AddCommand(trashEmptyCmd)
+	rootCmd.AddCommand(trashCmd)
 	rootCmd.AddCommand(replaceTagCmd)
 	rootCmd.AddCommand(normalizeCmd)
 	rootCmd.AddCommand(cleanCmd)
 	rootCmd.AddCommand(addToTaggedCmd)
+	rootCmd.AddCommand(applySetCmd)
+	tagSetCmd.AddCommand(tagSetListCmd)
+	tagSetCmd.AddCommand(tagSetSaveCmd)
+	tagSetCmd.AddCommand(tagSetDeleteCmd)
+	rootCmd.AddCommand(tagSetCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(renameFileCmd)
+	importCmd.AddCommand(importDigiKamCmd)
+	importCmd.AddCommand(importXMPCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(importTagSpacesCmd)
+	rootCmd.AddCommand(exportTagSpacesCmd)
+	rootCmd.AddCommand(exportResizedCmd)
+	importCardCmd.Flags().StringArrayVar(&cardTagsFlag, "tag", nil, "Tag to add to the copied images. Repeatable.")
+	importCardCmd.Flags().BoolVar(&cardDeleteFlag, "delete", false, "Delete the originals from the card once their copies are verified.")
+	importCardCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "List where each image would be copied without copying it.")
+	rootCmd.AddCommand(importCardCmd)
+	rootCmd.AddCommand(exportFilesCmd)
+	rootCmd.AddCommand(syncMetadataCmd)
+	rootCmd.AddCommand(historyLogCmd)
+	usageStatsCmd.Flags().IntVar(&usageDaysFlag, "days", 30, "Number of days to summarize, ending today.")
+	usageStatsCmd.Flags().BoolVar(&usageCSVFlag, "csv", false, "Write the statistics as CSV.")
+	usageStatsCmd.Flags().BoolVar(&usageJSONFlag, "json", false, "Write the statistics as JSON.")
+	rootCmd.AddCommand(usageStatsCmd)
+	dbCmd.AddCommand(dbExportCmd)
+	dbCmd.AddCommand(dbMergeCmd)
+	dbDiffCmd.Flags().BoolVar(&diffJSONFlag, "json", false, "Print the differences as JSON.")
+	dbCmd.AddCommand(dbDiffCmd)
+	rootCmd.AddCommand(dbCmd)
+	rootCmd.AddCommand(applyDirDefaultsCmd)
+	autotagCmd.AddCommand(autotagFilenameCmd)
+	autotagCmd.AddCommand(autotagEXIFCmd)
+	autotagCmd.AddCommand(autotagColorCmd)
+	rootCmd.AddCommand(autotagCmd)
+	rootCmd.AddCommand(analyzeCmd)
+	rootCmd.AddCommand(ocrCmd)
+	rootCmd.AddCommand(transformCmd)
+	rootCmd.AddCommand(findTextCmd)
+	rootCmd.AddCommand(serveGRPCCmd)
+	serveHTTPCmd.Flags().StringVar(&httpListenFlag, "listen", "localhost:8080", "Address to serve HTTP on.")
+	serveHTTPCmd.Flags().BoolVar(&webFlag, "web", false, "Also serve the web front-end at /.")
+	serveHTTPCmd.Flags().BoolVar(&readOnlyFlag, "read-only", false, "Refuse every request that would change images or tags.")
+	serveHTTPCmd.Flags().BoolVar(&noAuthFlag, "no-auth", false, "Serve beyond localhost even though no API users are configured.")
+	serveHTTPCmd.Flags().StringArrayVar(&libraryRootsFlag, "root", nil, "Library folder whose images are served. Repeatable.")
+	serveHTTPCmd.Flags().StringVar(&privateTagFlag, "private-tag", tagging.PrivateTag, "Tag marking private images, which are not served by default.")
+	serveHTTPCmd.Flags().BoolVar(&includePrivateFlag, "include-private", false, "Also list and serve images carrying the private tag.")
+	rootCmd.AddCommand(serveHTTPCmd)
+	rootCmd.AddCommand(pathsCmd)
+	statsCmd.Flags().BoolVar(&statsJSONFlag, "json", false, "Print the overview as JSON.")
+	statsCmd.Flags().IntVar(&statsTopFlag, "top", service.DefaultTopTags, "Number of most used tags to list.")
+	statsCmd.Flags().BoolVar(&statsSkipFilesFlag, "skip-files", false, "Don't check which image files are missing, which is slow for large libraries.")
+	statsCmd.Flags().StringArrayVar(&libraryRootsFlag, "root", nil, "Library folder, e.g. a share's mount point. While it is unreachable, files under it count as unreachable. Repeatable.")
+	rootCmd.AddCommand(statsCmd)
+	tagsForDirCmd.Flags().StringVar(&missingTagFlag, "missing", "", "Also list the images lacking this tag.")
+	rootCmd.AddCommand(tagsForDirCmd)
+	rootCmd.AddCommand(editCmd)
+	markUsageErrors(rootCmd)
 }
 
-// processFilesInDirectory is a helper function to reduce duplication between batch-add and batch-remove
-func processFilesInDirectory(cmd *cobra.Command, dirPath string, tagsToProcess []string,
-	tagAction func(filePath, tag string) error, actionVerb, operationName string,
-	isDryRun, needsConfirmation, isForced bool) error {
-
-	if needsConfirmation && !isForced && !isDryRun {
-		cmd.Printf("WARNING: You are about to %s %d tag(s) from all supported images in directory:\n%s\n",
-			operationName, len(tagsToProcess), dirPath)
-		cmd.Print("This action cannot be undone for the actual files.\nAre you sure you want to continue? (yes/no): ")
-		var response string
-		_, err := fmt.Scanln(&response)
-		if err != nil || strings.ToLower(strings.TrimSpace(response)) != "yes" {
-			if err != nil && err.Error() != "unexpected newline" && err.Error() != "EOF" { // Handle actual Scanln errors
-				cmd.PrintErrf("Error reading confirmation: %v\n", err)
-			}
-			cmd.Println("Operation cancelled by user.")
-			return nil
+// stdinArg in place of a directory or image reads newline-separated image paths
+// from stdin, so batch commands compose with find or fzf.
+const stdinArg = "-"
+
+// stdinSource describes the paths read from stdin in messages.
+const stdinSource = "the paths read from stdin"
+
+// readPathList reads newline-separated paths, skipping blank lines, and makes
+// them absolute.
+func readPathList(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		absPath, err := filepath.Abs(line)
+		if err != nil {
+			return nil, fmt.Errorf("error getting absolute path for %s: %w", line, err)
 		}
+		paths = append(paths, absPath)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading paths from stdin: %w", err)
 	}
+	return paths, nil
+}
 
+// imagesInDirectory lists the supported image files directly within dirPath.
+func imagesInDirectory(dirPath string) ([]string, error) {
 	files, err := os.ReadDir(dirPath)
 	if err != nil {
-		return fmt.Errorf("error reading directory %s: %w", dirPath, err)
+		return nil, fmt.Errorf("error reading directory %s: %w", dirPath, err)
 	}
-
-	var firstError error
-	filesProcessed := 0
-	tagsAppliedCount := 0
-
+	var paths []string
 	for _, file := range files {
 		if file.IsDir() {
 			continue
@@ -637,37 +3107,106 @@ func processFilesInDirectory(cmd *cobra.Command, dirPath string, tagsToProcess [
 		if !supportedImageExtensions[ext] {
 			continue
 		}
+		paths = append(paths, filepath.Join(dirPath, file.Name()))
+	}
+	return paths, nil
+}
+
+// batchTargets returns the images a batch command works on: the paths listed on
+// stdin when arg is "-", else the supported images directly within the
+// directory arg. source names them in messages; it is stdinSource for stdin.
+func batchTargets(cmd *cobra.Command, arg string) (paths []string, source string, err error) {
+	if arg == stdinArg {
+		paths, err = readPathList(cmd.InOrStdin())
+		return paths, stdinSource, err
+	}
+	absDirPath, err := filepath.Abs(arg)
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting absolute path for directory %s: %w", arg, err)
+	}
+	paths, err = imagesInDirectory(absDirPath)
+	return paths, absDirPath, err
+}
+
+// confirm asks the user to type "yes" and reports whether they did. Without a
+// terminal to ask on, because stdin carries a path list, it refuses.
+func confirm(cmd *cobra.Command, fromStdin bool, warning string) (bool, error) {
+	if fromStdin {
+		return false, fmt.Errorf("paths read from stdin leave no way to confirm; use --force")
+	}
+	cmd.Println(warning)
+	cmd.Print("This action cannot be undone for the actual files.\nAre you sure you want to continue? (yes/no): ")
+	var response string
+	_, err := fmt.Fscanln(cmd.InOrStdin(), &response)
+	if err != nil || strings.ToLower(strings.TrimSpace(response)) != "yes" {
+		if err != nil && err.Error() != "unexpected newline" && err.Error() != "EOF" { // Handle actual Scanln errors
+			cmd.PrintErrf("Error reading confirmation: %v\n", err)
+		}
+		cmd.Println("Operation cancelled by user.")
+		return false, nil
+	}
+	return true, nil
+}
+
+// processImages is a helper function to reduce duplication between batch-add and batch-remove
+func processImages(cmd *cobra.Command, paths []string, source string, tagsToProcess []string,
+	remove bool, isDryRun, needsConfirmation, isForced bool) error {
+	operationName, actionVerb := "add", "Added"
+	if remove {
+		operationName, actionVerb = "remove", "Removed"
+	}
+
+	if needsConfirmation && !isForced && !isDryRun {
+		ok, err := confirm(cmd, source == stdinSource, fmt.Sprintf("WARNING: You are about to %s %d tag(s) from %d supported image(s) in %s",
+			operationName, len(tagsToProcess), len(paths), source))
+		if !ok {
+			return err
+		}
+	}
 
-		filesProcessed++
-		filePath := filepath.Join(dirPath, file.Name())
-		for _, tag := range tagsToProcess {
-			if isDryRun {
+	tagsAppliedCount := 0
+	if isDryRun {
+		for _, filePath := range paths {
+			for _, tag := range tagsToProcess {
 				cmd.Printf("DRY RUN: Would %s tag '%s' for %s\n", operationName, tag, filePath)
 				tagsAppliedCount++ // Count as if it were applied for dry run summary
-			} else {
-				if err := tagAction(filePath, tag); err != nil {
-					cmd.PrintErrf("Error %sing tag '%s' for %s: %v\n", operationName, tag, filePath, err)
-					if firstError == nil {
-						firstError = err
-					}
-				} else {
-					cmd.Printf("%s tag '%s' for %s\n", actionVerb, tag, filePath)
-					tagsAppliedCount++
-				}
 			}
 		}
+		cmd.Printf("DRY RUN: Finished simulation of batch %s. Processed %d image files. %s %d tag instances in %s.\n", operationName, len(paths), actionVerb, tagsAppliedCount, source)
+		return nil
 	}
 
-	summaryPrefix := "Finished"
-	if isDryRun {
-		summaryPrefix = "DRY RUN: Finished simulation of"
+	progress := func(filePath, tag string, err error) {
+		if err != nil {
+			printItemError(cmd, filePath, tag, err, fmt.Sprintf("Error %sing tag '%s' for %s: %v", operationName, tag, filePath, err))
+		} else {
+			cmd.Printf("%s tag '%s' for %s\n", actionVerb, tag, filePath)
+		}
 	}
-	cmd.Printf("%s batch %s. Processed %d image files. %s %d tag instances in %s.\n", summaryPrefix, operationName, filesProcessed, actionVerb, tagsAppliedCount, dirPath)
-	return firstError
+	svc := service.New(tagDB)
+	var result service.BatchResult
+	if remove {
+		result = svc.RemoveTags(cmd.Context(), paths, tagsToProcess, progress)
+	} else {
+		result = svc.AddTags(cmd.Context(), paths, tagsToProcess, progress)
+	}
+	cmd.Printf("Finished batch %s. Processed %d image files. %s %d tag instances in %s.\n", operationName, len(paths), actionVerb, result.Done, source)
+	return batchError(result)
 }
+
 func main() {
-	if err := rootCmd.Execute(); err != nil {
-		// Cobra prints the error, so we just exit
-		os.Exit(1)
+	if wantsJSONErrors(os.Args[1:]) {
+		rootCmd.SilenceErrors = true
+		rootCmd.SilenceUsage = true
+	}
+	// Ctrl+C cancels the context of the running command, which stops at the next
+	// image with the work done so far kept.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	stop()
+	if err != nil && rootCmd.SilenceErrors {
+		writeJSONError(os.Stderr, cmd, err)
 	}
+	// Cobra prints the error otherwise, so we just exit
+	os.Exit(exitCode(err))
 }
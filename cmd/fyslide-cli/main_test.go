@@ -2,8 +2,18 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"fyslide/internal/metadata"
+	"fyslide/internal/paths"
+	"fyslide/internal/profile"
+	"fyslide/internal/service"
 	"fyslide/internal/tagging"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
 	"io"
 	"os"
 	"path/filepath"
@@ -47,7 +57,62 @@ func executeCommandC(root *cobra.Command, args ...string) (string, string, error
 	// though Cobra's flag parsing per Execute() should handle this.
 	dryRunFlag = false
 	forceFlag = false
-	// dbPathFlag is set via args like "--dbpath"
+	tagCorruptFlag = false
+	conflictPolicyFlag = "merge"
+	matchRootFlag = ""
+	syncIntervalFlag = 0
+	syncPreferFlag = "merge"
+	syncBatchSizeFlag = 100
+	syncStateFlag = ""
+	privateTagFlag = "private"
+	includePrivateFlag = false
+	profileFlag = ""
+	historyTagFlag = ""
+	historyPathFlag = ""
+	historySinceFlag = 0
+	historyLimitFlag = 0
+	historyCSVFlag = false
+	usageDaysFlag = 30
+	usageCSVFlag = false
+	usageJSONFlag = false
+	mapPrefixFlag = nil
+	recursiveFlag = false
+	onlyUntaggedFlag = false
+	listenFlag = "localhost:50051"
+	httpListenFlag = "localhost:8080"
+	webFlag = false
+	noAuthFlag = false
+	readOnlyFlag = false
+	rulesFlag = ""
+	namespacesFlag = "camera,year,month"
+	exportTagsFlag = nil
+	exportMaxSizeFlag = 0
+	exportQualityFlag = 90
+	exportFormatFlag = "keep"
+	stripMetadataFlag = false
+	autoLevelsFlag = false
+	libraryRootsFlag = nil
+	forceCleanFlag = false
+	maxMissingPctFlag = 20
+	statsJSONFlag = false
+	statsTopFlag = service.DefaultTopTags
+	statsSkipFilesFlag = false
+	missingTagFlag = ""
+	jsonErrorsFlag = false
+	allowProtectedFlag = false
+	dbOnlyFlag = false
+	filesOnlyFlag = false
+	minCountFlag = 0
+	confirmEachFlag = false
+	trashOlderThanFlag = 0
+	// pflag keeps the position of "--" from the previous parse; add and remove
+	// have no flags of their own, so a fresh flag set clears it.
+	addCmd.ResetFlags()
+	removeCmd.ResetFlags()
+	overwriteFlag = false
+	workersFlag = 0
+	configFlag = ""
+	dbPathFlag = "" // Set via args like "--dbpath"; tests without it use the default location
 
 	actualStdout := new(bytes.Buffer)
 	actualStderr := new(bytes.Buffer)
@@ -85,9 +150,9 @@ func TestListAllTagsCommand(t *testing.T) {
 		// Add some tags directly to the test DB for setup
 		tdb, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		require.NoError(t, tdb.AddTag(filepath.Join(t.TempDir(), "file1.jpg"), "tagA"))
-		require.NoError(t, tdb.AddTag(filepath.Join(t.TempDir(), "file2.png"), "tagB"))
-		require.NoError(t, tdb.AddTag(filepath.Join(t.TempDir(), "file3.gif"), "tagA")) // Duplicate tag on different file
+		require.NoError(t, tdb.AddTag(context.Background(), filepath.Join(t.TempDir(), "file1.jpg"), "tagA"))
+		require.NoError(t, tdb.AddTag(context.Background(), filepath.Join(t.TempDir(), "file2.png"), "tagB"))
+		require.NoError(t, tdb.AddTag(context.Background(), filepath.Join(t.TempDir(), "file3.gif"), "tagA")) // Duplicate tag on different file
 		tdb.Close()
 
 		stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbPath, "list-all-tags")
@@ -127,7 +192,7 @@ func TestAddCommand(t *testing.T) {
 		// Verify in DB
 		tdb, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tags, _ := tdb.GetTags(absDummyFilePath)
+		tags, _ := tdb.GetTags(context.Background(), absDummyFilePath)
 		tdb.Close()
 		assert.Contains(t, tags, "newTag1")
 	})
@@ -136,8 +201,8 @@ func TestAddCommand(t *testing.T) {
 		// Ensure the file is clean of these specific tags for this subtest
 		tdbClean, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tdbClean.RemoveTag(absDummyFilePath, "multiTagA")
-		tdbClean.RemoveTag(absDummyFilePath, "multiTagB")
+		tdbClean.RemoveTag(context.Background(), absDummyFilePath, "multiTagA")
+		tdbClean.RemoveTag(context.Background(), absDummyFilePath, "multiTagB")
 		tdbClean.Close()
 
 		stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbPath, "add", dummyFilePath, "multiTagA", "multiTagB")
@@ -148,7 +213,7 @@ func TestAddCommand(t *testing.T) {
 		// Verify in DB
 		tdb, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tags, _ := tdb.GetTags(absDummyFilePath)
+		tags, _ := tdb.GetTags(context.Background(), absDummyFilePath)
 		tdb.Close()
 		assert.Contains(t, tags, "multiTagA")
 		assert.Contains(t, tags, "multiTagB")
@@ -165,7 +230,7 @@ func TestAddCommand(t *testing.T) {
 
 		tdb, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tags, _ := tdb.GetTags(absNonExistentPath)
+		tags, _ := tdb.GetTags(context.Background(), absNonExistentPath)
 		tdb.Close()
 		assert.Contains(t, tags, "ghostTag")
 	})
@@ -190,14 +255,14 @@ func TestRemoveCommand(t *testing.T) {
 		tdb, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
 		// Clear all tags for this file first
-		currentTags, _ := tdb.GetTags(absDummyFilePath)
+		currentTags, _ := tdb.GetTags(context.Background(), absDummyFilePath)
 		for _, tag := range currentTags {
-			tdb.RemoveTag(absDummyFilePath, tag)
+			tdb.RemoveTag(context.Background(), absDummyFilePath, tag)
 		}
 		// Add specific tags for the test
-		require.NoError(t, tdb.AddTag(absDummyFilePath, "tagToRemove1"))
-		require.NoError(t, tdb.AddTag(absDummyFilePath, "tagToRemove2"))
-		require.NoError(t, tdb.AddTag(absDummyFilePath, "tagToKeep"))
+		require.NoError(t, tdb.AddTag(context.Background(), absDummyFilePath, "tagToRemove1"))
+		require.NoError(t, tdb.AddTag(context.Background(), absDummyFilePath, "tagToRemove2"))
+		require.NoError(t, tdb.AddTag(context.Background(), absDummyFilePath, "tagToKeep"))
 		tdb.Close()
 	}
 
@@ -209,7 +274,7 @@ func TestRemoveCommand(t *testing.T) {
 
 		tdb, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tags, _ := tdb.GetTags(absDummyFilePath)
+		tags, _ := tdb.GetTags(context.Background(), absDummyFilePath)
 		tdb.Close()
 		assert.NotContains(t, tags, "tagToRemove1")
 		assert.Contains(t, tags, "tagToRemove2")
@@ -225,7 +290,7 @@ func TestRemoveCommand(t *testing.T) {
 
 		tdb, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tags, _ := tdb.GetTags(absDummyFilePath)
+		tags, _ := tdb.GetTags(context.Background(), absDummyFilePath)
 		tdb.Close()
 		assert.NotContains(t, tags, "tagToRemove1")
 		assert.NotContains(t, tags, "tagToKeep")
@@ -241,7 +306,7 @@ func TestRemoveCommand(t *testing.T) {
 
 		tdb, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tags, _ := tdb.GetTags(absDummyFilePath)
+		tags, _ := tdb.GetTags(context.Background(), absDummyFilePath)
 		tdb.Close()
 		assert.Contains(t, tags, "tagToRemove1") // Ensure other tags are still there
 	})
@@ -265,9 +330,9 @@ func TestListCommand(t *testing.T) {
 		// Ensure no tags for this file
 		tdb, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		currentTags, _ := tdb.GetTags(absDummyFilePath)
+		currentTags, _ := tdb.GetTags(context.Background(), absDummyFilePath)
 		for _, tag := range currentTags {
-			tdb.RemoveTag(absDummyFilePath, tag)
+			tdb.RemoveTag(context.Background(), absDummyFilePath, tag)
 		}
 		tdb.Close()
 
@@ -279,8 +344,8 @@ func TestListCommand(t *testing.T) {
 	t.Run("list with tags", func(t *testing.T) {
 		tdb, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		require.NoError(t, tdb.AddTag(absDummyFilePath, "listTag1"))
-		require.NoError(t, tdb.AddTag(absDummyFilePath, "listTag2"))
+		require.NoError(t, tdb.AddTag(context.Background(), absDummyFilePath, "listTag1"))
+		require.NoError(t, tdb.AddTag(context.Background(), absDummyFilePath, "listTag2"))
 		tdb.Close()
 
 		stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbPath, "list", dummyFilePath)
@@ -311,9 +376,9 @@ func TestFindByTagCommand(t *testing.T) {
 	}
 	tdb, err := tagging.NewTagDB(dbPath, testLogger)
 	require.NoError(t, err)
-	require.NoError(t, tdb.AddTag(absFile1, "findThisTag"))
-	require.NoError(t, tdb.AddTag(absFile2, "findThisTag"))
-	require.NoError(t, tdb.AddTag(absFile3, "anotherTag"))
+	require.NoError(t, tdb.AddTag(context.Background(), absFile1, "findThisTag"))
+	require.NoError(t, tdb.AddTag(context.Background(), absFile2, "findThisTag"))
+	require.NoError(t, tdb.AddTag(context.Background(), absFile3, "anotherTag"))
 	tdb.Close()
 
 	t.Run("find existing tag", func(t *testing.T) {
@@ -368,8 +433,8 @@ func TestBatchAddCommand(t *testing.T) {
 
 		tdb, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tags1, _ := tdb.GetTags(absImg1Path)
-		tags2, _ := tdb.GetTags(absImg2Path)
+		tags1, _ := tdb.GetTags(context.Background(), absImg1Path)
+		tags2, _ := tdb.GetTags(context.Background(), absImg2Path)
 		tdb.Close()
 		assert.ElementsMatch(t, []string{"batchTag1", "batchTag2"}, tags1)
 		assert.ElementsMatch(t, []string{"batchTag1", "batchTag2"}, tags2)
@@ -379,10 +444,10 @@ func TestBatchAddCommand(t *testing.T) {
 		// Clear previous tags for a clean dry-run test
 		tdbClear, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tdbClear.RemoveTag(absImg1Path, "batchTag1")
-		tdbClear.RemoveTag(absImg1Path, "batchTag2")
-		tdbClear.RemoveTag(absImg2Path, "batchTag1")
-		tdbClear.RemoveTag(absImg2Path, "batchTag2")
+		tdbClear.RemoveTag(context.Background(), absImg1Path, "batchTag1")
+		tdbClear.RemoveTag(context.Background(), absImg1Path, "batchTag2")
+		tdbClear.RemoveTag(context.Background(), absImg2Path, "batchTag1")
+		tdbClear.RemoveTag(context.Background(), absImg2Path, "batchTag2")
 		tdbClear.Close()
 
 		stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbPath, "batch-add", "--dry-run", batchDir, "dryTag1")
@@ -394,8 +459,8 @@ func TestBatchAddCommand(t *testing.T) {
 
 		tdb, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tags1, _ := tdb.GetTags(absImg1Path)
-		tags2, _ := tdb.GetTags(absImg2Path)
+		tags1, _ := tdb.GetTags(context.Background(), absImg1Path)
+		tags2, _ := tdb.GetTags(context.Background(), absImg2Path)
 		tdb.Close()
 		assert.Empty(t, tags1)
 		assert.Empty(t, tags2)
@@ -424,19 +489,19 @@ func TestBatchRemoveCommand(t *testing.T) {
 		tdb, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
 		// Clear existing tags on these files
-		tags1, _ := tdb.GetTags(absImg1Path)
+		tags1, _ := tdb.GetTags(context.Background(), absImg1Path)
 		for _, tg := range tags1 {
-			tdb.RemoveTag(absImg1Path, tg)
+			tdb.RemoveTag(context.Background(), absImg1Path, tg)
 		}
-		tags2, _ := tdb.GetTags(absImg2Path)
+		tags2, _ := tdb.GetTags(context.Background(), absImg2Path)
 		for _, tg := range tags2 {
-			tdb.RemoveTag(absImg2Path, tg)
+			tdb.RemoveTag(context.Background(), absImg2Path, tg)
 		}
 		// Add fresh tags
-		require.NoError(t, tdb.AddTag(absImg1Path, "commonTag"))
-		require.NoError(t, tdb.AddTag(absImg1Path, "uniqueTag1"))
-		require.NoError(t, tdb.AddTag(absImg2Path, "commonTag"))
-		require.NoError(t, tdb.AddTag(absImg2Path, "uniqueTag2"))
+		require.NoError(t, tdb.AddTag(context.Background(), absImg1Path, "commonTag"))
+		require.NoError(t, tdb.AddTag(context.Background(), absImg1Path, "uniqueTag1"))
+		require.NoError(t, tdb.AddTag(context.Background(), absImg2Path, "commonTag"))
+		require.NoError(t, tdb.AddTag(context.Background(), absImg2Path, "uniqueTag2"))
 		tdb.Close()
 	}
 
@@ -451,8 +516,8 @@ func TestBatchRemoveCommand(t *testing.T) {
 
 		tdbVerify, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tags1, _ := tdbVerify.GetTags(absImg1Path)
-		tags2, _ := tdbVerify.GetTags(absImg2Path)
+		tags1, _ := tdbVerify.GetTags(context.Background(), absImg1Path)
+		tags2, _ := tdbVerify.GetTags(context.Background(), absImg2Path)
 		tdbVerify.Close()
 		assert.NotContains(t, tags1, "commonTag")
 		assert.Contains(t, tags1, "uniqueTag1")
@@ -473,8 +538,8 @@ func TestBatchRemoveCommand(t *testing.T) {
 
 		tdbVerify, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tags1, _ := tdbVerify.GetTags(absImg1Path)
-		tags2, _ := tdbVerify.GetTags(absImg2Path)
+		tags1, _ := tdbVerify.GetTags(context.Background(), absImg1Path)
+		tags2, _ := tdbVerify.GetTags(context.Background(), absImg2Path)
 		tdbVerify.Close()
 		assert.Contains(t, tags1, "commonTag")
 		assert.Contains(t, tags1, "uniqueTag1")
@@ -500,8 +565,8 @@ func TestBatchRemoveCommand(t *testing.T) {
 
 		tdbVerify, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tags1, _ := tdbVerify.GetTags(absImg1Path)
-		tags2, _ := tdbVerify.GetTags(absImg2Path)
+		tags1, _ := tdbVerify.GetTags(context.Background(), absImg1Path)
+		tags2, _ := tdbVerify.GetTags(context.Background(), absImg2Path)
 		tdbVerify.Close()
 		assert.Contains(t, tags1, "commonTag")
 		assert.Contains(t, tags2, "commonTag")
@@ -527,8 +592,8 @@ func TestBatchRemoveCommand(t *testing.T) {
 
 		tdbVerify, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tags1, _ := tdbVerify.GetTags(absImg1Path)
-		tags2, _ := tdbVerify.GetTags(absImg2Path)
+		tags1, _ := tdbVerify.GetTags(context.Background(), absImg1Path)
+		tags2, _ := tdbVerify.GetTags(context.Background(), absImg2Path)
 		tdbVerify.Close()
 		assert.NotContains(t, tags1, "commonTag")
 		assert.NotContains(t, tags2, "commonTag")
@@ -560,11 +625,11 @@ func TestCleanCommand(t *testing.T) {
 	tdb, err := tagging.NewTagDB(dbPath, testLogger)
 	require.NoError(t, err)
 	// Tags for a real file
-	require.NoError(t, tdb.AddTag(absRealFile1, "tagForRealFile"))
-	require.NoError(t, tdb.AddTag(absRealFile1, "commonTag"))
+	require.NoError(t, tdb.AddTag(context.Background(), absRealFile1, "tagForRealFile"))
+	require.NoError(t, tdb.AddTag(context.Background(), absRealFile1, "commonTag"))
 	// Tags for a file that will be "non-existent"
-	require.NoError(t, tdb.AddTag(absFakeFile1, "tagForFakeFile"))
-	require.NoError(t, tdb.AddTag(absFakeFile1, "commonTag"))
+	require.NoError(t, tdb.AddTag(context.Background(), absFakeFile1, "tagForFakeFile"))
+	require.NoError(t, tdb.AddTag(context.Background(), absFakeFile1, "commonTag"))
 	// Create an orphaned tag (add it, then ensure no files reference it)
 	// For this test, we'll add it to fakeFile1, then fakeFile1 is "deleted"
 	// The clean command should then find "tagForFakeFile" and "commonTag" (if only on fakeFile1) as orphaned
@@ -603,11 +668,11 @@ func TestCleanCommand(t *testing.T) {
 		// Verify DB is unchanged
 		tdbVerify, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tagsReal, _ := tdbVerify.GetTags(absRealFile1)
+		tagsReal, _ := tdbVerify.GetTags(context.Background(), absRealFile1)
 		assert.Contains(t, tagsReal, "tagForRealFile")
-		tagsFake, _ := tdbVerify.GetTags(absFakeFile1)
+		tagsFake, _ := tdbVerify.GetTags(context.Background(), absFakeFile1)
 		assert.Contains(t, tagsFake, "tagForFakeFile")
-		allTags, _ := tdbVerify.GetAllTags()
+		allTags, _ := tdbVerify.GetAllTags(context.Background())
 		foundOrphaned := false
 
 		for _, ti := range allTags {
@@ -625,16 +690,16 @@ func TestCleanCommand(t *testing.T) {
 		tdbSetup, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
 		// Clear and re-add
-		tdbSetup.RemoveAllTagsForImage(absRealFile1)
-		tdbSetup.RemoveAllTagsForImage(absFakeFile1)
-		tdbSetup.DeleteOrphanedTagKey("orphanedtagdirectly") // Clean from previous test if any
-		tdbSetup.DeleteOrphanedTagKey("commonTag")
-		tdbSetup.DeleteOrphanedTagKey("tagForFakeFile")
+		tdbSetup.RemoveAllTagsForImage(context.Background(), absRealFile1)
+		tdbSetup.RemoveAllTagsForImage(context.Background(), absFakeFile1)
+		tdbSetup.DeleteOrphanedTagKey(context.Background(), "orphanedtagdirectly") // Clean from previous test if any
+		tdbSetup.DeleteOrphanedTagKey(context.Background(), "commonTag")
+		tdbSetup.DeleteOrphanedTagKey(context.Background(), "tagForFakeFile")
 
-		require.NoError(t, tdbSetup.AddTag(absRealFile1, "tagForRealFile"))
-		require.NoError(t, tdbSetup.AddTag(absRealFile1, "commontag"))      // Use lowercase for consistency
-		require.NoError(t, tdbSetup.AddTag(absFakeFile1, "tagForFakeFile")) // For non-existent file
-		require.NoError(t, tdbSetup.AddTag(absFakeFile1, "commontag"))      // For non-existent file
+		require.NoError(t, tdbSetup.AddTag(context.Background(), absRealFile1, "tagForRealFile"))
+		require.NoError(t, tdbSetup.AddTag(context.Background(), absRealFile1, "commontag"))      // Use lowercase for consistency
+		require.NoError(t, tdbSetup.AddTag(context.Background(), absFakeFile1, "tagForFakeFile")) // For non-existent file
+		require.NoError(t, tdbSetup.AddTag(context.Background(), absFakeFile1, "commontag"))      // For non-existent file
 
 		boltDBSetup, err := bolt.Open(dbPath, 0600, nil) // Open bolt DB directly for setup
 		require.NoError(t, err)
@@ -662,13 +727,13 @@ func TestCleanCommand(t *testing.T) {
 		// Verify DB state
 		tdbVerify, err := tagging.NewTagDB(dbPath, testLogger)
 		require.NoError(t, err)
-		tagsReal, _ := tdbVerify.GetTags(absRealFile1)
+		tagsReal, _ := tdbVerify.GetTags(context.Background(), absRealFile1)
 		assert.ElementsMatch(t, []string{"commontag", "tagforrealfile"}, tagsReal, "Real file should retain its tags")
 
-		tagsFake, _ := tdbVerify.GetTags(absFakeFile1)
+		tagsFake, _ := tdbVerify.GetTags(context.Background(), absFakeFile1)
 		assert.Empty(t, tagsFake, "Tags for fake file should be gone")
 
-		allTags, _ := tdbVerify.GetAllTags()
+		allTags, _ := tdbVerify.GetAllTags(context.Background())
 		tagNames := []string{}
 		for _, ti := range allTags {
 			tagNames = append(tagNames, ti.Name)
@@ -681,3 +746,846 @@ func TestCleanCommand(t *testing.T) {
 		tdbVerify.Close()
 	})
 }
+
+func TestCleanCommandSafetyThreshold(t *testing.T) {
+	dbDir := t.TempDir()
+	testDir := t.TempDir()
+	realPath := filepath.Join(testDir, "real.jpg")
+	require.NoError(t, os.WriteFile(realPath, []byte("real"), 0644))
+
+	tdb, err := tagging.NewTagDB(dbDir, func(message string) { t.Logf("TestDBLogger: %s", message) })
+	require.NoError(t, err)
+	require.NoError(t, tdb.AddTag(context.Background(), realPath, "trip"))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, tdb.AddTag(context.Background(), filepath.Join(testDir, fmt.Sprintf("gone%d.jpg", i)), "trip"))
+	}
+	tdb.Close()
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "clean")
+	require.ErrorIs(t, err, service.ErrTooManyMissing, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stderr, "10 of 11 image files (91%) appear missing")
+	assert.Contains(t, stderr, filepath.Join(testDir, "gone0.jpg"))
+	tagDB.Close() // PersistentPostRun doesn't run after a failed command
+
+	stdout, stderr, err = executeCommandC(rootCmd, "--dbpath", dbDir, "clean", "--force-clean")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Non-existent image file entries processed: 10")
+
+	tdb, err = tagging.NewTagDB(dbDir, func(message string) { t.Logf("TestDBLogger: %s", message) })
+	require.NoError(t, err)
+	defer tdb.Close()
+	images, _ := tdb.GetImages(context.Background(), "trip")
+	assert.Equal(t, []string{realPath}, images)
+}
+
+func TestConfigFile(t *testing.T) {
+	dbDir := t.TempDir()
+	imgPath := filepath.Join(t.TempDir(), "a.jpg")
+	require.NoError(t, os.WriteFile(imgPath, []byte("img"), 0644))
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("db:\n  path: "+dbDir+"\n"), 0644))
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--config", configPath, "add", imgPath, "trip")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	_, err = os.Stat(filepath.Join(dbDir, tagging.DBFileName))
+	require.NoError(t, err, "the database should be in the configured directory")
+
+	// The environment overrides the file, and --dbpath overrides both
+	envDir := t.TempDir()
+	t.Setenv(paths.EnvDBPath, envDir)
+	stdout, stderr, err = executeCommandC(rootCmd, "--config", configPath, "list", imgPath)
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.NotContains(t, stdout, "trip")
+	stdout, stderr, err = executeCommandC(rootCmd, "--config", configPath, "--dbpath", dbDir, "list", imgPath)
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "trip")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("db:\n  backend: sqlite\n"), 0644))
+	_, _, err = executeCommandC(rootCmd, "--config", configPath, "list", imgPath)
+	assert.ErrorContains(t, err, "unsupported database backend")
+}
+
+func TestStatsCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	imgDir := t.TempDir()
+	a, b := filepath.Join(imgDir, "a.jpg"), filepath.Join(imgDir, "b.jpg")
+	require.NoError(t, os.WriteFile(a, []byte("img"), 0644))
+	for _, args := range [][]string{{"add", a, "trip", "beach"}, {"add", b, "trip"}} {
+		_, stderr, err := executeCommandC(rootCmd, append([]string{"--dbpath", dbDir}, args...)...)
+		require.NoError(t, err, stderr)
+	}
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "stats")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Tagged images: 2")
+	assert.Contains(t, stdout, "Tags: 2 (3 taggings)")
+	assert.Contains(t, stdout, "Missing image files: 1")
+	assert.Contains(t, stdout, "       2  trip")
+	assert.Contains(t, stdout, "       2  "+imgDir)
+
+	stdout, stderr, err = executeCommandC(rootCmd, "--dbpath", dbDir, "stats", "--json", "--top", "1", "--skip-files")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	var stats service.Stats
+	require.NoError(t, json.Unmarshal([]byte(stdout), &stats), stdout)
+	assert.Equal(t, 2, stats.TaggedImages)
+	assert.Equal(t, []service.TagCount{{Tag: "trip", Images: 2}}, stats.TopTags)
+	assert.False(t, stats.FilesChecked)
+	assert.Equal(t, filepath.Join(dbDir, tagging.DBFileName), stats.DBPath)
+	assert.Positive(t, stats.DBSize)
+}
+
+func TestTagsForDirCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	imgDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(imgDir, "day2"), 0755))
+	a, b, c := filepath.Join(imgDir, "a.jpg"), filepath.Join(imgDir, "b.png"), filepath.Join(imgDir, "day2", "c.jpg")
+	for _, path := range []string{a, b, c} {
+		require.NoError(t, os.WriteFile(path, []byte("img"), 0644))
+	}
+	for _, args := range [][]string{{"add", a, "Wedding", "alice"}, {"add", c, "wedding"}} {
+		_, stderr, err := executeCommandC(rootCmd, append([]string{"--dbpath", dbDir}, args...)...)
+		require.NoError(t, err, stderr)
+	}
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "tags-for-dir", imgDir, "--missing", "Alice")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "3 image(s) under "+imgDir+", 1 untagged.")
+	assert.Contains(t, stdout, "       2  wedding\n       1  alice\n")
+	assert.Contains(t, stdout, "Untagged images:\n  "+b+"\n")
+	assert.Contains(t, stdout, "2 image(s) missing tag 'alice':")
+	assert.Contains(t, stdout, "  "+c+"\n")
+}
+
+func TestEditCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	imgPath := filepath.Join(t.TempDir(), "a.jpg")
+	other := filepath.Join(t.TempDir(), "b.jpg")
+	for _, args := range [][]string{{"add", imgPath, "beach", "trip"}, {"add", other, "sunset", "summer"}} {
+		_, stderr, err := executeCommandC(rootCmd, append([]string{"--dbpath", dbDir}, args...)...)
+		require.NoError(t, err, stderr)
+	}
+	edit := func(input string, args ...string) string {
+		rootCmd.SetIn(strings.NewReader(input))
+		defer rootCmd.SetIn(nil)
+		stdout, stderr, err := executeCommandC(rootCmd, append([]string{"--dbpath", dbDir, "edit"}, args...)...)
+		require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+		return stdout
+	}
+	tagsOf := func() []string {
+		stdout, _, err := executeCommandC(rootCmd, "--dbpath", dbDir, "list", imgPath)
+		require.NoError(t, err)
+		_, list, _ := strings.Cut(strings.TrimSpace(stdout), ": ")
+		return strings.Split(list, ", ")
+	}
+
+	// Aborting, explicitly or by end of input, changes nothing
+	stdout := edit("1\nq\n", imgPath)
+	assert.Contains(t, stdout, "  1. beach\n  2. trip\n")
+	assert.Contains(t, stdout, "Aborted")
+	edit("1\n", imgPath)
+	assert.Contains(t, tagsOf(), "beach")
+
+	// Remove by number, add plain, completed and numeric tags, then save
+	stdout = edit(imgPath + "\n?s\nsu*\nsun*\nParty\n+1\n1\nw\n")
+	assert.Contains(t, stdout, "summer  sunset\n", "?s lists the existing tags")
+	assert.Contains(t, stdout, "Removed tag 'beach'")
+	assert.Contains(t, stdout, "Added tag 'sunset'")
+	assert.Contains(t, stdout, "Added tag 'party'")
+	assert.Contains(t, stdout, "Added tag '1'")
+	tags := tagsOf()
+	assert.NotContains(t, tags, "beach")
+	assert.NotContains(t, tags, "summer", "an ambiguous completion adds nothing")
+	for _, tag := range []string{"trip", "sunset", "party", "1"} {
+		assert.Contains(t, tags, tag)
+	}
+}
+
+func TestStdinPathLists(t *testing.T) {
+	dbDir := t.TempDir()
+	imgDir := t.TempDir()
+	var imgs []string
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		path := filepath.Join(imgDir, name)
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+		imgs = append(imgs, path)
+	}
+	withStdin := func(input string, args ...string) (string, string, error) {
+		rootCmd.SetIn(strings.NewReader(input))
+		defer rootCmd.SetIn(nil)
+		stdout, stderr, err := executeCommandC(rootCmd, append([]string{"--dbpath", dbDir}, args...)...)
+		if err != nil && tagDB != nil {
+			tagDB.Close() // PersistentPostRun is skipped after an error
+		}
+		return stdout, stderr, err
+	}
+	list := imgs[0] + "\n\n" + imgs[1] + "\n"
+
+	stdout, stderr, err := withStdin(list, "batch-add", "-", "Beach")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Processed 2 image files. Added 2 tag instances in the paths read from stdin")
+
+	_, _, err = withStdin(list, "batch-remove", "-", "beach")
+	assert.ErrorContains(t, err, "--force", "stdin can't also answer the confirmation")
+	stdout, stderr, err = withStdin(imgs[1]+"\n", "batch-remove", "--force", "-", "beach")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Removed tag 'beach' for "+imgs[1])
+
+	outDir := t.TempDir()
+	stdout, stderr, err = withStdin(imgs[0]+"\n"+imgs[2]+"\n", "export-files", outDir, "-")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	for _, name := range []string{"a.png", "c.png"} {
+		_, err := os.Stat(filepath.Join(outDir, name))
+		assert.NoError(t, err, "%s should be exported", name)
+	}
+	_, err = os.Stat(filepath.Join(outDir, "b.png"))
+	assert.True(t, os.IsNotExist(err), "only the listed images are exported")
+
+	stdout, stderr, err = withStdin(imgs[0]+"\n", "delete", "--force", "-")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Deleted 1 of 1 image(s).")
+	_, err = os.Stat(imgs[0])
+	assert.True(t, os.IsNotExist(err))
+	stdout, _, err = withStdin("", "find-by-tag", "beach")
+	require.NoError(t, err)
+	assert.NotContains(t, stdout, imgs[0], "the tags of a deleted image are removed")
+
+	stdout, stderr, err = withStdin("no\n", "delete", imgs[2])
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Operation cancelled by user.")
+	_, err = os.Stat(imgs[2])
+	assert.NoError(t, err)
+
+	_, _, err = withStdin("", "add", imgs[2], "keep")
+	require.NoError(t, err)
+	stdout, stderr, err = withStdin("", "delete", "--force", imgs[2])
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Skipping protected image")
+	_, err = os.Stat(imgs[2])
+	assert.NoError(t, err, "an image tagged keep is protected")
+	stdout, stderr, err = withStdin("", "delete", "--force", "--allow-protected", imgs[2])
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Deleted 1 of 1 image(s).")
+	_, err = os.Stat(imgs[2])
+	assert.True(t, os.IsNotExist(err))
+
+	stdout, _, err = withStdin("", "trash", "list")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, imgs[2], "deleted images are kept in the trash")
+	stdout, stderr, err = withStdin("", "trash", "restore", imgs[2])
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Restored 1 of 1 image(s).")
+	_, err = os.Stat(imgs[2])
+	assert.NoError(t, err, "a restored image is back in place")
+	stdout, _, err = withStdin("", "list", imgs[2])
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "keep", "a restored image gets its tags back")
+	stdout, stderr, err = withStdin("", "trash", "empty", "--force")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Deleted 1 image(s).")
+	stdout, _, err = withStdin("", "trash", "list")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "The trash is empty.")
+}
+
+func TestDeleteVariants(t *testing.T) {
+	dbDir := t.TempDir()
+	imgDir := t.TempDir()
+	var imgs []string
+	for _, name := range []string{"a.jpg", "b.jpg", "c.jpg"} {
+		path := filepath.Join(imgDir, name)
+		require.NoError(t, os.WriteFile(path, []byte("img"), 0644))
+		imgs = append(imgs, path)
+	}
+	run := func(input string, args ...string) (string, string, error) {
+		rootCmd.SetIn(strings.NewReader(input))
+		defer rootCmd.SetIn(nil)
+		stdout, stderr, err := executeCommandC(rootCmd, append([]string{"--dbpath", dbDir}, args...)...)
+		if err != nil && tagDB != nil {
+			tagDB.Close() // PersistentPostRun is skipped after an error
+		}
+		return stdout, stderr, err
+	}
+	for i, tags := range [][]string{{"sea", "trip"}, {"sea"}, {"keep", "sea"}} {
+		_, _, err := run("", append([]string{"add", imgs[i], "--"}, tags...)...)
+		require.NoError(t, err)
+	}
+
+	stdout, stderr, err := run("", "delete", "--dry-run", "--min-count", "2", imgs[0], imgs[1], imgs[2])
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Skipping 1 image(s) with fewer than 2 tag(s).")
+	assert.Contains(t, stdout, "Skipping protected image "+imgs[2])
+	assert.Contains(t, stdout, "DRY RUN: Would delete "+imgs[0]+" (tags: sea, trip)")
+	assert.NotContains(t, stdout, "Would delete "+imgs[1])
+
+	stdout, stderr, err = run("", "delete", "--db-only", "--force", imgs[2])
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Removed the tags of "+imgs[2], "--db-only ignores protection as the file stays")
+	_, err = os.Stat(imgs[2])
+	assert.NoError(t, err, "--db-only keeps the file")
+	stdout, _, err = run("", "list", imgs[2])
+	require.NoError(t, err)
+	assert.NotContains(t, stdout, "sea")
+
+	stdout, stderr, err = run("no\nyes\n", "delete", "--files-only", "--confirm-each", imgs[0], imgs[1])
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Deleted 1 of 1 image(s).")
+	_, err = os.Stat(imgs[0])
+	assert.NoError(t, err, "the declined image is kept")
+	_, err = os.Stat(imgs[1])
+	assert.True(t, os.IsNotExist(err), "the confirmed image is deleted")
+	stdout, _, err = run("", "find-by-tag", "sea")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, imgs[1], "--files-only keeps the tags")
+
+	_, _, err = run("", "delete", "--db-only", "--files-only", imgs[0])
+	assert.ErrorContains(t, err, "exclude each other")
+}
+
+func TestAddRemoveWithPatterns(t *testing.T) {
+	dbDir := t.TempDir()
+	imgDir := t.TempDir()
+	var imgs []string
+	for _, name := range []string{"2024/a.jpg", "2024/trip/b.jpg", "2024/trip/c.png", "other.jpg"} {
+		path := filepath.Join(imgDir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("img"), 0644))
+		imgs = append(imgs, path)
+	}
+	tagsOf := func(path string) []string {
+		stdout, _, err := executeCommandC(rootCmd, "--dbpath", dbDir, "list", path)
+		require.NoError(t, err)
+		_, list, _ := strings.Cut(strings.TrimSpace(stdout), ": ")
+		return strings.Split(list, ", ")
+	}
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "add", filepath.Join(imgDir, "2024", "**", "*.jpg"), "Beach", "sea")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Processed 2 image(s): 2 succeeded, 0 failed.")
+	assert.Equal(t, []string{"beach", "sea"}, tagsOf(imgs[0]))
+	assert.Equal(t, []string{"beach", "sea"}, tagsOf(imgs[1]))
+	assert.NotContains(t, tagsOf(imgs[2]), "beach")
+
+	// Several existing files, then tags; and an explicit split with --
+	stdout, stderr, err = executeCommandC(rootCmd, "--dbpath", dbDir, "add", imgs[2], imgs[3], "sunny")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, tagsOf(imgs[3]), "sunny")
+	_, _, err = executeCommandC(rootCmd, "--dbpath", dbDir, "remove", imgs[0], imgs[1], "--", "sea")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"beach"}, tagsOf(imgs[1]))
+
+	// A pattern matching nothing fails the command but the other paths are tagged
+	stdout, stderr, err = executeCommandC(rootCmd, "--dbpath", dbDir, "add", filepath.Join(imgDir, "*.gif"), imgs[3], "--", "late")
+	assert.Error(t, err)
+	tagDB.Close() // PersistentPostRun is skipped after an error
+	assert.Contains(t, stderr, "no images match")
+	assert.Contains(t, tagsOf(imgs[3]), "late")
+}
+
+func TestExitCodes(t *testing.T) {
+	dbDir := t.TempDir()
+	imgPath := filepath.Join(t.TempDir(), "a.jpg")
+	run := func(args ...string) (string, error) {
+		_, stderr, err := executeCommandC(rootCmd, args...)
+		if err != nil && tagDB != nil {
+			tagDB.Close() // PersistentPostRun is skipped after an error
+		}
+		return stderr, err
+	}
+
+	_, err := run("--dbpath", dbDir, "add", imgPath, "ok")
+	assert.Equal(t, exitOK, exitCode(err))
+	_, err = run("--dbpath", dbDir, "add", imgPath)
+	assert.Equal(t, exitUsage, exitCode(err), "missing arguments")
+	_, err = run("--dbpath", dbDir, "list", imgPath, "--no-such-flag")
+	assert.Equal(t, exitUsage, exitCode(err), "unknown flag")
+	_, err = run("--dbpath", dbDir, "no-such-command")
+	assert.Equal(t, exitUsage, exitCode(err), "unknown command")
+	_, err = run("--dbpath", dbDir, "add", filepath.Join(t.TempDir(), "*.jpg"), imgPath, "--", "x")
+	assert.Equal(t, exitFailure, exitCode(err), "partial failure")
+
+	interrupted, interrupt := context.WithCancel(context.Background())
+	interrupt()
+	addCmd.SetContext(interrupted)
+	_, err = run("--dbpath", dbDir, "add", imgPath, "more")
+	addCmd.SetContext(nil)
+	assert.Equal(t, exitInterrupted, exitCode(err), "interrupted")
+
+	notADir := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(notADir, nil, 0644))
+	_, err = run("--dbpath", notADir, "list", imgPath)
+	assert.Equal(t, exitDB, exitCode(err), "database that can't be opened")
+
+	var buf bytes.Buffer
+	writeJSONError(&buf, addCmd, err)
+	var report jsonError
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report), buf.String())
+	assert.Equal(t, jsonError{Command: "fyslide-cli add", Error: err.Error(), Kind: "database", Code: exitDB}, report)
+
+	// Failed images of a batch operation are reported one JSON object per line
+	stderr, err := run("--dbpath", dbDir, "--json-errors", "add", filepath.Join(t.TempDir(), "*.jpg"), imgPath, "--", "x")
+	assert.Error(t, err)
+	assert.NotContains(t, stderr, "{", "a failing pattern has no image to report")
+	stderr, err = run("--dbpath", dbDir, "--json-errors", "remove", imgPath, "")
+	assert.Error(t, err)
+	var item jsonItemError
+	firstLine, _, _ := strings.Cut(stderr, "\n") // Cobra's own report follows, as main isn't involved
+	require.NoError(t, json.Unmarshal([]byte(firstLine), &item), stderr)
+	assert.Equal(t, imgPath, item.Path)
+	assert.Equal(t, "fyslide-cli remove", item.Command)
+	assert.NotEmpty(t, item.Error)
+
+	assert.True(t, wantsJSONErrors([]string{"add", "--json-errors", "a.jpg", "x"}))
+	assert.False(t, wantsJSONErrors([]string{"add", "a.jpg", "--", "--json-errors"}))
+}
+
+func TestPathsCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+	confDir := filepath.Join(home, "conf")
+	t.Setenv(paths.EnvConfigDir, confDir)
+	dbDir := filepath.Join(home, "db")
+	t.Setenv(paths.EnvDBPath, dbDir)
+
+	stdout, stderr, err := executeCommandC(rootCmd, "paths")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Config file:         "+filepath.Join(confDir, "config.yaml"))
+	assert.Contains(t, stdout, "Tag database:        "+filepath.Join(dbDir, tagging.DBFileName))
+	assert.Contains(t, stdout, "Thumbnail cache:     "+filepath.Join(home, "cache", "fyslide", "thumbnails"))
+	_, err = os.Stat(dbDir)
+	assert.True(t, os.IsNotExist(err), "paths must not create the database")
+
+	stdout, stderr, err = executeCommandC(rootCmd, "--profile", "work", "paths")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Tag database:        "+filepath.Join(confDir, "profiles", "work", tagging.DBFileName))
+	assert.Contains(t, stdout, "Metadata sync state: "+filepath.Join(confDir, "profiles", "work", "metadata_sync.json"))
+
+	// Without a relocated config directory, named profiles are data
+	t.Setenv(paths.EnvConfigDir, "")
+	stdout, stderr, err = executeCommandC(rootCmd, "--profile", "work", "paths")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Profiles:            "+filepath.Join(home, "data", "fyslide", "profiles"))
+	assert.Contains(t, stdout, "Tag database:        "+filepath.Join(home, "data", "fyslide", "profiles", "work", tagging.DBFileName))
+}
+
+func TestVerifyCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	testDir := t.TempDir()
+
+	goodPath := filepath.Join(testDir, "good.png")
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))))
+	require.NoError(t, os.WriteFile(goodPath, buf.Bytes(), 0644))
+
+	badPath := filepath.Join(testDir, "bad.jpg")
+	require.NoError(t, os.WriteFile(badPath, []byte("not really a jpeg"), 0644))
+	absBad, _ := filepath.Abs(badPath)
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "verify", testDir, "--tag-corrupt")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "CORRUPT: "+absBad)
+	assert.NotContains(t, stdout, "CORRUPT: "+goodPath)
+	assert.Contains(t, stdout, "Checked 2 image files, 1 could not be decoded.")
+
+	tdb, err := tagging.NewTagDB(dbDir, func(string) {})
+	require.NoError(t, err)
+	defer tdb.Close()
+	tags, err := tdb.GetTags(context.Background(), absBad)
+	require.NoError(t, err)
+	assert.Contains(t, tags, tagging.CorruptTag)
+}
+
+func TestRenameFileCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	testDir := t.TempDir()
+	oldPath := filepath.Join(testDir, "before.jpg")
+	newPath := filepath.Join(testDir, "after.jpg")
+	require.NoError(t, os.WriteFile(oldPath, []byte("jpeg"), 0644))
+
+	tdb, err := tagging.NewTagDB(dbDir, func(string) {})
+	require.NoError(t, err)
+	require.NoError(t, tdb.AddTag(context.Background(), oldPath, "holiday"))
+	require.NoError(t, tdb.Close())
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "rename-file", oldPath, newPath)
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Renamed "+oldPath+" to "+newPath)
+	assert.FileExists(t, newPath)
+	assert.NoFileExists(t, oldPath)
+
+	tdb, err = tagging.NewTagDB(dbDir, func(string) {})
+	require.NoError(t, err)
+	defer tdb.Close()
+	tags, err := tdb.GetTags(context.Background(), newPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"holiday"}, tags)
+}
+
+func TestImportXMPCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	testDir := t.TempDir()
+	imgPath := filepath.Join(testDir, "photo.jpg")
+	require.NoError(t, os.WriteFile(imgPath, []byte("jpeg"), 0644))
+	sidecar := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:subject><rdf:Bag><rdf:li>Sunset</rdf:li></rdf:Bag></dc:subject></rdf:Description>
+</rdf:RDF></x:xmpmeta>`
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "photo.xmp"), []byte(sidecar), 0644))
+
+	tdb, err := tagging.NewTagDB(dbDir, func(string) {})
+	require.NoError(t, err)
+	require.NoError(t, tdb.AddTag(context.Background(), imgPath, "existing"))
+	require.NoError(t, tdb.Close())
+
+	t.Run("dry run", func(t *testing.T) {
+		stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "import", "xmp", testDir, "--dry-run", "--on-conflict", "replace")
+		require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+		assert.Contains(t, stdout, "DRY RUN: Would remove tag 'existing' from "+imgPath)
+		assert.Contains(t, stdout, "DRY RUN: Would add tag 'sunset' to "+imgPath)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "import", "xmp", testDir)
+		require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+		assert.Contains(t, stdout, "Added 1 tag(s), removed 0 tag(s)")
+
+		tdb, err := tagging.NewTagDB(dbDir, func(string) {})
+		require.NoError(t, err)
+		defer tdb.Close()
+		tags, err := tdb.GetTags(context.Background(), imgPath)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"existing", "sunset"}, tags)
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		_, _, err := executeCommandC(rootCmd, "--dbpath", dbDir, "import", "xmp", testDir, "--on-conflict", "clobber")
+		assert.Error(t, err)
+	})
+}
+
+func TestExportTagSpacesCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	testDir := t.TempDir()
+	tagged := filepath.Join(testDir, "tagged.png")
+	untagged := filepath.Join(testDir, "untagged.png")
+	private := filepath.Join(testDir, "private.png")
+	require.NoError(t, os.WriteFile(tagged, []byte("png"), 0644))
+	require.NoError(t, os.WriteFile(untagged, []byte("png"), 0644))
+	require.NoError(t, os.WriteFile(private, []byte("png"), 0644))
+
+	tdb, err := tagging.NewTagDB(dbDir, func(string) {})
+	require.NoError(t, err)
+	require.NoError(t, tdb.AddTag(context.Background(), tagged, "family"))
+	require.NoError(t, tdb.AddTag(context.Background(), private, "family"))
+	require.NoError(t, tdb.AddTag(context.Background(), private, tagging.PrivateTag))
+	require.NoError(t, tdb.Close())
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "export-tagspaces", testDir)
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Wrote 1 TagSpaces sidecar(s)")
+
+	data, err := os.ReadFile(filepath.Join(testDir, ".ts", "tagged.png.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"title": "family"`)
+	assert.NoFileExists(t, filepath.Join(testDir, ".ts", "untagged.png.json"))
+	assert.NoFileExists(t, filepath.Join(testDir, ".ts", "private.png.json"))
+	assert.Contains(t, stdout, "Skipped 1 private image(s)")
+
+	stdout, stderr, err = executeCommandC(rootCmd, "--dbpath", dbDir, "export-tagspaces", testDir, "--include-private")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Wrote 2 TagSpaces sidecar(s)")
+	assert.FileExists(t, filepath.Join(testDir, ".ts", "private.png.json"))
+}
+
+func TestSyncMetadataCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	testDir := t.TempDir()
+	imgPath := filepath.Join(testDir, "photo.png")
+	f, err := os.Create(imgPath)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+	require.NoError(t, f.Close())
+
+	tdb, err := tagging.NewTagDB(dbDir, func(string) {})
+	require.NoError(t, err)
+	require.NoError(t, tdb.AddTag(context.Background(), imgPath, "garden"))
+	require.NoError(t, tdb.Close())
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "sync-metadata", testDir, "--state", statePath)
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Checked 1 image(s): 1 file(s) written")
+	assert.FileExists(t, statePath)
+
+	keywords, err := metadata.ReadKeywords(imgPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"garden"}, keywords)
+}
+
+func TestProfileFlag(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	imgPath := filepath.Join(t.TempDir(), "photo.png")
+	require.NoError(t, os.WriteFile(imgPath, []byte("png"), 0644))
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--profile", "work", "add", imgPath, "beach")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+
+	workDir, err := profile.Dir("work")
+	require.NoError(t, err)
+	tdb, err := tagging.NewTagDB(workDir, func(string) {})
+	require.NoError(t, err)
+	tags, err := tdb.GetTags(context.Background(), imgPath)
+	require.NoError(t, err)
+	require.NoError(t, tdb.Close())
+	assert.Equal(t, []string{"beach"}, tags)
+
+	_, _, err = executeCommandC(rootCmd, "--profile", "../escape", "list", imgPath)
+	assert.Error(t, err, "an invalid profile name must be rejected")
+}
+
+func TestHistoryLogCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	tdb, err := tagging.NewTagDB(dbDir, func(string) {})
+	require.NoError(t, err)
+	tdb.SetActor("alice")
+	require.NoError(t, tdb.AddTag(context.Background(), "/photos/a.jpg", "beach"))
+	require.NoError(t, tdb.AddTag(context.Background(), "/photos/b.jpg", "city"))
+	require.NoError(t, tdb.RenameImage(context.Background(), "/photos/a.jpg", "/photos/sea.jpg"))
+	require.NoError(t, tdb.Close())
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "history-log")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "alice")
+	assert.Contains(t, stdout, "'beach' on /photos/a.jpg")
+	assert.Contains(t, stdout, "/photos/a.jpg -> /photos/sea.jpg")
+
+	stdout, _, err = executeCommandC(rootCmd, "--dbpath", dbDir, "history-log", "--tag", "city", "--csv")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 2, "header and one event: %s", stdout)
+	assert.Equal(t, "seq,time,user,action,path,tag,tags,detail", lines[0])
+	assert.Contains(t, lines[1], ",alice,add,/photos/b.jpg,city,,")
+}
+
+func TestUsageStatsCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	library := t.TempDir()
+	image := filepath.Join(library, "a.jpg")
+	require.NoError(t, os.WriteFile(image, []byte("jpeg"), 0o644))
+
+	_, _, err := executeCommandC(rootCmd, "--dbpath", dbDir, "add", image, "beach")
+	require.NoError(t, err)
+	_, _, err = executeCommandC(rootCmd, "--dbpath", dbDir, "batch-add", library, "beach,sea")
+	require.NoError(t, err)
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "usage-stats")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Tags added: 2 (2 new tags)")
+	assert.Contains(t, stdout, "Images processed: 2")
+	assert.Regexp(t, `(?m)^  add +1$`, stdout)
+	assert.Regexp(t, `(?m)^  batch-add +1$`, stdout)
+
+	stdout, _, err = executeCommandC(rootCmd, "--dbpath", dbDir, "usage-stats", "--csv")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, ",command:usage-stats,1\n", "the previous run counts")
+
+	_, _, err = executeCommandC(rootCmd, "--dbpath", dbDir, "usage-stats", "--days", "0")
+	require.Error(t, err)
+}
+
+func TestTagSetCommands(t *testing.T) {
+	dbDir := t.TempDir()
+	library := t.TempDir()
+	a, b := filepath.Join(library, "a.jpg"), filepath.Join(library, "b.jpg")
+	for _, image := range []string{a, b} {
+		require.NoError(t, os.WriteFile(image, []byte("jpeg"), 0o644))
+	}
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "tag-set", "save", "beach-trip", "Beach", "family", "2024")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	stdout, _, err = executeCommandC(rootCmd, "--dbpath", dbDir, "tag-set", "list")
+	require.NoError(t, err)
+	assert.Equal(t, "beach-trip: beach, family, 2024\n", stdout)
+
+	stdout, stderr, err = executeCommandC(rootCmd, "--dbpath", dbDir, "apply-set", "beach-trip", filepath.Join(library, "*.jpg"))
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Processed 2 image(s): 2 succeeded, 0 failed.")
+	stdout, _, err = executeCommandC(rootCmd, "--dbpath", dbDir, "list", b)
+	require.NoError(t, err)
+	for _, tag := range []string{"beach", "family", "2024"} {
+		assert.Contains(t, stdout, tag)
+	}
+
+	_, _, err = executeCommandC(rootCmd, "--dbpath", dbDir, "tag-set", "delete", "beach-trip")
+	require.NoError(t, err)
+	stdout, _, err = executeCommandC(rootCmd, "--dbpath", dbDir, "tag-set", "list")
+	require.NoError(t, err)
+	assert.Equal(t, "No tag sets.\n", stdout)
+	_, _, err = executeCommandC(rootCmd, "--dbpath", dbDir, "apply-set", "beach-trip", a)
+	assert.ErrorIs(t, err, tagging.ErrNoTagSet)
+}
+
+func TestDBExportAndMergeCommands(t *testing.T) {
+	desktopDir := t.TempDir()
+	laptopDir := t.TempDir()
+
+	tdb, err := tagging.NewTagDB(laptopDir, func(string) {})
+	require.NoError(t, err)
+	require.NoError(t, tdb.AddTag(context.Background(), "/Users/me/Photos/a.jpg", "beach"))
+	require.NoError(t, tdb.Close())
+
+	snapshot := filepath.Join(t.TempDir(), "laptop.db")
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", laptopDir, "db", "export", snapshot)
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.FileExists(t, snapshot)
+
+	stdout, stderr, err = executeCommandC(rootCmd, "--dbpath", desktopDir, "db", "merge", snapshot,
+		"--map-prefix", "/Users/me/Photos=/home/me/Photos", "--dry-run")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "DRY RUN: Would add 'beach' on /home/me/Photos/a.jpg")
+
+	stdout, stderr, err = executeCommandC(rootCmd, "--dbpath", desktopDir, "db", "merge", snapshot,
+		"--map-prefix", "/Users/me/Photos=/home/me/Photos")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Tags added: 1, removed: 0")
+
+	tdb, err = tagging.NewTagDB(desktopDir, func(string) {})
+	require.NoError(t, err)
+	defer tdb.Close()
+	tags, err := tdb.GetTags(context.Background(), "/home/me/Photos/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"beach"}, tags)
+}
+
+func TestServeGRPCRejectsBadAddress(t *testing.T) {
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", t.TempDir(), "serve-grpc", "--listen", "localhost:-1")
+	require.Error(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, err.Error(), "cannot listen on localhost:-1")
+}
+
+func TestServeHTTPRequiresAuthBeyondLocalhost(t *testing.T) {
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", t.TempDir(), "serve-http", "--root", t.TempDir(), "--listen", "0.0.0.0:-1")
+	require.Error(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, err.Error(), "without authentication")
+
+	_, _, err = executeCommandC(rootCmd, "--dbpath", t.TempDir(), "serve-http", "--root", t.TempDir(), "--listen", "0.0.0.0:-1", "--no-auth")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot listen on 0.0.0.0:-1")
+}
+
+func TestApplyDirDefaultsCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	imgDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(imgDir, ".fyslide-tags"), []byte("trip\n2024 # year\n"), 0644))
+	for _, name := range []string{"a.jpg", "b.jpg"} {
+		require.NoError(t, os.WriteFile(filepath.Join(imgDir, name), []byte("image"), 0644))
+	}
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "apply-dir-defaults", imgDir, "--dry-run")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "DRY RUN: Would add tag 'trip' to "+filepath.Join(imgDir, "a.jpg"))
+	assert.Contains(t, stdout, "2 image(s) needed tags, 4 tag(s) added")
+
+	stdout, stderr, err = executeCommandC(rootCmd, "--dbpath", dbDir, "apply-dir-defaults", imgDir)
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "2 image(s) needed tags, 4 tag(s) added")
+
+	// Everything is tagged now, so a second run has nothing to do.
+	stdout, stderr, err = executeCommandC(rootCmd, "--dbpath", dbDir, "apply-dir-defaults", imgDir)
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "0 image(s) needed tags, 0 tag(s) added")
+}
+
+func TestAutotagFilenameCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	imgDir := t.TempDir()
+	rulesPath := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(rulesPath, []byte("rules:\n  - pattern: '(?P<year>\\d{4})-(?P<event>[a-z]+)'\n"), 0644))
+	for _, name := range []string{"2021-wedding-01.jpg", "2021-wedding-02.jpg", "holiday.jpg"} {
+		require.NoError(t, os.WriteFile(filepath.Join(imgDir, name), []byte("image"), 0644))
+	}
+	first := filepath.Join(imgDir, "2021-wedding-01.jpg")
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "autotag", "filename", imgDir, "--rules", rulesPath, "--dry-run")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "DRY RUN: Would add tag 'wedding' to "+first)
+	assert.Contains(t, stdout, "Scanned 3 image(s), 2 needed tags, 4 tag(s) added")
+
+	stdout, stderr, err = executeCommandC(rootCmd, "--dbpath", dbDir, "autotag", "filename", imgDir, "--rules", rulesPath)
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Added tag '2021' to "+first)
+
+	stdout, stderr, err = executeCommandC(rootCmd, "--dbpath", dbDir, "autotag", "filename", imgDir, "--rules", rulesPath)
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "0 needed tags, 0 tag(s) added")
+
+	_, _, err = executeCommandC(rootCmd, "--dbpath", dbDir, "autotag", "filename", imgDir)
+	assert.Error(t, err, "--rules is required")
+}
+
+func TestAutotagEXIFCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	imgDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(imgDir, "plain.jpg"), []byte("no exif here"), 0644))
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "autotag", "exif", imgDir, "--namespaces", "camera,iso", "--dry-run")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "DRY RUN: Finished simulation of EXIF autotag. Scanned 1 image(s), 0 needed tags, 0 tag(s) added.")
+
+	_, _, err = executeCommandC(rootCmd, "--dbpath", dbDir, "autotag", "exif", imgDir, "--namespaces", "shutter")
+	assert.ErrorContains(t, err, "unknown EXIF namespace")
+}
+
+func TestExportResizedCommand(t *testing.T) {
+	dbDir := t.TempDir()
+	imgDir := t.TempDir()
+	outDir := filepath.Join(t.TempDir(), "out")
+	for _, name := range []string{"beach.png", "city.png", "secret.png"} {
+		img := image.NewRGBA(image.Rect(0, 0, 300, 100))
+		f, err := os.Create(filepath.Join(imgDir, name))
+		require.NoError(t, err)
+		require.NoError(t, png.Encode(f, img))
+		require.NoError(t, f.Close())
+	}
+	_, _, err := executeCommandC(rootCmd, "--dbpath", dbDir, "add", filepath.Join(imgDir, "beach.png"), "share")
+	require.NoError(t, err)
+	_, _, err = executeCommandC(rootCmd, "--dbpath", dbDir, "add", filepath.Join(imgDir, "secret.png"), "share", "private")
+	require.NoError(t, err)
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "export-resized", imgDir, outDir,
+		"--tag", "share", "--max-size", "150", "--format", "jpeg")
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Wrote "+filepath.Join(outDir, "beach.jpg"))
+	assert.Contains(t, stdout, "Wrote 1 copy(ies), skipped 0 existing, 0 failed.")
+	assert.Contains(t, stdout, "Skipped 1 private image(s)")
+
+	f, err := os.Open(filepath.Join(outDir, "beach.jpg"))
+	require.NoError(t, err)
+	defer f.Close()
+	cfg, format, err := image.DecodeConfig(f)
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 150, cfg.Width)
+	assert.Equal(t, 50, cfg.Height)
+	assert.NoFileExists(t, filepath.Join(outDir, "city.jpg"))
+	assert.NoFileExists(t, filepath.Join(outDir, "secret.jpg"))
+}
+
+func TestAutotagColorCommand(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir()) // Keep the signature cache out of the user's cache
+	dbDir := t.TempDir()
+	imgDir := t.TempDir()
+	red := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(red, red.Bounds(), &image.Uniform{color.RGBA{220, 20, 30, 255}}, image.Point{}, draw.Src)
+	f, err := os.Create(filepath.Join(imgDir, "red.png"))
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, red))
+	require.NoError(t, f.Close())
+
+	stdout, stderr, err := executeCommandC(rootCmd, "--dbpath", dbDir, "autotag", "color", imgDir)
+	require.NoError(t, err, "stdout: %s, stderr: %s", stdout, stderr)
+	assert.Contains(t, stdout, "Added tag 'color:red' to "+filepath.Join(imgDir, "red.png"))
+}
@@ -0,0 +1,166 @@
+// Package annotate draws annotations, such as arrows, boxes and text marking
+// issues on scanned documents and photos, over images. Annotations are kept
+// apart from the pixels; Flatten burns them into a copy.
+package annotate
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Kinds of shape.
+const (
+	KindArrow = "arrow" // From (X1, Y1) with the head at (X2, Y2)
+	KindBox   = "box"   // Rectangle with corners (X1, Y1) and (X2, Y2)
+	KindText  = "text"  // Text with its top left corner at (X1, Y1)
+)
+
+// Shape is one annotation. Coordinates are fractions of the image's width and
+// height, so annotations fit the image at any size it is decoded or shown at.
+type Shape struct {
+	Kind  string  `json:"kind"`
+	X1    float64 `json:"x1"`
+	Y1    float64 `json:"y1"`
+	X2    float64 `json:"x2,omitempty"`
+	Y2    float64 `json:"y2,omitempty"`
+	Text  string  `json:"text,omitempty"`
+	Color string  `json:"color"` // #rrggbb
+}
+
+// NamedColor is a color offered for annotations.
+type NamedColor struct {
+	Name string
+	Hex  string
+}
+
+// Palette lists the annotation colors in display order; the first is the default.
+var Palette = []NamedColor{
+	{"Red", "#ff3b30"},
+	{"Yellow", "#ffcc00"},
+	{"Green", "#34c759"},
+	{"Blue", "#007aff"},
+	{"White", "#ffffff"},
+	{"Black", "#000000"},
+}
+
+// ParseColor returns the color of a #rrggbb string, or the palette's first
+// color if s isn't one.
+func ParseColor(s string) color.RGBA {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || len(s) != 7 || s[0] != '#' {
+		v, _ = strconv.ParseUint(Palette[0].Hex[1:], 16, 32)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+}
+
+// textLines is how many lines of text fit the shorter side of an image.
+const textLines = 30
+
+// Draw paints shapes onto dst for an image occupying r, which may extend
+// beyond dst when the image is zoomed. Line widths and text sizes scale with
+// r, so the annotations keep their size relative to the image.
+func Draw(dst *image.RGBA, r image.Rectangle, shapes []Shape) {
+	if r.Empty() {
+		return
+	}
+	w, h := float64(r.Dx()), float64(r.Dy())
+	width := max(2, math.Min(w, h)/250)
+	at := func(fx, fy float64) (float64, float64) {
+		return float64(r.Min.X) + fx*w, float64(r.Min.Y) + fy*h
+	}
+	for _, s := range shapes {
+		c := ParseColor(s.Color)
+		x1, y1 := at(s.X1, s.Y1)
+		x2, y2 := at(s.X2, s.Y2)
+		switch s.Kind {
+		case KindArrow:
+			drawLine(dst, x1, y1, x2, y2, width, c)
+			length := math.Hypot(x2-x1, y2-y1)
+			if length == 0 {
+				continue
+			}
+			head := math.Min(length/2, 5*width)
+			angle := math.Atan2(y2-y1, x2-x1)
+			for _, side := range []float64{-1, 1} {
+				a := angle + math.Pi - side*math.Pi/7
+				drawLine(dst, x2, y2, x2+head*math.Cos(a), y2+head*math.Sin(a), width, c)
+			}
+		case KindBox:
+			drawLine(dst, x1, y1, x2, y1, width, c)
+			drawLine(dst, x2, y1, x2, y2, width, c)
+			drawLine(dst, x2, y2, x1, y2, width, c)
+			drawLine(dst, x1, y2, x1, y1, width, c)
+		case KindText:
+			drawText(dst, int(x1), int(y1), math.Min(w, h)/textLines, s.Text, c)
+		}
+	}
+}
+
+// Flatten returns a copy of img with shapes drawn onto it.
+func Flatten(img image.Image, shapes []Shape) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	Draw(dst, dst.Bounds(), shapes)
+	return dst
+}
+
+// drawLine paints a line of the given width with round ends, visiting only
+// the pixels of dst near it.
+func drawLine(dst *image.RGBA, x1, y1, x2, y2, width float64, c color.RGBA) {
+	half := width / 2
+	area := image.Rect(
+		int(math.Floor(math.Min(x1, x2)-half)), int(math.Floor(math.Min(y1, y2)-half)),
+		int(math.Ceil(math.Max(x1, x2)+half)), int(math.Ceil(math.Max(y1, y2)+half)),
+	).Intersect(dst.Bounds())
+	dx, dy := x2-x1, y2-y1
+	lengthSq := dx*dx + dy*dy
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			t := 0.0
+			if lengthSq > 0 {
+				t = math.Max(0, math.Min(1, ((px-x1)*dx+(py-y1)*dy)/lengthSq))
+			}
+			if math.Hypot(px-x1-t*dx, py-y1-t*dy) <= half {
+				dst.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// drawText paints text with its top left corner at (x, y), its lines height
+// pixels tall, on a translucent backing that keeps it readable on any image.
+func drawText(dst *image.RGBA, x, y int, height float64, text string, c color.RGBA) {
+	face := basicfont.Face7x13
+	lines := strings.Split(text, "\n")
+	textWidth := 0
+	for _, line := range lines {
+		textWidth = max(textWidth, font.MeasureString(face, line).Ceil())
+	}
+	if textWidth == 0 {
+		return
+	}
+	lineHeight := face.Metrics().Height.Ceil()
+	glyphs := image.NewRGBA(image.Rect(0, 0, textWidth, lineHeight*len(lines)))
+	d := font.Drawer{Dst: glyphs, Src: image.NewUniform(c), Face: face}
+	for i, line := range lines {
+		d.Dot = fixed.P(0, i*lineHeight+face.Metrics().Ascent.Ceil())
+		d.DrawString(line)
+	}
+
+	scale := math.Max(1, height/float64(lineHeight))
+	target := image.Rect(x, y, x+int(float64(glyphs.Rect.Dx())*scale), y+int(float64(glyphs.Rect.Dy())*scale))
+	pad := int(scale * 2)
+	backing := target.Inset(-pad).Intersect(dst.Bounds())
+	draw.Draw(dst, backing, image.NewUniform(color.NRGBA{A: 0x90}), image.Point{}, draw.Over)
+	draw.ApproxBiLinear.Scale(dst, target, glyphs, glyphs.Bounds(), draw.Over, nil)
+}
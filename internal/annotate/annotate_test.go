@@ -0,0 +1,50 @@
+package annotate
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	if got := ParseColor("#007aff"); got != (color.RGBA{R: 0x00, G: 0x7a, B: 0xff, A: 0xff}) {
+		t.Errorf("ParseColor(#007aff) = %v", got)
+	}
+	for _, bad := range []string{"", "red", "#12345", "#gggggg"} {
+		if got, want := ParseColor(bad), ParseColor(Palette[0].Hex); got != want {
+			t.Errorf("ParseColor(%q) = %v, want the default %v", bad, got, want)
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	img := image.NewGray(image.Rect(10, 10, 210, 110)) // Black, with a bounds origin away from zero
+	shapes := []Shape{
+		{Kind: KindBox, X1: 0.1, Y1: 0.2, X2: 0.5, Y2: 0.8, Color: "#ffffff"},
+		{Kind: KindText, X1: 0.6, Y1: 0.1, Text: "scratch", Color: "#ff0000"},
+	}
+	out := Flatten(img, shapes)
+	if out.Bounds() != image.Rect(0, 0, 200, 100) {
+		t.Fatalf("Flatten bounds = %v, want the image's size", out.Bounds())
+	}
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	if got := out.RGBAAt(20, 50); got != white { // Left edge of the box
+		t.Errorf("box edge pixel = %v, want white", got)
+	}
+	if got := out.RGBAAt(60, 50); got != (color.RGBA{A: 0xff}) { // Inside the box
+		t.Errorf("pixel inside the box = %v, want the image's black", got)
+	}
+	red := false
+	for y := 10; y < 30 && !red; y++ {
+		for x := 120; x < 200 && !red; x++ {
+			c := out.RGBAAt(x, y)
+			red = c.R > 0x80 && c.G < 0x40
+		}
+	}
+	if !red {
+		t.Error("no red text pixels drawn")
+	}
+	if got := img.GrayAt(30, 50).Y; got != 0 {
+		t.Errorf("Flatten changed the source image: %v", got)
+	}
+}
@@ -0,0 +1,160 @@
+package autotag
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// EXIF tag namespaces. Each derived tag is "namespace:value".
+const (
+	NamespaceCamera = "camera" // Camera model without spaces, e.g. camera:PixelFold
+	NamespaceLens   = "lens"   // Lens model without spaces
+	NamespaceYear   = "year"   // Year taken, e.g. year:2024
+	NamespaceMonth  = "month"  // Month taken, e.g. month:2024-06
+	NamespaceISO    = "iso"    // ISO speed bucket: low, medium or high
+)
+
+// Namespaces lists every EXIF namespace in display order.
+var Namespaces = []string{NamespaceCamera, NamespaceLens, NamespaceYear, NamespaceMonth, NamespaceISO}
+
+// DefaultNamespaces are the namespaces used when none are chosen. Lens and ISO
+// tags are left out since they add many tags few people filter by.
+var DefaultNamespaces = []string{NamespaceCamera, NamespaceYear, NamespaceMonth}
+
+// ISO speeds up to these values count as low and medium; anything above is high.
+const (
+	isoLowMax    = 200
+	isoMediumMax = 1600
+)
+
+// ParseNamespaces parses a comma-separated list of namespaces.
+func ParseNamespaces(s string) ([]string, error) {
+	var namespaces []string
+	for _, ns := range strings.Split(s, ",") {
+		ns = strings.ToLower(strings.TrimSpace(ns))
+		if ns == "" || slices.Contains(namespaces, ns) {
+			continue
+		}
+		if !slices.Contains(Namespaces, ns) {
+			return nil, fmt.Errorf("unknown EXIF namespace %q (valid: %s)", ns, strings.Join(Namespaces, ", "))
+		}
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces, nil
+}
+
+// EXIFInfo holds the EXIF fields tags are derived from. Fields missing from
+// the file are left zero.
+type EXIFInfo struct {
+	Camera string
+	Lens   string
+	Taken  time.Time
+	ISO    int
+
+	// Lat and Long are where the image was taken, in degrees, when HasGPS.
+	Lat, Long float64
+	HasGPS    bool
+}
+
+// ReadEXIF reads the EXIF fields of an image. Files without EXIF data yield
+// an empty EXIFInfo and no error.
+func ReadEXIF(path string) (EXIFInfo, error) {
+	var info EXIFInfo
+	f, err := os.Open(path)
+	if err != nil {
+		return info, err
+	}
+	defer f.Close()
+	x, err := exif.Decode(f)
+	if err != nil && (x == nil || exif.IsCriticalError(err)) {
+		return info, nil // No usable EXIF data
+	}
+	return InfoFrom(x), nil
+}
+
+// InfoFrom extracts the fields of EXIFInfo from decoded EXIF data, for
+// callers that decode the data themselves.
+func InfoFrom(x *exif.Exif) EXIFInfo {
+	var info EXIFInfo
+	stringField := func(name exif.FieldName) string {
+		tag, err := x.Get(name)
+		if err != nil {
+			return ""
+		}
+		s, err := tag.StringVal()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(strings.TrimRight(s, "\x00"))
+	}
+	info.Camera = stringField(exif.Model)
+	if info.Camera == "" {
+		info.Camera = stringField(exif.Make)
+	}
+	info.Lens = stringField(exif.LensModel)
+	if taken, err := x.DateTime(); err == nil {
+		info.Taken = taken
+	}
+	if tag, err := x.Get(exif.ISOSpeedRatings); err == nil {
+		if iso, err := tag.Int(0); err == nil {
+			info.ISO = iso
+		}
+	}
+	if lat, long, err := x.LatLong(); err == nil {
+		info.Lat, info.Long, info.HasGPS = lat, long, true
+	}
+	return info
+}
+
+// Tags returns the tags of the given namespaces that info provides, in the
+// order of namespaces.
+func (info EXIFInfo) Tags(namespaces []string) []string {
+	var tags []string
+	add := func(ns, value string) {
+		value = strings.Join(strings.Fields(value), "")
+		if value != "" {
+			tags = append(tags, ns+":"+value)
+		}
+	}
+	for _, ns := range namespaces {
+		switch ns {
+		case NamespaceCamera:
+			add(ns, info.Camera)
+		case NamespaceLens:
+			add(ns, info.Lens)
+		case NamespaceYear:
+			if !info.Taken.IsZero() {
+				add(ns, info.Taken.Format("2006"))
+			}
+		case NamespaceMonth:
+			if !info.Taken.IsZero() {
+				add(ns, info.Taken.Format("2006-01"))
+			}
+		case NamespaceISO:
+			switch {
+			case info.ISO <= 0:
+			case info.ISO <= isoLowMax:
+				add(ns, "low")
+			case info.ISO <= isoMediumMax:
+				add(ns, "medium")
+			default:
+				add(ns, "high")
+			}
+		}
+	}
+	return tags
+}
+
+// EXIFTags reads an image's EXIF data and returns its tags in the given namespaces.
+func EXIFTags(path string, namespaces []string) ([]string, error) {
+	info, err := ReadEXIF(path)
+	if err != nil {
+		return nil, err
+	}
+	return info.Tags(namespaces), nil
+}
@@ -0,0 +1,60 @@
+package autotag
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEXIFInfoTags(t *testing.T) {
+	info := EXIFInfo{
+		Camera: "Pixel Fold",
+		Lens:   "Pixel Fold back camera 6.9mm f/1.7",
+		Taken:  time.Date(2024, time.June, 3, 10, 0, 0, 0, time.UTC),
+		ISO:    3200,
+	}
+	want := []string{"camera:PixelFold", "lens:PixelFoldbackcamera6.9mmf/1.7", "year:2024", "month:2024-06", "iso:high"}
+	if got := info.Tags(Namespaces); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags(all) = %v, want %v", got, want)
+	}
+	if got, want := info.Tags([]string{NamespaceYear}), []string{"year:2024"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags(year) = %v, want %v", got, want)
+	}
+	if got := (EXIFInfo{ISO: 100}).Tags(Namespaces); !reflect.DeepEqual(got, []string{"iso:low"}) {
+		t.Errorf("Tags of an ISO-only info = %v, want [iso:low]", got)
+	}
+}
+
+func TestParseNamespaces(t *testing.T) {
+	got, err := ParseNamespaces(" Camera, year,camera,")
+	if err != nil || !reflect.DeepEqual(got, []string{"camera", "year"}) {
+		t.Errorf("ParseNamespaces = %v, %v", got, err)
+	}
+	if _, err := ParseNamespaces("camera,shutter"); err == nil {
+		t.Error("ParseNamespaces accepted an unknown namespace")
+	}
+}
+
+func TestReadEXIFWithoutData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	tags, err := EXIFTags(path, Namespaces)
+	if err != nil || len(tags) != 0 {
+		t.Errorf("EXIFTags of an image without EXIF = %v, %v", tags, err)
+	}
+	if _, err := ReadEXIF(filepath.Join(t.TempDir(), "missing.jpg")); err == nil {
+		t.Error("ReadEXIF of a missing file succeeded")
+	}
+}
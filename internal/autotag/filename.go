@@ -0,0 +1,146 @@
+// Package autotag derives tags from images without user input, such as from
+// the patterns their file names follow.
+package autotag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule targets.
+const (
+	MatchName = "name" // The file name without its extension (default)
+	MatchPath = "path" // The whole path, with forward slashes on every system
+)
+
+// FilenameRule derives tags from one regular expression. Without Tags, every
+// non-empty capture group becomes a tag; with Tags, each template becomes a tag
+// after replacing {name} (or {1}, {2}, ...) with the group's match, and
+// templates left with an empty group are dropped. A rule that doesn't match
+// adds nothing.
+type FilenameRule struct {
+	Pattern string   `yaml:"pattern"`
+	Match   string   `yaml:"match,omitempty"`
+	Tags    []string `yaml:"tags,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// FilenameRules is a rules file, e.g.
+//
+//	rules:
+//	  - pattern: '(?P<year>\d{4})-(?P<event>[a-z]+)'
+//	  - pattern: '/Trips/(?P<place>[^/]+)/'
+//	    match: path
+//	    tags: ["trip", "{place}"]
+type FilenameRules struct {
+	Rules []FilenameRule `yaml:"rules"`
+}
+
+// templateRef finds the group references in tag templates.
+var templateRef = regexp.MustCompile(`\{(\w+)\}`)
+
+// ParseFilenameRules parses and compiles rules written in YAML.
+func ParseFilenameRules(data []byte) (*FilenameRules, error) {
+	var rules FilenameRules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+	if len(rules.Rules) == 0 {
+		return nil, fmt.Errorf("no rules defined")
+	}
+	for i := range rules.Rules {
+		r := &rules.Rules[i]
+		var err error
+		if r.re, err = regexp.Compile(r.Pattern); err != nil {
+			return nil, fmt.Errorf("rule %d: invalid pattern: %w", i+1, err)
+		}
+		switch r.Match {
+		case "":
+			r.Match = MatchName
+		case MatchName, MatchPath:
+		default:
+			return nil, fmt.Errorf("rule %d: match must be %s or %s, not %q", i+1, MatchName, MatchPath, r.Match)
+		}
+		for _, tmpl := range r.Tags {
+			for _, ref := range templateRef.FindAllStringSubmatch(tmpl, -1) {
+				if groupIndex(r.re, ref[1]) < 0 {
+					return nil, fmt.Errorf("rule %d: tag %q refers to unknown group %s", i+1, tmpl, ref[0])
+				}
+			}
+		}
+	}
+	return &rules, nil
+}
+
+// LoadFilenameRules reads a rules file.
+func LoadFilenameRules(path string) (*FilenameRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := ParseFilenameRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// groupIndex returns the index of a group given by name or number, or -1.
+func groupIndex(re *regexp.Regexp, ref string) int {
+	if i := re.SubexpIndex(ref); i >= 0 {
+		return i
+	}
+	var n int
+	if _, err := fmt.Sscanf(ref, "%d", &n); err == nil && fmt.Sprint(n) == ref && n >= 0 && n <= re.NumSubexp() {
+		return n
+	}
+	return -1
+}
+
+// Tags returns the tags the rules derive from path, lowercased and without
+// duplicates, in rule order.
+func (rs *FilenameRules) Tags(path string) []string {
+	var tags []string
+	add := func(tag string) {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	for _, r := range rs.Rules {
+		target := name
+		if r.Match == MatchPath {
+			target = filepath.ToSlash(path)
+		}
+		m := r.re.FindStringSubmatch(target)
+		if m == nil {
+			continue
+		}
+		if len(r.Tags) == 0 {
+			for _, group := range m[1:] {
+				add(group)
+			}
+			continue
+		}
+		for _, tmpl := range r.Tags {
+			empty := false
+			tag := templateRef.ReplaceAllStringFunc(tmpl, func(ref string) string {
+				value := m[groupIndex(r.re, ref[1:len(ref)-1])]
+				empty = empty || value == ""
+				return value
+			})
+			if !empty {
+				add(tag)
+			}
+		}
+	}
+	return tags
+}
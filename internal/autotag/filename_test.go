@@ -0,0 +1,54 @@
+package autotag
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFilenameRules(t *testing.T) {
+	rules, err := ParseFilenameRules([]byte(`
+rules:
+  - pattern: '(?P<year>\d{4})-(?P<event>[a-z]+)'
+  - pattern: '/Trips/(?P<place>[^/]+)/'
+    match: path
+    tags: ["trip", "{place}"]
+  - pattern: 'IMG_(\d+)(_edit)?'
+    tags: ["edited{2}"]
+`))
+	if err != nil {
+		t.Fatalf("ParseFilenameRules failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/photos/2021-wedding-042.jpg", []string{"2021", "wedding"}},
+		{"/photos/Trips/Lisbon/2023-beach.png", []string{"2023", "beach", "trip", "lisbon"}},
+		{"/photos/IMG_0042_edit.jpg", []string{"edited_edit"}},
+		{"/photos/IMG_0042.jpg", nil}, // The template's group didn't take part in the match
+		{"/photos/holiday.jpg", nil},
+	}
+	for _, tt := range tests {
+		if got := rules.Tags(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tags(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseFilenameRulesErrors(t *testing.T) {
+	tests := map[string]string{
+		"rules: []":                "no rules",
+		"rules:\n  - pattern: '('": "invalid pattern",
+		"rules:\n  - pattern: 'a'\n    match: dir":           "match must be",
+		"rules:\n  - pattern: '(?P<y>a)'\n    tags: ['{z}']": "unknown group",
+		"rules:\n  - pattern: '(a)'\n    tags: ['{2}']":      "unknown group",
+		"rules: {pattern: a}":                                "invalid rules",
+	}
+	for input, want := range tests {
+		if _, err := ParseFilenameRules([]byte(input)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseFilenameRules(%q) error = %v, want it to mention %q", input, err, want)
+		}
+	}
+}
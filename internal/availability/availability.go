@@ -0,0 +1,168 @@
+// Package availability tells image files that were deleted apart from files
+// that are only out of reach because the disk or network share holding them
+// is disconnected, so that neither the viewer nor a database cleanup mistakes
+// an unmounted library for an empty one.
+package availability
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds each file system check. A hung network mount can
+// block a stat for minutes; past the timeout its location counts as unreachable.
+const DefaultTimeout = 3 * time.Second
+
+// Status is the outcome of checking a file.
+type Status int
+
+const (
+	// Present means the file exists.
+	Present Status = iota
+	// Missing means the file is gone while its surroundings are reachable: it was deleted or moved.
+	Missing
+	// Unreachable means the file's location can't be reached, so whether it still exists is unknown.
+	Unreachable
+)
+
+func (s Status) String() string {
+	switch s {
+	case Present:
+		return "present"
+	case Missing:
+		return "missing"
+	}
+	return "unreachable"
+}
+
+var errTimeout = errors.New("file system check timed out")
+
+// statWithTimeout is os.Stat giving up after timeout. A stat that never
+// returns leaves its goroutine behind; there is no way to cancel it.
+func statWithTimeout(path string, timeout time.Duration) (fs.FileInfo, error) {
+	type result struct {
+		info fs.FileInfo
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		info, err := os.Stat(path)
+		done <- result{info, err}
+	}()
+	select {
+	case r := <-done:
+		return r.info, r.err
+	case <-time.After(timeout):
+		return nil, errTimeout
+	}
+}
+
+// Reachable reports whether dir can be listed within timeout and has at least
+// one entry. An empty directory counts as unreachable: it is what a mount
+// point looks like with nothing mounted on it.
+func Reachable(dir string, timeout time.Duration) bool {
+	done := make(chan bool, 1)
+	go func() {
+		f, err := os.Open(dir)
+		if err != nil {
+			done <- false
+			return
+		}
+		defer f.Close()
+		names, err := f.Readdirnames(1)
+		done <- err == nil && len(names) > 0
+	}()
+	select {
+	case ok := <-done:
+		return ok
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Checker classifies files, remembering the reachability of the directories
+// it looked at. It is not safe for concurrent use.
+type Checker struct {
+	Timeout time.Duration // Bound of each file system check; 0 uses DefaultTimeout
+	roots   []string
+	reach   map[string]bool
+}
+
+// NewChecker returns a Checker for files of the libraries rooted at roots.
+// While a root is unreachable, no file under it is reported missing. Files
+// outside every root are judged by their nearest existing folder.
+func NewChecker(roots ...string) *Checker {
+	c := &Checker{reach: make(map[string]bool)}
+	for _, root := range roots {
+		if root != "" {
+			c.roots = append(c.roots, filepath.Clean(root))
+		}
+	}
+	return c
+}
+
+func (c *Checker) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return DefaultTimeout
+}
+
+// reachable is Reachable, cached per directory.
+func (c *Checker) reachable(dir string) bool {
+	ok, seen := c.reach[dir]
+	if !seen {
+		ok = Reachable(dir, c.timeout())
+		c.reach[dir] = ok
+	}
+	return ok
+}
+
+// Check returns whether path exists, is missing, or can't be reached. A file
+// is only missing if its folder still exists, or if the nearest folder above
+// it that exists has content, i.e. isn't an empty mount point. Errors other
+// than "does not exist", such as I/O errors and timeouts, make it unreachable.
+func (c *Checker) Check(path string) Status {
+	path = filepath.Clean(path)
+	_, err := statWithTimeout(path, c.timeout())
+	if err == nil {
+		return Present
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return Unreachable
+	}
+	for _, root := range c.roots {
+		if within(path, root) && !c.reachable(root) {
+			return Unreachable
+		}
+	}
+	parent := filepath.Dir(path)
+	if info, err := statWithTimeout(parent, c.timeout()); err == nil && info.IsDir() {
+		return Missing
+	}
+	for dir := filepath.Dir(parent); ; dir = filepath.Dir(dir) {
+		info, err := statWithTimeout(dir, c.timeout())
+		if err == nil && info.IsDir() {
+			if c.reachable(dir) {
+				return Missing
+			}
+			return Unreachable
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return Unreachable
+		}
+		if filepath.Dir(dir) == dir {
+			return Unreachable // Not even the volume exists, e.g. a drive letter of a disconnected share
+		}
+	}
+}
+
+// within reports whether path is root or lies below it.
+func within(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
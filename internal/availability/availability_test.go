@@ -0,0 +1,76 @@
+package availability
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func touch(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	library := filepath.Join(dir, "library")
+	kept := filepath.Join(library, "2019", "kept.jpg")
+	touch(t, kept)
+	mountPoint := filepath.Join(dir, "nas") // Exists but is empty: nothing mounted
+	if err := os.Mkdir(mountPoint, 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		roots []string
+		path  string
+		want  Status
+	}{
+		{"existing file", nil, kept, Present},
+		{"deleted file in an existing folder", nil, filepath.Join(library, "2019", "gone.jpg"), Missing},
+		{"deleted folder in a reachable library", nil, filepath.Join(library, "2020", "gone.jpg"), Missing},
+		{"folder below an empty mount point", nil, filepath.Join(mountPoint, "photos", "a.jpg"), Unreachable},
+		{"file under an unreachable root", []string{mountPoint}, filepath.Join(mountPoint, "a.jpg"), Unreachable},
+		{"file under a reachable root", []string{library}, filepath.Join(library, "2019", "gone.jpg"), Missing},
+	}
+	for _, test := range tests {
+		if got := NewChecker(test.roots...).Check(test.path); got != test.want {
+			t.Errorf("%s: Check(%s) = %v, want %v", test.name, test.path, got, test.want)
+		}
+	}
+}
+
+func TestReachable(t *testing.T) {
+	dir := t.TempDir()
+	if Reachable(dir, DefaultTimeout) {
+		t.Error("empty directory reported reachable")
+	}
+	touch(t, filepath.Join(dir, "a.jpg"))
+	if !Reachable(dir, DefaultTimeout) {
+		t.Error("directory with a file reported unreachable")
+	}
+	if Reachable(filepath.Join(dir, "none"), DefaultTimeout) {
+		t.Error("nonexistent directory reported reachable")
+	}
+}
+
+func TestWithin(t *testing.T) {
+	root := filepath.FromSlash("/mnt/nas")
+	for path, want := range map[string]bool{
+		"/mnt/nas":          true,
+		"/mnt/nas/a.jpg":    true,
+		"/mnt/nasty/a.jpg":  false,
+		"/mnt/other/..x":    false,
+		"/mnt/nas/..x/a.jp": true,
+	} {
+		if got := within(filepath.FromSlash(path), root); got != want {
+			t.Errorf("within(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
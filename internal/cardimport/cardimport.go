@@ -0,0 +1,246 @@
+// Package cardimport copies images from a camera card into the library,
+// filed by the date each was taken in YYYY/MM/DD folders. Files whose
+// content was imported before are skipped, copies are verified by hash, and
+// the originals can then be removed from the card.
+package cardimport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"fyslide/internal/autotag"
+	"fyslide/internal/scan"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Ledger remembers which file contents were imported and where to; the tag
+// database is one.
+type Ledger interface {
+	ImportedPath(ctx context.Context, hash string) (string, error)
+	RecordImport(ctx context.Context, hash, path string) error
+}
+
+// Options control an import.
+type Options struct {
+	Library        string // Root of the date folders
+	DeleteFromCard bool   // Remove each original once its copy is verified or found in the library
+	DryRun         bool   // Only work out where each file would go
+}
+
+// Validate checks opts.
+func (opts *Options) Validate() error {
+	if opts.Library == "" {
+		return errors.New("no library folder given")
+	}
+	library, err := filepath.Abs(opts.Library)
+	if err != nil {
+		return err
+	}
+	opts.Library = library
+	return nil
+}
+
+// Result is the outcome of importing one file.
+type Result struct {
+	Source  string
+	Target  string    // Library path of the copy, or of the earlier copy if Skipped
+	Taken   time.Time // Date the file was filed under
+	Skipped bool      // The content was already in the library
+	Deleted bool      // The original was removed from the card
+	Err     error
+}
+
+// Copied reports whether the file was copied into the library by this import.
+func (r Result) Copied() bool {
+	return r.Err == nil && !r.Skipped
+}
+
+// readEXIF reads the EXIF fields of an image; tests replace it.
+var readEXIF = autotag.ReadEXIF
+
+// Find returns the images on the card under dir, skipping hidden folders
+// such as the trash folders systems keep on removable media.
+func Find(dir string) ([]string, error) {
+	var sources []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(d.Name(), ".") && scan.IsImage(path) {
+			sources = append(sources, path)
+		}
+		return nil
+	})
+	return sources, err
+}
+
+// Run imports sources in order, calling progress, if not nil, after each one
+// with the number done so far. Cancelling ctx stops before the next file.
+// Failed files are reported in their Result rather than stopping the import.
+func Run(ctx context.Context, sources []string, opts Options, ledger Ledger, progress func(done, total int, r Result)) ([]Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(sources))
+	for _, src := range sources {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		r := importFile(ctx, src, opts, ledger)
+		results = append(results, r)
+		if progress != nil {
+			progress(len(results), len(sources), r)
+		}
+	}
+	return results, nil
+}
+
+// importFile imports one file.
+func importFile(ctx context.Context, src string, opts Options, ledger Ledger) Result {
+	r := Result{Source: src}
+	info, err := os.Stat(src)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	hash, err := hashFile(src)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	r.Taken = info.ModTime()
+	if x, err := readEXIF(src); err == nil && !x.Taken.IsZero() {
+		r.Taken = x.Taken
+	}
+
+	path, err := ledger.ImportedPath(ctx, hash)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	if path != "" && sameContent(path, hash) {
+		r.Target, r.Skipped = path, true
+		return finish(ctx, r, hash, opts, ledger)
+	}
+
+	dir := filepath.Join(opts.Library, r.Taken.Format("2006"), r.Taken.Format("01"), r.Taken.Format("02"))
+	ext := filepath.Ext(src)
+	base := strings.TrimSuffix(filepath.Base(src), ext)
+	for n := 1; ; n++ {
+		name := base + ext
+		if n > 1 {
+			name = fmt.Sprintf("%s-%d%s", base, n, ext)
+		}
+		r.Target = filepath.Join(dir, name)
+		if _, err := os.Stat(r.Target); errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if sameContent(r.Target, hash) {
+			r.Skipped = true // Copied before the ledger knew of it
+			return finish(ctx, r, hash, opts, ledger)
+		}
+	}
+	if opts.DryRun {
+		return r
+	}
+	if err := copyVerified(src, r.Target, hash, info.ModTime()); err != nil {
+		r.Err = err
+		return r
+	}
+	return finish(ctx, r, hash, opts, ledger)
+}
+
+// finish records the library copy of r and removes the original if asked.
+func finish(ctx context.Context, r Result, hash string, opts Options, ledger Ledger) Result {
+	if opts.DryRun {
+		return r
+	}
+	if err := ledger.RecordImport(ctx, hash, r.Target); err != nil {
+		r.Err = err
+		return r
+	}
+	if opts.DeleteFromCard {
+		if err := os.Remove(r.Source); err != nil {
+			r.Err = fmt.Errorf("in the library, but failed to delete from the card: %w", err)
+			return r
+		}
+		r.Deleted = true
+	}
+	return r
+}
+
+// copyVerified copies src to target through a temporary file, checks that
+// the copy has the expected hash and gives it the original's modification
+// time.
+func copyVerified(src, target, hash string, modTime time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create folder for %s: %w", target, err)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".import-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %s: %w", target, err)
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once renamed
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if !sameContent(tmp.Name(), hash) {
+		return fmt.Errorf("copy of %s does not match the original", src)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil { // CreateTemp makes it private
+		return err
+	}
+	if err := os.Chtimes(tmp.Name(), modTime, modTime); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return nil
+}
+
+// sameContent reports whether the file at path exists and has the hash.
+func sameContent(path, hash string) bool {
+	h, err := hashFile(path)
+	return err == nil && h == hash
+}
+
+// hashFile returns the hex SHA-256 of the file at path.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,95 @@
+package cardimport
+
+import (
+	"context"
+	"fyslide/internal/autotag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// memLedger is a Ledger in memory.
+type memLedger map[string]string
+
+func (l memLedger) ImportedPath(_ context.Context, hash string) (string, error) {
+	return l[hash], nil
+}
+
+func (l memLedger) RecordImport(_ context.Context, hash, path string) error {
+	l[hash] = path
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	taken := time.Date(2024, 6, 15, 9, 30, 0, 0, time.Local)
+	readEXIF = func(path string) (autotag.EXIFInfo, error) {
+		if filepath.Base(path) == "IMG_0001.JPG" {
+			return autotag.EXIFInfo{Taken: taken}, nil
+		}
+		return autotag.EXIFInfo{}, nil
+	}
+	t.Cleanup(func() { readEXIF = autotag.ReadEXIF })
+
+	card, library := t.TempDir(), t.TempDir()
+	write := func(path, content string, modTime time.Time) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	modTime := time.Date(2023, 1, 2, 12, 0, 0, 0, time.Local)
+	write(filepath.Join(card, "DCIM", "100CANON", "IMG_0001.JPG"), "first", modTime)
+	write(filepath.Join(card, "DCIM", "100CANON", "IMG_0002.JPG"), "second", modTime)
+	write(filepath.Join(card, ".Trashes", "IMG_0003.JPG"), "trashed", modTime)
+	write(filepath.Join(card, "DCIM", "notes.txt"), "not an image", modTime)
+	// A different file of the same name is already in the library.
+	write(filepath.Join(library, "2023", "01", "02", "IMG_0002.JPG"), "other", modTime)
+
+	sources, err := Find(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 2 {
+		t.Fatalf("Find = %v, want the two images outside hidden folders", sources)
+	}
+
+	ledger := memLedger{}
+	results, err := Run(context.Background(), sources, Options{Library: library}, ledger, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTargets := []string{
+		filepath.Join(library, "2024", "06", "15", "IMG_0001.JPG"),
+		filepath.Join(library, "2023", "01", "02", "IMG_0002-2.JPG"),
+	}
+	for i, r := range results {
+		if !r.Copied() || r.Target != wantTargets[i] {
+			t.Errorf("Result %d = %+v, want a copy at %s", i, r, wantTargets[i])
+			continue
+		}
+		if info, err := os.Stat(r.Target); err != nil || !info.ModTime().Equal(modTime) {
+			t.Errorf("Copy %s: %v, want the original's modification time", r.Target, err)
+		}
+	}
+
+	// Importing again skips both and deletes the originals when asked.
+	results, err = Run(context.Background(), sources, Options{Library: library, DeleteFromCard: true}, ledger, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if !r.Skipped || !r.Deleted || r.Target != wantTargets[i] {
+			t.Errorf("Second import result %d = %+v, want skipped and deleted", i, r)
+		}
+		if _, err := os.Stat(r.Source); !os.IsNotExist(err) {
+			t.Errorf("%s is still on the card", r.Source)
+		}
+	}
+}
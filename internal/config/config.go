@@ -0,0 +1,333 @@
+// Package config reads the optional config.yaml shared by the viewer and the
+// CLI. Settings come from three layers: command-line flags override
+// environment variables, which override the file. This package handles the
+// file and the environment; each binary applies its own flags on top.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"fyslide/internal/events"
+	"fyslide/internal/paths"
+	"fyslide/internal/profile"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the config file in the FySlide config directory.
+const FileName = "config.yaml"
+
+// BackendBolt is the only database backend, and the default.
+const BackendBolt = "bolt"
+
+// Environment variables overriding the file.
+const (
+	EnvConfig            = "FYSLIDE_CONFIG"             // Config file to read instead of the default one
+	EnvDBPath            = paths.EnvDBPath              // Directory of the tag database
+	EnvLibraryRoots      = "FYSLIDE_LIBRARY_ROOTS"      // Library folders, separated like PATH
+	EnvExclude           = "FYSLIDE_EXCLUDE"            // Comma-separated exclude patterns
+	EnvSlideshowInterval = "FYSLIDE_SLIDESHOW_INTERVAL" // Seconds per image
+	EnvHistorySize       = "FYSLIDE_HISTORY_SIZE"       // Images kept in the navigation history
+	EnvSkipCount         = "FYSLIDE_SKIP_COUNT"         // Images skipped with Page Up and Page Down
+)
+
+// Config is the content of config.yaml. Unset fields keep each binary's defaults.
+type Config struct {
+	DB        DB                `yaml:"db"`
+	Library   Library           `yaml:"library"`
+	Slideshow Slideshow         `yaml:"slideshow"`
+	OCR       OCR               `yaml:"ocr"`
+	Delete    Delete            `yaml:"delete"`
+	API       API               `yaml:"api"`
+	Hooks     map[string]string `yaml:"hooks"` // Shell command run per event type, e.g. tag_added; see events.Type
+}
+
+// DB locates the tag database.
+type DB struct {
+	Path    string `yaml:"path"`    // Directory of the database file; empty uses the profile's directory
+	Backend string `yaml:"backend"` // Storage engine; only "bolt" exists
+}
+
+// Library describes where the images are.
+type Library struct {
+	Roots   []string `yaml:"roots"`   // Folders scanned when none is given; see also availability
+	Exclude []string `yaml:"exclude"` // Patterns of files and folders to leave out, see Excluded
+}
+
+// Slideshow holds playback defaults.
+type Slideshow struct {
+	Interval    float64 `yaml:"interval"`     // Seconds per image; 0 keeps the default
+	HistorySize *int    `yaml:"history_size"` // Images kept in the navigation history; 0 disables it
+	SkipCount   int     `yaml:"skip_count"`   // Images skipped with Page Up and Page Down; 0 keeps the default
+	// Kiosk starts the viewer fullscreen, switching the filter by Schedule
+	Kiosk    bool            `yaml:"kiosk"`
+	Schedule []ScheduleEntry `yaml:"schedule"` // Filters by time of day in kiosk mode; the first active entry applies
+	Night    Night           `yaml:"night"`    // Dimmed and blank hours in kiosk mode
+}
+
+// OCR selects the engine that reads text from scanned documents.
+type OCR struct {
+	Engine   string `yaml:"engine"`   // tesseract command, or the http(s) URL of an OCR service; empty uses tesseract on the PATH
+	Language string `yaml:"language"` // tesseract languages, e.g. eng+deu; empty uses the engine's default
+}
+
+// Delete guards images against accidental deletion.
+type Delete struct {
+	// Images carrying one of these tags are only deleted with an extra
+	// confirmation; unset keeps service.DefaultProtectedTags, [] protects none
+	ProtectedTags []string `yaml:"protected_tags"`
+	Trash         *bool    `yaml:"trash"`      // Move deleted images to the trash instead of deleting them; unset is on
+	TrashDays     int      `yaml:"trash_days"` // Empty images out of the trash after this many days; 0 keeps them
+}
+
+// TrashOn reports whether deleted images go to the trash.
+func (d Delete) TrashOn() bool {
+	return d.Trash == nil || *d.Trash
+}
+
+// Roles of API users.
+const (
+	RoleViewer = "viewer" // Reads images and tags
+	RoleEditor = "editor" // Also changes tags
+)
+
+// minTokenLength is the shortest token an API user may have.
+const minTokenLength = 12
+
+// API configures access to the HTTP API and web front-end of serve-http.
+type API struct {
+	// Users who may sign in; without any the API is open to everyone, which
+	// serve-http only allows on localhost
+	Users []APIUser `yaml:"users"`
+}
+
+// APIUser is someone allowed to use the HTTP API.
+type APIUser struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"` // Secret sent as a bearer token, or as the password of basic auth
+	Role  string `yaml:"role"`  // RoleViewer or RoleEditor
+}
+
+// validate checks that the users have distinct names and tokens and known roles.
+func (a API) validate() error {
+	names := make(map[string]bool)
+	tokens := make(map[string]bool)
+	for _, user := range a.Users {
+		switch {
+		case user.Name == "":
+			return fmt.Errorf("API user without a name")
+		case names[user.Name]:
+			return fmt.Errorf("API user %q is listed twice", user.Name)
+		case len(user.Token) < minTokenLength:
+			return fmt.Errorf("token of API user %q is shorter than %d characters", user.Name, minTokenLength)
+		case tokens[user.Token]:
+			return fmt.Errorf("API user %q has the token of another user", user.Name)
+		case user.Role != RoleViewer && user.Role != RoleEditor:
+			return fmt.Errorf("API user %q has role %q, want %s or %s", user.Name, user.Role, RoleViewer, RoleEditor)
+		}
+		names[user.Name], tokens[user.Token] = true, true
+	}
+	return nil
+}
+
+// DefaultPath returns the config file in the FySlide config directory.
+func DefaultPath() (string, error) {
+	base, err := profile.BaseDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, FileName), nil
+}
+
+// Path returns the config file Load reads for path: path itself, else
+// $FYSLIDE_CONFIG, else DefaultPath. explicit reports whether the file was
+// named rather than defaulted.
+func Path(path string) (resolved string, explicit bool, err error) {
+	if path != "" {
+		return path, true, nil
+	}
+	if path = os.Getenv(EnvConfig); path != "" {
+		return path, true, nil
+	}
+	path, err = DefaultPath()
+	return path, false, err
+}
+
+// Load reads the config file at path, then applies the environment. An empty
+// path means $FYSLIDE_CONFIG or else DefaultPath, which may be missing; a file
+// named explicitly must exist. Unknown keys are errors, to catch typos.
+func Load(path string) (*Config, error) {
+	path, explicit, err := Path(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) { // io.EOF: empty file
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case errors.Is(err, fs.ErrNotExist) && !explicit:
+	default:
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if err := cfg.ApplyEnv(os.Getenv); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ApplyEnv overrides the file's settings with those of the environment, read
+// through getenv.
+func (c *Config) ApplyEnv(getenv func(string) string) error {
+	if v := getenv(EnvDBPath); v != "" {
+		c.DB.Path = v
+	}
+	if v := getenv(EnvLibraryRoots); v != "" {
+		c.Library.Roots = filepath.SplitList(v)
+	}
+	if v := getenv(EnvExclude); v != "" {
+		c.Library.Exclude = nil
+		for _, pattern := range strings.Split(v, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				c.Library.Exclude = append(c.Library.Exclude, pattern)
+			}
+		}
+	}
+	if v := getenv(EnvSlideshowInterval); v != "" {
+		interval, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", EnvSlideshowInterval, v, err)
+		}
+		c.Slideshow.Interval = interval
+	}
+	if v := getenv(EnvHistorySize); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", EnvHistorySize, v, err)
+		}
+		c.Slideshow.HistorySize = &size
+	}
+	if v := getenv(EnvSkipCount); v != "" {
+		count, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", EnvSkipCount, v, err)
+		}
+		c.Slideshow.SkipCount = count
+	}
+	return nil
+}
+
+// HookCommands returns the hooks keyed by event type, for events.RunHooks.
+func (c *Config) HookCommands() map[events.Type]string {
+	if c == nil {
+		return nil
+	}
+	hooks := make(map[events.Type]string, len(c.Hooks))
+	for name, command := range c.Hooks {
+		hooks[events.Type(name)] = command
+	}
+	return hooks
+}
+
+// hookEvents lists the event types hooks can run on.
+var hookEvents = []events.Type{events.ImageChanged, events.Paused, events.Resumed, events.TagAdded, events.TagRemoved, events.FilterChanged}
+
+// Validate checks the settings, expanding a leading ~ in paths.
+func (c *Config) Validate() error {
+	switch c.DB.Backend {
+	case "", BackendBolt:
+	default:
+		return fmt.Errorf("unsupported database backend %q (only %q is available)", c.DB.Backend, BackendBolt)
+	}
+	c.DB.Path = expandHome(c.DB.Path)
+	for i, root := range c.Library.Roots {
+		c.Library.Roots[i] = expandHome(root)
+	}
+	for _, pattern := range c.Library.Exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad exclude pattern %q: %w", pattern, err)
+		}
+	}
+	if c.Slideshow.Interval < 0 {
+		return fmt.Errorf("slideshow interval %v is negative", c.Slideshow.Interval)
+	}
+	if c.Slideshow.HistorySize != nil && *c.Slideshow.HistorySize < 0 {
+		return fmt.Errorf("history size %d is negative", *c.Slideshow.HistorySize)
+	}
+	if c.Slideshow.SkipCount < 0 {
+		return fmt.Errorf("skip count %d is negative", c.Slideshow.SkipCount)
+	}
+	for i, entry := range c.Slideshow.Schedule {
+		if err := entry.validate(); err != nil {
+			return fmt.Errorf("slideshow schedule entry %d (%s): %w", i+1, entry.Name, err)
+		}
+	}
+	if err := c.Slideshow.Night.validate(); err != nil {
+		return fmt.Errorf("slideshow night: %w", err)
+	}
+	if err := c.API.validate(); err != nil {
+		return err
+	}
+	if c.Delete.TrashDays < 0 {
+		return fmt.Errorf("trash days %d is negative", c.Delete.TrashDays)
+	}
+	for name := range c.Hooks {
+		if !slices.Contains(hookEvents, events.Type(name)) {
+			return fmt.Errorf("unknown hook event %q", name)
+		}
+	}
+	return nil
+}
+
+// expandHome replaces a leading ~ with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// Excluded reports whether path matches an exclude pattern. A pattern without
+// a slash, like "*.tmp" or "@eaDir", is matched against every element of the
+// path, so it excludes whole folders too; one with a slash is matched against
+// the whole path, with / as separator.
+func (c *Config) Excluded(path string) bool {
+	if c == nil || len(c.Library.Exclude) == 0 {
+		return false
+	}
+	slashed := filepath.ToSlash(path)
+	elements := strings.Split(slashed, "/")
+	for _, pattern := range c.Library.Exclude {
+		if strings.Contains(pattern, "/") {
+			if ok, _ := filepath.Match(pattern, slashed); ok {
+				return true
+			}
+			continue
+		}
+		for _, element := range elements {
+			if ok, _ := filepath.Match(pattern, element); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv(EnvDBPath, "")
+	t.Setenv(EnvSlideshowInterval, "")
+	path := writeConfig(t, `
+db:
+  path: /data/tags
+  backend: bolt
+library:
+  roots: [/mnt/photos, /mnt/scans]
+  exclude: ["@eaDir", "*.tmp"]
+slideshow:
+  interval: 4.5
+  history_size: 0
+delete:
+  protected_tags: []
+  trash_days: 30
+api:
+  users:
+    - {name: ann, token: correct-horse-battery, role: editor}
+    - {name: kitchen, token: staple-frame-display, role: viewer}
+hooks:
+  tag_added: notify-send "$FYSLIDE_TAG"
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.DB.Path != "/data/tags" || len(cfg.Library.Roots) != 2 || cfg.Slideshow.Interval != 4.5 {
+		t.Errorf("Load = %+v", cfg)
+	}
+	if cfg.Slideshow.HistorySize == nil || *cfg.Slideshow.HistorySize != 0 {
+		t.Errorf("history size = %v, want an explicit 0", cfg.Slideshow.HistorySize)
+	}
+	if cfg.Delete.ProtectedTags == nil || len(cfg.Delete.ProtectedTags) != 0 {
+		t.Errorf("protected tags = %#v, want an explicit empty list", cfg.Delete.ProtectedTags)
+	}
+	if !cfg.Delete.TrashOn() || cfg.Delete.TrashDays != 30 {
+		t.Errorf("delete = %+v, want the trash on by default, emptied after 30 days", cfg.Delete)
+	}
+	if len(cfg.API.Users) != 2 || cfg.API.Users[1].Role != RoleViewer {
+		t.Errorf("API users = %+v", cfg.API.Users)
+	}
+	if cfg.Hooks["tag_added"] == "" {
+		t.Error("hook not loaded")
+	}
+
+	// The environment overrides the file
+	t.Setenv(EnvDBPath, "/env/tags")
+	t.Setenv(EnvSlideshowInterval, "7")
+	if cfg, err = Load(path); err != nil || cfg.DB.Path != "/env/tags" || cfg.Slideshow.Interval != 7 {
+		t.Errorf("Load with environment = %+v, %v", cfg, err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown key":     "slidshow:\n  interval: 3\n",
+		"unknown backend": "db:\n  backend: sqlite\n",
+		"unknown hook":    "hooks:\n  image_deleted: rm -rf /\n",
+		"bad pattern":     "library:\n  exclude: ['[']\n",
+		"bad schedule":    "slideshow:\n  schedule:\n    - {days: [someday], filter: tag=x}\n",
+		"bad filter":      "slideshow:\n  schedule:\n    - {from: '08:00', filter: album=best}\n",
+		"open night":      "slideshow:\n  night: {from: '22:00'}\n",
+		"too bright":      "slideshow:\n  night: {from: '22:00', to: '07:00', brightness: 2}\n",
+		"short token":     "api:\n  users:\n    - {name: ann, token: secret, role: editor}\n",
+		"unknown role":    "api:\n  users:\n    - {name: ann, token: correct-horse-battery, role: admin}\n",
+	} {
+		if _, err := Load(writeConfig(t, content)); err == nil {
+			t.Errorf("%s: Load succeeded", name)
+		}
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "none.yaml")); err == nil {
+		t.Error("Load of a missing, explicitly named file succeeded")
+	}
+	if _, err := Load(writeConfig(t, "")); err != nil {
+		t.Errorf("Load of an empty file: %v", err)
+	}
+}
+
+func TestScheduledAt(t *testing.T) {
+	s := Slideshow{Schedule: []ScheduleEntry{
+		{Name: "weekends", Days: []string{"weekends"}, Filter: "tag=best-of"},
+		{Name: "mornings", From: "06:00", To: "12:00", Filter: "tag=family"},
+		{Name: "nights", Days: []string{"fri"}, From: "20:00", To: "02:00", Filter: "tag=landscape"},
+	}}
+	for at, want := range map[string]string{
+		"2024-05-06 07:30": "mornings", // Monday
+		"2024-05-06 12:00": "",
+		"2024-05-10 21:00": "nights",
+		"2024-05-11 01:59": "weekends", // Saturday, listed first
+		"2024-05-09 01:00": "",         // Thursday night isn't Friday's
+		"2024-05-12 10:00": "weekends",
+	} {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", at, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry, ok := s.ScheduledAt(tm)
+		if entry.Name != want || ok != (want != "") {
+			t.Errorf("ScheduledAt(%s) = %q, %v, want %q", at, entry.Name, ok, want)
+		}
+	}
+	s.Schedule = s.Schedule[2:]
+	tm := time.Date(2024, 5, 11, 1, 59, 0, 0, time.Local)
+	if entry, _ := s.ScheduledAt(tm); entry.Name != "nights" {
+		t.Errorf("Friday's night entry is not active early on Saturday: %q", entry.Name)
+	}
+}
+
+func TestNightStateAt(t *testing.T) {
+	n := Night{From: "21:00", To: "07:00", BlankFrom: "00:30", BlankTo: "06:00"}
+	for clock, want := range map[string]NightState{
+		"12:00": Day,
+		"21:00": Dimmed,
+		"00:29": Dimmed,
+		"00:30": Blank,
+		"05:59": Blank,
+		"06:30": Dimmed,
+		"07:00": Day,
+	} {
+		tm, err := time.ParseInLocation("15:04", clock, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := n.StateAt(tm); got != want {
+			t.Errorf("StateAt(%s) = %d, want %d", clock, got, want)
+		}
+	}
+	if got := (Night{}).StateAt(time.Now()); got != Day {
+		t.Errorf("unset night hours give state %d, want Day", got)
+	}
+	if n.DimBrightness() != DefaultNightBrightness {
+		t.Errorf("DimBrightness = %v, want the default", n.DimBrightness())
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	cfg := &Config{Library: Library{Exclude: []string{"@eaDir", "*.tmp", "/mnt/photos/private/*"}}}
+	for path, want := range map[string]bool{
+		"/mnt/photos/2019/a.jpg":         false,
+		"/mnt/photos/@eaDir/a.jpg":       true,
+		"/mnt/photos/2019/a.tmp":         true,
+		"/mnt/photos/private/a.jpg":      true,
+		"/mnt/photos/private/deep/a.jpg": false,
+		"/mnt/photos/not-private/a.jpg":  false,
+	} {
+		if got := cfg.Excluded(filepath.FromSlash(path)); got != want {
+			t.Errorf("Excluded(%s) = %v, want %v", path, got, want)
+		}
+	}
+	var none *Config
+	if none.Excluded("/a.jpg") {
+		t.Error("nil config excluded a path")
+	}
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"fyslide/internal/query"
+	"strings"
+	"time"
+)
+
+// minutesPerDay bounds the clock times of a schedule entry.
+const minutesPerDay = 24 * 60
+
+// ScheduleEntry shows the images matching a filter during part of the day,
+// e.g. the family photos in the mornings. An entry whose To is not after its
+// From runs past midnight into the next day.
+type ScheduleEntry struct {
+	Name   string   `yaml:"name"`
+	Days   []string `yaml:"days"`   // mon..sun, weekdays or weekends; empty is every day
+	From   string   `yaml:"from"`   // Start time as HH:MM; empty is midnight
+	To     string   `yaml:"to"`     // End time as HH:MM, excluded; empty is midnight
+	Filter string   `yaml:"filter"` // Criteria as read by query.Parse, e.g. tag=family; empty shows all images
+}
+
+// dayNames maps the day names of ScheduleEntry.Days to the days they cover.
+var dayNames = map[string][]time.Weekday{
+	"mon": {time.Monday}, "tue": {time.Tuesday}, "wed": {time.Wednesday}, "thu": {time.Thursday},
+	"fri": {time.Friday}, "sat": {time.Saturday}, "sun": {time.Sunday},
+	"weekdays": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekends": {time.Saturday, time.Sunday},
+}
+
+// Criteria returns the filter of e.
+func (e ScheduleEntry) Criteria() (query.Criteria, error) {
+	return query.Parse(e.Filter)
+}
+
+// validate checks the days, times and filter of e.
+func (e ScheduleEntry) validate() error {
+	for _, day := range e.Days {
+		if _, ok := dayNames[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("unknown day %q (use mon..sun, weekdays or weekends)", day)
+		}
+	}
+	if _, err := parseClock(e.From); err != nil {
+		return err
+	}
+	if _, err := parseClock(e.To); err != nil {
+		return err
+	}
+	_, err := e.Criteria()
+	return err
+}
+
+// covers reports whether e is active at t.
+func (e ScheduleEntry) covers(t time.Time) bool {
+	return within(e.From, e.To, t, e.onDay)
+}
+
+// within reports whether t lies between the HH:MM times from and to on a day
+// onDay accepts. A range whose to is not after its from runs past midnight and
+// belongs to the day it starts on.
+func within(from, to string, t time.Time, onDay func(time.Weekday) bool) bool {
+	start, _ := parseClock(from) // Checked when the config was loaded
+	end, _ := parseClock(to)
+	if end <= start {
+		end += minutesPerDay
+	}
+	now := t.Hour()*60 + t.Minute()
+	if now >= start && now < end {
+		return onDay(t.Weekday())
+	}
+	// Still running from the day before, past midnight
+	return now+minutesPerDay < end && onDay((t.Weekday()+6)%7)
+}
+
+// onDay reports whether e runs on day.
+func (e ScheduleEntry) onDay(day time.Weekday) bool {
+	if len(e.Days) == 0 {
+		return true
+	}
+	for _, name := range e.Days {
+		for _, d := range dayNames[strings.ToLower(name)] {
+			if d == day {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseClock returns the minutes past midnight of an HH:MM time, 0 for "".
+func parseClock(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("time %q is not HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// DefaultNightBrightness is the share of the normal brightness images are
+// shown at during night hours unless configured otherwise.
+const DefaultNightBrightness = 0.3
+
+// Night dims the display in kiosk mode during the night and can blank it for
+// part of it, e.g. while everyone is asleep.
+type Night struct {
+	From       string  `yaml:"from"`       // HH:MM the display dims; empty with To leaves it bright
+	To         string  `yaml:"to"`         // HH:MM it is bright again
+	Brightness float64 `yaml:"brightness"` // Share of the normal brightness, 0..1; 0 keeps DefaultNightBrightness
+	BlankFrom  string  `yaml:"blank_from"` // HH:MM the display goes blank and the slideshow pauses; empty never blanks
+	BlankTo    string  `yaml:"blank_to"`   // HH:MM it wakes up again
+}
+
+// NightState is how the display is shown at a time of day.
+type NightState int
+
+// Night states, from bright to blank.
+const (
+	Day NightState = iota
+	Dimmed
+	Blank
+)
+
+// StateAt returns how the display is shown at t. Blank hours take precedence
+// over dimmed ones.
+func (n Night) StateAt(t time.Time) NightState {
+	always := func(time.Weekday) bool { return true }
+	switch {
+	case n.BlankFrom != "" && within(n.BlankFrom, n.BlankTo, t, always):
+		return Blank
+	case n.From != "" && within(n.From, n.To, t, always):
+		return Dimmed
+	}
+	return Day
+}
+
+// DimBrightness returns the share of the normal brightness for dimmed hours.
+func (n Night) DimBrightness() float64 {
+	if n.Brightness == 0 {
+		return DefaultNightBrightness
+	}
+	return n.Brightness
+}
+
+// validate checks the times and the brightness of n.
+func (n Night) validate() error {
+	if (n.From == "") != (n.To == "") || (n.BlankFrom == "") != (n.BlankTo == "") {
+		return fmt.Errorf("night hours need both a start and an end time")
+	}
+	for _, clock := range []string{n.From, n.To, n.BlankFrom, n.BlankTo} {
+		if _, err := parseClock(clock); err != nil {
+			return err
+		}
+	}
+	if n.Brightness < 0 || n.Brightness > 1 {
+		return fmt.Errorf("night brightness %v is not between 0 and 1", n.Brightness)
+	}
+	return nil
+}
+
+// ScheduledAt returns the first entry of the schedule active at t, and
+// whether there is one. Listing specific entries such as weekends first
+// lets them take precedence over the everyday ones.
+func (s Slideshow) ScheduledAt(t time.Time) (ScheduleEntry, bool) {
+	for _, entry := range s.Schedule {
+		if entry.covers(t) {
+			return entry, true
+		}
+	}
+	return ScheduleEntry{}, false
+}
@@ -0,0 +1,173 @@
+// Package decode is the image decoding service shared by the viewer, the
+// thumbnails and background work. Decodes run on a bounded number of workers,
+// highest priority first, so large batches don't oversubscribe the CPU, and
+// requests for an image already queued or being decoded share its result.
+package decode
+
+import (
+	"context"
+	"fyslide/internal/iosched"
+	"image"
+	_ "image/gif" // Register the decoders of the supported formats
+	_ "image/jpeg"
+	_ "image/png"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// job is the decode of one file, shared by every request for it.
+type job struct {
+	path    string
+	class   iosched.Class // Highest priority among its requests until it starts
+	waiters int           // Requests still waiting for the result
+	started bool
+	ctx     context.Context // Cancelled once no request waits any more
+	cancel  context.CancelFunc
+	done    chan struct{} // Closed when img, format and err are set
+	img     image.Image
+	format  string
+	err     error
+}
+
+// Pool decodes images on at most a fixed number of workers. Requests carry
+// the priority classes of iosched, and files are read through the Pool's
+// scheduler in the class of the request. The background classes, thumbnails
+// and maintenance, leave one worker free for the foreground and prefetch.
+//
+// A nil Pool decodes every request directly.
+type Pool struct {
+	io      *iosched.Scheduler
+	workers int
+
+	mu         sync.Mutex
+	jobs       map[string]*job // Queued or running, by path
+	queue      []*job          // Not started, oldest first
+	running    int
+	background int // Running jobs of a background class
+
+	decodeFile func(ctx context.Context, s *iosched.Scheduler, class iosched.Class, path string) (image.Image, string, error)
+}
+
+// NewPool creates a Pool of workers decoders reading through io, which may be
+// nil. workers of 0 or less uses one per CPU.
+func NewPool(workers int, io *iosched.Scheduler) *Pool {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &Pool{io: io, workers: workers, jobs: make(map[string]*job), decodeFile: decodeFile}
+}
+
+// decodeFile reads and decodes the image at path in class.
+func decodeFile(ctx context.Context, s *iosched.Scheduler, class iosched.Class, path string) (image.Image, string, error) {
+	file, err := s.Open(ctx, class, path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+	return image.Decode(file)
+}
+
+// background reports whether class is one that must leave a worker free.
+func background(class iosched.Class) bool {
+	return class >= iosched.Thumbnails
+}
+
+// Decode returns the decoded image at path and its format name. A request
+// for an image already queued or being decoded waits for that decode instead,
+// raising its priority to class if it has not started yet. If ctx ends first,
+// Decode returns ctx's error, and the decode is dropped if no other request
+// waits for it.
+func (p *Pool) Decode(ctx context.Context, class iosched.Class, path string) (image.Image, string, error) {
+	if p == nil {
+		return decodeFile(ctx, nil, class, path)
+	}
+	p.mu.Lock()
+	j := p.jobs[path]
+	if j == nil {
+		j = &job{path: path, class: class, done: make(chan struct{})}
+		j.ctx, j.cancel = context.WithCancel(context.Background())
+		p.jobs[path] = j
+		p.queue = append(p.queue, j)
+	} else if !j.started && class < j.class {
+		j.class = class
+	}
+	j.waiters++
+	p.dispatch()
+	p.mu.Unlock()
+
+	select {
+	case <-j.done:
+		return j.img, j.format, j.err
+	case <-ctx.Done():
+		p.mu.Lock()
+		j.waiters--
+		if j.waiters == 0 {
+			j.cancel()
+			if p.jobs[path] == j {
+				delete(p.jobs, path) // Later requests start afresh
+			}
+			if !j.started {
+				p.queue = slices.DeleteFunc(p.queue, func(q *job) bool { return q == j })
+			}
+		}
+		p.mu.Unlock()
+		return nil, "", ctx.Err()
+	}
+}
+
+// Open opens path for reading in class through the Pool's scheduler, for
+// callers reading only a small part of a file, like its embedded thumbnail,
+// rather than decoding it. It does not take a worker.
+func (p *Pool) Open(ctx context.Context, class iosched.Class, path string) (*iosched.File, error) {
+	var s *iosched.Scheduler
+	if p != nil {
+		s = p.io
+	}
+	return s.Open(ctx, class, path)
+}
+
+// dispatch starts queued jobs while workers are free, highest priority first.
+// The caller must hold p.mu.
+func (p *Pool) dispatch() {
+	for p.running < p.workers {
+		best := -1
+		for i, j := range p.queue {
+			if background(j.class) && p.workers > 1 && p.background >= p.workers-1 {
+				continue
+			}
+			if best < 0 || j.class < p.queue[best].class {
+				best = i
+			}
+		}
+		if best < 0 {
+			return
+		}
+		j := p.queue[best]
+		p.queue = slices.Delete(p.queue, best, best+1)
+		j.started = true
+		p.running++
+		if background(j.class) {
+			p.background++
+		}
+		go p.run(j)
+	}
+}
+
+// run decodes j on a worker and hands the result to its requests.
+func (p *Pool) run(j *job) {
+	j.img, j.format, j.err = p.decodeFile(j.ctx, p.io, j.class, j.path)
+	j.cancel()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.jobs[j.path] == j {
+		delete(p.jobs, j.path)
+	}
+	p.running--
+	if background(j.class) {
+		p.background--
+	}
+	close(j.done)
+	p.dispatch()
+}
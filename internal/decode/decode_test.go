@@ -0,0 +1,164 @@
+package decode
+
+import (
+	"context"
+	"errors"
+	"fyslide/internal/iosched"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingPool returns a Pool whose decodes wait for release and record the
+// paths they decode, in order.
+func blockingPool(workers int) (p *Pool, release chan struct{}, decoded func() []string) {
+	p = NewPool(workers, nil)
+	release = make(chan struct{})
+	var mu sync.Mutex
+	var paths []string
+	p.decodeFile = func(ctx context.Context, _ *iosched.Scheduler, _ iosched.Class, path string) (image.Image, string, error) {
+		mu.Lock()
+		paths = append(paths, path)
+		mu.Unlock()
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		}
+		return image.NewGray(image.Rect(0, 0, 1, 1)), "test", nil
+	}
+	decoded = func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), paths...)
+	}
+	return p, release, decoded
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRequestsCoalesce(t *testing.T) {
+	p, release, decoded := blockingPool(2)
+	results := make(chan image.Image, 3)
+	for range 3 {
+		go func() {
+			img, _, _ := p.Decode(context.Background(), iosched.Thumbnails, "a.png")
+			results <- img
+		}()
+	}
+	waitFor(t, "the requests to queue", func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		j := p.jobs["a.png"]
+		return j != nil && j.waiters == 3
+	})
+	close(release)
+	first := <-results
+	for range 2 {
+		if img := <-results; img != first {
+			t.Error("Coalesced requests got different images")
+		}
+	}
+	if got := decoded(); len(got) != 1 {
+		t.Errorf("Decoded %v, want a.png once", got)
+	}
+}
+
+func TestPriorityOrder(t *testing.T) {
+	p, release, decoded := blockingPool(1)
+	done := make(chan struct{}, 3)
+	decodeAs := func(class iosched.Class, path string) {
+		p.Decode(context.Background(), class, path)
+		done <- struct{}{}
+	}
+	go decodeAs(iosched.Prefetch, "busy.png")
+	waitFor(t, "the first decode", func() bool { return len(decoded()) == 1 })
+	go decodeAs(iosched.Prefetch, "prefetch.png")
+	waitFor(t, "the prefetch to queue", func() bool { return p.queued() == 1 })
+	go decodeAs(iosched.Foreground, "shown.png")
+	waitFor(t, "the foreground to queue", func() bool { return p.queued() == 2 })
+
+	close(release)
+	for range 3 {
+		<-done
+	}
+	want := []string{"busy.png", "shown.png", "prefetch.png"}
+	if got := decoded(); !slices.Equal(got, want) {
+		t.Errorf("Decode order = %v, want %v", got, want)
+	}
+}
+
+func TestBackgroundLeavesAWorker(t *testing.T) {
+	p, release, decoded := blockingPool(2)
+	defer close(release)
+	go p.Decode(context.Background(), iosched.Thumbnails, "t1.png")
+	go p.Decode(context.Background(), iosched.Thumbnails, "t2.png")
+	waitFor(t, "the thumbnails to queue", func() bool { return len(decoded())+p.queued() == 2 })
+	if got := decoded(); len(got) != 1 {
+		t.Fatalf("Background decodes running = %v, want one", got)
+	}
+	go p.Decode(context.Background(), iosched.Foreground, "shown.png")
+	waitFor(t, "the foreground decode to start", func() bool { return len(decoded()) == 2 })
+	if got := decoded()[1]; got != "shown.png" {
+		t.Errorf("Second worker decoded %s, want shown.png", got)
+	}
+}
+
+func TestCancelledRequest(t *testing.T) {
+	p, release, decoded := blockingPool(1)
+	defer close(release)
+	go p.Decode(context.Background(), iosched.Foreground, "busy.png")
+	waitFor(t, "the first decode", func() bool { return len(decoded()) == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := p.Decode(ctx, iosched.Maintenance, "later.png"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Decode = %v, want the context's error", err)
+	}
+	if n := p.queued(); n != 0 {
+		t.Errorf("Abandoned decode still queued: %d jobs", n)
+	}
+}
+
+func TestDecodeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "img.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewGray(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	for _, p := range []*Pool{NewPool(0, iosched.New(0)), nil} {
+		img, format, err := p.Decode(context.Background(), iosched.Thumbnails, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format != "png" || img.Bounds().Dx() != 3 {
+			t.Errorf("Decode = %s %v, want a 3x2 png", format, img.Bounds())
+		}
+	}
+}
+
+// queued returns the number of jobs waiting for a worker.
+func (p *Pool) queued() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.queue)
+}
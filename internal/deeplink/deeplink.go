@@ -0,0 +1,62 @@
+// Package deeplink handles fyslide:// links, with which other apps open the
+// viewer at an image or folder, and registers the viewer as their handler.
+package deeplink
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// Scheme is the URI scheme of links into the viewer.
+const Scheme = "fyslide"
+
+// openAction is the host of links opening an image or folder, as in
+// fyslide://open?path=/home/me/Pictures/a.jpg.
+const openAction = "open"
+
+// OpenURL returns the link opening the viewer at the image or folder path.
+func OpenURL(path string) string {
+	u := url.URL{Scheme: Scheme, Host: openAction, RawQuery: url.Values{"path": {path}}.Encode()}
+	return u.String()
+}
+
+// IsLink reports whether arg is a fyslide:// link rather than a path.
+func IsLink(arg string) bool {
+	return strings.HasPrefix(strings.ToLower(arg), Scheme+":")
+}
+
+// Target returns the absolute path of the image or folder arg names: a
+// fyslide://open link, a file:// URI or a path, relative to the working
+// directory. Links must give absolute paths, as the directory they were made
+// in means nothing to the viewer.
+func Target(arg string) (string, error) {
+	lower := strings.ToLower(arg)
+	switch {
+	case IsLink(arg):
+		u, err := url.Parse(arg)
+		if err != nil {
+			return "", fmt.Errorf("bad link %s: %w", arg, err)
+		}
+		if u.Host != openAction {
+			return "", fmt.Errorf("unknown action %q in link %s, want %s", u.Host, arg, openAction)
+		}
+		path := u.Query().Get("path")
+		if !filepath.IsAbs(path) {
+			return "", fmt.Errorf("link %s must give an absolute path", arg)
+		}
+		return filepath.Clean(path), nil
+	case strings.HasPrefix(lower, "file://"):
+		u, err := url.Parse(arg)
+		if err != nil {
+			return "", fmt.Errorf("bad file URI %s: %w", arg, err)
+		}
+		return filepath.Clean(filepath.FromSlash(u.Path)), nil
+	}
+	path, err := filepath.Abs(arg)
+	if err != nil {
+		return "", fmt.Errorf("error getting absolute path of %s: %w", arg, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,92 @@
+package deeplink
+
+import (
+	"fyslide/internal/query"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTarget(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	photo := filepath.Join(wd, "my photos", "a&b.jpg")
+	tests := map[string]string{
+		OpenURL(photo):                          photo,
+		"fyslide://open?path=%2Fp%2F..%2Fa.jpg": filepath.Clean("/a.jpg"),
+		"file:///p/a%20b.jpg":                   filepath.FromSlash("/p/a b.jpg"),
+		"a.jpg":                                 filepath.Join(wd, "a.jpg"),
+	}
+	for arg, want := range tests {
+		if got, err := Target(arg); err != nil || got != want {
+			t.Errorf("Target(%q) = %q, %v; want %q", arg, got, err, want)
+		}
+	}
+	for _, bad := range []string{"fyslide://open?path=a.jpg", "fyslide://delete?path=/p/a.jpg", "fyslide://open"} {
+		if got, err := Target(bad); err == nil {
+			t.Errorf("Target(%q) = %q, want an error", bad, got)
+		}
+	}
+	if !IsLink("FySlide://open?path=/a.jpg") || IsLink("/p/fyslide:a.jpg") {
+		t.Error("IsLink must only match the scheme")
+	}
+}
+
+func TestRegisterXDG(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("desktop entries are for freedesktop.org desktops")
+	}
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("PATH", t.TempDir()) // No xdg-mime
+	path, err := Register("/opt/my apps/fyslide")
+	if err == nil || !strings.Contains(err.Error(), "xdg-mime") {
+		t.Errorf("Register without xdg-mime: %v, want an error naming it", err)
+	}
+	data, readErr := os.ReadFile(path)
+	if readErr != nil {
+		t.Fatalf("desktop entry was not written: %v", readErr)
+	}
+	for _, line := range []string{`Exec="/opt/my apps/fyslide" %u`, "MimeType=x-scheme-handler/fyslide;"} {
+		if !strings.Contains(string(data), line+"\n") {
+			t.Errorf("desktop entry lacks %q:\n%s", line, data)
+		}
+	}
+}
+
+func TestViewURL(t *testing.T) {
+	filter, err := query.Parse("tag=beach, tag=sunset, folder=/photos/Rome\\, Italy, from=2023-06-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := View{Roots: []string{"/photos", "/mnt/nas"}, Filter: filter, Path: "/photos/Rome, Italy/a b.jpg", Index: 7}
+	link := ViewURL(v)
+	if !IsView(link) || IsView(OpenURL("/photos/a.jpg")) {
+		t.Errorf("IsView(%q) must only match view links", link)
+	}
+	got, err := ParseView(" " + link + "\n")
+	if err != nil {
+		t.Fatalf("ParseView(%q): %v", link, err)
+	}
+	if !slices.Equal(got.Roots, v.Roots) || got.Filter.Signature() != v.Filter.Signature() || got.Shuffle || got.Path != v.Path || got.Index != v.Index {
+		t.Errorf("ParseView(ViewURL(v)) = %+v, want %+v", got, v)
+	}
+	if got, err := ParseView(ViewURL(View{Shuffle: true})); err != nil || !got.Shuffle || !got.Filter.IsEmpty() || got.Roots != nil {
+		t.Errorf("shuffled view of everything = %+v, %v", got, err)
+	}
+	for _, bad := range []string{
+		"fyslide://view?root=photos",
+		"fyslide://view?filter=album%3Dbest",
+		"fyslide://view?order=backwards",
+		"fyslide://view?path=/p/a.jpg&index=-1",
+		OpenURL("/photos/a.jpg"),
+	} {
+		if _, err := ParseView(bad); err == nil {
+			t.Errorf("ParseView(%q) accepted it", bad)
+		}
+	}
+}
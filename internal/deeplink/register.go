@@ -0,0 +1,93 @@
+package deeplink
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// desktopFileName is the name of the desktop entry handling fyslide:// links
+// on freedesktop.org desktops.
+const desktopFileName = "fyslide-link.desktop"
+
+// Register makes the viewer at executable the handler of fyslide:// links
+// for the current user, returning where it was registered. On Linux and the
+// BSDs it writes a desktop entry and sets it as the default handler with
+// xdg-mime; on Windows it adds the scheme to the user's registry classes.
+// Other platforms register URL schemes in the app bundle instead.
+func Register(executable string) (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		return registerWindows(executable)
+	case "darwin", "ios", "android", "plan9", "js":
+		return "", fmt.Errorf("registering %s:// links is not supported on %s", Scheme, runtime.GOOS)
+	}
+	return registerXDG(executable)
+}
+
+// desktopEntry returns the desktop entry running executable on links.
+func desktopEntry(executable string) string {
+	return fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=FySlide
+Comment=Open %[1]s:// links in the FySlide viewer
+Exec=%[2]s %%u
+Icon=fyslide
+Terminal=false
+NoDisplay=true
+MimeType=x-scheme-handler/%[1]s;
+`, Scheme, quoteExec(executable))
+}
+
+// quoteExec quotes an argument of a desktop entry's Exec key.
+func quoteExec(arg string) string {
+	if !strings.ContainsAny(arg, " \t\"'`$\\<>~|&;*?#()") {
+		return arg
+	}
+	escaped := strings.NewReplacer(`\`, `\\\\`, `"`, `\\"`, "`", "\\\\`", `$`, `\\$`).Replace(arg)
+	return `"` + escaped + `"`
+}
+
+// registerXDG writes the desktop entry to $XDG_DATA_HOME/applications and
+// makes it the default handler of the scheme.
+func registerXDG(executable string) (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not get home dir: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	dir := filepath.Join(dataHome, "applications")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, desktopFileName)
+	if err := os.WriteFile(path, []byte(desktopEntry(executable)), 0o644); err != nil {
+		return "", fmt.Errorf("writing %s: %w", path, err)
+	}
+	out, err := exec.Command("xdg-mime", "default", desktopFileName, "x-scheme-handler/"+Scheme).CombinedOutput()
+	if err != nil {
+		return path, fmt.Errorf("wrote %s, but xdg-mime could not make it the handler: %v %s", path, err, strings.TrimSpace(string(out)))
+	}
+	return path, nil
+}
+
+// registerWindows adds the scheme to HKEY_CURRENT_USER\Software\Classes.
+func registerWindows(executable string) (string, error) {
+	key := `HKCU\Software\Classes\` + Scheme
+	for _, args := range [][]string{
+		{"add", key, "/ve", "/d", "URL:FySlide", "/f"},
+		{"add", key, "/v", "URL Protocol", "/d", "", "/f"},
+		{"add", key + `\shell\open\command`, "/ve", "/d", fmt.Sprintf(`"%s" "%%1"`, executable), "/f"},
+	} {
+		if out, err := exec.Command("reg", args...).CombinedOutput(); err != nil {
+			return "", fmt.Errorf("reg %s: %v %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,97 @@
+package deeplink
+
+import (
+	"fmt"
+	"fyslide/internal/query"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// viewAction is the host of links reproducing a view of the library.
+const viewAction = "view"
+
+// Playback orders of a view.
+const (
+	orderShuffle    = "shuffle"
+	orderSequential = "sequential"
+)
+
+// View is what the viewer shows: which library, filtered how, in which
+// order, and at which image, so it can be reproduced elsewhere.
+type View struct {
+	Roots   []string // Library folders; none means the receiver's own
+	Filter  query.Criteria
+	Shuffle bool   // Random order rather than the order of the list
+	Path    string // The image shown, if any
+	Index   int    // Its position in the filtered list, used when Path is missing
+}
+
+// ViewURL returns the link to v, e.g.
+// fyslide://view?root=%2Fphotos&filter=tag%3Dbeach&order=sequential&path=%2Fphotos%2Fa.jpg&index=3.
+// The filter is written as query.Format does.
+func ViewURL(v View) string {
+	values := url.Values{"root": v.Roots}
+	if filter := query.Format(v.Filter); filter != "" {
+		values.Set("filter", filter)
+	}
+	values.Set("order", orderSequential)
+	if v.Shuffle {
+		values.Set("order", orderShuffle)
+	}
+	if v.Path != "" {
+		values.Set("path", v.Path)
+		values.Set("index", strconv.Itoa(v.Index))
+	}
+	u := url.URL{Scheme: Scheme, Host: viewAction, RawQuery: values.Encode()}
+	return u.String()
+}
+
+// IsView reports whether arg is a link to a view.
+func IsView(arg string) bool {
+	u, err := url.Parse(strings.TrimSpace(arg))
+	return err == nil && strings.EqualFold(u.Scheme, Scheme) && u.Host == viewAction
+}
+
+// ParseView reads a link written by ViewURL.
+func ParseView(link string) (View, error) {
+	link = strings.TrimSpace(link)
+	u, err := url.Parse(link)
+	if err != nil {
+		return View{}, fmt.Errorf("bad link %s: %w", link, err)
+	}
+	if !strings.EqualFold(u.Scheme, Scheme) || u.Host != viewAction {
+		return View{}, fmt.Errorf("%s is not a %s://%s link", link, Scheme, viewAction)
+	}
+	q := u.Query()
+	var v View
+	for _, root := range q["root"] {
+		if !filepath.IsAbs(root) {
+			return View{}, fmt.Errorf("link %s must give absolute library folders", link)
+		}
+		v.Roots = append(v.Roots, filepath.Clean(root))
+	}
+	if v.Filter, err = query.Parse(q.Get("filter")); err != nil {
+		return View{}, fmt.Errorf("link %s: %w", link, err)
+	}
+	switch order := q.Get("order"); order {
+	case orderShuffle:
+		v.Shuffle = true
+	case orderSequential, "":
+	default:
+		return View{}, fmt.Errorf("link %s: unknown order %q", link, order)
+	}
+	if v.Path = q.Get("path"); v.Path != "" {
+		if !filepath.IsAbs(v.Path) {
+			return View{}, fmt.Errorf("link %s must give an absolute path", link)
+		}
+		v.Path = filepath.Clean(v.Path)
+	}
+	if index := q.Get("index"); index != "" {
+		if v.Index, err = strconv.Atoi(index); err != nil || v.Index < 0 {
+			return View{}, fmt.Errorf("link %s: bad index %q", link, index)
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,133 @@
+// Package dirtags reads the default tags a folder declares in a .fyslide-tags
+// file and works out which of its images still lack them.
+//
+// The file lists tags separated by commas or newlines; everything after a '#'
+// on a line is a comment. The tags apply to the images directly in that
+// folder, not to those in subfolders, which can declare their own.
+package dirtags
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/scan"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FileName is the name of the per-folder defaults file.
+const FileName = ".fyslide-tags"
+
+// Parse reads tags from a defaults file, lowercased and without duplicates,
+// in the order they are listed.
+func Parse(r io.Reader) ([]string, error) {
+	var tags []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		for _, tag := range strings.Split(line, ",") {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag != "" && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags, scanner.Err()
+}
+
+// Load returns the default tags of dir, or nil if it has no defaults file.
+func Load(dir string) ([]string, error) {
+	f, err := os.Open(filepath.Join(dir, FileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tags, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", f.Name(), err)
+	}
+	return tags, nil
+}
+
+// TagSource looks up the current tags of an image, e.g. TagDB.GetTags.
+type TagSource func(ctx context.Context, path string) ([]string, error)
+
+// Options select the images Plan considers.
+type Options struct {
+	Recursive    bool // Also visit subfolders, each with its own defaults file
+	OnlyUntagged bool // Leave images that already carry any tag alone
+}
+
+// Change lists the default tags an image is missing.
+type Change struct {
+	Path string
+	Tags []string
+}
+
+// Plan returns, for every image in dir that is missing some of its folder's
+// default tags, the tags to add. Folders without a defaults file are skipped.
+// It stops with ctx's error if ctx ends first.
+func Plan(ctx context.Context, dir string, getTags TagSource, opts Options) ([]Change, error) {
+	var changes []Change
+	visit := func(folder string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		defaults, err := Load(folder)
+		if err != nil || len(defaults) == 0 {
+			return err
+		}
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			return fmt.Errorf("error reading directory %s: %w", folder, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !scan.IsImage(entry.Name()) {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			path := filepath.Join(folder, entry.Name())
+			current, err := getTags(ctx, path)
+			if err != nil {
+				return fmt.Errorf("error getting tags for %s: %w", path, err)
+			}
+			if opts.OnlyUntagged && len(current) > 0 {
+				continue
+			}
+			var missing []string
+			for _, tag := range defaults {
+				if !slices.Contains(current, tag) {
+					missing = append(missing, tag)
+				}
+			}
+			if len(missing) > 0 {
+				changes = append(changes, Change{Path: path, Tags: missing})
+			}
+		}
+		return nil
+	}
+
+	if !opts.Recursive {
+		return changes, visit(dir)
+	}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return visit(path)
+	})
+	return changes, err
+}
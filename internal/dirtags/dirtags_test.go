@@ -0,0 +1,71 @@
+package dirtags
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tags, err := Parse(strings.NewReader("# Summer trip\nBeach, sunset\n\nfamily # everyone\nbeach\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"beach", "sunset", "family"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("Parse = %v, want %v", tags, want)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(root, FileName): "trip, 2024",
+		filepath.Join(root, "a.jpg"):  "",
+		filepath.Join(root, "b.png"):  "",
+		filepath.Join(root, "c.txt"):  "",
+		filepath.Join(sub, FileName):  "sub",
+		filepath.Join(sub, "d.jpg"):   "",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tagged := map[string][]string{filepath.Join(root, "b.png"): {"trip"}}
+	getTags := func(_ context.Context, path string) ([]string, error) { return tagged[path], nil }
+
+	changes, err := Plan(context.Background(), root, getTags, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Path: filepath.Join(root, "a.jpg"), Tags: []string{"trip", "2024"}},
+		{Path: filepath.Join(root, "b.png"), Tags: []string{"2024"}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Plan = %+v, want %+v", changes, want)
+	}
+
+	changes, err = Plan(context.Background(), root, getTags, Options{Recursive: true, OnlyUntagged: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Change{
+		{Path: filepath.Join(root, "a.jpg"), Tags: []string{"trip", "2024"}},
+		{Path: filepath.Join(sub, "d.jpg"), Tags: []string{"sub"}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("recursive untagged Plan = %+v, want %+v", changes, want)
+	}
+
+	if changes, err := Plan(context.Background(), sub+"/missing", getTags, Options{}); err != nil || len(changes) != 0 {
+		t.Errorf("Plan of a folder without defaults = %v, %v", changes, err)
+	}
+}
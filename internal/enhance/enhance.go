@@ -0,0 +1,123 @@
+// Package enhance computes automatic tonal corrections, such as a levels
+// stretch, from an image's histogram.
+package enhance
+
+import (
+	"image"
+	"image/draw"
+)
+
+const (
+	clipFraction = 0.005 // Share of the darkest and of the brightest pixels clipped by AutoLevels
+	minRange     = 16    // Narrowest luminance range stretched; flatter images are left alone
+	maxSamples   = 1 << 20
+)
+
+// Levels maps the luminance range [Low, High] onto the full range [0, 255].
+type Levels struct {
+	Low  uint8
+	High uint8
+}
+
+// IsIdentity reports whether applying l would leave an image unchanged.
+func (l Levels) IsIdentity() bool {
+	return l.Low == 0 && l.High == 255
+}
+
+// ComputeLevels returns the levels stretching img's luminance so that the
+// darkest and brightest half percent of its pixels become black and white.
+// Large images are sampled on a grid. Images with hardly any tonal range,
+// such as a blank page, get the identity levels rather than amplified noise.
+func ComputeLevels(img image.Image) Levels {
+	b := img.Bounds()
+	if b.Empty() {
+		return Levels{0, 255}
+	}
+	step := 1
+	for (b.Dx()/step)*(b.Dy()/step) > maxSamples {
+		step++
+	}
+	var hist [256]int
+	n := 0
+	for y := b.Min.Y; y < b.Max.Y; y += step {
+		for x := b.Min.X; x < b.Max.X; x += step {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			hist[(299*r+587*g+114*bl)/1000>>8]++
+			n++
+		}
+	}
+	clip := int(float64(n) * clipFraction)
+	low, high := 0, 255
+	for count := hist[low]; count <= clip && low < 255; count += hist[low] {
+		low++
+	}
+	for count := hist[high]; count <= clip && high > 0; count += hist[high] {
+		high--
+	}
+	if high-low < minRange {
+		return Levels{0, 255}
+	}
+	return Levels{Low: uint8(low), High: uint8(high)}
+}
+
+// Apply returns a copy of img with every color channel stretched by l. The
+// same mapping is used for all channels, so colors keep their balance.
+func (l Levels) Apply(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, img, b.Min, draw.Src)
+	if l.IsIdentity() || l.High <= l.Low {
+		return dst
+	}
+	var table [256]uint8
+	span := int(l.High) - int(l.Low)
+	for v := range table {
+		table[v] = uint8(min(max((v-int(l.Low))*255/span, 0), 255))
+	}
+	for i := 0; i < len(dst.Pix); i += 4 {
+		a := dst.Pix[i+3]
+		if a == 0xff {
+			dst.Pix[i] = table[dst.Pix[i]]
+			dst.Pix[i+1] = table[dst.Pix[i+1]]
+			dst.Pix[i+2] = table[dst.Pix[i+2]]
+			continue
+		}
+		if a == 0 {
+			continue
+		}
+		// Premultiplied: stretch the straight color and premultiply again
+		for c := 0; c < 3; c++ {
+			straight := int(dst.Pix[i+c]) * 255 / int(a)
+			dst.Pix[i+c] = uint8(int(table[min(straight, 255)]) * int(a) / 255)
+		}
+	}
+	return dst
+}
+
+// Dim returns a copy of img with every color channel scaled by brightness,
+// from 0 for black to 1 for unchanged, e.g. to show images darker at night.
+// Scaling premultiplied colors leaves transparency as it is.
+func Dim(img image.Image, brightness float64) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, img, b.Min, draw.Src)
+	if brightness >= 1 {
+		return dst
+	}
+	var table [256]uint8
+	for v := range table {
+		table[v] = uint8(float64(v) * max(brightness, 0))
+	}
+	for i := 0; i < len(dst.Pix); i += 4 {
+		dst.Pix[i] = table[dst.Pix[i]]
+		dst.Pix[i+1] = table[dst.Pix[i+1]]
+		dst.Pix[i+2] = table[dst.Pix[i+2]]
+	}
+	return dst
+}
+
+// AutoLevels returns img with its levels stretched, and the levels used.
+func AutoLevels(img image.Image) (*image.RGBA, Levels) {
+	l := ComputeLevels(img)
+	return l.Apply(img), l
+}
@@ -0,0 +1,82 @@
+package enhance
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// grayRamp returns an image whose columns run through the gray values lo to hi.
+func grayRamp(lo, hi int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, hi-lo+1, 4))
+	for x := 0; x <= hi-lo; x++ {
+		for y := 0; y < 4; y++ {
+			v := uint8(lo + x)
+			img.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 0xff})
+		}
+	}
+	return img
+}
+
+func TestComputeLevels(t *testing.T) {
+	if got := ComputeLevels(grayRamp(60, 180)); got.Low < 60 || got.Low > 62 || got.High < 178 || got.High > 180 {
+		t.Errorf("ComputeLevels of a 60-180 ramp = %+v, want about {60 180}", got)
+	}
+	if got := ComputeLevels(grayRamp(100, 110)); !got.IsIdentity() {
+		t.Errorf("ComputeLevels of a flat image = %+v, want identity", got)
+	}
+	if got := ComputeLevels(grayRamp(0, 255)); got.Low > 2 || got.High < 253 {
+		t.Errorf("ComputeLevels of a full range image = %+v, want about {0 255}", got)
+	}
+}
+
+func TestApplyStretchesToFullRange(t *testing.T) {
+	out, l := AutoLevels(grayRamp(60, 180))
+	if l.IsIdentity() {
+		t.Fatal("AutoLevels did not stretch a low contrast image")
+	}
+	b := out.Bounds()
+	if first := out.RGBAAt(b.Min.X, 0).R; first > 2 {
+		t.Errorf("darkest pixel = %d, want about 0", first)
+	}
+	if last := out.RGBAAt(b.Max.X-1, 0).R; last < 253 {
+		t.Errorf("brightest pixel = %d, want about 255", last)
+	}
+
+	src := grayRamp(60, 180)
+	_ = Levels{Low: 60, High: 180}.Apply(src)
+	if src.RGBAAt(0, 0).R != 60 {
+		t.Error("Apply modified its input")
+	}
+}
+
+func TestApplyKeepsTransparency(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.SetRGBA(0, 0, color.RGBA{R: 60, G: 30, B: 0, A: 0x80}) // Premultiplied straight color 120,60,0
+	out := Levels{Low: 60, High: 180}.Apply(img)
+	if got := out.RGBAAt(0, 0); got.A != 0x80 || got.R < 0x3e || got.R > 0x41 || got.G != 0 {
+		t.Errorf("half transparent pixel = %v, want alpha kept and color stretched to about {63 0 0 128}", got)
+	}
+	if got := out.RGBAAt(1, 0); got != (color.RGBA{}) {
+		t.Errorf("transparent pixel = %v, want it untouched", got)
+	}
+}
+
+func TestDim(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.SetRGBA(0, 0, color.RGBA{R: 200, G: 100, B: 0, A: 0xff})
+	img.SetRGBA(1, 0, color.RGBA{R: 60, G: 60, B: 60, A: 0x80})
+	out := Dim(img, 0.5)
+	if got := out.RGBAAt(0, 0); got != (color.RGBA{R: 100, G: 50, B: 0, A: 0xff}) {
+		t.Errorf("dimmed pixel = %v, want {100 50 0 255}", got)
+	}
+	if got := out.RGBAAt(1, 0); got != (color.RGBA{R: 30, G: 30, B: 30, A: 0x80}) {
+		t.Errorf("dimmed half transparent pixel = %v, want {30 30 30 128}", got)
+	}
+	if img.RGBAAt(0, 0).R != 200 {
+		t.Error("Dim modified its input")
+	}
+	if got := Dim(img, 1).RGBAAt(0, 0); got.R != 200 {
+		t.Errorf("full brightness changed the pixel to %v", got)
+	}
+}
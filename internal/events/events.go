@@ -0,0 +1,82 @@
+// Package events is an in-process bus for what happens in the viewer, such as
+// the image shown or tags changing, which integrations subscribe to.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type names the kind of an event.
+type Type string
+
+// Published event types.
+const (
+	ImageChanged  Type = "image_changed"  // A new image is displayed
+	Paused        Type = "paused"         // The slideshow stopped advancing
+	Resumed       Type = "resumed"        // The slideshow started advancing again
+	TagAdded      Type = "tag_added"      // A tag was added to an image
+	TagRemoved    Type = "tag_removed"    // A tag was removed from an image
+	FilterChanged Type = "filter_changed" // A filter was applied or cleared
+)
+
+// Event is one thing that happened, encoded as JSON for subscribers outside the process.
+type Event struct {
+	Type   Type      `json:"type"`
+	Time   time.Time `json:"time"`
+	Path   string    `json:"path,omitempty"`
+	Tag    string    `json:"tag,omitempty"`
+	Filter string    `json:"filter,omitempty"` // Description of the active filter; empty when cleared
+	Index  int       `json:"index,omitempty"`  // 1-based position of the image in the current list
+	Count  int       `json:"count,omitempty"`  // Images in the current list
+}
+
+// Bus delivers published events to every subscriber. Publishing never blocks:
+// a subscriber that falls behind by more than its buffer misses events.
+// A nil *Bus is valid and drops everything.
+type Bus struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+// NewBus creates a Bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[chan Event]struct{})}
+}
+
+// Publish sends ev to all subscribers, stamping it with the current time if unset.
+func (b *Bus) Publish(ev Event) {
+	if b == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default: // Slow subscriber; drop rather than stall the UI
+		}
+	}
+}
+
+// Subscribe returns a channel receiving events published from now on, holding
+// up to buffer undelivered ones, and a function that ends the subscription and
+// closes the channel.
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestBusDeliversAndDrops(t *testing.T) {
+	bus := NewBus()
+	events, unsubscribe := bus.Subscribe(1)
+
+	bus.Publish(Event{Type: TagAdded, Path: "/p/a.jpg", Tag: "cats"})
+	bus.Publish(Event{Type: TagRemoved}) // Buffer full; dropped instead of blocking
+	ev := <-events
+	if ev.Type != TagAdded || ev.Tag != "cats" || ev.Time.IsZero() {
+		t.Errorf("received %+v, want the stamped tag_added event", ev)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("received %+v, want the second event dropped", ev)
+	default:
+	}
+
+	unsubscribe()
+	unsubscribe() // Safe to call twice
+	if _, ok := <-events; ok {
+		t.Error("channel still open after unsubscribing")
+	}
+	bus.Publish(Event{Type: Paused}) // No subscribers left
+
+	var nilBus *Bus
+	nilBus.Publish(Event{Type: Paused}) // Must not panic
+}
+
+func TestParseListenURL(t *testing.T) {
+	tests := []struct {
+		raw, addr, path string
+		ok              bool
+	}{
+		{"ws://:8090", ":8090", "/", true},
+		{"ws://localhost:8090/events", "localhost:8090", "/events", true},
+		{"http://:8090", "", "", false},
+		{"ws://localhost", "", "", false},
+	}
+	for _, tt := range tests {
+		addr, path, err := ParseListenURL(tt.raw)
+		if (err == nil) != tt.ok || addr != tt.addr || path != tt.path {
+			t.Errorf("ParseListenURL(%q) = %q, %q, %v", tt.raw, addr, path, err)
+		}
+	}
+}
+
+func TestWebSocketBroadcast(t *testing.T) {
+	bus := NewBus()
+	server, err := ListenWebSocket("ws://127.0.0.1:0/events", bus)
+	if err != nil {
+		t.Fatalf("ListenWebSocket failed: %v", err)
+	}
+	defer server.Close()
+
+	url := "ws://" + server.Addr().String() + "/events"
+	var clients []*websocket.Conn
+	for range 2 {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer conn.Close()
+		clients = append(clients, conn)
+	}
+
+	// The handler subscribes after the upgrade; publish until both clients hear it.
+	for i, conn := range clients {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		received := make(chan Event, 1)
+		go func() {
+			var ev Event
+			if err := conn.ReadJSON(&ev); err == nil {
+				received <- ev
+			}
+			close(received)
+		}()
+		var ev Event
+	wait:
+		for {
+			bus.Publish(Event{Type: ImageChanged, Path: "/p/a.jpg", Index: 3, Count: 10})
+			select {
+			case got, ok := <-received:
+				if !ok {
+					t.Fatalf("client %d got no event", i)
+				}
+				ev = got
+				break wait
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		if ev.Type != ImageChanged || ev.Path != "/p/a.jpg" || ev.Index != 3 || ev.Count != 10 {
+			t.Errorf("client %d received %+v", i, ev)
+		}
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook test uses a POSIX shell")
+	}
+	out := filepath.Join(t.TempDir(), "hook.out")
+	bus := NewBus()
+	stop := RunHooks(bus, map[Type]string{TagAdded: `printf '%s %s ' "$FYSLIDE_TAG" "$FYSLIDE_PATH" >> ` + out + ` && cat >> ` + out}, func(message string) { t.Error(message) })
+	defer stop()
+
+	bus.Publish(Event{Type: ImageChanged, Path: "/p/a.jpg"}) // No hook
+	bus.Publish(Event{Type: TagAdded, Path: "/p/a.jpg", Tag: "cats"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(out)
+		if strings.HasPrefix(string(data), "cats /p/a.jpg {") && strings.HasSuffix(string(data), "}") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("hook output = %q", data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
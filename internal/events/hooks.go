@@ -0,0 +1,72 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// hookTimeout bounds each hook command, so a hung one can't hold up the rest.
+const hookTimeout = 30 * time.Second
+
+// hookBuffer is how many events may wait for their hooks before new ones are dropped.
+const hookBuffer = 64
+
+// RunHooks runs the shell command hooks maps an event's type to for every
+// event published on b, until stop is called. Each command gets the event as
+// JSON on its standard input and its fields in FYSLIDE_EVENT, FYSLIDE_PATH,
+// FYSLIDE_TAG, FYSLIDE_FILTER, FYSLIDE_INDEX and FYSLIDE_COUNT. Commands run
+// one at a time in event order; failures are passed to logger.
+func RunHooks(b *Bus, hooks map[Type]string, logger func(string)) (stop func()) {
+	if b == nil || len(hooks) == 0 {
+		return func() {}
+	}
+	ch, unsubscribe := b.Subscribe(hookBuffer)
+	go func() {
+		for ev := range ch {
+			command, ok := hooks[ev.Type]
+			if !ok || command == "" {
+				continue
+			}
+			if err := runHook(command, ev); err != nil && logger != nil {
+				logger(fmt.Sprintf("Hook for %s failed: %v", ev.Type, err))
+			}
+		}
+	}()
+	return unsubscribe
+}
+
+// runHook runs command through the system shell for ev.
+func runHook(command string, ev Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"FYSLIDE_EVENT="+string(ev.Type),
+		"FYSLIDE_PATH="+ev.Path,
+		"FYSLIDE_TAG="+ev.Tag,
+		"FYSLIDE_FILTER="+ev.Filter,
+		"FYSLIDE_INDEX="+strconv.Itoa(ev.Index),
+		"FYSLIDE_COUNT="+strconv.Itoa(ev.Count),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
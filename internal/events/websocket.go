@@ -0,0 +1,143 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	clientBuffer = 64               // Events queued per client before it starts missing them
+	writeTimeout = 10 * time.Second // Clients that can't take a message this fast are dropped
+	pingInterval = 30 * time.Second // Keeps idle connections through proxies and detects dead peers
+)
+
+// ParseListenURL splits an --events value such as "ws://:8090" or
+// "ws://localhost:8090/events" into the address to listen on and the HTTP path
+// clients connect to. An empty host listens on all interfaces.
+func ParseListenURL(raw string) (addr, path string, err error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid events URL %q: %w", raw, err)
+	}
+	if u.Scheme != "ws" {
+		return "", "", fmt.Errorf("invalid events URL %q: scheme must be ws", raw)
+	}
+	if u.Port() == "" {
+		return "", "", fmt.Errorf("invalid events URL %q: a port is required", raw)
+	}
+	path = u.Path
+	if path == "" {
+		path = "/"
+	}
+	return u.Host, path, nil
+}
+
+// WebSocketServer broadcasts the events of a Bus as JSON text messages to
+// every connected WebSocket client. Clients only listen; anything they send is
+// ignored.
+type WebSocketServer struct {
+	bus      *Bus
+	listener net.Listener
+	server   *http.Server
+	upgrader websocket.Upgrader
+
+	mu    sync.Mutex
+	conns map[*websocket.Conn]struct{} // Hijacked connections, which the http.Server no longer tracks
+}
+
+// ListenWebSocket starts serving bus on the ws:// URL raw, see ParseListenURL.
+func ListenWebSocket(raw string, bus *Bus) (*WebSocketServer, error) {
+	addr, path, err := ParseListenURL(raw)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen for event clients on %s: %w", addr, err)
+	}
+	s := &WebSocketServer{bus: bus, listener: listener, conns: make(map[*websocket.Conn]struct{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, s.handle)
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: writeTimeout}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Event stream stopped: %v", err)
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *WebSocketServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close stops accepting clients and disconnects the connected ones.
+func (s *WebSocketServer) Close() error {
+	err := s.server.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return err
+}
+
+// handle upgrades a request and streams events to it until either side goes away.
+func (s *WebSocketServer) handle(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // The upgrader has already replied with an error
+	}
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	events, unsubscribe := s.bus.Subscribe(clientBuffer)
+	defer unsubscribe()
+
+	// Reading is needed to process control frames; the loop ends when the client disconnects.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
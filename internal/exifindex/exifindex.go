@@ -0,0 +1,126 @@
+// Package exifindex keeps facts read from image files, such as when and with
+// which camera each was taken and its dimensions, in the tag database, so that
+// filters and views over the whole library don't read every file each time.
+// An image is read again only once its size or modification time changed.
+package exifindex
+
+import (
+	"context"
+	"fyslide/internal/autotag"
+	"fyslide/internal/scan"
+	"fyslide/internal/tagging"
+	"image"
+	_ "image/gif" // Register decoders so DecodeConfig can read dimensions
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"os"
+)
+
+// Version identifies what read records; it is raised whenever fields are
+// added so that older records are read again.
+const Version = 1
+
+// batchSize is how many records are stored per transaction, so an update
+// that is cancelled keeps most of its work.
+const batchSize = 200
+
+// readEXIF reads the EXIF fields of an image; tests replace it.
+var readEXIF = autotag.ReadEXIF
+
+// Current reports whether record was read, by this version, from the file
+// described by info.
+func Current(record tagging.ImageInfo, info fs.FileInfo) bool {
+	return record.Version == Version && record.Current(info.ModTime(), info.Size())
+}
+
+// Update brings the index up to date for items and returns their records by
+// path. progress, if not nil, is called after each image read, with the
+// number read so far and the number to read. If ctx ends, the records stored
+// so far are kept and the context's error is returned.
+func Update(ctx context.Context, db *tagging.TagDB, items scan.FileItems, progress func(done, total int)) (map[string]tagging.ImageInfo, error) {
+	stored, err := db.ImageInfos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[string]tagging.ImageInfo, len(items))
+	var stale scan.FileItems
+	for _, item := range items {
+		if item.Info == nil {
+			info, err := os.Stat(item.Path)
+			if err != nil {
+				continue // Gone since the scan
+			}
+			item.Info = info
+		}
+		if record, ok := stored[item.Path]; ok && Current(record, item.Info) {
+			records[item.Path] = record
+			continue
+		}
+		stale = append(stale, item)
+	}
+
+	var batch []tagging.ImageInfo
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := db.PutImageInfo(context.WithoutCancel(ctx), batch) // Work already done is worth keeping
+		batch = batch[:0]
+		return err
+	}
+	for i, item := range stale {
+		if err := ctx.Err(); err != nil {
+			flush() //nolint:errcheck // Reporting the cancellation matters more
+			return records, err
+		}
+		info := read(item)
+		records[item.Path] = info
+		batch = append(batch, info)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return records, err
+			}
+		}
+		if progress != nil {
+			progress(i+1, len(stale))
+		}
+	}
+	return records, flush()
+}
+
+// read gathers the record of one image. Unreadable EXIF data or image headers
+// leave the fields they would have provided at their fallbacks.
+func read(item scan.FileItem) tagging.ImageInfo {
+	info := tagging.ImageInfo{
+		Path:    item.Path,
+		ModTime: item.Info.ModTime(),
+		Size:    item.Info.Size(),
+		Taken:   item.Info.ModTime(),
+		Version: Version,
+	}
+	if x, err := readEXIF(item.Path); err == nil {
+		if !x.Taken.IsZero() {
+			info.Taken, info.DateFromEXIF = x.Taken, true
+		}
+		info.Camera = x.Camera
+		info.Lat, info.Long, info.HasGPS = x.Lat, x.Long, x.HasGPS
+	}
+	info.Width, info.Height = dimensions(item.Path)
+	return info
+}
+
+// dimensions reads the width and height from an image's header, or returns
+// zeros if it can't.
+func dimensions(path string) (width, height int) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0
+	}
+	return cfg.Width, cfg.Height
+}
@@ -0,0 +1,99 @@
+package exifindex
+
+import (
+	"bytes"
+	"context"
+	"fyslide/internal/autotag"
+	"fyslide/internal/scan"
+	"fyslide/internal/tagging"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestUpdate(t *testing.T) {
+	db, err := tagging.NewTagDB(t.TempDir(), func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	taken := time.Date(2019, 7, 14, 10, 0, 0, 0, time.Local)
+	var reads []string
+	readEXIF = func(path string) (autotag.EXIFInfo, error) {
+		reads = append(reads, filepath.Base(path))
+		if filepath.Base(path) == "dated.jpg" {
+			return autotag.EXIFInfo{Taken: taken, Camera: "Pixel 7", Lat: 48.5, Long: -2.25, HasGPS: true}, nil
+		}
+		return autotag.EXIFInfo{}, nil
+	}
+	t.Cleanup(func() { readEXIF = autotag.ReadEXIF })
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	var items scan.FileItems
+	for name, data := range map[string][]byte{"dated.jpg": []byte("not a jpeg"), "undated.png": encoded.Bytes()} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		info, _ := os.Stat(path)
+		items = append(items, scan.NewFileItem(path, info))
+	}
+	items = append(items, scan.FileItem{Path: filepath.Join(dir, "gone.jpg")})
+
+	var calls int
+	records, err := Update(context.Background(), db, items, func(done, total int) { calls++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || calls != 2 {
+		t.Fatalf("Update returned %d records with %d progress calls, want 2 and 2", len(records), calls)
+	}
+	dated, undated := records[filepath.Join(dir, "dated.jpg")], records[filepath.Join(dir, "undated.png")]
+	if !dated.Taken.Equal(taken) || !dated.DateFromEXIF || dated.Camera != "Pixel 7" || !dated.HasGPS || dated.Lat != 48.5 {
+		t.Errorf("dated.jpg record = %+v, want the EXIF fields", dated)
+	}
+	if dated.Width != 0 || undated.Width != 3 || undated.Height != 2 {
+		t.Errorf("Dimensions are %dx%d and %dx%d, want 0x0 and 3x2", dated.Width, dated.Height, undated.Width, undated.Height)
+	}
+	if undated.DateFromEXIF || undated.Camera != "" || undated.Version != Version {
+		t.Errorf("undated.png record = %+v, want the modification time and no EXIF fields", undated)
+	}
+
+	// Unchanged files are not read again; changed ones and those read by an
+	// older version are.
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(undated.Path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	for i := range items {
+		items[i].Info, _ = os.Stat(items[i].Path)
+	}
+	dated.Version = 0
+	if err := db.PutImageInfo(context.Background(), []tagging.ImageInfo{dated}); err != nil {
+		t.Fatal(err)
+	}
+	reads = nil
+	if _, err := Update(context.Background(), db, items, nil); err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(reads)
+	if !slices.Equal(reads, []string{"dated.jpg", "undated.png"}) {
+		t.Errorf("Second update read %v, want the outdated dated.jpg and the changed undated.png", reads)
+	}
+	reads = nil
+	if _, err := Update(context.Background(), db, items, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(reads) != 0 {
+		t.Errorf("Third update read %v, want nothing", reads)
+	}
+}
@@ -0,0 +1,316 @@
+// Package exporter writes resized and converted copies of images to a folder,
+// for sharing or for devices that can't show the originals.
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"fyslide/internal/enhance"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/draw"
+)
+
+// Output formats. FormatKeep writes each copy in the format of its source.
+const (
+	FormatKeep = "keep"
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+)
+
+// Formats lists the valid output formats in display order.
+var Formats = []string{FormatKeep, FormatJPEG, FormatPNG, FormatGIF}
+
+// DefaultQuality is the JPEG quality used when none is given.
+const DefaultQuality = 90
+
+// Options control an export.
+type Options struct {
+	OutDir        string
+	MaxDimension  int    // Longest edge of the copies in pixels; 0 keeps the size
+	Quality       int    // JPEG quality from 1 to 100; 0 uses DefaultQuality
+	Format        string // One of Formats; "" keeps the source format
+	StripMetadata bool   // Leave out the EXIF data JPEG copies of JPEGs otherwise keep
+	Overwrite     bool   // Replace existing files instead of skipping them
+	AutoLevels    bool   // Stretch each copy's levels, see enhance.AutoLevels
+	Workers       int    // Images converted at once; 0 uses the number of CPUs
+}
+
+// Validate checks opts and fills in defaults.
+func (opts *Options) Validate() error {
+	if opts.OutDir == "" {
+		return errors.New("no target folder given")
+	}
+	if opts.MaxDimension < 0 {
+		return fmt.Errorf("maximum dimension %d is negative", opts.MaxDimension)
+	}
+	if opts.Quality == 0 {
+		opts.Quality = DefaultQuality
+	}
+	if opts.Quality < 1 || opts.Quality > 100 {
+		return fmt.Errorf("JPEG quality %d is not between 1 and 100", opts.Quality)
+	}
+	switch opts.Format = strings.ToLower(opts.Format); opts.Format {
+	case "":
+		opts.Format = FormatKeep
+	case "jpg":
+		opts.Format = FormatJPEG
+	}
+	if !slices.Contains(Formats, opts.Format) {
+		return fmt.Errorf("unknown format %q (valid: %s)", opts.Format, strings.Join(Formats, ", "))
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	return nil
+}
+
+// Job is one copy to write.
+type Job struct {
+	Source string
+	Target string
+	Format string // Format written, never FormatKeep
+}
+
+// Result is the outcome of one Job.
+type Result struct {
+	Job
+	Skipped bool // Target existed and Overwrite was off
+	Err     error
+}
+
+// Plan works out the copy of each source: named after the source with the
+// extension of the output format, in opts.OutDir. Sources with the same name
+// from different folders get a numeric suffix so no copy overwrites another.
+func Plan(sources []string, opts Options) []Job {
+	jobs := make([]Job, 0, len(sources))
+	used := make(map[string]bool)
+	for _, src := range sources {
+		format := opts.Format
+		if format == FormatKeep || format == "" {
+			format = formatOf(src)
+		}
+		base := strings.TrimSuffix(filepath.Base(src), filepath.Ext(src))
+		ext := extensionOf(format)
+		name := base + ext
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s-%d%s", base, n, ext)
+		}
+		used[strings.ToLower(name)] = true
+		jobs = append(jobs, Job{Source: src, Target: filepath.Join(opts.OutDir, name), Format: format})
+	}
+	return jobs
+}
+
+// formatOf returns the output format matching a source file's extension.
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return FormatPNG
+	case ".gif":
+		return FormatGIF
+	}
+	return FormatJPEG
+}
+
+// extensionOf returns the file extension written for format.
+func extensionOf(format string) string {
+	switch format {
+	case FormatPNG:
+		return ".png"
+	case FormatGIF:
+		return ".gif"
+	}
+	return ".jpg"
+}
+
+// Run writes the copies of sources with opts.Workers workers, calling
+// progress, if not nil, after each one with the number done so far. Calls to
+// progress don't overlap. Cancelling ctx stops handing out further copies.
+// Failed copies are reported in their Result rather than stopping the export.
+func Run(ctx context.Context, sources []string, opts Options, progress func(done, total int, r Result)) ([]Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(opts.OutDir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create target folder %s: %w", opts.OutDir, err)
+	}
+
+	jobs := Plan(sources, opts)
+	results := make([]Result, len(jobs))
+	next := make(chan int)
+	var mu sync.Mutex
+	done := 0
+	var wg sync.WaitGroup
+	for w := 0; w < min(opts.Workers, len(jobs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				r := Export(jobs[i], opts)
+				mu.Lock()
+				results[i] = r
+				done++
+				if progress != nil {
+					progress(done, len(jobs), r)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for i := range jobs {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	return results[:done], ctx.Err() // Jobs are handed out in order, so the first done ones ran
+}
+
+// Export writes the copy job describes.
+func Export(job Job, opts Options) Result {
+	r := Result{Job: job}
+	if !opts.Overwrite {
+		if _, err := os.Stat(job.Target); err == nil {
+			r.Skipped = true
+			return r
+		}
+	}
+	src, err := os.ReadFile(job.Source)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	img, _, err := image.Decode(bytes.NewReader(src))
+	if err != nil {
+		r.Err = fmt.Errorf("decoding %s: %w", job.Source, err)
+		return r
+	}
+	if opts.AutoLevels {
+		img, _ = enhance.AutoLevels(img)
+	}
+	img = Resize(img, opts.MaxDimension)
+
+	var out bytes.Buffer
+	switch job.Format {
+	case FormatPNG:
+		err = png.Encode(&out, img)
+	case FormatGIF:
+		err = gif.Encode(&out, img, nil)
+	default:
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: opts.Quality})
+	}
+	if err != nil {
+		r.Err = fmt.Errorf("encoding %s: %w", job.Target, err)
+		return r
+	}
+	data := out.Bytes()
+	// Re-encoding drops all metadata; only EXIF data of JPEG to JPEG copies is carried over
+	if job.Format == FormatJPEG && !opts.StripMetadata && formatOf(job.Source) == FormatJPEG {
+		data = CopyEXIF(data, src)
+	}
+	if err := WriteFileAtomic(job.Target, data); err != nil {
+		r.Err = err
+	}
+	return r
+}
+
+// Resize returns img scaled so that its longest edge is at most maxDimension
+// pixels. A maxDimension of 0, or an image that already fits, is returned as is.
+func Resize(img image.Image, maxDimension int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if maxDimension <= 0 || (w <= maxDimension && h <= maxDimension) {
+		return img
+	}
+	if w >= h {
+		w, h = maxDimension, max(1, h*maxDimension/w)
+	} else {
+		w, h = max(1, w*maxDimension/h), maxDimension
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// CopyEXIF returns the encoded JPEG data with the EXIF data of the JPEG src
+// added, as re-encoding an image drops it. data is returned as is if src has
+// no EXIF data.
+func CopyEXIF(data, src []byte) []byte {
+	if segment := exifSegment(src); segment != nil {
+		return insertAfterSOI(data, segment)
+	}
+	return data
+}
+
+// exifSegment returns the complete APP1 EXIF segment, marker included, of
+// JPEG data, or nil if there is none before the image data.
+func exifSegment(data []byte) []byte {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	for pos := 2; pos+4 <= len(data) && data[pos] == 0xFF; {
+		marker := data[pos+1]
+		if marker == 0xDA { // Start of scan: no more metadata segments
+			return nil
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		if end < pos+4 || end > len(data) {
+			return nil
+		}
+		if marker == 0xE1 && bytes.HasPrefix(data[pos+4:end], []byte("Exif\x00\x00")) {
+			return data[pos:end]
+		}
+		pos = end
+	}
+	return nil
+}
+
+// insertAfterSOI returns JPEG data with segment inserted right after the
+// start of image marker.
+func insertAfterSOI(data, segment []byte) []byte {
+	out := make([]byte, 0, len(data)+len(segment))
+	out = append(out, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+// WriteFileAtomic writes data to a temporary file next to path and renames it
+// into place, so an interrupted write never leaves a truncated file.
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %s: %w", path, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
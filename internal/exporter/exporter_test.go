@@ -0,0 +1,209 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeImage writes a w x h JPEG or PNG, chosen by the extension of name.
+func writeImage(t *testing.T, path string, w, h int) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	var err error
+	if filepath.Ext(path) == ".png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlanKeepsNamesUnique(t *testing.T) {
+	jobs := Plan([]string{"/a/beach.jpg", "/b/Beach.JPEG", "/c/beach.png"}, Options{OutDir: "/out", Format: FormatJPEG})
+	want := []string{"/out/beach.jpg", "/out/Beach-2.jpg", "/out/beach-3.jpg"}
+	for i, job := range jobs {
+		if job.Target != want[i] || job.Format != FormatJPEG {
+			t.Errorf("job %d = %+v, want target %s as jpeg", i, job, want[i])
+		}
+	}
+
+	jobs = Plan([]string{"/a/logo.png", "/a/photo.jpeg"}, Options{OutDir: "/out", Format: FormatKeep})
+	if jobs[0].Target != "/out/logo.png" || jobs[0].Format != FormatPNG || jobs[1].Target != "/out/photo.jpg" {
+		t.Errorf("keep format plan = %+v", jobs)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	opts := Options{OutDir: "/out", Format: "JPG"}
+	if err := opts.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if opts.Format != FormatJPEG || opts.Quality != DefaultQuality || opts.Workers < 1 {
+		t.Errorf("defaults not filled in: %+v", opts)
+	}
+	for _, bad := range []Options{{}, {OutDir: "/out", Quality: 101}, {OutDir: "/out", Format: "webp"}, {OutDir: "/out", MaxDimension: -1}} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Validate(%+v) accepted invalid options", bad)
+		}
+	}
+}
+
+func TestRunResizesAndConverts(t *testing.T) {
+	dir := t.TempDir()
+	wide := filepath.Join(dir, "in", "wide.jpg")
+	small := filepath.Join(dir, "in", "small.png")
+	writeImage(t, wide, 400, 100)
+	writeImage(t, small, 50, 40)
+	out := filepath.Join(dir, "out")
+
+	var calls int
+	results, err := Run(context.Background(), []string{wide, small}, Options{OutDir: out, MaxDimension: 200, Format: FormatPNG, Workers: 2},
+		func(done, total int, r Result) {
+			calls++
+			if total != 2 || done != calls {
+				t.Errorf("progress(%d, %d), call %d", done, total, calls)
+			}
+		})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil || r.Skipped {
+			t.Errorf("result %+v", r)
+		}
+	}
+
+	sizes := map[string]image.Point{"wide.png": {200, 50}, "small.png": {50, 40}}
+	for name, want := range sizes {
+		f, err := os.Open(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, format, err := image.DecodeConfig(f)
+		f.Close()
+		if err != nil || format != "png" || cfg.Width != want.X || cfg.Height != want.Y {
+			t.Errorf("%s: %s %dx%d (%v), want png %dx%d", name, format, cfg.Width, cfg.Height, err, want.X, want.Y)
+		}
+	}
+
+	// A second run leaves existing copies alone
+	results, err = Run(context.Background(), []string{wide}, Options{OutDir: out, Format: FormatPNG}, nil)
+	if err != nil || len(results) != 1 || !results[0].Skipped {
+		t.Errorf("second run = %+v, %v; want the copy skipped", results, err)
+	}
+}
+
+func TestExportAutoLevels(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "dull.png")
+	img := image.NewRGBA(image.Rect(0, 0, 100, 10))
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 10; y++ {
+			v := uint8(100 + x/2) // Gray values 100 to 149 only
+			img.Set(x, y, color.RGBA{R: v, G: v, B: v, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{OutDir: dir, Format: FormatPNG, AutoLevels: true, Overwrite: true}
+	if err := opts.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	job := Job{Source: src, Target: filepath.Join(dir, "bright.png"), Format: FormatPNG}
+	if r := Export(job, opts); r.Err != nil {
+		t.Fatalf("Export: %v", r.Err)
+	}
+	f, err := os.Open(job.Target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	out, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dark, _, _, _ := out.At(0, 0).RGBA()
+	light, _, _, _ := out.At(99, 0).RGBA()
+	if dark>>8 > 10 || light>>8 < 245 {
+		t.Errorf("enhanced copy spans %d to %d, want about 0 to 255", dark>>8, light>>8)
+	}
+}
+
+func TestRunStopsWhenCancelled(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.jpg")
+	writeImage(t, src, 10, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := Run(ctx, []string{src, src, src}, Options{OutDir: filepath.Join(dir, "out"), Workers: 1}, nil)
+	if err == nil {
+		t.Error("Run of a cancelled context returned no error")
+	}
+	if len(results) == 3 {
+		t.Error("Run of a cancelled context exported everything")
+	}
+}
+
+func TestEXIFSegmentCarriedOver(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "plain.jpg")
+	writeImage(t, plain, 20, 10)
+	data, err := os.ReadFile(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := append([]byte("Exif\x00\x00"), []byte("MM\x00\x2a fake tiff")...)
+	segment := append([]byte{0xFF, 0xE1, 0, byte(len(payload) + 2)}, payload...)
+	tagged := filepath.Join(dir, "tagged.jpg")
+	if err := os.WriteFile(tagged, insertAfterSOI(data, segment), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := exifSegment(data); got != nil {
+		t.Errorf("exifSegment of a JPEG without EXIF = %x", got)
+	}
+
+	for _, strip := range []bool{false, true} {
+		out := filepath.Join(dir, "out", map[bool]string{false: "keep", true: "strip"}[strip])
+		results, err := Run(context.Background(), []string{tagged}, Options{OutDir: out, StripMetadata: strip}, nil)
+		if err != nil || results[0].Err != nil {
+			t.Fatalf("Run: %v %+v", err, results)
+		}
+		copied, err := os.ReadFile(results[0].Target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := exifSegment(copied); bytes.Equal(got, segment) == strip {
+			t.Errorf("strip=%v: copy has EXIF segment %x", strip, got)
+		}
+	}
+}
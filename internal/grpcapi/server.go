@@ -0,0 +1,269 @@
+// Package grpcapi serves the tag service defined in api/fyslide/v1 over gRPC,
+// backed by the same Service layer the GUI and the CLI use.
+package grpcapi
+
+//go:generate make -C ../.. proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/scan"
+	"fyslide/internal/service"
+	"fyslide/internal/tagging"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pb "fyslide/api/fyslide/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements pb.TagServiceServer. Mutations go through the Service, so
+// its read-only mode applies to remote clients too; queries read the database.
+type Server struct {
+	pb.UnimplementedTagServiceServer
+	svc   *service.Service
+	tagDB *tagging.TagDB
+}
+
+// NewServer creates a Server for svc, which must operate on tagDB.
+func NewServer(svc *service.Service, tagDB *tagging.TagDB) *Server {
+	return &Server{svc: svc, tagDB: tagDB}
+}
+
+// Register adds the tag service to gs.
+func (s *Server) Register(gs *grpc.Server) {
+	pb.RegisterTagServiceServer(gs, s)
+}
+
+// toStatus maps errors of the service layer to gRPC status codes.
+func toStatus(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service.ErrReadOnly):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrTooManyMissing):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrDestinationExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, fs.ErrNotExist):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// checkPath rejects paths the database could not hold: the server's working
+// directory means nothing to a client, so relative paths are refused.
+func checkPath(name, path string) (string, error) {
+	if path == "" {
+		return "", status.Errorf(codes.InvalidArgument, "%s is required", name)
+	}
+	if !filepath.IsAbs(path) {
+		return "", status.Errorf(codes.InvalidArgument, "%s must be absolute: %s", name, path)
+	}
+	return filepath.Clean(path), nil
+}
+
+// normalizeTags lowercases tags like the CLI does and requires at least one.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one tag is required")
+	}
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			return nil, status.Error(codes.InvalidArgument, "tags cannot be empty")
+		}
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+// imageTags returns the current tags of path.
+func (s *Server) imageTags(ctx context.Context, path string) (*pb.ImageTags, error) {
+	tags, err := s.tagDB.GetTags(ctx, path)
+	if err != nil {
+		return nil, toStatus(fmt.Errorf("error listing tags for %s: %w", path, err))
+	}
+	return &pb.ImageTags{Path: path, Tags: tags}, nil
+}
+
+// changeTags applies change to each tag of path and returns the resulting tags.
+func (s *Server) changeTags(ctx context.Context, rawPath string, rawTags []string, change func(ctx context.Context, path, tag string) error) (*pb.ImageTags, error) {
+	path, err := checkPath("path", rawPath)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(rawTags)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		if err := change(ctx, path, tag); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	return s.imageTags(ctx, path)
+}
+
+// AddTags adds tags to an image.
+func (s *Server) AddTags(ctx context.Context, req *pb.AddTagsRequest) (*pb.ImageTags, error) {
+	return s.changeTags(ctx, req.GetPath(), req.GetTags(), s.svc.AddTag)
+}
+
+// RemoveTags removes tags from an image.
+func (s *Server) RemoveTags(ctx context.Context, req *pb.RemoveTagsRequest) (*pb.ImageTags, error) {
+	return s.changeTags(ctx, req.GetPath(), req.GetTags(), s.svc.RemoveTag)
+}
+
+// GetTags returns the tags of an image.
+func (s *Server) GetTags(ctx context.Context, req *pb.GetTagsRequest) (*pb.ImageTags, error) {
+	path, err := checkPath("path", req.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	return s.imageTags(ctx, path)
+}
+
+// ListTags returns every tag with its image count.
+func (s *Server) ListTags(ctx context.Context, _ *pb.ListTagsRequest) (*pb.ListTagsResponse, error) {
+	tags, err := s.tagDB.GetAllTags(ctx)
+	if err != nil {
+		return nil, toStatus(fmt.Errorf("error listing all tags: %w", err))
+	}
+	resp := &pb.ListTagsResponse{Tags: make([]*pb.TagCount, 0, len(tags))}
+	for _, tag := range tags {
+		resp.Tags = append(resp.Tags, &pb.TagCount{Name: tag.Name, Count: int32(tag.Count)})
+	}
+	return resp, nil
+}
+
+// FindImages returns the images carrying all of the requested tags.
+func (s *Server) FindImages(ctx context.Context, req *pb.FindImagesRequest) (*pb.FindImagesResponse, error) {
+	tags, err := normalizeTags(req.GetTags())
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for i, tag := range tags {
+		images, err := s.tagDB.GetImages(ctx, tag)
+		if err != nil {
+			return nil, toStatus(fmt.Errorf("error finding images for tag '%s': %w", tag, err))
+		}
+		if i == 0 {
+			paths = images
+			continue
+		}
+		has := make(map[string]bool, len(images))
+		for _, image := range images {
+			has[image] = true
+		}
+		kept := paths[:0]
+		for _, path := range paths {
+			if has[path] {
+				kept = append(kept, path)
+			}
+		}
+		paths = kept
+	}
+	return &pb.FindImagesResponse{Paths: paths}, nil
+}
+
+// RenameImage renames an image file and moves its tags.
+func (s *Server) RenameImage(ctx context.Context, req *pb.RenameImageRequest) (*pb.RenameImageResponse, error) {
+	oldPath, err := checkPath("old_path", req.GetOldPath())
+	if err != nil {
+		return nil, err
+	}
+	newPath, err := checkPath("new_path", req.GetNewPath())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.svc.RenameImage(ctx, oldPath, newPath); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.RenameImageResponse{}, nil
+}
+
+// DeleteImage deletes an image file and its tags.
+func (s *Server) DeleteImage(ctx context.Context, req *pb.DeleteImageRequest) (*pb.DeleteImageResponse, error) {
+	path, err := checkPath("path", req.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.svc.DeleteImage(ctx, path); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteImageResponse{}, nil
+}
+
+// BatchAddTags adds tags to the images directly within a directory.
+func (s *Server) BatchAddTags(ctx context.Context, req *pb.BatchTagsRequest) (*pb.BatchTagsResponse, error) {
+	return s.batch(ctx, req, s.svc.AddTag, "adding")
+}
+
+// BatchRemoveTags removes tags from the images directly within a directory.
+func (s *Server) BatchRemoveTags(ctx context.Context, req *pb.BatchTagsRequest) (*pb.BatchTagsResponse, error) {
+	return s.batch(ctx, req, s.svc.RemoveTag, "removing")
+}
+
+// batch applies change to every tag of every supported image directly within
+// the requested directory, like the CLI's batch commands. Failures for single
+// images are reported in the response; a read-only service fails the call.
+func (s *Server) batch(ctx context.Context, req *pb.BatchTagsRequest, change func(ctx context.Context, path, tag string) error, doing string) (*pb.BatchTagsResponse, error) {
+	dir, err := checkPath("directory", req.GetDirectory())
+	if err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(req.GetTags())
+	if err != nil {
+		return nil, err
+	}
+	if s.svc.ReadOnly() && !req.GetDryRun() {
+		return nil, toStatus(service.ErrReadOnly)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, toStatus(fmt.Errorf("error reading directory %s: %w", dir, err))
+	}
+
+	resp := &pb.BatchTagsResponse{}
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+		if entry.IsDir() || !scan.IsImage(entry.Name()) {
+			continue
+		}
+		resp.Images++
+		path := filepath.Join(dir, entry.Name())
+		for _, tag := range tags {
+			if !req.GetDryRun() {
+				if err := change(ctx, path, tag); err != nil {
+					resp.Errors = append(resp.Errors, fmt.Sprintf("error %s tag '%s' for %s: %v", doing, tag, path, err))
+					continue
+				}
+			}
+			resp.Changes++
+		}
+	}
+	return resp, nil
+}
+
+// Clean removes stale entries from the database.
+func (s *Server) Clean(ctx context.Context, req *pb.CleanRequest) (*pb.CleanResponse, error) {
+	result, err := s.svc.Clean(ctx, service.CleanOptions{DryRun: req.GetDryRun()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CleanResponse{MissingImages: result.MissingImages, OrphanedTags: result.OrphanedTags}, nil
+}
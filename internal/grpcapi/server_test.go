@@ -0,0 +1,191 @@
+package grpcapi
+
+import (
+	"context"
+	"fyslide/internal/service"
+	"fyslide/internal/tagging"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	pb "fyslide/api/fyslide/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient serves a Server over an in-memory connection and returns a
+// generated client talking to it.
+func newTestClient(t *testing.T, readOnly bool) pb.TagServiceClient {
+	t.Helper()
+	tagDB, err := tagging.NewTagDB(t.TempDir(), func(message string) { t.Logf("TagDB: %s", message) })
+	if err != nil {
+		t.Fatalf("NewTagDB failed: %v", err)
+	}
+	t.Cleanup(func() { tagDB.Close() })
+	svc := service.New(tagDB)
+	svc.SetReadOnly(readOnly)
+
+	listener := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	NewServer(svc, tagDB).Register(gs)
+	go gs.Serve(listener)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewTagServiceClient(conn)
+}
+
+func writeImages(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("image"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func wantCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Errorf("error = %v, want code %s", err, code)
+	}
+}
+
+func TestTagOperations(t *testing.T) {
+	client := newTestClient(t, false)
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeImages(t, dir, "a.jpg", "b.png", "notes.txt")
+	a, b := filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.png")
+
+	got, err := client.AddTags(ctx, &pb.AddTagsRequest{Path: a, Tags: []string{"Beach", "sunset"}})
+	if err != nil {
+		t.Fatalf("AddTags failed: %v", err)
+	}
+	if !reflect.DeepEqual(got.GetTags(), []string{"beach", "sunset"}) {
+		t.Errorf("AddTags returned %v, want [beach sunset]", got.GetTags())
+	}
+	if _, err := client.AddTags(ctx, &pb.AddTagsRequest{Path: b, Tags: []string{"beach"}}); err != nil {
+		t.Fatalf("AddTags failed: %v", err)
+	}
+
+	found, err := client.FindImages(ctx, &pb.FindImagesRequest{Tags: []string{"beach", "SUNSET"}})
+	if err != nil {
+		t.Fatalf("FindImages failed: %v", err)
+	}
+	if !reflect.DeepEqual(found.GetPaths(), []string{a}) {
+		t.Errorf("FindImages = %v, want [%s]", found.GetPaths(), a)
+	}
+
+	list, err := client.ListTags(ctx, &pb.ListTagsRequest{})
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	if len(list.GetTags()) != 2 || list.GetTags()[0].GetName() != "beach" || list.GetTags()[0].GetCount() != 2 {
+		t.Errorf("ListTags = %v, want beach (2) and sunset (1)", list.GetTags())
+	}
+
+	if got, err := client.RemoveTags(ctx, &pb.RemoveTagsRequest{Path: a, Tags: []string{"sunset"}}); err != nil || !reflect.DeepEqual(got.GetTags(), []string{"beach"}) {
+		t.Errorf("RemoveTags = %v, %v; want [beach]", got.GetTags(), err)
+	}
+
+	renamed := filepath.Join(dir, "c.jpg")
+	if _, err := client.RenameImage(ctx, &pb.RenameImageRequest{OldPath: a, NewPath: b}); err == nil {
+		t.Error("RenameImage overwrote another file")
+	} else {
+		wantCode(t, err, codes.AlreadyExists)
+	}
+	if _, err := client.RenameImage(ctx, &pb.RenameImageRequest{OldPath: a, NewPath: renamed}); err != nil {
+		t.Fatalf("RenameImage failed: %v", err)
+	}
+	if got, err := client.GetTags(ctx, &pb.GetTagsRequest{Path: renamed}); err != nil || !reflect.DeepEqual(got.GetTags(), []string{"beach"}) {
+		t.Errorf("GetTags after rename = %v, %v; want [beach]", got.GetTags(), err)
+	}
+
+	if _, err := client.DeleteImage(ctx, &pb.DeleteImageRequest{Path: renamed}); err != nil {
+		t.Fatalf("DeleteImage failed: %v", err)
+	}
+	if _, err := os.Stat(renamed); !os.IsNotExist(err) {
+		t.Errorf("deleted file still present, stat err = %v", err)
+	}
+
+	_, err = client.GetTags(ctx, &pb.GetTagsRequest{Path: "relative.jpg"})
+	wantCode(t, err, codes.InvalidArgument)
+	_, err = client.AddTags(ctx, &pb.AddTagsRequest{Path: b})
+	wantCode(t, err, codes.InvalidArgument)
+}
+
+func TestBatchAndClean(t *testing.T) {
+	client := newTestClient(t, false)
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeImages(t, dir, "a.jpg", "b.gif", "notes.txt")
+
+	req := &pb.BatchTagsRequest{Directory: dir, Tags: []string{"trip", "2024"}, DryRun: true}
+	resp, err := client.BatchAddTags(ctx, req)
+	if err != nil {
+		t.Fatalf("BatchAddTags dry run failed: %v", err)
+	}
+	if resp.GetImages() != 2 || resp.GetChanges() != 4 {
+		t.Errorf("dry run = %v, want 2 images and 4 changes", resp)
+	}
+	if list, _ := client.ListTags(ctx, &pb.ListTagsRequest{}); len(list.GetTags()) != 0 {
+		t.Errorf("dry run added tags: %v", list.GetTags())
+	}
+
+	req.DryRun = false
+	if _, err := client.BatchAddTags(ctx, req); err != nil {
+		t.Fatalf("BatchAddTags failed: %v", err)
+	}
+	if resp, err := client.BatchRemoveTags(ctx, &pb.BatchTagsRequest{Directory: dir, Tags: []string{"2024"}}); err != nil || resp.GetChanges() != 2 {
+		t.Errorf("BatchRemoveTags = %v, %v; want 2 changes", resp, err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "a.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	cleaned, err := client.Clean(ctx, &pb.CleanRequest{})
+	if err != nil {
+		t.Fatalf("Clean failed: %v", err)
+	}
+	if !reflect.DeepEqual(cleaned.GetMissingImages(), []string{filepath.Join(dir, "a.jpg")}) {
+		t.Errorf("Clean missing images = %v, want a.jpg", cleaned.GetMissingImages())
+	}
+	if found, _ := client.FindImages(ctx, &pb.FindImagesRequest{Tags: []string{"trip"}}); len(found.GetPaths()) != 1 {
+		t.Errorf("images tagged trip after clean = %v, want b.gif only", found.GetPaths())
+	}
+}
+
+func TestReadOnlyServer(t *testing.T) {
+	client := newTestClient(t, true)
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeImages(t, dir, "a.jpg")
+
+	_, err := client.AddTags(ctx, &pb.AddTagsRequest{Path: filepath.Join(dir, "a.jpg"), Tags: []string{"x"}})
+	wantCode(t, err, codes.PermissionDenied)
+	_, err = client.BatchAddTags(ctx, &pb.BatchTagsRequest{Directory: dir, Tags: []string{"x"}})
+	wantCode(t, err, codes.PermissionDenied)
+	_, err = client.Clean(ctx, &pb.CleanRequest{})
+	wantCode(t, err, codes.PermissionDenied)
+
+	// Dry runs and queries still work.
+	if resp, err := client.BatchAddTags(ctx, &pb.BatchTagsRequest{Directory: dir, Tags: []string{"x"}, DryRun: true}); err != nil || resp.GetChanges() != 1 {
+		t.Errorf("read-only dry run = %v, %v; want 1 change", resp, err)
+	}
+	if _, err := client.ListTags(ctx, &pb.ListTagsRequest{}); err != nil {
+		t.Errorf("ListTags failed on a read-only server: %v", err)
+	}
+}
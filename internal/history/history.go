@@ -55,27 +55,53 @@ func (hm *HistoryManager) RecordNavigation(path string) {
 // NavigateBack attempts to get the previous path from history.
 // Returns the path and true if successful, or an empty string and false.
 func (hm *HistoryManager) NavigateBack() (path string, ok bool) {
-	if hm.capacity == 0 {
-		return "", false
-	}
-	if hm.currentIndex <= 0 { // Need to be at least at the second item (index 1) to go back
-		return "", false
-	}
-	hm.currentIndex--
-	return hm.stack[hm.currentIndex], true
+	return hm.navigate(-1, nil)
 }
 
 // NavigateForward attempts to get the next path from history.
 // Returns the path and true if successful, or an empty string and false.
 func (hm *HistoryManager) NavigateForward() (path string, ok bool) {
-	if hm.capacity == 0 {
+	return hm.navigate(1, nil)
+}
+
+// NavigateBackValid moves back to the closest earlier path for which valid
+// returns true, removing the invalid paths it skips from the history, e.g.
+// images deleted since. Returns an empty string and false if there is none.
+func (hm *HistoryManager) NavigateBackValid(valid func(path string) bool) (path string, ok bool) {
+	return hm.navigate(-1, valid)
+}
+
+// NavigateForwardValid is NavigateBackValid going forward.
+func (hm *HistoryManager) NavigateForwardValid(valid func(path string) bool) (path string, ok bool) {
+	return hm.navigate(1, valid)
+}
+
+// CanGoForward reports whether there are paths after the current one, i.e.
+// the user went back and has not navigated anywhere new since.
+func (hm *HistoryManager) CanGoForward() bool {
+	return hm.capacity > 0 && hm.currentIndex >= 0 && hm.currentIndex < len(hm.stack)-1
+}
+
+// navigate moves step entries at a time to the first path accepted by valid,
+// or the adjacent one if valid is nil, and drops the rejected paths.
+func (hm *HistoryManager) navigate(step int, valid func(path string) bool) (string, bool) {
+	if hm.capacity == 0 || hm.currentIndex < 0 {
 		return "", false
 	}
-	if hm.currentIndex == -1 || hm.currentIndex >= len(hm.stack)-1 {
-		return "", false
+	var invalid []string
+	defer func() {
+		for _, p := range invalid {
+			hm.RemovePath(p)
+		}
+	}()
+	for i := hm.currentIndex + step; i >= 0 && i < len(hm.stack); i += step {
+		if valid == nil || valid(hm.stack[i]) {
+			hm.currentIndex = i
+			return hm.stack[i], true
+		}
+		invalid = append(invalid, hm.stack[i])
 	}
-	hm.currentIndex++
-	return hm.stack[hm.currentIndex], true
+	return "", false
 }
 
 // RemovePath removes all occurrences of a given path from the history stack
@@ -127,3 +153,13 @@ func (hm *HistoryManager) RemovePath(pathToRemove string) {
 		hm.currentIndex = newCurrentIndex
 	}
 }
+
+// RenamePath replaces every occurrence of oldPath in the history with newPath,
+// keeping the entries' positions.
+func (hm *HistoryManager) RenamePath(oldPath, newPath string) {
+	for i, p := range hm.stack {
+		if p == oldPath {
+			hm.stack[i] = newPath
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package history
+
+import "testing"
+
+// walk checks that navigating with step returns want, in order, and then
+// nothing further.
+func walk(t *testing.T, hm *HistoryManager, step func() (string, bool), want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if got, ok := step(); !ok || got != w {
+			t.Fatalf("Navigated to %q, %v; want %q", got, ok, w)
+		}
+	}
+	if got, ok := step(); ok {
+		t.Fatalf("Navigated past the end to %q", got)
+	}
+}
+
+func TestMixedRandomAndSequentialNavigation(t *testing.T) {
+	hm := NewHistoryManager(10)
+	// Random picks, then sequential steps.
+	for _, p := range []string{"/r/7", "/r/2", "/r/9", "/s/1", "/s/2"} {
+		hm.RecordNavigation(p)
+	}
+
+	// Back walks the exact sequence shown, forward replays it.
+	walk(t, hm, hm.NavigateBack, "/s/1", "/r/9", "/r/2", "/r/7")
+	if !hm.CanGoForward() {
+		t.Fatal("CanGoForward is false after going back")
+	}
+	walk(t, hm, hm.NavigateForward, "/r/2", "/r/9", "/s/1", "/s/2")
+	if hm.CanGoForward() {
+		t.Fatal("CanGoForward is true at the latest image")
+	}
+
+	// A new image after going back replaces the images after it.
+	hm.NavigateBack()
+	hm.NavigateBack()
+	hm.RecordNavigation("/r/4")
+	if hm.CanGoForward() {
+		t.Fatal("CanGoForward is true after a new image")
+	}
+	walk(t, hm, hm.NavigateBack, "/r/9", "/r/2", "/r/7")
+}
+
+func TestNavigateSkipsInvalidPaths(t *testing.T) {
+	hm := NewHistoryManager(10)
+	for _, p := range []string{"/a", "/gone1", "/b", "/gone2", "/gone1", "/c"} {
+		hm.RecordNavigation(p)
+	}
+	valid := func(path string) bool { return path[1] != 'g' }
+	back := func() (string, bool) { return hm.NavigateBackValid(valid) }
+	forward := func() (string, bool) { return hm.NavigateForwardValid(valid) }
+
+	walk(t, hm, back, "/b", "/a")
+	walk(t, hm, forward, "/b", "/c")
+	// The skipped paths were dropped, so plain navigation no longer sees them.
+	walk(t, hm, hm.NavigateBack, "/b", "/a")
+}
+
+func TestDisabledHistory(t *testing.T) {
+	hm := NewHistoryManager(0)
+	hm.RecordNavigation("/a")
+	hm.RecordNavigation("/b")
+	walk(t, hm, hm.NavigateBack)
+	if hm.CanGoForward() {
+		t.Error("CanGoForward is true with history disabled")
+	}
+}
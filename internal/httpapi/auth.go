@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"fyslide/internal/config"
+	"net/http"
+	"strings"
+)
+
+// authRealm names the protection space in basic auth challenges.
+const authRealm = "FySlide"
+
+// Me is who a request is made as.
+type Me struct {
+	Name string `json:"name"` // Empty without users configured
+	Role string `json:"role"` // config.RoleViewer or config.RoleEditor
+}
+
+// userKey is the context key of the config.APIUser a request is made as.
+type userKey struct{}
+
+// SetUsers sets who may use the API. Without users everyone may, with the
+// editor role unless the service is read-only.
+func (s *Server) SetUsers(users ...config.APIUser) {
+	s.users = users
+}
+
+// authenticate returns the user whose token r carries, as a bearer token or
+// as the password of basic auth, or false for none.
+func (s *Server) authenticate(r *http.Request) (config.APIUser, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	name := ""
+	if !ok {
+		if name, token, ok = r.BasicAuth(); !ok {
+			return config.APIUser{}, false
+		}
+	}
+	// Compare with every user, so the time taken tells nothing about tokens
+	var found config.APIUser
+	match := 0
+	for _, user := range s.users {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(user.Token)) == 1 && (name == "" || name == user.Name) {
+			found, match = user, 1
+		}
+	}
+	return found, match == 1
+}
+
+// withAuth returns next behind authentication of the configured users.
+// Viewers may only read; changing tags takes an editor.
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := s.authenticate(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+authRealm+`", charset="UTF-8"`)
+			http.Error(w, "sign in with a configured API token", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && user.Role != config.RoleEditor {
+			writeError(w, errorf(http.StatusForbidden, "user %s may not change the library", user.Name))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, user)))
+	})
+}
+
+// me answers who the request is made as, so front-ends can hide what the
+// user may not do.
+func (s *Server) me(w http.ResponseWriter, r *http.Request) {
+	if user, ok := r.Context().Value(userKey{}).(config.APIUser); ok {
+		writeJSON(w, Me{Name: user.Name, Role: user.Role})
+		return
+	}
+	role := config.RoleEditor
+	if s.svc.ReadOnly() {
+		role = config.RoleViewer
+	}
+	writeJSON(w, Me{Role: role})
+}
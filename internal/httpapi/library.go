@@ -0,0 +1,291 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"fyslide/internal/scan"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPageSize and MaxPageSize bound the images listed per request.
+	DefaultPageSize = 200
+	MaxPageSize     = 1000
+	// libraryTTL is how long a scan of the library roots answers image lists
+	// before the folders are walked again.
+	libraryTTL = 5 * time.Minute
+)
+
+// TagCount is a tag with the number of images carrying it.
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// ImagePage is a page of an image list.
+type ImagePage struct {
+	Total  int      `json:"total"` // Images in the whole list
+	Offset int      `json:"offset"`
+	Images []string `json:"images"` // Absolute paths
+}
+
+// ImageTags are the tags of an image.
+type ImageTags struct {
+	Path string   `json:"path"`
+	Tags []string `json:"tags"`
+}
+
+// writeJSON answers v as JSON.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("HTTP API: writing response: %v", err)
+	}
+}
+
+// libraryImages returns the images under the library roots, sorted, from a
+// scan at most libraryTTL old.
+func (s *Server) libraryImages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.images != nil && time.Since(s.scanned) < libraryTTL {
+		return s.images
+	}
+	images := []string{}
+	for _, root := range s.roots {
+		for item := range scan.Run(root, func(message string) { log.Printf("HTTP API: %s", message) }) {
+			images = append(images, item.Path)
+		}
+	}
+	slices.Sort(images)
+	s.images, s.scanned = images, time.Now()
+	return images
+}
+
+// inLibrary reports whether the clean path lies under one of the library roots.
+func (s *Server) inLibrary(path string) bool {
+	for _, root := range s.roots {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hiddenImages returns the private images the client may not see, nil if
+// it may see them all.
+func (s *Server) hiddenImages(ctx context.Context) (map[string]bool, error) {
+	if s.showPrivate || s.privateTag == "" {
+		return nil, nil
+	}
+	images, err := s.tagDB.GetImages(ctx, s.privateTag)
+	if err != nil {
+		return nil, fmt.Errorf("error finding private images: %w", err)
+	}
+	hidden := make(map[string]bool, len(images))
+	for _, image := range images {
+		hidden[image] = true
+	}
+	return hidden, nil
+}
+
+// isPrivate reports whether path is an image the client may not see.
+func (s *Server) isPrivate(ctx context.Context, path string) (bool, error) {
+	if s.showPrivate || s.privateTag == "" {
+		return false, nil
+	}
+	tags, err := s.tagDB.GetTags(ctx, path)
+	if err != nil {
+		return false, fmt.Errorf("error listing tags for %s: %w", path, err)
+	}
+	return slices.Contains(tags, s.privateTag), nil
+}
+
+// listTags answers every tag with its image count. The private images are
+// not counted, and the private tag is not listed, unless they may be seen.
+func (s *Server) listTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.tagDB.GetAllTags(r.Context())
+	if err != nil {
+		writeError(w, fmt.Errorf("error listing all tags: %w", err))
+		return
+	}
+	hidden, err := s.hiddenImages(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	uncounted := make(map[string]int)
+	for image := range hidden {
+		imageTags, err := s.tagDB.GetTags(r.Context(), image)
+		if err != nil {
+			writeError(w, fmt.Errorf("error listing tags for %s: %w", image, err))
+			return
+		}
+		for _, tag := range imageTags {
+			uncounted[tag]++
+		}
+	}
+	counts := make([]TagCount, 0, len(tags))
+	for _, tag := range tags {
+		if n := tag.Count - uncounted[tag.Name]; n > 0 {
+			counts = append(counts, TagCount{Name: tag.Name, Count: n})
+		}
+	}
+	writeJSON(w, counts)
+}
+
+// listImages answers a page of the library's images, only those carrying
+// every ?tag= given, leaving out the private ones.
+func (s *Server) listImages(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	offset, err := intParam(query.Get("offset"), 0, 0, -1)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	limit, err := intParam(query.Get("limit"), DefaultPageSize, 1, MaxPageSize)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var images []string
+	if tags := normalizeTags(query["tag"]); len(tags) > 0 {
+		if images, err = s.taggedImages(r, tags); err != nil {
+			writeError(w, err)
+			return
+		}
+	} else {
+		images = s.libraryImages()
+	}
+	hidden, err := s.hiddenImages(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(hidden) > 0 {
+		// Filtered into a new list, as the library scan is shared between requests
+		images = slices.DeleteFunc(slices.Clone(images), func(image string) bool { return hidden[image] })
+	}
+	page := ImagePage{Total: len(images), Offset: offset, Images: []string{}}
+	if offset < len(images) {
+		page.Images = images[offset:min(offset+limit, len(images))]
+	}
+	writeJSON(w, page)
+}
+
+// taggedImages returns the images of the library carrying all of tags, sorted.
+func (s *Server) taggedImages(r *http.Request, tags []string) ([]string, error) {
+	hits := make(map[string]int)
+	for _, tag := range tags {
+		images, err := s.tagDB.GetImages(r.Context(), tag)
+		if err != nil {
+			return nil, fmt.Errorf("error finding images for tag '%s': %w", tag, err)
+		}
+		for _, image := range images {
+			hits[image]++
+		}
+	}
+	images := []string{}
+	for image, n := range hits {
+		if n == len(tags) && s.inLibrary(image) {
+			images = append(images, image)
+		}
+	}
+	slices.Sort(images)
+	return images, nil
+}
+
+// intParam reads a whole number parameter between lo and hi, no upper bound
+// if hi is negative, def if raw is empty.
+func intParam(raw string, def, lo, hi int) (int, error) {
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < lo || (hi >= 0 && n > hi) {
+		return 0, errorf(http.StatusBadRequest, "%q is out of range", raw)
+	}
+	return n, nil
+}
+
+// normalizeTags lowercases tags like the CLI does, dropping empty ones.
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// sameOrigin reports whether r comes from a page of this server, or from a
+// client that is no browser. Other sites open in a browser on the LAN must
+// not change tags through it.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// writeImageTags answers the current tags of path.
+func (s *Server) writeImageTags(w http.ResponseWriter, r *http.Request, path string) {
+	tags, err := s.tagDB.GetTags(r.Context(), path)
+	if err != nil {
+		writeError(w, fmt.Errorf("error listing tags for %s: %w", path, err))
+		return
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	writeJSON(w, ImageTags{Path: path, Tags: tags})
+}
+
+// getImageTags answers the tags of the image ?path=.
+func (s *Server) getImageTags(w http.ResponseWriter, r *http.Request) {
+	path, err := s.visibleImage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.writeImageTags(w, r, path)
+}
+
+// changeImageTags returns a handler applying change to each ?tag= of the
+// image ?path=, answering its resulting tags.
+func (s *Server) changeImageTags(change func(ctx context.Context, path, tag string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !sameOrigin(r) {
+			writeError(w, errorf(http.StatusForbidden, "cross-origin change refused"))
+			return
+		}
+		path, err := s.visibleImage(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		tags := normalizeTags(r.URL.Query()["tag"])
+		if len(tags) == 0 {
+			writeError(w, errorf(http.StatusBadRequest, "at least one tag is required"))
+			return
+		}
+		for _, tag := range tags {
+			if err := change(r.Context(), path, tag); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+		s.writeImageTags(w, r, path)
+	}
+}
@@ -0,0 +1,283 @@
+// Package httpapi serves the library over HTTP for web front-ends and home
+// dashboards, backed by the same Service layer the GUI and the CLI use.
+// Images are only ever served resized and re-encoded, never as their
+// original files.
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/config"
+	"fyslide/internal/exporter"
+	"fyslide/internal/scan"
+	"fyslide/internal/service"
+	"fyslide/internal/tagging"
+	"hash/fnv"
+	"image"
+	_ "image/gif" // Register the decoders of the formats the scanner finds
+	"image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sizes of the served images: the longest edge in pixels, by default and at
+// most, as a client may ask for any size up to the maximum.
+const (
+	DefaultThumbnailSize = 256
+	MaxThumbnailSize     = 512
+	DefaultPreviewSize   = 1600
+	MaxPreviewSize       = 4096
+)
+
+// jpegQuality is the quality thumbnails and previews are encoded with.
+const jpegQuality = 85
+
+// Server answers the HTTP API. It only serves images under the library roots
+// it was created with.
+type Server struct {
+	svc     *service.Service
+	tagDB   *tagging.TagDB
+	roots   []string
+	decodes chan struct{} // Limits concurrent decodes, which hold whole images in memory
+	web     bool          // Serve the web front-end at /; see SetWeb
+	users   []config.APIUser
+
+	privateTag  string // Images carrying it are hidden unless showPrivate; see SetPrivate
+	showPrivate bool
+
+	mu      sync.Mutex
+	images  []string  // Images under the roots, sorted; nil until scanned
+	scanned time.Time // When images was scanned
+}
+
+// NewServer creates a Server for svc, which must operate on tagDB, serving
+// the images under roots.
+func NewServer(svc *service.Service, tagDB *tagging.TagDB, roots ...string) *Server {
+	cleaned := make([]string, 0, len(roots))
+	for _, root := range roots {
+		cleaned = append(cleaned, filepath.Clean(root))
+	}
+	return &Server{svc: svc, tagDB: tagDB, roots: cleaned, decodes: make(chan struct{}, runtime.NumCPU()), privateTag: tagging.PrivateTag}
+}
+
+// SetPrivate sets the tag marking private images, tagging.PrivateTag by
+// default. They are left out of every list and answered as not found, like
+// the GUI hides them behind its PIN, unless show is set. An empty tag marks
+// no image private.
+func (s *Server) SetPrivate(tag string, show bool) {
+	s.privateTag, s.showPrivate = tag, show
+}
+
+// SetWeb turns serving the web front-end at / on or off.
+func (s *Server) SetWeb(on bool) {
+	s.web = on
+}
+
+// Handler returns the HTTP handler of the API:
+//
+//	GET /api/v1/me
+//	GET /api/v1/thumbnail?path=<absolute path>&size=<pixels>
+//	GET /api/v1/preview?path=<absolute path>&size=<pixels>
+//	GET /api/v1/tags
+//	GET /api/v1/images?tag=<tag>&offset=<n>&limit=<n>
+//	GET /api/v1/image/tags?path=<absolute path>
+//	POST /api/v1/image/tags?path=<absolute path>&tag=<tag>
+//	DELETE /api/v1/image/tags?path=<absolute path>&tag=<tag>
+//
+// Images carrying the private tag are left out and answered as not found;
+// see SetPrivate.
+//
+// The thumbnail and preview answer a JPEG whose longest edge is at most size
+// pixels, with an ETag that changes with the file, so clients can revalidate
+// with If-None-Match. The others answer JSON: every tag with its count, a
+// page of the images carrying every tag given (all images without one), and
+// the tags of an image after adding or removing the tags given, and who the
+// client is signed in as.
+//
+// With users set, every request must carry a user's token, as a bearer token
+// or as the password of basic auth, and only editors may change tags.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/me", s.me)
+	mux.HandleFunc("GET /api/v1/thumbnail", s.resized(DefaultThumbnailSize, MaxThumbnailSize))
+	mux.HandleFunc("GET /api/v1/preview", s.resized(DefaultPreviewSize, MaxPreviewSize))
+	mux.HandleFunc("GET /api/v1/tags", s.listTags)
+	mux.HandleFunc("GET /api/v1/images", s.listImages)
+	mux.HandleFunc("GET /api/v1/image/tags", s.getImageTags)
+	mux.HandleFunc("POST /api/v1/image/tags", s.changeImageTags(s.svc.AddTag))
+	mux.HandleFunc("DELETE /api/v1/image/tags", s.changeImageTags(s.svc.RemoveTag))
+	if s.web {
+		mux.Handle("GET /", webHandler())
+	}
+	if len(s.users) > 0 {
+		return s.withAuth(mux)
+	}
+	return mux
+}
+
+// httpError is an error with the HTTP status it is answered with.
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string { return e.err.Error() }
+func (e *httpError) Unwrap() error { return e.err }
+
+// errorf returns an httpError with status.
+func errorf(status int, format string, args ...any) error {
+	return &httpError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+// writeError answers err with its HTTP status, mapping the errors of the
+// service layer like grpcapi maps them to gRPC codes.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var he *httpError
+	switch {
+	case errors.As(err, &he):
+		status = he.status
+	case errors.Is(err, service.ErrReadOnly):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrDestinationExists):
+		status = http.StatusConflict
+	case errors.Is(err, fs.ErrNotExist):
+		status = http.StatusNotFound
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// libraryImage returns the cleaned path parameter of r if it names an image
+// under the library roots. The server's working directory means nothing to a
+// client, so relative paths are refused.
+func (s *Server) libraryImage(r *http.Request) (string, error) {
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		return "", errorf(http.StatusBadRequest, "path is required")
+	}
+	if !filepath.IsAbs(path) {
+		return "", errorf(http.StatusBadRequest, "path must be absolute: %s", path)
+	}
+	path = filepath.Clean(path)
+	if !scan.IsImage(path) {
+		return "", errorf(http.StatusNotFound, "not an image: %s", path)
+	}
+	if !s.inLibrary(path) {
+		return "", errorf(http.StatusForbidden, "not in the library: %s", path)
+	}
+	return path, nil
+}
+
+// visibleImage is libraryImage for an image the client may see: private
+// images are answered as not found, so their paths can't be confirmed.
+func (s *Server) visibleImage(r *http.Request) (string, error) {
+	path, err := s.libraryImage(r)
+	if err != nil {
+		return "", err
+	}
+	private, err := s.isPrivate(r.Context(), path)
+	if err != nil {
+		return "", err
+	}
+	if private {
+		return "", errorf(http.StatusNotFound, "no such image: %s", path)
+	}
+	return path, nil
+}
+
+// sizeParam returns the size parameter of r, def if unset.
+func sizeParam(r *http.Request, def, maxSize int) (int, error) {
+	raw := r.URL.Query().Get("size")
+	if raw == "" {
+		return def, nil
+	}
+	size, err := strconv.Atoi(raw)
+	if err != nil || size < 1 || size > maxSize {
+		return 0, errorf(http.StatusBadRequest, "size must be between 1 and %d", maxSize)
+	}
+	return size, nil
+}
+
+// etag identifies the rendering of the file with info at size. It changes
+// whenever the file is modified or replaced.
+func etag(path string, info fs.FileInfo, size int) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s\x00%d\x00%d\x00%d", path, info.Size(), info.ModTime().UnixNano(), size)
+	return fmt.Sprintf(`"%x"`, h.Sum64())
+}
+
+// resized returns a handler answering the image named by the request scaled
+// down to at most the requested size, def by default and maxSize at most.
+func (s *Server) resized(def, maxSize int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path, err := s.visibleImage(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		size, err := sizeParam(r, def, maxSize)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		tag := etag(path, info, size)
+		w.Header().Set("ETag", tag)
+		w.Header().Set("Cache-Control", "private, no-cache") // Revalidate, which costs a stat
+		if match := r.Header.Get("If-None-Match"); match != "" && strings.Contains(match, tag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		data, err := s.render(r.Context(), path, size)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method != http.MethodHead {
+			w.Write(data)
+		}
+	}
+}
+
+// render decodes the image at path and encodes it as JPEG, scaled down to at
+// most size pixels on its longest edge.
+func (s *Server) render(ctx context.Context, path string, size int) ([]byte, error) {
+	select {
+	case s.decodes <- struct{}{}:
+		defer func() { <-s.decodes }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, errorf(http.StatusUnprocessableEntity, "cannot decode %s: %v", path, err)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, exporter.Resize(img, size), &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("cannot encode %s: %w", path, err)
+	}
+	return buf.Bytes(), nil
+}
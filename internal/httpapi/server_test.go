@@ -0,0 +1,340 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fyslide/internal/config"
+	"fyslide/internal/service"
+	"fyslide/internal/tagging"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer serves a Server with the web front-end for a fresh database
+// and library folder, which holds a 1000x500 PNG named wide.png, open to
+// everyone unless users are given.
+func newTestServer(t *testing.T, readOnly bool, users ...config.APIUser) (*httptest.Server, string) {
+	t.Helper()
+	return newServerWith(t, readOnly, func(s *Server) { s.SetUsers(users...) })
+}
+
+// newServerWith is newTestServer with the Server set up by setup instead.
+func newServerWith(t *testing.T, readOnly bool, setup func(*Server)) (*httptest.Server, string) {
+	t.Helper()
+	tagDB, err := tagging.NewTagDB(t.TempDir(), func(message string) { t.Logf("TagDB: %s", message) })
+	if err != nil {
+		t.Fatalf("NewTagDB failed: %v", err)
+	}
+	t.Cleanup(func() { tagDB.Close() })
+	library := t.TempDir()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1000, 500))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(library, "wide.png"), buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	svc := service.New(tagDB)
+	svc.SetReadOnly(readOnly)
+	s := NewServer(svc, tagDB, library)
+	s.SetWeb(true)
+	setup(s)
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+	return server, library
+}
+
+// get requests the API path with header If-None-Match set to match, if not empty.
+func get(t *testing.T, url, match string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match != "" {
+		req.Header.Set("If-None-Match", match)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestThumbnail(t *testing.T) {
+	server, library := newTestServer(t, false)
+	wide := filepath.Join(library, "wide.png")
+	url := server.URL + "/api/v1/thumbnail?path=" + wide
+
+	resp := get(t, url, "")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("thumbnail: %s, %s", resp.Status, resp.Header.Get("Content-Type"))
+	}
+	img, err := jpeg.Decode(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != DefaultThumbnailSize || b.Dy() != DefaultThumbnailSize/2 {
+		t.Errorf("thumbnail is %v, want %dx%d", b, DefaultThumbnailSize, DefaultThumbnailSize/2)
+	}
+
+	tag := resp.Header.Get("ETag")
+	if resp := get(t, url, tag); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("revalidating an unchanged image: %s, want 304", resp.Status)
+	}
+	if resp := get(t, url+"&size=100", tag); resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == tag {
+		t.Errorf("another size: %s with ETag %s, want a new rendering", resp.Status, resp.Header.Get("ETag"))
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(wide, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if resp := get(t, url, tag); resp.StatusCode != http.StatusOK {
+		t.Errorf("revalidating a changed image: %s, want 200", resp.Status)
+	}
+}
+
+func TestPreviewRefusals(t *testing.T) {
+	server, library := newTestServer(t, false)
+	outside := filepath.Join(t.TempDir(), "outside.png")
+	if err := os.WriteFile(outside, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for query, want := range map[string]int{
+		"":                                    http.StatusBadRequest,
+		"path=wide.png":                       http.StatusBadRequest,
+		"path=" + outside:                     http.StatusForbidden,
+		"path=" + library + "/../outside.png": http.StatusForbidden,
+		"path=" + filepath.Join(library, "x.txt"):                    http.StatusNotFound,
+		"path=" + filepath.Join(library, "gone.png"):                 http.StatusNotFound,
+		"path=" + filepath.Join(library, "wide.png") + "&size=99999": http.StatusBadRequest,
+		"path=" + filepath.Join(library, "wide.png") + "&size=2000":  http.StatusOK,
+	} {
+		if resp := get(t, server.URL+"/api/v1/preview?"+query, ""); resp.StatusCode != want {
+			t.Errorf("preview?%s: %s, want %d", query, resp.Status, want)
+		}
+	}
+}
+
+// call requests the API path with method and decodes the JSON answer into v,
+// returning the status code.
+func call(t *testing.T, method, url string, v any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK && v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestTagsAndImages(t *testing.T) {
+	server, library := newTestServer(t, false)
+	wide := filepath.Join(library, "wide.png")
+	api := server.URL + "/api/v1/"
+
+	var tags ImageTags
+	if code := call(t, http.MethodPost, api+"image/tags?path="+wide+"&tag=Beach&tag=sunset", &tags); code != http.StatusOK || !slices.Equal(tags.Tags, []string{"beach", "sunset"}) {
+		t.Fatalf("adding tags: %d, %+v", code, tags)
+	}
+	if code := call(t, http.MethodDelete, api+"image/tags?path="+wide+"&tag=sunset", &tags); code != http.StatusOK || !slices.Equal(tags.Tags, []string{"beach"}) {
+		t.Errorf("removing a tag: %d, %+v", code, tags)
+	}
+	var counts []TagCount
+	if call(t, http.MethodGet, api+"tags", &counts); len(counts) != 1 || counts[0] != (TagCount{Name: "beach", Count: 1}) {
+		t.Errorf("tags = %+v, want beach once", counts)
+	}
+
+	var page ImagePage
+	if call(t, http.MethodGet, api+"images", &page); page.Total != 1 || !slices.Equal(page.Images, []string{wide}) {
+		t.Errorf("all images = %+v", page)
+	}
+	if call(t, http.MethodGet, api+"images?tag=beach", &page); page.Total != 1 {
+		t.Errorf("images tagged beach = %+v", page)
+	}
+	if call(t, http.MethodGet, api+"images?tag=beach&tag=city", &page); page.Total != 0 || page.Images == nil {
+		t.Errorf("images tagged beach and city = %+v, want an empty list", page)
+	}
+	if code := call(t, http.MethodGet, api+"images?limit=0", nil); code != http.StatusBadRequest {
+		t.Errorf("limit 0: %d, want 400", code)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, api+"image/tags?path="+wide+"&tag=x", nil)
+	req.Header.Set("Origin", "http://evil.example")
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("cross-origin change: %v, %v, want 403", resp, err)
+	} else {
+		resp.Body.Close()
+	}
+
+	resp := get(t, server.URL+"/", "")
+	if body, _ := io.ReadAll(resp.Body); resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "app.js") {
+		t.Errorf("web front-end: %s", resp.Status)
+	}
+}
+
+func TestPrivateImages(t *testing.T) {
+	for _, show := range []bool{false, true} {
+		server, library := newServerWith(t, false, func(s *Server) { s.SetPrivate(tagging.PrivateTag, show) })
+		wide := filepath.Join(library, "wide.png")
+		api := server.URL + "/api/v1/"
+		if code := call(t, http.MethodPost, api+"image/tags?path="+wide+"&tag=beach&tag="+tagging.PrivateTag, nil); code != http.StatusOK {
+			t.Fatalf("tagging private: %d", code)
+		}
+
+		want := http.StatusNotFound
+		if show {
+			want = http.StatusOK
+		}
+		for _, path := range []string{"thumbnail", "preview", "image/tags"} {
+			if code := call(t, http.MethodGet, api+path+"?path="+wide, nil); code != want {
+				t.Errorf("show %v: %s of a private image: %d, want %d", show, path, code, want)
+			}
+		}
+		if code := call(t, http.MethodDelete, api+"image/tags?path="+wide+"&tag=beach", nil); code != want {
+			t.Errorf("show %v: untagging a private image: %d, want %d", show, code, want)
+		}
+		var page ImagePage
+		if call(t, http.MethodGet, api+"images", &page); (page.Total == 1) != show {
+			t.Errorf("show %v: all images = %+v", show, page)
+		}
+		var counts []TagCount
+		if call(t, http.MethodGet, api+"tags", &counts); (len(counts) > 0) != show {
+			t.Errorf("show %v: tags = %+v", show, counts)
+		}
+	}
+}
+
+// TestListImagesPrivate checks the lists the web front-end browses, searches
+// by tag and plays slideshows from leave out an image tagged private.
+func TestListImagesPrivate(t *testing.T) {
+	server, library := newTestServer(t, false)
+	wide := filepath.Join(library, "wide.png")
+	secret := filepath.Join(library, "secret.png")
+	data, err := os.ReadFile(wide)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(secret, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	api := server.URL + "/api/v1/"
+	for path, tags := range map[string]string{wide: "&tag=beach", secret: "&tag=beach&tag=private"} {
+		if code := call(t, http.MethodPost, api+"image/tags?path="+path+tags, nil); code != http.StatusOK {
+			t.Fatalf("tagging %s: %d", path, code)
+		}
+	}
+
+	for _, query := range []string{"", "?tag=beach", "?tag=beach&limit=1", "?tag=beach&offset=1"} {
+		var page ImagePage
+		call(t, http.MethodGet, api+"images"+query, &page)
+		if page.Total != 1 || slices.Contains(page.Images, secret) {
+			t.Errorf("images%s = %+v, want only %s", query, page, wide)
+		}
+	}
+	var page ImagePage
+	if call(t, http.MethodGet, api+"images?tag=private", &page); page.Total != 0 {
+		t.Errorf("images tagged private = %+v, want none", page)
+	}
+	var counts []TagCount
+	if call(t, http.MethodGet, api+"tags", &counts); !slices.Equal(counts, []TagCount{{Name: "beach", Count: 1}}) {
+		t.Errorf("tags = %+v, want beach once", counts)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	server, library := newTestServer(t, true)
+	url := server.URL + "/api/v1/image/tags?path=" + filepath.Join(library, "wide.png") + "&tag=beach"
+	if code := call(t, http.MethodPost, url, nil); code != http.StatusForbidden {
+		t.Errorf("adding a tag when read-only: %d, want 403", code)
+	}
+	if code := call(t, http.MethodGet, url, nil); code != http.StatusOK {
+		t.Errorf("reading tags when read-only: %d, want 200", code)
+	}
+	var me Me
+	if call(t, http.MethodGet, server.URL+"/api/v1/me", &me); me.Role != config.RoleViewer {
+		t.Errorf("me when read-only = %+v, want a viewer", me)
+	}
+}
+
+func TestUsers(t *testing.T) {
+	server, library := newTestServer(t, false,
+		config.APIUser{Name: "ann", Token: "correct-horse-battery", Role: config.RoleEditor},
+		config.APIUser{Name: "kitchen", Token: "staple-frame-display", Role: config.RoleViewer})
+	url := server.URL + "/api/v1/image/tags?path=" + filepath.Join(library, "wide.png") + "&tag=beach"
+	request := func(method, url string, auth func(*http.Request)) int {
+		t.Helper()
+		req, err := http.NewRequest(method, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		auth(req)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	bearer := func(token string) func(*http.Request) {
+		return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	}
+	basic := func(name, token string) func(*http.Request) {
+		return func(req *http.Request) { req.SetBasicAuth(name, token) }
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		auth   func(*http.Request)
+		want   int
+	}{
+		{"no token", http.MethodGet, url, func(*http.Request) {}, http.StatusUnauthorized},
+		{"no token for the web front-end", http.MethodGet, server.URL + "/", func(*http.Request) {}, http.StatusUnauthorized},
+		{"wrong token", http.MethodGet, url, bearer("staple-frame-displax"), http.StatusUnauthorized},
+		{"token of another user", http.MethodGet, url, basic("ann", "staple-frame-display"), http.StatusUnauthorized},
+		{"viewer reads", http.MethodGet, url, bearer("staple-frame-display"), http.StatusOK},
+		{"viewer opens the web front-end", http.MethodGet, server.URL + "/", basic("kitchen", "staple-frame-display"), http.StatusOK},
+		{"viewer changes", http.MethodPost, url, bearer("staple-frame-display"), http.StatusForbidden},
+		{"editor changes", http.MethodPost, url, basic("ann", "correct-horse-battery"), http.StatusOK},
+		{"editor removes", http.MethodDelete, url, bearer("correct-horse-battery"), http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := request(tt.method, tt.url, tt.auth); got != tt.want {
+			t.Errorf("%s: %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/me", nil)
+	req.SetBasicAuth("kitchen", "staple-frame-display")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var me Me
+	if err := json.NewDecoder(resp.Body).Decode(&me); err != nil || me != (Me{Name: "kitchen", Role: config.RoleViewer}) {
+		t.Errorf("me = %+v, %v", me, err)
+	}
+}
@@ -0,0 +1,22 @@
+package httpapi
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// webFiles is the web front-end: a single page using the JSON API to browse
+// the library, find images by tag, play a slideshow and edit tags.
+//
+//go:embed web
+var webFiles embed.FS
+
+// webHandler serves the web front-end.
+func webHandler() http.Handler {
+	root, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		panic(err) // The embedded folder is always there
+	}
+	return http.FileServerFS(root)
+}
@@ -0,0 +1,175 @@
+// FySlide web front-end: browses the library through the JSON API of
+// fyslide-cli serve-http, finds images by tag, plays a slideshow and edits tags.
+"use strict";
+
+const slideInterval = 5000; // Milliseconds per image in the slideshow
+const $ = (id) => document.getElementById(id);
+
+const state = { tags: [], images: [], total: 0, index: -1, timer: null, editor: false };
+
+// api calls the JSON API and returns the decoded answer, throwing its error text.
+async function api(method, path, params) {
+  const query = new URLSearchParams(params);
+  const resp = await fetch(`/api/v1/${path}?${query}`, { method });
+  if (!resp.ok) {
+    throw new Error((await resp.text()).trim() || resp.statusText);
+  }
+  return resp.json();
+}
+
+function imageURL(kind, path) {
+  return `/api/v1/${kind}?${new URLSearchParams({ path })}`;
+}
+
+function baseName(path) {
+  return path.split(/[\\/]/).pop();
+}
+
+function showError(err) {
+  $("status").textContent = `Error: ${err.message}`;
+}
+
+async function loadTagList() {
+  const tags = await api("GET", "tags");
+  $("tag-list").replaceChildren(...tags.map((tag) => {
+    const option = document.createElement("option");
+    option.value = tag.name;
+    option.label = `${tag.name} (${tag.count})`;
+    return option;
+  }));
+}
+
+// loadPage appends the next page of the current image list to the grid.
+async function loadPage() {
+  const params = [["offset", state.images.length]];
+  state.tags.forEach((tag) => params.push(["tag", tag]));
+  const page = await api("GET", "images", params);
+  state.total = page.total;
+  for (const path of page.images) {
+    const index = state.images.push(path) - 1;
+    const img = document.createElement("img");
+    img.loading = "lazy";
+    img.alt = baseName(path);
+    img.src = imageURL("thumbnail", path);
+    img.addEventListener("click", () => openViewer(index));
+    $("grid").append(img);
+  }
+  const filter = state.tags.length ? ` tagged ${state.tags.join(" + ")}` : "";
+  $("status").textContent = `${state.total} images${filter}`;
+  $("more").hidden = state.images.length >= state.total;
+}
+
+async function search(tags) {
+  state.tags = tags;
+  state.images = [];
+  $("grid").replaceChildren();
+  await loadPage();
+}
+
+async function openViewer(index) {
+  if (index >= state.images.length && state.images.length < state.total) {
+    await loadPage(); // The slideshow ran past the loaded pages
+  }
+  if (state.images.length === 0) {
+    return;
+  }
+  state.index = (index + state.images.length) % state.images.length;
+  const path = state.images[state.index];
+  $("viewer").hidden = false;
+  $("preview").src = imageURL("preview", path);
+  $("name").textContent = `${state.index + 1} / ${state.total}: ${path}`;
+  showImageTags(await api("GET", "image/tags", { path }));
+}
+
+function closeViewer() {
+  stopSlideshow();
+  $("viewer").hidden = true;
+}
+
+// loadMe finds out whether the user may edit tags, offering it only then.
+async function loadMe() {
+  const me = await api("GET", "me");
+  state.editor = me.role === "editor";
+  $("add-tag").hidden = !state.editor;
+}
+
+function showImageTags(image) {
+  if (image.path !== state.images[state.index]) {
+    return; // Moved on in the meantime
+  }
+  $("image-tags").replaceChildren(...image.tags.map((tag) => {
+    const item = document.createElement("li");
+    item.textContent = tag;
+    if (!state.editor) {
+      return item;
+    }
+    const remove = document.createElement("button");
+    remove.textContent = "×";
+    remove.title = `Remove ${tag}`;
+    remove.addEventListener("click", () => changeTag("DELETE", tag));
+    item.append(remove);
+    return item;
+  }));
+}
+
+async function changeTag(method, tag) {
+  const path = state.images[state.index];
+  try {
+    showImageTags(await api(method, "image/tags", { path, tag }));
+    loadTagList();
+  } catch (err) {
+    alert(err.message);
+  }
+}
+
+function stopSlideshow() {
+  clearInterval(state.timer);
+  state.timer = null;
+  $("play").innerHTML = "&#9654;";
+}
+
+function toggleSlideshow() {
+  if (state.timer) {
+    stopSlideshow();
+    return;
+  }
+  state.timer = setInterval(() => openViewer(state.index + 1).catch(showError), slideInterval);
+  $("play").innerHTML = "&#10074;&#10074;";
+}
+
+$("search").addEventListener("submit", (event) => {
+  event.preventDefault();
+  const tags = $("tags").value.split(",").map((tag) => tag.trim().toLowerCase()).filter(Boolean);
+  search(tags).catch(showError);
+});
+$("more").addEventListener("click", () => loadPage().catch(showError));
+$("prev").addEventListener("click", () => openViewer(state.index - 1).catch(showError));
+$("next").addEventListener("click", () => openViewer(state.index + 1).catch(showError));
+$("play").addEventListener("click", toggleSlideshow);
+$("close").addEventListener("click", closeViewer);
+$("add-tag").addEventListener("submit", (event) => {
+  event.preventDefault();
+  const tag = $("new-tag").value.trim().toLowerCase();
+  $("new-tag").value = "";
+  if (tag) {
+    changeTag("POST", tag);
+  }
+});
+document.addEventListener("keydown", (event) => {
+  if ($("viewer").hidden || event.target.tagName === "INPUT") {
+    return;
+  }
+  const keys = { ArrowLeft: -1, ArrowRight: 1 };
+  if (event.key in keys) {
+    openViewer(state.index + keys[event.key]).catch(showError);
+  } else if (event.key === " ") {
+    event.preventDefault();
+    toggleSlideshow();
+  } else if (event.key === "Escape") {
+    closeViewer();
+  }
+});
+
+loadMe().catch(showError);
+loadTagList().catch(showError);
+search([]).catch(showError);
@@ -0,0 +1,119 @@
+// Package imagesig Cache persists signatures between launches, keyed by path
+// and invalidated when a file's size or modification time changes.
+package imagesig
+
+import (
+	"encoding/json"
+	"fmt"
+	"fyslide/internal/paths"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	cacheFileName    = "fyslide_signatures.db"
+	signaturesBucket = "Signatures"
+
+	// signatureVersion changes whenever Signature gains a field, so entries
+	// computed before are recomputed rather than served without it.
+	signatureVersion = 2
+)
+
+// Cache stores the signature of each image file along with the file's size
+// and modification time when it was computed.
+type Cache struct {
+	db     *bolt.DB
+	decode Decoder // Decodes the images to compute; nil decodes directly
+}
+
+// cachedSignature is the persisted form of one file's signature.
+type cachedSignature struct {
+	Version int       `json:"v"`
+	Size    int64     `json:"size"`
+	ModTime int64     `json:"mtime"` // Unix nanoseconds
+	Sig     Signature `json:"sig"`
+}
+
+// OpenCache opens or creates the signature cache in cacheDir. An empty
+// cacheDir uses the per-user cache directory, paths.CacheDir.
+func OpenCache(cacheDir string) (*Cache, error) {
+	if cacheDir == "" {
+		dir, err := paths.CacheDir()
+		if err != nil {
+			return nil, err
+		}
+		cacheDir = dir
+	}
+	if err := os.MkdirAll(cacheDir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %s: %w", cacheDir, err)
+	}
+
+	cachePath := filepath.Join(cacheDir, cacheFileName)
+	db, err := bolt.Open(cachePath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open signature cache %s: %w", cachePath, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(signaturesBucket))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create signature cache bucket: %w", err)
+	}
+	return &Cache{db: db}, nil
+}
+
+// SetDecoder makes the cache decode the images whose signatures it computes
+// through decode.
+func (c *Cache) SetDecoder(decode Decoder) {
+	c.decode = decode
+}
+
+// Close closes the underlying database.
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+// Get returns the signature of the image at path, computing and storing it
+// unless the cached one is still current. A nil Cache always computes.
+func (c *Cache) Get(path string) (Signature, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Signature{}, err
+	}
+	if c == nil {
+		return ComputeFile(path)
+	}
+
+	var cached cachedSignature
+	found := false
+	c.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket([]byte(signaturesBucket)).Get([]byte(path)); v != nil {
+			found = json.Unmarshal(v, &cached) == nil // Treat a damaged entry as a miss
+		}
+		return nil
+	})
+	if found && cached.Version == signatureVersion && cached.Size == info.Size() && cached.ModTime == info.ModTime().UnixNano() {
+		return cached.Sig, nil
+	}
+
+	sig, err := computeFile(path, c.decode)
+	if err != nil {
+		return sig, err
+	}
+	data, err := json.Marshal(cachedSignature{Version: signatureVersion, Size: info.Size(), ModTime: info.ModTime().UnixNano(), Sig: sig})
+	if err != nil {
+		return sig, err
+	}
+	err = c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(signaturesBucket)).Put([]byte(path), data)
+	})
+	if err != nil {
+		return sig, fmt.Errorf("failed to cache signature of %s: %w", path, err)
+	}
+	return sig, nil
+}
@@ -0,0 +1,298 @@
+// Package imagesig computes compact signatures of image content, namely the
+// share of each broad color and a perceptual hash, and caches them per file so
+// that comparing a library against one image only decodes the files that changed.
+package imagesig
+
+import (
+	"cmp"
+	"fmt"
+	"image"
+	_ "image/gif" // Register the decoders of the supported formats
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"math/bits"
+	"os"
+	"slices"
+	"sort"
+)
+
+// Color buckets, in the order of Signature.Colors.
+const (
+	Red = iota
+	Orange
+	Yellow
+	Green
+	Cyan
+	Blue
+	Purple
+	Pink
+	Brown
+	Black
+	White
+	Gray
+	numColors
+)
+
+// ColorNames names the color buckets, indexed like Signature.Colors.
+var ColorNames = [numColors]string{"red", "orange", "yellow", "green", "cyan", "blue", "purple", "pink", "brown", "black", "white", "gray"}
+
+// ColorTagPrefix starts every tag derived from colors.
+const ColorTagPrefix = "color:"
+
+// BWTag marks images without noticeable color.
+const BWTag = ColorTagPrefix + "bw"
+
+const (
+	sampleGrid     = 64   // Pixels sampled per axis
+	neutralMaxSat  = 0.15 // Saturation below which a pixel counts as neutral
+	bwMinNeutral   = 0.97 // Share of neutral pixels making an image black and white
+	colorTagMin    = 0.20 // Share a chromatic bucket needs to become a tag
+	maxColorTags   = 2
+	similarMaxDist = 0.5 // Color distance beyond which images aren't similar
+
+	hashWidth   = 9  // Cells per row of the difference hash grid, one more than bits
+	hashHeight  = 8  // Rows of the difference hash grid
+	hashMaxDist = 12 // Differing hash bits beyond which images don't look alike
+)
+
+// Signature summarizes an image's content.
+type Signature struct {
+	Colors  [numColors]float64 `json:"colors"`  // Share of sampled pixels in each color bucket
+	Neutral float64            `json:"neutral"` // Share of sampled pixels with hardly any saturation
+	Hash    uint64             `json:"dhash"`   // Difference hash: brightness gradients of a 9x8 grid
+
+	// Quality measurements, see QualityTags.
+	Sharpness       float64 `json:"sharpness"`         // Variance of the Laplacian of the luma
+	Dark            float64 `json:"dark"`              // Share of samples clipped to black
+	Bright          float64 `json:"bright"`            // Share of samples clipped to white
+	QualityMeasured bool    `json:"quality,omitempty"` // False for images too small to judge sharpness
+}
+
+// Compute samples img on a grid and returns its signature.
+func Compute(img image.Image) Signature {
+	var sig Signature
+	b := img.Bounds()
+	if b.Empty() {
+		return sig
+	}
+	stepX := max(1, b.Dx()/sampleGrid)
+	stepY := max(1, b.Dy()/sampleGrid)
+	n := 0
+	var luma, cells [hashHeight][hashWidth]float64
+	for y := b.Min.Y; y < b.Max.Y; y += stepY {
+		for x := b.Min.X; x < b.Max.X; x += stepX {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			rf, gf, bf := float64(r)/0xffff, float64(g)/0xffff, float64(bl)/0xffff
+			bucket, sat := classify(rf, gf, bf)
+			sig.Colors[bucket]++
+			if sat < neutralMaxSat {
+				sig.Neutral++
+			}
+			cy, cx := (y-b.Min.Y)*hashHeight/b.Dy(), (x-b.Min.X)*hashWidth/b.Dx()
+			luma[cy][cx] += 0.299*rf + 0.587*gf + 0.114*bf
+			cells[cy][cx]++
+			n++
+		}
+	}
+	for i := range sig.Colors {
+		sig.Colors[i] /= float64(n)
+	}
+	sig.Neutral /= float64(n)
+	sig.Hash = differenceHash(luma, cells)
+	measureQuality(img, &sig)
+	return sig
+}
+
+// differenceHash sets one bit per pair of horizontally adjacent grid cells,
+// when the left one is brighter. Cells without samples (images narrower or
+// shorter than the grid) count as black.
+func differenceHash(luma, cells [hashHeight][hashWidth]float64) uint64 {
+	var hash uint64
+	for y := 0; y < hashHeight; y++ {
+		for x := 0; x < hashWidth-1; x++ {
+			left, right := luma[y][x], luma[y][x+1]
+			if cells[y][x] > 0 {
+				left /= cells[y][x]
+			}
+			if cells[y][x+1] > 0 {
+				right /= cells[y][x+1]
+			}
+			hash <<= 1
+			if left > right {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// Decoder decodes an image file, e.g. through a shared decode pool.
+type Decoder func(path string) (image.Image, error)
+
+// ComputeFile decodes the image at path and returns its signature.
+func ComputeFile(path string) (Signature, error) {
+	return computeFile(path, nil)
+}
+
+// computeFile is ComputeFile decoding through decode, or directly if nil.
+func computeFile(path string, decode Decoder) (Signature, error) {
+	if decode == nil {
+		decode = decodeFile
+	}
+	img, err := decode(path)
+	if err != nil {
+		return Signature{}, err
+	}
+	return Compute(img), nil
+}
+
+// decodeFile opens and decodes the image at path.
+func decodeFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// classify returns the color bucket of an RGB color with components in [0,1],
+// and its HSV saturation.
+func classify(r, g, b float64) (int, float64) {
+	hi := max(r, g, b)
+	lo := min(r, g, b)
+	v := hi
+	var s float64
+	if hi > 0 {
+		s = (hi - lo) / hi
+	}
+	switch {
+	case v < 0.2:
+		return Black, s
+	case s < neutralMaxSat:
+		if v > 0.85 {
+			return White, s
+		}
+		return Gray, s
+	}
+
+	var h float64
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/(hi-lo), 6)
+	case g:
+		h = (b-r)/(hi-lo) + 2
+	default:
+		h = (r-g)/(hi-lo) + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	switch {
+	case h < 15 || h >= 335:
+		return Red, s
+	case h < 40:
+		if v < 0.6 {
+			return Brown, s
+		}
+		return Orange, s
+	case h < 65:
+		return Yellow, s
+	case h < 165:
+		return Green, s
+	case h < 195:
+		return Cyan, s
+	case h < 255:
+		return Blue, s
+	case h < 290:
+		return Purple, s
+	default:
+		return Pink, s
+	}
+}
+
+// ColorTags returns broad color tags for the signature: color:bw for images
+// without noticeable color, otherwise the most common chromatic buckets that
+// cover a fair share of the image.
+func (s Signature) ColorTags() []string {
+	if s.Neutral >= bwMinNeutral {
+		return []string{BWTag}
+	}
+	var buckets []int
+	for i := Red; i <= Brown; i++ {
+		if s.Colors[i] >= colorTagMin {
+			buckets = append(buckets, i)
+		}
+	}
+	sort.SliceStable(buckets, func(i, j int) bool { return s.Colors[buckets[i]] > s.Colors[buckets[j]] })
+	var tags []string
+	for _, i := range buckets[:min(len(buckets), maxColorTags)] {
+		tags = append(tags, ColorTagPrefix+ColorNames[i])
+	}
+	return tags
+}
+
+// ColorDistance returns how differently two images are colored, from 0 for
+// identical color distributions to 1 for ones without any color in common.
+func ColorDistance(a, b Signature) float64 {
+	var d float64
+	for i := range a.Colors {
+		d += math.Abs(a.Colors[i] - b.Colors[i])
+	}
+	return d / 2
+}
+
+// HashDistance returns the number of differing perceptual hash bits, from 0
+// for images that look the same to 64.
+func HashDistance(a, b Signature) int {
+	return bits.OnesCount64(a.Hash ^ b.Hash)
+}
+
+// Match is an image ranked by its distance to a reference image.
+type Match struct {
+	Path     string
+	Distance float64
+}
+
+// RankByColor returns the images of sigs whose colors are similar to target,
+// closest first, at most limit of them (0 means no limit).
+func RankByColor(target Signature, sigs map[string]Signature, limit int) []Match {
+	return rank(sigs, limit, similarMaxDist, func(sig Signature) float64 { return ColorDistance(target, sig) })
+}
+
+// RankByLook returns the images of sigs that look like target according to
+// their perceptual hashes, closest first, at most limit of them (0 means no
+// limit). Ties are broken by color distance.
+func RankByLook(target Signature, sigs map[string]Signature, limit int) []Match {
+	return rank(sigs, limit, hashMaxDist+similarMaxDist, func(sig Signature) float64 {
+		d := float64(HashDistance(target, sig))
+		if d > hashMaxDist {
+			return math.Inf(1)
+		}
+		return d + ColorDistance(target, sig)
+	})
+}
+
+// rank returns the images of sigs at most maxDist from the reference image,
+// closest first.
+func rank(sigs map[string]Signature, limit int, maxDist float64, distance func(Signature) float64) []Match {
+	var matches []Match
+	for path, sig := range sigs {
+		if d := distance(sig); d <= maxDist {
+			matches = append(matches, Match{Path: path, Distance: d})
+		}
+	}
+	slices.SortFunc(matches, func(a, b Match) int {
+		return cmp.Or(cmp.Compare(a.Distance, b.Distance), cmp.Compare(a.Path, b.Path))
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
@@ -0,0 +1,186 @@
+package imagesig
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// halves returns an image whose left half is left and right half is right.
+func halves(left, right color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if x < 50 {
+				img.Set(x, y, left)
+			} else {
+				img.Set(x, y, right)
+			}
+		}
+	}
+	return img
+}
+
+func writePNG(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestColorTags(t *testing.T) {
+	red := color.RGBA{220, 20, 30, 255}
+	blue := color.RGBA{20, 40, 200, 255}
+	tests := []struct {
+		name string
+		img  image.Image
+		want []string
+	}{
+		{"red and blue", halves(red, blue), []string{"color:red", "color:blue"}},
+		{"black and white", halves(color.Black, color.White), []string{BWTag}},
+		{"mostly gray", halves(color.Gray{128}, color.White), []string{BWTag}},
+		{"red on black", halves(red, color.Black), []string{"color:red"}},
+	}
+	for _, tt := range tests {
+		if got := Compute(tt.img).ColorTags(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ColorTags = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRankByColor(t *testing.T) {
+	red := color.RGBA{220, 20, 30, 255}
+	green := color.RGBA{30, 200, 40, 255}
+	target := Compute(halves(red, color.White))
+	sigs := map[string]Signature{
+		"same":    Compute(halves(red, color.White)),
+		"close":   Compute(halves(red, color.Gray{200})),
+		"nothing": Compute(halves(green, color.Black)),
+	}
+	matches := RankByColor(target, sigs, 0)
+	var paths []string
+	for _, m := range matches {
+		paths = append(paths, m.Path)
+	}
+	if want := []string{"same", "close"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("RankByColor = %v, want %v", matches, want)
+	}
+	if matches[0].Distance != 0 {
+		t.Errorf("distance to an identical image = %v, want 0", matches[0].Distance)
+	}
+	if got := RankByColor(target, sigs, 1); len(got) != 1 {
+		t.Errorf("RankByColor with limit 1 returned %d matches", len(got))
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.png")
+	writePNG(t, path, halves(color.Black, color.White))
+
+	cache, err := OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	sig, err := cache.Get(path)
+	if err != nil || !reflect.DeepEqual(sig.ColorTags(), []string{BWTag}) {
+		t.Fatalf("Get = %+v, %v", sig, err)
+	}
+
+	// A changed file is computed again rather than served from the cache.
+	writePNG(t, path, halves(color.RGBA{220, 20, 30, 255}, color.RGBA{220, 20, 30, 255}))
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	sig, err = cache.Get(path)
+	if err != nil || !reflect.DeepEqual(sig.ColorTags(), []string{"color:red"}) {
+		t.Errorf("Get after change = %v, %v", sig.ColorTags(), err)
+	}
+
+	var nilCache *Cache
+	if _, err := nilCache.Get(path); err != nil {
+		t.Errorf("Get without a cache failed: %v", err)
+	}
+	if _, err := cache.Get(filepath.Join(dir, "missing.png")); err == nil {
+		t.Error("Get of a missing file succeeded")
+	}
+}
+
+// gradient returns a horizontal gray gradient, brightening to the right
+// unless reversed, lifted by offset.
+func gradient(reversed bool, offset uint8) image.Image {
+	img := image.NewGray(image.Rect(0, 0, 120, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 120; x++ {
+			v := x * 2
+			if reversed {
+				v = 238 - v
+			}
+			img.SetGray(x, y, color.Gray{uint8(v)/2 + offset})
+		}
+	}
+	return img
+}
+
+func TestRankByLook(t *testing.T) {
+	target := Compute(gradient(false, 0))
+	sigs := map[string]Signature{
+		"brighter": Compute(gradient(false, 60)),
+		"reversed": Compute(gradient(true, 0)),
+		"same":     Compute(gradient(false, 0)),
+	}
+	if d := HashDistance(target, sigs["reversed"]); d < 32 {
+		t.Errorf("hash distance to the reversed gradient = %d, want most bits to differ", d)
+	}
+	var paths []string
+	for _, m := range RankByLook(target, sigs, 0) {
+		paths = append(paths, m.Path)
+	}
+	if want := []string{"same", "brighter"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("RankByLook = %v, want %v", paths, want)
+	}
+}
+
+func TestQualityTags(t *testing.T) {
+	checker := image.NewGray(image.Rect(0, 0, 200, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			checker.SetGray(x, y, color.Gray{Y: uint8(60 + 120*((x/2+y/2)%2))})
+		}
+	}
+	gradient := image.NewGray(image.Rect(0, 0, 200, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			gradient.SetGray(x, y, color.Gray{Y: uint8(40 + x/2)})
+		}
+	}
+	tests := []struct {
+		name string
+		img  image.Image
+		want []string
+	}{
+		{"sharp", checker, nil},
+		{"smooth", gradient, []string{BlurryTag}},
+		{"blown out with a sharp edge", halves(color.White, color.Gray{Y: 128}), []string{OverexposedTag}},
+		{"too dark", halves(color.Black, color.Black), []string{BlurryTag, UnderexposedTag}},
+		{"too small to judge", image.NewGray(image.Rect(0, 0, 2, 2)), []string{UnderexposedTag}},
+	}
+	for _, tt := range tests {
+		if got := Compute(tt.img).QualityTags(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: QualityTags = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package imagesig quality: heuristics flagging images that are likely
+// blurry, judged by the variance of their Laplacian, or badly exposed, judged
+// by how much of their histogram is clipped to black or white.
+package imagesig
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// QualityTagPrefix starts every tag derived from quality warnings.
+const QualityTagPrefix = "quality:"
+
+// Quality warning tags.
+const (
+	BlurryTag       = QualityTagPrefix + "blurry"
+	UnderexposedTag = QualityTagPrefix + "underexposed"
+	OverexposedTag  = QualityTagPrefix + "overexposed"
+)
+
+const (
+	qualityGrid      = 512  // Luma samples per axis the heuristics look at
+	blurMaxSharpness = 100  // Laplacian variance, in 8-bit luma units, below which an image counts as blurry
+	clipDarkMax      = 8    // Luma at or below which a sample is clipped to black
+	clipBrightMin    = 247  // Luma at or above which a sample is clipped to white
+	underexposedMin  = 0.40 // Share of black-clipped samples making an image underexposed
+	overexposedMin   = 0.20 // Share of white-clipped samples making an image overexposed
+)
+
+// measureQuality fills in the quality fields of sig from a grid of luma
+// samples of img. Sharpness is the variance of the 4-neighbour Laplacian.
+func measureQuality(img image.Image, sig *Signature) {
+	b := img.Bounds()
+	stepX := max(1, b.Dx()/qualityGrid)
+	stepY := max(1, b.Dy()/qualityGrid)
+	w, h := (b.Dx()+stepX-1)/stepX, (b.Dy()+stepY-1)/stepY
+	luma := make([]float64, 0, w*h)
+	dark, bright := 0, 0
+	for y := b.Min.Y; y < b.Max.Y; y += stepY {
+		for x := b.Min.X; x < b.Max.X; x += stepX {
+			l := lumaAt(img, x, y)
+			switch {
+			case l <= clipDarkMax:
+				dark++
+			case l >= clipBrightMin:
+				bright++
+			}
+			luma = append(luma, l)
+		}
+	}
+	sig.Dark = float64(dark) / float64(len(luma))
+	sig.Bright = float64(bright) / float64(len(luma))
+
+	if w < 3 || h < 3 {
+		return // Too small to judge; leave Sharpness at 0
+	}
+	var sum, sumSq float64
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			i := y*w + x
+			lap := luma[i-w] + luma[i+w] + luma[i-1] + luma[i+1] - 4*luma[i]
+			sum += lap
+			sumSq += lap * lap
+		}
+	}
+	n := float64((w - 2) * (h - 2))
+	mean := sum / n
+	sig.Sharpness = sumSq/n - mean*mean
+	sig.QualityMeasured = true
+}
+
+// lumaAt returns the luma of img at (x, y) from 0 to 255, reading the Y plane
+// of YCbCr images, as decoded JPEGs are, directly.
+func lumaAt(img image.Image, x, y int) float64 {
+	if ycc, ok := img.(*image.YCbCr); ok {
+		return float64(ycc.Y[ycc.YOffset(x, y)])
+	}
+	r, g, b, _ := img.At(x, y).RGBA()
+	return (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 0x101
+}
+
+// QualityTags returns the quality warning tags of the signature, if any.
+func (s Signature) QualityTags() []string {
+	var tags []string
+	if s.QualityMeasured && s.Sharpness < blurMaxSharpness {
+		tags = append(tags, BlurryTag)
+	}
+	if s.Dark >= underexposedMin {
+		tags = append(tags, UnderexposedTag)
+	}
+	if s.Bright >= overexposedMin {
+		tags = append(tags, OverexposedTag)
+	}
+	return tags
+}
+
+// QualitySummary describes the quality measurements, e.g. for a report line.
+func (s Signature) QualitySummary() string {
+	var warnings []string
+	for _, tag := range s.QualityTags() {
+		warnings = append(warnings, strings.TrimPrefix(tag, QualityTagPrefix))
+	}
+	summary := fmt.Sprintf("sharpness %.0f, %.0f%% black, %.0f%% white", s.Sharpness, s.Dark*100, s.Bright*100)
+	if len(warnings) == 0 {
+		return summary
+	}
+	return strings.Join(warnings, ", ") + " (" + summary + ")"
+}
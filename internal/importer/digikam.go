@@ -0,0 +1,104 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sqliteCommand is the SQLite shell used to read digiKam databases. No SQLite
+// driver is linked into FySlide, so the catalog is queried read-only through it.
+var sqliteCommand = "sqlite3"
+
+// Field and row separators used in the sqlite3 output; neither can appear in
+// file names or tag names.
+const (
+	sqliteFieldSep = "\x1f"
+	sqliteRowSep   = "\x1e"
+)
+
+// digiKamQuery lists every user tag of every visible image. Tags below digiKam's
+// internal root (colour labels, pick labels, ...) are excluded.
+const digiKamQuery = `
+WITH RECURSIVE internal(id) AS (
+	SELECT id FROM Tags WHERE name = '_Digikam_Internal_Tags_'
+	UNION SELECT t.id FROM Tags t JOIN internal ON t.pid = internal.id
+)
+SELECT r.identifier, r.specificPath, a.relativePath, i.name, COALESCE(i.fileSize, -1), t.name
+FROM ImageTags it
+JOIN Images i ON i.id = it.imageid
+JOIN Albums a ON a.id = i.album
+JOIN AlbumRoots r ON r.id = a.albumRoot
+JOIN Tags t ON t.id = it.tagid
+WHERE i.status = 1 AND t.id NOT IN (SELECT id FROM internal)
+ORDER BY r.id, a.relativePath, i.name;`
+
+// ReadDigiKam reads the tags of all images from a digiKam database
+// (digikam4.db). It requires the sqlite3 command-line shell.
+func ReadDigiKam(dbPath string) ([]Record, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("cannot read digiKam database: %w", err)
+	}
+	if _, err := exec.LookPath(sqliteCommand); err != nil {
+		return nil, fmt.Errorf("reading digiKam databases requires the %s command: %w", sqliteCommand, err)
+	}
+	cmd := exec.Command(sqliteCommand, "-readonly", "-batch", "-noheader",
+		"-separator", sqliteFieldSep, "-newline", sqliteRowSep, dbPath, digiKamQuery)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("querying %s: %w: %s", dbPath, err, strings.TrimSpace(stderr.String()))
+	}
+	return parseDigiKamRows(string(out))
+}
+
+// parseDigiKamRows groups the query output into one record per image.
+func parseDigiKamRows(out string) ([]Record, error) {
+	var records []Record
+	index := make(map[string]int)
+	for _, row := range strings.Split(out, sqliteRowSep) {
+		row = strings.TrimPrefix(row, "\n")
+		if row == "" {
+			continue
+		}
+		fields := strings.Split(row, sqliteFieldSep)
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("unexpected digiKam row %q", row)
+		}
+		size, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			size = -1
+		}
+		path := filepath.Join(albumRootPath(fields[0], fields[1]), filepath.FromSlash(fields[2]), fields[3])
+		i, ok := index[path]
+		if !ok {
+			i = len(records)
+			index[path] = i
+			records = append(records, Record{Path: path, Size: size})
+		}
+		records[i].Tags = normalizeTags(append(records[i].Tags, fields[5]))
+	}
+	return records, nil
+}
+
+// albumRootPath resolves where an album root lives. digiKam records either an
+// explicit path ("volumeid:?path=%2Fhome%2Fme%2FPictures") or a volume UUID plus a
+// path relative to that volume's mount point, which can't be resolved here; the
+// relative path is then used as-is and name-and-size matching covers the rest.
+func albumRootPath(identifier, specificPath string) string {
+	const pathPrefix = "volumeid:?path="
+	if strings.HasPrefix(identifier, pathPrefix) {
+		path := strings.TrimPrefix(identifier, pathPrefix)
+		if unescaped, err := url.QueryUnescape(path); err == nil { // digiKam percent-encodes it
+			path = unescaped
+		}
+		return filepath.FromSlash(path)
+	}
+	return filepath.FromSlash(specificPath)
+}
@@ -0,0 +1,117 @@
+// Package importer exchanges tags with other photo managers: it reads their
+// catalogs and sidecars, plans how to merge them into the FySlide tag database,
+// and writes sidecars they can read.
+package importer
+
+import (
+	"fmt"
+	"fyslide/internal/scan"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Record is the set of tags another application stores for one image.
+type Record struct {
+	Path string   // Path as recorded by the source application
+	Size int64    // File size recorded by the source, -1 if unknown
+	Tags []string // Normalized (lower-cased, de-duplicated) tags
+}
+
+// ConflictPolicy decides what happens when an imported image already has tags.
+type ConflictPolicy string
+
+// Conflict policies.
+const (
+	PolicyMerge   ConflictPolicy = "merge"   // Add imported tags to the existing ones
+	PolicySkip    ConflictPolicy = "skip"    // Leave images that already have tags untouched
+	PolicyReplace ConflictPolicy = "replace" // Replace existing tags with the imported ones
+)
+
+// ParsePolicy validates a policy name given on the command line.
+func ParsePolicy(name string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(strings.ToLower(name)); p {
+	case PolicyMerge, PolicySkip, PolicyReplace:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (want merge, skip or replace)", name)
+}
+
+// Plan lists the tag changes needed to import incoming onto an image that
+// currently has existing tags.
+func Plan(existing, incoming []string, policy ConflictPolicy) (add, remove []string) {
+	if len(existing) > 0 && policy == PolicySkip {
+		return nil, nil
+	}
+	for _, tag := range incoming {
+		if !slices.Contains(existing, tag) {
+			add = append(add, tag)
+		}
+	}
+	if policy == PolicyReplace {
+		for _, tag := range existing {
+			if !slices.Contains(incoming, tag) {
+				remove = append(remove, tag)
+			}
+		}
+	}
+	return add, remove
+}
+
+// normalizeTags lower-cases and trims tags, dropping empties and duplicates.
+func normalizeTags(tags []string) []string {
+	var out []string
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+type nameSize struct {
+	name string
+	size int64
+}
+
+// Matcher resolves imported records to image files on disk: by the recorded
+// path when it still exists, otherwise by file name and size among known images.
+type Matcher struct {
+	byNameSize map[nameSize][]string
+}
+
+// NewMatcher indexes images for name-and-size matching. images may be empty,
+// in which case only records whose path still exists are matched.
+func NewMatcher(images scan.FileItems) *Matcher {
+	m := &Matcher{byNameSize: make(map[nameSize][]string)}
+	for _, item := range images {
+		if item.Info == nil {
+			continue
+		}
+		key := nameSize{strings.ToLower(filepath.Base(item.Path)), item.Info.Size()}
+		m.byNameSize[key] = append(m.byNameSize[key], item.Path)
+	}
+	return m
+}
+
+// Match returns the absolute path of the image rec refers to. Name-and-size
+// matches that are ambiguous are rejected rather than guessed.
+func (m *Matcher) Match(rec Record) (string, error) {
+	if info, err := os.Stat(rec.Path); err == nil && !info.IsDir() {
+		return filepath.Abs(rec.Path)
+	}
+	if rec.Size < 0 {
+		return "", fmt.Errorf("%s not found and no file size recorded to match by", rec.Path)
+	}
+	candidates := m.byNameSize[nameSize{strings.ToLower(filepath.Base(rec.Path)), rec.Size}]
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("%s not found", rec.Path)
+	case 1:
+		return candidates[0], nil
+	default:
+		return "", fmt.Errorf("%s matches %d files by name and size", rec.Path, len(candidates))
+	}
+}
@@ -0,0 +1,200 @@
+package importer
+
+import (
+	"fmt"
+	"fyslide/internal/scan"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const lightroomXMP = `<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about=""
+    xmlns:dc="http://purl.org/dc/elements/1.1/"
+    xmlns:lr="http://ns.adobe.com/lightroom/1.0/">
+   <dc:title><rdf:Alt><rdf:li xml:lang="x-default">Not a keyword</rdf:li></rdf:Alt></dc:title>
+   <dc:subject><rdf:Bag><rdf:li>Beach</rdf:li><rdf:li>Summer </rdf:li><rdf:li>beach</rdf:li></rdf:Bag></dc:subject>
+   <lr:hierarchicalSubject><rdf:Bag><rdf:li>Places|France|Nice</rdf:li></rdf:Bag></lr:hierarchicalSubject>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>`
+
+const digiKamXMP = `<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:digiKam="http://www.digikam.org/ns/1.0/">
+   <digiKam:TagsList><rdf:Seq><rdf:li>People/Alice</rdf:li></rdf:Seq></digiKam:TagsList>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>`
+
+func TestParseXMP(t *testing.T) {
+	tags, err := ParseXMP(strings.NewReader(lightroomXMP))
+	if err != nil {
+		t.Fatalf("ParseXMP failed: %v", err)
+	}
+	if want := []string{"beach", "summer"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("flat keywords = %v, want %v", tags, want)
+	}
+
+	tags, err = ParseXMP(strings.NewReader(digiKamXMP))
+	if err != nil {
+		t.Fatalf("ParseXMP failed: %v", err)
+	}
+	if want := []string{"alice"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("hierarchical keywords = %v, want %v", tags, want)
+	}
+}
+
+func TestReadXMPSidecars(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	write("lr.jpg", "jpeg")
+	write("lr.xmp", lightroomXMP)
+	write("dk.png", "png")
+	write("dk.png.xmp", digiKamXMP)
+	write("orphan.xmp", lightroomXMP)
+
+	records, err := ReadXMPSidecars(dir, func(string) {})
+	if err != nil {
+		t.Fatalf("ReadXMPSidecars failed: %v", err)
+	}
+	got := make(map[string][]string)
+	for _, r := range records {
+		got[filepath.Base(r.Path)] = r.Tags
+	}
+	want := map[string][]string{"lr.jpg": {"beach", "summer"}, "dk.png": {"alice"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %v, want %v", got, want)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	existing := []string{"old", "shared"}
+	incoming := []string{"shared", "new"}
+	tests := []struct {
+		policy      ConflictPolicy
+		add, remove []string
+	}{
+		{PolicyMerge, []string{"new"}, nil},
+		{PolicySkip, nil, nil},
+		{PolicyReplace, []string{"new"}, []string{"old"}},
+	}
+	for _, tt := range tests {
+		add, remove := Plan(existing, incoming, tt.policy)
+		if !reflect.DeepEqual(add, tt.add) || !reflect.DeepEqual(remove, tt.remove) {
+			t.Errorf("Plan(%s) = add %v remove %v, want add %v remove %v", tt.policy, add, remove, tt.add, tt.remove)
+		}
+	}
+	if add, _ := Plan(nil, incoming, PolicySkip); !reflect.DeepEqual(add, incoming) {
+		t.Errorf("Plan(skip) on untagged image = %v, want %v", add, incoming)
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	dir := t.TempDir()
+	moved := filepath.Join(dir, "IMG_1.jpg")
+	if err := os.WriteFile(moved, []byte("12345"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(moved)
+	m := NewMatcher(scan.FileItems{scan.NewFileItem(moved, info)})
+
+	if got, err := m.Match(Record{Path: moved, Size: -1}); err != nil || got != moved {
+		t.Errorf("Match by existing path = %q, %v", got, err)
+	}
+	if got, err := m.Match(Record{Path: "/gone/img_1.JPG", Size: 5}); err != nil || got != moved {
+		t.Errorf("Match by name and size = %q, %v", got, err)
+	}
+	if _, err := m.Match(Record{Path: "/gone/IMG_1.jpg", Size: 6}); err == nil {
+		t.Error("Match with a different size should fail")
+	}
+}
+
+func TestReadDigiKam(t *testing.T) {
+	if _, err := exec.LookPath(sqliteCommand); err != nil {
+		t.Skipf("%s not available: %v", sqliteCommand, err)
+	}
+	dbPath := filepath.Join(t.TempDir(), "digikam4.db")
+	schema := fmt.Sprintf(`
+CREATE TABLE AlbumRoots (id INTEGER PRIMARY KEY, identifier TEXT, specificPath TEXT);
+CREATE TABLE Albums (id INTEGER PRIMARY KEY, albumRoot INTEGER, relativePath TEXT);
+CREATE TABLE Images (id INTEGER PRIMARY KEY, album INTEGER, name TEXT, status INTEGER, fileSize INTEGER);
+CREATE TABLE Tags (id INTEGER PRIMARY KEY, pid INTEGER, name TEXT);
+CREATE TABLE ImageTags (imageid INTEGER, tagid INTEGER);
+INSERT INTO AlbumRoots VALUES (1, 'volumeid:?path=%s', '/');
+INSERT INTO Albums VALUES (1, 1, '/2024/Trip');
+INSERT INTO Images VALUES (1, 1, 'a.jpg', 1, 100), (2, 1, 'deleted.jpg', 3, 50);
+INSERT INTO Tags VALUES (1, 0, 'Places'), (2, 1, 'Paris'), (3, 0, '_Digikam_Internal_Tags_'), (4, 3, 'Color Label Red');
+INSERT INTO ImageTags VALUES (1, 2), (1, 1), (1, 4), (2, 2);
+`, "%2Fphotos")
+	cmd := exec.Command(sqliteCommand, dbPath)
+	cmd.Stdin = strings.NewReader(schema)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("creating test database: %v: %s", err, out)
+	}
+
+	records, err := ReadDigiKam(dbPath)
+	if err != nil {
+		t.Fatalf("ReadDigiKam failed: %v", err)
+	}
+	want := []Record{{Path: filepath.FromSlash("/photos/2024/Trip/a.jpg"), Size: 100, Tags: []string{"paris", "places"}}}
+	if len(records) == 1 {
+		sort.Strings(records[0].Tags) // Row order within an image is unspecified
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %+v, want %+v", records, want)
+	}
+}
+
+func TestTagSpacesRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	img := filepath.Join(dir, "beach[Summer].jpg")
+	if err := os.WriteFile(img, []byte("jpeg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := FilenameTags(img); !reflect.DeepEqual(got, []string{"summer"}) {
+		t.Errorf("FilenameTags = %v, want [summer]", got)
+	}
+
+	// An existing sidecar keeps its description and the color of a kept tag.
+	sidecarPath := TagSpacesSidecarPath(img)
+	if err := os.MkdirAll(filepath.Dir(sidecarPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	existing := `{"description":"Nice day","tags":[{"title":"Sea","color":"#00f"},{"title":"dropped"}]}`
+	if err := os.WriteFile(sidecarPath, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTagSpacesSidecar(img, []string{"sea", "holiday"}); err != nil {
+		t.Fatalf("WriteTagSpacesSidecar failed: %v", err)
+	}
+	data, err := os.ReadFile(sidecarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"description": "Nice day"`, `"color": "#00f"`, `"title": "holiday"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("sidecar missing %s:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), "dropped") {
+		t.Errorf("sidecar still lists a removed tag:\n%s", data)
+	}
+
+	records := ReadTagSpaces(dir, func(string) {})
+	if len(records) != 1 {
+		t.Fatalf("ReadTagSpaces returned %d records, want 1", len(records))
+	}
+	if want := []string{"sea", "holiday", "summer"}; !reflect.DeepEqual(records[0].Tags, want) {
+		t.Errorf("imported tags = %v, want %v", records[0].Tags, want)
+	}
+}
@@ -0,0 +1,143 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fyslide/internal/scan"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TagSpaces keeps the tags of "dir/photo.jpg" in "dir/.ts/photo.jpg.json".
+const tagSpacesDir = ".ts"
+
+// tagSpacesTag is one entry of a TagSpaces sidecar's "tags" array.
+type tagSpacesTag struct {
+	Title string `json:"title"`
+	Type  string `json:"type,omitempty"`
+}
+
+// TagSpacesSidecarPath returns the TagSpaces sidecar path for imagePath.
+func TagSpacesSidecarPath(imagePath string) string {
+	return filepath.Join(filepath.Dir(imagePath), tagSpacesDir, filepath.Base(imagePath)+".json")
+}
+
+// FilenameTags returns the tags TagSpaces embeds in a file name, as in
+// "beach[summer family].jpg", or nil if there are none.
+func FilenameTags(name string) []string {
+	stem := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	open := strings.LastIndex(stem, "[")
+	if open < 0 || !strings.HasSuffix(stem, "]") {
+		return nil
+	}
+	return normalizeTags(strings.Fields(stem[open+1 : len(stem)-1]))
+}
+
+// InTagSpacesDir reports whether path lies directly in a TagSpaces metadata
+// folder, where TagSpaces also keeps its thumbnails; those aren't library images.
+func InTagSpacesDir(path string) bool {
+	return filepath.Base(filepath.Dir(path)) == tagSpacesDir
+}
+
+// readTagSpacesSidecar returns the tags in the sidecar for imagePath, or nil if
+// there is no sidecar.
+func readTagSpacesSidecar(imagePath string) ([]string, error) {
+	data, err := os.ReadFile(TagSpacesSidecarPath(imagePath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sidecar struct {
+		Tags []tagSpacesTag `json:"tags"`
+	}
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return nil, fmt.Errorf("invalid TagSpaces sidecar %s: %w", TagSpacesSidecarPath(imagePath), err)
+	}
+	titles := make([]string, len(sidecar.Tags))
+	for i, t := range sidecar.Tags {
+		titles[i] = t.Title
+	}
+	return normalizeTags(titles), nil
+}
+
+// ReadTagSpaces scans dir for images and returns the tags TagSpaces records for
+// them, from sidecars and from tags embedded in file names.
+func ReadTagSpaces(dir string, logger scan.LoggerFunc) []Record {
+	var records []Record
+	for item := range scan.Run(dir, logger) {
+		if InTagSpacesDir(item.Path) {
+			continue
+		}
+		tags, err := readTagSpacesSidecar(item.Path)
+		if err != nil {
+			logger(err.Error())
+		}
+		tags = normalizeTags(append(tags, FilenameTags(item.Path)...))
+		if len(tags) == 0 {
+			continue
+		}
+		size := int64(-1)
+		if item.Info != nil {
+			size = item.Info.Size()
+		}
+		records = append(records, Record{Path: item.Path, Size: size, Tags: tags})
+	}
+	return records
+}
+
+// WriteTagSpacesSidecar writes tags to the TagSpaces sidecar of imagePath. An
+// existing sidecar keeps its other fields (description, colors of tags that are
+// still present, ...); only its tag list and update time change.
+func WriteTagSpacesSidecar(imagePath string, tags []string) error {
+	sidecarPath := TagSpacesSidecarPath(imagePath)
+	sidecar := map[string]any{}
+	if data, err := os.ReadFile(sidecarPath); err == nil {
+		if err := json.Unmarshal(data, &sidecar); err != nil {
+			return fmt.Errorf("invalid TagSpaces sidecar %s: %w", sidecarPath, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	existing := make(map[string]any)
+	if list, ok := sidecar["tags"].([]any); ok {
+		for _, entry := range list {
+			if m, ok := entry.(map[string]any); ok {
+				if title, ok := m["title"].(string); ok {
+					existing[strings.ToLower(title)] = m
+				}
+			}
+		}
+	}
+	list := make([]any, 0, len(tags))
+	for _, tag := range tags {
+		if entry, ok := existing[tag]; ok {
+			list = append(list, entry)
+		} else {
+			list = append(list, tagSpacesTag{Title: tag, Type: "sidecar"})
+		}
+	}
+	sidecar["tags"] = list
+	sidecar["lastUpdated"] = time.Now().UTC().Format(time.RFC3339)
+	if _, ok := sidecar["appName"]; !ok {
+		sidecar["appName"] = "FySlide"
+	}
+
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding sidecar for %s: %w", imagePath, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(sidecarPath), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(sidecarPath), err)
+	}
+	if err := os.WriteFile(sidecarPath, data, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", sidecarPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package importer
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"fyslide/internal/scan"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// XMP elements whose rdf:li items are keywords. dc:subject holds flat keywords
+// (Lightroom, digiKam and most other tools); the others hold hierarchical
+// keywords, of which only the leaf is used.
+const (
+	xmpSubject             = "subject"             // dc:subject
+	xmpHierarchicalSubject = "hierarchicalSubject" // lr:hierarchicalSubject, "|" separated
+	xmpDigiKamTagsList     = "TagsList"            // digiKam:TagsList, "/" separated
+)
+
+// ParseXMP extracts the keywords from an XMP packet. Flat dc:subject keywords
+// are preferred; hierarchical lists are only used when there are none.
+func ParseXMP(r io.Reader) ([]string, error) {
+	dec := xml.NewDecoder(r)
+	var stack []string
+	var flat, hierarchical []string
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XMP: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) == 0 || stack[len(stack)-1] != "li" {
+				continue
+			}
+			text := strings.TrimSpace(string(t))
+			if text == "" {
+				continue
+			}
+			switch keywordList(stack) {
+			case xmpSubject:
+				flat = append(flat, text)
+			case xmpHierarchicalSubject:
+				hierarchical = append(hierarchical, leaf(text, "|"))
+			case xmpDigiKamTagsList:
+				hierarchical = append(hierarchical, leaf(text, "/"))
+			}
+		}
+	}
+	if len(flat) > 0 {
+		return normalizeTags(flat), nil
+	}
+	return normalizeTags(hierarchical), nil
+}
+
+// keywordList returns the nearest enclosing keyword list element, or "".
+func keywordList(stack []string) string {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch stack[i] {
+		case xmpSubject, xmpHierarchicalSubject, xmpDigiKamTagsList:
+			return stack[i]
+		}
+	}
+	return ""
+}
+
+func leaf(path, sep string) string {
+	parts := strings.Split(path, sep)
+	return parts[len(parts)-1]
+}
+
+// sidecarImage finds the image an .xmp sidecar describes. digiKam names sidecars
+// "photo.jpg.xmp"; Lightroom names them "photo.xmp" next to "photo.<ext>".
+func sidecarImage(sidecar string) (string, bool) {
+	base := strings.TrimSuffix(sidecar, filepath.Ext(sidecar))
+	if scan.IsImage(base) {
+		if _, err := os.Stat(base); err == nil {
+			return base, true
+		}
+	}
+	matches, _ := filepath.Glob(escapeGlob(base) + ".*")
+	for _, m := range matches {
+		if scan.IsImage(m) {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+// escapeGlob quotes the characters filepath.Match treats specially.
+func escapeGlob(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`)
+	return r.Replace(s)
+}
+
+// ReadXMPSidecars walks dir for .xmp sidecars and returns the keywords of the
+// images they describe. Sidecars without keywords or without a supported image
+// next to them are reported to logger and skipped.
+func ReadXMPSidecars(dir string, logger scan.LoggerFunc) ([]Record, error) {
+	var records []Record
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			logger(fmt.Sprintf("Error accessing %s: %v", path, err))
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".xmp") {
+			return nil
+		}
+		imagePath, ok := sidecarImage(path)
+		if !ok {
+			logger(fmt.Sprintf("No supported image found for sidecar %s", path))
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			logger(fmt.Sprintf("Error opening %s: %v", path, err))
+			return nil
+		}
+		tags, err := ParseXMP(f)
+		f.Close()
+		if err != nil {
+			logger(fmt.Sprintf("Error reading %s: %v", path, err))
+			return nil
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		size := int64(-1)
+		if info, err := os.Stat(imagePath); err == nil {
+			size = info.Size()
+		}
+		records = append(records, Record{Path: imagePath, Size: size, Tags: tags})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking %s: %w", dir, err)
+	}
+	return records, nil
+}
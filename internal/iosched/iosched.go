@@ -0,0 +1,188 @@
+// Package iosched orders the disk reads of the viewer by priority, so that
+// background work such as thumbnail generation never delays the image the
+// user is waiting for, and caps the throughput of background reads so they
+// don't saturate slow disks.
+package iosched
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// Class is the priority of a read, highest first.
+type Class int
+
+const (
+	Foreground  Class = iota // Decoding the image being shown
+	Prefetch                 // Reading ahead the images likely shown next
+	Thumbnails               // Generating thumbnails
+	Maintenance              // Bulk work such as computing signatures
+	numClasses
+)
+
+// String returns the name of the class.
+func (c Class) String() string {
+	switch c {
+	case Foreground:
+		return "foreground"
+	case Prefetch:
+		return "prefetch"
+	case Thumbnails:
+		return "thumbnails"
+	case Maintenance:
+		return "maintenance"
+	}
+	return "unknown"
+}
+
+// minSleep is the smallest delay the throughput cap sleeps for; smaller debts
+// are carried over to the next read, so small reads don't each start a timer.
+const minSleep = 10 * time.Millisecond
+
+// Scheduler coordinates the reads of all files opened through it. While a file
+// of some class is open, reads of lower classes wait, so background work
+// pauses between reads as soon as the foreground needs the disk. Background
+// reads share a throughput cap; foreground reads are never delayed.
+//
+// A nil Scheduler opens files without any scheduling.
+type Scheduler struct {
+	mu    sync.Mutex
+	open  [numClasses]int // Files currently open, per class
+	wake  chan struct{}   // Closed and replaced whenever a file is closed
+	limit int64           // Bytes per second of background reads, 0 for no cap
+	next  time.Time       // When the cap allows the next background read
+}
+
+// New creates a Scheduler capping background reads at bytesPerSecond, or not
+// at all if it is 0.
+func New(bytesPerSecond int64) *Scheduler {
+	return &Scheduler{wake: make(chan struct{}), limit: max(bytesPerSecond, 0)}
+}
+
+// SetLimit changes the cap of background reads; 0 removes it.
+func (s *Scheduler) SetLimit(bytesPerSecond int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = max(bytesPerSecond, 0)
+}
+
+// Limit returns the cap of background reads in bytes per second, 0 for none.
+func (s *Scheduler) Limit() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.limit
+}
+
+// Open opens path for reading as class. The file counts as busy until it is
+// closed, which must not be forgotten: lower classes wait for it. ctx bounds
+// the waits of the file's reads.
+func (s *Scheduler) Open(ctx context.Context, class Class, path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{File: file, s: s, class: class, ctx: ctx}
+	if s != nil {
+		s.mu.Lock()
+		s.open[class]++
+		s.mu.Unlock()
+	}
+	return f, nil
+}
+
+// release marks a file of class as closed and wakes the waiting reads.
+func (s *Scheduler) release(class Class) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.open[class]--
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+// waitTurn blocks while a file of a class higher than class is open.
+func (s *Scheduler) waitTurn(ctx context.Context, class Class) error {
+	for {
+		s.mu.Lock()
+		busy := false
+		for c := Foreground; c < class; c++ {
+			busy = busy || s.open[c] > 0
+		}
+		wake := s.wake
+		s.mu.Unlock()
+		if !busy {
+			return nil
+		}
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// throttle charges n bytes read in the background against the cap, sleeping
+// once the reads are ahead of it.
+func (s *Scheduler) throttle(ctx context.Context, n int) error {
+	s.mu.Lock()
+	if s.limit <= 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	if s.next.Before(now) {
+		s.next = now // Idle time doesn't build up a burst allowance
+	}
+	s.next = s.next.Add(time.Duration(float64(n) / float64(s.limit) * float64(time.Second)))
+	delay := s.next.Sub(now)
+	s.mu.Unlock()
+	if delay < minSleep {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// File is a file opened through a Scheduler. Its reads wait for their turn
+// and, unless in the foreground, count against the throughput cap.
+type File struct {
+	*os.File
+	s      *Scheduler
+	class  Class
+	ctx    context.Context
+	closed sync.Once
+}
+
+// Read reads from the file once no higher class has a file open.
+func (f *File) Read(p []byte) (int, error) {
+	if f.s == nil || f.class == Foreground {
+		return f.File.Read(p)
+	}
+	if err := f.s.waitTurn(f.ctx, f.class); err != nil {
+		return 0, err
+	}
+	n, err := f.File.Read(p)
+	if n > 0 {
+		if werr := f.s.throttle(f.ctx, n); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return n, err
+}
+
+// Close closes the file and lets the reads of lower classes continue.
+func (f *File) Close() error {
+	f.closed.Do(func() {
+		if f.s != nil {
+			f.s.release(f.class)
+		}
+	})
+	return f.File.Close()
+}
@@ -0,0 +1,125 @@
+package iosched
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, size int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBackgroundWaitsForForeground(t *testing.T) {
+	s := New(0)
+	path := writeFile(t, 1024)
+	fg, err := s.Open(context.Background(), Foreground, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb, err := s.Open(context.Background(), Thumbnails, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer thumb.Close()
+
+	done := make(chan error)
+	go func() {
+		_, err := io.ReadAll(thumb)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("Thumbnail read finished while the foreground file was open: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if _, err := io.ReadAll(fg); err != nil {
+		t.Fatalf("Foreground read: %v", err)
+	}
+	fg.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Thumbnail read: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Thumbnail read still waiting after the foreground file was closed")
+	}
+}
+
+func TestWaitIsCancelled(t *testing.T) {
+	s := New(0)
+	path := writeFile(t, 16)
+	prefetch, err := s.Open(context.Background(), Prefetch, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer prefetch.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	maintenance, err := s.Open(ctx, Maintenance, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer maintenance.Close()
+	if _, err := maintenance.Read(make([]byte, 16)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Read behind a prefetch: got %v, want the context's error", err)
+	}
+}
+
+func TestThroughputCap(t *testing.T) {
+	const limit = 1 << 20 // 1 MB/s
+	s := New(limit)
+	path := writeFile(t, limit/4)
+
+	start := time.Now()
+	f, err := s.Open(context.Background(), Thumbnails, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Read a quarter of the cap in %v, want about 250ms", elapsed)
+	}
+
+	start = time.Now()
+	f, err = s.Open(context.Background(), Foreground, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Foreground read was throttled: took %v", elapsed)
+	}
+}
+
+func TestNilScheduler(t *testing.T) {
+	var s *Scheduler
+	f, err := s.Open(context.Background(), Maintenance, writeFile(t, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil || len(data) != 8 {
+		t.Fatalf("ReadAll = %d bytes, %v", len(data), err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,505 @@
+package metadata
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrNoDate is returned when shifting the dates of an image that has none.
+var ErrNoDate = errors.New("image has no EXIF date")
+
+// jpegEXIFHeader prefixes the EXIF data in a JPEG APP1 segment.
+var jpegEXIFHeader = []byte("Exif\x00\x00")
+
+// EXIF tags edited here.
+const (
+	tagImageDescription  = 0x010E
+	tagDateTime          = 0x0132 // When the file was last changed
+	tagExifIFD           = 0x8769
+	tagDateTimeOriginal  = 0x9003 // When the photo was taken
+	tagDateTimeDigitized = 0x9004 // When it was scanned or stored
+)
+
+// TIFF field types used here.
+const (
+	tiffASCII = 2
+	tiffLong  = 4
+)
+
+// tiffTypeSizes is the size in bytes of one value of each TIFF field type.
+var tiffTypeSizes = [...]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+// EXIFDateLayout is how EXIF stores dates. They have no time zone; the camera's
+// clock is taken to be local time.
+const EXIFDateLayout = "2006:01:02 15:04:05"
+
+// EXIF holds the editable EXIF fields of an image.
+type EXIF struct {
+	Taken time.Time // DateTimeOriginal, or DateTime if it is missing; zero if both are
+	Title string    // ImageDescription
+}
+
+// EXIFUpdate lists the fields WriteEXIF changes; nil fields are left alone.
+type EXIFUpdate struct {
+	Taken *time.Time // Stored as DateTimeOriginal, in t's own time zone
+	Title *string    // Stored as ImageDescription; "" removes it
+}
+
+// EXIFSupported reports whether EXIF fields can be written for path. Only JPEG
+// files are supported.
+func EXIFSupported(path string) bool {
+	c, err := format(path)
+	_, isJPEG := c.(jpegContainer)
+	return err == nil && isJPEG
+}
+
+// ReadEXIF returns the editable EXIF fields of the image at path. Files
+// without EXIF data yield an empty EXIF and no error.
+func ReadEXIF(path string) (EXIF, error) {
+	var fields EXIF
+	if !EXIFSupported(path) {
+		return fields, fmt.Errorf("%s: %w", filepath.Base(path), ErrUnsupportedFormat)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fields, err
+	}
+	t, err := jpegTIFF(data)
+	if err != nil || t == nil {
+		return fields, err
+	}
+	ifd0, _, exifIFD, err := t.dirs()
+	if err != nil {
+		return fields, fmt.Errorf("%s: %w", path, err)
+	}
+	fields.Title = t.ascii(ifd0, tagImageDescription)
+	taken := t.ascii(exifIFD, tagDateTimeOriginal)
+	if taken == "" {
+		taken = t.ascii(ifd0, tagDateTime)
+	}
+	if taken != "" {
+		fields.Taken, _ = time.ParseInLocation(EXIFDateLayout, taken, time.Local) // Unreadable dates count as missing
+	}
+	return fields, nil
+}
+
+// WriteEXIF changes the EXIF fields of the image at path given in update,
+// creating EXIF data if the file has none. The file is replaced atomically
+// and keeps its permissions.
+func WriteEXIF(path string, update EXIFUpdate) error {
+	return editEXIF(path, func(t *tiff, ifd0, exifIFD []ifdEntry) ([]ifdEntry, []ifdEntry, error) {
+		if update.Taken != nil {
+			exifIFD = setEntry(exifIFD, t.asciiEntry(tagDateTimeOriginal, update.Taken.Format(EXIFDateLayout)))
+		}
+		if update.Title != nil {
+			if *update.Title == "" {
+				ifd0 = removeEntry(ifd0, tagImageDescription)
+			} else {
+				ifd0 = setEntry(ifd0, t.asciiEntry(tagImageDescription, *update.Title))
+			}
+		}
+		return ifd0, exifIFD, nil
+	})
+}
+
+// DateAdjustment corrects an EXIF date. Dates are passed as their wall clock
+// time in UTC, so that adjustments ignore daylight saving unless they ask for it.
+type DateAdjustment func(wall time.Time) time.Time
+
+// ShiftDates moves dates by d, to correct a camera clock that was set wrong.
+func ShiftDates(d time.Duration) DateAdjustment {
+	return func(wall time.Time) time.Time { return wall.Add(d) }
+}
+
+// ConvertZone changes dates recorded by a clock set to the time zone from to
+// the same instants in the time zone to, daylight saving included.
+func ConvertZone(from, to *time.Location) DateAdjustment {
+	return func(wall time.Time) time.Time {
+		return wallClock(wallClock(wall, from).In(to), time.UTC)
+	}
+}
+
+// Apply returns t corrected by adjust, in t's time zone.
+func (adjust DateAdjustment) Apply(t time.Time) time.Time {
+	return wallClock(adjust(wallClock(t, time.UTC)), t.Location())
+}
+
+// wallClock returns the time reading the same as t on a clock in loc.
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// AdjustEXIFDates corrects every date in the EXIF data of the image at path
+// with adjust. It fails with ErrNoDate if the image has no date to correct.
+func AdjustEXIFDates(path string, adjust DateAdjustment) error {
+	return editEXIF(path, func(t *tiff, ifd0, exifIFD []ifdEntry) ([]ifdEntry, []ifdEntry, error) {
+		adjusted := false
+		adjustEntry := func(entries []ifdEntry, tag uint16) []ifdEntry {
+			date, err := time.Parse(EXIFDateLayout, t.ascii(entries, tag))
+			if err != nil {
+				return entries
+			}
+			adjusted = true
+			return setEntry(entries, t.asciiEntry(tag, adjust(date).Format(EXIFDateLayout)))
+		}
+		ifd0 = adjustEntry(ifd0, tagDateTime)
+		exifIFD = adjustEntry(exifIFD, tagDateTimeOriginal)
+		exifIFD = adjustEntry(exifIFD, tagDateTimeDigitized)
+		if !adjusted {
+			return nil, nil, ErrNoDate
+		}
+		return ifd0, exifIFD, nil
+	})
+}
+
+// editEXIF rewrites the EXIF data of the JPEG at path with the directories
+// change returns for its IFD0 and Exif IFD.
+func editEXIF(path string, change func(t *tiff, ifd0, exifIFD []ifdEntry) ([]ifdEntry, []ifdEntry, error)) error {
+	if !EXIFSupported(path) {
+		return fmt.Errorf("%s: %w", filepath.Base(path), ErrUnsupportedFormat)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	t, err := jpegTIFF(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if t == nil {
+		t = newTIFF()
+	}
+	ifd0, next, exifIFD, err := t.dirs()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	cut := t.earlierEdit(ifd0, exifIFD)
+	if ifd0, exifIFD, err = change(t, ifd0, exifIFD); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	ifd0, exifIFD = t.dropFrom(cut, ifd0, exifIFD) // So repeated edits don't grow the data
+	t.rewrite(ifd0, next, exifIFD)
+	updated, err := jpegWithEXIF(data, t.data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return replaceFile(path, updated)
+}
+
+// jpegTIFF returns the EXIF data of a JPEG file, or nil if it has none.
+func jpegTIFF(data []byte) (*tiff, error) {
+	segments, err := jpegSegments(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range segments {
+		if s.marker == jpegAPP1 && bytes.HasPrefix(s.payload, jpegEXIFHeader) {
+			return parseTIFF(slices.Clone(s.payload[len(jpegEXIFHeader):]))
+		}
+	}
+	return nil, nil
+}
+
+// jpegWithEXIF replaces the EXIF APP1 segment of data, or inserts one right
+// after the start of the image or its JFIF segment, where readers expect it.
+func jpegWithEXIF(data, exif []byte) ([]byte, error) {
+	segments, err := jpegSegments(data)
+	if err != nil {
+		return nil, err
+	}
+	payload := append(slices.Clone(jpegEXIFHeader), exif...)
+	if len(payload) > maxJPEGSegment {
+		return nil, fmt.Errorf("EXIF data of %d bytes does not fit in a JPEG segment", len(payload))
+	}
+	start, end := 2, 2
+	if len(segments) > 0 && segments[0].marker == jpegAPP0 {
+		start, end = segments[0].end, segments[0].end
+	}
+	for _, s := range segments {
+		if s.marker == jpegAPP1 && bytes.HasPrefix(s.payload, jpegEXIFHeader) {
+			start, end = s.start, s.end
+			break
+		}
+	}
+	segment := jpegSegmentBytes(jpegAPP1, payload)
+	out := make([]byte, 0, len(data)-(end-start)+len(segment))
+	out = append(out, data[:start]...)
+	out = append(out, segment...)
+	return append(out, data[end:]...), nil
+}
+
+// tiff is EXIF data: a TIFF header followed by directories of tagged values.
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// ifdEntry is one tagged value of a TIFF directory.
+type ifdEntry struct {
+	tag, typ uint16
+	count    uint32
+	value    [4]byte // The value itself if it fits, else its offset
+}
+
+func parseTIFF(data []byte) (*tiff, error) {
+	if len(data) < 8 {
+		return nil, errors.New("truncated EXIF data")
+	}
+	t := &tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, errors.New("malformed EXIF data")
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return nil, errors.New("malformed EXIF data")
+	}
+	return t, nil
+}
+
+// newTIFF returns EXIF data without any directory.
+func newTIFF() *tiff {
+	return &tiff{data: []byte{'M', 'M', 0, 42, 0, 0, 0, 0}, order: binary.BigEndian}
+}
+
+// dirs reads IFD0, the offset of the directory following it (that of the
+// thumbnail) and the Exif IFD.
+func (t *tiff) dirs() (ifd0 []ifdEntry, next uint32, exifIFD []ifdEntry, err error) {
+	if ifd0, next, err = t.ifd(t.order.Uint32(t.data[4:])); err != nil {
+		return nil, 0, nil, err
+	}
+	if e, ok := findEntry(ifd0, tagExifIFD); ok {
+		if exifIFD, _, err = t.ifd(t.order.Uint32(e.value[:])); err != nil {
+			return nil, 0, nil, err
+		}
+	}
+	return ifd0, next, exifIFD, nil
+}
+
+// ifd reads the directory at offset; offset 0 is an empty one.
+func (t *tiff) ifd(offset uint32) ([]ifdEntry, uint32, error) {
+	if offset == 0 {
+		return nil, 0, nil
+	}
+	off := int(offset)
+	if off+2 > len(t.data) {
+		return nil, 0, errors.New("truncated EXIF directory")
+	}
+	n := int(t.order.Uint16(t.data[off:]))
+	end := off + 2 + 12*n
+	if end+4 > len(t.data) {
+		return nil, 0, errors.New("truncated EXIF directory")
+	}
+	entries := make([]ifdEntry, n)
+	for i := range entries {
+		raw := t.data[off+2+12*i:]
+		entries[i] = ifdEntry{tag: t.order.Uint16(raw), typ: t.order.Uint16(raw[2:]), count: t.order.Uint32(raw[4:])}
+		copy(entries[i].value[:], raw[8:12])
+	}
+	return entries, t.order.Uint32(t.data[end:]), nil
+}
+
+// rewrite links copies of IFD0 and the Exif IFD with the given entries in
+// place of the originals. The originals stay where they are, unused, so
+// offsets elsewhere in the data, such as inside maker notes, remain valid;
+// editEXIF drops the copies of an earlier edit first, see earlierEdit.
+func (t *tiff) rewrite(ifd0 []ifdEntry, next uint32, exifIFD []ifdEntry) {
+	if len(exifIFD) > 0 {
+		ifd0 = setEntry(ifd0, t.longEntry(tagExifIFD, t.appendIFD(exifIFD, 0)))
+	}
+	offset := t.appendIFD(ifd0, next) // May reallocate the data
+	t.order.PutUint32(t.data[4:], offset)
+}
+
+// editedTags are the tags whose values edits add to the data.
+var editedTags = []uint16{tagImageDescription, tagDateTime, tagDateTimeOriginal, tagDateTimeDigitized}
+
+// earlierEdit returns where the copies an earlier rewrite added at the end
+// of the data start: IFD0, the Exif IFD and the values of editedTags, as long
+// as they follow one another up to the end. It returns the length of the data
+// if IFD0 isn't at its end, as data not made by an edit is left alone.
+func (t *tiff) earlierEdit(ifd0, exifIFD []ifdEntry) int {
+	type span struct{ start, end int }
+	dirSpan := func(offset uint32, n int) span { return span{int(offset), int(offset) + 2 + 12*n + 4} }
+	ifd0Span := dirSpan(t.order.Uint32(t.data[4:]), len(ifd0))
+	if ifd0Span.end != len(t.data) {
+		return len(t.data) // Not rewritten before, or something else was added after it
+	}
+	spans := []span{ifd0Span}
+	if e, ok := findEntry(ifd0, tagExifIFD); ok && len(exifIFD) > 0 {
+		spans = append(spans, dirSpan(t.order.Uint32(e.value[:]), len(exifIFD)))
+	}
+	for _, entries := range [][]ifdEntry{ifd0, exifIFD} {
+		for _, e := range entries {
+			if offset, size, ok := t.outOfLine(e); ok && slices.Contains(editedTags, e.tag) {
+				spans = append(spans, span{offset, offset + size})
+			}
+		}
+	}
+
+	// Walk back from the end over spans that adjoin, allowing for the byte
+	// that keeps each at a word boundary.
+	cut := len(t.data)
+	for found := true; found; {
+		found = false
+		for _, s := range spans {
+			if s.start >= 8 && s.start < cut && (s.end == cut || s.end+1 == cut) {
+				cut, found = s.start, true
+			}
+		}
+	}
+	return cut
+}
+
+// dropFrom removes the data from offset cut on, adding back the values the
+// entries still point to there, and returns the entries pointing to them
+// anew. Values no entry needs any longer, such as those an edit replaced,
+// are gone.
+func (t *tiff) dropFrom(cut int, ifd0, exifIFD []ifdEntry) ([]ifdEntry, []ifdEntry) {
+	if cut >= len(t.data) {
+		return ifd0, exifIFD
+	}
+	type value struct {
+		entry *ifdEntry
+		b     []byte
+	}
+	var kept []value
+	ifd0, exifIFD = slices.Clone(ifd0), slices.Clone(exifIFD)
+	for _, entries := range [][]ifdEntry{ifd0, exifIFD} {
+		for i, e := range entries {
+			if offset, size, ok := t.outOfLine(e); ok && offset >= cut {
+				kept = append(kept, value{&entries[i], slices.Clone(t.data[offset : offset+size])})
+			}
+		}
+	}
+	t.data = t.data[:cut]
+	for _, v := range kept {
+		t.order.PutUint32(v.entry.value[:], t.append(v.b))
+	}
+	return ifd0, exifIFD
+}
+
+// outOfLine returns where the value of e lies in the data when it doesn't
+// fit in the entry itself; ok is false if it does, or if it lies outside the
+// data.
+func (t *tiff) outOfLine(e ifdEntry) (offset, size int, ok bool) {
+	if int(e.typ) < len(tiffTypeSizes) {
+		size = tiffTypeSizes[e.typ]
+	}
+	n := uint64(size) * uint64(e.count)
+	off := uint64(t.order.Uint32(e.value[:]))
+	if n <= 4 || off+n > uint64(len(t.data)) {
+		return 0, 0, false
+	}
+	return int(off), int(n), true
+}
+
+// ascii returns the text of the ASCII entry tag, or "" if there is none.
+func (t *tiff) ascii(entries []ifdEntry, tag uint16) string {
+	e, ok := findEntry(entries, tag)
+	if !ok || e.typ != tiffASCII {
+		return ""
+	}
+	b := t.valueBytes(e)
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// valueBytes returns the raw value of e, or nil if it lies outside the data.
+func (t *tiff) valueBytes(e ifdEntry) []byte {
+	size := 0
+	if int(e.typ) < len(tiffTypeSizes) {
+		size = tiffTypeSizes[e.typ]
+	}
+	n := uint64(size) * uint64(e.count)
+	if n <= 4 {
+		return e.value[:n]
+	}
+	off := uint64(t.order.Uint32(e.value[:]))
+	if off+n > uint64(len(t.data)) {
+		return nil
+	}
+	return t.data[off : off+n]
+}
+
+// append adds b to the data at a word boundary, as TIFF requires, and
+// returns its offset.
+func (t *tiff) append(b []byte) uint32 {
+	if len(t.data)%2 == 1 {
+		t.data = append(t.data, 0)
+	}
+	off := uint32(len(t.data))
+	t.data = append(t.data, b...)
+	return off
+}
+
+// appendIFD adds a directory of entries, sorted by tag as TIFF requires, and
+// returns its offset.
+func (t *tiff) appendIFD(entries []ifdEntry, next uint32) uint32 {
+	entries = slices.SortedFunc(slices.Values(entries), func(a, b ifdEntry) int { return cmp.Compare(a.tag, b.tag) })
+	b := make([]byte, 2+12*len(entries)+4)
+	t.order.PutUint16(b, uint16(len(entries)))
+	for i, e := range entries {
+		raw := b[2+12*i:]
+		t.order.PutUint16(raw, e.tag)
+		t.order.PutUint16(raw[2:], e.typ)
+		t.order.PutUint32(raw[4:], e.count)
+		copy(raw[8:], e.value[:])
+	}
+	t.order.PutUint32(b[len(b)-4:], next)
+	return t.append(b)
+}
+
+// asciiEntry creates an entry holding s, adding it to the data if it doesn't
+// fit in the entry.
+func (t *tiff) asciiEntry(tag uint16, s string) ifdEntry {
+	b := append([]byte(s), 0)
+	e := ifdEntry{tag: tag, typ: tiffASCII, count: uint32(len(b))}
+	if len(b) <= len(e.value) {
+		copy(e.value[:], b)
+	} else {
+		t.order.PutUint32(e.value[:], t.append(b))
+	}
+	return e
+}
+
+func (t *tiff) longEntry(tag uint16, v uint32) ifdEntry {
+	e := ifdEntry{tag: tag, typ: tiffLong, count: 1}
+	t.order.PutUint32(e.value[:], v)
+	return e
+}
+
+func findEntry(entries []ifdEntry, tag uint16) (ifdEntry, bool) {
+	i := slices.IndexFunc(entries, func(e ifdEntry) bool { return e.tag == tag })
+	if i < 0 {
+		return ifdEntry{}, false
+	}
+	return entries[i], true
+}
+
+// setEntry replaces the entry with e's tag by e, or adds e.
+func setEntry(entries []ifdEntry, e ifdEntry) []ifdEntry {
+	if i := slices.IndexFunc(entries, func(old ifdEntry) bool { return old.tag == e.tag }); i >= 0 {
+		entries = slices.Clone(entries)
+		entries[i] = e
+		return entries
+	}
+	return append(slices.Clone(entries), e)
+}
+
+func removeEntry(entries []ifdEntry, tag uint16) []ifdEntry {
+	return slices.DeleteFunc(slices.Clone(entries), func(e ifdEntry) bool { return e.tag == tag })
+}
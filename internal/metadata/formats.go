@@ -0,0 +1,202 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// jpegXMPHeader prefixes the XMP packet in a JPEG APP1 segment.
+var jpegXMPHeader = []byte("http://ns.adobe.com/xap/1.0/\x00")
+
+const (
+	jpegSOI  = 0xD8
+	jpegSOS  = 0xDA
+	jpegAPP0 = 0xE0
+	jpegAPP1 = 0xE1
+	// maxJPEGSegment is the largest payload a JPEG segment can hold.
+	maxJPEGSegment = 0xFFFF - 2
+)
+
+type jpegSegment struct {
+	marker     byte
+	start, end int // Byte range of the whole segment, marker included
+	payload    []byte
+}
+
+// jpegSegments lists the marker segments before the image data.
+func jpegSegments(data []byte) ([]jpegSegment, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != jpegSOI {
+		return nil, errors.New("not a JPEG file")
+	}
+	var segments []jpegSegment
+	pos := 2
+	for {
+		if pos+4 > len(data) || data[pos] != 0xFF {
+			return nil, errors.New("malformed JPEG segment")
+		}
+		marker := data[pos+1]
+		if marker == jpegSOS {
+			return segments, nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, errors.New("truncated JPEG segment")
+		}
+		segments = append(segments, jpegSegment{marker: marker, start: pos, end: end, payload: data[pos+4 : end]})
+		pos = end
+	}
+}
+
+type jpegContainer struct{}
+
+func (jpegContainer) xmp(data []byte) ([]byte, error) {
+	segments, err := jpegSegments(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range segments {
+		if s.marker == jpegAPP1 && bytes.HasPrefix(s.payload, jpegXMPHeader) {
+			return s.payload[len(jpegXMPHeader):], nil
+		}
+	}
+	return nil, nil
+}
+
+// withXMP replaces the XMP APP1 segment, or inserts one after the leading
+// JFIF/Exif segments, which readers expect to come first.
+func (jpegContainer) withXMP(data, packet []byte) ([]byte, error) {
+	segments, err := jpegSegments(data)
+	if err != nil {
+		return nil, err
+	}
+	payload := append(append([]byte(nil), jpegXMPHeader...), packet...)
+	if len(payload) > maxJPEGSegment {
+		return nil, fmt.Errorf("XMP packet of %d bytes does not fit in a JPEG segment", len(payload))
+	}
+	segment := jpegSegmentBytes(jpegAPP1, payload)
+
+	start, end := 2, 2
+	for _, s := range segments {
+		if s.marker == jpegAPP1 && bytes.HasPrefix(s.payload, jpegXMPHeader) {
+			start, end = s.start, s.end
+			break
+		}
+		if s.marker != jpegAPP0 && s.marker != jpegAPP1 {
+			break
+		}
+		start, end = s.end, s.end
+	}
+	out := make([]byte, 0, len(data)+len(segment))
+	out = append(out, data[:start]...)
+	out = append(out, segment...)
+	return append(out, data[end:]...), nil
+}
+
+// jpegSegmentBytes encodes a marker segment, which must fit maxJPEGSegment.
+func jpegSegmentBytes(marker byte, payload []byte) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngXMPKeyword identifies the iTXt chunk holding XMP.
+const pngXMPKeyword = "XML:com.adobe.xmp"
+
+type pngChunk struct {
+	typ        string
+	start, end int // Byte range of the whole chunk, length and CRC included
+	data       []byte
+}
+
+func pngChunks(data []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("not a PNG file")
+	}
+	var chunks []pngChunk
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+12 > len(data) {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if end > len(data) {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		chunks = append(chunks, pngChunk{typ: string(data[pos+4 : pos+8]), start: pos, end: end, data: data[pos+8 : pos+8+length]})
+		pos = end
+	}
+	return chunks, nil
+}
+
+// xmpFromITXt returns the text of an uncompressed XMP iTXt chunk, or nil.
+func xmpFromITXt(chunk []byte) []byte {
+	prefix := append([]byte(pngXMPKeyword), 0, 0, 0) // Keyword, not compressed, method 0
+	if !bytes.HasPrefix(chunk, prefix) {
+		return nil
+	}
+	rest := chunk[len(prefix):]
+	for range 2 { // Skip the language tag and translated keyword
+		i := bytes.IndexByte(rest, 0)
+		if i < 0 {
+			return nil
+		}
+		rest = rest[i+1:]
+	}
+	return rest
+}
+
+type pngContainer struct{}
+
+func (pngContainer) xmp(data []byte) ([]byte, error) {
+	chunks, err := pngChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range chunks {
+		if c.typ == "iTXt" {
+			if packet := xmpFromITXt(c.data); packet != nil {
+				return packet, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// withXMP replaces the XMP iTXt chunk, or inserts one before the image data.
+func (pngContainer) withXMP(data, packet []byte) ([]byte, error) {
+	chunks, err := pngChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	body := append([]byte("iTXt"+pngXMPKeyword), 0, 0, 0, 0, 0)
+	body = append(body, packet...)
+	chunk := make([]byte, 4, len(body)+8)
+	binary.BigEndian.PutUint32(chunk, uint32(len(body)-4))
+	chunk = append(chunk, body...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(body))
+
+	start, end := -1, -1
+	for _, c := range chunks {
+		if c.typ == "iTXt" && xmpFromITXt(c.data) != nil {
+			start, end = c.start, c.end
+			break
+		}
+		if start < 0 && (c.typ == "IDAT" || c.typ == "IEND") {
+			start, end = c.start, c.start
+		}
+	}
+	if start < 0 {
+		return nil, errors.New("PNG file has no image data")
+	}
+	out := make([]byte, 0, len(data)+len(chunk))
+	out = append(out, data[:start]...)
+	out = append(out, chunk...)
+	return append(out, data[end:]...), nil
+}
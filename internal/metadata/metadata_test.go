@@ -0,0 +1,347 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/tagging"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+func writeTestImage(t *testing.T, path string) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	var buf bytes.Buffer
+	var err error
+	if strings.HasSuffix(path, ".png") {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatalf("encoding %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeywordsRoundTrip(t *testing.T) {
+	for _, name := range []string{"a.jpg", "b.png"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			writeTestImage(t, path)
+
+			if got, err := ReadKeywords(path); err != nil || got != nil {
+				t.Fatalf("ReadKeywords on a fresh file = %v, %v", got, err)
+			}
+			if err := WriteKeywords(path, []string{"cats", "a&b"}); err != nil {
+				t.Fatalf("WriteKeywords failed: %v", err)
+			}
+			if err := WriteKeywords(path, []string{"dogs", "a&b"}); err != nil { // Replace, not append
+				t.Fatalf("second WriteKeywords failed: %v", err)
+			}
+			got, err := ReadKeywords(path)
+			if err != nil {
+				t.Fatalf("ReadKeywords failed: %v", err)
+			}
+			if want := []string{"dogs", "a&b"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("keywords = %v, want %v", got, want)
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			if _, _, err := image.Decode(f); err != nil {
+				t.Errorf("image no longer decodes after writing keywords: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetSubjectPreservesOtherProperties(t *testing.T) {
+	packet := []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmp:Rating="5" xmlns:xmp="http://ns.adobe.com/xap/1.0/"/></rdf:RDF></x:xmpmeta>`)
+	out := string(setSubject(packet, []string{"new"}))
+	if !strings.Contains(out, `xmp:Rating="5"`) || !strings.Contains(out, "<rdf:li>new</rdf:li>") {
+		t.Errorf("setSubject lost a property or the keyword:\n%s", out)
+	}
+	out = string(setSubject([]byte(out), []string{"newer"}))
+	if strings.Count(out, "<dc:subject>") != 1 || strings.Contains(out, ">new<") {
+		t.Errorf("setSubject did not replace the existing subject:\n%s", out)
+	}
+}
+
+func TestSyncer(t *testing.T) {
+	tagDB, err := tagging.NewTagDB(t.TempDir(), func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tagDB.Close()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "photo.jpg")
+	writeTestImage(t, path)
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	pass := func(prefer Prefer) SyncStats {
+		t.Helper()
+		s, err := NewSyncer(tagDB, statePath, SyncOptions{Prefer: prefer})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats, err := s.SyncDir(context.Background(), dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stats
+	}
+	expect := func(want []string) {
+		t.Helper()
+		dbTags, _ := tagDB.GetTags(context.Background(), path)
+		fileTags, _ := ReadKeywords(path)
+		if !reflect.DeepEqual(dbTags, want) || !reflect.DeepEqual(sortedSet(fileTags), want) {
+			t.Errorf("db = %v, file = %v, want both %v", dbTags, fileTags, want)
+		}
+	}
+	// Keep modification times distinguishable on coarse filesystems.
+	touch := func() {
+		later := time.Now().Add(time.Minute)
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// First sync merges both sides.
+	if err := WriteKeywords(path, []string{"file"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tagDB.AddTag(context.Background(), path, "db"); err != nil {
+		t.Fatal(err)
+	}
+	pass(PreferMerge)
+	expect([]string{"db", "file"})
+
+	// A database change is written to the file.
+	if err := tagDB.AddTag(context.Background(), path, "added"); err != nil {
+		t.Fatal(err)
+	}
+	if stats := pass(PreferMerge); stats.ToFile != 1 || stats.ToDB != 0 {
+		t.Errorf("db change stats = %+v", stats)
+	}
+	expect([]string{"added", "db", "file"})
+
+	// An external keyword edit is imported.
+	if err := WriteKeywords(path, []string{"db"}); err != nil {
+		t.Fatal(err)
+	}
+	touch()
+	if stats := pass(PreferMerge); stats.ToDB != 1 || stats.ToFile != 0 {
+		t.Errorf("file change stats = %+v", stats)
+	}
+	expect([]string{"db"})
+
+	// Both changed: the conflict rule decides.
+	if err := tagDB.AddTag(context.Background(), path, "fromdb"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteKeywords(path, []string{"db", "fromfile"}); err != nil {
+		t.Fatal(err)
+	}
+	touch()
+	if stats := pass(PreferFile); stats.Conflicts != 1 {
+		t.Errorf("conflict stats = %+v", stats)
+	}
+	expect([]string{"db", "fromfile"})
+}
+
+func TestEXIFRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.jpg")
+	writeTestImage(t, path)
+	// Give the file EXIF data with a field the edits must keep.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := newTIFF()
+	x.rewrite([]ifdEntry{x.asciiEntry(0x010F, "ScanCo")}, 0, nil) // Make
+	if data, err = jpegWithEXIF(data, x.data); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := ReadEXIF(path); err != nil || got != (EXIF{}) {
+		t.Fatalf("ReadEXIF before editing = %+v, %v", got, err)
+	}
+	taken := time.Date(1987, 6, 5, 14, 30, 0, 0, time.Local)
+	title := "Grandma's garden"
+	if err := WriteEXIF(path, EXIFUpdate{Taken: &taken, Title: &title}); err != nil {
+		t.Fatalf("WriteEXIF failed: %v", err)
+	}
+	if err := AdjustEXIFDates(path, ShiftDates(-3*time.Hour)); err != nil {
+		t.Fatalf("AdjustEXIFDates failed: %v", err)
+	}
+	got, err := ReadEXIF(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (EXIF{Taken: taken.Add(-3 * time.Hour), Title: title}); !got.Taken.Equal(want.Taken) || got.Title != want.Title {
+		t.Errorf("ReadEXIF = %+v, want %+v", got, want)
+	}
+
+	// Other readers see the same fields, and the image still decodes.
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	parsed, err := exif.Decode(f)
+	if err != nil {
+		t.Fatalf("goexif can't read the edited EXIF data: %v", err)
+	}
+	for field, want := range map[exif.FieldName]string{exif.Make: "ScanCo", exif.ImageDescription: title, exif.DateTimeOriginal: "1987:06:05 11:30:00"} {
+		if tag, err := parsed.Get(field); err != nil {
+			t.Errorf("%s missing: %v", field, err)
+		} else if s, _ := tag.StringVal(); s != want {
+			t.Errorf("%s = %q, want %q", field, s, want)
+		}
+	}
+	f.Seek(0, io.SeekStart)
+	if _, _, err := image.Decode(f); err != nil {
+		t.Errorf("image no longer decodes after editing EXIF: %v", err)
+	}
+}
+
+func TestEXIFRepeatedEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "camera.jpg")
+	writeTestImage(t, path)
+	// Camera EXIF data with a large maker note, near the size of a segment.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := newTIFF()
+	makerNote := ifdEntry{tag: 0x927C, typ: 7, count: 60000}
+	x.order.PutUint32(makerNote.value[:], x.append(make([]byte, makerNote.count)))
+	x.rewrite([]ifdEntry{x.asciiEntry(0x010F, "CamCo")}, 0, []ifdEntry{makerNote})
+	if data, err = jpegWithEXIF(data, x.data); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	exifSize := func() int {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, err := jpegTIFF(data)
+		if err != nil || x == nil {
+			t.Fatalf("reading EXIF data: %v", err)
+		}
+		return len(x.data)
+	}
+
+	taken := time.Date(2001, 2, 3, 4, 5, 6, 0, time.Local)
+	var size int
+	for i := range 100 {
+		title := fmt.Sprintf("Holiday photo number %03d", i)
+		if err := WriteEXIF(path, EXIFUpdate{Title: &title}); err != nil {
+			t.Fatalf("edit %d: WriteEXIF failed: %v", i, err)
+		}
+		if err := WriteEXIF(path, EXIFUpdate{Taken: &taken}); err != nil {
+			t.Fatalf("edit %d: WriteEXIF failed: %v", i, err)
+		}
+		if err := AdjustEXIFDates(path, ShiftDates(0)); err != nil {
+			t.Fatalf("edit %d: AdjustEXIFDates failed: %v", i, err)
+		}
+		if i == 0 {
+			size = exifSize()
+		} else if got := exifSize(); got != size {
+			t.Fatalf("after %d rounds of edits the EXIF data is %d bytes, was %d after the first", i+1, got, size)
+		}
+	}
+	got, err := ReadEXIF(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Taken.Equal(taken) || got.Title != "Holiday photo number 099" {
+		t.Errorf("ReadEXIF after repeated edits = %+v", got)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	parsed, err := exif.Decode(f)
+	if err != nil {
+		t.Fatalf("goexif can't read the edited EXIF data: %v", err)
+	}
+	if tag, err := parsed.Get(exif.Make); err != nil {
+		t.Errorf("Make missing: %v", err)
+	} else if s, _ := tag.StringVal(); s != "CamCo" {
+		t.Errorf("Make = %q, want CamCo", s)
+	}
+}
+
+func TestDateAdjustments(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	wall := func(s string) time.Time {
+		t.Helper()
+		d, err := time.Parse(EXIFDateLayout, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		name   string
+		adjust DateAdjustment
+		in     string
+		want   string
+	}{
+		{"shift", ShiftDates(90 * time.Minute), "2024:03:31 01:00:00", "2024:03:31 02:30:00"},
+		{"winter", ConvertZone(time.UTC, paris), "2024:01:15 12:00:00", "2024:01:15 13:00:00"},
+		{"summer", ConvertZone(time.UTC, paris), "2024:07:15 12:00:00", "2024:07:15 14:00:00"},
+		{"fixed", ConvertZone(paris, time.FixedZone("UTC-5", -5*3600)), "2024:07:15 12:00:00", "2024:07:15 05:00:00"},
+	}
+	for _, tt := range tests {
+		if got := tt.adjust(wall(tt.in)).Format(EXIFDateLayout); got != tt.want {
+			t.Errorf("%s: %s became %s, want %s", tt.name, tt.in, got, tt.want)
+		}
+	}
+	local := time.Date(2024, 7, 15, 12, 0, 0, 0, paris)
+	if got := ShiftDates(time.Hour).Apply(local); !got.Equal(local.Add(time.Hour)) || got.Location() != paris {
+		t.Errorf("Apply = %v, want an hour later in Paris", got)
+	}
+}
+
+func TestShiftEXIFDatesWithoutDate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "undated.jpg")
+	writeTestImage(t, path)
+	if err := AdjustEXIFDates(path, ShiftDates(time.Hour)); !errors.Is(err, ErrNoDate) {
+		t.Errorf("AdjustEXIFDates = %v, want ErrNoDate", err)
+	}
+	if err := WriteEXIF(filepath.Join(t.TempDir(), "b.png"), EXIFUpdate{}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("WriteEXIF on a PNG = %v, want ErrUnsupportedFormat", err)
+	}
+}
@@ -0,0 +1,262 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fyslide/internal/paths"
+	"fyslide/internal/scan"
+	"fyslide/internal/tagging"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Prefer decides which side wins when an image's tags changed both in the
+// database and in the file since the last sync.
+type Prefer string
+
+// Conflict resolution rules.
+const (
+	PreferMerge Prefer = "merge" // Keep the union of both sides
+	PreferDB    Prefer = "db"    // The database's tags overwrite the file's
+	PreferFile  Prefer = "file"  // The file's keywords overwrite the database's
+)
+
+// ParsePrefer validates a conflict rule given on the command line.
+func ParsePrefer(name string) (Prefer, error) {
+	switch p := Prefer(name); p {
+	case PreferMerge, PreferDB, PreferFile:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown conflict rule %q (want merge, db or file)", name)
+}
+
+// DefaultBatchSize limits how many files one pass rewrites.
+const DefaultBatchSize = 100
+
+// SyncOptions configure a Syncer.
+type SyncOptions struct {
+	Prefer    Prefer
+	BatchSize int  // Files rewritten per pass; the rest wait for the next pass. <= 0 uses DefaultBatchSize
+	DryRun    bool // Report changes without writing files, the database or the state
+	Logger    scan.LoggerFunc
+}
+
+// SyncStats summarizes one pass.
+type SyncStats struct {
+	Checked     int // Supported images examined
+	ToFile      int // Files whose keywords were (or would be) rewritten
+	ToDB        int // Images whose database tags were (or would be) updated
+	Conflicts   int // Images changed on both sides
+	Deferred    int // File writes left for the next pass by the batch limit
+	Unsupported int // Images whose format can't carry keywords
+	Errors      int
+}
+
+// fileState is what an image looked like when it was last synced.
+type fileState struct {
+	ModTime time.Time `json:"modTime"`
+	Size    int64     `json:"size"`
+	Tags    []string  `json:"tags"`
+}
+
+// Syncer keeps embedded keywords and the tag database in step. It remembers
+// each file's tags and modification time at the last sync, so a pass can tell
+// which side changed: a changed modification time means the keywords may have
+// been edited externally, and tags differing from the remembered ones mean the
+// database was edited.
+type Syncer struct {
+	tagDB     *tagging.TagDB
+	statePath string
+	state     map[string]fileState
+	opts      SyncOptions
+}
+
+// NewSyncer loads the sync state from statePath (which need not exist yet).
+func NewSyncer(tagDB *tagging.TagDB, statePath string, opts SyncOptions) (*Syncer, error) {
+	if opts.Prefer == "" {
+		opts.Prefer = PreferMerge
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.Logger == nil {
+		opts.Logger = func(string) {}
+	}
+	s := &Syncer{tagDB: tagDB, statePath: statePath, state: make(map[string]fileState), opts: opts}
+	data, err := os.ReadFile(statePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading sync state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("invalid sync state %s: %w", statePath, err)
+	}
+	return s, nil
+}
+
+// DefaultStatePath returns the sync state file in the per-user FySlide state
+// directory.
+func DefaultStatePath() (string, error) {
+	return paths.SyncStatePath()
+}
+
+func (s *Syncer) saveState() error {
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.statePath), 0750); err != nil {
+		return err
+	}
+	tmp := s.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.statePath)
+}
+
+func sortedSet(tags []string) []string {
+	out := slices.Clone(tags)
+	sort.Strings(out)
+	return slices.Compact(out)
+}
+
+// resolve decides the tags an image should end up with.
+func resolve(dbTags, fileTags []string, known bool, last fileState, fileChanged bool, prefer Prefer) (want []string, conflict bool) {
+	dbChanged := !known || !slices.Equal(dbTags, last.Tags)
+	switch {
+	case !known: // First sync: nothing to compare with, so lose nothing
+		return sortedSet(append(slices.Clone(dbTags), fileTags...)), false
+	case dbChanged && fileChanged && !slices.Equal(dbTags, fileTags):
+		switch prefer {
+		case PreferDB:
+			return dbTags, true
+		case PreferFile:
+			return fileTags, true
+		}
+		return sortedSet(append(slices.Clone(dbTags), fileTags...)), true
+	case fileChanged:
+		return fileTags, false
+	default:
+		return dbTags, false
+	}
+}
+
+// SyncDir runs one pass over the images under dir. If ctx ends, the pass stops
+// before the next image and the state of the images already synced is saved,
+// so the next pass resumes from there; the error is then ctx's.
+func (s *Syncer) SyncDir(ctx context.Context, dir string) (SyncStats, error) {
+	var stats SyncStats
+	writes := 0
+	for item := range scan.Run(dir, s.opts.Logger) {
+		if ctx.Err() != nil {
+			continue // Drain the scan
+		}
+		if !Supported(item.Path) {
+			stats.Unsupported++
+			continue
+		}
+		stats.Checked++
+		if err := s.syncFile(ctx, item.Path, &stats, &writes); err != nil {
+			if ctx.Err() != nil {
+				continue // Stopped before changing anything
+			}
+			stats.Errors++
+			s.opts.Logger(fmt.Sprintf("Error syncing %s: %v", item.Path, err))
+		}
+	}
+	if s.opts.DryRun {
+		return stats, ctx.Err()
+	}
+	if err := s.saveState(); err != nil {
+		return stats, fmt.Errorf("saving sync state: %w", err)
+	}
+	return stats, ctx.Err()
+}
+
+func (s *Syncer) syncFile(ctx context.Context, path string, stats *SyncStats, writes *int) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	last, known := s.state[path]
+	fileChanged := !known || !info.ModTime().Equal(last.ModTime) || info.Size() != last.Size
+
+	dbTags, err := s.tagDB.GetTags(ctx, path)
+	if err != nil {
+		return err
+	}
+	fileTags := last.Tags
+	if fileChanged {
+		if fileTags, err = ReadKeywords(path); err != nil {
+			return err
+		}
+		fileTags = sortedSet(fileTags)
+	}
+
+	want, conflict := resolve(dbTags, fileTags, known, last, fileChanged, s.opts.Prefer)
+	if conflict {
+		stats.Conflicts++
+		s.opts.Logger(fmt.Sprintf("Conflict in %s resolved by rule %q: %v", path, s.opts.Prefer, want))
+	}
+
+	if !slices.Equal(dbTags, want) {
+		stats.ToDB++
+		if s.opts.DryRun {
+			s.opts.Logger(fmt.Sprintf("DRY RUN: Would set database tags of %s to %v", path, want))
+		} else if err := s.setDBTags(context.WithoutCancel(ctx), path, dbTags, want); err != nil {
+			return err
+		}
+	}
+	if !slices.Equal(fileTags, want) {
+		if *writes >= s.opts.BatchSize {
+			stats.Deferred++
+			return nil // State left untouched so the next pass picks this file up again
+		}
+		*writes++
+		stats.ToFile++
+		if s.opts.DryRun {
+			s.opts.Logger(fmt.Sprintf("DRY RUN: Would write keywords %v to %s", want, path))
+			return nil
+		}
+		if err := WriteKeywords(path, want); err != nil {
+			return err
+		}
+		if info, err = os.Stat(path); err != nil {
+			return err
+		}
+	}
+	if !s.opts.DryRun {
+		s.state[path] = fileState{ModTime: info.ModTime(), Size: info.Size(), Tags: want}
+	}
+	return nil
+}
+
+// setDBTags applies the difference between have and want to the database.
+// SyncDir doesn't let it be cancelled, as the database would be left with tags
+// the next pass would take for the user's.
+func (s *Syncer) setDBTags(ctx context.Context, path string, have, want []string) error {
+	for _, tag := range have {
+		if !slices.Contains(want, tag) {
+			if err := s.tagDB.RemoveTag(ctx, path, tag); err != nil {
+				return err
+			}
+		}
+	}
+	for _, tag := range want {
+		if !slices.Contains(have, tag) {
+			if err := s.tagDB.AddTag(ctx, path, tag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
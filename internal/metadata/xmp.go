@@ -0,0 +1,160 @@
+// Package metadata reads and writes the keywords embedded in image files and
+// keeps them in sync with the tag database. Keywords are stored as XMP
+// dc:subject, the field Lightroom, digiKam and most viewers read, inside the
+// APP1 segment of JPEG files and the iTXt chunk of PNG files.
+package metadata
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"fyslide/internal/importer"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrUnsupportedFormat is returned for files whose format can't carry XMP here.
+var ErrUnsupportedFormat = errors.New("format does not support embedded keywords")
+
+// format returns the container handler for path based on its extension.
+func format(path string) (container, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		return jpegContainer{}, nil
+	case ".png":
+		return pngContainer{}, nil
+	}
+	return nil, fmt.Errorf("%s: %w", filepath.Base(path), ErrUnsupportedFormat)
+}
+
+// container extracts and replaces the XMP packet of one file format.
+type container interface {
+	// xmp returns the XMP packet in data, or nil if there is none.
+	xmp(data []byte) ([]byte, error)
+	// withXMP returns data with its XMP packet replaced by (or extended with) packet.
+	withXMP(data, packet []byte) ([]byte, error)
+}
+
+// Supported reports whether keywords can be read and written for path.
+func Supported(path string) bool {
+	_, err := format(path)
+	return err == nil
+}
+
+// ReadKeywords returns the normalized keywords embedded in the image at path.
+func ReadKeywords(path string) ([]string, error) {
+	c, err := format(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	packet, err := c.xmp(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if packet == nil {
+		return nil, nil
+	}
+	return importer.ParseXMP(bytes.NewReader(packet))
+}
+
+// WriteKeywords embeds keywords in the image at path, replacing its existing
+// dc:subject list. Other XMP properties already in the file are preserved. The
+// file is replaced atomically and keeps its permissions.
+func WriteKeywords(path string, keywords []string) error {
+	c, err := format(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	packet, err := c.xmp(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	updated, err := c.withXMP(data, setSubject(packet, keywords))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return replaceFile(path, updated)
+}
+
+// replaceFile atomically replaces the file at path with data, keeping its
+// permissions.
+func replaceFile(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+var (
+	subjectRe  = regexp.MustCompile(`(?s)<dc:subject\s*/>|<dc:subject>.*?</dc:subject>`)
+	rdfCloseRe = regexp.MustCompile(`</rdf:RDF>`)
+)
+
+const (
+	xpacketBegin  = `<?xpacket begin="` + "\uFEFF" + `" id="W5M0MpCehiHzreSzNTczkc9d"?>`
+	xpacketEnd    = `<?xpacket end="w"?>`
+	dcNamespace   = `http://purl.org/dc/elements/1.1/`
+	rdfNamespace  = `http://www.w3.org/1999/02/22-rdf-syntax-ns#`
+	xmetaTemplate = xpacketBegin + "\n" +
+		`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="` + rdfNamespace + `">%s</rdf:RDF></x:xmpmeta>` +
+		"\n" + xpacketEnd
+)
+
+// subjectXML renders keywords as a dc:subject element.
+func subjectXML(keywords []string) string {
+	var b strings.Builder
+	b.WriteString("<dc:subject><rdf:Bag>")
+	for _, k := range keywords {
+		b.WriteString("<rdf:li>")
+		xml.EscapeText(&b, []byte(k)) //nolint:errcheck // strings.Builder never fails
+		b.WriteString("</rdf:li>")
+	}
+	b.WriteString("</rdf:Bag></dc:subject>")
+	return b.String()
+}
+
+// setSubject returns packet with its dc:subject replaced by keywords. A packet
+// without dc:subject gets a new rdf:Description; a missing or unrecognizable
+// packet is replaced by a minimal one.
+func setSubject(packet []byte, keywords []string) []byte {
+	subject := subjectXML(keywords)
+	if loc := subjectRe.FindIndex(packet); loc != nil {
+		out := append([]byte(nil), packet[:loc[0]]...)
+		out = append(out, subject...)
+		return append(out, packet[loc[1]:]...)
+	}
+	description := `<rdf:Description rdf:about="" xmlns:dc="` + dcNamespace + `">` + subject + `</rdf:Description>`
+	if loc := rdfCloseRe.FindIndex(packet); loc != nil {
+		out := append([]byte(nil), packet[:loc[0]]...)
+		out = append(out, description...)
+		return append(out, packet[loc[0]:]...)
+	}
+	return []byte(fmt.Sprintf(xmetaTemplate, description))
+}
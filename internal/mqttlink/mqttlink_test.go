@@ -0,0 +1,60 @@
+package mqttlink
+
+import (
+	"fyslide/internal/events"
+	"reflect"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    Command
+		ok      bool
+	}{
+		{"next", Command{Action: ActionNext}, true},
+		{"  PAUSE \n", Command{Action: ActionPause}, true},
+		{"set-filter tag=Holiday", Command{Action: ActionSetFilter, Tags: []string{"holiday"}}, true},
+		{"set-filter tag=beach,sunset tag=2024", Command{Action: ActionSetFilter, Tags: []string{"beach", "sunset", "2024"}}, true},
+		{"set-filter", Command{Action: ActionClearFilter}, true},
+		{"clear-filter", Command{Action: ActionClearFilter}, true},
+		{"set-filter holiday", Command{}, false},
+		{"next 3", Command{}, false},
+		{"reboot", Command{}, false},
+		{"", Command{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseCommand(tt.payload)
+		if (err == nil) != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCommand(%q) = %+v, %v; want %+v", tt.payload, got, err, tt.want)
+		}
+	}
+}
+
+func TestStatusApply(t *testing.T) {
+	var s Status
+	if !s.apply(events.Event{Type: events.ImageChanged, Path: "/p/a.jpg", Index: 2, Count: 5}) {
+		t.Error("image change not published")
+	}
+	s.apply(events.Event{Type: events.Paused})
+	s.apply(events.Event{Type: events.FilterChanged, Filter: "Tags: holiday", Count: 3})
+	if s.apply(events.Event{Type: events.TagAdded, Path: "/p/a.jpg", Tag: "x"}) {
+		t.Error("tag change published as a status change")
+	}
+	want := Status{Paused: true, Path: "/p/a.jpg", Index: 2, Count: 3, Filter: "Tags: holiday"}
+	s.Time = want.Time
+	if s != want {
+		t.Errorf("status = %+v, want %+v", s, want)
+	}
+}
+
+func TestConnectRejectsBadBroker(t *testing.T) {
+	logf := func(string, ...any) {}
+	if _, err := Connect(Options{Broker: "not a url"}, events.NewBus(), func(Command) {}, logf); err == nil {
+		t.Error("Connect accepted an invalid broker URL")
+	}
+	// Nothing listens on port 1, so the connection is refused right away.
+	if _, err := Connect(Options{Broker: "tcp://127.0.0.1:1", Topic: "test/frame"}, events.NewBus(), func(Command) {}, logf); err == nil {
+		t.Error("Connect succeeded without a broker")
+	}
+}
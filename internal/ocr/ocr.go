@@ -0,0 +1,159 @@
+// Package ocr extracts the text of images such as scanned documents with an
+// OCR engine: the tesseract command, or a web service the image is posted to.
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultCommand is the tesseract command used when no engine is configured.
+const DefaultCommand = "tesseract"
+
+// serviceTimeout bounds a request to an OCR service.
+const serviceTimeout = 2 * time.Minute
+
+// Engine extracts the text of an image file.
+type Engine interface {
+	Extract(ctx context.Context, path string) (string, error)
+}
+
+// New returns the engine spec names: "" for tesseract on the PATH, the path
+// of the tesseract command, or the http(s) URL of a service. lang selects the
+// languages tesseract reads, such as "eng" or "eng+deu"; "" uses its default.
+func New(spec, lang string) (Engine, error) {
+	if strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://") {
+		return Service{URL: spec, Lang: lang}, nil
+	}
+	if spec == "" {
+		spec = DefaultCommand
+	}
+	command, err := exec.LookPath(spec)
+	if err != nil {
+		return nil, fmt.Errorf("OCR engine %s not found; install tesseract or set ocr.engine in the config file: %w", spec, err)
+	}
+	return Tesseract{Command: command, Lang: lang}, nil
+}
+
+// Tesseract runs the tesseract command on each image.
+type Tesseract struct {
+	Command string
+	Lang    string
+}
+
+// Extract returns the text tesseract reads from the image at path.
+func (t Tesseract) Extract(ctx context.Context, path string) (string, error) {
+	args := []string{path, "stdout"}
+	if t.Lang != "" {
+		args = append(args, "-l", t.Lang)
+	}
+	cmd := exec.CommandContext(ctx, t.Command, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s on %s: %w: %s", filepath.Base(t.Command), filepath.Base(path), err, lastLine(msg))
+		}
+		return "", fmt.Errorf("%s on %s: %w", filepath.Base(t.Command), filepath.Base(path), err)
+	}
+	return Clean(string(out)), nil
+}
+
+// Service posts each image to an OCR web service. The service answers with
+// the text, either as plain text or as a JSON object with a "text" field.
+// The language, if set, is passed as the lang query parameter.
+type Service struct {
+	URL    string
+	Lang   string
+	Client *http.Client // nil uses a client with a timeout
+}
+
+// Extract returns the text the service reads from the image at path.
+func (s Service) Extract(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	url := s.URL
+	if s.Lang != "" {
+		sep := "?"
+		if strings.Contains(url, "?") {
+			sep = "&"
+		}
+		url += sep + "lang=" + s.Lang
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	if contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path))); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: serviceTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("OCR service: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("OCR service: reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("OCR service: %s: %s", resp.Status, lastLine(strings.TrimSpace(string(body))))
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "application/json" {
+		var reply struct {
+			Text *string `json:"text"`
+		}
+		if err := json.Unmarshal(body, &reply); err != nil {
+			return "", fmt.Errorf("OCR service: decoding response: %w", err)
+		}
+		if reply.Text == nil {
+			return "", errors.New(`OCR service: response has no "text" field`)
+		}
+		return Clean(*reply.Text), nil
+	}
+	return Clean(string(body)), nil
+}
+
+// Clean tidies extracted text: trailing spaces and page breaks go, and runs
+// of blank lines shrink to one.
+func Clean(text string) string {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\f", "\n")
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lastLine returns the last line of a message, where tools put the error.
+func lastLine(msg string) string {
+	return msg[strings.LastIndex(msg, "\n")+1:]
+}
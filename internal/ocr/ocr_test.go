@@ -0,0 +1,93 @@
+package ocr
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	got := Clean("ACME Corp  \r\n\n\n\nInvoice 42\t\n\f")
+	if want := "ACME Corp\n\nInvoice 42"; got != want {
+		t.Errorf("Clean = %q, want %q", got, want)
+	}
+	if got := Clean(" \n\f\n"); got != "" {
+		t.Errorf("Clean of blank text = %q, want \"\"", got)
+	}
+}
+
+func writeImage(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scan.png")
+	if err := os.WriteFile(path, []byte("fake png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestService(t *testing.T) {
+	path := writeImage(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case string(body) != "fake png" || r.Header.Get("Content-Type") != "image/png":
+			http.Error(w, "bad upload", http.StatusBadRequest)
+		case r.URL.Query().Get("lang") == "json":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			io.WriteString(w, `{"text": "Invoice 42  \n"}`)
+		case r.URL.Query().Get("lang") == "fail":
+			http.Error(w, "engine crashed", http.StatusInternalServerError)
+		default:
+			io.WriteString(w, "Receipt\n")
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	for lang, want := range map[string]string{"": "Receipt", "json": "Invoice 42"} {
+		engine, err := New(srv.URL, lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := engine.Extract(ctx, path)
+		if err != nil || got != want {
+			t.Errorf("Extract with lang %q = %q, %v; want %q", lang, got, err, want)
+		}
+	}
+	if _, err := (Service{URL: srv.URL, Lang: "fail"}).Extract(ctx, path); err == nil || !strings.Contains(err.Error(), "engine crashed") {
+		t.Errorf("Extract from a failing service = %v, want its message", err)
+	}
+}
+
+func TestTesseract(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+	fake := filepath.Join(t.TempDir(), "tesseract")
+	script := "#!/bin/sh\nif [ \"$4\" = xx ]; then echo 'Error opening data file xx.traineddata' >&2; exit 1; fi\nprintf 'Read from %s\\n\\f' \"$1\"\n"
+	if err := os.WriteFile(fake, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := writeImage(t)
+	ctx := context.Background()
+
+	engine, err := New(fake, "eng")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := engine.Extract(ctx, path); err != nil || got != "Read from "+path {
+		t.Errorf("Extract = %q, %v", got, err)
+	}
+	if _, err := (Tesseract{Command: fake, Lang: "xx"}).Extract(ctx, path); err == nil || !strings.Contains(err.Error(), "traineddata") {
+		t.Errorf("Extract with a missing language = %v, want tesseract's message", err)
+	}
+	if _, err := New(filepath.Join(t.TempDir(), "missing"), ""); err == nil {
+		t.Error("New with a missing command succeeded")
+	}
+}
@@ -0,0 +1,149 @@
+// Package paths resolves where FySlide keeps its files. Settings live in the
+// config directory, the tag database and other irreplaceable state in the data
+// directory, and rebuildable caches in the cache directory, following the XDG
+// base directory conventions where the platform has them. Environment
+// variables relocate the config directory and the database; files found at
+// their pre-XDG location in the config directory keep being used there.
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const appName = "fyslide"
+
+// Environment variables relocating FySlide's files.
+const (
+	EnvConfigDir = "FYSLIDE_CONFIG_DIR" // Config directory, holding config.yaml and the profiles
+	EnvDBPath    = "FYSLIDE_DB_PATH"    // Directory of the default profile's tag database
+)
+
+// DBFileName is the name of the tag database file.
+const DBFileName = "fyslide_tags.db"
+
+// LogFileName is the name of the viewer's log file in LogDir.
+const LogFileName = "fyslide.log"
+
+// xdg reports whether the platform follows the XDG base directory conventions.
+func xdg() bool {
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "android", "plan9", "js":
+		return false
+	}
+	return true
+}
+
+// ConfigDir returns $FYSLIDE_CONFIG_DIR, or the fyslide folder of the user's
+// config directory ($XDG_CONFIG_HOME, ~/.config).
+func ConfigDir() (string, error) {
+	if dir := os.Getenv(EnvConfigDir); dir != "" {
+		return dir, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user config dir: %w", err)
+	}
+	return filepath.Join(configDir, appName), nil
+}
+
+// xdgDir returns the fyslide folder of the XDG directory named by env,
+// falling back to fallback below the home directory. ok is false on platforms
+// without XDG conventions.
+func xdgDir(env, fallback string) (dir string, ok bool, err error) {
+	if !xdg() {
+		return "", false, nil
+	}
+	if base := os.Getenv(env); base != "" {
+		return filepath.Join(base, appName), true, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false, fmt.Errorf("could not get home dir: %w", err)
+	}
+	return filepath.Join(home, fallback, appName), true, nil
+}
+
+// DataDir returns the fyslide folder of $XDG_DATA_HOME (~/.local/share). On
+// other platforms data stays in ConfigDir.
+func DataDir() (string, error) {
+	dir, ok, err := xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+	if err != nil || ok {
+		return dir, err
+	}
+	return ConfigDir()
+}
+
+// StateDir returns the fyslide folder of $XDG_STATE_HOME (~/.local/state),
+// for logs and sync state. On other platforms it is DataDir.
+func StateDir() (string, error) {
+	dir, ok, err := xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+	if err != nil || ok {
+		return dir, err
+	}
+	return DataDir()
+}
+
+// CacheDir returns the fyslide folder of the user's cache directory
+// ($XDG_CACHE_HOME, ~/.cache), for the scan, signature and thumbnail caches.
+func CacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user cache dir: %w", err)
+	}
+	return filepath.Join(cacheDir, appName), nil
+}
+
+// ThumbnailDir returns the folder of the on-disk thumbnail cache.
+func ThumbnailDir() (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "thumbnails"), nil
+}
+
+// LogDir returns the folder of the viewer's log file.
+func LogDir() (string, error) {
+	return StateDir()
+}
+
+// DBDir returns the directory of the default profile's tag database:
+// $FYSLIDE_DB_PATH, else the config directory if it already holds a database
+// or was relocated with $FYSLIDE_CONFIG_DIR, else DataDir.
+func DBDir() (string, error) {
+	if dir := os.Getenv(EnvDBPath); dir != "" {
+		return dir, nil
+	}
+	if os.Getenv(EnvConfigDir) != "" {
+		return ConfigDir()
+	}
+	return withLegacy(DataDir, DBFileName)
+}
+
+// withLegacy returns dir(), unless the config directory already holds name
+// from before the XDG layout, in which case it stays there.
+func withLegacy(dir func() (string, error), name string) (string, error) {
+	legacy, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(legacy, name)); err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return legacy, nil
+	}
+	return dir()
+}
+
+// SyncStatePath returns the metadata sync state file.
+func SyncStatePath() (string, error) {
+	const name = "metadata_sync.json"
+	dir, err := withLegacy(StateDir, name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,58 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEnvironmentOverrides(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvConfigDir, filepath.Join(dir, "conf"))
+	t.Setenv(EnvDBPath, "")
+	if got, err := ConfigDir(); err != nil || got != filepath.Join(dir, "conf") {
+		t.Errorf("ConfigDir = %s, %v", got, err)
+	}
+	if got, err := DBDir(); err != nil || got != filepath.Join(dir, "conf") {
+		t.Errorf("DBDir with a relocated config dir = %s, %v; want the config dir", got, err)
+	}
+	t.Setenv(EnvDBPath, filepath.Join(dir, "db"))
+	if got, err := DBDir(); err != nil || got != filepath.Join(dir, "db") {
+		t.Errorf("DBDir = %s, %v; want %s", got, err, EnvDBPath)
+	}
+}
+
+func TestXDGLayoutKeepsLegacyDatabase(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("XDG layout only")
+	}
+	dir := t.TempDir()
+	t.Setenv(EnvConfigDir, "")
+	t.Setenv(EnvDBPath, "")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	if got, _ := DBDir(); got != filepath.Join(dir, "data", appName) {
+		t.Errorf("DBDir of a new install = %s, want the data dir", got)
+	}
+	if got, _ := CacheDir(); got != filepath.Join(dir, "cache", appName) {
+		t.Errorf("CacheDir = %s", got)
+	}
+	if got, _ := LogDir(); got != filepath.Join(dir, "state", appName) {
+		t.Errorf("LogDir = %s", got)
+	}
+
+	legacy := filepath.Join(dir, "config", appName)
+	if err := os.MkdirAll(legacy, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(legacy, DBFileName), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := DBDir(); got != legacy {
+		t.Errorf("DBDir with an existing database = %s, want %s", got, legacy)
+	}
+}
@@ -0,0 +1,128 @@
+// Package profile manages named user profiles. Each profile has its own
+// directory holding its tag database and other per-user state, so several
+// people sharing a machine keep their curation apart. The default profile
+// lives directly in the directory of paths.DBDir, the named ones below the
+// data directory. Profiles made before the XDG layout keep their place in
+// the config directory.
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"fyslide/internal/paths"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+)
+
+// DefaultName is the profile used when none is chosen.
+const DefaultName = "default"
+
+// profilesDirName is the subdirectory of the data directory holding named profiles.
+const profilesDirName = "profiles"
+
+var nameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,31}$`)
+
+// ValidateName checks that name can be used as a profile (and directory) name.
+func ValidateName(name string) error {
+	if !nameRe.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use up to 32 letters, digits, '-' or '_'", name)
+	}
+	return nil
+}
+
+// BaseDir returns the per-user FySlide config directory.
+func BaseDir() (string, error) {
+	return paths.ConfigDir()
+}
+
+// ProfilesDir returns the directory holding the named profiles: below
+// paths.DataDir, or below the config directory when it was relocated with
+// $FYSLIDE_CONFIG_DIR, as the default profile's database is.
+func ProfilesDir() (string, error) {
+	if os.Getenv(paths.EnvConfigDir) != "" {
+		return legacyProfilesDir()
+	}
+	data, err := paths.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(data, profilesDirName), nil
+}
+
+// legacyProfilesDir returns where the named profiles were kept before the
+// XDG layout.
+func legacyProfilesDir() (string, error) {
+	base, err := BaseDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, profilesDirName), nil
+}
+
+// Dir returns the directory of the named profile. An empty name means the
+// default profile, whose database lives in paths.DBDir. A named profile whose
+// database is still in the config directory stays there; otherwise it is in
+// ProfilesDir. The directory is not created.
+func Dir(name string) (string, error) {
+	if name == "" || name == DefaultName {
+		return paths.DBDir()
+	}
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	legacy, err := legacyProfilesDir()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(legacy, name, paths.DBFileName)); err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return filepath.Join(legacy, name), nil
+	}
+	dir, err := ProfilesDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Create makes the directory of the named profile if needed and returns it.
+func Create(name string) (string, error) {
+	dir, err := Dir(name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("failed to create profile directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// List returns the default profile followed by the named profiles, in
+// ProfilesDir or still in the config directory, in alphabetical order.
+func List() ([]string, error) {
+	var named []string
+	for _, dir := range []func() (string, error){ProfilesDir, legacyProfilesDir} {
+		profilesDir, err := dir()
+		if err != nil {
+			return nil, err
+		}
+		entries, err := os.ReadDir(profilesDir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("listing profiles: %w", err)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() && name != DefaultName && ValidateName(name) == nil && !slices.Contains(named, name) {
+				named = append(named, name)
+			}
+		}
+	}
+	sort.Strings(named)
+	return append([]string{DefaultName}, named...), nil
+}
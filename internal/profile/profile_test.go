@@ -0,0 +1,93 @@
+package profile
+
+import (
+	"fyslide/internal/paths"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir()) // For platforms that ignore XDG_CONFIG_HOME
+	t.Setenv(paths.EnvConfigDir, "")
+	t.Setenv(paths.EnvDBPath, "")
+
+	base, err := BaseDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	profilesDir, err := ProfilesDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataDir, err := paths.DataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dataDir, profilesDirName); profilesDir != want {
+		t.Errorf("ProfilesDir() = %q, want %q in the data directory", profilesDir, want)
+	}
+	dbDir, err := paths.DBDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir, err := Dir(""); err != nil || dir != dbDir {
+		t.Errorf("Dir(\"\") = %q, %v; want the database dir %q", dir, err, dbDir)
+	}
+	if dir, err := Dir(DefaultName); err != nil || dir != dbDir {
+		t.Errorf("Dir(%q) = %q, %v; want the database dir %q", DefaultName, dir, err, dbDir)
+	}
+
+	if got, err := List(); err != nil || !reflect.DeepEqual(got, []string{DefaultName}) {
+		t.Errorf("List() before any profile = %v, %v", got, err)
+	}
+	for _, name := range []string{"work", "alice"} {
+		dir, err := Create(name)
+		if err != nil {
+			t.Fatalf("Create(%q) failed: %v", name, err)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			t.Errorf("profile directory %s was not created", dir)
+		}
+		if dir != filepath.Join(profilesDir, name) {
+			t.Errorf("Create(%q) made %s, want it in %s", name, dir, profilesDir)
+		}
+	}
+	// Files and invalid names in the profiles directory are not profiles.
+	if err := os.WriteFile(filepath.Join(profilesDir, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(profilesDir, ".hidden"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	// A profile from before the XDG layout keeps its database in the config directory.
+	legacy := filepath.Join(base, profilesDirName, "bob")
+	if err := os.MkdirAll(legacy, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(legacy, paths.DBFileName), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if dir, err := Dir("bob"); err != nil || dir != legacy {
+		t.Errorf("Dir(\"bob\") = %q, %v; want the legacy directory %q", dir, err, legacy)
+	}
+	if got, err := List(); err != nil || !reflect.DeepEqual(got, []string{DefaultName, "alice", "bob", "work"}) {
+		t.Errorf("List() = %v, %v", got, err)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"work", "Bob_2", "a-b"} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "../x", "a/b", "-lead", "with space", "abcdefghijklmnopqrstuvwxyz0123456"} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("ValidateName(%q) accepted an invalid name", name)
+		}
+	}
+}
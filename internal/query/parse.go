@@ -0,0 +1,159 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayout is the layout of the dates Parse reads and String writes.
+const dateLayout = "2006-01-02"
+
+// Parse reads criteria written as comma-separated key=value terms, e.g.
+// "tag=family, tag=beach, from=2023-01-01". The keys are tag (repeatable),
+// folder, text, camera, orientation, from, to (dates as 2006-01-02),
+// min-width, min-height, min-size and max-size in KB, and colors-like and
+// looks-like, naming the image others must resemble. A comma in a value is
+// written as \, while other backslashes, as in Windows paths, stay as they
+// are. An empty string is empty criteria. Format writes criteria the same way.
+func Parse(s string) (Criteria, error) {
+	var c Criteria
+	for _, term := range splitTerms(s) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(term, "=")
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		if !ok || value == "" {
+			return Criteria{}, fmt.Errorf("filter term %q is not key=value", term)
+		}
+		if err := c.set(key, value); err != nil {
+			return Criteria{}, fmt.Errorf("filter term %q: %w", term, err)
+		}
+	}
+	return c, nil
+}
+
+// set applies one key=value term of Parse to c.
+func (c *Criteria) set(key, value string) error {
+	var err error
+	switch key {
+	case "tag":
+		c.Tags = append(c.Tags, strings.ToLower(value))
+	case "folder":
+		c.Folder = value
+	case "text":
+		c.Text = value
+	case "camera":
+		c.Camera = value
+	case "orientation":
+		c.Orientation = Orientation(strings.ToLower(value))
+		if !slices.Contains(Orientations, c.Orientation) {
+			return fmt.Errorf("unknown orientation")
+		}
+	case "from":
+		c.From, err = time.ParseInLocation(dateLayout, value, time.Local)
+	case "to":
+		// Inclusive of the whole day, as the filter dialog sets it
+		if c.To, err = time.ParseInLocation(dateLayout, value, time.Local); err == nil {
+			c.To = c.To.Add(24*time.Hour - time.Nanosecond)
+		}
+	case "min-width":
+		c.MinWidth, err = parseCount(value)
+	case "min-height":
+		c.MinHeight, err = parseCount(value)
+	case "colors-like":
+		c.SimilarColorsTo = value
+	case "looks-like":
+		c.SimilarTo = value
+	case "min-size", "max-size":
+		var kb int
+		if kb, err = parseCount(value); key == "min-size" {
+			c.MinSize = int64(kb) * 1024
+		} else {
+			c.MaxSize = int64(kb) * 1024
+		}
+	default:
+		return fmt.Errorf("unknown key")
+	}
+	return err
+}
+
+// splitTerms splits s at the commas not escaped as \, unescaping them.
+func splitTerms(s string) []string {
+	var terms []string
+	term := ""
+	parts := strings.Split(s, ",")
+	for i, part := range parts {
+		if rest, escaped := strings.CutSuffix(part, `\`); escaped && i < len(parts)-1 {
+			term += rest + ","
+			continue
+		}
+		terms = append(terms, term+part)
+		term = ""
+	}
+	return terms
+}
+
+// Format writes c as the terms Parse reads, so that parsing them gives c
+// back, e.g. to save or share a filter as text. The end date is written as
+// its day.
+func Format(c Criteria) string {
+	var terms []string
+	add := func(key, value string) {
+		value = strings.ReplaceAll(value, ",", `\,`)
+		terms = append(terms, key+"="+value)
+	}
+	for _, tag := range c.Tags {
+		add("tag", tag)
+	}
+	if c.Folder != "" {
+		add("folder", c.Folder)
+	}
+	if c.Text != "" {
+		add("text", c.Text)
+	}
+	if c.Camera != "" {
+		add("camera", c.Camera)
+	}
+	if c.Orientation != "" && c.Orientation != OrientationAny {
+		add("orientation", string(c.Orientation))
+	}
+	if !c.From.IsZero() {
+		add("from", c.From.Format(dateLayout))
+	}
+	if !c.To.IsZero() {
+		add("to", c.To.Format(dateLayout))
+	}
+	if c.MinWidth > 0 {
+		add("min-width", strconv.Itoa(c.MinWidth))
+	}
+	if c.MinHeight > 0 {
+		add("min-height", strconv.Itoa(c.MinHeight))
+	}
+	if c.MinSize > 0 {
+		add("min-size", strconv.FormatInt(c.MinSize/1024, 10))
+	}
+	if c.MaxSize > 0 {
+		add("max-size", strconv.FormatInt(c.MaxSize/1024, 10))
+	}
+	if c.SimilarColorsTo != "" {
+		add("colors-like", c.SimilarColorsTo)
+	}
+	if c.SimilarTo != "" {
+		add("looks-like", c.SimilarTo)
+	}
+	return strings.Join(terms, ", ")
+}
+
+// parseCount reads a non-negative whole number.
+func parseCount(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a whole number", value)
+	}
+	return n, nil
+}
@@ -0,0 +1,265 @@
+// Package query defines the criteria used to filter the image list and the
+// predicates that evaluate them against per-file properties.
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"fyslide/internal/autotag"
+	"image"
+	_ "image/gif" // Register decoders so DecodeConfig can read dimensions
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// Orientation classifies an image by its aspect ratio.
+type Orientation string
+
+// Orientation values. OrientationAny matches every image.
+const (
+	OrientationAny       Orientation = "any"
+	OrientationLandscape Orientation = "landscape"
+	OrientationPortrait  Orientation = "portrait"
+	OrientationSquare    Orientation = "square"
+)
+
+// Orientations lists the selectable orientations in display order.
+var Orientations = []Orientation{OrientationAny, OrientationLandscape, OrientationPortrait, OrientationSquare}
+
+// squareTolerance is how far the aspect ratio may stray from 1 and still count as square.
+const squareTolerance = 0.05
+
+// OrientationOf returns the orientation of a width x height image.
+func OrientationOf(width, height int) Orientation {
+	if width <= 0 || height <= 0 {
+		return OrientationAny
+	}
+	ratio := float64(width) / float64(height)
+	switch {
+	case ratio > 1+squareTolerance:
+		return OrientationLandscape
+	case ratio < 1-squareTolerance:
+		return OrientationPortrait
+	default:
+		return OrientationSquare
+	}
+}
+
+// Properties are the per-file facts the criteria are evaluated against.
+type Properties struct {
+	Date   time.Time // EXIF capture date when available, otherwise modification time
+	Width  int
+	Height int
+	Size   int64  // File size in bytes
+	Camera string // EXIF camera model, else make; only loaded for a camera predicate
+}
+
+// Criteria combines a tag filter with date and file property filters.
+// Zero values leave the corresponding dimension unrestricted.
+type Criteria struct {
+	Tags        []string  // Images must carry every one of these tags
+	From        time.Time // Inclusive lower bound on Date
+	To          time.Time // Inclusive upper bound on Date
+	MinWidth    int
+	MinHeight   int
+	Orientation Orientation
+	MinSize     int64  // Bytes
+	MaxSize     int64  // Bytes
+	Folder      string // Images must be in this folder or below it
+	Camera      string // The camera must contain this, ignoring case
+	Text        string // The text read by OCR must contain this, ignoring case; see MatchesText
+
+	// SimilarColorsTo, when set, keeps only images colored like this one and
+	// orders them by color distance; SimilarTo does the same for images that
+	// look like this one. They are evaluated by the caller, which holds the
+	// image signatures.
+	SimilarColorsTo string
+	SimilarTo       string
+}
+
+// IsEmpty reports whether the criteria restrict nothing.
+func (c Criteria) IsEmpty() bool {
+	return len(c.Tags) == 0 && c.Folder == "" && c.Text == "" && !c.HasPropertyFilters()
+}
+
+// MatchesText reports whether text, read from an image by OCR, contains
+// c.Text ignoring case, or c.Text is unset. The caller, which holds the
+// extracted texts, evaluates it.
+func (c Criteria) MatchesText(text string) bool {
+	return c.Text == "" || strings.Contains(strings.ToLower(text), strings.ToLower(c.Text))
+}
+
+// InFolder reports whether path lies within c.Folder, or c.Folder is unset.
+func (c Criteria) InFolder(path string) bool {
+	if c.Folder == "" {
+		return true
+	}
+	folder := filepath.Clean(c.Folder)
+	if !strings.HasSuffix(folder, string(filepath.Separator)) { // Only a root ends in one
+		folder += string(filepath.Separator)
+	}
+	return strings.HasPrefix(filepath.Clean(path), folder)
+}
+
+// HasPropertyFilters reports whether any non-tag predicate is set.
+func (c Criteria) HasPropertyFilters() bool {
+	return c.needsEXIF() || c.NeedsDimensions() || c.MinSize > 0 || c.MaxSize > 0 || c.SimilarColorsTo != "" || c.SimilarTo != ""
+}
+
+func (c Criteria) hasDateFilter() bool {
+	return !c.From.IsZero() || !c.To.IsZero()
+}
+
+// needsEXIF reports whether a predicate needs the file's EXIF data.
+func (c Criteria) needsEXIF() bool {
+	return c.hasDateFilter() || c.Camera != ""
+}
+
+// NeedsDimensions reports whether a predicate needs the image's width and
+// height, which are costlier to load than the other properties.
+func (c Criteria) NeedsDimensions() bool {
+	return c.MinWidth > 0 || c.MinHeight > 0 || (c.Orientation != "" && c.Orientation != OrientationAny)
+}
+
+// Matches reports whether p satisfies every property predicate. The tag
+// predicates are evaluated separately against the tag database.
+func (c Criteria) Matches(p Properties) bool {
+	if !c.From.IsZero() && p.Date.Before(c.From) {
+		return false
+	}
+	if !c.To.IsZero() && p.Date.After(c.To) {
+		return false
+	}
+	if c.MinWidth > 0 && p.Width < c.MinWidth {
+		return false
+	}
+	if c.MinHeight > 0 && p.Height < c.MinHeight {
+		return false
+	}
+	if c.Orientation != "" && c.Orientation != OrientationAny && OrientationOf(p.Width, p.Height) != c.Orientation {
+		return false
+	}
+	if c.MinSize > 0 && p.Size < c.MinSize {
+		return false
+	}
+	if c.MaxSize > 0 && p.Size > c.MaxSize {
+		return false
+	}
+	if c.Camera != "" && !strings.Contains(strings.ToLower(p.Camera), strings.ToLower(c.Camera)) {
+		return false
+	}
+	return true
+}
+
+// String describes the active criteria for the status bar and info panel.
+func (c Criteria) String() string {
+	var parts []string
+	if len(c.Tags) > 0 {
+		parts = append(parts, strings.Join(c.Tags, " + "))
+	}
+	if c.Folder != "" {
+		parts = append(parts, "in "+c.Folder)
+	}
+	if c.Text != "" {
+		parts = append(parts, fmt.Sprintf("text contains %q", c.Text))
+	}
+	switch {
+	case !c.From.IsZero() && !c.To.IsZero():
+		parts = append(parts, fmt.Sprintf("%s..%s", c.From.Format(dateLayout), c.To.Format(dateLayout)))
+	case !c.From.IsZero():
+		parts = append(parts, "from "+c.From.Format(dateLayout))
+	case !c.To.IsZero():
+		parts = append(parts, "until "+c.To.Format(dateLayout))
+	}
+	if c.MinWidth > 0 || c.MinHeight > 0 {
+		parts = append(parts, fmt.Sprintf("≥%dx%d", c.MinWidth, c.MinHeight))
+	}
+	if c.Orientation != "" && c.Orientation != OrientationAny {
+		parts = append(parts, string(c.Orientation))
+	}
+	if c.MinSize > 0 {
+		parts = append(parts, fmt.Sprintf("≥%d KB", c.MinSize/1024))
+	}
+	if c.MaxSize > 0 {
+		parts = append(parts, fmt.Sprintf("≤%d KB", c.MaxSize/1024))
+	}
+	if c.Camera != "" {
+		parts = append(parts, "camera "+c.Camera)
+	}
+	if c.SimilarColorsTo != "" {
+		parts = append(parts, "colors like "+filepath.Base(c.SimilarColorsTo))
+	}
+	if c.SimilarTo != "" {
+		parts = append(parts, "looks like "+filepath.Base(c.SimilarTo))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Signature returns a key identifying the criteria, equal for criteria that
+// differ only in the order of their tags, e.g. to remember per-filter state.
+// Empty criteria have the signature "".
+func (c Criteria) Signature() string {
+	if c.IsEmpty() {
+		return ""
+	}
+	c.Tags = slices.Sorted(slices.Values(c.Tags))
+	if c.Orientation == OrientationAny {
+		c.Orientation = ""
+	}
+	c.From, c.To = c.From.UTC(), c.To.UTC()
+	data, _ := json.Marshal(c) // Criteria holds nothing json can't encode
+	return string(data)
+}
+
+// LoadProperties gathers the properties of the file at path that c needs. Only
+// the cheap stat data is used unless a date, camera or dimension predicate is set.
+func (c Criteria) LoadProperties(path string, info fs.FileInfo) (Properties, error) {
+	var p Properties
+	if info == nil {
+		var err error
+		if info, err = os.Stat(path); err != nil {
+			return p, fmt.Errorf("stat %s: %w", path, err)
+		}
+	}
+	p.Size = info.Size()
+	p.Date = info.ModTime()
+
+	if !c.needsEXIF() && !c.NeedsDimensions() {
+		return p, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return p, fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if c.needsEXIF() {
+		if x, err := exif.Decode(file); err == nil {
+			info := autotag.InfoFrom(x)
+			if !info.Taken.IsZero() {
+				p.Date = info.Taken
+			}
+			p.Camera = info.Camera
+		}
+		if _, err := file.Seek(0, 0); err != nil {
+			return p, fmt.Errorf("rewinding %s: %w", path, err)
+		}
+	}
+	if c.NeedsDimensions() {
+		cfg, _, err := image.DecodeConfig(file)
+		if err != nil {
+			return p, fmt.Errorf("reading dimensions of %s: %w", path, err)
+		}
+		p.Width, p.Height = cfg.Width, cfg.Height
+	}
+	return p, nil
+}
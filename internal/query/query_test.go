@@ -0,0 +1,187 @@
+package query
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOrientationOf(t *testing.T) {
+	tests := []struct {
+		w, h int
+		want Orientation
+	}{
+		{400, 300, OrientationLandscape},
+		{300, 400, OrientationPortrait},
+		{300, 300, OrientationSquare},
+		{1000, 980, OrientationSquare},
+		{0, 100, OrientationAny},
+	}
+	for _, tt := range tests {
+		if got := OrientationOf(tt.w, tt.h); got != tt.want {
+			t.Errorf("OrientationOf(%d, %d) = %s, want %s", tt.w, tt.h, got, tt.want)
+		}
+	}
+}
+
+func TestCriteriaMatches(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	photo := Properties{Date: day("2023-06-15"), Width: 4000, Height: 3000, Size: 2 << 20, Camera: "Pixel 7 Pro"}
+
+	tests := []struct {
+		name string
+		c    Criteria
+		want bool
+	}{
+		{"empty", Criteria{}, true},
+		{"inside date range", Criteria{From: day("2023-01-01"), To: day("2023-12-31")}, true},
+		{"before range", Criteria{From: day("2024-01-01")}, false},
+		{"after range", Criteria{To: day("2022-12-31")}, false},
+		{"resolution met", Criteria{MinWidth: 1920, MinHeight: 1080}, true},
+		{"resolution too small", Criteria{MinWidth: 5000}, false},
+		{"orientation matches", Criteria{Orientation: OrientationLandscape}, true},
+		{"orientation differs", Criteria{Orientation: OrientationPortrait}, false},
+		{"size in range", Criteria{MinSize: 1 << 20, MaxSize: 4 << 20}, true},
+		{"too large", Criteria{MaxSize: 1 << 20}, false},
+		{"too small", Criteria{MinSize: 4 << 20}, false},
+		{"camera matches", Criteria{Camera: "pixel 7"}, true},
+		{"camera differs", Criteria{Camera: "Canon"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.c.Matches(photo); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCriteriaIsEmpty(t *testing.T) {
+	if !(Criteria{Orientation: OrientationAny}).IsEmpty() {
+		t.Error("criteria with only OrientationAny should be empty")
+	}
+	if (Criteria{Tags: []string{"cats"}}).IsEmpty() {
+		t.Error("tag criteria should not be empty")
+	}
+	if (Criteria{Tags: []string{"cats"}}).HasPropertyFilters() {
+		t.Error("tag-only criteria should have no property filters")
+	}
+	if !(Criteria{MaxSize: 10}).HasPropertyFilters() {
+		t.Error("size criteria should have property filters")
+	}
+	similar := Criteria{SimilarTo: "/photos/beach.jpg"}
+	if similar.IsEmpty() || !similar.HasPropertyFilters() {
+		t.Error("similarity criteria should not be empty")
+	}
+	if got, want := similar.String(), "looks like beach.jpg"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestCriteriaSignature(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	a := Criteria{Tags: []string{"cats", "dogs"}, From: day, Orientation: OrientationAny}
+	b := Criteria{Tags: []string{"dogs", "cats"}, From: day.In(time.FixedZone("X", 3600))}
+	if a.Signature() != b.Signature() {
+		t.Errorf("Equivalent criteria have different signatures: %s and %s", a.Signature(), b.Signature())
+	}
+	for _, other := range []Criteria{
+		{Tags: []string{"cats"}, From: day},
+		{Tags: []string{"cats", "dogs"}, To: day},
+		{Tags: []string{"cats", "dogs"}, From: day, SimilarTo: "/x.jpg"},
+	} {
+		if other.Signature() == a.Signature() {
+			t.Errorf("Different criteria %v share the signature %s", other, a.Signature())
+		}
+	}
+	if got := (Criteria{Tags: []string{}, Orientation: OrientationAny}).Signature(); got != "" {
+		t.Errorf("Empty criteria signature = %q, want \"\"", got)
+	}
+}
+
+func TestCriteriaInFolder(t *testing.T) {
+	c := Criteria{Folder: "/photos/2024/"}
+	for path, want := range map[string]bool{
+		"/photos/2024/a.jpg":         true,
+		"/photos/2024/trip/b.jpg":    true,
+		"/photos/2024-old/c.jpg":     false,
+		"/photos/d.jpg":              false,
+		"/photos/2024/../2023/e.jpg": false,
+	} {
+		if got := c.InFolder(path); got != want {
+			t.Errorf("InFolder(%s) = %v, want %v", path, got, want)
+		}
+	}
+	if !(Criteria{Folder: "/"}).InFolder("/a.jpg") {
+		t.Error("InFolder of the root folder is false")
+	}
+	if c.IsEmpty() || c.HasPropertyFilters() {
+		t.Error("A folder filter should be non-empty without property filters")
+	}
+}
+
+func TestCriteriaMatchesText(t *testing.T) {
+	c := Criteria{Text: "invoice"}
+	if !c.MatchesText("ACME Corp\nINVOICE No. 1042") || c.MatchesText("Receipt") || c.MatchesText("") {
+		t.Error("MatchesText should find the text ignoring case")
+	}
+	if !(Criteria{}).MatchesText("") {
+		t.Error("criteria without text should match any image")
+	}
+	if c.IsEmpty() || c.HasPropertyFilters() {
+		t.Error("A text filter should be non-empty without property filters")
+	}
+	if got, want := c.String(), `text contains "invoice"`; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	c, err := Parse("tag=Family, tag=beach ,folder=/photos/2023, from=2023-06-01, to=2023-06-30, orientation=Landscape, min-size=100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(c.Tags, []string{"family", "beach"}) || c.Folder != "/photos/2023" || c.Orientation != OrientationLandscape || c.MinSize != 100*1024 {
+		t.Errorf("Parse = %+v", c)
+	}
+	june30 := time.Date(2023, 6, 30, 23, 0, 0, 0, time.Local)
+	if c.From.Day() != 1 || c.To.Before(june30) {
+		t.Errorf("dates %v..%v, want all of June", c.From, c.To)
+	}
+	if c, err := Parse(""); err != nil || !c.IsEmpty() {
+		t.Errorf(`Parse("") = %+v, %v, want empty criteria`, c, err)
+	}
+	for _, bad := range []string{"family", "tag=", "album=best", "from=June", "orientation=round", "min-width=-1"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) accepted it", bad)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	c, err := Parse(`tag=family, folder=/photos/Rome\, Italy, from=2023-06-01, to=2023-06-30, min-width=1920, max-size=500, looks-like=/photos/a.jpg`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Folder != "/photos/Rome, Italy" {
+		t.Errorf("escaped comma: folder = %q", c.Folder)
+	}
+	if c, err := Parse(`folder=C:\Photos\2023`); err != nil || c.Folder != `C:\Photos\2023` {
+		t.Errorf("Windows path: folder = %q, %v", c.Folder, err)
+	}
+	formatted := Format(c)
+	again, err := Parse(formatted)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", formatted, err)
+	}
+	if again.Signature() != c.Signature() || !again.To.Equal(c.To) {
+		t.Errorf("Parse(Format(c)) = %+v, want %+v", again, c)
+	}
+	if Format(Criteria{}) != "" {
+		t.Errorf("Format of empty criteria = %q", Format(Criteria{}))
+	}
+}
@@ -0,0 +1,279 @@
+// Package scan Cache persists directory listings between launches so unchanged
+// directories don't need to be re-read on startup.
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"fyslide/internal/paths"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	cacheFileName = "fyslide_scan.db"
+	dirsBucket    = "Dirs"
+	// cacheLayout is the version of the persisted listings; listings of
+	// another layout are read again.
+	cacheLayout = 1
+)
+
+// Cache stores, per directory, its modification time and the image files and
+// subdirectories it contained when last read.
+type Cache struct {
+	db *bolt.DB
+}
+
+// cachedEntry is an image file or subdirectory of a cached listing; for a
+// file, the persisted subset of its FileInfo.
+type cachedEntry struct {
+	Name    string `json:"name"`
+	Dir     bool   `json:"dir,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+	ModTime int64  `json:"mtime,omitempty"` // Unix nanoseconds
+}
+
+// cachedDir is the persisted listing of one directory. Its files and
+// subdirectories are kept together in name order, the order Run visits them
+// in, so a cached walk sends the images in the same order.
+type cachedDir struct {
+	ModTime int64         `json:"mtime"` // Unix nanoseconds of the directory itself
+	Layout  int           `json:"layout"`
+	Entries []cachedEntry `json:"entries"`
+}
+
+// cachedFileInfo implements fs.FileInfo for files served from the cache.
+type cachedFileInfo struct {
+	f cachedEntry
+}
+
+func (ci cachedFileInfo) Name() string       { return ci.f.Name }
+func (ci cachedFileInfo) Size() int64        { return ci.f.Size }
+func (ci cachedFileInfo) Mode() fs.FileMode  { return 0644 }
+func (ci cachedFileInfo) ModTime() time.Time { return time.Unix(0, ci.f.ModTime) }
+func (ci cachedFileInfo) IsDir() bool        { return false }
+func (ci cachedFileInfo) Sys() any           { return nil }
+
+// OpenCache opens or creates the scan cache in cacheDir. An empty cacheDir uses
+// the per-user cache directory, paths.CacheDir.
+func OpenCache(cacheDir string) (*Cache, error) {
+	if cacheDir == "" {
+		dir, err := paths.CacheDir()
+		if err != nil {
+			return nil, err
+		}
+		cacheDir = dir
+	}
+	if err := os.MkdirAll(cacheDir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %s: %w", cacheDir, err)
+	}
+
+	cachePath := filepath.Join(cacheDir, cacheFileName)
+	db, err := bolt.Open(cachePath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open scan cache %s: %w", cachePath, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(dirsBucket))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create scan cache bucket: %w", err)
+	}
+	return &Cache{db: db}, nil
+}
+
+// Close closes the underlying database.
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+// load returns every cached directory listing under root.
+func (c *Cache) load(root string) (map[string]cachedDir, error) {
+	dirs := make(map[string]cachedDir)
+	err := c.db.View(func(tx *bolt.Tx) error {
+		cur := tx.Bucket([]byte(dirsBucket)).Cursor()
+		prefix := []byte(root)
+		for k, v := cur.Seek(prefix); k != nil && strings.HasPrefix(string(k), root); k, v = cur.Next() {
+			if !isWithin(root, string(k)) {
+				continue // Sibling sharing a name prefix, e.g. /photos2 for /photos
+			}
+			var entry cachedDir
+			if err := json.Unmarshal(v, &entry); err != nil || entry.Layout != cacheLayout {
+				continue // Treat a damaged or outdated entry as a miss
+			}
+			dirs[string(k)] = entry
+		}
+		return nil
+	})
+	return dirs, err
+}
+
+// store replaces the cached listings under root with dirs.
+func (c *Cache) store(root string, dirs map[string]cachedDir) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(dirsBucket))
+		cur := b.Cursor()
+		var stale [][]byte
+		for k, _ := cur.Seek([]byte(root)); k != nil && strings.HasPrefix(string(k), root); k, _ = cur.Next() {
+			if _, ok := dirs[string(k)]; !ok && isWithin(root, string(k)) {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		for dir, entry := range dirs {
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(dir), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// isWithin reports whether path is root or inside it.
+func isWithin(root, path string) bool {
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}
+
+// RunCached is like Run, but serves directories whose modification time is
+// unchanged from the cache instead of listing them again. Only directory
+// mtimes are checked, so a file rewritten in place keeps its cached size and
+// mtime until its directory changes. fullRescan ignores the cache and rebuilds it.
+func RunCached(dir string, cache *Cache, fullRescan bool, logger LoggerFunc) <-chan FileItem {
+	return RunCachedContext(context.Background(), dir, cache, fullRescan, logger)
+}
+
+// RunCachedContext is like RunCached, but stops scanning and closes the
+// channel once ctx is cancelled. The cache keeps its listings from before then,
+// as those of an unfinished walk are incomplete.
+func RunCachedContext(ctx context.Context, dir string, cache *Cache, fullRescan bool, logger LoggerFunc) <-chan FileItem {
+	if cache == nil {
+		return RunContext(ctx, dir, logger)
+	}
+	out := make(chan FileItem, 100)
+
+	logMsg := func(format string, args ...interface{}) {
+		if logger != nil {
+			logger(fmt.Sprintf(format, args...))
+		} else {
+			log.Printf(format, args...) // Fallback
+		}
+	}
+
+	go func() {
+		defer close(out)
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			logMsg("Scan: Error getting absolute path for %s: %v. Aborting scan.", dir, err)
+			return
+		}
+
+		cached := map[string]cachedDir{}
+		if !fullRescan {
+			if cached, err = cache.load(absDir); err != nil {
+				logMsg("Scan: Error reading scan cache, rescanning: %v", err)
+				cached = map[string]cachedDir{}
+			}
+		}
+
+		s := &cachedScan{ctx: ctx, cached: cached, fresh: make(map[string]cachedDir), out: out, logMsg: logMsg}
+		s.walk(absDir)
+		if ctx.Err() != nil {
+			return
+		}
+		logMsg("Scan: %d directories from cache, %d re-read.", s.hits, s.misses)
+
+		if err := cache.store(absDir, s.fresh); err != nil {
+			logMsg("Scan: Error saving scan cache: %v", err)
+		}
+	}()
+	return out
+}
+
+// cachedScan holds the state of one RunCached walk.
+type cachedScan struct {
+	ctx          context.Context // Stops the walk once cancelled
+	cached       map[string]cachedDir
+	fresh        map[string]cachedDir // Listings to persist once the walk completes
+	out          chan<- FileItem
+	logMsg       func(format string, args ...interface{})
+	hits, misses int
+}
+
+// walk emits the images in dir and recurses into its subdirectories, in name
+// order like filepath.WalkDir.
+func (s *cachedScan) walk(dir string) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		s.logMsg("Scan: Error accessing path %q: %v", dir, err)
+		return
+	}
+
+	entry, ok := s.cached[dir]
+	if ok && entry.ModTime == info.ModTime().UnixNano() {
+		s.hits++
+	} else {
+		s.misses++
+		if entry, err = readDir(dir, info); err != nil {
+			s.logMsg("Scan: Error accessing path %q: %v", dir, err)
+			return // Skip problematic directory
+		}
+	}
+	s.fresh[dir] = entry
+
+	for _, e := range entry.Entries {
+		if e.Dir {
+			if s.ctx.Err() != nil {
+				return
+			}
+			s.walk(filepath.Join(dir, e.Name))
+			continue
+		}
+		select {
+		case s.out <- NewFileItem(filepath.Join(dir, e.Name), cachedFileInfo{f: e}):
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// readDir lists dir from disk, keeping non-empty image files and
+// subdirectories in name order, as os.ReadDir returns them.
+func readDir(dir string, info fs.FileInfo) (cachedDir, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return cachedDir{}, err
+	}
+	listing := cachedDir{ModTime: info.ModTime().UnixNano(), Layout: cacheLayout}
+	for _, d := range entries {
+		if d.IsDir() {
+			listing.Entries = append(listing.Entries, cachedEntry{Name: d.Name(), Dir: true})
+			continue
+		}
+		if !isImage(d.Name()) {
+			continue
+		}
+		fi, err := d.Info()
+		if err != nil || fi.Size() == 0 { // Skip unreadable and empty files, as Run does
+			continue
+		}
+		listing.Entries = append(listing.Entries, cachedEntry{Name: d.Name(), Size: fi.Size(), ModTime: fi.ModTime().UnixNano()})
+	}
+	return listing, nil
+}
@@ -0,0 +1,164 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+// collectPaths drains items and returns the sorted paths.
+func collectPaths(t *testing.T, items <-chan FileItem) []string {
+	t.Helper()
+	paths := scanOrder(t, items)
+	sort.Strings(paths)
+	return paths
+}
+
+// scanOrder drains items and returns the paths in the order they were sent.
+func scanOrder(t *testing.T, items <-chan FileItem) []string {
+	t.Helper()
+	var paths []string
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case item, ok := <-items:
+			if !ok {
+				return paths
+			}
+			paths = append(paths, item.Path)
+		case <-timeout:
+			t.Fatal("timed out waiting for scan results")
+			return nil
+		}
+	}
+}
+
+func TestRunCached(t *testing.T) {
+	rootDir := t.TempDir()
+	subDir := filepath.Join(rootDir, "sub")
+	if err := os.Mkdir(subDir, 0755); err != nil {
+		t.Fatalf("Failed to create sub dir: %v", err)
+	}
+	writeFile := func(path string) {
+		if err := os.WriteFile(path, []byte("a"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	writeFile(filepath.Join(rootDir, "a.png"))
+	writeFile(filepath.Join(subDir, "b.jpg"))
+	writeFile(filepath.Join(subDir, "notes.txt"))
+
+	cache, err := OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenCache failed: %v", err)
+	}
+	defer cache.Close()
+	testLogger := func(message string) { t.Logf("ScanTestLogger: %s", message) }
+
+	first := collectPaths(t, RunCached(rootDir, cache, false, testLogger))
+	if len(first) != 2 {
+		t.Fatalf("first scan found %v, want 2 images", first)
+	}
+
+	// A second scan with nothing changed must return the same result from the cache.
+	second := collectPaths(t, RunCached(rootDir, cache, false, testLogger))
+	if len(second) != len(first) || second[0] != first[0] || second[1] != first[1] {
+		t.Errorf("cached scan found %v, want %v", second, first)
+	}
+
+	// Adding a file changes the directory mtime, so the listing is re-read.
+	newFile := filepath.Join(subDir, "c.gif")
+	writeFile(newFile)
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(subDir, future, future); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+	third := collectPaths(t, RunCached(rootDir, cache, false, testLogger))
+	if len(third) != 3 {
+		t.Errorf("scan after change found %v, want 3 images", third)
+	}
+
+	// Removing the sub directory drops its images and its cache entry.
+	if err := os.RemoveAll(subDir); err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+	later := time.Now().Add(2 * time.Minute)
+	if err := os.Chtimes(rootDir, later, later); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+	fourth := collectPaths(t, RunCached(rootDir, cache, false, testLogger))
+	if len(fourth) != 1 {
+		t.Errorf("scan after removal found %v, want 1 image", fourth)
+	}
+	dirs, err := cache.load(rootDir)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if _, ok := dirs[subDir]; ok {
+		t.Errorf("cache still holds removed directory %s", subDir)
+	}
+}
+
+func TestRunCachedOrder(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, path := range []string{"a/b/x.jpg", "a/c.jpg", "a/a.png", "b.png", "a/d/y.gif", "c/z.jpg"} {
+		path = filepath.Join(rootDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("a"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	cache, err := OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenCache failed: %v", err)
+	}
+	defer cache.Close()
+	testLogger := func(message string) { t.Logf("ScanTestLogger: %s", message) }
+
+	want := scanOrder(t, Run(rootDir, testLogger))
+	if len(want) != 6 {
+		t.Fatalf("Run found %v, want 6 images", want)
+	}
+	for _, run := range []string{"listing", "cached"} {
+		if got := scanOrder(t, RunCached(rootDir, cache, false, testLogger)); !slices.Equal(got, want) {
+			t.Errorf("%s scan order = %v, want Run's %v", run, got, want)
+		}
+	}
+}
+
+func TestRunCachedContextCancelled(t *testing.T) {
+	rootDir := t.TempDir()
+	const files = 150 // More than the channel holds, so the walk waits for the reader
+	for i := range files {
+		if err := os.WriteFile(filepath.Join(rootDir, fmt.Sprintf("%03d.png", i)), []byte("a"), 0644); err != nil {
+			t.Fatalf("Failed to write image: %v", err)
+		}
+	}
+	cache, err := OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenCache failed: %v", err)
+	}
+	defer cache.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	items := RunCachedContext(ctx, rootDir, cache, false, func(message string) { t.Logf("ScanTestLogger: %s", message) })
+	<-items
+	cancel()
+	if got := collectPaths(t, items); len(got) >= files-1 {
+		t.Errorf("cancelled scan sent %d more images, want it to stop", len(got))
+	}
+	dirs, err := cache.load(rootDir)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(dirs) != 0 {
+		t.Errorf("cancelled scan cached %d directories, want none", len(dirs))
+	}
+}
@@ -0,0 +1,58 @@
+package scan
+
+import (
+	"slices"
+	"strings"
+)
+
+// ListDiff is how a fresh scan differs from an earlier list of images.
+type ListDiff struct {
+	Added   FileItems // In the fresh scan only
+	Removed FileItems // In the earlier list only
+	Changed FileItems // In both but with another size or modification time, as found by the fresh scan
+}
+
+// IsEmpty reports whether nothing changed.
+func (d ListDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares the images of a fresh scan with those listed before, by path.
+// Each part of the result is sorted by path.
+func Diff(before, fresh FileItems) ListDiff {
+	old := make(map[string]FileItem, len(before))
+	for _, item := range before {
+		old[item.Path] = item
+	}
+	var d ListDiff
+	seen := make(map[string]bool, len(fresh))
+	for _, item := range fresh {
+		seen[item.Path] = true
+		prev, ok := old[item.Path]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, item)
+		case modified(prev, item):
+			d.Changed = append(d.Changed, item)
+		}
+	}
+	for _, item := range before {
+		if !seen[item.Path] {
+			d.Removed = append(d.Removed, item)
+		}
+	}
+	byPath := func(a, b FileItem) int { return strings.Compare(a.Path, b.Path) }
+	slices.SortFunc(d.Added, byPath)
+	slices.SortFunc(d.Removed, byPath)
+	slices.SortFunc(d.Changed, byPath)
+	return d
+}
+
+// modified reports whether the file of item changed since prev was listed.
+// Items without file info are taken as unchanged.
+func modified(prev, item FileItem) bool {
+	if prev.Info == nil || item.Info == nil {
+		return false
+	}
+	return prev.Info.Size() != item.Info.Size() || !prev.Info.ModTime().Equal(item.Info.ModTime())
+}
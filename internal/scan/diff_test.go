@@ -0,0 +1,40 @@
+package scan
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).UnixNano()
+	item := func(path string, size int64, mtime int64) FileItem {
+		return NewFileItem(path, cachedFileInfo{f: cachedEntry{Name: path, Size: size, ModTime: mtime}})
+	}
+	before := FileItems{item("/p/a.jpg", 10, at), item("/p/b.jpg", 20, at), item("/p/c.jpg", 30, at), item("/p/d.jpg", 40, at), {Path: "/p/pasted.jpg"}}
+	fresh := FileItems{item("/p/e.jpg", 50, at), item("/p/d.jpg", 40, at+1), item("/p/b.jpg", 21, at), item("/p/a.jpg", 10, at), item("/p/pasted.jpg", 5, at), item("/p/0.jpg", 1, at)}
+
+	d := Diff(before, fresh)
+	paths := func(items FileItems) []string {
+		var p []string
+		for _, item := range items {
+			p = append(p, item.Path)
+		}
+		return p
+	}
+	check := func(name string, got FileItems, want ...string) {
+		t.Helper()
+		if p := paths(got); !slices.Equal(p, want) {
+			t.Errorf("%s = %v, want %v", name, p, want)
+		}
+	}
+	check("Added", d.Added, "/p/0.jpg", "/p/e.jpg")
+	check("Removed", d.Removed, "/p/c.jpg")
+	check("Changed", d.Changed, "/p/b.jpg", "/p/d.jpg") // Not pasted.jpg, listed without file info
+	if d.Changed[0].Info.Size() != 21 {
+		t.Errorf("changed items must carry the fresh file info, size %d", d.Changed[0].Info.Size())
+	}
+	if d.IsEmpty() || !Diff(fresh, fresh).IsEmpty() {
+		t.Error("IsEmpty is wrong")
+	}
+}
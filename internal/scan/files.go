@@ -2,6 +2,8 @@
 package scan
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
@@ -31,8 +33,8 @@ func NewFileItem(path string, fi fs.FileInfo) FileItem {
 }
 
 // findImageFiles recursively scans dir for image files and sends them to the out channel.
-// It closes the out channel when done.
-func findImageFiles(dir string, out chan<- FileItem, logger LoggerFunc) {
+// It closes the out channel when done or when ctx is cancelled.
+func findImageFiles(ctx context.Context, dir string, out chan<- FileItem, logger LoggerFunc) {
 	defer close(out) // Ensure channel is closed when WalkDir finishes or panics
 
 	logMsg := func(format string, args ...interface{}) {
@@ -60,13 +62,17 @@ func findImageFiles(dir string, out chan<- FileItem, logger LoggerFunc) {
 				return nil // Skip this file
 			}
 			if info.Size() > 0 { // Ensure it's not an empty file
-				out <- NewFileItem(path, info)
+				select {
+				case out <- NewFileItem(path, info):
+				case <-ctx.Done():
+					return ctx.Err() // Nobody reads the rest
+				}
 			}
 		}
 		return nil
 	})
 
-	if err != nil {
+	if err != nil && !errors.Is(err, ctx.Err()) {
 		// Log the error from WalkDir itself, if any.
 		// The channel will still be closed by defer.
 		logMsg("Scan: Error walking directory %s: %v", dir, err)
@@ -76,6 +82,12 @@ func findImageFiles(dir string, out chan<- FileItem, logger LoggerFunc) {
 // Run is the entry point for the package. It now returns a channel
 // from which FileItems can be read. The scanning happens in a new goroutine.
 func Run(dir string, logger LoggerFunc) <-chan FileItem {
+	return RunContext(context.Background(), dir, logger)
+}
+
+// RunContext is like Run, but stops scanning and closes the channel once ctx
+// is cancelled, so a reader may stop reading then.
+func RunContext(ctx context.Context, dir string, logger LoggerFunc) <-chan FileItem {
 	out := make(chan FileItem, 100) // Buffered channel for some decoupling
 
 	logMsg := func(format string, args ...interface{}) {
@@ -93,12 +105,17 @@ func Run(dir string, logger LoggerFunc) <-chan FileItem {
 			close(out) // Close channel to signal error and stop processing
 			return     // Do not proceed with findImageFiles
 		}
-		findImageFiles(absDir, out, logger)
+		findImageFiles(ctx, absDir, out, logger)
 	}()
 
 	return out
 }
 
+// IsImage reports whether fileName has a supported image extension.
+func IsImage(fileName string) bool {
+	return isImage(fileName)
+}
+
 func isImage(fileName string) bool {
 	switch strings.ToLower(filepath.Ext(fileName)) {
 	case ".png", ".jpg", ".jpeg", ".gif":
@@ -0,0 +1,91 @@
+package scan
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// HasGlobMeta reports whether pattern contains glob metacharacters.
+func HasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[`)
+}
+
+// Glob returns the absolute paths of the supported images matching pattern,
+// sorted. Besides the wildcards of filepath.Match, a "**" path segment matches
+// any number of directories, as in "photos/2024/**/*.jpg". Only the directory
+// below the pattern's literal prefix is walked, symlinks to directories are not
+// followed, and a malformed pattern is an error rather than matching nothing.
+func Glob(pattern string) ([]string, error) {
+	absPattern, err := filepath.Abs(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("error getting absolute path for %s: %w", pattern, err)
+	}
+	segments := strings.Split(filepath.ToSlash(absPattern), "/")
+	for _, seg := range segments {
+		if _, err := filepath.Match(seg, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+	}
+	literal := 0
+	for literal < len(segments) && !HasGlobMeta(segments[literal]) {
+		literal++
+	}
+	if literal == len(segments) {
+		return nil, fmt.Errorf("%s is not a pattern", pattern)
+	}
+	root := filepath.FromSlash(strings.Join(segments[:literal], "/"))
+	if root == "" {
+		root = string(filepath.Separator)
+	}
+	rest := segments[literal:]
+
+	var matches []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil // Skip unreadable subdirectories
+		}
+		if d.IsDir() || !d.Type().IsRegular() || !isImage(path) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		if matchSegments(rest, strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error expanding %s: %w", pattern, err)
+	}
+	slices.Sort(matches)
+	return matches, nil
+}
+
+// matchSegments matches path segments against pattern segments, where "**"
+// matches zero or more segments.
+func matchSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	ok, _ := filepath.Match(pattern[0], path[0])
+	return ok && matchSegments(pattern[1:], path[1:])
+}
@@ -0,0 +1,56 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.jpg", "b.png", "notes.txt", "2024/x.jpg", "2024/trip/y.JPG", "2024/trip/deep/z.jpg", "2023/w.jpg"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	abs := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, filepath.FromSlash(name)))
+		}
+		return paths
+	}
+	for pattern, want := range map[string][]string{
+		"*":              abs("a.jpg", "b.png"),
+		"*.jpg":          abs("a.jpg"),
+		"2024/**/*.jpg":  abs("2024/trip/deep/z.jpg", "2024/x.jpg"),
+		"2024/**/*.JPG":  abs("2024/trip/y.JPG"),
+		"**/?.jpg":       abs("2023/w.jpg", "2024/trip/deep/z.jpg", "2024/x.jpg", "a.jpg"),
+		"202[3]/*":       abs("2023/w.jpg"),
+		"2024/*/deep/**": abs("2024/trip/deep/z.jpg"),
+		"1999/*.jpg":     nil,
+	} {
+		got, err := Glob(filepath.Join(dir, pattern))
+		if pattern == "1999/*.jpg" {
+			if err == nil {
+				t.Errorf("Glob(%s) under a missing directory should fail", pattern)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Glob(%s) failed: %v", pattern, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Glob(%s) = %v, want %v", pattern, got, want)
+		}
+	}
+	if _, err := Glob(filepath.Join(dir, "[.jpg")); err == nil {
+		t.Error("a malformed pattern should be an error")
+	}
+}
@@ -0,0 +1,249 @@
+// Package search provides an in-memory substring index over image file names,
+// directory paths, tags and text extracted by OCR. The index is updated incrementally as images are
+// tagged, renamed or deleted so searches never touch the disk or database.
+package search
+
+import (
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Field identifies which part of an entry a search term matched.
+type Field string
+
+// Searchable fields.
+const (
+	FieldName Field = "name"
+	FieldPath Field = "path"
+	FieldTags Field = "tags"
+	FieldText Field = "text"
+)
+
+// snippetRadius is how many characters of text a Snippet shows around a match.
+const snippetRadius = 40
+
+// entry holds the lower-cased searchable text of one image.
+type entry struct {
+	name string
+	dir  string
+	tags []string
+	text string
+}
+
+// Result is one matching image.
+type Result struct {
+	Path    string
+	Tags    []string // Tags of the image, as indexed
+	Matched []Field  // Fields that matched at least one term, in Field order
+	Snippet string   // Text around the first term found in the image's text, when FieldText matched
+}
+
+// Index is a concurrency-safe search index keyed by image path.
+type Index struct {
+	mu      sync.RWMutex
+	entries map[string]*entry
+	tags    map[string][]string // Original (display) tags per path
+	texts   map[string]string   // Original text per path, for snippets
+}
+
+// New returns an empty index.
+func New() *Index {
+	return &Index{
+		entries: make(map[string]*entry),
+		tags:    make(map[string][]string),
+		texts:   make(map[string]string),
+	}
+}
+
+func newEntry(path string, tags []string, text string) *entry {
+	e := &entry{
+		name: strings.ToLower(filepath.Base(path)),
+		dir:  strings.ToLower(filepath.Dir(path)),
+		tags: make([]string, len(tags)),
+		text: strings.ToLower(text),
+	}
+	for i, t := range tags {
+		e.tags[i] = strings.ToLower(t)
+	}
+	return e
+}
+
+// Set adds path to the index or replaces its tags, keeping its text.
+func (idx *Index) Set(path string, tags []string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.entries[path] = newEntry(path, tags, idx.texts[path])
+	idx.tags[path] = append([]string(nil), tags...)
+}
+
+// UpdateTags replaces the tags of path if it is indexed. Paths outside the
+// index are ignored so tag changes can't pull unscanned images into results.
+func (idx *Index) UpdateTags(path string, tags []string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if _, ok := idx.entries[path]; !ok {
+		return
+	}
+	idx.entries[path] = newEntry(path, tags, idx.texts[path])
+	idx.tags[path] = append([]string(nil), tags...)
+}
+
+// SetText replaces the text of path, such as that read by OCR, if it is
+// indexed; "" removes it.
+func (idx *Index) SetText(path, text string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if _, ok := idx.entries[path]; !ok {
+		return
+	}
+	if text == "" {
+		delete(idx.texts, path)
+	} else {
+		idx.texts[path] = text
+	}
+	idx.entries[path] = newEntry(path, idx.tags[path], text)
+}
+
+// Remove drops path from the index.
+func (idx *Index) Remove(path string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	delete(idx.entries, path)
+	delete(idx.tags, path)
+	delete(idx.texts, path)
+}
+
+// Rename moves the entry for oldPath to newPath, keeping its tags and text.
+func (idx *Index) Rename(oldPath, newPath string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	tags, ok := idx.tags[oldPath]
+	if !ok {
+		return
+	}
+	text, hasText := idx.texts[oldPath]
+	delete(idx.entries, oldPath)
+	delete(idx.tags, oldPath)
+	delete(idx.texts, oldPath)
+	idx.entries[newPath] = newEntry(newPath, tags, text)
+	idx.tags[newPath] = tags
+	if hasText {
+		idx.texts[newPath] = text
+	}
+}
+
+// Len returns the number of indexed images.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.entries)
+}
+
+// Search returns the images for which every whitespace-separated term of query
+// is a case-insensitive substring of the file name, directory path, a tag or
+// the text.
+// Results are sorted by path; at most limit are returned when limit > 0.
+func (idx *Index) Search(query string, limit int) []Result {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	var results []Result
+	for path, e := range idx.entries {
+		if matched, ok := e.match(terms); ok {
+			r := Result{Path: path, Tags: idx.tags[path], Matched: matched}
+			if slices.Contains(matched, FieldText) {
+				r.Snippet = snippet(idx.texts[path], e.text, terms)
+			}
+			results = append(results, r)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// match reports whether every term occurs in some field and which fields matched.
+func (e *entry) match(terms []string) ([]Field, bool) {
+	var inName, inPath, inTags, inText bool
+	for _, term := range terms {
+		found := false
+		if strings.Contains(e.name, term) {
+			inName, found = true, true
+		}
+		if strings.Contains(e.dir, term) {
+			inPath, found = true, true
+		}
+		for _, tag := range e.tags {
+			if strings.Contains(tag, term) {
+				inTags, found = true, true
+				break
+			}
+		}
+		if strings.Contains(e.text, term) {
+			inText, found = true, true
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	var matched []Field
+	if inName {
+		matched = append(matched, FieldName)
+	}
+	if inPath {
+		matched = append(matched, FieldPath)
+	}
+	if inTags {
+		matched = append(matched, FieldTags)
+	}
+	if inText {
+		matched = append(matched, FieldText)
+	}
+	return matched, true
+}
+
+// snippet returns the line of text, cut to snippetRadius characters either
+// side, around the first term found in lower, its lower-cased form.
+func snippet(text, lower string, terms []string) string {
+	if len(lower) != len(text) { // Lower-casing changed the length, so offsets don't carry over
+		return ""
+	}
+	at := -1
+	for _, term := range terms {
+		if at = strings.Index(lower, term); at >= 0 {
+			break
+		}
+	}
+	if at < 0 {
+		return ""
+	}
+	start := strings.LastIndex(text[:at], "\n") + 1
+	end := len(text)
+	if i := strings.IndexByte(text[at:], '\n'); i >= 0 {
+		end = at + i
+	}
+	prefix, suffix := "", ""
+	if at-start > snippetRadius {
+		start, prefix = at-snippetRadius, "…"
+	}
+	if end-at > 2*snippetRadius {
+		end, suffix = at+2*snippetRadius, "…"
+	}
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start++
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return prefix + strings.TrimSpace(text[start:end]) + suffix
+}
@@ -0,0 +1,116 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func paths(results []Result) []string {
+	var out []string
+	for _, r := range results {
+		out = append(out, r.Path)
+	}
+	return out
+}
+
+func TestSearch(t *testing.T) {
+	idx := New()
+	idx.Set("/photos/2023/Beach.jpg", []string{"holiday", "sea"})
+	idx.Set("/photos/2024/cat.png", []string{"pets"})
+	idx.Set("/scans/holiday-card.jpg", nil)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"beach", []string{"/photos/2023/Beach.jpg"}},
+		{"HOLIDAY", []string{"/photos/2023/Beach.jpg", "/scans/holiday-card.jpg"}},
+		{"2024", []string{"/photos/2024/cat.png"}},
+		{"holiday sea", []string{"/photos/2023/Beach.jpg"}}, // Every term must match
+		{"holiday pets", nil},
+		{"   ", nil},
+	}
+	for _, tt := range tests {
+		if got := paths(idx.Search(tt.query, 0)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	if got := idx.Search("photos", 1); len(got) != 1 {
+		t.Errorf("Search with limit 1 returned %d results", len(got))
+	}
+	got := idx.Search("holiday", 0)
+	if want := []Field{FieldTags}; !reflect.DeepEqual(got[0].Matched, want) {
+		t.Errorf("Matched fields = %v, want %v", got[0].Matched, want)
+	}
+}
+
+func TestIndexUpdates(t *testing.T) {
+	idx := New()
+	idx.Set("/a/one.jpg", []string{"red"})
+
+	idx.Set("/a/one.jpg", []string{"blue"})
+	if got := idx.Search("red", 0); len(got) != 0 {
+		t.Errorf("stale tag still matches after Set: %v", paths(got))
+	}
+
+	idx.UpdateTags("/elsewhere/x.jpg", []string{"blue"})
+	if idx.Len() != 1 {
+		t.Errorf("UpdateTags added an unindexed path; Len = %d", idx.Len())
+	}
+
+	idx.Rename("/a/one.jpg", "/b/two.jpg")
+	if got := paths(idx.Search("blue", 0)); !reflect.DeepEqual(got, []string{"/b/two.jpg"}) {
+		t.Errorf("after Rename, Search(blue) = %v", got)
+	}
+	if got := idx.Search("one", 0); len(got) != 0 {
+		t.Errorf("old name still matches after Rename: %v", paths(got))
+	}
+
+	idx.Remove("/b/two.jpg")
+	if idx.Len() != 0 {
+		t.Errorf("Len after Remove = %d, want 0", idx.Len())
+	}
+}
+
+func TestSearchText(t *testing.T) {
+	idx := New()
+	idx.Set("/scans/doc1.png", []string{"paperwork"})
+	idx.Set("/scans/doc2.png", nil)
+	idx.SetText("/scans/doc1.png", "ACME Corp\nInvoice No. 1042\nTotal due: 99.00")
+	idx.SetText("/elsewhere/x.png", "invoice") // Not indexed, ignored
+
+	got := idx.Search("invoice", 0)
+	if !reflect.DeepEqual(paths(got), []string{"/scans/doc1.png"}) {
+		t.Fatalf("Search(invoice) = %v", paths(got))
+	}
+	if want := []Field{FieldText}; !reflect.DeepEqual(got[0].Matched, want) {
+		t.Errorf("Matched fields = %v, want %v", got[0].Matched, want)
+	}
+	if got[0].Snippet != "Invoice No. 1042" {
+		t.Errorf("Snippet = %q, want the matching line", got[0].Snippet)
+	}
+	if got := paths(idx.Search("paperwork 1042", 0)); len(got) != 1 {
+		t.Errorf("terms across tags and text matched %v", got)
+	}
+
+	// The text survives tag changes and renames, and goes when cleared.
+	idx.UpdateTags("/scans/doc1.png", []string{"bills"})
+	idx.Rename("/scans/doc1.png", "/scans/acme.png")
+	if got := paths(idx.Search("acme corp", 0)); !reflect.DeepEqual(got, []string{"/scans/acme.png"}) {
+		t.Errorf("after UpdateTags and Rename, Search(acme corp) = %v", got)
+	}
+	idx.SetText("/scans/acme.png", "")
+	if got := idx.Search("invoice", 0); len(got) != 0 {
+		t.Errorf("cleared text still matches: %v", paths(got))
+	}
+}
+
+func TestSnippetLongLine(t *testing.T) {
+	text := strings.Repeat("a", 100) + " needle " + strings.Repeat("b", 100)
+	got := snippet(text, text, []string{"needle"})
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "needle") {
+		t.Errorf("snippet = %q, want the match cut out with ellipses", got)
+	}
+}
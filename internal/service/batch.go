@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fyslide/internal/metadata"
+	"fyslide/internal/tagging"
+)
+
+// ImageError is the failure of a batch operation on one image.
+type ImageError struct {
+	Path string
+	Err  error
+}
+
+func (e ImageError) Error() string { return e.Path + ": " + e.Err.Error() }
+
+func (e ImageError) Unwrap() error { return e.Err }
+
+// BatchResult reports a batch operation on an explicit list of images.
+type BatchResult struct {
+	Done    int          // Successful operations: image-tag pairs when tagging, images when deleting
+	Errors  []ImageError // Failed operations, in order
+	Stopped error        // The context's error if the batch was cancelled before the end
+}
+
+// Err returns the context's error if the batch was cancelled, else the first
+// failure, or nil if there was none.
+func (r BatchResult) Err() error {
+	if r.Stopped != nil {
+		return r.Stopped
+	}
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r.Errors[0]
+}
+
+// AddTags adds every tag to every image at paths, carrying on past failures.
+// progress, if not nil, is called after each image-tag pair. If ctx ends, the
+// pairs already tagged stay tagged and the rest are left alone.
+func (s *Service) AddTags(ctx context.Context, paths, tags []string, progress func(path, tag string, err error)) BatchResult {
+	return s.tagEach(ctx, paths, tags, s.AddTag, progress)
+}
+
+// RemoveTags removes every tag from every image at paths, carrying on past
+// failures. progress, if not nil, is called after each image-tag pair. If ctx
+// ends, the pairs already untagged stay so and the rest are left alone.
+func (s *Service) RemoveTags(ctx context.Context, paths, tags []string, progress func(path, tag string, err error)) BatchResult {
+	return s.tagEach(ctx, paths, tags, s.RemoveTag, progress)
+}
+
+func (s *Service) tagEach(ctx context.Context, paths, tags []string, action func(ctx context.Context, path, tag string) error, progress func(path, tag string, err error)) BatchResult {
+	var result BatchResult
+	processed := 0
+	defer func() { s.countProcessed(ctx, processed) }()
+	for _, path := range paths {
+		done := result.Done
+		for _, tag := range tags {
+			if err := ctx.Err(); err != nil {
+				result.Stopped = err
+				return result
+			}
+			err := action(ctx, path, tag)
+			if err != nil && ctx.Err() != nil {
+				result.Stopped = ctx.Err() // Rolled back, not a failure of this pair
+				return result
+			}
+			if err != nil {
+				result.Errors = append(result.Errors, ImageError{Path: path, Err: err})
+			} else {
+				result.Done++
+			}
+			if progress != nil {
+				progress(path, tag, err)
+			}
+		}
+		if result.Done > done {
+			processed++
+		}
+	}
+	return result
+}
+
+// countProcessed adds n images to the usage statistics, even when ctx was
+// cancelled part way.
+func (s *Service) countProcessed(ctx context.Context, n int) {
+	if n > 0 {
+		s.tagDB.CountUsage(context.WithoutCancel(ctx), map[string]int{tagging.UsageImagesProcessed: n})
+	}
+}
+
+// DeleteImages deletes the image files at paths and their tags, carrying on
+// past failures; see DeleteImage. Protected images fail with ErrProtected
+// unless force is set. progress, if not nil, is called after each image. If
+// ctx ends, the images already deleted stay deleted and the rest are kept.
+func (s *Service) DeleteImages(ctx context.Context, paths []string, force bool, progress func(path string, err error)) BatchResult {
+	if force {
+		return s.eachImage(ctx, paths, s.ForceDeleteImage, progress)
+	}
+	return s.eachImage(ctx, paths, s.DeleteImage, progress)
+}
+
+// ForgetImages removes the tags of the images at paths and keeps the files,
+// carrying on past failures; see ForgetImage. progress, if not nil, is called
+// after each image. If ctx ends, the images already forgotten stay so.
+func (s *Service) ForgetImages(ctx context.Context, paths []string, progress func(path string, err error)) BatchResult {
+	return s.eachImage(ctx, paths, s.ForgetImage, progress)
+}
+
+// DeleteImageFiles deletes the image files at paths and keeps their tags,
+// carrying on past failures; see DeleteImageFile. Protected images fail with
+// ErrProtected unless force is set. progress, if not nil, is called after
+// each image. If ctx ends, the files already deleted stay deleted.
+func (s *Service) DeleteImageFiles(ctx context.Context, paths []string, force bool, progress func(path string, err error)) BatchResult {
+	if force {
+		return s.eachImage(ctx, paths, s.ForceDeleteImageFile, progress)
+	}
+	return s.eachImage(ctx, paths, s.DeleteImageFile, progress)
+}
+
+// AdjustEXIFDates corrects the EXIF dates of the images at paths with adjust,
+// carrying on past failures; see metadata.AdjustEXIFDates. Images without a
+// date fail with metadata.ErrNoDate. progress, if not nil, is called after
+// each image. If ctx ends, the images already corrected stay so.
+func (s *Service) AdjustEXIFDates(ctx context.Context, paths []string, adjust metadata.DateAdjustment, progress func(path string, err error)) BatchResult {
+	return s.eachImage(ctx, paths, func(ctx context.Context, path string) error {
+		if s.readOnly {
+			return ErrReadOnly
+		}
+		return metadata.AdjustEXIFDates(path, adjust)
+	}, progress)
+}
+
+func (s *Service) eachImage(ctx context.Context, paths []string, action func(ctx context.Context, path string) error, progress func(path string, err error)) BatchResult {
+	var result BatchResult
+	defer func() { s.countProcessed(ctx, result.Done) }()
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			result.Stopped = err
+			return result
+		}
+		err := action(ctx, path)
+		if err != nil && ctx.Err() != nil {
+			result.Stopped = ctx.Err()
+			return result
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, ImageError{Path: path, Err: err})
+		} else {
+			result.Done++
+		}
+		if progress != nil {
+			progress(path, err)
+		}
+	}
+	return result
+}
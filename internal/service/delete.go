@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// DeletePreview is what deleting an image would remove, as PreviewDelete finds it.
+type DeletePreview struct {
+	Path      string
+	Tags      []string // Every tag of the image
+	Protected []string // The tags among Tags protecting its file; see SetProtectedTags
+}
+
+// PreviewDelete returns the tags of each of paths and those protecting it
+// from deletion, in the order of paths. It reads the tag database only, so
+// it neither needs nor checks the files.
+func (s *Service) PreviewDelete(ctx context.Context, paths []string) ([]DeletePreview, error) {
+	previews := make([]DeletePreview, 0, len(paths))
+	for _, path := range paths {
+		tags, err := s.tagDB.GetTags(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tags of %s: %w", path, err)
+		}
+		p := DeletePreview{Path: path, Tags: tags}
+		for _, tag := range tags {
+			if slices.Contains(s.protected, tag) {
+				p.Protected = append(p.Protected, tag)
+			}
+		}
+		previews = append(previews, p)
+	}
+	return previews, nil
+}
+
+// ForgetImage removes every tag of the image at path from the database and
+// keeps the file, as if it had never been tagged. Protected tags don't keep
+// an image from being forgotten, as its file stays.
+func (s *Service) ForgetImage(ctx context.Context, path string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	return s.tagDB.RemoveAllTagsForImage(ctx, path)
+}
+
+// DeleteImageFile deletes the image file at path for good and keeps its tags,
+// so they can be moved to the image at its new place or dropped by Clean
+// later. The trash is not used as it holds the tags along with the file. An
+// image carrying a protected tag is kept like by DeleteImage.
+func (s *Service) DeleteImageFile(ctx context.Context, path string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	if err := s.refuseProtected(ctx, path); err != nil {
+		return err
+	}
+	return s.ForceDeleteImageFile(ctx, path)
+}
+
+// ForceDeleteImageFile deletes the image file at path like DeleteImageFile,
+// even if it carries a protected tag.
+func (s *Service) ForceDeleteImageFile(ctx context.Context, path string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,392 @@
+// Package service implements operations shared by the GUI and the CLI that must
+// keep the image files on disk and the tag database consistent with each other.
+//
+// Operations take a context to be cancelled or time-limited. A single-image
+// operation either completes or leaves files and tags as they were. Operations
+// on many images check the context between images and stop at the next one,
+// keeping what was already done and returning the context's error along with
+// the partial result; as each image is done in its own transaction, running
+// them again continues where they stopped.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/availability"
+	"fyslide/internal/metadata"
+	"fyslide/internal/scan"
+	"fyslide/internal/tagging"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrDestinationExists is returned when a rename would overwrite another file.
+	ErrDestinationExists = errors.New("destination already exists")
+	// ErrReadOnly is returned by every mutating operation of a read-only Service.
+	ErrReadOnly = errors.New("library is open read-only")
+	// ErrTagCleanup is wrapped when a file was deleted but its tags could not be removed.
+	ErrTagCleanup = errors.New("file deleted but its tags were not removed")
+	// ErrProtected is wrapped when DeleteImage refuses an image carrying a protected tag.
+	ErrProtected = errors.New("image is protected from deletion")
+)
+
+// DefaultProtectedTags protect the images carrying them from deletion unless
+// other tags are set with SetProtectedTags.
+var DefaultProtectedTags = []string{"keep", "favorite"}
+
+// Service couples filesystem changes with the matching tag database updates.
+type Service struct {
+	tagDB     *tagging.TagDB
+	readOnly  bool
+	roots     []string // Library folders; see SetLibraryRoots
+	protected []string // Tags protecting images from deletion; see SetProtectedTags
+	trashDir  string   // Where deleted images go; "" deletes them for good, see SetTrash
+}
+
+// New creates a Service operating on tagDB, protecting the images carrying
+// one of DefaultProtectedTags from deletion.
+func New(tagDB *tagging.TagDB) *Service {
+	return &Service{tagDB: tagDB, protected: slices.Clone(DefaultProtectedTags)}
+}
+
+// SetProtectedTags makes DeleteImage refuse the images carrying one of tags,
+// which only ForceDeleteImage then deletes. No tags protect no images.
+func (s *Service) SetProtectedTags(tags ...string) {
+	s.protected = s.protected[:0]
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && !slices.Contains(s.protected, tag) {
+			s.protected = append(s.protected, tag)
+		}
+	}
+}
+
+// ProtectedTags returns the tags protecting images from deletion.
+func (s *Service) ProtectedTags() []string {
+	return slices.Clone(s.protected)
+}
+
+// Protection returns the protected tags each of paths carries, leaving out
+// the images carrying none.
+func (s *Service) Protection(ctx context.Context, paths []string) (map[string][]string, error) {
+	protection := make(map[string][]string)
+	if len(s.protected) == 0 {
+		return protection, nil
+	}
+	for _, path := range paths {
+		tags, err := s.protectionOf(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		if len(tags) > 0 {
+			protection[path] = tags
+		}
+	}
+	return protection, nil
+}
+
+// protectionOf returns the protected tags of the image at path.
+func (s *Service) protectionOf(ctx context.Context, path string) ([]string, error) {
+	if len(s.protected) == 0 {
+		return nil, nil
+	}
+	tags, err := s.tagDB.GetTags(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags of %s: %w", path, err)
+	}
+	var found []string
+	for _, tag := range tags {
+		if slices.Contains(s.protected, tag) {
+			found = append(found, tag)
+		}
+	}
+	return found, nil
+}
+
+// refuseProtected returns an error wrapping ErrProtected if the image at
+// path carries a protected tag.
+func (s *Service) refuseProtected(ctx context.Context, path string) error {
+	tags, err := s.protectionOf(ctx, path)
+	if err != nil {
+		return err
+	}
+	if len(tags) > 0 {
+		return fmt.Errorf("%w: %s is tagged %s", ErrProtected, filepath.Base(path), strings.Join(tags, ", "))
+	}
+	return nil
+}
+
+// SetReadOnly makes every mutating operation fail with ErrReadOnly, so a library
+// can be browsed without any risk of changing files or tags.
+func (s *Service) SetReadOnly(readOnly bool) {
+	s.readOnly = readOnly
+}
+
+// SetLibraryRoots names the folders holding the library, such as a network
+// share's mount point. While one can't be reached, Clean leaves the tags of
+// images under it alone instead of taking them for deleted.
+func (s *Service) SetLibraryRoots(roots ...string) {
+	s.roots = roots
+}
+
+// ReadOnly reports whether mutating operations are refused.
+func (s *Service) ReadOnly() bool {
+	return s.readOnly
+}
+
+// AddTag adds tag to the image at path.
+func (s *Service) AddTag(ctx context.Context, path, tag string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	return s.tagDB.AddTag(ctx, path, tag)
+}
+
+// RemoveTag removes tag from the image at path.
+func (s *Service) RemoveTag(ctx context.Context, path, tag string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	return s.tagDB.RemoveTag(ctx, path, tag)
+}
+
+// DeleteImage deletes the image file at path and then its tags, or moves both
+// to the trash while it is on; see SetTrash. An image carrying a protected
+// tag is kept and the returned error wraps ErrProtected; see SetProtectedTags.
+// If the file is gone but the tags remain, the returned error wraps
+// ErrTagCleanup. Once the file is deleted, ctx no longer cancels the removal
+// of its tags.
+func (s *Service) DeleteImage(ctx context.Context, path string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	if err := s.refuseProtected(ctx, path); err != nil {
+		return err
+	}
+	return s.ForceDeleteImage(ctx, path)
+}
+
+// ForceDeleteImage deletes the image file at path and then its tags like
+// DeleteImage, even if it carries a protected tag. Callers must have had the
+// user confirm the deletion of protected images.
+func (s *Service) ForceDeleteImage(ctx context.Context, path string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	if s.trashDir != "" {
+		return s.trashImage(ctx, path)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	if err := s.tagDB.RemoveAllTagsForImage(context.WithoutCancel(ctx), path); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrTagCleanup, path, err)
+	}
+	return nil
+}
+
+// RenameImage renames the image file oldPath to newPath and moves its tags along.
+// It refuses to overwrite an existing file and to give the file an extension that
+// would hide it from scans. If the database update fails the file is renamed back.
+// Both paths are made absolute, matching how images are keyed in the database.
+// If ctx ends before the tags are moved, the file is renamed back as well.
+func (s *Service) RenameImage(ctx context.Context, oldPath, newPath string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	oldAbs, err := filepath.Abs(oldPath)
+	if err != nil {
+		return fmt.Errorf("error getting absolute path for %s: %w", oldPath, err)
+	}
+	newAbs, err := filepath.Abs(newPath)
+	if err != nil {
+		return fmt.Errorf("error getting absolute path for %s: %w", newPath, err)
+	}
+	if oldAbs == newAbs {
+		return nil
+	}
+
+	oldInfo, err := os.Stat(oldAbs)
+	if err != nil {
+		return fmt.Errorf("cannot rename %s: %w", oldAbs, err)
+	}
+	if oldInfo.IsDir() {
+		return fmt.Errorf("cannot rename %s: is a directory", oldAbs)
+	}
+	if !scan.IsImage(newAbs) {
+		return fmt.Errorf("new name %s must keep a supported image extension", filepath.Base(newAbs))
+	}
+
+	// A case-only rename on a case-insensitive filesystem finds the source itself.
+	if newInfo, err := os.Lstat(newAbs); err == nil {
+		if !os.SameFile(oldInfo, newInfo) {
+			return fmt.Errorf("%w: %s", ErrDestinationExists, newAbs)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("cannot check destination %s: %w", newAbs, err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.Rename(oldAbs, newAbs); err != nil {
+		return fmt.Errorf("failed to rename %s: %w", oldAbs, err)
+	}
+	if err := s.tagDB.RenameImage(ctx, oldAbs, newAbs); err != nil {
+		if rbErr := os.Rename(newAbs, oldAbs); rbErr != nil {
+			return fmt.Errorf("failed to move tags to %s (%v) and to restore the original name: %w", newAbs, err, rbErr)
+		}
+		return fmt.Errorf("failed to move tags to %s, rename undone: %w", newAbs, err)
+	}
+	return nil
+}
+
+// SetEXIF changes the EXIF date taken and title of the image at path; see
+// metadata.WriteEXIF.
+func (s *Service) SetEXIF(ctx context.Context, path string, update metadata.EXIFUpdate) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return metadata.WriteEXIF(path, update)
+}
+
+// DefaultMaxMissingPercent is the share of the referenced images that may be
+// missing before Clean suspects an unmounted drive and refuses to run.
+const DefaultMaxMissingPercent = 20
+
+// minMissingForThreshold is how many images must be missing before the
+// threshold applies, so that a few deletions from a small library never trip it.
+const minMissingForThreshold = 10
+
+// ErrTooManyMissing is returned by Clean, along with the report of what it
+// would have removed, when more images are missing than the threshold allows.
+var ErrTooManyMissing = errors.New("too many images missing")
+
+// TooManyMissing reports whether missing of total referenced images exceed
+// maxPercent, the threshold past which a cleanup more likely faces an
+// unmounted drive than deleted files. Fewer than ten missing images never do.
+func TooManyMissing(missing, total int, maxPercent float64) bool {
+	return missing >= minMissingForThreshold && total > 0 && 100*float64(missing)/float64(total) > maxPercent
+}
+
+// CleanOptions control Clean.
+type CleanOptions struct {
+	DryRun            bool    // Report what would be removed without removing anything
+	Force             bool    // Clean even when more images are missing than MaxMissingPercent allows
+	MaxMissingPercent float64 // Threshold in percent of the referenced images; 0 uses DefaultMaxMissingPercent
+}
+
+// CleanResult lists what Clean removed, or would remove in a dry run or when
+// it refused to run.
+type CleanResult struct {
+	Checked        int      // Images referenced by the database
+	MissingImages  []string // Images whose files no longer exist; their tags are removed
+	OrphanedTags   []string // Tags no image carries any more
+	Unreachable    []string // Images on a disconnected disk or share; their tags are kept
+	MissingPercent float64  // Share of the checked images that are missing
+	OverThreshold  bool     // MissingPercent exceeds the threshold; nothing is removed unless forced
+}
+
+// Clean removes the tags of image files that no longer exist and then the tags
+// left without images. Images whose location can't be reached, such as an
+// unmounted share, are not taken for deleted; see availability.Checker. If more
+// images are missing than opts.MaxMissingPercent allows, which usually means a
+// drive is not mounted, Clean removes nothing and returns ErrTooManyMissing
+// unless opts.Force is set. It keeps going past individual failures and returns
+// the first one along with everything that was cleaned. A dry run changes
+// nothing, never fails on the threshold, and is allowed on a read-only Service.
+// If ctx ends, Clean stops before the next image and returns what it cleaned
+// so far with ctx's error; orphaned tags are then left for the next run.
+func (s *Service) Clean(ctx context.Context, opts CleanOptions) (CleanResult, error) {
+	var result CleanResult
+	if s.readOnly && !opts.DryRun {
+		return result, ErrReadOnly
+	}
+	paths, err := s.tagDB.GetAllImagePaths(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to get image paths for cleanup: %w", err)
+	}
+
+	result.Checked = len(paths)
+	checker := availability.NewChecker(s.roots...)
+	var missing []string
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		switch checker.Check(path) {
+		case availability.Missing:
+			missing = append(missing, path)
+		case availability.Unreachable:
+			result.Unreachable = append(result.Unreachable, path)
+		}
+	}
+	if len(paths) > 0 {
+		result.MissingPercent = 100 * float64(len(missing)) / float64(len(paths))
+	}
+	limit := opts.MaxMissingPercent
+	if limit <= 0 {
+		limit = DefaultMaxMissingPercent
+	}
+	result.OverThreshold = TooManyMissing(len(missing), len(paths), limit)
+	if result.OverThreshold && !opts.Force && !opts.DryRun {
+		result.MissingImages = missing
+		return result, fmt.Errorf("%w: %d of %d images (%.0f%%, more than %.0f%%) appear missing; is a drive or share not mounted? Force the cleanup if they were really deleted",
+			ErrTooManyMissing, len(missing), len(paths), result.MissingPercent, limit)
+	}
+
+	var firstError error
+	for _, path := range missing {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if !opts.DryRun {
+			if err := s.tagDB.CleanImage(ctx, path); err != nil {
+				if firstError == nil {
+					firstError = fmt.Errorf("error removing tags for %s: %w", path, err)
+				}
+				continue
+			}
+		}
+		result.MissingImages = append(result.MissingImages, path)
+	}
+
+	tags, err := s.tagDB.GetAllTags(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return result, err
+		}
+		if firstError == nil {
+			firstError = fmt.Errorf("error getting all tags for orphan check: %w", err)
+		}
+		return result, firstError
+	}
+	for _, tag := range tags {
+		if tag.Count != 0 {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if !opts.DryRun {
+			if err := s.tagDB.DeleteOrphanedTagKey(ctx, tag.Name); err != nil {
+				if firstError == nil {
+					firstError = fmt.Errorf("error removing orphaned tag '%s': %w", tag.Name, err)
+				}
+				continue
+			}
+		}
+		result.OrphanedTags = append(result.OrphanedTags, tag.Name)
+	}
+	return result, firstError
+}
@@ -0,0 +1,548 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/metadata"
+	"fyslide/internal/tagging"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestService(t *testing.T) (*Service, *tagging.TagDB) {
+	t.Helper()
+	tagDB, err := tagging.NewTagDB(t.TempDir(), func(message string) { t.Logf("TagDB: %s", message) })
+	if err != nil {
+		t.Fatalf("NewTagDB failed: %v", err)
+	}
+	t.Cleanup(func() { tagDB.Close() })
+	return New(tagDB), tagDB
+}
+
+func writeImage(t *testing.T, path string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("image"), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestRenameImageMovesTags(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.jpg")
+	newPath := filepath.Join(dir, "new.jpg")
+	writeImage(t, oldPath)
+	if err := tagDB.AddTag(context.Background(), oldPath, "beach"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	if err := svc.RenameImage(context.Background(), oldPath, newPath); err != nil {
+		t.Fatalf("RenameImage failed: %v", err)
+	}
+
+	if _, err := os.Stat(newPath); err != nil {
+		t.Errorf("renamed file missing: %v", err)
+	}
+	if _, err := os.Stat(oldPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("old file still present, stat err = %v", err)
+	}
+	if tags, _ := tagDB.GetTags(context.Background(), newPath); len(tags) != 1 || tags[0] != "beach" {
+		t.Errorf("tags of new path = %v, want [beach]", tags)
+	}
+	if tags, _ := tagDB.GetTags(context.Background(), oldPath); len(tags) != 0 {
+		t.Errorf("tags of old path = %v, want none", tags)
+	}
+	if images, _ := tagDB.GetImages(context.Background(), "beach"); len(images) != 1 || images[0] != newPath {
+		t.Errorf("images for tag = %v, want [%s]", images, newPath)
+	}
+}
+
+func TestRenameImageRejectsCollision(t *testing.T) {
+	svc, _ := newTestService(t)
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "a.png")
+	newPath := filepath.Join(dir, "b.png")
+	writeImage(t, oldPath)
+	writeImage(t, newPath)
+
+	if err := svc.RenameImage(context.Background(), oldPath, newPath); !errors.Is(err, ErrDestinationExists) {
+		t.Errorf("RenameImage onto existing file: err = %v, want ErrDestinationExists", err)
+	}
+	if _, err := os.Stat(oldPath); err != nil {
+		t.Errorf("source should be untouched after a rejected rename: %v", err)
+	}
+}
+
+func TestRenameImageRejectsNonImageExtension(t *testing.T) {
+	svc, _ := newTestService(t)
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "a.png")
+	writeImage(t, oldPath)
+
+	if err := svc.RenameImage(context.Background(), oldPath, filepath.Join(dir, "a.txt")); err == nil {
+		t.Error("RenameImage to a non-image extension should fail")
+	}
+}
+
+func TestReadOnlyRefusesMutations(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.jpg")
+	writeImage(t, path)
+	if err := tagDB.AddTag(context.Background(), path, "keep"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	svc.SetReadOnly(true)
+
+	checks := map[string]error{
+		"AddTag":          svc.AddTag(context.Background(), path, "new"),
+		"RemoveTag":       svc.RemoveTag(context.Background(), path, "keep"),
+		"RenameImage":     svc.RenameImage(context.Background(), path, filepath.Join(dir, "b.jpg")),
+		"DeleteImage":     svc.DeleteImage(context.Background(), path),
+		"SetEXIF":         svc.SetEXIF(context.Background(), path, metadata.EXIFUpdate{}),
+		"AdjustEXIFDates": svc.AdjustEXIFDates(context.Background(), []string{path}, metadata.ShiftDates(time.Hour), nil).Err(),
+	}
+	for name, err := range checks {
+		if !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s in read-only mode: err = %v, want ErrReadOnly", name, err)
+		}
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("file changed in read-only mode: %v", err)
+	}
+	if tags, _ := tagDB.GetTags(context.Background(), path); len(tags) != 1 || tags[0] != "keep" {
+		t.Errorf("tags changed in read-only mode: %v", tags)
+	}
+}
+
+func TestDeleteImageRemovesTags(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	path := filepath.Join(t.TempDir(), "a.jpg")
+	writeImage(t, path)
+	if err := tagDB.AddTag(context.Background(), path, "gone"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := svc.DeleteImage(context.Background(), path); err != nil {
+		t.Fatalf("DeleteImage failed: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file still present, stat err = %v", err)
+	}
+	if images, _ := tagDB.GetImages(context.Background(), "gone"); len(images) != 0 {
+		t.Errorf("tag still lists deleted image: %v", images)
+	}
+}
+
+func TestDeleteImageKeepsProtected(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	dir := t.TempDir()
+	kept, other := filepath.Join(dir, "kept.jpg"), filepath.Join(dir, "other.jpg")
+	for path, tag := range map[string]string{kept: "keep", other: "trip"} {
+		writeImage(t, path)
+		if err := tagDB.AddTag(context.Background(), path, tag); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+
+	protection, err := svc.Protection(context.Background(), []string{kept, other})
+	if err != nil || len(protection) != 1 || len(protection[kept]) != 1 || protection[kept][0] != "keep" {
+		t.Errorf("Protection = %v, %v; want only %s protected by keep", protection, err, kept)
+	}
+	result := svc.DeleteImages(context.Background(), []string{kept, other}, false, nil)
+	if result.Done != 1 || len(result.Errors) != 1 || !errors.Is(result.Errors[0], ErrProtected) {
+		t.Errorf("DeleteImages = %+v, want the protected image refused", result)
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("protected image deleted: %v", err)
+	}
+	if tags, _ := tagDB.GetTags(context.Background(), kept); len(tags) != 1 {
+		t.Errorf("tags of the protected image changed: %v", tags)
+	}
+
+	if err := svc.ForceDeleteImage(context.Background(), kept); err != nil {
+		t.Fatalf("ForceDeleteImage failed: %v", err)
+	}
+	if _, err := os.Stat(kept); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("forced delete left the file, stat err = %v", err)
+	}
+
+	svc.SetProtectedTags("Trip")
+	writeImage(t, kept)
+	if err := tagDB.AddTag(context.Background(), kept, "keep"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := svc.DeleteImage(context.Background(), kept); err != nil {
+		t.Errorf("DeleteImage of an image no longer protected: %v", err)
+	}
+}
+
+func TestDeleteTagsOrFilesOnly(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	ctx := context.Background()
+	kept, trip := "/nowhere/kept.jpg", "/nowhere/trip.jpg" // The tag database alone needs no files
+	for path, tags := range map[string][]string{kept: {"keep", "sea"}, trip: {"trip"}} {
+		for _, tag := range tags {
+			if err := tagDB.AddTag(ctx, path, tag); err != nil {
+				t.Fatalf("AddTag failed: %v", err)
+			}
+		}
+	}
+
+	previews, err := svc.PreviewDelete(ctx, []string{trip, kept, "/nowhere/untagged.jpg"})
+	if err != nil {
+		t.Fatalf("PreviewDelete failed: %v", err)
+	}
+	if got := fmt.Sprint(previews); got != "[{/nowhere/trip.jpg [trip] []} {/nowhere/kept.jpg [keep sea] [keep]} {/nowhere/untagged.jpg [] []}]" {
+		t.Errorf("PreviewDelete = %s", got)
+	}
+
+	if result := svc.ForgetImages(ctx, []string{kept}, nil); result.Done != 1 || result.Err() != nil {
+		t.Errorf("ForgetImages = %+v, want the protected image forgotten too", result)
+	}
+	if tags, _ := tagDB.GetTags(ctx, kept); len(tags) != 0 {
+		t.Errorf("tags of the forgotten image = %v", tags)
+	}
+
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.jpg")
+	writeImage(t, a)
+	writeImage(t, b)
+	for path, tag := range map[string]string{a: "trip", b: "favorite"} {
+		if err := tagDB.AddTag(ctx, path, tag); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	result := svc.DeleteImageFiles(ctx, []string{a, b}, false, nil)
+	if result.Done != 1 || len(result.Errors) != 1 || !errors.Is(result.Err(), ErrProtected) {
+		t.Errorf("DeleteImageFiles = %+v, want the protected image refused", result)
+	}
+	if _, err := os.Stat(a); !os.IsNotExist(err) {
+		t.Errorf("a.jpg still exists: %v", err)
+	}
+	if tags, _ := tagDB.GetTags(ctx, a); fmt.Sprint(tags) != "[trip]" {
+		t.Errorf("tags of the deleted file = %v, want them kept", tags)
+	}
+	if result := svc.DeleteImageFiles(ctx, []string{b}, true, nil); result.Done != 1 {
+		t.Errorf("forced DeleteImageFiles = %+v", result)
+	}
+	if _, err := os.Stat(b); !os.IsNotExist(err) {
+		t.Errorf("forced delete left b.jpg: %v", err)
+	}
+}
+
+func TestCleanRemovesMissingImages(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	dir := t.TempDir()
+	kept, gone := filepath.Join(dir, "kept.jpg"), filepath.Join(dir, "gone.jpg")
+	writeImage(t, kept)
+	for _, path := range []string{kept, gone} {
+		if err := tagDB.AddTag(context.Background(), path, "trip"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	svc.SetReadOnly(true)
+	if _, err := svc.Clean(context.Background(), CleanOptions{}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Clean in read-only mode: err = %v, want ErrReadOnly", err)
+	}
+
+	result, err := svc.Clean(context.Background(), CleanOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run Clean failed: %v", err)
+	}
+	if len(result.MissingImages) != 1 || result.MissingImages[0] != gone {
+		t.Errorf("dry run missing images = %v, want [%s]", result.MissingImages, gone)
+	}
+	if images, _ := tagDB.GetImages(context.Background(), "trip"); len(images) != 2 {
+		t.Errorf("dry run changed the database: %v", images)
+	}
+
+	svc.SetReadOnly(false)
+	if _, err := svc.Clean(context.Background(), CleanOptions{}); err != nil {
+		t.Fatalf("Clean failed: %v", err)
+	}
+	if images, _ := tagDB.GetImages(context.Background(), "trip"); len(images) != 1 || images[0] != kept {
+		t.Errorf("images after Clean = %v, want [%s]", images, kept)
+	}
+}
+
+func TestCleanKeepsImagesOfUnreachableLibrary(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	mountPoint := t.TempDir() // Empty, like a share that is not mounted
+	offline := filepath.Join(mountPoint, "photos", "a.jpg")
+	if err := tagDB.AddTag(context.Background(), offline, "trip"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	svc.SetLibraryRoots(mountPoint)
+
+	result, err := svc.Clean(context.Background(), CleanOptions{})
+	if err != nil {
+		t.Fatalf("Clean failed: %v", err)
+	}
+	if len(result.MissingImages) != 0 || len(result.Unreachable) != 1 || result.Unreachable[0] != offline {
+		t.Errorf("Clean = %+v, want %s reported unreachable only", result, offline)
+	}
+	if images, _ := tagDB.GetImages(context.Background(), "trip"); len(images) != 1 {
+		t.Errorf("Clean removed the tags of an unreachable image: %v", images)
+	}
+}
+
+func TestCleanRefusesWhenTooManyMissing(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.jpg")
+	writeImage(t, kept)
+	if err := tagDB.AddTag(context.Background(), kept, "trip"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	for i := 0; i < minMissingForThreshold; i++ {
+		if err := tagDB.AddTag(context.Background(), filepath.Join(dir, fmt.Sprintf("gone%d.jpg", i)), "trip"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+
+	result, err := svc.Clean(context.Background(), CleanOptions{})
+	if !errors.Is(err, ErrTooManyMissing) {
+		t.Fatalf("Clean err = %v, want ErrTooManyMissing", err)
+	}
+	if !result.OverThreshold || result.Checked != minMissingForThreshold+1 || len(result.MissingImages) != minMissingForThreshold {
+		t.Errorf("refused Clean report = %+v", result)
+	}
+	if images, _ := tagDB.GetImages(context.Background(), "trip"); len(images) != minMissingForThreshold+1 {
+		t.Errorf("refused Clean changed the database: %d images left", len(images))
+	}
+
+	if _, err := svc.Clean(context.Background(), CleanOptions{MaxMissingPercent: 95}); err != nil {
+		t.Fatalf("Clean with a raised threshold failed: %v", err)
+	}
+	if images, _ := tagDB.GetImages(context.Background(), "trip"); len(images) != 1 || images[0] != kept {
+		t.Errorf("images after Clean = %v, want [%s]", images, kept)
+	}
+}
+
+func TestStats(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0750); err != nil {
+		t.Fatal(err)
+	}
+	a, b, c := filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.jpg"), filepath.Join(sub, "c.jpg")
+	writeImage(t, a)
+	writeImage(t, c)
+	for _, tagging := range [][2]string{{a, "trip"}, {a, "beach"}, {b, "trip"}, {c, "trip"}, {c, "gone"}} {
+		if err := tagDB.AddTag(context.Background(), tagging[0], tagging[1]); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	if err := tagDB.RemoveTag(context.Background(), c, "gone"); err != nil {
+		t.Fatalf("RemoveTag failed: %v", err)
+	}
+
+	stats, err := svc.Stats(context.Background(), StatsOptions{TopTags: 1})
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.TaggedImages != 3 || stats.Tags != 2 || stats.Taggings != 4 {
+		t.Errorf("counts = %d images, %d tags, %d taggings; want 3, 2, 4", stats.TaggedImages, stats.Tags, stats.Taggings)
+	}
+	if len(stats.TopTags) != 1 || stats.TopTags[0] != (TagCount{Tag: "trip", Images: 3}) {
+		t.Errorf("top tags = %v, want [trip 3]", stats.TopTags)
+	}
+	wantDirs := []DirCount{{Dir: dir, Images: 2}, {Dir: sub, Images: 1}}
+	if fmt.Sprint(stats.Directories) != fmt.Sprint(wantDirs) {
+		t.Errorf("directories = %v, want %v", stats.Directories, wantDirs)
+	}
+	if stats.MissingImages != 1 || !stats.FilesChecked {
+		t.Errorf("missing images = %d (checked %v), want 1", stats.MissingImages, stats.FilesChecked)
+	}
+	if stats.OrphanedTags != 0 {
+		t.Errorf("orphaned tags = %d, want 0: removing the last image drops the tag", stats.OrphanedTags)
+	}
+	if stats.DBSize == 0 || stats.DBPath == "" {
+		t.Errorf("database file = %q, %d bytes", stats.DBPath, stats.DBSize)
+	}
+
+	if stats, err = svc.Stats(context.Background(), StatsOptions{SkipFiles: true}); err != nil || stats.MissingImages != 0 || stats.FilesChecked {
+		t.Errorf("Stats without file checks = %+v, %v", stats, err)
+	}
+}
+
+func TestTagsOf(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	a, b, c := "/event/a.jpg", "/event/b.jpg", "/event/c.jpg"
+	for _, tagging := range [][2]string{{a, "party"}, {a, "alice"}, {b, "party"}, {"/elsewhere.jpg", "party"}} {
+		if err := tagDB.AddTag(context.Background(), tagging[0], tagging[1]); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	got, err := svc.TagsOf(context.Background(), []string{a, b, c}, "alice")
+	if err != nil {
+		t.Fatalf("TagsOf failed: %v", err)
+	}
+	want := ImageSetTags{
+		Images:   3,
+		Tags:     []TagCount{{Tag: "party", Images: 2}, {Tag: "alice", Images: 1}},
+		Untagged: []string{c},
+		Missing:  []string{b, c},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("TagsOf = %+v, want %+v", got, want)
+	}
+}
+
+func TestSiblingTags(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	dir := filepath.Join(string(filepath.Separator), "event")
+	a, b, c := filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.jpg"), filepath.Join(dir, "c.jpg")
+	for _, tagging := range [][2]string{
+		{a, "party"}, {a, "alice"}, {b, "party"}, {b, "cake"}, {c, "alice"},
+		{filepath.Join(dir, "sub", "d.jpg"), "sub"}, {dir + "-2023.jpg", "other"},
+	} {
+		if err := tagDB.AddTag(context.Background(), tagging[0], tagging[1]); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	got, err := svc.SiblingTags(context.Background(), c)
+	if err != nil {
+		t.Fatalf("SiblingTags failed: %v", err)
+	}
+	want := []TagCount{{Tag: "party", Images: 2}, {Tag: "alice", Images: 1}, {Tag: "cake", Images: 1}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("SiblingTags = %+v, want %+v", got, want)
+	}
+}
+
+func TestBatchOperations(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.jpg")
+	writeImage(t, a)
+	writeImage(t, b)
+
+	var calls int
+	result := svc.AddTags(context.Background(), []string{a, b}, []string{"x", "y"}, func(string, string, error) { calls++ })
+	if result.Done != 4 || result.Err() != nil || calls != 4 {
+		t.Errorf("AddTags = %+v after %d calls, want 4 done", result, calls)
+	}
+	result = svc.RemoveTags(context.Background(), []string{a}, []string{"x", ""}, nil)
+	if result.Done != 1 || len(result.Errors) != 1 || result.Errors[0].Path != a {
+		t.Errorf("RemoveTags = %+v, want 1 done and the empty tag failing", result)
+	}
+	if tags, _ := tagDB.GetTags(context.Background(), a); fmt.Sprint(tags) != "[y]" {
+		t.Errorf("tags of a = %v, want [y]", tags)
+	}
+
+	result = svc.DeleteImages(context.Background(), []string{a, filepath.Join(dir, "gone.jpg")}, false, nil)
+	if result.Done != 1 || len(result.Errors) != 1 || !errors.Is(result.Err(), os.ErrNotExist) {
+		t.Errorf("DeleteImages = %+v, want 1 done and the missing file failing", result)
+	}
+	if _, err := os.Stat(a); !os.IsNotExist(err) {
+		t.Errorf("a.jpg still exists: %v", err)
+	}
+	if tags, _ := tagDB.GetTags(context.Background(), a); len(tags) != 0 {
+		t.Errorf("tags of the deleted image = %v", tags)
+	}
+
+	svc.SetReadOnly(true)
+	if result := svc.AddTags(context.Background(), []string{b}, []string{"z"}, nil); !errors.Is(result.Err(), ErrReadOnly) {
+		t.Errorf("AddTags on a read-only service: %v", result.Err())
+	}
+}
+
+func TestCancellation(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.jpg")
+	writeImage(t, a)
+	writeImage(t, b)
+
+	// Cancelled partway: the pairs done are kept and the rest are not started.
+	ctx, cancel := context.WithCancel(context.Background())
+	result := svc.AddTags(ctx, []string{a, b}, []string{"x", "y"}, func(path, tag string, err error) {
+		if path == a && tag == "y" {
+			cancel()
+		}
+	})
+	if result.Done != 2 || len(result.Errors) != 0 || !errors.Is(result.Err(), context.Canceled) {
+		t.Errorf("Cancelled AddTags = %+v, want 2 done and stopped", result)
+	}
+	if tags, _ := tagDB.GetTags(context.Background(), b); len(tags) != 0 {
+		t.Errorf("tags of b = %v, want none after the cancel", tags)
+	}
+
+	// A cleanup that ran out of time changes nothing.
+	if err := os.Remove(a); err != nil {
+		t.Fatal(err)
+	}
+	expired, cancelExpired := context.WithTimeout(context.Background(), 0)
+	defer cancelExpired()
+	if _, err := svc.Clean(expired, CleanOptions{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Clean with an expired context: %v", err)
+	}
+	if tags, _ := tagDB.GetTags(context.Background(), a); fmt.Sprint(tags) != "[x y]" {
+		t.Errorf("tags of the missing image = %v, want them kept", tags)
+	}
+
+	result = svc.DeleteImages(expired, []string{b}, false, nil)
+	if result.Done != 0 || !errors.Is(result.Err(), context.DeadlineExceeded) {
+		t.Errorf("DeleteImages with an expired context = %+v", result)
+	}
+	if _, err := os.Stat(b); err != nil {
+		t.Errorf("b.jpg was deleted: %v", err)
+	}
+}
+
+func TestTrashRestoreAndEmpty(t *testing.T) {
+	svc, tagDB := newTestService(t)
+	svc.SetTrash(true)
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.jpg")
+	for _, path := range []string{a, b} {
+		writeImage(t, path)
+		if err := tagDB.AddTag(context.Background(), path, "trip"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	if result := svc.DeleteImages(context.Background(), []string{a, b}, false, nil); result.Err() != nil {
+		t.Fatalf("DeleteImages with the trash on: %v", result.Err())
+	}
+	items, err := svc.Trash(context.Background())
+	if err != nil || len(items) != 2 {
+		t.Fatalf("Trash = %+v, %v; want both images", items, err)
+	}
+	for _, item := range items {
+		if _, err := os.Stat(item.Path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s still in place after deletion", item.Path)
+		}
+		if _, err := os.Stat(item.TrashPath); err != nil {
+			t.Errorf("%s not kept in the trash: %v", item.Path, err)
+		}
+	}
+
+	restored := items[len(items)-1] // a, deleted first
+	if _, err := svc.RestoreImage(context.Background(), restored.ID); err != nil {
+		t.Fatalf("RestoreImage failed: %v", err)
+	}
+	if _, err := os.Stat(a); err != nil {
+		t.Errorf("restored file missing: %v", err)
+	}
+	if tags, _ := tagDB.GetTags(context.Background(), a); len(tags) != 1 || tags[0] != "trip" {
+		t.Errorf("restored image has tags %v, want trip", tags)
+	}
+
+	if result := svc.EmptyTrash(context.Background(), time.Hour, nil); result.Done != 0 {
+		t.Errorf("emptying images trashed over an hour ago deleted %d", result.Done)
+	}
+	if result := svc.EmptyTrash(context.Background(), 0, nil); result.Done != 1 || result.Err() != nil {
+		t.Errorf("EmptyTrash = %+v, want b deleted for good", result)
+	}
+	if _, err := os.Stat(items[0].TrashPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("emptied trash still holds %s", items[0].TrashPath)
+	}
+	if items, _ := svc.Trash(context.Background()); len(items) != 0 {
+		t.Errorf("trash holds %+v after emptying", items)
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"fyslide/internal/availability"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// DefaultTopTags is the number of most used tags Stats reports.
+const DefaultTopTags = 20
+
+// StatsOptions control Stats.
+type StatsOptions struct {
+	TopTags   int  // Most used tags to list; 0 uses DefaultTopTags
+	SkipFiles bool // Don't stat the image files, leaving the missing and unreachable counts at zero
+}
+
+// TagCount is a tag with the number of images carrying it.
+type TagCount struct {
+	Tag    string `json:"tag"`
+	Images int    `json:"images"`
+}
+
+// DirCount is a directory with the number of tagged images directly in it.
+type DirCount struct {
+	Dir    string `json:"dir"`
+	Images int    `json:"images"`
+}
+
+// Stats is an overview of the tag database.
+type Stats struct {
+	DBPath            string     `json:"db_path"`
+	DBSize            int64      `json:"db_size"`            // Bytes
+	TaggedImages      int        `json:"tagged_images"`      // Images with at least one tag
+	Tags              int        `json:"tags"`               // Tags carried by at least one image
+	Taggings          int        `json:"taggings"`           // Image-tag pairs
+	TopTags           []TagCount `json:"top_tags"`           // Most used first, ties by name
+	Directories       []DirCount `json:"directories"`        // Most images first, ties by name
+	OrphanedTags      int        `json:"orphaned_tags"`      // Tags no image carries any more
+	MissingImages     int        `json:"missing_images"`     // Tagged images whose files are gone
+	UnreachableImages int        `json:"unreachable_images"` // Tagged images on a disconnected disk or share
+	FilesChecked      bool       `json:"files_checked"`      // False when the missing counts were skipped
+}
+
+// Stats counts the images, tags and directories of the database, and the
+// entries Clean would remove. Checking for missing files stats every tagged
+// image, using the library roots like Clean does, and stops with ctx's error
+// if ctx ends first.
+func (s *Service) Stats(ctx context.Context, opts StatsOptions) (Stats, error) {
+	stats := Stats{DBPath: s.tagDB.Path(), FilesChecked: !opts.SkipFiles}
+	if info, err := os.Stat(stats.DBPath); err == nil {
+		stats.DBSize = info.Size()
+	}
+	imageTags, err := s.tagDB.GetAllImageTags(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to read image tags for stats: %w", err)
+	}
+	tags, err := s.tagDB.GetAllTags(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to read tags for stats: %w", err)
+	}
+
+	dirs := make(map[string]int)
+	checker := availability.NewChecker(s.roots...)
+	for path, imgTags := range imageTags {
+		if len(imgTags) == 0 {
+			continue
+		}
+		stats.TaggedImages++
+		stats.Taggings += len(imgTags)
+		dirs[filepath.Dir(path)]++
+		if opts.SkipFiles {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		switch checker.Check(path) {
+		case availability.Missing:
+			stats.MissingImages++
+		case availability.Unreachable:
+			stats.UnreachableImages++
+		}
+	}
+
+	var counts []TagCount
+	for _, tag := range tags {
+		if tag.Count == 0 {
+			stats.OrphanedTags++
+			continue
+		}
+		counts = append(counts, TagCount{Tag: tag.Name, Images: tag.Count})
+	}
+	stats.Tags = len(counts)
+	sortTagCounts(counts)
+	top := opts.TopTags
+	if top <= 0 {
+		top = DefaultTopTags
+	}
+	stats.TopTags = counts[:min(top, len(counts))]
+
+	for dir, n := range dirs {
+		stats.Directories = append(stats.Directories, DirCount{Dir: dir, Images: n})
+	}
+	sort.Slice(stats.Directories, func(i, j int) bool {
+		a, b := stats.Directories[i], stats.Directories[j]
+		if a.Images != b.Images {
+			return a.Images > b.Images
+		}
+		return a.Dir < b.Dir
+	})
+	return stats, nil
+}
+
+// ImageSetTags summarizes the tags of a set of images, such as a folder.
+type ImageSetTags struct {
+	Images   int        // Images in the set
+	Tags     []TagCount // Union of their tags, most used first, ties by name
+	Untagged []string   // Images without any tag
+	Missing  []string   // Images without the tag asked about, if any
+}
+
+// TagsOf returns the union of the tags of the images at paths with the number
+// of images carrying each, and, unless missingTag is empty, the images that
+// lack missingTag, in the order of paths.
+func (s *Service) TagsOf(ctx context.Context, paths []string, missingTag string) (ImageSetTags, error) {
+	result := ImageSetTags{Images: len(paths)}
+	imageTags, err := s.tagDB.GetAllImageTags(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to read image tags: %w", err)
+	}
+	counts := make(map[string]int)
+	for _, path := range paths {
+		tags := imageTags[path]
+		if len(tags) == 0 {
+			result.Untagged = append(result.Untagged, path)
+		}
+		for _, tag := range tags {
+			counts[tag]++
+		}
+		if missingTag != "" && !slices.Contains(tags, missingTag) {
+			result.Missing = append(result.Missing, path)
+		}
+	}
+	for tag, n := range counts {
+		result.Tags = append(result.Tags, TagCount{Tag: tag, Images: n})
+	}
+	sortTagCounts(result.Tags)
+	return result, nil
+}
+
+// SiblingTags returns the tags of the other images in the folder of the
+// image at path, with the number of them carrying each, most used first:
+// photos in a folder usually share most of their tags, so these are the
+// likeliest tags for path. Only that folder's entries are read.
+func (s *Service) SiblingTags(ctx context.Context, path string) ([]TagCount, error) {
+	imageTags, err := s.tagDB.GetDirectoryImageTags(ctx, filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for sibling, tags := range imageTags {
+		if sibling == path {
+			continue
+		}
+		for _, tag := range tags {
+			counts[tag]++
+		}
+	}
+	var result []TagCount
+	for tag, n := range counts {
+		result = append(result, TagCount{Tag: tag, Images: n})
+	}
+	sortTagCounts(result)
+	return result, nil
+}
+
+// sortTagCounts orders counts by use, most used first, then by name.
+func sortTagCounts(counts []TagCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Images != counts[j].Images {
+			return counts[i].Images > counts[j].Images
+		}
+		return counts[i].Tag < counts[j].Tag
+	})
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/tagging"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// TrashDirName is the folder next to the tag database deleted images are
+// moved to while the trash is on.
+const TrashDirName = "Trash"
+
+// SetTrash turns the trash on or off. While it is on, deleting an image moves
+// it into the trash folder next to the tag database, from where RestoreImage
+// puts it back with its tags, until PurgeTrash or EmptyTrash delete it for good.
+func (s *Service) SetTrash(on bool) {
+	s.trashDir = ""
+	if on {
+		s.trashDir = filepath.Join(filepath.Dir(s.tagDB.Path()), TrashDirName)
+	}
+}
+
+// TrashDir returns the trash folder, or "" if the trash is off.
+func (s *Service) TrashDir() string {
+	return s.trashDir
+}
+
+// Trash returns the images in the trash, the most recently deleted first.
+func (s *Service) Trash(ctx context.Context) ([]tagging.TrashItem, error) {
+	return s.tagDB.TrashItems(ctx)
+}
+
+// trashImage moves the image file at path into the trash and then its tags
+// into the trash record. If the record can't be stored the file is moved back.
+func (s *Service) trashImage(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.trashDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create trash folder: %w", err)
+	}
+	now := time.Now()
+	item := tagging.TrashItem{
+		ID:      fmt.Sprintf("%d-%s", now.UnixNano(), filepath.Base(path)),
+		Path:    path,
+		Deleted: now,
+		Size:    info.Size(),
+	}
+	item.TrashPath = filepath.Join(s.trashDir, item.ID)
+	if err := moveFile(path, item.TrashPath); err != nil {
+		return fmt.Errorf("failed to move %s to the trash: %w", path, err)
+	}
+	if err := s.tagDB.TrashImage(context.WithoutCancel(ctx), item); err != nil {
+		if backErr := moveFile(item.TrashPath, path); backErr != nil {
+			return fmt.Errorf("%w: %s is left in the trash as %s: %v (moving it back: %v)", ErrTagCleanup, path, item.TrashPath, err, backErr)
+		}
+		return fmt.Errorf("failed to record %s in the trash: %w", path, err)
+	}
+	return nil
+}
+
+// RestoreImage moves the image with trash id back to where it was and gives
+// it its tags back, returning its record. It refuses to overwrite a file
+// that took its place. If the tags can't be restored the file goes back to
+// the trash.
+func (s *Service) RestoreImage(ctx context.Context, id string) (tagging.TrashItem, error) {
+	if s.readOnly {
+		return tagging.TrashItem{}, ErrReadOnly
+	}
+	item, err := s.tagDB.TrashItemOf(ctx, id)
+	if err != nil {
+		return item, err
+	}
+	if _, err := os.Lstat(item.Path); err == nil {
+		return item, fmt.Errorf("%w: %s", ErrDestinationExists, item.Path)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return item, fmt.Errorf("cannot check destination %s: %w", item.Path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(item.Path), 0o755); err != nil {
+		return item, fmt.Errorf("failed to recreate folder of %s: %w", item.Path, err)
+	}
+	if err := moveFile(item.TrashPath, item.Path); err != nil {
+		return item, fmt.Errorf("failed to restore %s: %w", item.Path, err)
+	}
+	if _, err := s.tagDB.RestoreTrashItem(context.WithoutCancel(ctx), id); err != nil {
+		if backErr := moveFile(item.Path, item.TrashPath); backErr != nil {
+			return item, fmt.Errorf("restored %s without its tags: %v (moving it back: %v)", item.Path, err, backErr)
+		}
+		return item, fmt.Errorf("failed to restore the tags of %s: %w", item.Path, err)
+	}
+	return item, nil
+}
+
+// PurgeTrash deletes the image with trash id for good.
+func (s *Service) PurgeTrash(ctx context.Context, id string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	item, err := s.tagDB.TrashItemOf(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(item.TrashPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return s.tagDB.DeleteTrashItem(context.WithoutCancel(ctx), id)
+}
+
+// EmptyTrash deletes the images that have been in the trash for longer than
+// olderThan for good, all of them if it is 0, carrying on past failures.
+// progress, if not nil, is called after each image, with its original path.
+func (s *Service) EmptyTrash(ctx context.Context, olderThan time.Duration, progress func(path string, err error)) BatchResult {
+	items, err := s.tagDB.TrashItems(ctx)
+	if err != nil {
+		return BatchResult{Errors: []ImageError{{Path: s.trashDir, Err: err}}}
+	}
+	cutoff := time.Now().Add(-olderThan)
+	var ids []string
+	paths := make(map[string]string)
+	for _, item := range items {
+		if item.Deleted.Before(cutoff) {
+			ids = append(ids, item.ID)
+			paths[item.ID] = item.Path
+		}
+	}
+	var report func(id string, err error)
+	if progress != nil {
+		report = func(id string, err error) { progress(paths[id], err) }
+	}
+	return s.eachImage(ctx, ids, s.PurgeTrash, report)
+}
+
+// moveFile renames src to dst, copying it across filesystems.
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyFile(src, dst); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyFile copies src to the new file dst, keeping its modification time.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,102 @@
+package slideshow
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// PermutationState is the position of a PermutationManager, enough to
+// continue the same sequence later, e.g. in the next session.
+type PermutationState struct {
+	Seed  int64 `json:"seed"`
+	Round int   `json:"round"` // Completed passes over all items
+	Count int   `json:"count"` // Number of items being shuffled
+	Pos   int   `json:"pos"`   // Items already handed out in this round
+}
+
+// PermutationManager hands out the items of a list in a shuffled order that
+// visits each item once per round. The order is determined by the seed, so
+// the same seed on the same number of items gives the same sequence. Each
+// round is shuffled anew from the seed and the round number.
+type PermutationManager struct {
+	mu    sync.Mutex
+	state PermutationState
+	order []int // The current round; nil until needed
+}
+
+// NewPermutationManager creates a PermutationManager shuffling with seed.
+func NewPermutationManager(seed int64) *PermutationManager {
+	return &PermutationManager{state: PermutationState{Seed: seed}}
+}
+
+// RandomSeed returns a seed for a fresh sequence.
+func RandomSeed() int64 {
+	return time.Now().UnixNano() & (1<<53 - 1) // Small enough to survive JSON and float conversions
+}
+
+// Seed returns the seed of the sequence.
+func (pm *PermutationManager) Seed() int64 {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.state.Seed
+}
+
+// Reseed restarts the sequence from the start of the order given by seed.
+func (pm *PermutationManager) Reseed(seed int64) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.state = PermutationState{Seed: seed}
+	pm.order = nil
+}
+
+// Reshuffle restarts the sequence with a new random seed, which it returns.
+func (pm *PermutationManager) Reshuffle() int64 {
+	seed := RandomSeed()
+	pm.Reseed(seed)
+	return seed
+}
+
+// Next returns the index of the next item out of count. A change of count,
+// such as a new filter, restarts the sequence of the seed over the new items.
+func (pm *PermutationManager) Next(count int) int {
+	if count <= 0 {
+		return 0
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if count != pm.state.Count {
+		pm.state = PermutationState{Seed: pm.state.Seed, Count: count}
+		pm.order = nil
+	}
+	if pm.state.Pos >= count {
+		pm.state.Round++
+		pm.state.Pos = 0
+		pm.order = nil
+	}
+	if pm.order == nil {
+		pm.order = rand.New(rand.NewSource(pm.state.Seed + int64(pm.state.Round))).Perm(count)
+	}
+	next := pm.order[pm.state.Pos]
+	pm.state.Pos++
+	return next
+}
+
+// State returns the current position, for Restore.
+func (pm *PermutationManager) State() PermutationState {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.state
+}
+
+// Restore continues the sequence from state, as returned by State. Going
+// back within the current round keeps its order rather than shuffling again.
+func (pm *PermutationManager) Restore(state PermutationState) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if state.Seed != pm.state.Seed || state.Round != pm.state.Round || state.Count != pm.state.Count {
+		pm.order = nil
+	}
+	pm.state = state
+	pm.state.Pos = max(state.Pos, 0)
+}
@@ -3,6 +3,7 @@ package slideshow
 
 import (
 	"fmt"
+	"math"
 	"sync"
 	"time"
 )
@@ -11,16 +12,61 @@ const (
 	defaultSlideshowInterval = 2 * time.Second
 )
 
+// Images up to these sizes get the base interval; larger or more elongated
+// ones get proportionally longer.
+const (
+	referenceMegapixels = 12.0
+	referenceAspect     = 16.0 / 9.0
+)
+
 // LoggerFunc defines a function signature for logging messages.
 type LoggerFunc func(message string)
 
-// SlideshowManager handles the slideshow functionality.
+// DurationProvider returns how long the current item should stay on screen,
+// given the configured base interval.
+type DurationProvider func(base time.Duration) time.Duration
+
+// AdaptiveBounds limits the display time AdaptiveInterval hands out.
+type AdaptiveBounds struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// AdaptiveInterval scales base by the size of a width x height image: the
+// square root of its megapixels beyond referenceMegapixels, times how much its
+// aspect ratio exceeds referenceAspect, so dense images and panoramas stay on
+// screen longer. The result is clamped to bounds; a zero Max leaves it unbounded.
+func AdaptiveInterval(base time.Duration, width, height int, bounds AdaptiveBounds) time.Duration {
+	d := base
+	if width > 0 && height > 0 {
+		megapixels := float64(width) * float64(height) / 1e6
+		aspect := float64(max(width, height)) / float64(min(width, height))
+		factor := max(1, math.Sqrt(megapixels/referenceMegapixels)) * max(1, aspect/referenceAspect)
+		d = time.Duration(float64(base) * factor)
+	}
+	if bounds.Max > 0 {
+		d = min(d, bounds.Max)
+	}
+	return max(d, bounds.Min)
+}
+
+// SlideshowManager handles the slideshow functionality: whether it plays and
+// the timer advancing it; see Start.
 type SlideshowManager struct {
 	mu                 sync.Mutex
 	isPaused           bool
 	wasPlayingBeforeOp bool // Tracks if slideshow was playing before a temp pause
 	interval           time.Duration
+	durationProvider   DurationProvider // Optional per-item display time
 	logger             LoggerFunc
+	onPausedChanged    func(paused bool)
+
+	// The timer, armed by Start and Reset
+	advance   func()        // Called when the item on screen has had its time
+	timer     *time.Timer   // Nil until Start
+	itemTime  time.Duration // Display time of the item on screen
+	timerGen  int           // Counts the arms, so a timer stopped too late doesn't fire
+	isStopped bool
 }
 
 // NewSlideshowManager creates a new SlideshowManager.
@@ -47,8 +93,28 @@ func (sm *SlideshowManager) logMsg(format string, args ...interface{}) {
 	}
 }
 
+// SetOnPausedChanged registers f to be called, without the lock held, whenever
+// the slideshow switches between playing and paused.
+func (sm *SlideshowManager) SetOnPausedChanged(f func(paused bool)) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.onPausedChanged = f
+}
+
+// notify runs the change callback if the paused state differs from before.
+// Deferred by the state-changing methods before they take the lock.
+func (sm *SlideshowManager) notify(before bool) {
+	sm.mu.Lock()
+	paused, f := sm.isPaused, sm.onPausedChanged
+	sm.mu.Unlock()
+	if f != nil && paused != before {
+		f(paused)
+	}
+}
+
 // TogglePlayPause toggles the play/pause state.
 func (sm *SlideshowManager) TogglePlayPause() {
+	defer sm.notify(sm.IsPaused())
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 	sm.isPaused = !sm.isPaused
@@ -63,6 +129,7 @@ func (sm *SlideshowManager) TogglePlayPause() {
 // Pause forces the slideshow to pause.
 // If forOperation is true, it remembers if the slideshow was playing.
 func (sm *SlideshowManager) Pause(forOperation bool) {
+	defer sm.notify(sm.IsPaused())
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 	if forOperation {
@@ -76,6 +143,7 @@ func (sm *SlideshowManager) Pause(forOperation bool) {
 
 // ResumeAfterOperation resumes the slideshow only if it was playing before Pause(true) was called.
 func (sm *SlideshowManager) ResumeAfterOperation() {
+	defer sm.notify(sm.IsPaused())
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 	if sm.wasPlayingBeforeOp {
@@ -100,3 +168,106 @@ func (sm *SlideshowManager) Interval() time.Duration {
 	defer sm.mu.Unlock()
 	return sm.interval
 }
+
+// SetInterval changes the time between automatic transitions; a non-positive
+// interval restores the default. Once started, the item on screen gets its
+// full display time by the new interval, see Reset.
+func (sm *SlideshowManager) SetInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultSlideshowInterval
+	}
+	sm.mu.Lock()
+	changed := interval != sm.interval
+	sm.interval = interval
+	sm.mu.Unlock()
+	if changed {
+		sm.logMsg("Slideshow interval set to %v", interval)
+		sm.Reset()
+	}
+}
+
+// Start starts the timer: advance is called, on the timer's goroutine, each
+// time the item on screen has been shown for its display time while playing.
+// The time passes on while paused, so playing again advances at the next beat.
+func (sm *SlideshowManager) Start(advance func()) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.timer != nil || sm.isStopped {
+		return
+	}
+	sm.advance = advance
+	sm.itemTime = sm.interval
+	sm.arm()
+}
+
+// Reset gives the item on screen its full display time from now, so an item
+// navigated to by hand isn't advanced from a moment later. It asks the
+// duration provider, if any, for the time; see ItemInterval. Before Start and
+// after Stop it does nothing.
+func (sm *SlideshowManager) Reset() {
+	d, _ := sm.ItemInterval()
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.timer == nil || sm.isStopped {
+		return
+	}
+	sm.itemTime = d
+	sm.arm()
+}
+
+// Stop stops the timer for good, e.g. as the window closes.
+func (sm *SlideshowManager) Stop() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.isStopped = true
+	if sm.timer != nil {
+		sm.timer.Stop()
+	}
+}
+
+// arm restarts the timer with the item's display time. Called with the lock held.
+func (sm *SlideshowManager) arm() {
+	if sm.timer != nil {
+		sm.timer.Stop()
+	}
+	sm.timerGen++
+	gen := sm.timerGen
+	sm.timer = time.AfterFunc(sm.itemTime, func() { sm.fire(gen) })
+}
+
+// fire advances unless paused and arms the timer for the next beat, unless a
+// later arm or Stop superseded the arm numbered gen.
+func (sm *SlideshowManager) fire(gen int) {
+	sm.mu.Lock()
+	if sm.isStopped || gen != sm.timerGen {
+		sm.mu.Unlock()
+		return
+	}
+	sm.arm()
+	paused, advance := sm.isPaused, sm.advance
+	sm.mu.Unlock()
+	if !paused && advance != nil {
+		advance()
+	}
+}
+
+// SetDurationProvider registers p to decide each item's display time. A nil p
+// restores the fixed interval.
+func (sm *SlideshowManager) SetDurationProvider(p DurationProvider) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.durationProvider = p
+}
+
+// ItemInterval returns how long the current item should be shown, and false
+// when no provider is set and every item gets the fixed interval. The provider
+// runs without the lock held.
+func (sm *SlideshowManager) ItemInterval() (time.Duration, bool) {
+	sm.mu.Lock()
+	p, base := sm.durationProvider, sm.interval
+	sm.mu.Unlock()
+	if p == nil {
+		return base, false
+	}
+	return p(base), true
+}
@@ -0,0 +1,133 @@
+package slideshow
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveInterval(t *testing.T) {
+	base := 2 * time.Second
+	bounds := AdaptiveBounds{Min: time.Second, Max: 10 * time.Second}
+	tests := []struct {
+		name          string
+		width, height int
+		want          time.Duration
+	}{
+		{"small 4:3", 1600, 1200, base},
+		{"at reference size", 4000, 3000, base},
+		{"48 megapixels", 8000, 6000, 2 * base},
+		{"panorama", 8000, 1500, time.Duration(float64(base) * (8000.0 / 1500.0) / (16.0 / 9.0))},
+		{"huge panorama capped", 40000, 4000, bounds.Max},
+		{"unknown size", 0, 0, base},
+	}
+	for _, tt := range tests {
+		if got := AdaptiveInterval(base, tt.width, tt.height, bounds); got != tt.want {
+			t.Errorf("%s: AdaptiveInterval(%dx%d) = %v, want %v", tt.name, tt.width, tt.height, got, tt.want)
+		}
+	}
+
+	if got := AdaptiveInterval(base, 100, 100, AdaptiveBounds{Min: 3 * time.Second}); got != 3*time.Second {
+		t.Errorf("AdaptiveInterval below Min = %v, want 3s", got)
+	}
+}
+
+func TestItemInterval(t *testing.T) {
+	sm := NewSlideshowManager(2*time.Second, nil)
+	if d, ok := sm.ItemInterval(); ok || d != 2*time.Second {
+		t.Errorf("ItemInterval without provider = %v, %v; want 2s, false", d, ok)
+	}
+	sm.SetDurationProvider(func(base time.Duration) time.Duration { return 3 * base })
+	if d, ok := sm.ItemInterval(); !ok || d != 6*time.Second {
+		t.Errorf("ItemInterval with provider = %v, %v; want 6s, true", d, ok)
+	}
+	sm.SetDurationProvider(nil)
+	if _, ok := sm.ItemInterval(); ok {
+		t.Error("ItemInterval after clearing the provider reports a provider")
+	}
+	sm.SetInterval(5 * time.Second)
+	if d, _ := sm.ItemInterval(); d != 5*time.Second {
+		t.Errorf("ItemInterval after SetInterval(5s) = %v", d)
+	}
+	sm.SetInterval(0)
+	if d := sm.Interval(); d != defaultSlideshowInterval {
+		t.Errorf("Interval after SetInterval(0) = %v, want the default", d)
+	}
+}
+
+func TestTimer(t *testing.T) {
+	const interval = 40 * time.Millisecond
+	sm := NewSlideshowManager(interval, nil)
+	advanced := make(chan time.Time, 10)
+	sm.Reset() // Not started yet: nothing to reset
+	start := time.Now()
+	sm.Start(func() { advanced <- time.Now() })
+	if at := <-advanced; at.Sub(start) < interval {
+		t.Errorf("advanced after %v, before the interval of %v", at.Sub(start), interval)
+	}
+
+	time.Sleep(interval / 2)
+	reset := time.Now()
+	sm.Reset()
+	if at := <-advanced; at.Sub(reset) < interval {
+		t.Errorf("advanced %v after Reset, want the full interval of %v", at.Sub(reset), interval)
+	}
+
+	sm.Pause(false)
+	time.Sleep(3 * interval)
+	select {
+	case <-advanced:
+		t.Error("advanced while paused")
+	default:
+	}
+	sm.TogglePlayPause()
+	<-advanced
+
+	sm.Stop()
+	drain := len(advanced)
+	time.Sleep(3 * interval)
+	if len(advanced) > drain {
+		t.Error("advanced after Stop")
+	}
+}
+
+func TestPermutationManager(t *testing.T) {
+	const count = 20
+	draw := func(pm *PermutationManager, n int) []int {
+		var seq []int
+		for range n {
+			seq = append(seq, pm.Next(count))
+		}
+		return seq
+	}
+
+	pm := NewPermutationManager(42)
+	first := draw(pm, count)
+	seen := make(map[int]bool)
+	for _, i := range first {
+		seen[i] = true
+	}
+	if len(seen) != count {
+		t.Errorf("One round visited %d of %d items: %v", len(seen), count, first)
+	}
+	if again := draw(NewPermutationManager(42), count); !slices.Equal(again, first) {
+		t.Errorf("Same seed gave %v, then %v", first, again)
+	}
+
+	// Restoring a saved state continues the same sequence.
+	pm.Reseed(7)
+	draw(pm, count+5)
+	state := pm.State()
+	want := draw(pm, 10)
+	restored := NewPermutationManager(0)
+	restored.Restore(state)
+	if got := draw(restored, 10); !slices.Equal(got, want) {
+		t.Errorf("Restored sequence = %v, want %v", got, want)
+	}
+
+	// A different number of items starts over.
+	pm.Next(count - 1)
+	if s := pm.State(); s.Count != count-1 || s.Pos != 1 || s.Round != 0 {
+		t.Errorf("State after the count changed = %+v", s)
+	}
+}
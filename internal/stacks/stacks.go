@@ -0,0 +1,195 @@
+// Package stacks groups images, such as the shots of a burst, into stacks that
+// are shown as a single item until expanded.
+//
+// Membership is stored as a "stack:<id>" tag on every image of a stack, so it
+// travels with the tag database through exports, merges and renames.
+package stacks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fyslide/internal/imagesig"
+	"fyslide/internal/scan"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TagPrefix starts the tag marking an image's stack.
+const TagPrefix = "stack:"
+
+// Tag returns the tag marking the images of stack id.
+func Tag(id string) string {
+	return TagPrefix + id
+}
+
+// ID returns the stack id a tag marks, if it is a stack tag.
+func ID(tag string) (string, bool) {
+	id, ok := strings.CutPrefix(tag, TagPrefix)
+	return id, ok && id != ""
+}
+
+// NewID returns a random id for a new stack.
+func NewID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Index maps images to their stacks and back.
+type Index struct {
+	stackOf map[string]string   // Image path to stack id
+	members map[string][]string // Stack id to sorted image paths
+}
+
+// NewIndex builds an index from the images of each stack id.
+func NewIndex(members map[string][]string) *Index {
+	ix := &Index{stackOf: make(map[string]string), members: make(map[string][]string)}
+	for id, paths := range members {
+		for _, path := range paths {
+			ix.Add(path, id)
+		}
+	}
+	return ix
+}
+
+// Len returns the number of stacks.
+func (ix *Index) Len() int {
+	if ix == nil {
+		return 0
+	}
+	return len(ix.members)
+}
+
+// StackOf returns the stack id of the image at path, or "" if it isn't stacked.
+func (ix *Index) StackOf(path string) string {
+	if ix == nil {
+		return ""
+	}
+	return ix.stackOf[path]
+}
+
+// Members returns the sorted image paths of stack id.
+func (ix *Index) Members(id string) []string {
+	if ix == nil {
+		return nil
+	}
+	return ix.members[id]
+}
+
+// Add puts the image at path into stack id, taking it out of any other stack.
+func (ix *Index) Add(path, id string) {
+	ix.Remove(path)
+	ix.stackOf[path] = id
+	members := append(ix.members[id], path)
+	sort.Strings(members)
+	ix.members[id] = members
+}
+
+// Remove takes the image at path out of its stack. A stack left with a single
+// image is kept; it is shown like an unstacked image.
+func (ix *Index) Remove(path string) {
+	id, ok := ix.stackOf[path]
+	if !ok {
+		return
+	}
+	delete(ix.stackOf, path)
+	members := slices.DeleteFunc(ix.members[id], func(p string) bool { return p == path })
+	if len(members) == 0 {
+		delete(ix.members, id)
+	} else {
+		ix.members[id] = members
+	}
+}
+
+// Collapse returns items with each stack that is not expanded reduced to its
+// first item, which stands for the whole stack. Stacks with a single item in
+// items need no collapsing, so items is returned unchanged when nothing collapses.
+func (ix *Index) Collapse(items scan.FileItems, expanded map[string]bool) scan.FileItems {
+	if ix.Len() == 0 {
+		return items
+	}
+	seen := make(map[string]bool)
+	var out scan.FileItems
+	for i, item := range items {
+		id := ix.stackOf[item.Path]
+		if id == "" || expanded[id] {
+			if out != nil {
+				out = append(out, item)
+			}
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			if out != nil {
+				out = append(out, item)
+			}
+			continue
+		}
+		if out == nil {
+			out = append(make(scan.FileItems, 0, len(items)), items[:i]...) // First item dropped: copy from here on
+		}
+	}
+	if out == nil {
+		return items
+	}
+	return out
+}
+
+// Candidate is an image considered for automatic stacking.
+type Candidate struct {
+	Path  string
+	Taken time.Time           // Capture time, e.g. from EXIF, otherwise modification time
+	Sig   *imagesig.Signature // Content signature; nil skips the similarity check
+}
+
+// AutoOptions control automatic stacking.
+type AutoOptions struct {
+	MaxGap      time.Duration // Longest time between consecutive shots of a stack
+	MaxHashDist int           // Most perceptual hash bits consecutive shots may differ in
+}
+
+// DefaultAutoOptions suit the bursts of phone and camera shots.
+var DefaultAutoOptions = AutoOptions{MaxGap: 2 * time.Second, MaxHashDist: 12}
+
+// AutoGroup groups candidates into stacks of images in the same folder taken
+// in quick succession that, when both have signatures, look alike. Only groups
+// of two or more images are returned, each sorted by capture time.
+func AutoGroup(candidates []Candidate, opts AutoOptions) [][]string {
+	sorted := slices.Clone(candidates)
+	slices.SortStableFunc(sorted, func(a, b Candidate) int {
+		if c := strings.Compare(filepath.Dir(a.Path), filepath.Dir(b.Path)); c != 0 {
+			return c
+		}
+		return a.Taken.Compare(b.Taken)
+	})
+	var groups [][]string
+	var current []string
+	flush := func() {
+		if len(current) > 1 {
+			groups = append(groups, current)
+		}
+		current = nil
+	}
+	for i, c := range sorted {
+		if i > 0 && !belongTogether(sorted[i-1], c, opts) {
+			flush()
+		}
+		current = append(current, c.Path)
+	}
+	flush()
+	return groups
+}
+
+// belongTogether reports whether b continues the burst a is in.
+func belongTogether(a, b Candidate, opts AutoOptions) bool {
+	if filepath.Dir(a.Path) != filepath.Dir(b.Path) || a.Taken.IsZero() || b.Taken.IsZero() {
+		return false
+	}
+	if b.Taken.Sub(a.Taken) > opts.MaxGap {
+		return false
+	}
+	return a.Sig == nil || b.Sig == nil || imagesig.HashDistance(*a.Sig, *b.Sig) <= opts.MaxHashDist
+}
@@ -0,0 +1,83 @@
+package stacks
+
+import (
+	"fyslide/internal/imagesig"
+	"fyslide/internal/scan"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func paths(items scan.FileItems) []string {
+	var out []string
+	for _, item := range items {
+		out = append(out, item.Path)
+	}
+	return out
+}
+
+func TestTagAndID(t *testing.T) {
+	if id, ok := ID(Tag("ab12")); !ok || id != "ab12" {
+		t.Errorf("ID(Tag(ab12)) = %q, %v", id, ok)
+	}
+	for _, tag := range []string{"stack:", "beach"} {
+		if _, ok := ID(tag); ok {
+			t.Errorf("ID(%q) reported a stack", tag)
+		}
+	}
+	if a, b := NewID(), NewID(); a == b || len(a) != 8 {
+		t.Errorf("NewID returned %q and %q", a, b)
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	items := scan.FileItems{{Path: "/a"}, {Path: "/b1"}, {Path: "/b2"}, {Path: "/c"}, {Path: "/b3"}}
+	ix := NewIndex(map[string][]string{"b": {"/b2", "/b1", "/b3"}})
+
+	if got, want := paths(ix.Collapse(items, nil)), []string{"/a", "/b1", "/c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Collapse = %v, want %v", got, want)
+	}
+	if got := ix.Collapse(items, map[string]bool{"b": true}); len(got) != len(items) {
+		t.Errorf("Collapse with the stack expanded = %v", paths(got))
+	}
+	if got, want := ix.Members("b"), []string{"/b1", "/b2", "/b3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Members = %v, want %v", got, want)
+	}
+
+	ix.Remove("/b2")
+	ix.Add("/c", "b")
+	if got, want := paths(ix.Collapse(items, nil)), []string{"/a", "/b1", "/b2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Collapse after changes = %v, want %v", got, want)
+	}
+	ix.Remove("/b1")
+	ix.Remove("/b3")
+	ix.Remove("/c")
+	if ix.Len() != 0 || ix.StackOf("/c") != "" {
+		t.Errorf("emptied index still has %d stacks", ix.Len())
+	}
+
+	var empty *Index
+	if got := empty.Collapse(items, nil); len(got) != len(items) {
+		t.Errorf("Collapse without stacks = %v", paths(got))
+	}
+}
+
+func TestAutoGroup(t *testing.T) {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	alike := &imagesig.Signature{Hash: 0xff00}
+	different := &imagesig.Signature{Hash: 0x00ff_ffff_0000_0000}
+	candidates := []Candidate{
+		{Path: "/p/3.jpg", Taken: base.Add(2 * time.Second), Sig: alike},
+		{Path: "/p/1.jpg", Taken: base, Sig: alike},
+		{Path: "/p/2.jpg", Taken: base.Add(time.Second), Sig: alike},
+		{Path: "/p/4.jpg", Taken: base.Add(3 * time.Second), Sig: different}, // Quick but another scene
+		{Path: "/p/5.jpg", Taken: base.Add(time.Minute)},
+		{Path: "/p/6.jpg", Taken: base.Add(time.Minute + time.Second)},
+		{Path: "/q/7.jpg", Taken: base.Add(time.Minute + 2*time.Second)}, // Another folder
+	}
+	got := AutoGroup(candidates, DefaultAutoOptions)
+	want := [][]string{{"/p/1.jpg", "/p/2.jpg", "/p/3.jpg"}, {"/p/5.jpg", "/p/6.jpg"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AutoGroup = %v, want %v", got, want)
+	}
+}
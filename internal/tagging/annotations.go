@@ -0,0 +1,91 @@
+package tagging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"fyslide/internal/annotate"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// AnnotationsBucket holds the annotations drawn over images, keyed by path.
+const AnnotationsBucket = "Annotations"
+
+// Annotations returns the annotations of the image at path, if any.
+func (tdb *TagDB) Annotations(ctx context.Context, path string) ([]annotate.Shape, error) {
+	var shapes []annotate.Shape
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(AnnotationsBucket))
+		if bucket == nil {
+			return nil // Nothing annotated yet
+		}
+		data := bucket.Get([]byte(path))
+		if data == nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, &shapes); err != nil {
+			return fmt.Errorf("failed to decode annotations of %s: %w", path, err)
+		}
+		return nil
+	})
+	return shapes, err
+}
+
+// SetAnnotations replaces the annotations of the image at path; no shapes
+// removes them.
+func (tdb *TagDB) SetAnnotations(ctx context.Context, path string, shapes []annotate.Shape) error {
+	if path == "" {
+		return fmt.Errorf("image path cannot be empty")
+	}
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
+		return putAnnotations(tx, []byte(path), shapes)
+	})
+}
+
+// putAnnotations stores shapes under key within tx.
+func putAnnotations(tx *bolt.Tx, key []byte, shapes []annotate.Shape) error {
+	if len(shapes) == 0 {
+		if bucket := tx.Bucket([]byte(AnnotationsBucket)); bucket != nil {
+			return bucket.Delete(key)
+		}
+		return nil
+	}
+	data, err := json.Marshal(shapes)
+	if err != nil {
+		return fmt.Errorf("failed to encode annotations of %s: %w", key, err)
+	}
+	bucket, err := tx.CreateBucketIfNotExists([]byte(AnnotationsBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create bucket %s: %w", AnnotationsBucket, err)
+	}
+	return bucket.Put(key, data)
+}
+
+// imageDataBuckets hold data about an image, keyed by its path, that follows
+// the image when it is renamed and goes when it is deleted.
+var imageDataBuckets = []string{AnnotationsBucket, OCRTextBucket}
+
+// moveImageData gives the annotations and extracted text of oldPath to
+// newPath within tx, or drops them if newPath is "".
+func moveImageData(tx *bolt.Tx, oldPath, newPath string) error {
+	for _, name := range imageDataBuckets {
+		bucket := tx.Bucket([]byte(name))
+		if bucket == nil {
+			continue
+		}
+		data := bucket.Get([]byte(oldPath))
+		if data == nil {
+			continue
+		}
+		if newPath != "" {
+			if err := bucket.Put([]byte(newPath), data); err != nil {
+				return fmt.Errorf("failed to move %s of %s to %s: %w", name, oldPath, newPath, err)
+			}
+		}
+		if err := bucket.Delete([]byte(oldPath)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
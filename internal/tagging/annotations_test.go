@@ -0,0 +1,52 @@
+package tagging
+
+import (
+	"context"
+	"fyslide/internal/annotate"
+	"reflect"
+	"testing"
+)
+
+func TestAnnotations(t *testing.T) {
+	ctx := context.Background()
+	tdb := openTestDB(t, "me")
+	shapes := []annotate.Shape{
+		{Kind: annotate.KindBox, X1: 0.1, Y1: 0.1, X2: 0.4, Y2: 0.3, Color: "#ff3b30"},
+		{Kind: annotate.KindText, X1: 0.5, Y1: 0.5, Text: "tear", Color: "#ffffff"},
+	}
+	expect := func(path string, want []annotate.Shape) {
+		t.Helper()
+		got, err := tdb.Annotations(ctx, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Annotations(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	expect("/p/a.jpg", nil)
+	if err := tdb.SetAnnotations(ctx, "/p/a.jpg", shapes); err != nil {
+		t.Fatal(err)
+	}
+	expect("/p/a.jpg", shapes)
+
+	// Annotations follow a rename, even of an untagged image, and go with a deletion.
+	if err := tdb.RenameImage(ctx, "/p/a.jpg", "/p/b.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	expect("/p/a.jpg", nil)
+	expect("/p/b.jpg", shapes)
+	if err := tdb.RemoveAllTagsForImage(ctx, "/p/b.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	expect("/p/b.jpg", nil)
+
+	if err := tdb.SetAnnotations(ctx, "/p/c.jpg", shapes); err != nil {
+		t.Fatal(err)
+	}
+	if err := tdb.SetAnnotations(ctx, "/p/c.jpg", nil); err != nil {
+		t.Fatal(err)
+	}
+	expect("/p/c.jpg", nil)
+}
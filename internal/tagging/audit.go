@@ -0,0 +1,184 @@
+package tagging
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// AuditBucket holds the append-only log of changes made to the database.
+const AuditBucket = "AuditLog"
+
+// AuditAction names the kind of change an audit event records.
+type AuditAction string
+
+// Recorded actions.
+const (
+	ActionAdd    AuditAction = "add"    // A tag was added to an image
+	ActionRemove AuditAction = "remove" // A tag was removed from an image
+	ActionRename AuditAction = "rename" // An image's tags moved to a new path
+	ActionDelete AuditAction = "delete" // An image was deleted along with its tags
+	ActionClean  AuditAction = "clean"  // Stale entries were removed by a cleanup
+)
+
+// AuditEvent is one entry of the audit log.
+type AuditEvent struct {
+	Seq    uint64      `json:"-"` // Position in the log, assigned when recorded
+	Time   time.Time   `json:"time"`
+	User   string      `json:"user"`
+	Action AuditAction `json:"action"`
+	Path   string      `json:"path,omitempty"`
+	Tag    string      `json:"tag,omitempty"`
+	Tags   []string    `json:"tags,omitempty"`   // Tags the image carried, for renames, deletions and cleanups
+	Detail string      `json:"detail,omitempty"` // The new path of a rename, or where a merged change came from
+}
+
+// AuditFilter selects events from the audit log. Zero fields match everything.
+type AuditFilter struct {
+	Tag   string    // Only events for this tag, or for images that carried it
+	Path  string    // Only events whose path contains this text (case-insensitive)
+	Since time.Time // Only events at or after this time
+	Limit int       // Keep only the most recent Limit matches; <= 0 keeps all
+}
+
+// Matches reports whether ev passes the filter, ignoring Limit.
+func (f AuditFilter) Matches(ev AuditEvent) bool {
+	if f.Tag != "" && ev.Tag != f.Tag && !slices.Contains(ev.Tags, f.Tag) {
+		return false
+	}
+	if f.Path != "" {
+		needle := strings.ToLower(f.Path)
+		if !strings.Contains(strings.ToLower(ev.Path), needle) &&
+			!(ev.Action == ActionRename && strings.Contains(strings.ToLower(ev.Detail), needle)) {
+			return false
+		}
+	}
+	return f.Since.IsZero() || !ev.Time.Before(f.Since)
+}
+
+// Summary describes what the event affected, e.g. "'cats' on /p/a.jpg".
+func (ev AuditEvent) Summary() string {
+	switch ev.Action {
+	case ActionRename:
+		return fmt.Sprintf("%s -> %s", ev.Path, ev.Detail)
+	case ActionDelete, ActionClean:
+		if ev.Path == "" {
+			return fmt.Sprintf("tag '%s'", ev.Tag)
+		}
+		return fmt.Sprintf("%s (tags: %s)", ev.Path, strings.Join(ev.Tags, ", "))
+	}
+	if ev.Detail != "" {
+		return fmt.Sprintf("'%s' on %s (%s)", ev.Tag, ev.Path, ev.Detail)
+	}
+	return fmt.Sprintf("'%s' on %s", ev.Tag, ev.Path)
+}
+
+// defaultActor returns the name changes are attributed to: the OS user.
+func defaultActor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, env := range []string{"USER", "USERNAME"} {
+		if name := os.Getenv(env); name != "" {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// SetActor sets the name recorded as the user of subsequent changes.
+func (tdb *TagDB) SetActor(name string) {
+	tdb.actor = name
+}
+
+// audit appends ev to the log within tx, so the event is stored if and only if
+// the change it describes is. The time and user default to now and the actor.
+func (tdb *TagDB) audit(tx *bolt.Tx, ev AuditEvent) error {
+	b := tx.Bucket([]byte(AuditBucket))
+	seq, err := b.NextSequence()
+	if err != nil {
+		return fmt.Errorf("allocating audit sequence: %w", err)
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	if ev.User == "" {
+		ev.User = tdb.actor
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("encoding audit event: %w", err)
+	}
+	if err := b.Put(binary.BigEndian.AppendUint64(nil, seq), data); err != nil {
+		return fmt.Errorf("storing audit event: %w", err)
+	}
+	return nil
+}
+
+// AuditLog returns the events matching filter in the order they were recorded.
+func (tdb *TagDB) AuditLog(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	var events []AuditEvent
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(AuditBucket))
+		if b == nil {
+			return nil // Opened read-only from a version without the log
+		}
+		return b.ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var ev AuditEvent
+			if err := json.Unmarshal(v, &ev); err != nil {
+				return fmt.Errorf("decoding audit event: %w", err)
+			}
+			ev.Seq = binary.BigEndian.Uint64(k)
+			if filter.Matches(ev) {
+				events = append(events, ev)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[len(events)-filter.Limit:]
+	}
+	return events, nil
+}
+
+// WriteAuditCSV writes events as CSV with a header row.
+func WriteAuditCSV(w io.Writer, events []AuditEvent) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"seq", "time", "user", "action", "path", "tag", "tags", "detail"}); err != nil {
+		return err
+	}
+	for _, ev := range events {
+		record := []string{
+			strconv.FormatUint(ev.Seq, 10),
+			ev.Time.Format(time.RFC3339),
+			ev.User,
+			string(ev.Action),
+			ev.Path,
+			ev.Tag,
+			strings.Join(ev.Tags, ";"),
+			ev.Detail,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,86 @@
+package tagging
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	tdb, err := NewTagDB(t.TempDir(), func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tdb.Close()
+	tdb.SetActor("bob")
+
+	steps := []func() error{
+		func() error { return tdb.AddTag(context.Background(), "/p/a.jpg", "cats") },
+		func() error { return tdb.AddTag(context.Background(), "/p/a.jpg", "cats") }, // No change, not recorded
+		func() error { return tdb.AddTag(context.Background(), "/p/b.jpg", "dogs") },
+		func() error { return tdb.RemoveTag(context.Background(), "/p/b.jpg", "nope") }, // No change, not recorded
+		func() error { return tdb.RenameImage(context.Background(), "/p/a.jpg", "/p/c.jpg") },
+		func() error { return tdb.RemoveAllTagsForImage(context.Background(), "/p/c.jpg") },
+		func() error { return tdb.CleanImage(context.Background(), "/p/b.jpg") },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d failed: %v", i, err)
+		}
+	}
+
+	events, err := tdb.AuditLog(context.Background(), AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AuditAction{ActionAdd, ActionAdd, ActionRename, ActionDelete, ActionClean}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, ev := range events {
+		if ev.Action != want[i] || ev.User != "bob" || ev.Seq != uint64(i+1) || ev.Time.IsZero() {
+			t.Errorf("event %d = %+v, want action %s by bob", i, ev, want[i])
+		}
+	}
+	if ev := events[3]; ev.Path != "/p/c.jpg" || len(ev.Tags) != 1 || ev.Tags[0] != "cats" {
+		t.Errorf("delete event = %+v, want path /p/c.jpg with its tags", ev)
+	}
+
+	filtered := func(f AuditFilter) []AuditAction {
+		t.Helper()
+		events, err := tdb.AuditLog(context.Background(), f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actions []AuditAction
+		for _, ev := range events {
+			actions = append(actions, ev.Action)
+		}
+		return actions
+	}
+	// The tag filter also finds renames and deletions of images that carried the tag.
+	if got := filtered(AuditFilter{Tag: "cats"}); len(got) != 3 || got[2] != ActionDelete {
+		t.Errorf("tag filter = %v, want add, rename and delete", got)
+	}
+	// The path filter matches renames by their new path too.
+	if got := filtered(AuditFilter{Path: "C.JPG"}); len(got) != 2 || got[0] != ActionRename {
+		t.Errorf("path filter = %v, want rename and delete", got)
+	}
+	if got := filtered(AuditFilter{Limit: 1}); len(got) != 1 || got[0] != ActionClean {
+		t.Errorf("limit = %v, want the last event only", got)
+	}
+	if got := filtered(AuditFilter{Since: time.Now().Add(time.Hour)}); len(got) != 0 {
+		t.Errorf("since filter = %v, want nothing", got)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAuditCSV(&buf, events[:1]); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "1,") || !strings.HasSuffix(lines[1], ",bob,add,/p/a.jpg,cats,,") {
+		t.Errorf("CSV = %q", buf.String())
+	}
+}
@@ -0,0 +1,75 @@
+package tagging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BookmarksBucket holds the named viewing positions, keyed by name.
+const BookmarksBucket = "Bookmarks"
+
+// Bookmark is a named position in the slideshow to come back to.
+type Bookmark struct {
+	Name    string          `json:"-"` // Unique, the bucket key
+	Path    string          `json:"path"`
+	Index   int             `json:"index"`            // Position in the list, used when Path is gone
+	Filter  json.RawMessage `json:"filter,omitempty"` // Filter criteria as saved by the viewer
+	Random  bool            `json:"random,omitempty"` // Whether playback was in random order
+	Created time.Time       `json:"created"`
+}
+
+// SaveBookmark stores b, replacing any bookmark of the same name.
+func (tdb *TagDB) SaveBookmark(ctx context.Context, b Bookmark) error {
+	if b.Name == "" {
+		return fmt.Errorf("bookmark name must not be empty")
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to encode bookmark '%s': %w", b.Name, err)
+	}
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(BookmarksBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket %s: %w", BookmarksBucket, err)
+		}
+		return bucket.Put([]byte(b.Name), data)
+	})
+}
+
+// DeleteBookmark removes the bookmark called name, if any.
+func (tdb *TagDB) DeleteBookmark(ctx context.Context, name string) error {
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BookmarksBucket))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(name))
+	})
+}
+
+// Bookmarks returns every bookmark, most recently created first.
+func (tdb *TagDB) Bookmarks(ctx context.Context) ([]Bookmark, error) {
+	var bookmarks []Bookmark
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BookmarksBucket))
+		if bucket == nil {
+			return nil // No bookmarks saved yet
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var b Bookmark
+			if err := json.Unmarshal(v, &b); err != nil {
+				return fmt.Errorf("failed to decode bookmark '%s': %w", k, err)
+			}
+			b.Name = string(k)
+			bookmarks = append(bookmarks, b)
+			return nil
+		})
+	})
+	sort.SliceStable(bookmarks, func(i, j int) bool { return bookmarks[i].Created.After(bookmarks[j].Created) })
+	return bookmarks, err
+}
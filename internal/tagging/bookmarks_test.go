@@ -0,0 +1,52 @@
+package tagging
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestBookmarks(t *testing.T) {
+	tdb, err := NewTagDB(t.TempDir(), func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tdb.Close()
+
+	if bookmarks, err := tdb.Bookmarks(context.Background()); err != nil || len(bookmarks) != 0 {
+		t.Fatalf("Bookmarks of a new database = %v, %v", bookmarks, err)
+	}
+	if err := tdb.SaveBookmark(context.Background(), Bookmark{}); err == nil {
+		t.Error("SaveBookmark accepted an empty name")
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	vacation := Bookmark{Name: "2019 vacation", Path: "/p/a.jpg", Index: 41, Filter: json.RawMessage(`{"Tags":["vacation"]}`), Created: start}
+	for _, b := range []Bookmark{
+		vacation,
+		{Name: "cats", Path: "/p/cat.jpg", Random: true, Created: start.Add(time.Hour)},
+		{Name: "2019 vacation", Path: "/p/b.jpg", Index: 42, Filter: vacation.Filter, Created: start.Add(2 * time.Hour)}, // Replaces the first
+	} {
+		if err := tdb.SaveBookmark(context.Background(), b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bookmarks, err := tdb.Bookmarks(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 2 || bookmarks[0].Name != "2019 vacation" || bookmarks[0].Path != "/p/b.jpg" || bookmarks[1].Name != "cats" || !bookmarks[1].Random {
+		t.Fatalf("Bookmarks = %+v", bookmarks)
+	}
+	if string(bookmarks[0].Filter) != `{"Tags":["vacation"]}` {
+		t.Errorf("filter = %s", bookmarks[0].Filter)
+	}
+
+	if err := tdb.DeleteBookmark(context.Background(), "cats"); err != nil {
+		t.Fatal(err)
+	}
+	if bookmarks, _ := tdb.Bookmarks(context.Background()); len(bookmarks) != 1 {
+		t.Errorf("%d bookmarks left after deleting one of two", len(bookmarks))
+	}
+}
@@ -0,0 +1,170 @@
+package tagging
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// PathChange is an image whose tags moved to another path.
+type PathChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Retag is an image whose tags changed. An image new to the database has
+// only Added tags, one that left it only Removed ones.
+type Retag struct {
+	Path    string   `json:"path"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// DBDiff is what changed from one tag database to another.
+type DBDiff struct {
+	AddedTags   []string     `json:"addedTags"`   // Tags only the new database uses
+	RemovedTags []string     `json:"removedTags"` // Tags only the old database uses
+	Moved       []PathChange `json:"moved"`       // Images whose tags now belong to another path
+	Retagged    []Retag      `json:"retagged"`    // Images whose tags differ, by their new path
+}
+
+// Empty reports whether the databases hold the same tags.
+func (d DBDiff) Empty() bool {
+	return len(d.AddedTags) == 0 && len(d.RemovedTags) == 0 && len(d.Moved) == 0 && len(d.Retagged) == 0
+}
+
+// Diff compares the tags of two databases, such as snapshots taken before
+// and after a bulk operation. An image is taken to have moved when the new
+// database's audit log records the rename, or when an image left and one of
+// the same file name with the same tags arrived.
+func Diff(ctx context.Context, old, new *TagDB) (DBDiff, error) {
+	var d DBDiff
+	before, err := old.GetAllImageTags(ctx)
+	if err != nil {
+		return d, err
+	}
+	after, err := new.GetAllImageTags(ctx)
+	if err != nil {
+		return d, err
+	}
+
+	// Pair the images that left with those that arrived.
+	gone := func(path string) bool { _, ok := after[path]; return !ok && before[path] != nil }
+	arrived := func(path string) bool { _, ok := before[path]; return !ok && after[path] != nil }
+	movedTo := make(map[string]string)   // Old path -> new path
+	movedFrom := make(map[string]string) // New path -> old path
+	events, err := new.AuditLog(ctx, AuditFilter{})
+	if err != nil {
+		return d, err
+	}
+	for _, ev := range events {
+		if ev.Action != ActionRename {
+			continue
+		}
+		from := ev.Path
+		if to, ok := movedTo[from]; ok { // Renamed again; follow the chain from the original path
+			delete(movedTo, from)
+			delete(movedFrom, to)
+		}
+		if orig, ok := movedFrom[from]; ok {
+			delete(movedFrom, from)
+			from = orig
+		}
+		movedTo[from], movedFrom[ev.Detail] = ev.Detail, from
+	}
+	for from, to := range movedTo { // Keep only renames that explain the difference
+		if !gone(from) || !arrived(to) {
+			delete(movedTo, from)
+			delete(movedFrom, to)
+		}
+	}
+	byName := make(map[string][]string) // File name -> arrived paths not yet paired
+	for path := range after {
+		if arrived(path) && movedFrom[path] == "" {
+			byName[filepath.Base(path)] = append(byName[filepath.Base(path)], path)
+		}
+	}
+	for _, from := range sortedKeys(before) {
+		if !gone(from) || movedTo[from] != "" {
+			continue
+		}
+		candidates := byName[filepath.Base(from)]
+		sort.Strings(candidates)
+		for i, to := range candidates {
+			if sameTags(before[from], after[to]) {
+				movedTo[from], movedFrom[to] = to, from
+				byName[filepath.Base(from)] = slices.Delete(candidates, i, i+1)
+				break
+			}
+		}
+	}
+	for from, to := range movedTo {
+		d.Moved = append(d.Moved, PathChange{From: from, To: to})
+	}
+	sort.Slice(d.Moved, func(i, j int) bool { return d.Moved[i].From < d.Moved[j].From })
+
+	// Compare every image's tags with those it had before, at its old path if it moved.
+	paths := make(map[string]bool)
+	for path := range after {
+		paths[path] = true
+	}
+	for path := range before {
+		if movedTo[path] == "" {
+			paths[path] = true
+		}
+	}
+	for _, path := range sortedKeys(paths) {
+		was := before[path]
+		if from := movedFrom[path]; from != "" {
+			was = before[from]
+		}
+		r := Retag{Path: path, Added: missingFrom(after[path], was), Removed: missingFrom(was, after[path])}
+		if len(r.Added) > 0 || len(r.Removed) > 0 {
+			d.Retagged = append(d.Retagged, r)
+		}
+	}
+
+	oldTags, newTags := tagSet(before), tagSet(after)
+	d.AddedTags = missingFrom(sortedKeys(newTags), sortedKeys(oldTags))
+	d.RemovedTags = missingFrom(sortedKeys(oldTags), sortedKeys(newTags))
+	return d, nil
+}
+
+// missingFrom returns the tags of tags that other lacks, sorted.
+func missingFrom(tags, other []string) []string {
+	var missing []string
+	for _, tag := range tags {
+		if !slices.Contains(other, tag) {
+			missing = append(missing, tag)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// sameTags reports whether a and b hold the same tags in any order.
+func sameTags(a, b []string) bool {
+	return len(a) == len(b) && len(missingFrom(a, b)) == 0
+}
+
+// tagSet returns every tag used in imageTags.
+func tagSet(imageTags map[string][]string) map[string]bool {
+	set := make(map[string]bool)
+	for _, tags := range imageTags {
+		for _, tag := range tags {
+			set[tag] = true
+		}
+	}
+	return set
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,47 @@
+package tagging
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	ctx := context.Background()
+	old := openTestDB(t, "me")
+	new := openTestDB(t, "me")
+	for _, tdb := range []*TagDB{old, new} {
+		step(t, tdb.AddTag(ctx, "/p/a.jpg", "cat"))
+		step(t, tdb.AddTag(ctx, "/p/b.jpg", "dog"))
+		step(t, tdb.AddTag(ctx, "/p/c.jpg", "sea"))
+		step(t, tdb.AddTag(ctx, "/p/d.jpg", "sky"))
+	}
+	step(t, new.AddTag(ctx, "/p/a.jpg", "pet"))            // Retagged
+	step(t, new.RenameImage(ctx, "/p/b.jpg", "/q/b2.jpg")) // Moved, per the audit log
+	step(t, new.AddTag(ctx, "/q/b2.jpg", "pet"))
+	step(t, new.RemoveTag(ctx, "/p/c.jpg", "sea")) // Untagged
+	step(t, new.RemoveTag(ctx, "/p/d.jpg", "sky")) // Moved by hand: same name and tags
+	step(t, new.AddTag(ctx, "/r/d.jpg", "sky"))
+
+	got, err := Diff(ctx, old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DBDiff{
+		AddedTags:   []string{"pet"},
+		RemovedTags: []string{"sea"},
+		Moved:       []PathChange{{From: "/p/b.jpg", To: "/q/b2.jpg"}, {From: "/p/d.jpg", To: "/r/d.jpg"}},
+		Retagged: []Retag{
+			{Path: "/p/a.jpg", Added: []string{"pet"}},
+			{Path: "/p/c.jpg", Removed: []string{"sea"}},
+			{Path: "/q/b2.jpg", Added: []string{"pet"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %+v\nwant %+v", got, want)
+	}
+
+	if got, err := Diff(ctx, old, old); err != nil || !got.Empty() {
+		t.Errorf("Diff of a database with itself = %+v, %v; want empty", got, err)
+	}
+}
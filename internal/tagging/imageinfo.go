@@ -0,0 +1,80 @@
+package tagging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ImageInfoBucket caches facts read from image files, keyed by path.
+const ImageInfoBucket = "ImageInfo"
+
+// ImageInfo is what was read from an image file, kept so the file needn't be
+// read again until it changes.
+type ImageInfo struct {
+	Path         string    `json:"-"`       // The bucket key
+	ModTime      time.Time `json:"modTime"` // The file's, when it was read
+	Size         int64     `json:"size"`
+	Taken        time.Time `json:"taken"`                  // EXIF date taken, else the file's modification time
+	DateFromEXIF bool      `json:"dateFromExif,omitempty"` // Whether Taken came from EXIF data
+	Camera       string    `json:"camera,omitempty"`       // EXIF camera model, else make
+	Width        int       `json:"width,omitempty"`        // Zero when the image couldn't be decoded
+	Height       int       `json:"height,omitempty"`
+	Lat          float64   `json:"lat,omitempty"` // EXIF GPS position in degrees, when HasGPS
+	Long         float64   `json:"long,omitempty"`
+	HasGPS       bool      `json:"hasGps,omitempty"`
+	Version      int       `json:"version,omitempty"` // Of the reader that made the record; older records are read again
+}
+
+// Current reports whether info was read from the file as it is now.
+func (info ImageInfo) Current(modTime time.Time, size int64) bool {
+	return info.ModTime.Equal(modTime) && info.Size == size
+}
+
+// PutImageInfo stores infos, replacing what was stored for their paths.
+func (tdb *TagDB) PutImageInfo(ctx context.Context, infos []ImageInfo) error {
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(ImageInfoBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket %s: %w", ImageInfoBucket, err)
+		}
+		for _, info := range infos {
+			data, err := json.Marshal(info)
+			if err != nil {
+				return fmt.Errorf("failed to encode image info for %s: %w", info.Path, err)
+			}
+			if err := bucket.Put([]byte(info.Path), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ImageInfos returns everything stored by PutImageInfo, by path. Entries of
+// files that no longer exist are included; callers look up the images they hold.
+func (tdb *TagDB) ImageInfos(ctx context.Context) (map[string]ImageInfo, error) {
+	infos := make(map[string]ImageInfo)
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(ImageInfoBucket))
+		if bucket == nil {
+			return nil // Nothing indexed yet
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var info ImageInfo
+			if err := json.Unmarshal(v, &info); err != nil {
+				return fmt.Errorf("failed to decode image info for %s: %w", k, err)
+			}
+			info.Path = string(k)
+			infos[info.Path] = info
+			return nil
+		})
+	})
+	return infos, err
+}
@@ -0,0 +1,36 @@
+package tagging
+
+import (
+	"context"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ImportsBucket records the files imported from camera cards, keyed by the
+// hex SHA-256 of their content, with the library path of each as the value.
+const ImportsBucket = "Imports"
+
+// ImportedPath returns the library path the file with content hash was
+// imported to, or "" if it was never imported.
+func (tdb *TagDB) ImportedPath(ctx context.Context, hash string) (string, error) {
+	var path string
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket([]byte(ImportsBucket)); bucket != nil {
+			path = string(bucket.Get([]byte(hash)))
+		}
+		return nil
+	})
+	return path, err
+}
+
+// RecordImport remembers that the file with content hash was imported to path.
+func (tdb *TagDB) RecordImport(ctx context.Context, hash, path string) error {
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(ImportsBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket %s: %w", ImportsBucket, err)
+		}
+		return bucket.Put([]byte(hash), []byte(path))
+	})
+}
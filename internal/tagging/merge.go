@@ -0,0 +1,251 @@
+package tagging
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// PathMapping rewrites a path prefix of the other database to the local one,
+// for libraries mounted at different places on each machine.
+type PathMapping struct {
+	From, To string
+}
+
+// ParsePathMapping parses a mapping written as "from=to".
+func ParsePathMapping(text string) (PathMapping, error) {
+	from, to, ok := strings.Cut(text, "=")
+	if !ok || from == "" || to == "" {
+		return PathMapping{}, fmt.Errorf("invalid path mapping %q (want from=to)", text)
+	}
+	return PathMapping{From: from, To: to}, nil
+}
+
+// MergeOptions configure TagDB.Merge.
+type MergeOptions struct {
+	Source  string        // Describes the other database in the audit log, e.g. its file name
+	PathMap []PathMapping // Applied to the other database's paths; the first matching prefix wins
+	DryRun  bool          // Compute the changes without applying them
+}
+
+// MergeChange is one tag association added or removed by a merge.
+type MergeChange struct {
+	Action AuditAction // ActionAdd or ActionRemove
+	Path   string
+	Tag    string
+	Time   time.Time // When the change was made in the other database; zero if it predates the audit log
+	User   string
+}
+
+// MergeStats summarizes a merge.
+type MergeStats struct {
+	Changes []MergeChange // Applied (or, in a dry run, pending) changes, sorted by path and tag
+	Ignored int           // Changes from the other side that lost to newer local ones
+}
+
+// pairTimes records when an image-tag association was last added and removed.
+type pairTimes struct {
+	added, removed     time.Time
+	addedBy, removedBy string
+}
+
+// pairHistory maps path -> tag -> times, replayed from an audit log.
+type pairHistory map[string]map[string]*pairTimes
+
+func (h pairHistory) at(path, tag string) *pairTimes {
+	tags := h[path]
+	if tags == nil {
+		tags = make(map[string]*pairTimes)
+		h[path] = tags
+	}
+	if tags[tag] == nil {
+		tags[tag] = &pairTimes{}
+	}
+	return tags[tag]
+}
+
+// times returns the recorded times, zero if the pair has no history.
+func (h pairHistory) times(path, tag string) pairTimes {
+	if p := h[path][tag]; p != nil {
+		return *p
+	}
+	return pairTimes{}
+}
+
+func (p *pairTimes) add(ev AuditEvent) {
+	if !ev.Time.Before(p.added) {
+		p.added, p.addedBy = ev.Time, ev.User
+	}
+}
+
+func (p *pairTimes) remove(ev AuditEvent) {
+	if !ev.Time.Before(p.removed) {
+		p.removed, p.removedBy = ev.Time, ev.User
+	}
+}
+
+// replayHistory derives the last add and removal of every pair from events.
+// Merged changes are logged with their original time, after newer local
+// ones, so the latest time wins rather than the latest event.
+func replayHistory(events []AuditEvent, mapPath func(string) string) pairHistory {
+	h := make(pairHistory)
+	removeAll := func(ev AuditEvent, path string) {
+		for _, tag := range ev.Tags {
+			h.at(path, tag).remove(ev)
+		}
+	}
+	for _, ev := range events {
+		path := mapPath(ev.Path)
+		switch ev.Action {
+		case ActionAdd:
+			h.at(path, ev.Tag).add(ev)
+		case ActionRemove:
+			h.at(path, ev.Tag).remove(ev)
+		case ActionRename:
+			removeAll(ev, path)
+			newPath := mapPath(ev.Detail)
+			for _, tag := range ev.Tags {
+				h.at(newPath, tag).add(ev)
+			}
+		case ActionDelete, ActionClean:
+			if path != "" {
+				removeAll(ev, path)
+			}
+		}
+	}
+	return h
+}
+
+// mapper returns a function applying the first matching mapping to a path.
+func mapper(mappings []PathMapping) func(string) string {
+	return func(path string) string {
+		for _, m := range mappings {
+			if strings.HasPrefix(path, m.From) {
+				return m.To + strings.TrimPrefix(path, m.From)
+			}
+		}
+		return path
+	}
+}
+
+// Merge brings the changes made in other into this database without losing
+// either side's additions. Associations present on only one side are decided
+// per image-tag pair by the last writer, using both audit logs: an association
+// missing here is added unless it was removed here after the other side added
+// it, and one missing there is removed here only if the other side removed it
+// after it was added here. Applied changes are recorded in the audit log with
+// their original time and user, so repeated merges in either direction agree.
+// All changes are applied in one transaction, so a merge cancelled through ctx
+// changes nothing.
+func (tdb *TagDB) Merge(ctx context.Context, other *TagDB, opts MergeOptions) (MergeStats, error) {
+	var stats MergeStats
+	mapPath := mapper(opts.PathMap)
+
+	ours, err := tdb.GetAllImageTags(ctx)
+	if err != nil {
+		return stats, err
+	}
+	theirsRaw, err := other.GetAllImageTags(ctx)
+	if err != nil {
+		return stats, err
+	}
+	theirs := make(map[string]map[string]bool, len(theirsRaw))
+	for path, tags := range theirsRaw {
+		path = mapPath(path)
+		if theirs[path] == nil {
+			theirs[path] = make(map[string]bool)
+		}
+		for _, tag := range tags {
+			theirs[path][tag] = true
+		}
+	}
+	ourEvents, err := tdb.AuditLog(ctx, AuditFilter{})
+	if err != nil {
+		return stats, err
+	}
+	theirEvents, err := other.AuditLog(ctx, AuditFilter{})
+	if err != nil {
+		return stats, err
+	}
+	ourHistory := replayHistory(ourEvents, func(path string) string { return path })
+	theirHistory := replayHistory(theirEvents, mapPath)
+
+	ourSet := make(map[string]map[string]bool, len(ours))
+	for path, tags := range ours {
+		ourSet[path] = make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			ourSet[path][tag] = true
+		}
+	}
+
+	for path, tags := range theirs {
+		for tag := range tags {
+			if ourSet[path][tag] {
+				continue
+			}
+			o, t := ourHistory.times(path, tag), theirHistory.times(path, tag)
+			if o.removed.After(t.added) {
+				stats.Ignored++ // Removed here after it was added there
+				continue
+			}
+			stats.Changes = append(stats.Changes, MergeChange{Action: ActionAdd, Path: path, Tag: tag, Time: t.added, User: t.addedBy})
+		}
+	}
+	for path, tags := range ourSet {
+		for tag := range tags {
+			if theirs[path][tag] {
+				continue
+			}
+			o, t := ourHistory.times(path, tag), theirHistory.times(path, tag)
+			if !t.removed.After(o.added) {
+				if !t.removed.IsZero() {
+					stats.Ignored++ // Removed there, but added again here since
+				}
+				continue
+			}
+			stats.Changes = append(stats.Changes, MergeChange{Action: ActionRemove, Path: path, Tag: tag, Time: t.removed, User: t.removedBy})
+		}
+	}
+	sort.Slice(stats.Changes, func(i, j int) bool {
+		a, b := stats.Changes[i], stats.Changes[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Tag < b.Tag
+	})
+	if opts.DryRun || len(stats.Changes) == 0 {
+		return stats, nil
+	}
+
+	detail := "merged"
+	if opts.Source != "" {
+		detail = "merged from " + opts.Source
+	}
+	err = tdb.update(ctx, func(tx *bolt.Tx) error {
+		for _, c := range stats.Changes {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			add := c.Action == ActionAdd
+			if _, err := tdb._updateStoredList(tx, []byte(ImagesToTagsBucket), []byte(c.Path), c.Tag, add); err != nil {
+				return fmt.Errorf("merging tag '%s' of %s: %w", c.Tag, c.Path, err)
+			}
+			if _, err := tdb._updateStoredList(tx, []byte(TagsToImagesBucket), []byte(c.Tag), c.Path, add); err != nil {
+				return fmt.Errorf("merging image %s of tag '%s': %w", c.Path, c.Tag, err)
+			}
+			ev := AuditEvent{Action: c.Action, Path: c.Path, Tag: c.Tag, Time: c.Time, User: c.User, Detail: detail}
+			if err := tdb.audit(tx, ev); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return MergeStats{}, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,146 @@
+package tagging
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func openTestDB(t *testing.T, actor string) *TagDB {
+	t.Helper()
+	tdb, err := NewTagDB(t.TempDir(), func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tdb.Close() })
+	tdb.SetActor(actor)
+	return tdb
+}
+
+// step runs a change and waits a little, so consecutive changes get distinct times.
+func step(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Millisecond)
+}
+
+func merge(t *testing.T, into, from *TagDB) MergeStats {
+	t.Helper()
+	stats, err := into.Merge(context.Background(), from, MergeOptions{Source: "test"})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	return stats
+}
+
+func expectTags(t *testing.T, tdb *TagDB, path string, want ...string) {
+	t.Helper()
+	got, err := tdb.GetTags(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want) == 0 {
+		want = nil
+	}
+	if len(got) == 0 {
+		got = nil
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s tags = %v, want %v", path, got, want)
+	}
+}
+
+func TestMergeLastWriterWins(t *testing.T) {
+	desktop := openTestDB(t, "desk")
+	laptop := openTestDB(t, "lap")
+
+	// Additions on both sides are kept.
+	step(t, desktop.AddTag(context.Background(), "/p/a.jpg", "beach"))
+	step(t, laptop.AddTag(context.Background(), "/p/a.jpg", "family"))
+	step(t, laptop.AddTag(context.Background(), "/p/b.jpg", "city"))
+	merge(t, desktop, laptop)
+	merge(t, laptop, desktop)
+	expectTags(t, desktop, "/p/a.jpg", "beach", "family")
+	expectTags(t, laptop, "/p/a.jpg", "beach", "family")
+	expectTags(t, desktop, "/p/b.jpg", "city")
+
+	// A removal made after the add is carried over.
+	step(t, laptop.RemoveTag(context.Background(), "/p/a.jpg", "beach"))
+	stats := merge(t, desktop, laptop)
+	if len(stats.Changes) != 1 || stats.Changes[0].Action != ActionRemove || stats.Changes[0].User != "lap" {
+		t.Errorf("changes = %+v, want laptop's removal of beach", stats.Changes)
+	}
+	expectTags(t, desktop, "/p/a.jpg", "family")
+
+	// Re-adding after the other side removed wins over the older removal.
+	step(t, laptop.RemoveTag(context.Background(), "/p/b.jpg", "city"))
+	step(t, desktop.AddTag(context.Background(), "/p/b.jpg", "city"))
+	step(t, desktop.RemoveTag(context.Background(), "/p/b.jpg", "city"))
+	step(t, desktop.AddTag(context.Background(), "/p/b.jpg", "city"))
+	if stats := merge(t, desktop, laptop); len(stats.Changes) != 0 || stats.Ignored != 1 {
+		t.Errorf("stats = %+v, want laptop's older removal ignored", stats)
+	}
+	expectTags(t, desktop, "/p/b.jpg", "city")
+	merge(t, laptop, desktop)
+	expectTags(t, laptop, "/p/b.jpg", "city")
+
+	// Both sides have converged, so merging again changes nothing.
+	if stats := merge(t, desktop, laptop); len(stats.Changes) != 0 {
+		t.Errorf("repeated merge changed %+v", stats.Changes)
+	}
+	if stats := merge(t, laptop, desktop); len(stats.Changes) != 0 {
+		t.Errorf("repeated reverse merge changed %+v", stats.Changes)
+	}
+}
+
+func TestMergeRenameAndPathMap(t *testing.T) {
+	local := openTestDB(t, "me")
+	remote := openTestDB(t, "them")
+	step(t, local.AddTag(context.Background(), "/home/me/Photos/x.jpg", "old"))
+	step(t, remote.AddTag(context.Background(), "/Users/me/Photos/x.jpg", "old"))
+	step(t, remote.RenameImage(context.Background(), "/Users/me/Photos/x.jpg", "/Users/me/Photos/y.jpg"))
+
+	mapping, err := ParsePathMapping("/Users/me/Photos=/home/me/Photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := local.Merge(context.Background(), remote, MergeOptions{PathMap: []PathMapping{mapping}, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Changes) != 2 {
+		t.Fatalf("dry run changes = %+v, want the rename as a removal and an add", stats.Changes)
+	}
+	expectTags(t, local, "/home/me/Photos/x.jpg", "old") // Dry run leaves the database alone
+
+	if _, err := local.Merge(context.Background(), remote, MergeOptions{PathMap: []PathMapping{mapping}}); err != nil {
+		t.Fatal(err)
+	}
+	expectTags(t, local, "/home/me/Photos/x.jpg")
+	expectTags(t, local, "/home/me/Photos/y.jpg", "old")
+
+	if _, err := ParsePathMapping("no-equals"); err == nil {
+		t.Error("ParsePathMapping accepted a mapping without '='")
+	}
+}
+
+func TestOpenReadOnlyExport(t *testing.T) {
+	tdb := openTestDB(t, "me")
+	step(t, tdb.AddTag(context.Background(), "/p/a.jpg", "cats"))
+	copyPath := t.TempDir() + "/copy.db"
+	if err := tdb.Export(context.Background(), copyPath); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	ro, err := OpenReadOnly(copyPath, func(string) {})
+	if err != nil {
+		t.Fatalf("OpenReadOnly failed: %v", err)
+	}
+	defer ro.Close()
+	expectTags(t, ro, "/p/a.jpg", "cats")
+	if err := ro.AddTag(context.Background(), "/p/a.jpg", "dogs"); err == nil {
+		t.Error("AddTag succeeded on a read-only database")
+	}
+}
@@ -0,0 +1,92 @@
+package tagging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// OCRTextBucket holds the text extracted from images by OCR, keyed by path.
+const OCRTextBucket = "OCRText"
+
+// OCRText is the text read from an image file, kept with the file's state so
+// it is extracted again only when the file changes.
+type OCRText struct {
+	Path    string    `json:"-"`       // The bucket key
+	Text    string    `json:"text"`    // Empty when the image holds no text
+	ModTime time.Time `json:"modTime"` // The file's, when the text was extracted
+	Size    int64     `json:"size"`
+}
+
+// Current reports whether the text was extracted from the file as it is now.
+func (t OCRText) Current(modTime time.Time, size int64) bool {
+	return t.ModTime.Equal(modTime) && t.Size == size
+}
+
+// PutOCRText stores texts, replacing what was stored for their paths.
+func (tdb *TagDB) PutOCRText(ctx context.Context, texts []OCRText) error {
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(OCRTextBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket %s: %w", OCRTextBucket, err)
+		}
+		for _, text := range texts {
+			data, err := json.Marshal(text)
+			if err != nil {
+				return fmt.Errorf("failed to encode text of %s: %w", text.Path, err)
+			}
+			if err := bucket.Put([]byte(text.Path), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// OCRTextOf returns the text extracted from the image at path; ok is false
+// if none was.
+func (tdb *TagDB) OCRTextOf(ctx context.Context, path string) (text OCRText, ok bool, err error) {
+	err = tdb.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(OCRTextBucket))
+		if bucket == nil {
+			return nil // Nothing extracted yet
+		}
+		data := bucket.Get([]byte(path))
+		if data == nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, &text); err != nil {
+			return fmt.Errorf("failed to decode text of %s: %w", path, err)
+		}
+		text.Path, ok = path, true
+		return nil
+	})
+	return text, ok, err
+}
+
+// OCRTexts returns everything stored by PutOCRText, by path.
+func (tdb *TagDB) OCRTexts(ctx context.Context) (map[string]OCRText, error) {
+	texts := make(map[string]OCRText)
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(OCRTextBucket))
+		if bucket == nil {
+			return nil // Nothing extracted yet
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var text OCRText
+			if err := json.Unmarshal(v, &text); err != nil {
+				return fmt.Errorf("failed to decode text of %s: %w", k, err)
+			}
+			text.Path = string(k)
+			texts[text.Path] = text
+			return nil
+		})
+	})
+	return texts, err
+}
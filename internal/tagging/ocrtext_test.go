@@ -0,0 +1,46 @@
+package tagging
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOCRText(t *testing.T) {
+	ctx := context.Background()
+	tdb := openTestDB(t, "me")
+	mod := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	expect := func(path, want string, wantOK bool) {
+		t.Helper()
+		got, ok, err := tdb.OCRTextOf(ctx, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != wantOK || got.Text != want {
+			t.Errorf("OCRTextOf(%s) = %q, %v; want %q, %v", path, got.Text, ok, want, wantOK)
+		}
+	}
+
+	expect("/p/a.jpg", "", false)
+	step(t, tdb.PutOCRText(ctx, []OCRText{
+		{Path: "/p/a.jpg", Text: "INVOICE 42", ModTime: mod, Size: 100},
+		{Path: "/p/blank.jpg", ModTime: mod, Size: 50},
+	}))
+	expect("/p/a.jpg", "INVOICE 42", true)
+	expect("/p/blank.jpg", "", true)
+
+	texts, err := tdb.OCRTexts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(texts) != 2 || !texts["/p/a.jpg"].Current(mod, 100) || texts["/p/a.jpg"].Current(mod, 101) {
+		t.Errorf("OCRTexts = %v", texts)
+	}
+
+	// The text follows a rename and goes with a deletion.
+	step(t, tdb.RenameImage(ctx, "/p/a.jpg", "/p/b.jpg"))
+	expect("/p/a.jpg", "", false)
+	expect("/p/b.jpg", "INVOICE 42", true)
+	step(t, tdb.RemoveAllTagsForImage(ctx, "/p/b.jpg"))
+	expect("/p/b.jpg", "", false)
+}
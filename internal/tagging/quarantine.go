@@ -0,0 +1,95 @@
+package tagging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// QuarantineBucket records the images that failed to load in the viewer,
+// keyed by path.
+const QuarantineBucket = "Quarantine"
+
+// QuarantineAfter is how many failed loads put an image into quarantine,
+// where the viewer skips it until it is retried.
+const QuarantineAfter = 3
+
+// LoadFailure records how often an image failed to load and why it last did.
+type LoadFailure struct {
+	Path       string    `json:"-"` // The bucket key
+	Failures   int       `json:"failures"`
+	LastError  string    `json:"lastError"`
+	LastFailed time.Time `json:"lastFailed"`
+}
+
+// Quarantined reports whether the image failed often enough to be skipped.
+func (f LoadFailure) Quarantined() bool {
+	return f.Failures >= QuarantineAfter
+}
+
+// RecordLoadFailure counts a failed load of the image at path, caused by
+// loadErr, and returns its updated record.
+func (tdb *TagDB) RecordLoadFailure(ctx context.Context, path string, loadErr error) (LoadFailure, error) {
+	f := LoadFailure{Path: path}
+	if path == "" {
+		return f, fmt.Errorf("image path cannot be empty")
+	}
+	err := tdb.update(ctx, func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(QuarantineBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket %s: %w", QuarantineBucket, err)
+		}
+		if data := bucket.Get([]byte(path)); data != nil {
+			if err := json.Unmarshal(data, &f); err != nil {
+				return fmt.Errorf("failed to decode load failures of %s: %w", path, err)
+			}
+		}
+		f.Failures++
+		f.LastFailed = time.Now()
+		if loadErr != nil {
+			f.LastError = loadErr.Error()
+		}
+		data, err := json.Marshal(f)
+		if err != nil {
+			return fmt.Errorf("failed to encode load failures of %s: %w", path, err)
+		}
+		return bucket.Put([]byte(path), data)
+	})
+	return f, err
+}
+
+// ClearLoadFailures forgets the failed loads of the image at path, if any,
+// taking it out of quarantine.
+func (tdb *TagDB) ClearLoadFailures(ctx context.Context, path string) error {
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(QuarantineBucket))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(path))
+	})
+}
+
+// LoadFailures returns the records of every image that failed to load, by path.
+func (tdb *TagDB) LoadFailures(ctx context.Context) ([]LoadFailure, error) {
+	var failures []LoadFailure
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(QuarantineBucket))
+		if bucket == nil {
+			return nil // Nothing failed yet
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var f LoadFailure
+			if err := json.Unmarshal(v, &f); err != nil {
+				return fmt.Errorf("failed to decode load failures of %s: %w", k, err)
+			}
+			f.Path = string(k)
+			failures = append(failures, f)
+			return nil
+		})
+	})
+	return failures, err
+}
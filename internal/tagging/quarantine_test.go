@@ -0,0 +1,57 @@
+package tagging
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLoadFailures(t *testing.T) {
+	tdb, err := NewTagDB(t.TempDir(), func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tdb.Close()
+	ctx := context.Background()
+
+	if failures, err := tdb.LoadFailures(ctx); err != nil || len(failures) != 0 {
+		t.Fatalf("LoadFailures of a new database = %v, %v", failures, err)
+	}
+	if _, err := tdb.RecordLoadFailure(ctx, "", nil); err == nil {
+		t.Error("RecordLoadFailure accepted an empty path")
+	}
+
+	var f LoadFailure
+	for i := 1; i <= QuarantineAfter; i++ {
+		if f, err = tdb.RecordLoadFailure(ctx, "/p/broken.jpg", errors.New("unexpected EOF")); err != nil {
+			t.Fatal(err)
+		}
+		if f.Failures != i || f.Quarantined() != (i == QuarantineAfter) {
+			t.Errorf("after %d failure(s) the record is %+v, quarantined %v", i, f, f.Quarantined())
+		}
+	}
+	if f.LastError != "unexpected EOF" || f.LastFailed.IsZero() {
+		t.Errorf("record = %+v, want the last error and time", f)
+	}
+	if _, err := tdb.RecordLoadFailure(ctx, "/p/slow.jpg", errors.New("timeout")); err != nil {
+		t.Fatal(err)
+	}
+
+	failures, err := tdb.LoadFailures(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 2 || failures[0].Path != "/p/broken.jpg" || !failures[0].Quarantined() || failures[1].Path != "/p/slow.jpg" || failures[1].Quarantined() {
+		t.Fatalf("LoadFailures = %+v", failures)
+	}
+
+	if err := tdb.ClearLoadFailures(ctx, "/p/broken.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if failures, _ := tdb.LoadFailures(ctx); len(failures) != 1 || failures[0].Path != "/p/slow.jpg" {
+		t.Errorf("after clearing, LoadFailures = %+v", failures)
+	}
+	if err := tdb.ClearLoadFailures(ctx, "/p/unknown.jpg"); err != nil {
+		t.Errorf("clearing an image without failures: %v", err)
+	}
+}
@@ -1,23 +1,40 @@
 // Package tagging provides functionality for managing image tags using a BoltDB database.
 // It allows adding, removing, and retrieving tags associated with image paths.
 // It also provides a way to retrieve all unique tags in the database.
+//
+// Every TagDB method that touches the database takes a context. Each call is a
+// single transaction: if the context ends before it commits, the transaction
+// is rolled back and the context's error returned, so a cancelled call leaves
+// the database as it was. Reads that walk a whole bucket check the context at
+// every entry and stop early.
 package tagging // Or place within your ui package if preferred
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"fyslide/internal/paths"
 	"log"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"time"
 
 	bolt "go.etcd.io/bbolt"
 )
 
 const (
-	dbFileName         = "fyslide_tags.db"
-	ImagesToTagsBucket = "ImagesToTags" // Exported
-	TagsToImagesBucket = "TagsToImages" // Exported
+	DBFileName         = paths.DBFileName // Name of the database file inside its directory
+	ImagesToTagsBucket = "ImagesToTags"   // Exported
+	TagsToImagesBucket = "TagsToImages"   // Exported
+
+	// CorruptTag marks images that could not be decoded, for later cleanup.
+	CorruptTag = "corrupt"
+	// PrivateTag is the default tag hiding images from browsing and exports
+	// until the private images are unlocked.
+	PrivateTag = "private"
 )
 
 // LoggerFunc defines a function signature for logging messages.
@@ -28,6 +45,7 @@ type LoggerFunc func(message string)
 type TagDB struct {
 	db     *bolt.DB
 	logger LoggerFunc
+	actor  string // Recorded as the user of changes in the audit log
 }
 
 // TagWithCount holds a tag name and the number of images associated with it.
@@ -41,26 +59,21 @@ type TagWithCount struct {
 // logger is a function that will be used for logging messages.
 func NewTagDB(dbDir string, logger LoggerFunc) (*TagDB, error) {
 	if dbDir == "" {
-		// Default to user config directory or current directory if needed
-		configDir, err := os.UserConfigDir()
+		// Default to the per-user data directory or current directory if needed
+		dataDir, err := paths.DBDir()
 		if err != nil {
-			log.Printf("Warning: Could not get user config dir: %v. Using current dir.", err)
+			log.Printf("Warning: Could not locate the database directory: %v. Using current dir.", err)
 			dbDir = "." // Fallback to current directory
 		} else {
-			appName := "fyslide" // Consider making this a constant if used elsewhere
-			// Attempt to get executable name for the app folder if possible, otherwise default.
-			// For simplicity, we'll stick to a fixed name.
-			appConfigDir := filepath.Join(configDir, appName) // App specific subfolder
-
 			// Ensure the directory exists
-			if err := os.MkdirAll(appConfigDir, 0750); err != nil {
-				return nil, fmt.Errorf("failed to create config directory %s: %w", appConfigDir, err)
+			if err := os.MkdirAll(dataDir, 0750); err != nil {
+				return nil, fmt.Errorf("failed to create database directory %s: %w", dataDir, err)
 			}
-			dbDir = appConfigDir
+			dbDir = dataDir
 		}
 	}
 
-	dbPath := filepath.Join(dbDir, dbFileName)
+	dbPath := filepath.Join(dbDir, DBFileName)
 	// Use the provided logger if available for this initial message
 	if logger != nil {
 		logger(fmt.Sprintf("Using tag database at: %s", dbPath))
@@ -83,6 +96,10 @@ func NewTagDB(dbDir string, logger LoggerFunc) (*TagDB, error) {
 		if err != nil {
 			return fmt.Errorf("failed to create bucket %s: %w", TagsToImagesBucket, err)
 		}
+		_, err = tx.CreateBucketIfNotExists([]byte(AuditBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket %s: %w", AuditBucket, err)
+		}
 		return nil
 	})
 
@@ -91,7 +108,62 @@ func NewTagDB(dbDir string, logger LoggerFunc) (*TagDB, error) {
 		return nil, err
 	}
 
-	return &TagDB{db: db, logger: logger}, nil
+	return &TagDB{db: db, logger: logger, actor: defaultActor()}, nil
+}
+
+// OpenReadOnly opens the tag database file at dbPath without modifying it, e.g.
+// a copy from another machine to merge from.
+func OpenReadOnly(dbPath string, logger LoggerFunc) (*TagDB, error) {
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tag database %s: %w", dbPath, err)
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(ImagesToTagsBucket)) == nil {
+			return fmt.Errorf("%s is not a FySlide tag database", dbPath)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &TagDB{db: db, logger: logger, actor: defaultActor()}, nil
+}
+
+// Export writes a consistent copy of the database to path, which is safe to
+// take while the database is in use.
+func (tdb *TagDB) Export(ctx context.Context, path string) error {
+	return tdb.view(ctx, func(tx *bolt.Tx) error {
+		return tx.CopyFile(path, 0600)
+	})
+}
+
+// Path returns the file the database is stored in.
+func (tdb *TagDB) Path() string {
+	return tdb.db.Path()
+}
+
+// update runs fn in a read-write transaction unless ctx is already done.
+// The transaction is rolled back if fn fails or ctx ends before it commits.
+func (tdb *TagDB) update(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return tdb.db.Update(func(tx *bolt.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return ctx.Err() // Cancelled while fn ran: roll back
+	})
+}
+
+// view runs fn in a read-only transaction unless ctx is already done.
+func (tdb *TagDB) view(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return tdb.db.View(fn)
 }
 
 // logMessage is a helper to use the configured logger or fallback to standard log.
@@ -197,13 +269,15 @@ func (tdb *TagDB) _updateStoredList(tx *bolt.Tx, bucketName []byte, key []byte,
 // --- Core Tagging Functions ---
 
 // AddTag associates a tag with an image path.
-func (tdb *TagDB) AddTag(imagePath string, tag string) error {
+func (tdb *TagDB) AddTag(ctx context.Context, imagePath string, tag string) error {
 	if imagePath == "" || tag == "" {
 		return fmt.Errorf("image path and tag cannot be empty")
 	}
-	return tdb.db.Update(func(tx *bolt.Tx) error {
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
+		created := tx.Bucket([]byte(TagsToImagesBucket)).Get([]byte(tag)) == nil
+
 		// 1. Update Image -> Tags mapping
-		_, err := tdb._updateStoredList(tx, []byte(ImagesToTagsBucket), []byte(imagePath), tag, true)
+		changed, err := tdb._updateStoredList(tx, []byte(ImagesToTagsBucket), []byte(imagePath), tag, true)
 		if err != nil {
 			return fmt.Errorf("updating image->tags for '%s' with tag '%s': %w", imagePath, tag, err)
 		}
@@ -213,18 +287,28 @@ func (tdb *TagDB) AddTag(imagePath string, tag string) error {
 		if err != nil {
 			return fmt.Errorf("updating tag->images for '%s' with image '%s': %w", tag, imagePath, err)
 		}
-		return nil
+		if !changed {
+			return nil // Already tagged; nothing to record
+		}
+		usage := map[string]int{UsageTagsAdded: 1}
+		if created {
+			usage[UsageTagsCreated] = 1
+		}
+		if err := addUsage(tx, time.Now(), usage); err != nil {
+			return err
+		}
+		return tdb.audit(tx, AuditEvent{Action: ActionAdd, Path: imagePath, Tag: tag})
 	})
 }
 
 // RemoveTag disassociates a tag from an image path.
-func (tdb *TagDB) RemoveTag(imagePath string, tag string) error {
+func (tdb *TagDB) RemoveTag(ctx context.Context, imagePath string, tag string) error {
 	if imagePath == "" || tag == "" {
 		return fmt.Errorf("image path and tag cannot be empty")
 	}
-	return tdb.db.Update(func(tx *bolt.Tx) error {
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
 		// 1. Update Image -> Tags mapping
-		_, err := tdb._updateStoredList(tx, []byte(ImagesToTagsBucket), []byte(imagePath), tag, false)
+		changed, err := tdb._updateStoredList(tx, []byte(ImagesToTagsBucket), []byte(imagePath), tag, false)
 		if err != nil {
 			return fmt.Errorf("updating image->tags for '%s' removing tag '%s': %w", imagePath, tag, err)
 		}
@@ -234,14 +318,17 @@ func (tdb *TagDB) RemoveTag(imagePath string, tag string) error {
 		if err != nil {
 			return fmt.Errorf("updating tag->images for '%s' removing image '%s': %w", tag, imagePath, err)
 		}
-		return nil
+		if !changed {
+			return nil // Tag wasn't there; nothing to record
+		}
+		return tdb.audit(tx, AuditEvent{Action: ActionRemove, Path: imagePath, Tag: tag})
 	})
 }
 
 // GetTags retrieves all tags associated with a given image path.
-func (tdb *TagDB) GetTags(imagePath string) ([]string, error) {
+func (tdb *TagDB) GetTags(ctx context.Context, imagePath string) ([]string, error) {
 	var tags []string
-	err := tdb.db.View(func(tx *bolt.Tx) error {
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(ImagesToTagsBucket))
 		tagsBytes := bucket.Get([]byte(imagePath))
 		if tagsBytes == nil {
@@ -260,9 +347,9 @@ func (tdb *TagDB) GetTags(imagePath string) ([]string, error) {
 }
 
 // GetImages retrieves all image paths associated with a given tag.
-func (tdb *TagDB) GetImages(tag string) ([]string, error) {
+func (tdb *TagDB) GetImages(ctx context.Context, tag string) ([]string, error) {
 	var images []string
-	err := tdb.db.View(func(tx *bolt.Tx) error {
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(TagsToImagesBucket))
 		imagesBytes := bucket.Get([]byte(tag))
 		if imagesBytes == nil {
@@ -282,11 +369,14 @@ func (tdb *TagDB) GetImages(tag string) ([]string, error) {
 
 // GetAllTags retrieves a sorted list of all unique tags in the database,
 // along with the count of images associated with each tag.
-func (tdb *TagDB) GetAllTags() ([]TagWithCount, error) {
+func (tdb *TagDB) GetAllTags(ctx context.Context) ([]TagWithCount, error) {
 	var allTagsInfo []TagWithCount
-	err := tdb.db.View(func(tx *bolt.Tx) error {
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(TagsToImagesBucket))
 		return bucket.ForEach(func(k, v []byte) error { // k is tag name, v is list of image paths
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			tagName := string(k)
 			imageList, err := decodeList(v)
 			if err != nil {
@@ -310,58 +400,129 @@ func (tdb *TagDB) GetAllTags() ([]TagWithCount, error) {
 }
 
 // RemoveAllTagsForImage removes all tag associations for a given imagePath
-// and cleans up the image's entry from the ImagesToTags bucket.
-func (tdb *TagDB) RemoveAllTagsForImage(imagePath string) error {
+// and cleans up the image's entry from the ImagesToTags bucket, along with its
+// annotations. It is recorded in the audit log as the deletion of the image.
+func (tdb *TagDB) RemoveAllTagsForImage(ctx context.Context, imagePath string) error {
+	return tdb.removeAllTagsForImage(ctx, imagePath, ActionDelete)
+}
+
+// CleanImage removes all tag associations of an image that no longer exists,
+// like RemoveAllTagsForImage, but records the change as a cleanup.
+func (tdb *TagDB) CleanImage(ctx context.Context, imagePath string) error {
+	return tdb.removeAllTagsForImage(ctx, imagePath, ActionClean)
+}
+
+func (tdb *TagDB) removeAllTagsForImage(ctx context.Context, imagePath string, action AuditAction) error {
 	if imagePath == "" {
 		return fmt.Errorf("image path cannot be empty")
 	}
-	return tdb.db.Update(func(tx *bolt.Tx) error {
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
+		if err := moveImageData(tx, imagePath, ""); err != nil {
+			return err
+		}
+		_, err := tdb.removeImageTags(tx, imagePath, AuditEvent{Action: action})
+		return err
+	})
+}
+
+// removeImageTags removes all tags of imagePath within tx and records ev,
+// completed with the path and the tags, in the audit log. It returns the
+// tags removed; an image without tags records nothing.
+func (tdb *TagDB) removeImageTags(tx *bolt.Tx, imagePath string, ev AuditEvent) ([]string, error) {
+	imgBucket := tx.Bucket([]byte(ImagesToTagsBucket))
+
+	// 1. Get all tags currently associated with the image
+	currentTagsBytes := imgBucket.Get([]byte(imagePath))
+	if currentTagsBytes == nil {
+		// Image has no tags, nothing to do for its specific tags.
+		// It might still be listed under some tags if data is inconsistent,
+		// but RemoveTag below would handle that if called.
+		// For a full cleanup, we'd iterate all tags and check, but that's less efficient.
+		// This function assumes we primarily care about removing the image's own tag list
+		// and its references from tags it knows it has.
+		return nil, nil
+	}
+	currentTags, err := decodeList(currentTagsBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode tags for image %s during cleanup: %w", imagePath, err)
+	}
+
+	// 2. For each tag, remove the imagePath from that tag's list of images
+	for _, tag := range currentTags {
+		// The _updateStoredList helper handles decoding, removing, encoding, and deleting the key if the list becomes empty.
+		// It also handles the case where the tag might not exist or its list is already empty.
+		// The 'changed' boolean return isn't strictly needed here but the error is.
+		_, err := tdb._updateStoredList(tx, []byte(TagsToImagesBucket), []byte(tag), imagePath, false)
+		if err != nil {
+			// If one update fails, the transaction will be rolled back.
+			return nil, fmt.Errorf("failed to remove image '%s' from tag '%s' during cleanup: %w", imagePath, tag, err)
+		}
+	}
+
+	// 3. Remove the imagePath key from the imagesToTagsBucket
+	if err := imgBucket.Delete([]byte(imagePath)); err != nil {
+		return nil, fmt.Errorf("failed to delete image key %s from images bucket: %w", imagePath, err)
+	}
+	ev.Path, ev.Tags = imagePath, currentTags
+	return currentTags, tdb.audit(tx, ev)
+}
+
+// RenameImage moves all tags, annotations and OCR text of oldPath to newPath
+// in a single transaction, so the database never holds a half-renamed image.
+// newPath must not have tags of its own.
+func (tdb *TagDB) RenameImage(ctx context.Context, oldPath, newPath string) error {
+	if oldPath == "" || newPath == "" {
+		return fmt.Errorf("image paths cannot be empty")
+	}
+	if oldPath == newPath {
+		return nil
+	}
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
 		imgBucket := tx.Bucket([]byte(ImagesToTagsBucket))
+		if imgBucket.Get([]byte(newPath)) != nil {
+			return fmt.Errorf("image %s already has tags", newPath)
+		}
+		if err := moveImageData(tx, oldPath, newPath); err != nil {
+			return err
+		}
 
-		// 1. Get all tags currently associated with the image
-		currentTagsBytes := imgBucket.Get([]byte(imagePath))
+		currentTagsBytes := imgBucket.Get([]byte(oldPath))
 		if currentTagsBytes == nil {
-			// Image has no tags, nothing to do for its specific tags.
-			// It might still be listed under some tags if data is inconsistent,
-			// but RemoveTag below would handle that if called.
-			// For a full cleanup, we'd iterate all tags and check, but that's less efficient.
-			// This function assumes we primarily care about removing the image's own tag list
-			// and its references from tags it knows it has.
-			return nil
+			return nil // Untagged image, nothing to move
 		}
 		currentTags, err := decodeList(currentTagsBytes)
 		if err != nil {
-			return fmt.Errorf("failed to decode tags for image %s during cleanup: %w", imagePath, err)
+			return fmt.Errorf("failed to decode tags for image %s during rename: %w", oldPath, err)
 		}
 
-		// 2. For each tag, remove the imagePath from that tag's list of images
+		// Re-point each tag from the old path to the new one.
 		for _, tag := range currentTags {
-			// The _updateStoredList helper handles decoding, removing, encoding, and deleting the key if the list becomes empty.
-			// It also handles the case where the tag might not exist or its list is already empty.
-			// The 'changed' boolean return isn't strictly needed here but the error is.
-			_, err := tdb._updateStoredList(tx, []byte(TagsToImagesBucket), []byte(tag), imagePath, false)
-			if err != nil {
-				// If one update fails, the transaction will be rolled back.
-				return fmt.Errorf("failed to remove image '%s' from tag '%s' during cleanup: %w", imagePath, tag, err)
+			if _, err := tdb._updateStoredList(tx, []byte(TagsToImagesBucket), []byte(tag), oldPath, false); err != nil {
+				return fmt.Errorf("failed to remove image '%s' from tag '%s' during rename: %w", oldPath, tag, err)
+			}
+			if _, err := tdb._updateStoredList(tx, []byte(TagsToImagesBucket), []byte(tag), newPath, true); err != nil {
+				return fmt.Errorf("failed to add image '%s' to tag '%s' during rename: %w", newPath, tag, err)
 			}
 		}
 
-		// 3. Remove the imagePath key from the imagesToTagsBucket
-		if err := imgBucket.Delete([]byte(imagePath)); err != nil {
-			return fmt.Errorf("failed to delete image key %s from images bucket: %w", imagePath, err)
+		if err := imgBucket.Put([]byte(newPath), currentTagsBytes); err != nil {
+			return fmt.Errorf("failed to store tags for image %s: %w", newPath, err)
 		}
-		return nil
+		if err := imgBucket.Delete([]byte(oldPath)); err != nil {
+			return fmt.Errorf("failed to delete image key %s from images bucket: %w", oldPath, err)
+		}
+		return tdb.audit(tx, AuditEvent{Action: ActionRename, Path: oldPath, Tags: currentTags, Detail: newPath})
 	})
 }
 
 // DeleteOrphanedTagKey directly removes a tag key from the TagsToImages bucket.
 // This is intended for cleanup scenarios where a tag is known to be orphaned
 // (i.e., its list of associated images is empty, as determined by the caller).
-func (tdb *TagDB) DeleteOrphanedTagKey(tag string) error {
+func (tdb *TagDB) DeleteOrphanedTagKey(ctx context.Context, tag string) error {
 	if tag == "" {
 		return fmt.Errorf("tag cannot be empty for DeleteOrphanedTagKey")
 	}
-	return tdb.db.Update(func(tx *bolt.Tx) error {
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
 		tagBucket := tx.Bucket([]byte(TagsToImagesBucket))
 		if tagBucket == nil {
 			// This should not happen if DB is initialized correctly
@@ -369,23 +530,29 @@ func (tdb *TagDB) DeleteOrphanedTagKey(tag string) error {
 		}
 		// We trust that the caller has determined this tag is orphaned.
 		// If the key doesn't exist, Delete does nothing and returns nil.
+		if tagBucket.Get([]byte(tag)) == nil {
+			return nil
+		}
 		if err := tagBucket.Delete([]byte(tag)); err != nil {
 			return fmt.Errorf("failed to delete orphaned tag key '%s' from %s bucket: %w", tag, TagsToImagesBucket, err)
 		}
-		return nil
+		return tdb.audit(tx, AuditEvent{Action: ActionClean, Tag: tag})
 	})
 }
 
 // GetAllImagePaths retrieves all image paths stored in the ImagesToTagsBucket.
-func (tdb *TagDB) GetAllImagePaths() ([]string, error) {
+func (tdb *TagDB) GetAllImagePaths(ctx context.Context) ([]string, error) {
 	var paths []string
-	err := tdb.db.View(func(tx *bolt.Tx) error {
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(ImagesToTagsBucket))
 		if bucket == nil {
 			// Bucket doesn't exist, which means no images are tagged.
 			return nil // Not an error, just no paths.
 		}
 		return bucket.ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			paths = append(paths, string(k))
 			return nil
 		})
@@ -395,3 +562,68 @@ func (tdb *TagDB) GetAllImagePaths() ([]string, error) {
 	}
 	return paths, nil
 }
+
+// GetAllImageTags retrieves the tags of every tagged image in a single read,
+// keyed by image path. Entries that fail to decode are logged and skipped.
+func (tdb *TagDB) GetAllImageTags(ctx context.Context) (map[string][]string, error) {
+	imageTags := make(map[string][]string)
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(ImagesToTagsBucket))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			tags, err := decodeList(v)
+			if err != nil {
+				tdb.logMessage("Error decoding tags for image '%s', skipping: %v", string(k), err)
+				return nil
+			}
+			imageTags[string(k)] = tags
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all image tags: %w", err)
+	}
+	return imageTags, nil
+}
+
+// GetDirectoryImageTags retrieves the tags of the tagged images directly in
+// dir, not in its subdirectories, keyed by image path. Image keys are sorted
+// by path, so only that directory's range of the bucket is read.
+func (tdb *TagDB) GetDirectoryImageTags(ctx context.Context, dir string) (map[string][]string, error) {
+	prefix := filepath.Clean(dir)
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	imageTags := make(map[string][]string)
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(ImagesToTagsBucket))
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.Cursor()
+		for k, v := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, v = c.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if bytes.ContainsRune(k[len(prefix):], filepath.Separator) {
+				continue // In a subdirectory
+			}
+			tags, err := decodeList(v)
+			if err != nil {
+				tdb.logMessage("Error decoding tags for image '%s', skipping: %v", string(k), err)
+				continue
+			}
+			imageTags[string(k)] = tags
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the image tags of %s: %w", dir, err)
+	}
+	return imageTags, nil
+}
@@ -0,0 +1,62 @@
+package tagging
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// cancelAfter is a context that reports itself cancelled once Err has been
+// called more than n times, to cancel an operation partway through.
+type cancelAfter struct {
+	context.Context
+	n int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestCancelledContext(t *testing.T) {
+	tdb := openTestDB(t, "test")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := tdb.AddTag(ctx, "/p/a.jpg", "cats"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("AddTag with cancelled context: got %v, want context.Canceled", err)
+	}
+	if _, err := tdb.GetAllTags(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetAllTags with cancelled context: got %v, want context.Canceled", err)
+	}
+	expectTags(t, tdb, "/p/a.jpg")
+}
+
+func TestCancelRollsBack(t *testing.T) {
+	tdb := openTestDB(t, "test")
+	step(t, tdb.AddTag(context.Background(), "/p/a.jpg", "cats"))
+
+	// Cancelled after the changes were made but before the commit.
+	err := tdb.RenameImage(&cancelAfter{Context: context.Background(), n: 1}, "/p/a.jpg", "/p/b.jpg")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("RenameImage: got %v, want context.Canceled", err)
+	}
+	expectTags(t, tdb, "/p/a.jpg", "cats")
+	expectTags(t, tdb, "/p/b.jpg")
+	events, err := tdb.AuditLog(context.Background(), AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Errorf("Rolled back rename was audited: %v", events)
+	}
+
+	// Cancelled while walking the tags.
+	step(t, tdb.AddTag(context.Background(), "/p/a.jpg", "dogs"))
+	if _, err := tdb.GetAllImageTags(&cancelAfter{Context: context.Background(), n: 1}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetAllImageTags: got %v, want context.Canceled", err)
+	}
+}
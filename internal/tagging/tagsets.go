@@ -0,0 +1,114 @@
+package tagging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// TagSetsBucket holds the named sets of tags applied together, keyed by name.
+const TagSetsBucket = "TagSets"
+
+// ErrNoTagSet is returned for a tag set name that is not stored.
+var ErrNoTagSet = errors.New("no such tag set")
+
+// TagSet is a named group of tags applied in one action, such as
+// "beach-trip" for beach, family and 2024.
+type TagSet struct {
+	Name    string    `json:"-"` // Unique, the bucket key
+	Tags    []string  `json:"tags"`
+	Created time.Time `json:"created"`
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty ones and duplicates,
+// the way tags are entered everywhere else.
+func NormalizeTags(tags []string) []string {
+	var result []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// SaveTagSet stores s with its tags normalized, replacing any set of the same name.
+func (tdb *TagDB) SaveTagSet(ctx context.Context, s TagSet) error {
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return fmt.Errorf("tag set name must not be empty")
+	}
+	if s.Tags = NormalizeTags(s.Tags); len(s.Tags) == 0 {
+		return fmt.Errorf("tag set '%s' must have at least one tag", s.Name)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode tag set '%s': %w", s.Name, err)
+	}
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(TagSetsBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket %s: %w", TagSetsBucket, err)
+		}
+		return bucket.Put([]byte(s.Name), data)
+	})
+}
+
+// DeleteTagSet removes the tag set called name, or returns ErrNoTagSet.
+func (tdb *TagDB) DeleteTagSet(ctx context.Context, name string) error {
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(TagSetsBucket))
+		if bucket == nil || bucket.Get([]byte(name)) == nil {
+			return fmt.Errorf("%w: %s", ErrNoTagSet, name)
+		}
+		return bucket.Delete([]byte(name))
+	})
+}
+
+// GetTagSet returns the tag set called name, or ErrNoTagSet.
+func (tdb *TagDB) GetTagSet(ctx context.Context, name string) (TagSet, error) {
+	s := TagSet{Name: name}
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		var data []byte
+		if bucket := tx.Bucket([]byte(TagSetsBucket)); bucket != nil {
+			data = bucket.Get([]byte(name))
+		}
+		if data == nil {
+			return fmt.Errorf("%w: %s", ErrNoTagSet, name)
+		}
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("failed to decode tag set '%s': %w", name, err)
+		}
+		return nil
+	})
+	return s, err
+}
+
+// TagSets returns every tag set, ordered by name.
+func (tdb *TagDB) TagSets(ctx context.Context) ([]TagSet, error) {
+	var sets []TagSet
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(TagSetsBucket))
+		if bucket == nil {
+			return nil // No tag sets saved yet
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			s := TagSet{Name: string(k)}
+			if err := json.Unmarshal(v, &s); err != nil {
+				return fmt.Errorf("failed to decode tag set '%s': %w", k, err)
+			}
+			sets = append(sets, s)
+			return nil
+		})
+	})
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Name < sets[j].Name })
+	return sets, err
+}
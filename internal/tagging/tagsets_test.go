@@ -0,0 +1,57 @@
+package tagging
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestTagSets(t *testing.T) {
+	tdb, err := NewTagDB(t.TempDir(), func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tdb.Close()
+	ctx := context.Background()
+
+	if sets, err := tdb.TagSets(ctx); err != nil || len(sets) != 0 {
+		t.Fatalf("TagSets of a new database = %v, %v", sets, err)
+	}
+	if _, err := tdb.GetTagSet(ctx, "beach-trip"); !errors.Is(err, ErrNoTagSet) {
+		t.Errorf("GetTagSet of a missing set = %v, want ErrNoTagSet", err)
+	}
+	if err := tdb.SaveTagSet(ctx, TagSet{Name: " ", Tags: []string{"beach"}}); err == nil {
+		t.Error("SaveTagSet accepted an empty name")
+	}
+	if err := tdb.SaveTagSet(ctx, TagSet{Name: "empty", Tags: []string{" ", ""}}); err == nil {
+		t.Error("SaveTagSet accepted a set without tags")
+	}
+
+	for _, s := range []TagSet{
+		{Name: "beach-trip", Tags: []string{"Beach", " family", "2024", "beach"}},
+		{Name: "alps", Tags: []string{"mountains"}},
+	} {
+		if err := tdb.SaveTagSet(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := tdb.GetTagSet(ctx, "beach-trip")
+	if err != nil || !slices.Equal(s.Tags, []string{"beach", "family", "2024"}) {
+		t.Errorf("GetTagSet = %+v, %v; want the normalized tags", s, err)
+	}
+	sets, err := tdb.TagSets(ctx)
+	if err != nil || len(sets) != 2 || sets[0].Name != "alps" || sets[1].Name != "beach-trip" {
+		t.Fatalf("TagSets = %+v, %v", sets, err)
+	}
+
+	if err := tdb.DeleteTagSet(ctx, "alps"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tdb.DeleteTagSet(ctx, "alps"); !errors.Is(err, ErrNoTagSet) {
+		t.Errorf("deleting a deleted set = %v, want ErrNoTagSet", err)
+	}
+	if sets, _ := tdb.TagSets(ctx); len(sets) != 1 {
+		t.Errorf("%d tag sets left after deleting one of two", len(sets))
+	}
+}
@@ -0,0 +1,152 @@
+package tagging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// TrashBucket records the images moved to the trash, keyed by their ID.
+const TrashBucket = "Trash"
+
+// ErrNotInTrash is returned for a trash ID with no record.
+var ErrNotInTrash = errors.New("no such image in the trash")
+
+// TrashItem is an image moved to the trash, with what it takes to put it back.
+type TrashItem struct {
+	ID        string    `json:"-"`         // The bucket key, unique within the trash
+	Path      string    `json:"path"`      // Where the image was
+	TrashPath string    `json:"trashPath"` // Where the image is kept in the trash
+	Deleted   time.Time `json:"deleted"`
+	Size      int64     `json:"size"`
+	Tags      []string  `json:"tags,omitempty"` // Filled in by TrashImage
+}
+
+// TrashImage records item and takes the tags of the image at item.Path away,
+// keeping them with the record, in a single transaction. Its annotations and
+// extracted text move to item.TrashPath. The audit log records a deletion.
+func (tdb *TagDB) TrashImage(ctx context.Context, item TrashItem) error {
+	if item.ID == "" || item.Path == "" || item.TrashPath == "" {
+		return fmt.Errorf("trash ID and paths cannot be empty")
+	}
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(TrashBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket %s: %w", TrashBucket, err)
+		}
+		if err := moveImageData(tx, item.Path, item.TrashPath); err != nil {
+			return err
+		}
+		if item.Tags, err = tdb.removeImageTags(tx, item.Path, AuditEvent{Action: ActionDelete, Detail: "moved to trash"}); err != nil {
+			return err
+		}
+		data, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to encode trash record of %s: %w", item.Path, err)
+		}
+		return bucket.Put([]byte(item.ID), data)
+	})
+}
+
+// TrashItemOf returns the trash record with id, or ErrNotInTrash.
+func (tdb *TagDB) TrashItemOf(ctx context.Context, id string) (TrashItem, error) {
+	var item TrashItem
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		var err error
+		item, err = trashItem(tx, id)
+		return err
+	})
+	return item, err
+}
+
+// trashItem reads the trash record with id within tx.
+func trashItem(tx *bolt.Tx, id string) (TrashItem, error) {
+	var item TrashItem
+	bucket := tx.Bucket([]byte(TrashBucket))
+	if bucket == nil {
+		return item, fmt.Errorf("%w: %s", ErrNotInTrash, id)
+	}
+	data := bucket.Get([]byte(id))
+	if data == nil {
+		return item, fmt.Errorf("%w: %s", ErrNotInTrash, id)
+	}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return item, fmt.Errorf("failed to decode trash record %s: %w", id, err)
+	}
+	item.ID = id
+	return item, nil
+}
+
+// TrashItems returns the images in the trash, the most recently deleted first.
+func (tdb *TagDB) TrashItems(ctx context.Context) ([]TrashItem, error) {
+	var items []TrashItem
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(TrashBucket))
+		if bucket == nil {
+			return nil // Nothing deleted yet
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var item TrashItem
+			if err := json.Unmarshal(v, &item); err != nil {
+				return fmt.Errorf("failed to decode trash record %s: %w", k, err)
+			}
+			item.ID = string(k)
+			items = append(items, item)
+			return nil
+		})
+	})
+	slices.SortStableFunc(items, func(a, b TrashItem) int { return b.Deleted.Compare(a.Deleted) })
+	return items, err
+}
+
+// RestoreTrashItem gives the image of the trash record with id its tags,
+// annotations and extracted text back under its original path and drops the
+// record, in a single transaction. The audit log records the tags as added.
+func (tdb *TagDB) RestoreTrashItem(ctx context.Context, id string) (TrashItem, error) {
+	var item TrashItem
+	err := tdb.update(ctx, func(tx *bolt.Tx) error {
+		var err error
+		if item, err = trashItem(tx, id); err != nil {
+			return err
+		}
+		if err := moveImageData(tx, item.TrashPath, item.Path); err != nil {
+			return err
+		}
+		for _, tag := range item.Tags {
+			changed, err := tdb._updateStoredList(tx, []byte(ImagesToTagsBucket), []byte(item.Path), tag, true)
+			if err != nil {
+				return fmt.Errorf("updating image->tags for '%s' with tag '%s': %w", item.Path, tag, err)
+			}
+			if _, err := tdb._updateStoredList(tx, []byte(TagsToImagesBucket), []byte(tag), item.Path, true); err != nil {
+				return fmt.Errorf("updating tag->images for '%s' with image '%s': %w", tag, item.Path, err)
+			}
+			if changed {
+				if err := tdb.audit(tx, AuditEvent{Action: ActionAdd, Path: item.Path, Tag: tag, Detail: "restored from trash"}); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Bucket([]byte(TrashBucket)).Delete([]byte(id))
+	})
+	return item, err
+}
+
+// DeleteTrashItem drops the trash record with id along with the annotations
+// and extracted text kept for it, once its file is deleted for good.
+func (tdb *TagDB) DeleteTrashItem(ctx context.Context, id string) error {
+	return tdb.update(ctx, func(tx *bolt.Tx) error {
+		item, err := trashItem(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := moveImageData(tx, item.TrashPath, ""); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(TrashBucket)).Delete([]byte(id))
+	})
+}
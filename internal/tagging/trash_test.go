@@ -0,0 +1,59 @@
+package tagging
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTrashAndRestore(t *testing.T) {
+	ctx := context.Background()
+	tdb := openTestDB(t, "me")
+	step(t, tdb.AddTag(ctx, "/p/a.jpg", "cats"))
+	step(t, tdb.AddTag(ctx, "/p/a.jpg", "keep"))
+	step(t, tdb.PutOCRText(ctx, []OCRText{{Path: "/p/a.jpg", Text: "HELLO"}}))
+	deleted := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	step(t, tdb.TrashImage(ctx, TrashItem{ID: "1-a.jpg", Path: "/p/a.jpg", TrashPath: "/trash/1-a.jpg", Deleted: deleted}))
+	step(t, tdb.TrashImage(ctx, TrashItem{ID: "2-b.jpg", Path: "/p/b.jpg", TrashPath: "/trash/2-b.jpg", Deleted: deleted.Add(time.Hour)}))
+	if tags, _ := tdb.GetTags(ctx, "/p/a.jpg"); len(tags) != 0 {
+		t.Errorf("trashed image still has tags %v", tags)
+	}
+	items, err := tdb.TrashItems(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].ID != "2-b.jpg" || items[1].Path != "/p/a.jpg" || !slices.Equal(items[1].Tags, []string{"cats", "keep"}) {
+		t.Errorf("TrashItems = %+v, want b then a with its tags", items)
+	}
+	if log, _ := tdb.AuditLog(ctx, AuditFilter{Path: "/p/a.jpg"}); len(log) != 3 || log[2].Action != ActionDelete {
+		t.Errorf("audit log of a = %v, want two adds and a delete", log)
+	}
+
+	item, err := tdb.RestoreTrashItem(ctx, "1-a.jpg")
+	if err != nil || item.Path != "/p/a.jpg" {
+		t.Fatalf("RestoreTrashItem = %+v, %v", item, err)
+	}
+	if tags, _ := tdb.GetTags(ctx, "/p/a.jpg"); !slices.Equal(tags, []string{"cats", "keep"}) {
+		t.Errorf("restored image has tags %v, want cats and keep", tags)
+	}
+	if images, _ := tdb.GetImages(ctx, "cats"); !slices.Equal(images, []string{"/p/a.jpg"}) {
+		t.Errorf("cats lists %v after the restore", images)
+	}
+	if text, ok, _ := tdb.OCRTextOf(ctx, "/p/a.jpg"); !ok || text.Text != "HELLO" {
+		t.Error("the extracted text did not come back with the image")
+	}
+	if _, err := tdb.TrashItemOf(ctx, "1-a.jpg"); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("restored record still in the trash: %v", err)
+	}
+
+	step(t, tdb.DeleteTrashItem(ctx, "2-b.jpg"))
+	if items, _ := tdb.TrashItems(ctx); len(items) != 0 {
+		t.Errorf("trash holds %+v after deleting its last record", items)
+	}
+	if _, err := tdb.RestoreTrashItem(ctx, "2-b.jpg"); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("restoring a deleted record: %v, want ErrNotInTrash", err)
+	}
+}
@@ -0,0 +1,154 @@
+package tagging
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// UsageBucket holds daily counters of how FySlide is used, keyed by the day
+// as YYYY-MM-DD. They are only ever read locally; nothing is sent anywhere.
+const UsageBucket = "Usage"
+
+// usageDayLayout formats the keys of UsageBucket.
+const usageDayLayout = "2006-01-02"
+
+// Usage counters. Commands run are counted as UsageCommandPrefix followed by
+// the command, e.g. "command:batch-add".
+const (
+	UsageTagsAdded       = "tags_added"       // Tags added to images
+	UsageTagsCreated     = "tags_created"     // Tags used for the first time
+	UsageImagesProcessed = "images_processed" // Images handled by batch operations
+	UsageImagesViewed    = "images_viewed"    // Images shown in the viewer
+	UsageCommandPrefix   = "command:"
+)
+
+// UsageDay holds the counters of one day.
+type UsageDay struct {
+	Day    time.Time      `json:"day"` // Midnight, local time
+	Counts map[string]int `json:"counts"`
+}
+
+// addUsage adds counts to the counters of the day of now within tx.
+func addUsage(tx *bolt.Tx, now time.Time, counts map[string]int) error {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(UsageBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create bucket %s: %w", UsageBucket, err)
+	}
+	key := []byte(now.Format(usageDayLayout))
+	day := make(map[string]int)
+	if data := bucket.Get(key); data != nil {
+		if err := json.Unmarshal(data, &day); err != nil {
+			return fmt.Errorf("failed to decode usage of %s: %w", key, err)
+		}
+	}
+	for name, n := range counts {
+		day[name] += n
+	}
+	data, err := json.Marshal(day)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage of %s: %w", key, err)
+	}
+	return bucket.Put(key, data)
+}
+
+// CountUsage adds counts to today's usage counters. Statistics are never worth
+// failing what they count, so errors are only logged.
+func (tdb *TagDB) CountUsage(ctx context.Context, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	err := tdb.update(ctx, func(tx *bolt.Tx) error {
+		return addUsage(tx, time.Now(), counts)
+	})
+	if err != nil {
+		tdb.logMessage("Failed to record usage statistics: %v", err)
+	}
+}
+
+// UsageSince returns the counters of the days from since on, oldest first.
+// Days without any usage are left out.
+func (tdb *TagDB) UsageSince(ctx context.Context, since time.Time) ([]UsageDay, error) {
+	var days []UsageDay
+	err := tdb.view(ctx, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(UsageBucket))
+		if bucket == nil {
+			return nil // Nothing recorded yet
+		}
+		c := bucket.Cursor()
+		for k, v := c.Seek([]byte(since.Format(usageDayLayout))); k != nil; k, v = c.Next() {
+			day, err := time.ParseInLocation(usageDayLayout, string(k), time.Local)
+			if err != nil {
+				return fmt.Errorf("bad usage day %q: %w", k, err)
+			}
+			counts := make(map[string]int)
+			if err := json.Unmarshal(v, &counts); err != nil {
+				return fmt.Errorf("failed to decode usage of %s: %w", k, err)
+			}
+			days = append(days, UsageDay{Day: day, Counts: counts})
+		}
+		return nil
+	})
+	return days, err
+}
+
+// UsageTotals adds up the counters of days.
+func UsageTotals(days []UsageDay) map[string]int {
+	totals := make(map[string]int)
+	for _, day := range days {
+		for name, n := range day.Counts {
+			totals[name] += n
+		}
+	}
+	return totals
+}
+
+// WriteUsageCSV writes one row per day and counter with a header row.
+func WriteUsageCSV(w io.Writer, days []UsageDay) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"day", "counter", "count"}); err != nil {
+		return err
+	}
+	for _, day := range days {
+		for _, name := range slices.Sorted(maps.Keys(day.Counts)) {
+			if err := cw.Write([]string{day.Day.Format(usageDayLayout), name, strconv.Itoa(day.Counts[name])}); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// UsageCount is how often something was counted.
+type UsageCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// UsageCommands returns the commands counted in counts with how often each
+// ran, most run first.
+func UsageCommands(counts map[string]int) []UsageCount {
+	var commands []UsageCount
+	for name, n := range counts {
+		if command, ok := strings.CutPrefix(name, UsageCommandPrefix); ok {
+			commands = append(commands, UsageCount{Name: command, Count: n})
+		}
+	}
+	slices.SortFunc(commands, func(a, b UsageCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return commands
+}
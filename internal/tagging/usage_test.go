@@ -0,0 +1,59 @@
+package tagging
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUsage(t *testing.T) {
+	tdb, err := NewTagDB(t.TempDir(), func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tdb.Close()
+	ctx := context.Background()
+
+	if days, err := tdb.UsageSince(ctx, time.Time{}); err != nil || len(days) != 0 {
+		t.Fatalf("UsageSince of a new database = %v, %v", days, err)
+	}
+	for _, add := range [][2]string{{"/p/a.jpg", "cats"}, {"/p/b.jpg", "cats"}, {"/p/b.jpg", "cats"}, {"/p/b.jpg", "dogs"}} {
+		if err := tdb.AddTag(ctx, add[0], add[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tdb.CountUsage(ctx, map[string]int{UsageCommandPrefix + "add": 2, UsageImagesProcessed: 5})
+	tdb.CountUsage(ctx, map[string]int{UsageCommandPrefix + "clean": 1})
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	days, err := tdb.UsageSince(ctx, yesterday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 1 {
+		t.Fatalf("UsageSince = %+v, want today only", days)
+	}
+	totals := UsageTotals(days)
+	want := map[string]int{UsageTagsAdded: 3, UsageTagsCreated: 2, UsageImagesProcessed: 5, "command:add": 2, "command:clean": 1}
+	for name, n := range want {
+		if totals[name] != n {
+			t.Errorf("%s = %d, want %d", name, totals[name], n)
+		}
+	}
+	if commands := UsageCommands(totals); len(commands) != 2 || commands[0] != (UsageCount{Name: "add", Count: 2}) {
+		t.Errorf("UsageCommands = %+v", commands)
+	}
+	if days, _ := tdb.UsageSince(ctx, time.Now().AddDate(0, 0, 1)); len(days) != 0 {
+		t.Errorf("UsageSince tomorrow = %+v", days)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteUsageCSV(&buf, days); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 1+len(want) || lines[0] != "day,counter,count" {
+		t.Errorf("CSV = %q", buf.String())
+	}
+}
@@ -0,0 +1,415 @@
+// Package transform straightens batches of scanned images, such as film
+// negatives: it turns them by quarter turns, detects and corrects a slight
+// skew, and crops the uniform borders a scanner leaves around the frame.
+package transform
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"fyslide/internal/exporter"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// DefaultMaxSkew is the largest skew in degrees DetectSkew looks for when no
+// other limit is given.
+const DefaultMaxSkew = 10.0
+
+const (
+	// analysisSize is the longest edge of the copy skew and borders are detected on.
+	analysisSize = 800
+	// minEdgeStrength is the Sobel gradient, on a 0-255 gray scale, of the
+	// pixels whose edge direction counts towards the skew.
+	minEdgeStrength = 96
+	// skewBinsPerDegree is the resolution of the skew histogram.
+	skewBinsPerDegree = 10
+	// minSkew is the smallest skew worth correcting, in degrees.
+	minSkew = 0.1
+	// borderTolerance is how far, on a 0-255 gray scale, a border line's mean
+	// may stray from the outermost line and how much its values may spread.
+	borderTolerance = 20
+	// maxBorderFraction is the most of each dimension a border may take up.
+	maxBorderFraction = 0.3
+)
+
+// ErrUnsupported is returned for images whose format can't be rewritten.
+var ErrUnsupported = errors.New("only JPEG and PNG images can be transformed")
+
+// Options select the transforms to apply, in the order listed.
+type Options struct {
+	Rotate  int     // Degrees clockwise, a multiple of 90
+	Deskew  bool    // Detect and correct a slight skew
+	Crop    bool    // Crop uniform borders
+	MaxSkew float64 // Largest skew to detect in degrees; 0 uses DefaultMaxSkew
+	Quality int     // JPEG quality from 1 to 100; 0 uses exporter.DefaultQuality
+}
+
+// Validate checks opts and fills in defaults.
+func (opts *Options) Validate() error {
+	if opts.Rotate%90 != 0 {
+		return fmt.Errorf("rotation %d is not a multiple of 90 degrees", opts.Rotate)
+	}
+	opts.Rotate = (opts.Rotate%360 + 360) % 360
+	if opts.MaxSkew < 0 || opts.MaxSkew >= 45 {
+		return fmt.Errorf("maximum skew %g is not between 0 and 45 degrees", opts.MaxSkew)
+	}
+	if opts.MaxSkew == 0 {
+		opts.MaxSkew = DefaultMaxSkew
+	}
+	if opts.Quality == 0 {
+		opts.Quality = exporter.DefaultQuality
+	}
+	if opts.Quality < 1 || opts.Quality > 100 {
+		return fmt.Errorf("JPEG quality %d is not between 1 and 100", opts.Quality)
+	}
+	if opts.Rotate == 0 && !opts.Deskew && !opts.Crop {
+		return errors.New("no transform selected")
+	}
+	return nil
+}
+
+// Result describes what Apply did to an image.
+type Result struct {
+	Skew    float64         // Skew corrected in degrees clockwise, 0 if none was
+	Crop    image.Rectangle // Part of the rotated and deskewed image kept, empty if not cropped
+	Size    image.Point     // Size of the transformed image
+	Changed bool            // Whether any transform changed the image
+}
+
+// String describes r for logs, such as "deskewed 1.4°, cropped to 3000x2000".
+func (r Result) String() string {
+	var parts []string
+	if r.Skew != 0 {
+		parts = append(parts, fmt.Sprintf("deskewed %.1f°", r.Skew))
+	}
+	if !r.Crop.Empty() {
+		parts = append(parts, fmt.Sprintf("cropped to %dx%d", r.Crop.Dx(), r.Crop.Dy()))
+	}
+	if len(parts) == 0 {
+		if r.Changed {
+			return "rotated"
+		}
+		return "unchanged"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Apply returns img transformed as opts select, which must have been validated.
+func Apply(img image.Image, opts Options) (image.Image, Result) {
+	var r Result
+	if opts.Rotate != 0 {
+		img = Rotate(img, opts.Rotate)
+		r.Changed = true
+	}
+	if opts.Deskew {
+		if skew := DetectSkew(img, opts.MaxSkew); skew != 0 {
+			img = Deskew(img, skew)
+			r.Skew = skew
+			r.Changed = true
+		}
+	}
+	if opts.Crop {
+		if crop := DetectBorders(img); crop != img.Bounds() {
+			img = Crop(img, crop)
+			r.Crop = crop
+			r.Changed = true
+		}
+	}
+	r.Size = img.Bounds().Size()
+	return img, r
+}
+
+// Supported reports whether the image at path has a format ApplyFile rewrites.
+func Supported(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg", ".png":
+		return true
+	}
+	return false
+}
+
+// ApplyFile transforms the image at path in place, keeping the EXIF data of
+// JPEGs. The file is only rewritten if the image changed, and not at all if
+// dryRun is set, so the result tells what would be done.
+func ApplyFile(path string, opts Options, dryRun bool) (Result, error) {
+	if !Supported(path) {
+		return Result{}, fmt.Errorf("%s: %w", filepath.Base(path), ErrUnsupported)
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return Result{}, err
+	}
+	img, format, err := image.Decode(bytes.NewReader(src))
+	if err != nil {
+		return Result{}, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	img, r := Apply(img, opts)
+	if !r.Changed || dryRun {
+		return r, nil
+	}
+	var out bytes.Buffer
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: opts.Quality})
+	case "png":
+		err = png.Encode(&out, img)
+	default:
+		return r, fmt.Errorf("%s is a %s image: %w", filepath.Base(path), format, ErrUnsupported)
+	}
+	if err != nil {
+		return r, fmt.Errorf("encoding %s: %w", path, err)
+	}
+	data := out.Bytes()
+	if format == "jpeg" {
+		data = exporter.CopyEXIF(data, src)
+	}
+	return r, exporter.WriteFileAtomic(path, data)
+}
+
+// Rotate returns img turned clockwise by degrees, a multiple of 90.
+func Rotate(img image.Image, degrees int) image.Image {
+	turns := ((degrees/90)%4 + 4) % 4
+	if turns == 0 {
+		return img
+	}
+	src := toRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := h, w
+	if turns == 2 {
+		dw, dh = w, h
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		row := src.Pix[y*src.Stride:]
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch turns {
+			case 1:
+				dx, dy = h-1-y, x
+			case 2:
+				dx, dy = w-1-x, h-1-y
+			default:
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dy*dst.Stride+dx*4:][:4], row[x*4:x*4+4])
+		}
+	}
+	return dst
+}
+
+// toRGBA returns img as an RGBA image with its origin at (0, 0).
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	return rgba
+}
+
+// analysisCopy returns a gray copy of img no larger than analysisSize, and
+// the factor its coordinates are scaled down by.
+func analysisCopy(img image.Image) (*image.Gray, float64) {
+	b := img.Bounds()
+	scale := 1.0
+	if longest := max(b.Dx(), b.Dy()); longest > analysisSize {
+		scale = float64(longest) / analysisSize
+	}
+	w := max(1, int(float64(b.Dx())/scale))
+	h := max(1, int(float64(b.Dy())/scale))
+	gray := image.NewGray(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(gray, gray.Rect, img, b, draw.Src, nil)
+	return gray, scale
+}
+
+// DetectSkew returns the angle in degrees, clockwise, by which the straight
+// edges of img, such as the frame of a negative, lean away from the vertical
+// and horizontal. It looks for the angle most strong edges share, up to
+// maxSkew either way, and returns 0 if there is no clear one.
+func DetectSkew(img image.Image, maxSkew float64) float64 {
+	if maxSkew <= 0 {
+		maxSkew = DefaultMaxSkew
+	}
+	gray, _ := analysisCopy(img)
+	w, h := gray.Rect.Dx(), gray.Rect.Dy()
+	// The histogram covers every lean so a skew beyond maxSkew isn't mistaken
+	// for a smaller one
+	const bins = 45 * skewBinsPerDegree
+	hist := make([]float64, 2*bins)
+	at := func(x, y int) float64 { return float64(gray.Pix[y*gray.Stride+x]) }
+	total := 0.0
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+			strength := math.Hypot(gx, gy) / 4
+			if strength < minEdgeStrength {
+				continue
+			}
+			// The gradient is square to the edge; folding it into a quarter
+			// turn gives the lean of horizontal and vertical edges alike
+			angle := math.Atan2(gy, gx) * 180 / math.Pi
+			angle = math.Mod(angle+45+360, 90) - 45
+			bin := min(int(math.Round(angle*skewBinsPerDegree)), bins-1)
+			hist[bin+bins] += strength
+			total += strength
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	// The peak of the smoothed histogram, refined by the mean around it
+	const window = skewBinsPerDegree / 2
+	best, bestSum := 0, -1.0
+	for i := range hist {
+		sum := 0.0
+		for j := max(0, i-window); j <= min(len(hist)-1, i+window); j++ {
+			sum += hist[j]
+		}
+		if sum > bestSum {
+			best, bestSum = i, sum
+		}
+	}
+	if bestSum < total/4 { // Edges of every direction, as in a photo of a tree
+		return 0
+	}
+	weighted := 0.0
+	for j := max(0, best-window); j <= min(len(hist)-1, best+window); j++ {
+		weighted += float64(j-bins) * hist[j]
+	}
+	skew := weighted / bestSum / skewBinsPerDegree
+	if math.Abs(skew) < minSkew || math.Abs(skew) > maxSkew {
+		return 0
+	}
+	return math.Round(skew*skewBinsPerDegree) / skewBinsPerDegree
+}
+
+// Deskew returns img turned counterclockwise by skew degrees about its center,
+// undoing a clockwise skew. The image keeps its size; the corners it turns
+// away from are filled with the color of its edges.
+func Deskew(img image.Image, skew float64) image.Image {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Rect, image.NewUniform(edgeColor(img)), image.Point{}, draw.Src)
+	rad := -skew * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	cx, cy := float64(b.Min.X)+float64(b.Dx())/2, float64(b.Min.Y)+float64(b.Dy())/2
+	dcx, dcy := float64(b.Dx())/2, float64(b.Dy())/2
+	// Source to destination: turn about the source center onto the destination center
+	m := f64.Aff3{
+		cos, -sin, dcx - cos*cx + sin*cy,
+		sin, cos, dcy - sin*cx - cos*cy,
+	}
+	draw.BiLinear.Transform(dst, m, img, b, draw.Over, nil)
+	return dst
+}
+
+// edgeColor returns the average color of the outermost pixels of img.
+func edgeColor(img image.Image) color.RGBA {
+	b := img.Bounds()
+	var r, g, bl, n uint64
+	add := func(x, y int) {
+		cr, cg, cb, _ := img.At(x, y).RGBA()
+		r, g, bl, n = r+uint64(cr>>8), g+uint64(cg>>8), bl+uint64(cb>>8), n+1
+	}
+	step := max(1, max(b.Dx(), b.Dy())/analysisSize)
+	for x := b.Min.X; x < b.Max.X; x += step {
+		add(x, b.Min.Y)
+		add(x, b.Max.Y-1)
+	}
+	for y := b.Min.Y; y < b.Max.Y; y += step {
+		add(b.Min.X, y)
+		add(b.Max.X-1, y)
+	}
+	if n == 0 {
+		return color.RGBA{A: 0xff}
+	}
+	return color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: 0xff}
+}
+
+// DetectBorders returns the part of img inside the uniform borders along its
+// edges, such as the black film rebate or the white scanner bed around a
+// scanned frame, or img's bounds if it has none. Each side is trimmed while
+// its lines stay close to the color of the outermost one and barely vary.
+func DetectBorders(img image.Image) image.Rectangle {
+	gray, scale := analysisCopy(img)
+	w, h := gray.Rect.Dx(), gray.Rect.Dy()
+	row := func(y int) []uint8 { return gray.Pix[y*gray.Stride:][:w] }
+	col := func(x int) []uint8 {
+		line := make([]uint8, h)
+		for y := range line {
+			line[y] = gray.Pix[y*gray.Stride+x]
+		}
+		return line
+	}
+	// trim counts the border lines from one side, line(0) being the outermost
+	trim := func(n int, line func(i int) []uint8) int {
+		ref, _ := lineStats(line(0))
+		limit := int(float64(n) * maxBorderFraction)
+		i := 0
+		for ; i < limit; i++ {
+			mean, spread := lineStats(line(i))
+			if math.Abs(mean-ref) > borderTolerance || spread > borderTolerance {
+				break
+			}
+		}
+		return i
+	}
+	top := trim(h, func(i int) []uint8 { return row(i) })
+	bottom := trim(h, func(i int) []uint8 { return row(h - 1 - i) })
+	left := trim(w, func(i int) []uint8 { return col(i) })
+	right := trim(w, func(i int) []uint8 { return col(w - 1 - i) })
+
+	b := img.Bounds()
+	if top+bottom == 0 && left+right == 0 {
+		return b
+	}
+	// Back to full size, rounding inwards so no border is left
+	r := image.Rect(
+		b.Min.X+int(math.Ceil(float64(left)*scale)),
+		b.Min.Y+int(math.Ceil(float64(top)*scale)),
+		b.Max.X-int(math.Ceil(float64(right)*scale)),
+		b.Max.Y-int(math.Ceil(float64(bottom)*scale)),
+	)
+	if r.Empty() {
+		return b
+	}
+	return r
+}
+
+// lineStats returns the mean of values and the spread between their 5th and
+// 95th percentiles, which ignores the odd speck of dust.
+func lineStats(values []uint8) (mean, spread float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sorted := make([]uint8, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	sum := 0
+	for _, v := range sorted {
+		sum += int(v)
+	}
+	lo, hi := sorted[len(sorted)*5/100], sorted[(len(sorted)-1)*95/100]
+	return float64(sum) / float64(len(sorted)), float64(hi) - float64(lo)
+}
+
+// Crop returns the part r of img, copied so the rest can be freed.
+func Crop(img image.Image, r image.Rectangle) image.Image {
+	r = r.Intersect(img.Bounds())
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Rect, img, r.Min, draw.Src)
+	return dst
+}
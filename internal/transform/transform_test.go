@@ -0,0 +1,144 @@
+package transform
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// frame returns a white image of size w x h with a gray frame inset from its
+// edges, like a scanned negative on a light table.
+func frame(w, h, inset int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Rect, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(inset, inset, w-inset, h-inset), image.NewUniform(color.Gray{Y: 60}), image.Point{}, draw.Src)
+	return img
+}
+
+func TestRotate(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	red := color.RGBA{R: 255, A: 255}
+	img.SetRGBA(0, 0, red) // Top left corner
+
+	tests := []struct {
+		degrees int
+		size    image.Point
+		corner  image.Point
+	}{
+		{90, image.Pt(2, 3), image.Pt(1, 0)},
+		{180, image.Pt(3, 2), image.Pt(2, 1)},
+		{270, image.Pt(2, 3), image.Pt(0, 2)},
+		{-90, image.Pt(2, 3), image.Pt(0, 2)},
+	}
+	for _, test := range tests {
+		got := Rotate(img, test.degrees).(*image.RGBA)
+		if got.Rect.Size() != test.size {
+			t.Errorf("Rotate(%d) size = %v, want %v", test.degrees, got.Rect.Size(), test.size)
+		}
+		if got.RGBAAt(test.corner.X, test.corner.Y) != red {
+			t.Errorf("Rotate(%d) did not move the top left corner to %v", test.degrees, test.corner)
+		}
+	}
+	if Rotate(img, 360) != image.Image(img) {
+		t.Error("Rotate(360) copied the image")
+	}
+}
+
+func TestDetectSkew(t *testing.T) {
+	straight := frame(600, 400, 80)
+	if got := DetectSkew(straight, 0); got != 0 {
+		t.Errorf("DetectSkew of a straight frame = %v, want 0", got)
+	}
+	for _, skew := range []float64{3, -1.5} {
+		skewed := Deskew(straight, -skew) // Deskewing by -skew turns it clockwise by skew
+		got := DetectSkew(skewed, 0)
+		if math.Abs(got-skew) > 0.3 {
+			t.Errorf("DetectSkew of a frame skewed %v° = %v", skew, got)
+		}
+		if again := DetectSkew(Deskew(skewed, got), 0); math.Abs(again) > 0.3 {
+			t.Errorf("frame skewed %v° still skewed %v° after Deskew", skew, again)
+		}
+	}
+	if got := DetectSkew(Deskew(straight, -12), 0); got != 0 {
+		t.Errorf("DetectSkew found %v° in a frame skewed beyond the maximum", got)
+	}
+}
+
+func TestDetectBorders(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	draw.Draw(img, image.Rect(20, 10, 290, 170), image.NewUniform(color.White), image.Point{}, draw.Src)
+	// Content that isn't uniform
+	for x := 20; x < 290; x += 7 {
+		draw.Draw(img, image.Rect(x, 10, x+3, 170), image.NewUniform(color.Gray{Y: 128}), image.Point{}, draw.Src)
+	}
+	if got, want := DetectBorders(img), image.Rect(20, 10, 290, 170); got != want {
+		t.Errorf("DetectBorders = %v, want %v", got, want)
+	}
+
+	busy := image.NewGray(image.Rect(0, 0, 100, 100))
+	for i := range busy.Pix {
+		busy.Pix[i] = uint8(i * 37)
+	}
+	if got := DetectBorders(busy); got != busy.Rect {
+		t.Errorf("DetectBorders of a borderless image = %v, want its bounds", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	opts := Options{Rotate: -90}
+	if err := opts.Validate(); err != nil || opts.Rotate != 270 || opts.MaxSkew != DefaultMaxSkew {
+		t.Errorf("Validate = %v, options %+v; want rotation 270 and the default maximum skew", err, opts)
+	}
+	for _, bad := range []Options{{}, {Rotate: 45}, {Deskew: true, MaxSkew: 50}, {Crop: true, Quality: 101}} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestApplyFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scan.png")
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, frame(300, 200, 30)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Rotate: 90, Crop: true}
+	if err := opts.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := ApplyFile(path, opts, true)
+	if err != nil || !r.Changed || r.Size != image.Pt(140, 240) {
+		t.Fatalf("dry run = %+v, %v; want a rotated 140x240 crop", r, err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, buf.Bytes()) {
+		t.Error("dry run rewrote the file")
+	}
+
+	if _, err := ApplyFile(path, opts, false); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cfg, err := png.DecodeConfig(f)
+	if err != nil || cfg.Width != 140 || cfg.Height != 240 {
+		t.Errorf("transformed file is %dx%d (%v), want 140x240", cfg.Width, cfg.Height, err)
+	}
+
+	if _, err := ApplyFile(filepath.Join(dir, "anim.gif"), opts, true); err == nil {
+		t.Error("ApplyFile of a GIF succeeded")
+	}
+}
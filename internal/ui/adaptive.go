@@ -0,0 +1,43 @@
+// Package ui Adaptive slideshow timing: large images and panoramas stay longer.
+package ui
+
+import (
+	"fyslide/internal/slideshow"
+	"time"
+)
+
+const (
+	// DefaultAdaptiveMinSeconds is the default shortest display time in adaptive mode.
+	DefaultAdaptiveMinSeconds = 1
+	// DefaultAdaptiveMaxSeconds is the default longest display time in adaptive mode.
+	DefaultAdaptiveMaxSeconds = 15
+	minAdaptiveSeconds        = 1
+	maxAdaptiveSeconds        = 600
+)
+
+// adaptiveBounds returns the configured display time limits of adaptive mode.
+func (a *App) adaptiveBounds() slideshow.AdaptiveBounds {
+	lo := a.prefs().IntWithFallback(prefAdaptiveMinSeconds, DefaultAdaptiveMinSeconds)
+	hi := a.prefs().IntWithFallback(prefAdaptiveMaxSeconds, DefaultAdaptiveMaxSeconds)
+	lo = min(max(lo, minAdaptiveSeconds), maxAdaptiveSeconds)
+	hi = min(max(hi, lo), maxAdaptiveSeconds)
+	return slideshow.AdaptiveBounds{Min: time.Duration(lo) * time.Second, Max: time.Duration(hi) * time.Second}
+}
+
+// applyAdaptiveIntervalPreference switches the slideshow between the fixed
+// interval and per-image display times.
+func (a *App) applyAdaptiveIntervalPreference() {
+	if a.prefs().Bool(prefAdaptiveInterval) {
+		a.slideshowManager.SetDurationProvider(a.adaptiveDuration)
+	} else {
+		a.slideshowManager.SetDurationProvider(nil)
+	}
+	a.slideshowManager.Reset()
+}
+
+// adaptiveDuration is the slideshow's duration provider in adaptive mode. It
+// sizes the display time by the file's resolution, even when it is shown
+// downscaled, so the slideshow is only reset on the Fyne goroutine.
+func (a *App) adaptiveDuration(base time.Duration) time.Duration {
+	return slideshow.AdaptiveInterval(base, a.img.FullSize.X, a.img.FullSize.Y, a.adaptiveBounds())
+}
@@ -0,0 +1,257 @@
+// Package ui Annotations: arrows, boxes and text drawn over the current image
+// (A), kept in the tag database apart from the pixels and exportable as a
+// flattened copy.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/annotate"
+	"fyslide/internal/exporter"
+	"fyslide/internal/iosched"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Tools of the annotation bar, by the kind of shape they draw.
+var annotateTools = map[string]string{
+	"Arrow": annotate.KindArrow,
+	"Box":   annotate.KindBox,
+	"Text":  annotate.KindText,
+}
+
+// buildAnnotationBar creates the hidden bar of annotate mode, choosing the
+// tool and color and offering undo, clear and export.
+func (a *App) buildAnnotationBar() fyne.CanvasObject {
+	a.UI.annotateTool = widget.NewRadioGroup([]string{"Arrow", "Box", "Text"}, func(string) { a.applyAnnotateTool() })
+	a.UI.annotateTool.Horizontal = true
+	a.UI.annotateTool.Required = true
+	a.UI.annotateTool.SetSelected("Arrow")
+
+	var names []string
+	for _, c := range annotate.Palette {
+		names = append(names, c.Name)
+	}
+	a.UI.annotateColor = widget.NewSelect(names, func(name string) {
+		a.prefs().SetString(prefAnnotationColor, name)
+		a.applyAnnotateTool()
+	})
+	if name := a.prefs().String(prefAnnotationColor); slices.Contains(names, name) {
+		a.UI.annotateColor.SetSelected(name)
+	} else {
+		a.UI.annotateColor.SetSelected(names[0])
+	}
+
+	hint := widget.NewLabel("Drag to draw, click to place text")
+	tools := container.NewHBox(hint, a.UI.annotateTool, a.UI.annotateColor)
+	actions := container.NewHBox(
+		widget.NewButtonWithIcon("Undo", theme.ContentUndoIcon(), a.undoAnnotation),
+		widget.NewButtonWithIcon("Clear", theme.ContentClearIcon(), a.clearAnnotations),
+		widget.NewButtonWithIcon("Export Copy...", theme.DocumentSaveIcon(), a.exportAnnotatedCopy),
+		widget.NewButton("Done", a.toggleAnnotating),
+	)
+	a.UI.annotationBar = container.NewBorder(nil, nil, tools, actions)
+	a.UI.annotationBar.Hide()
+	return a.UI.annotationBar
+}
+
+// annotating reports whether annotate mode is on.
+func (a *App) annotating() bool {
+	return a.UI.annotationBar != nil && a.UI.annotationBar.Visible()
+}
+
+// applyAnnotateTool passes the bar's tool and color to the image while
+// annotate mode is on.
+func (a *App) applyAnnotateTool() {
+	if !a.annotating() {
+		return
+	}
+	color := annotate.Palette[0].Hex
+	for _, c := range annotate.Palette {
+		if c.Name == a.UI.annotateColor.Selected {
+			color = c.Hex
+		}
+	}
+	a.zoomPanArea.SetAnnotateTool(annotateTools[a.UI.annotateTool.Selected], color, a.addAnnotation)
+}
+
+// toggleAnnotating switches annotate mode on or off. While it is on, the
+// slideshow stays paused and dragging over the image draws instead of panning.
+func (a *App) toggleAnnotating() {
+	if a.UI.annotationBar == nil {
+		return
+	}
+	if a.annotating() {
+		a.UI.annotationBar.Hide()
+		a.zoomPanArea.SetAnnotateTool("", "", nil)
+		a.updateStatusBar()
+		return
+	}
+	if a.refuseInReadOnly("Annotate") {
+		return
+	}
+	if a.img.Path == "" {
+		dialog.ShowInformation("Annotate", "No image loaded to annotate.", a.UI.MainWin)
+		return
+	}
+	if a.pairedIndex >= 0 {
+		dialog.ShowInformation("Annotate", "Side-by-side pairs can't be annotated. Show the image on its own first.", a.UI.MainWin)
+		return
+	}
+	a.slideshowManager.Pause(true)
+	a.UI.annotationBar.Show()
+	a.applyAnnotateTool()
+	a.updateStatusBar()
+}
+
+// toggleShowAnnotations shows or hides the annotations of every image.
+func (a *App) toggleShowAnnotations() {
+	show := !a.prefs().BoolWithFallback(prefShowAnnotations, true)
+	a.prefs().SetBool(prefShowAnnotations, show)
+	a.zoomPanArea.SetShowAnnotations(show)
+	if show {
+		a.addLogMessage("Annotations shown")
+	} else {
+		a.addLogMessage("Annotations hidden")
+	}
+}
+
+// loadAnnotations shows the annotations of the image at path. A pair shown
+// side by side gets none, as they wouldn't line up.
+func (a *App) loadAnnotations(path string, paired bool) {
+	if paired {
+		return
+	}
+	shapes, err := a.tagDB.Annotations(a.ctx, path)
+	if err != nil {
+		a.addLogMessage(fmt.Sprintf("Error loading annotations of %s: %v", filepath.Base(path), err))
+		return
+	}
+	a.zoomPanArea.SetAnnotations(shapes)
+}
+
+// addAnnotation stores a shape drawn over the current image, first asking
+// for the text of a text annotation.
+func (a *App) addAnnotation(s annotate.Shape) {
+	path := a.img.Path
+	if path == "" || a.pairedIndex >= 0 { // A pair was shown while annotating
+		return
+	}
+	if s.Kind != annotate.KindText {
+		a.saveAnnotations(path, append(slices.Clone(a.zoomPanArea.Annotations()), s))
+		return
+	}
+	entry := widget.NewMultiLineEntry()
+	entry.SetPlaceHolder("Text of the annotation")
+	entry.SetMinRowsVisible(3)
+	d := dialog.NewForm("Add Text", "Add", "Cancel", []*widget.FormItem{widget.NewFormItem("Text", entry)}, func(confirm bool) {
+		s.Text = strings.TrimSpace(entry.Text)
+		if !confirm || s.Text == "" || a.img.Path != path {
+			return
+		}
+		a.saveAnnotations(path, append(slices.Clone(a.zoomPanArea.Annotations()), s))
+	}, a.UI.MainWin)
+	d.Resize(fyne.NewSize(searchDialogWidth, d.MinSize().Height))
+	d.Show()
+	a.UI.MainWin.Canvas().Focus(entry)
+}
+
+// undoAnnotation removes the shape drawn last over the current image.
+func (a *App) undoAnnotation() {
+	if shapes := a.zoomPanArea.Annotations(); len(shapes) > 0 {
+		a.saveAnnotations(a.img.Path, slices.Clone(shapes[:len(shapes)-1]))
+	}
+}
+
+// clearAnnotations removes every annotation of the current image after
+// asking.
+func (a *App) clearAnnotations() {
+	path, n := a.img.Path, len(a.zoomPanArea.Annotations())
+	if n == 0 {
+		return
+	}
+	dialog.ShowConfirm("Clear Annotations", fmt.Sprintf("Remove all %d annotations of %s?", n, filepath.Base(path)), func(ok bool) {
+		if ok && a.img.Path == path {
+			a.saveAnnotations(path, nil)
+		}
+	}, a.UI.MainWin)
+}
+
+// saveAnnotations stores shapes as the annotations of the image at path and
+// shows them.
+func (a *App) saveAnnotations(path string, shapes []annotate.Shape) {
+	if err := a.tagDB.SetAnnotations(a.ctx, path, shapes); err != nil {
+		a.addLogMessage(fmt.Sprintf("Error saving annotations of %s: %v", filepath.Base(path), err))
+		dialog.ShowError(err, a.UI.MainWin)
+		return
+	}
+	if a.img.Path == path {
+		a.zoomPanArea.SetAnnotations(shapes)
+	}
+}
+
+// exportAnnotatedCopy writes a copy of the current image, decoded at full
+// resolution, with its annotations drawn in, to a file chosen by the user.
+// The copy is a PNG if its name ends in .png and a JPEG otherwise.
+func (a *App) exportAnnotatedCopy() {
+	path, shapes := a.img.Path, slices.Clone(a.zoomPanArea.Annotations())
+	if path == "" || len(shapes) == 0 {
+		dialog.ShowInformation("Export Annotated Copy", "The current image has no annotations.", a.UI.MainWin)
+		return
+	}
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		go func() {
+			err := a.writeAnnotatedCopy(path, shapes, writer)
+			fyne.Do(func() {
+				if err != nil {
+					a.addLogMessage(fmt.Sprintf("Export of annotated copy failed: %v", err))
+					dialog.ShowError(err, a.UI.MainWin)
+					return
+				}
+				a.addLogMessage(fmt.Sprintf("Annotated copy saved as %s.", writer.URI().Path()))
+			})
+		}()
+	}, a.UI.MainWin)
+	ext := filepath.Ext(path)
+	name := strings.TrimSuffix(filepath.Base(path), ext) + "-annotated"
+	if strings.EqualFold(ext, ".png") {
+		save.SetFileName(name + ".png")
+	} else {
+		save.SetFileName(name + ".jpg")
+	}
+	if dir, err := storage.ListerForURI(storage.NewFileURI(filepath.Dir(path))); err == nil {
+		save.SetLocation(dir)
+	}
+	save.Show()
+}
+
+// writeAnnotatedCopy decodes the image at path, draws shapes onto it and
+// encodes the result to writer, closing it.
+func (a *App) writeAnnotatedCopy(path string, shapes []annotate.Shape, writer fyne.URIWriteCloser) (err error) {
+	defer func() {
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	img, _, err := a.decoder.Decode(a.ctx, iosched.Foreground, path)
+	if err != nil {
+		return fmt.Errorf("decoding %s: %w", filepath.Base(path), err)
+	}
+	flattened := annotate.Flatten(img, shapes)
+	if strings.EqualFold(writer.URI().Extension(), ".png") {
+		return png.Encode(writer, flattened)
+	}
+	return jpeg.Encode(writer, flattened, &jpeg.Options{Quality: exporter.DefaultQuality})
+}
@@ -2,21 +2,38 @@
 package ui
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"fyslide/internal/config"
+	"fyslide/internal/decode"
+	"fyslide/internal/deeplink"
+	"fyslide/internal/events"
 	"fyslide/internal/history"
+	"fyslide/internal/imagesig"
+	"fyslide/internal/iosched"
+	"fyslide/internal/mqttlink"
+	"fyslide/internal/paths"
+	"fyslide/internal/profile"
+	"fyslide/internal/query"
 	"fyslide/internal/scan"
+	"fyslide/internal/search"
+	"fyslide/internal/service"
 	"fyslide/internal/slideshow" // Import the new package
+	"fyslide/internal/stacks"
 	"fyslide/internal/tagging"
 	"image"
 	"io"
 	"log"
-	"math/rand"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -34,6 +51,8 @@ import (
 const (
 	// DefaultSkipCount is the default number of images to skip with PageUp/PageDown.
 	DefaultSkipCount = 20
+	// maxProtectedListed is how many protected images a delete confirmation names.
+	maxProtectedListed = 10
 )
 
 // Img struct
@@ -43,6 +62,8 @@ type Img struct {
 	Path          string
 	Directory     string
 	EXIFData      map[string]string // To store selected EXIF fields
+	FullSize      image.Point       // Pixel size of the file; OriginalImage may be downscaled, see maxDecodeDimension
+	Shown         image.Image       // What the zoom area shows before auto-enhance and night dimming; a side-by-side pair shows both images
 }
 
 // UI struct
@@ -61,9 +82,12 @@ type UI struct {
 	// randomBtn    *widget.Button
 
 	toolBar            *widget.Toolbar
+	quickFilter        *quickFilterEntry
+	filterCount        *widget.Label         // Live count of the active filter, beside the quick filter
 	randomAction       *widget.ToolbarAction // Action for toggling random mode
 	pauseAction        *widget.ToolbarAction // Action for toggling play/pause
 	showFullSizeAction *widget.ToolbarAction // Action for showing image at full size
+	zoomFitAction      *widget.ToolbarAction // Action for fitting the image to the view
 
 	contentStack     *fyne.Container   // To hold the main views
 	imageContentView fyne.CanvasObject // ADDED: Holds the image view (split)
@@ -72,8 +96,32 @@ type UI struct {
 	statusBar        *fyne.Container // Changed from *widget.Label to *fyne.Container
 	statusPathLabel  *widget.Label   // For file path and image count
 	statusLogLabel   *widget.Label   // For log messages
+	statusZoomLabel  *widget.Label   // Zoom indicator ("Fit", "100%", ...)
 	statusLogUpBtn   *widget.Button
 	statusLogDownBtn *widget.Button
+
+	dirDefaultsBanner *fyne.Container // Offers the folder's default tags, see checkDirDefaults
+	dirDefaultsLabel  *widget.Label
+
+	offlineBanner *fyne.Container // Shown while the library is unreachable, see onLibraryOffline
+	offlineLabel  *widget.Label
+
+	bookmarksMenu     *fyne.Menu // View > Bookmarks, rebuilt when bookmarks change
+	tagSetsMenu       *fyne.Menu // Edit > Tag Sets, rebuilt when tag sets change
+	recentFoldersMenu *fyne.Menu // View > Recent Folders, rebuilt when a folder is filtered to
+	playbackMenu      *fyne.Menu // View > Playback, rebuilt when the mode or loop changes
+
+	breadcrumbBar fyne.CanvasObject // Above the image, kept across rebuilds of the image pane
+	breadcrumbs   *fyne.Container   // Segments of the current image's folder, see updateBreadcrumbs
+	breadcrumbDir string            // Folder the breadcrumbs show
+
+	annotationBar *fyne.Container    // Tools of annotate mode, shown while it is on
+	annotateTool  *widget.RadioGroup // Kind of shape to draw
+	annotateColor *widget.Select     // Color to draw in
+
+	imageArea  *fyne.Container // The image with the loupe pane beside it, kept across rebuilds of the image pane
+	loupePane  *fyne.Container // Shown while the loupe is on
+	loupeLabel *widget.Label   // Zoom of the loupe
 }
 
 // App represents the whole application with all its windows, widgets and functions
@@ -88,19 +136,84 @@ type App struct {
 	index          int
 	img            Img
 	zoomPanArea    *ZoomPanArea
+	loupe          *ZoomPanArea // Close-up linked to zoomPanArea while the loupe pane is shown
+
+	thumbnailManager  *ThumbnailManager            // Generates and caches thumbnails for the strip
+	thumbStrip        *thumbnailStrip              // Strip of thumbnails around the current image
+	loadingPath       string                       // Path of the image load currently in flight, "" if none
+	consecutiveSkips  int                          // Unreadable images skipped in a row by the skip-corrupt policy
+	tagWorker         *tagWorker                   // Applies tag mutations off the UI goroutine
+	copiedTags        *copiedTags                  // Taken by Copy Tags for Paste Tags; nil until then
+	io                *iosched.Scheduler           // Orders disk reads: the displayed image first
+	decoder           *decode.Pool                 // Decodes images for the view, thumbnails and signatures
+	editorWatchStop   chan struct{}                // Closes to stop watching the file opened in the external editor
+	orientations      *orientationCache            // Orientation of images seen so far, for orientation-aware playback
+	pendingPairPath   string                       // Portrait to show next to the image about to load, "" if none
+	pairedIndex       int                          // Index of the partner currently shown alongside a.index, -1 if none
+	probingHeaders    bool                         // Image headers are read in the background to pick the next slideshow image
+	fullResPath       string                       // Image to decode without the size cap, set by Load Full Resolution
+	autoEnhance       bool                         // Auto-enhance preview on: displayed images get their levels stretched
+	dimBrightness     float64                      // Share of the normal brightness images are shown at in night hours; 0 leaves them as they are
+	blankedContent    fyne.CanvasObject            // The window's content while blank hours show a black screen instead; nil otherwise
+	imagesViewed      atomic.Int64                 // Images shown since the usage statistics were last flushed
+	filterPositions   *filterPositions             // Where each filter was left, to resume there
+	viewMemory        *viewMemory                  // Zoom and pan of images visited, restored when returning to them
+	config            *config.Config               // Settings of config.yaml and the environment
+	libraryRoots      []string                     // Folders the images were scanned from
+	libraryOffline    bool                         // The library's disk or share is unreachable; the offline banner is up
+	offlineRetry      chan struct{}                // Asks the offline watcher to check right away
+	searchIndex       *search.Index                // Built on first search, then kept current incrementally; nil until then
+	imageInfo         map[string]tagging.ImageInfo // EXIF index records by path, replaced whole when updated; nil until loaded
+	indexCancel       context.CancelFunc           // Stops the running EXIF indexing job; nil when none runs
+	indexStatus       string                       // Progress of the EXIF indexing job, for the status bar
+	qualityWarnings   map[string][]string          // Quality tags of the images carrying any, for the strip's badges
+	privateImages     scan.FileItems               // Images carrying the private tag, kept out of a.images while locked
+	privateUnlocked   bool                         // Whether the PIN was entered this session
+	quarantinedImages scan.FileItems               // Images that failed to load repeatedly, kept out of a.images until retried
+	loadFailures      map[string]bool              // Images with recorded load failures, cleared once they load
+	rescanning        bool                         // A rescan of the library is running
+	profile           string                       // Active profile name, see --profile
+	kiosk             bool                         // Fullscreen, with the filter switched by the config's schedule; see --kiosk
+	startView         *deeplink.View               // View to show first, named on the command line or by a fyslide:// link; nil for none
+	scanDone          atomic.Bool                  // Set once the initial scan of the roots finished
+	preferences       fyne.Preferences             // Settings of the active profile, see prefs()
+	events            *events.Bus                  // UI actions publish here for integrations, see --events
+	stopHooks         func()                       // Stops running the config's event hooks
+	eventServer       *events.WebSocketServer      // Broadcasts events to WebSocket clients; nil without --events
+	mqttClient        *mqttlink.Client             // Link to the MQTT broker; nil until connected or without --mqtt-broker
+
+	dirDefaultsFolder    string          // Folder last checked for default tags
+	dirDefaultsDismissed map[string]bool // Folders whose default tags banner was dismissed this session
+
+	stacks         *stacks.Index   // Stacks of images, from the stack tags; nil until loaded
+	expandedStacks map[string]bool // Stacks shown image by image instead of as their first image
+	stackMarks     []string        // Images marked for Stack Marked Images
+
+	startPath  string // Image to show first once the next filter is applied or cleared, e.g. a bookmark's
+	startIndex int    // Position to show instead when startPath isn't in the list
 
 	historyManager      *history.HistoryManager // Manages navigation history
 	isNavigatingHistory bool                    // True if DisplayImage is called from a history action
 
-	slideshowManager *slideshow.SlideshowManager // NEW: Use SlideshowManager
+	slideshowManager *slideshow.SlideshowManager   // NEW: Use SlideshowManager
+	shuffle          *slideshow.PermutationManager // Order of random mode
 	direction        int
+	loopA, loopB     string // Paths of the A-B loop's start and end points, "" if unset
+	lastFolder       string // Folder of the image displayed last, to name a newly entered one
 
 	random bool
 
-	tagDB *tagging.TagDB // Add the tag database instance
+	commands *commandRegistry // Actions shared by the menus, the toolbar and the command palette
 
-	isFiltered       bool   // NEW: Flag to indicate if filtering is active
-	currentFilterTag string // NEW: The tag currently being filtered by
+	tagDB   *tagging.TagDB   // Add the tag database instance
+	service *service.Service // File operations that keep the tag database in sync
+	ctx     context.Context  // Passed to database operations and honored by background work; cancelled when the main window closes
+	stop    context.CancelFunc
+	workers sync.WaitGroup // Background work started by goBackground, waited for before the database closes
+
+	isFiltered    bool           // NEW: Flag to indicate if filtering is active
+	currentFilter query.Criteria // The tag and property criteria currently applied
+	filterCounts  *filterCounts  // Live counts of the filter's tags; nil without a tag filter
 
 	refreshTagsFunc func() // This will hold the function returned by buildTagsTab
 
@@ -109,12 +222,13 @@ type App struct {
 	logUIManager   *LogUIManager
 }
 
-// getCurrentList returns the active image list (filtered or full)
+// getCurrentList returns the active image list (filtered or full), with
+// collapsed stacks reduced to their first image.
 func (a *App) getCurrentList() scan.FileItems {
 	if a.isFiltered {
-		return a.filteredImages
+		return a.stacks.Collapse(a.filteredImages, a.expandedStacks)
 	}
-	return a.images
+	return a.stacks.Collapse(a.images, a.expandedStacks)
 }
 
 // getCurrentImageCount returns the count of the active image list
@@ -174,6 +288,13 @@ func (a *App) getCurrentItem() *scan.FileItem {
 	return &currentList[a.index]
 }
 
+// updateThumbStrip moves the thumbnail strip window to the current index.
+func (a *App) updateThumbStrip() {
+	if a.thumbStrip != nil {
+		a.thumbStrip.Update()
+	}
+}
+
 // updateStatusBar updates the text of the status bar.
 func (a *App) updateStatusBar() {
 	if a.UI.statusPathLabel == nil {
@@ -188,7 +309,7 @@ func (a *App) updateStatusBar() {
 		// as GetImageFullPath() might panic if a.index is somehow out of sync.
 		statusText = fmt.Sprintf("%s  |  Image %d / %d", currentItem.Path, a.index+1, a.getCurrentImageCount())
 		if a.isFiltered {
-			statusText += fmt.Sprintf(" (Filtered: %s)", a.currentFilterTag)
+			statusText += fmt.Sprintf(" (Filtered: %s)", a.filterStatus())
 		}
 	}
 	if a.slideshowManager.IsPaused() {
@@ -196,14 +317,39 @@ func (a *App) updateStatusBar() {
 	} else {
 		statusText += " | Playing"
 	}
+	if stack := a.stackStatus(); stack != "" {
+		statusText += " | " + stack
+	}
+	if n := a.pendingTagJobs(); n > 0 {
+		statusText += fmt.Sprintf(" | Saving tags (%d)...", n)
+	}
+	if a.indexCancel != nil {
+		statusText += fmt.Sprintf(" | Indexing EXIF (%s)...", a.indexStatus)
+	}
+	if a.readOnly() {
+		statusText += " | Read-only"
+	}
+	if a.autoEnhance {
+		statusText += " | Auto-enhanced"
+	}
+	if a.annotating() {
+		statusText += " | Annotating"
+	}
+	if loop := a.loopStatus(); loop != "" {
+		statusText += " | " + loop
+	}
+	if a.random && a.shuffle != nil {
+		statusText += fmt.Sprintf(" | Shuffle seed %d", a.shuffle.Seed())
+	}
 	a.UI.statusPathLabel.SetText(statusText) // Update only the path label
+	a.syncFilterCount()
 }
 
 // addLogMessage adds a message to the UI log display.
 func (a *App) addLogMessage(message string) {
-	// Optional: Keep console logging here if desired, or move to LogUIManager
-	// log.Printf("App->LogUIManager: %s", message)
-
+	if fileLog != nil {
+		fileLog.Print(message)
+	}
 	if a.logUIManager != nil {
 		a.logUIManager.AddLogMessage(message)
 	} else {
@@ -244,15 +390,22 @@ func (a *App) updateInfoText() {
 	imgWidth := 0
 	imgHeight := 0
 	if a.img.OriginalImage != nil {
-		imgWidth = a.img.OriginalImage.Bounds().Max.X
-		imgHeight = a.img.OriginalImage.Bounds().Max.Y
+		imgWidth = a.img.FullSize.X
+		imgHeight = a.img.FullSize.Y
+	}
+	sizeNote := ""
+	if a.img.downscaled() {
+		shown := a.img.OriginalImage.Bounds().Size()
+		sizeNote = fmt.Sprintf("\n\n*Shown at %dx%d; View > Load Full Resolution shows every pixel.*", shown.X, shown.Y)
 	}
 
 	// --- Get Tags ---
-	currentTags, errTags := a.tagDB.GetTags(a.img.Path)
+	currentTags, errTags := a.tagDB.GetTags(a.ctx, a.img.Path)
 	if errTags != nil {
 		// Log the error, but continue to display other info
 		a.addLogMessage(fmt.Sprintf("Error getting tags for %s: %v", a.img.Path, errTags))
+	} else {
+		currentTags = a.withPendingTags(a.img.Path, currentTags) // Show unsaved changes optimistically
 	}
 	tagsString := "(none)" // Default if no tags or error occurred
 	// Only join if no error occurred and tags exist
@@ -266,7 +419,7 @@ func (a *App) updateInfoText() {
 		var exifParts []string
 		// Define a preferred order or a selection of tags to display
 		displayOrder := []exif.FieldName{ // Use exif.FieldName for keys
-			exif.Make, exif.Model, exif.DateTimeOriginal,
+			exif.ImageDescription, exif.Make, exif.Model, exif.DateTimeOriginal,
 			exif.ExposureTime, exif.FNumber, exif.ISOSpeedRatings,
 			exif.PixelXDimension, exif.PixelYDimension, // Original dimensions from EXIF
 		}
@@ -299,7 +452,7 @@ func (a *App) updateInfoText() {
 	// --- Build Markdown ---
 	filterStatus := ""
 	if a.isFiltered {
-		filterStatus = fmt.Sprintf("\n**Filter Active:** %s\n", a.currentFilterTag)
+		filterStatus = fmt.Sprintf("\n**Filter Active:** %s\n", a.currentFilter)
 	}
 
 	md := fmt.Sprintf(`## Stats
@@ -312,7 +465,7 @@ func (a *App) updateInfoText() {
 
 **Width:**   %d px
 
-**Height:**  %d px
+**Height:**  %d px%s
 
 **Last modified:** %s
 
@@ -330,11 +483,14 @@ func (a *App) updateInfoText() {
 		formatNumberWithCommas(fileInfo.Size()), // Format size
 		imgWidth,                                // Reverted
 		imgHeight,                               // Reverted
+		sizeNote,
 		fileInfo.ModTime().Format("2006-01-02"),
 		tagsString, // Add the formatted tags string here
 		exifString, // Add the formatted EXIF string
 	)
 
+	md += a.ocrTextSection(a.img.Path)
+
 	// --- Update Widget ---
 	a.UI.infoText.ParseMarkdown(md)
 	// Optional: Scroll to top if content is long
@@ -343,12 +499,38 @@ func (a *App) updateInfoText() {
 	// }
 }
 
+// imageLoadState describes the progress of an asynchronous image load.
+type imageLoadState int
+
+const (
+	imageLoadStarted imageLoadState = iota
+	imageLoadSucceeded
+	imageLoadFailed
+)
+
+// onImageLoadState is the loading-state callback of the image load pipeline.
+// It drives the busy indicator; states for a path other than the most recently
+// requested one are stale and ignored. Must be called on the Fyne goroutine.
+func (a *App) onImageLoadState(path string, state imageLoadState) {
+	switch state {
+	case imageLoadStarted:
+		a.loadingPath = path
+		a.zoomPanArea.SetLoading(true)
+	case imageLoadSucceeded, imageLoadFailed:
+		if path == a.loadingPath {
+			a.loadingPath = ""
+			a.zoomPanArea.SetLoading(false)
+		}
+	}
+}
+
 // handleImageDisplayError is a helper to set the UI state when an image fails to load or decode.
 // formatName is optional and only used if errorType is "Decoding".
 func (a *App) handleImageDisplayError(imagePath, errorType string, originalError error, formatName string) {
+	a.onImageLoadState(imagePath, imageLoadFailed)
 	a.img = Img{Path: imagePath, EXIFData: make(map[string]string)} // Keep path, clear EXIF
-	a.zoomPanArea.SetImage(nil)
-	a.UI.MainWin.SetTitle(fmt.Sprintf("FySlide - Error %s %s", errorType, filepath.Base(imagePath)))
+	a.zoomPanArea.ShowError(filepath.Base(imagePath), fmt.Errorf("%s failed: %w", errorType, originalError))
+	a.UI.MainWin.SetTitle(fmt.Sprintf("FySlide%s - Error %s %s", a.profileTitle(), errorType, filepath.Base(imagePath)))
 	a.updateInfoText()
 	if errorType == "Decoding" && formatName != "" {
 		msg := fmt.Sprintf("Error %s %s (format: %s): %v", errorType, filepath.Base(imagePath), formatName, originalError)
@@ -357,6 +539,37 @@ func (a *App) handleImageDisplayError(imagePath, errorType string, originalError
 		msg := fmt.Sprintf("Error %s %s: %v", errorType, filepath.Base(imagePath), originalError)
 		a.addLogMessage(msg)
 	}
+	if a.recordLoadFailure(imagePath, originalError) {
+		return // The next image is shown instead
+	}
+	a.skipCorruptImage(imagePath)
+}
+
+// skipCorruptImage applies the skip-corrupt playback policy: while the slideshow is
+// playing, an unreadable image is logged (and optionally tagged) and playback moves
+// on immediately instead of sitting on the error placeholder until the next tick.
+func (a *App) skipCorruptImage(imagePath string) {
+	prefs := a.prefs()
+	if !prefs.Bool(prefSkipCorrupt) || a.slideshowManager.IsPaused() {
+		return
+	}
+	if item := a.getCurrentItem(); item == nil || item.Path != imagePath {
+		return // A newer image was requested meanwhile; nothing to skip
+	}
+
+	if prefs.Bool(prefTagCorrupt) && !a.readOnly() {
+		a.submitTagJob(fmt.Sprintf("Tagging %s as '%s'", filepath.Base(imagePath), tagging.CorruptTag),
+			[]tagOp{{path: imagePath, tag: tagging.CorruptTag, add: true}}, nil)
+	}
+
+	a.consecutiveSkips++
+	if a.consecutiveSkips >= a.getCurrentImageCount() {
+		a.addLogMessage("Every image in the current list failed to load; no longer skipping.")
+		a.consecutiveSkips = 0
+		return
+	}
+	a.addLogMessage(fmt.Sprintf("Skipping unreadable image %s.", filepath.Base(imagePath)))
+	a.nextImage()
 }
 func (a *App) GetImageFullPath() string {
 	currentList := a.getCurrentList() // Use helper
@@ -367,15 +580,22 @@ func (a *App) GetImageFullPath() string {
 // loadAndDisplayCurrentImage loads the image at the current index in the active list
 // in a background goroutine and updates the UI on the main Fyne thread.
 func (a *App) loadAndDisplayCurrentImage() {
+	a.rememberCurrentView()
 	count := a.getCurrentImageCount()
+	pairPath := a.pendingPairPath // Only set by slideshowAdvance for this one display
+	a.pendingPairPath = ""
+	if pairPath == "" {
+		a.pairedIndex = -1
+	}
 	// Handle empty list (either full or filtered)
 
 	if count == 0 { // Handle empty list (either full or filtered)
 		a.zoomPanArea.SetImage(nil)
 		a.img = Img{EXIFData: make(map[string]string)} // Clear EXIF
-		a.UI.MainWin.SetTitle("FySlide")
+		a.UI.MainWin.SetTitle("FySlide" + a.profileTitle())
 		a.updateStatusBar()
 		a.updateInfoText()
+		a.updateThumbStrip()
 		a.addLogMessage("No images available.")
 		return // Exit the function, no image to load
 	}
@@ -384,8 +604,7 @@ func (a *App) loadAndDisplayCurrentImage() {
 		if count == 1 {
 			a.index = 0
 		} else if count > 1 { // count is already guaranteed > 0 here
-			randomNumber := rand.Intn(count)
-			a.index = randomNumber
+			a.index = a.shuffle.Next(count)
 		}
 	}
 	imagePath := a.GetImageFullPath() // Get the full path of the current image
@@ -401,7 +620,7 @@ func (a *App) loadAndDisplayCurrentImage() {
 			fyne.Do(func() {
 				a.zoomPanArea.SetImage(nil)                    // Clear the image display
 				a.img = Img{EXIFData: make(map[string]string)} // Clear EXIF
-				a.UI.MainWin.SetTitle("FySlide")
+				a.UI.MainWin.SetTitle("FySlide" + a.profileTitle())
 				a.updateStatusBar()
 				a.updateInfoText()
 				a.addLogMessage("No images available after index reset.")
@@ -412,12 +631,27 @@ func (a *App) loadAndDisplayCurrentImage() {
 		imagePath = a.GetImageFullPath()
 	}
 
+	a.updateThumbStrip() // Index is final now; move the strip window before decoding starts
+
 	isHistoryNav := a.isNavigatingHistory // Capture the flag state
+	wantPreview := pairPath == "" && a.prefs().BoolWithFallback(prefLoadPreview, true)
+	wantEnhance, wantBrightness := a.autoEnhance, a.dimBrightness
+	maxEdge := a.maxDecodeDimension()
+	if a.fullResPath == imagePath {
+		maxEdge = 0
+	} else {
+		a.fullResPath = "" // Full resolution was asked for another image only
+	}
+	a.onImageLoadState(imagePath, imageLoadStarted)
 
 	// Launch goroutine for loading and decoding
 	go func(path string, historyNav bool) {
-		file, err := os.Open(path)
+		file, err := a.io.Open(a.ctx, iosched.Foreground, path) // Background reads pause until it's closed
 		if err != nil {
+			if a.locationUnreachable(path) {
+				fyne.Do(func() { a.onLibraryOffline(path) })
+				return
+			}
 			fyne.Do(func() {
 				a.handleImageDisplayError(path, "Loading", err, "")
 			})
@@ -427,6 +661,7 @@ func (a *App) loadAndDisplayCurrentImage() {
 
 		// --- EXIF Parsing ---
 		currentEXIFData := make(map[string]string)
+		var exifData *exif.Exif
 		// Seek to beginning for EXIF parsing
 		_, seekErr := file.Seek(0, 0)
 		if seekErr != nil {
@@ -435,11 +670,12 @@ func (a *App) loadAndDisplayCurrentImage() {
 			})
 			// Continue to image decoding if seek fails, EXIF will be empty
 		} else {
-			exifData, exifErr := exif.Decode(file)
+			var exifErr error
+			exifData, exifErr = exif.Decode(file)
 			if exifErr == nil && exifData != nil {
 				// Extract specific tags you're interested in
 				tagsToExtract := []exif.FieldName{
-					exif.DateTimeOriginal, exif.Make, exif.Model,
+					exif.ImageDescription, exif.DateTimeOriginal, exif.Make, exif.Model,
 					exif.ExposureTime, exif.FNumber, exif.ISOSpeedRatings,
 					exif.PixelXDimension, exif.PixelYDimension,
 				}
@@ -458,34 +694,79 @@ func (a *App) loadAndDisplayCurrentImage() {
 		}
 		// --- End EXIF Parsing ---
 
-		// IMPORTANT: Seek back to the beginning for image decoding
-		_, seekErr = file.Seek(0, 0)
-		if seekErr != nil {
-			fyne.Do(func() {
-				a.handleImageDisplayError(path, "Seeking before Decode", seekErr, "")
-			})
-			return
+		if wantPreview {
+			if preview := a.loadPreview(file.File, exifData); preview != nil {
+				fyne.Do(func() { a.showLoadPreview(path, preview) })
+			}
 		}
 
-		imageDecoded, formatName, err := image.Decode(file)
+		imageDecoded, formatName, err := a.decoder.Decode(a.ctx, iosched.Foreground, path)
 		if err != nil {
+			if a.locationUnreachable(path) { // Disconnected while reading
+				fyne.Do(func() { a.onLibraryOffline(path) })
+				return
+			}
 			fyne.Do(func() {
-				a.handleImageDisplayError(file.Name(), "Decoding", err, formatName)
+				a.handleImageDisplayError(path, "Decoding", err, formatName)
 			})
 			return // Exit goroutine
 		}
 
+		a.orientations.record(path, imageDecoded.Bounds())
+		fullSize := imageDecoded.Bounds().Size()
+		imageDecoded = capDecoded(imageDecoded, maxEdge)
+		displayed := image.Image(imageDecoded)
+		if pairPath != "" {
+			if partner, err := a.loadPairPartner(pairPath); err == nil {
+				a.orientations.record(pairPath, partner.Bounds())
+				displayed = composeSideBySide(imageDecoded, partner, pairGap)
+			} else {
+				fyne.Do(func() {
+					a.addLogMessage(fmt.Sprintf("Could not load paired image %s: %v", filepath.Base(pairPath), err))
+				})
+				pairPath = ""
+			}
+		}
+		shown := displayed
+		displayed = forDisplay(shown, wantEnhance, wantBrightness)
+
 		// Successfully decoded image - perform UI updates on the Fyne thread
 		fyne.Do(func() {
+			if a.loadingPath != path {
+				return // A newer image was asked for meanwhile; keep its predecessor on screen until it is decoded
+			}
+			a.onImageLoadState(path, imageLoadSucceeded)
+			a.consecutiveSkips = 0
+			a.clearLoadFailures(path)
 			a.img.OriginalImage = imageDecoded
-			a.img.Path = file.Name()                    // Update the path in the Img struct
-			a.img.EXIFData = currentEXIFData            // Store parsed EXIF data
-			a.zoomPanArea.SetImage(a.img.OriginalImage) // This will also call Reset and Refresh
+			a.img.FullSize = fullSize
+			a.img.Path = file.Name()         // Update the path in the Img struct
+			a.img.EXIFData = currentEXIFData // Store parsed EXIF data
+			a.img.Shown = shown
+			if wantEnhance != a.autoEnhance || wantBrightness != a.dimBrightness { // Changed while decoding
+				displayed = forDisplay(shown, a.autoEnhance, a.dimBrightness)
+			}
+			a.zoomPanArea.SetImage(displayed) // This will also call Reset and Refresh
+			a.loadAnnotations(a.img.Path, pairPath != "")
+			a.linkLoupe()
+			if pairPath == "" {
+				a.restoreView(a.img.Path, historyNav)
+			}
 
 			// Update Title, Status Bar, and Info Text
-			a.UI.MainWin.SetTitle(fmt.Sprintf("FySlide - %v", a.img.Path))
+			if pairPath != "" {
+				a.UI.MainWin.SetTitle(fmt.Sprintf("FySlide%s - %v", a.profileTitle(), pairTitle(a.img.Path, pairPath)))
+			} else {
+				a.UI.MainWin.SetTitle(fmt.Sprintf("FySlide%s - %v", a.profileTitle(), a.img.Path))
+			}
 			a.updateStatusBar()
 			a.updateInfoText()
+			a.publishImageChanged()
+			a.countImageViewed()
+			a.checkDirDefaults(a.img.Path)
+			a.noteFolderChange(a.img.Path)
+			a.updateBreadcrumbs(a.img.Path)
+			a.slideshowManager.Reset() // The image gets its full time, however it was reached
 
 			// History Update (only if not navigating history)
 			if a.historyManager != nil && !historyNav {
@@ -496,56 +777,149 @@ func (a *App) loadAndDisplayCurrentImage() {
 	}(imagePath, isHistoryNav) // Pass the path and flag to the goroutine
 }
 
-// showFilterDialog displays a dialog to select a tag for filtering.
+// showFilterDialog displays a dialog to filter the image list by tag, date and file properties.
 func (a *App) showFilterDialog() {
-	allTagsWithCounts, err := a.tagDB.GetAllTags() // This now returns []tagging.TagWithCount
+	allTagsWithCounts, err := a.tagDB.GetAllTags(a.ctx) // This now returns []tagging.TagWithCount
 	if err != nil {
 		dialog.ShowError(fmt.Errorf("failed to get tags for filtering: %w", err), a.UI.MainWin)
 		return
 	}
 
-	if len(allTagsWithCounts) == 0 {
-		dialog.ShowInformation("Filter by Tag", "No tags found in the database to filter by.", a.UI.MainWin)
-		return
-	}
-
 	// Extract just the tag names for the dialog options
-	tagNames := make([]string, len(allTagsWithCounts))
-	for i, tagInfo := range allTagsWithCounts {
-		tagNames[i] = tagInfo.Name
+	const anyTagOption = "(Any Tag)"
+	options := []string{anyTagOption}
+	for _, tagInfo := range allTagsWithCounts {
+		options = append(options, tagInfo.Name)
 	}
+	current := a.currentFilter
 
-	// Add option to clear filter
-	options := append([]string{"(Show All / Clear Filter)"}, tagNames...)
-
-	var selectedOption string
-	filterSelector := widget.NewSelect(options, func(selected string) {
-		selectedOption = selected
-	})
-	// Set initial selection based on current filter
-	if a.isFiltered {
-		filterSelector.SetSelected(a.currentFilterTag)
-		selectedOption = a.currentFilterTag
+	tagSelector := widget.NewSelect(options, nil)
+	if len(current.Tags) == 1 {
+		tagSelector.SetSelected(current.Tags[0])
 	} else {
-		filterSelector.SetSelected(options[0]) // Default to "Show All"
-		selectedOption = options[0]
+		tagSelector.SetSelected(anyTagOption)
 	}
 
-	dialog.ShowForm("Filter by Tag", "Apply", "Cancel", []*widget.FormItem{
-		widget.NewFormItem("Select Tag", filterSelector),
+	fromEntry := newOptionalEntry(formatOptionalDate(current.From), "YYYY-MM-DD", validateOptionalDate)
+	toEntry := newOptionalEntry(formatOptionalDate(current.To), "YYYY-MM-DD", validateOptionalDate)
+	minWidthEntry := newOptionalEntry(formatOptionalInt(int64(current.MinWidth)), "pixels", validateOptionalInt)
+	minHeightEntry := newOptionalEntry(formatOptionalInt(int64(current.MinHeight)), "pixels", validateOptionalInt)
+	minSizeEntry := newOptionalEntry(formatOptionalInt(current.MinSize/1024), "KB", validateOptionalInt)
+	maxSizeEntry := newOptionalEntry(formatOptionalInt(current.MaxSize/1024), "KB", validateOptionalInt)
+	cameraEntry := newOptionalEntry(current.Camera, "model or make, e.g. Pixel", nil)
+	textEntry := newOptionalEntry(current.Text, "text read by OCR, e.g. invoice", nil)
+
+	orientationOptions := make([]string, len(query.Orientations))
+	for i, o := range query.Orientations {
+		orientationOptions[i] = string(o)
+	}
+	orientationSelector := widget.NewSelect(orientationOptions, nil)
+	if current.Orientation != "" {
+		orientationSelector.SetSelected(string(current.Orientation))
+	} else {
+		orientationSelector.SetSelected(string(query.OrientationAny))
+	}
+
+	dialog.ShowForm("Filter Images", "Apply", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Tag", tagSelector),
+		widget.NewFormItem("Date from", fromEntry),
+		widget.NewFormItem("Date to", toEntry),
+		widget.NewFormItem("Camera", cameraEntry),
+		widget.NewFormItem("Text contains", textEntry),
+		widget.NewFormItem("Min width", minWidthEntry),
+		widget.NewFormItem("Min height", minHeightEntry),
+		widget.NewFormItem("Orientation", orientationSelector),
+		widget.NewFormItem("Min file size", minSizeEntry),
+		widget.NewFormItem("Max file size", maxSizeEntry),
 	}, func(confirm bool) {
 		if !confirm {
 			return
 		}
 
-		if selectedOption == options[0] { // "(Show All / Clear Filter)"
+		// Entries were validated by the form, so parse errors can't occur here.
+		var c query.Criteria
+		if tagSelector.Selected != anyTagOption {
+			c.Tags = []string{tagSelector.Selected}
+		}
+		c.From, _ = parseOptionalDate(fromEntry.Text)
+		if to, _ := parseOptionalDate(toEntry.Text); !to.IsZero() {
+			c.To = to.Add(24*time.Hour - time.Nanosecond) // Include the whole end day
+		}
+		c.Camera = strings.TrimSpace(cameraEntry.Text)
+		c.Text = strings.TrimSpace(textEntry.Text)
+		minWidth, _ := parseOptionalInt(minWidthEntry.Text)
+		minHeight, _ := parseOptionalInt(minHeightEntry.Text)
+		c.MinWidth, c.MinHeight = int(minWidth), int(minHeight)
+		c.Orientation = query.Orientation(orientationSelector.Selected)
+		minKB, _ := parseOptionalInt(minSizeEntry.Text)
+		maxKB, _ := parseOptionalInt(maxSizeEntry.Text)
+		c.MinSize, c.MaxSize = minKB*1024, maxKB*1024
+
+		if c.IsEmpty() {
 			a.clearFilter()
 		} else {
-			a.applyFilter(selectedOption)
+			a.applyCriteria(c)
 		}
 	}, a.UI.MainWin)
 }
 
+// newOptionalEntry creates a form entry that may be left blank.
+func newOptionalEntry(text, placeholder string, validator func(string) error) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(text)
+	entry.SetPlaceHolder(placeholder)
+	entry.Validator = validator
+	return entry
+}
+
+// parseOptionalDate parses a YYYY-MM-DD date in local time; blank yields the zero time.
+func parseOptionalDate(text string) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", text, time.Local)
+}
+
+func validateOptionalDate(text string) error {
+	if _, err := parseOptionalDate(text); err != nil {
+		return fmt.Errorf("use the form YYYY-MM-DD")
+	}
+	return nil
+}
+
+func formatOptionalDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// parseOptionalInt parses a non-negative integer; blank yields 0.
+func parseOptionalInt(text string) (int64, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("must be a non-negative whole number")
+	}
+	return n, nil
+}
+
+func validateOptionalInt(text string) error {
+	_, err := parseOptionalInt(text)
+	return err
+}
+
+func formatOptionalInt(n int64) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(n, 10)
+}
+
 // handleShowFullSizeBtn is called when the "Show Full Size" toolbar action is triggered.
 func (a *App) handleShowFullSizeBtn() {
 	if a.zoomPanArea != nil {
@@ -556,28 +930,66 @@ func (a *App) handleShowFullSizeBtn() {
 	}
 }
 
-// updateShowFullSizeButtonVisibility enables or disables the "Show Full Size" toolbar action
-// based on the current image's zoom state and original size relative to the view.
+// handleZoomToFitBtn is called when the "Fit" toolbar action is triggered.
+func (a *App) handleZoomToFitBtn() {
+	if a.zoomPanArea != nil {
+		a.zoomPanArea.Reset() // Reset fits the image to the view and triggers onZoomPanChange
+	}
+}
+
+// toggleFullSizeFit switches between 100% and fit-to-view.
+func (a *App) toggleFullSizeFit() {
+	if a.zoomPanArea == nil {
+		return
+	}
+	if a.zoomPanArea.IsFullSize() {
+		a.handleZoomToFitBtn()
+	} else {
+		a.handleShowFullSizeBtn()
+	}
+}
+
+// onZoomPanChanged is the ZoomPanArea callback; it keeps zoom-dependent UI in sync.
+func (a *App) onZoomPanChanged() {
+	a.updateShowFullSizeButtonVisibility()
+	a.updateZoomIndicator()
+}
+
+// updateZoomIndicator shows the current zoom level in the status bar.
+func (a *App) updateZoomIndicator() {
+	if a.UI.statusZoomLabel == nil || a.zoomPanArea == nil {
+		return
+	}
+	a.UI.statusZoomLabel.SetText(a.zoomPanArea.ZoomLabel())
+}
+
+// updateShowFullSizeButtonVisibility enables or disables the "Show Full Size" and "Fit"
+// toolbar actions based on the current image's zoom state.
 func (a *App) updateShowFullSizeButtonVisibility() {
 	if a.UI.showFullSizeAction == nil || a.zoomPanArea == nil || a.zoomPanArea.originalImg == nil {
 		if a.UI.showFullSizeAction != nil {
 			a.UI.showFullSizeAction.Disable()
-			if a.UI.toolBar != nil {
-				a.UI.toolBar.Refresh()
-			}
+		}
+		if a.UI.zoomFitAction != nil {
+			a.UI.zoomFitAction.Disable()
+		}
+		if a.UI.toolBar != nil {
+			a.UI.toolBar.Refresh()
 		}
 		return
 	}
 
-	currentZoom := a.zoomPanArea.CurrentZoom()
-	epsilon := float32(0.001) // Tolerance for float comparison
-
-	shouldBeEnabled := (currentZoom < (1.0 - epsilon)) || (currentZoom > (1.0 + epsilon))
-
-	if shouldBeEnabled {
-		a.UI.showFullSizeAction.Enable()
-	} else {
+	if a.zoomPanArea.IsFullSize() {
 		a.UI.showFullSizeAction.Disable()
+	} else {
+		a.UI.showFullSizeAction.Enable()
+	}
+	if a.UI.zoomFitAction != nil {
+		if a.zoomPanArea.IsFitted() {
+			a.UI.zoomFitAction.Disable()
+		} else {
+			a.UI.zoomFitAction.Enable()
+		}
 	}
 	if a.UI.toolBar != nil {
 		a.UI.toolBar.Refresh()
@@ -586,51 +998,139 @@ func (a *App) updateShowFullSizeButtonVisibility() {
 
 // applyFilter filters the image list based on the selected tag.
 func (a *App) applyFilter(tag string) {
-	a.addLogMessage(fmt.Sprintf("Applying filter for tag: %s", tag))
-	tagImagesPaths, err := a.tagDB.GetImages(tag)
-	if err != nil {
-		dialog.ShowError(fmt.Errorf("failed to get images for tag '%s': %w", tag, err), a.UI.MainWin)
-		a.clearFilter() // Revert if error occurs
-		return
-	}
+	a.applyCriteria(query.Criteria{Tags: []string{tag}})
+}
 
-	if len(tagImagesPaths) == 0 {
-		dialog.ShowInformation("Filter Results", fmt.Sprintf("No images found with the tag '%s'.", tag), a.UI.MainWin)
-		a.addLogMessage(fmt.Sprintf("No images found with tag '%s'.", tag))
-		// Decide whether to clear filter or keep showing nothing - clearing is probably better UX
-		a.clearFilter()
-		return
+// applyCriteria filters the image list by tag and file properties. Date and
+// dimension predicates need to read every candidate file, so they are evaluated
+// off the UI goroutine behind a progress dialog.
+func (a *App) applyCriteria(c query.Criteria) {
+	a.addLogMessage(fmt.Sprintf("Applying filter: %s", c))
+
+	candidates := a.images
+	if len(c.Tags) > 0 {
+		// Count how many of the requested tags each image carries; only images
+		// carrying all of them pass (AND semantics).
+		tagHits := make(map[string]int)
+		for _, tag := range c.Tags {
+			tagImagesPaths, err := a.tagDB.GetImages(a.ctx, tag)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("failed to get images for tag '%s': %w", tag, err), a.UI.MainWin)
+				a.clearFilter() // Revert if error occurs
+				return
+			}
+
+			if len(tagImagesPaths) == 0 {
+				dialog.ShowInformation("Filter Results", fmt.Sprintf("No images found with the tag '%s'.", tag), a.UI.MainWin)
+				a.addLogMessage(fmt.Sprintf("No images found with tag '%s'.", tag))
+				// Decide whether to clear filter or keep showing nothing - clearing is probably better UX
+				a.clearFilter()
+				return
+			}
+			for _, path := range tagImagesPaths {
+				tagHits[path]++
+			}
+		}
+
+		// Iterate through the original full list to maintain FileItem structure
+		var tagged scan.FileItems
+		for _, item := range a.images {
+			if tagHits[item.Path] == len(c.Tags) {
+				tagged = append(tagged, item)
+			}
+		}
+		candidates = tagged
+	}
+	if c.Folder != "" {
+		var inFolder scan.FileItems
+		for _, item := range candidates {
+			if c.InFolder(item.Path) {
+				inFolder = append(inFolder, item)
+			}
+		}
+		candidates = inFolder
+	}
+	if c.Text != "" {
+		withText, err := a.filterByText(candidates, c)
+		if err != nil {
+			dialog.ShowError(err, a.UI.MainWin)
+			a.clearFilter()
+			return
+		}
+		candidates = withText
 	}
 
-	// Build the filtered list
-	var newFilteredImages scan.FileItems
-	// Create a map for quick path lookup
-	pathMap := make(map[string]bool)
-	for _, path := range tagImagesPaths {
-		pathMap[path] = true
+	if !c.HasPropertyFilters() {
+		a.setFilteredImages(c, candidates)
+		return
 	}
 
-	// Iterate through the original full list to maintain FileItem structure
-	for _, item := range a.images {
-		if _, found := pathMap[item.Path]; found {
-			newFilteredImages = append(newFilteredImages, item)
+	index := a.imageInfo // Indexed images needn't be read
+	progress := dialog.NewCustomWithoutButtons("Filtering Images", widget.NewProgressBarInfinite(), a.UI.MainWin)
+	progress.Show()
+	a.goBackground(func() {
+		var matched scan.FileItems
+		unreadable := 0
+		for _, item := range candidates {
+			if a.ctx.Err() != nil {
+				return // The window closed while filtering
+			}
+			props, ok := indexedProperties(index, c, item)
+			if !ok {
+				var err error
+				if props, err = c.LoadProperties(item.Path, item.Info); err != nil {
+					unreadable++
+					continue
+				}
+			}
+			if c.Matches(props) {
+				matched = append(matched, item)
+			}
 		}
-	}
+		if c.SimilarColorsTo != "" {
+			var skipped int
+			matched, skipped = a.rankBySignature(c.SimilarColorsTo, matched, imagesig.RankByColor)
+			unreadable += skipped
+		}
+		if c.SimilarTo != "" {
+			var skipped int
+			matched, skipped = a.rankBySignature(c.SimilarTo, matched, imagesig.RankByLook)
+			unreadable += skipped
+		}
+		if a.ctx.Err() != nil {
+			return
+		}
+		fyne.Do(func() {
+			progress.Hide()
+			if unreadable > 0 {
+				a.addLogMessage(fmt.Sprintf("Filter skipped %d unreadable images.", unreadable))
+			}
+			a.setFilteredImages(c, matched)
+		})
+	})
+}
 
-	if len(newFilteredImages) == 0 {
+// setFilteredImages makes list the active filtered view for c, or clears the
+// filter if nothing matched.
+func (a *App) setFilteredImages(c query.Criteria, list scan.FileItems) {
+	if len(list) == 0 {
 		// This might happen if tagged images were deleted/moved from the original scan
-		dialog.ShowInformation("Filter Results", fmt.Sprintf("No currently loaded images match the tag '%s'.", tag), a.UI.MainWin)
-		a.addLogMessage(fmt.Sprintf("No loaded images match tag '%s'.", tag))
+		dialog.ShowInformation("Filter Results", fmt.Sprintf("No currently loaded images match the filter '%s'.", c), a.UI.MainWin)
+		a.addLogMessage(fmt.Sprintf("No loaded images match filter '%s'.", c))
 		a.clearFilter()
 		return
 	}
 
-	a.filteredImages = newFilteredImages
+	a.rememberFilterPosition()
+	a.filteredImages = list
 	a.isFiltered = true
-	a.currentFilterTag = tag
-	a.index = 0     // Reset index to the start of the filtered list
-	a.direction = 1 // Default direction
-	a.addLogMessage(fmt.Sprintf("Filter active: %d images with tag '%s'.", len(a.filteredImages), tag))
+	a.currentFilter = c
+	a.filterCounts = a.countFilterTags(c)
+	a.index = a.takeStartIndex() // Resume the filtered list where it was left, or at a bookmark's position
+	a.direction = 1              // Default direction
+	a.addLogMessage(fmt.Sprintf("Filter active: %d images matching '%s'.", len(a.filteredImages), c))
+	a.syncQuickFilter()
+	a.publishFilterChanged()
 
 	a.isNavigatingHistory = false  // Applying a filter is a new view, not history navigation
 	a.loadAndDisplayCurrentImage() // Display the first image in the filtered set
@@ -638,17 +1138,21 @@ func (a *App) applyFilter(tag string) {
 	a.updateStatusBar()
 }
 
-// clearFilter removes any active tag filter.
+// clearFilter removes any active filter.
 func (a *App) clearFilter() {
 	if !a.isFiltered {
 		return // Nothing to clear
 	}
 	a.addLogMessage("Filter cleared. Showing all images.")
+	a.rememberFilterPosition()
 	a.isFiltered = false
-	a.currentFilterTag = ""
-	a.filteredImages = nil // Clear the filtered list
-	a.index = 0            // Reset index to the start of the full list
+	a.currentFilter = query.Criteria{}
+	a.filterCounts = nil
+	a.syncQuickFilter()
+	a.filteredImages = nil       // Clear the filtered list
+	a.index = a.takeStartIndex() // Resume the full list where it was left, or at a bookmark's position
 	a.direction = 1
+	a.publishFilterChanged()
 
 	a.isNavigatingHistory = false  // Clearing a filter is a new view state
 	a.loadAndDisplayCurrentImage() // Display the first image in the full set
@@ -683,21 +1187,23 @@ func (a *App) nextImage() {
 		return
 	} // Add check
 	// --- History Navigation Logic ---
-	if a.isNavigatingHistory {
-		// If currently navigating history (came here from a 'back' action),
-		// try to go forward in history first.
+	if a.replayingHistory() {
+		// In random mode after going back, replay the images shown before
+		// going back before picking new ones.
 		if a.ShowNextImageFromHistory() {
 			return // Successfully moved forward in history
 		}
-		// If ShowNextImageFromHistory returned false, it means we are at the end
-		// of the history stack. Fall through to standard next image logic.
+		// If ShowNextImageFromHistory returned false, no image further on in
+		// history is still available. Fall through to standard next image logic.
 	}
 	// --- Standard Next Image Logic ---
 	a.isNavigatingHistory = false // Ensure this is false for standard navigation
 
-	// Calculate next index based on direction (original logic)
-	a.index += a.direction              // This might go out of bounds
-	a.index = (a.index + count) % count // Wrap around using modulo
+	// Calculate next index based on direction and the playback mode
+	if !a.stepPlayback() {
+		a.onPlaybackEnd()
+		return
+	}
 
 	a.loadAndDisplayCurrentImage() // Display the image at the calculated index
 
@@ -726,189 +1232,214 @@ func (a *App) skipImages(offset int) {
 // ShowNextImageFromHistory attempts to move forward in the history stack.
 // Returns true if successful, false otherwise (e.g., at end of history or history disabled).
 func (a *App) ShowNextImageFromHistory() bool {
-	imagePathFromHistory, ok := a.historyManager.NavigateForward()
+	imagePathFromHistory, ok := a.historyManager.NavigateForwardValid(a.inLibrary)
 	if !ok {
 		return false
 	}
-
-	a.isNavigatingHistory = true // Signal DisplayImage not to add to history stack for this action
-
-	// Check if the historical image is in the current filtered list if filtering is active.
-	// Unlike 'back', for 'forward' it might be better to just skip if it's not in the filter,
-	// or clear the filter. Clearing the filter seems more consistent with the 'back' behavior.
-	mustClearFilter := false
-	if a.isFiltered {
-		foundInFilter := false
-		for _, item := range a.filteredImages {
-			if item.Path == imagePathFromHistory {
-				foundInFilter = true
-				break
-			}
-		}
-		if !foundInFilter {
-			mustClearFilter = true
-		}
-	}
-
-	if mustClearFilter {
-		a.addLogMessage(fmt.Sprintf("Image %s from history not in current filter. Clearing filter state for forward navigation.", filepath.Base(imagePathFromHistory)))
-		// Directly modify filter state without calling a.clearFilter() to avoid its DisplayImage call
-		a.isFiltered = false
-		a.currentFilterTag = ""
-		a.filteredImages = nil
-		// The info text will be updated by the DisplayImage call later.
-	}
-
-	// Find the index of the historical image in the *current* active list (full or filtered)
-	activeList := a.getCurrentList()
-	foundIndexInActiveList := -1
-	for i, item := range activeList {
-		if item.Path == imagePathFromHistory {
-			foundIndexInActiveList = i
-			break
-		}
-	}
-
-	if foundIndexInActiveList == -1 {
-		a.addLogMessage(fmt.Sprintf("Error: Image from history (%s) not found in current active list during forward navigation. Removing from history.", filepath.Base(imagePathFromHistory)))
-		// Image might have been deleted or is otherwise inaccessible.
-		a.historyManager.RemovePath(imagePathFromHistory) // Remove problematic path
-		dialog.ShowInformation("History Navigation", "A previously viewed image is no longer available and was removed from history.", a.UI.MainWin)
-		a.isNavigatingHistory = false // Reset flag as this specific navigation failed
-		return false                  // Failed to show the historical image
-	}
-
-	a.index = foundIndexInActiveList
-
-	a.loadAndDisplayCurrentImage() // loadAndDisplayCurrentImage will respect a.isNavigatingHistory
-	// Error handling is now internal to loadAndDisplayCurrentImage
-	a.isNavigatingHistory = false // Reset flag after the operation is complete
-	return true                   // Successfully displayed historical image
+	a.showHistoryPath(imagePathFromHistory)
+	return true
 }
 
-// ShowPreviousImage handles the "back" button logic using history.
+// ShowPreviousImage handles the "back" button logic using history. Random
+// mode stays on: going back and then forward again replays the images shown
+// before, in order, and only then continues with new random picks.
 func (a *App) ShowPreviousImage() {
-
-	// --- Turn off random mode if active ---
-	if a.random {
-		a.toggleRandom() // This function handles icon update and state change
-	}
-
 	// --- Pause slideshow if it's playing (user is navigating back) ---
 	if !a.slideshowManager.IsPaused() {
 		a.togglePlay() // This effectively pauses it via user action
 	}
 
-	imagePathFromHistory, ok := a.historyManager.NavigateBack()
+	imagePathFromHistory, ok := a.historyManager.NavigateBackValid(a.inLibrary)
 	if !ok {
 		a.addLogMessage("No previous image in history.")
 		return
 	}
-	a.isNavigatingHistory = true // Signal DisplayImage not to add to history stack for this action
+	a.showHistoryPath(imagePathFromHistory)
+}
 
-	mustClearFilter := false
-	if a.isFiltered {
-		foundInFilter := false
-		for _, item := range a.filteredImages {
-			if item.Path == imagePathFromHistory {
-				foundInFilter = true
-				break
-			}
-		}
-		if !foundInFilter {
-			mustClearFilter = true
-		}
-	}
+// inLibrary reports whether path is one of the images that can be shown,
+// filtered out or not. Images deleted, moved away or stacked since they were
+// shown are skipped in history.
+func (a *App) inLibrary(path string) bool {
+	return slices.ContainsFunc(a.stacks.Collapse(a.images, a.expandedStacks), func(item scan.FileItem) bool { return item.Path == path })
+}
+
+// replayingHistory reports whether the next image in random mode comes from
+// history, because the user went back and has not reached the latest image again.
+func (a *App) replayingHistory() bool {
+	return a.random && a.historyManager != nil && a.historyManager.CanGoForward()
+}
 
-	if mustClearFilter {
-		a.addLogMessage(fmt.Sprintf("Image %s from history not in current filter. Clearing filter state.", filepath.Base(imagePathFromHistory)))
+// showHistoryPath displays path, reached by moving through history, without
+// recording it again. The filter is cleared if it hides path, since history
+// spans filter changes.
+func (a *App) showHistoryPath(path string) {
+	if a.isFiltered && !slices.ContainsFunc(a.filteredImages, func(item scan.FileItem) bool { return item.Path == path }) {
+		a.addLogMessage(fmt.Sprintf("Image %s from history not in current filter. Clearing filter state.", filepath.Base(path)))
+		a.rememberFilterPosition()
 		// Directly modify filter state without calling a.clearFilter() to avoid its DisplayImage call
 		a.isFiltered = false
-		a.currentFilterTag = ""
+		a.currentFilter = query.Criteria{}
 		a.filteredImages = nil
 		// The info text will be updated by the DisplayImage call later.
 	}
-
-	activeList := a.getCurrentList() // Get the list that's now active (could be a.images if filter was cleared)
-	foundIndexInActiveList := -1
-	for i, item := range activeList {
-		if item.Path == imagePathFromHistory {
-			foundIndexInActiveList = i
-			break
-		}
-	}
-
-	if foundIndexInActiveList == -1 {
-		a.addLogMessage(fmt.Sprintf("Error: Image from history (%s) not found in current active list. Removing from history.", filepath.Base(imagePathFromHistory)))
-		a.historyManager.RemovePath(imagePathFromHistory) // Remove problematic path
-		dialog.ShowInformation("History Navigation", "A previously viewed image is no longer available and was removed from history.", a.UI.MainWin)
-		a.isNavigatingHistory = false // Reset flag as this specific navigation failed
+	index := slices.IndexFunc(a.getCurrentList(), func(item scan.FileItem) bool { return item.Path == path })
+	if index < 0 {
+		a.addLogMessage(fmt.Sprintf("Image %s from history can no longer be shown.", filepath.Base(path)))
 		return
 	}
+	a.index = index
 
-	a.index = foundIndexInActiveList
-
+	a.isNavigatingHistory = true   // Signal DisplayImage not to add to history stack for this action
 	a.loadAndDisplayCurrentImage() // loadAndDisplayCurrentImage will respect a.isNavigatingHistory
-	// Error handling is now internal to loadAndDisplayCurrentImage
-	a.isNavigatingHistory = false // Reset flag after the operation is complete
+	a.isNavigatingHistory = false  // Reset flag after the operation is complete
 }
 
 // Delete file
 
+// deleteFileCheck asks before deleting the current image or its stack, and
+// for an override if one of them carries a protected tag.
 func (a *App) deleteFileCheck() {
-	dialog.ShowConfirm("Delete file!", "Are you sure?\n This action can't be undone.", func(b bool) {
+	if a.refuseInReadOnly("Delete Image") {
+		return
+	}
+	stackCheck := a.stackTargetCheck("Delete all %d images of this stack")
+	_, members := a.currentStack()
+	protection, err := a.service.Protection(a.ctx, append([]string{a.img.Path}, members...))
+	if err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+		return
+	}
+	var overrideCheck *widget.Check
+	if len(protection) > 0 {
+		overrideCheck = widget.NewCheck("Delete protected images anyway", nil)
+	}
+	if stackCheck != nil || overrideCheck != nil {
+		content := container.NewVBox(widget.NewLabel("Are you sure?\n " + a.deleteConsequence()))
+		if stackCheck != nil {
+			content.Add(stackCheck)
+		}
+		if overrideCheck != nil {
+			content.Add(widget.NewLabel(protectionSummary(protection)))
+			content.Add(overrideCheck)
+		}
+		dialog.ShowCustomConfirm("Delete file!", "Delete", "Cancel", content, func(b bool) {
+			force := overrideCheck != nil && overrideCheck.Checked
+			if b && stackCheck != nil && stackCheck.Checked {
+				a.deleteFiles(members, force)
+			} else if b {
+				a.deleteFile(force)
+			}
+		}, a.UI.MainWin)
+		return
+	}
+	dialog.ShowConfirm("Delete file!", "Are you sure?\n "+a.deleteConsequence(), func(b bool) {
 		if b {
-			a.deleteFile()
+			a.deleteFile(false)
 		}
 	}, a.UI.MainWin)
 }
 
-func (a *App) deleteFile() {
-	deletedPath := a.img.Path
-	if deletedPath == "" {
+// protectionSummary lists the protected images of protection with their
+// protected tags, for a delete confirmation.
+func protectionSummary(protection map[string][]string) string {
+	paths := slices.Sorted(maps.Keys(protection))
+	lines := []string{"Protected from deletion:"}
+	for i, path := range paths {
+		if i == maxProtectedListed {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(paths)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s)", filepath.Base(path), strings.Join(protection[path], ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (a *App) deleteFile(force bool) {
+	if a.img.Path == "" {
 		return
 	} // No image loaded
+	a.deleteFiles([]string{a.img.Path}, force)
+}
 
-	// 1. Remove from OS
-	if err := os.Remove(deletedPath); err != nil {
-		dialog.ShowError(err, a.UI.MainWin)
-		return
+// deleteFiles deletes the given images, e.g. the current one or its whole
+// stack, and shows the image that takes the current one's place. Images
+// carrying a protected tag are kept unless force is set.
+func (a *App) deleteFiles(paths []string, force bool) {
+	// 1. Remove from OS, then the tags associated with each file from DB
+	deleted := make(map[string]bool, len(paths))
+	var kept []string
+	for _, deletedPath := range paths {
+		remove := a.service.DeleteImage
+		if force {
+			remove = a.service.ForceDeleteImage
+		}
+		if err := remove(a.ctx, deletedPath); err != nil {
+			if errors.Is(err, service.ErrProtected) {
+				a.addLogMessage(fmt.Sprintf("Kept %v", err))
+				kept = append(kept, filepath.Base(deletedPath))
+				continue
+			}
+			if !errors.Is(err, service.ErrTagCleanup) {
+				dialog.ShowError(err, a.UI.MainWin)
+				continue
+			}
+			a.addLogMessage(fmt.Sprintf("Warn: %v", err))
+		}
+		a.addLogMessage(fmt.Sprintf("Deleted file: %s", deletedPath))
+		deleted[deletedPath] = true
 	}
-	a.addLogMessage(fmt.Sprintf("Deleted file: %s", deletedPath))
+	if len(kept) > 0 {
+		dialog.ShowInformation("Delete file!", fmt.Sprintf("Kept %d protected image(s): %s", len(kept), strings.Join(kept, ", ")), a.UI.MainWin)
+	}
+	a.removeDeleted(deleted)
+}
 
-	// 2. Remove tags associated with this file from DB
-	err := a.tagDB.RemoveAllTagsForImage(deletedPath)
-	if err != nil {
-		a.addLogMessage(fmt.Sprintf("Warn: Failed to remove tags for deleted file %s: %v", deletedPath, err))
+// removeDeleted drops the deleted images from the history, the search index,
+// the stacks and the image lists, and shows the image that takes the current
+// one's place.
+func (a *App) removeDeleted(deleted map[string]bool) {
+	if len(deleted) == 0 {
+		return
+	}
+	// 2. Remove from historyStack, the search index and its stack
+	for deletedPath := range deleted {
+		if a.historyManager != nil {
+			a.historyManager.RemovePath(deletedPath)
+		}
+		if a.searchIndex != nil {
+			a.searchIndex.Remove(deletedPath)
+		}
+		if a.stacks != nil {
+			a.stacks.Remove(deletedPath)
+		}
 	}
 
 	// 3. Remove from the main image list (a.images)
-	originalIndex := -1
+	removed := 0
 	newImages := a.images[:0]
-	for i, item := range a.images {
-		if item.Path == deletedPath {
-			originalIndex = i // Keep track of original index if needed
+	for _, item := range a.images {
+		if deleted[item.Path] {
+			removed++
 		} else {
 			newImages = append(newImages, item)
 		}
 	}
-	if originalIndex != -1 {
-		a.addLogMessage(fmt.Sprintf("Removed %s from image list.", filepath.Base(deletedPath)))
+	if removed == len(deleted) {
+		a.addLogMessage(fmt.Sprintf("Removed %d image(s) from image list.", removed))
 	} else {
-		a.addLogMessage(fmt.Sprintf("Warning: Image %s not found in main list during deletion.", deletedPath))
+		a.addLogMessage(fmt.Sprintf("Warning: %d deleted image(s) not found in main list.", len(deleted)-removed))
 	}
 	a.images = newImages
 
-	// 3.5. Remove from historyStack
-	if a.historyManager != nil {
-		a.historyManager.RemovePath(deletedPath)
-	}
-
 	// 4. Remove from the filtered list (a.filteredImages) if filtering is active
 	if a.isFiltered {
+		if a.filterCounts != nil {
+			a.filterCounts.remove(deleted)
+		}
 		newFiltered := a.filteredImages[:0]
 		for _, item := range a.filteredImages {
-			if item.Path != deletedPath {
+			if !deleted[item.Path] {
 				newFiltered = append(newFiltered, item)
 			}
 		}
@@ -948,8 +1479,10 @@ func (a *App) deleteFile() {
 // 	return fileURI, nil
 // }
 
-func (a *App) loadImages(root string) {
+func (a *App) loadImages(roots ...string) {
 	a.images = nil // Clear previous images or a.images = a.images[:0]
+	a.privateImages = nil
+	a.quarantinedImages = nil
 
 	// Define a logger function that matches scan.LoggerFunc
 	// and uses the app's logUIManager.
@@ -958,16 +1491,58 @@ func (a *App) loadImages(root string) {
 		// and a.addLogMessage directly updates UI. a.addLogMessage itself uses logUIManager.
 		fyne.Do(func() { a.addLogMessage(message) })
 	}
-	imageChan := scan.Run(root, scanLogger) // Pass the logger
-	for item := range imageChan {           // Loop until the channel is closed
-		a.images = append(a.images, item)
-		// Optionally, you could update a progress indicator here
-		// if the GUI needs to show loading progress.
+	// The scan cache lets unchanged directories be served without re-listing them.
+	// Without it (e.g. another instance holds the lock) fall back to a full walk.
+	cache, err := scan.OpenCache("")
+	if err != nil {
+		scanLogger(fmt.Sprintf("Scan cache unavailable, doing a full scan: %v", err))
+	} else {
+		defer cache.Close()
+	}
+
+	hidden := a.lockedPrivatePaths() // Sorted out while scanning so they never show up
+	quarantined := a.quarantinedPaths()
+	for _, root := range roots {
+		imageChan := scan.RunCachedContext(a.ctx, root, cache, *fullRescanFlag, scanLogger)
+		for item := range imageChan { // Loop until the channel is closed
+			if a.config.Excluded(item.Path) {
+				continue
+			}
+			if hidden[item.Path] {
+				a.privateImages = append(a.privateImages, item)
+				continue
+			}
+			if quarantined[item.Path] {
+				a.quarantinedImages = append(a.quarantinedImages, item)
+				continue
+			}
+			a.images = append(a.images, item)
+			// Optionally, you could update a progress indicator here
+			// if the GUI needs to show loading progress.
+		}
+	}
+	if a.ctx.Err() != nil {
+		return // The window closed while scanning
+	}
+	a.scanDone.Store(true)
+	msg := fmt.Sprintf("Loaded %d images from %s", len(a.images), strings.Join(roots, ", "))
+	if len(a.privateImages) > 0 {
+		msg += fmt.Sprintf(" (%d private images hidden)", len(a.privateImages))
+	}
+	if len(a.quarantinedImages) > 0 {
+		msg += fmt.Sprintf(" (%d problem files skipped, see View > Problem Files)", len(a.quarantinedImages))
 	}
-	msg := fmt.Sprintf("Loaded %d images from %s", len(a.images), root)
 	fyne.Do(func() {
 		a.addLogMessage(msg)
 	})
+	a.autoApplyDirDefaults(hidden)
+	a.autoApplyEXIFTags(hidden)
+	a.autoApplyColorTags(hidden)
+	a.loadStacks()
+	a.loadImageInfo()
+	a.loadQualityWarnings()
+	a.followSchedule() // Once all images are there to filter
+	a.followNight()
 }
 
 func (a *App) imageCount() int {
@@ -986,7 +1561,33 @@ func (a *App) init(historyCap int, slideshowIntervalSec float64, skipNum int) {
 		fyne.Do(func() { a.addLogMessage(fmt.Sprintf("Slideshow: %s", message)) })
 	}
 
+	if seconds := a.prefs().Float(prefSlideshowInterval); seconds > 0 && !flagGiven("slideshow-interval") {
+		slideshowIntervalSec = seconds
+	}
+	if n := a.prefs().Int(prefSkipCount); n > 0 && !flagGiven("skip-count") {
+		skipNum = n
+	}
 	a.skipCount = skipNum
+	thumbLogger := func(message string) {
+		fyne.Do(func() { a.addLogMessage(message) })
+	}
+	a.startTagWorker()
+	a.orientations = newOrientationCache()
+	a.viewMemory = newViewMemory()
+	a.loadSavedViews()
+	a.initShuffle()
+	a.loadFilterPositions()
+	a.pairedIndex = -1
+	a.dirDefaultsDismissed = make(map[string]bool)
+	a.expandedStacks = make(map[string]bool)
+	a.io = iosched.New(int64(a.backgroundIOLimit()) << 20)
+	a.thumbnailManager = NewThumbnailManager(DefaultThumbnailCacheSize, DefaultThumbnailSize, thumbLogger)
+	a.thumbnailManager.SetContext(a.ctx)
+	a.decoder = decode.NewPool(0, a.io)
+	a.thumbnailManager.SetDecoder(a.decoder)
+	if thumbDir, err := paths.ThumbnailDir(); err == nil {
+		a.thumbnailManager.SetDiskCache(thumbDir)
+	}
 	a.slideshowManager = slideshow.NewSlideshowManager(time.Duration(slideshowIntervalSec*1000)*time.Millisecond, slideshowLogger) //nolint:durationcheck
 	a.isNavigatingHistory = false
 	a.maxLogMessages = DefaultMaxLogMessages
@@ -1035,37 +1636,45 @@ func (a *App) toggleRandom() {
 	if a.UI.toolBar != nil {
 		a.UI.toolBar.Refresh()
 	}
+	a.updateStatusBar()
 }
 
 // Command-line flags
 var historySizeFlag = flag.Int("history-size", 10, "Number of last viewed images to remember (0 to disable). Min: 0.")
 var slideshowIntervalFlag = flag.Float64("slideshow-interval", 2.0, "Slideshow image display interval in seconds. Min: 0.1.")
 var skipCountFlag = flag.Int("skip-count", 20, "Number of images to skip with PageUp/PageDown. Min: 1.")
+var fullRescanFlag = flag.Bool("full-rescan", false, "Ignore the scan cache and re-read every directory.")
+var kioskFlag = flag.Bool("kiosk", false, "Run as a photo frame: fullscreen, switching the filter by the slideshow schedule of the config file.")
+var readOnlyFlag = flag.Bool("read-only", false, "Browse without allowing any change to images or tags.")
+var eventsFlag = flag.String("events", "", "Broadcast playback and tag events as JSON to WebSocket clients on this URL, e.g. ws://:8090.")
+var mqttBrokerFlag = flag.String("mqtt-broker", "", "MQTT broker to publish status to and take commands from, e.g. tcp://broker:1883.")
+var mqttTopicFlag = flag.String("mqtt-topic", "", "MQTT topic prefix of this frame. If empty, uses fyslide/<hostname>.")
+var mqttGroupFlag = flag.String("mqtt-group", "", "MQTT topic prefix whose commands this frame also follows, for a whole fleet.")
+var configFlag = flag.String("config", "", "Config file to read. If empty, uses $FYSLIDE_CONFIG or config.yaml in the FySlide config directory.")
+var profileFlag = flag.String("profile", "", "Profile whose tag database and preferences to use. If empty, a chooser is shown when profiles exist.")
+var viewFlag = flag.String("view", "", "Reproduce a view copied with View > Copy View Link, given as its fyslide://view link.")
+var registerLinksFlag = flag.Bool("register-links", false, "Make this program the handler of fyslide:// links for the current user, then exit.")
 
 // CreateApplication is the GUI entrypoint
 func CreateApplication() {
 	flag.Parse() // Parse command-line flags
-	dir, err := os.Getwd()
-	if err != nil {
-		fmt.Printf("error while opening the directory : %v\n", err)
+	if *registerLinksFlag {
+		registerLinks()
 		return
 	}
-	if len(os.Args) > 1 {
-		file, err := os.Open(os.Args[1])
-		if err != nil {
-			fmt.Printf("error while opening the directory '%s': %v\n", file.Name(), err)
-			return
-		}
-		s, _ := file.Stat()
-		if s.IsDir() {
-			dir = s.Name()
-		}
+	cfg, err := config.Load(*configFlag)
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-	dir, err = filepath.Abs(dir)
+	roots, start, err := libraryRoots(cfg)
 	if err != nil {
-		fmt.Println("Error getting absolute path:", err)
+		fmt.Println(err)
 		return
 	}
+	applyConfigDefaults(cfg)
+	closeLog := openLogFile()
+	defer closeLog()
 
 	a := app.NewWithID("com.github.nicky-ayoub/fyslide")
 	a.SetIcon(resourceIconPng)
@@ -1073,7 +1682,35 @@ func CreateApplication() {
 	currentTheme := a.Settings().Theme()
 	a.Settings().SetTheme(NewSmallTabsTheme(currentTheme))
 
-	ui := &App{app: a, direction: 1}
+	if !profileNeedsChoice() {
+		if err := profile.ValidateName(*profileFlag); *profileFlag != "" && err != nil {
+			log.Fatal(err)
+		}
+		ui := startProfile(a, *profileFlag, cfg, roots, start)
+		ui.waitForImages()
+		ui.startSlideshow()
+		ui.UI.MainWin.ShowAndRun()
+		return
+	}
+	// The main window is built once a profile is picked; scanning then runs
+	// while the window is already up, so wait for images off the UI goroutine.
+	showProfileChooser(a, func(name string) {
+		ui := startProfile(a, name, cfg, roots, start)
+		ui.UI.MainWin.Show()
+		go func() {
+			ui.waitForImages()
+			fyne.Do(ui.startSlideshow)
+		}()
+	})
+	a.Run()
+}
+
+// startProfile opens the tag database of the named profile, builds the main
+// window and starts scanning roots, to show start first if not nil. The
+// window is not shown yet.
+func startProfile(a fyne.App, profileName string, cfg *config.Config, roots []string, start *deeplink.View) *App {
+	ui := &App{app: a, direction: 1, profile: profileName, config: cfg, kiosk: *kioskFlag || cfg.Slideshow.Kiosk, startView: start}
+	ui.ctx, ui.stop = context.WithCancel(context.Background())
 
 	// Define the logger function that TagDB will use.
 	// This closure captures the 'ui' variable (*App instance).
@@ -1087,23 +1724,34 @@ func CreateApplication() {
 		}
 	}
 
-	ui.tagDB, err = tagging.NewTagDB("", appLoggerFunc) // Pass the logger function
+	dbDir, err := profile.Create(profileName)
+	if err != nil {
+		log.Fatalf("Failed to open profile: %v", err)
+	}
+	if cfg.DB.Path != "" && (profileName == "" || profileName == profile.DefaultName) {
+		dbDir = cfg.DB.Path // Named profiles keep their own databases
+	}
+	ui.tagDB, err = tagging.NewTagDB(dbDir, appLoggerFunc) // Pass the logger function
 	if err != nil {
 		log.Fatalf("Failed to initialize tag database: %v", err)
 	}
+	ui.service = service.New(ui.tagDB)
+	ui.service.SetReadOnly(*readOnlyFlag)
+	ui.service.SetLibraryRoots(roots...)
+	if cfg.Delete.ProtectedTags != nil {
+		ui.service.SetProtectedTags(cfg.Delete.ProtectedTags...)
+	}
+	ui.service.SetTrash(cfg.Delete.TrashOn())
+	ui.libraryRoots = roots
 	// Initialize UI components that need the app instance
-	ui.UI.MainWin = a.NewWindow("FySlide")
-	ui.UI.MainWin.SetCloseIntercept(func() {
-		log.Println("Closing tag database...")
-		if err := ui.tagDB.Close(); err != nil {
-			log.Printf("Error closing tag database: %v", err)
-		}
-		ui.UI.MainWin.Close() // Proceed with closing the window
-	})
+	ui.UI.MainWin = a.NewWindow("FySlide" + ui.profileTitle())
+	ui.UI.MainWin.SetCloseIntercept(ui.shutdown)
 
 	ui.UI.MainWin.SetIcon(resourceIconPng)
 	ui.init(*historySizeFlag, *slideshowIntervalFlag, *skipCountFlag) // Pass parsed flags to init
-	ui.random = true
+	ui.startEvents(*eventsFlag)
+	ui.startMQTT(mqttlink.Options{Broker: *mqttBrokerFlag, Topic: *mqttTopicFlag, Group: *mqttGroupFlag})
+	ui.random = start == nil || start.Shuffle // Browse an opened image's folder in order, a view in its own
 
 	ui.UI.clockLabel = widget.NewLabel("Time: ")
 	ui.UI.infoText = widget.NewRichTextFromMarkdown("# Info\n---\n")
@@ -1111,217 +1759,126 @@ func CreateApplication() {
 	// Status bar will be initialized in buildMainUI
 	ui.UI.MainWin.SetContent(ui.buildMainUI())
 
-	go ui.loadImages(dir)
+	ui.goBackground(func() { ui.loadImages(roots...) })
+	ui.scheduleTrashEmptying(cfg.Delete.TrashDays)
+	ui.scheduleUsageFlush()
 
-	ui.UI.MainWin.CenterOnScreen()
-	ui.UI.MainWin.SetFullScreen(true)
+	ui.restoreWindowState()
+	if ui.kiosk {
+		ui.UI.MainWin.SetFullScreen(true)
+	}
+	return ui
+}
 
-	// Wait for initial scan
+// waitForImages blocks until the initial scan found an image, or finished if
+// a start view is to be found in it, or timed out.
+func (a *App) waitForImages() {
 	startTime := time.Now()
-	for ui.imageCount() < 1 {
+	for a.imageCount() < 1 || (a.startView != nil && !a.scanDone.Load()) {
 		if time.Since(startTime) > 10*time.Second { // Timeout
-			ui.addLogMessage("Timeout waiting for images to load. Please check the directory.")
+			if a.imageCount() < 1 {
+				fyne.Do(func() { a.addLogMessage("Timeout waiting for images to load. Please check the directory.") })
+			}
 			// No images loaded, so the UI will reflect this.
 			break
 		}
 		time.Sleep(100 * time.Millisecond) // Slightly longer sleep
 	}
+}
 
+// startSlideshow shows the first image and starts the slideshow and clock.
+func (a *App) startSlideshow() {
 	// Check if images were actually loaded
-	if ui.imageCount() > 0 {
-		ticker := time.NewTicker(ui.slideshowManager.Interval())
-		ui.isNavigatingHistory = false // Initial display is not from history
-		go ui.pauser(ticker)           // pauser will call loadAndDisplayCurrentImage via fyne.Do
-		go ui.updateTimer()
-		ui.loadAndDisplayCurrentImage()
+	if a.imageCount() > 0 {
+		a.slideshowManager.Start(func() {
+			fyne.Do(func() {
+				a.isNavigatingHistory = false // Standard "next" is not history navigation
+				a.slideshowAdvance()
+			})
+		})
+		a.applyAdaptiveIntervalPreference()
+		a.isNavigatingHistory = false // Initial display is not from history
+		a.goBackground(a.updateTimer)
+		if !a.openStartView() {
+			a.loadAndDisplayCurrentImage()
+		}
 	} else {
 		// This case is also hit on timeout if no images loaded.
-		ui.updateStatusBar() // Will show "No images available" or similar.
-		ui.updateInfoText()
+		a.updateStatusBar() // Will show "No images available" or similar.
+		a.updateInfoText()
 	}
-
-	ui.UI.MainWin.ShowAndRun()
 }
 
+// updateTimer shows the time of day in the clock label every second until
+// the app stops.
 func (a *App) updateTimer() {
-	for range time.Tick(time.Second) {
-		// ???
-		if a.UI.MainWin == nil || a.UI.clockLabel == nil { // Check if UI elements are still valid
-			return // Exit goroutine if window is closed
-		}
-		fyne.Do(func() {
-			formatted := time.Now().Format("Time: 03:04:05")
-			a.UI.clockLabel.SetText(formatted)
-		})
-	}
-}
-
-func (a *App) pauser(ticker *time.Ticker) {
-	for range ticker.C {
-		if a.UI.MainWin == nil { // Check if window is still valid
-			ticker.Stop() // Stop the ticker
-			return        // Exit goroutine
-		}
-		if !a.slideshowManager.IsPaused() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
 			fyne.Do(func() {
-				a.isNavigatingHistory = false // Standard "next" is not history navigation
-				a.nextImage()
+				formatted := time.Now().Format("Time: 03:04:05")
+				a.UI.clockLabel.SetText(formatted)
 			})
 		}
 	}
 }
 
-// removeTagGlobally removes a specific tag from all images in the database.
-func (a *App) removeTagGlobally(tag string) error {
+// removeTagGlobally removes a specific tag from all images in the database. The
+// removal runs on the tag worker; onDone receives its outcome on the Fyne goroutine.
+func (a *App) removeTagGlobally(tag string, onDone func(err error)) {
 	if tag == "" {
-		return fmt.Errorf("cannot remove an empty tag")
+		onDone(fmt.Errorf("cannot remove an empty tag"))
+		return
+	}
+	if a.readOnly() {
+		onDone(service.ErrReadOnly)
+		return
 	}
 	a.addLogMessage(fmt.Sprintf("Global removal for tag '%s' started.", tag))
 
 	// 1. Get all images associated with this tag
-	imagePaths, err := a.tagDB.GetImages(tag)
+	imagePaths, err := a.tagDB.GetImages(a.ctx, tag)
 	if err != nil {
-		// Log the error, but maybe the tag just doesn't exist (which is fine for removal)
-		a.addLogMessage(fmt.Sprintf("Error getting images for tag '%s' during global removal: %v", tag, err))
-		// Check if it's a "not found" type error if your DB layer provides it.
-		// If it's just not found, we can consider it a success (nothing to remove).
 		// For BoltDB, GetImages returns an empty list if the tag key doesn't exist, not an error.
 		// So, an error here is likely a real DB issue.
-		return fmt.Errorf("database error while getting images for tag '%s': %w", tag, err)
+		onDone(fmt.Errorf("database error while getting images for tag '%s': %w", tag, err))
+		return
 	}
 
 	if len(imagePaths) == 0 {
 		a.addLogMessage(fmt.Sprintf("Tag '%s' not found or no images associated with it. Global removal complete.", tag))
-		return nil // No images had this tag, so removal is effectively done.
-	}
-
-	a.addLogMessage(fmt.Sprintf("Found %d images with tag '%s'. Removing...", len(imagePaths), tag))
-
-	// 2. Iterate and remove the tag from each image
-	var firstError error
-	errorsEncountered := 0
-	successfulRemovals := 0
-
-	for _, path := range imagePaths {
-		// RemoveTag handles both Image->Tag and Tag->Image mappings.
-		// It should also delete the Tag key if the image list becomes empty.
-		errRemove := a.tagDB.RemoveTag(path, tag)
-		if errRemove != nil {
-			a.addLogMessage(fmt.Sprintf("Error removing tag '%s' from image '%s': %v", tag, filepath.Base(path), errRemove))
-			errorsEncountered++
-			if firstError == nil {
-				firstError = fmt.Errorf("failed removing tag '%s' from %s: %w", tag, filepath.Base(path), errRemove)
-			}
-		} else {
-			successfulRemovals++
-		}
+		onDone(nil) // No images had this tag, so removal is effectively done.
+		return
 	}
 
-	a.addLogMessage(fmt.Sprintf("Global removal for '%s': %d successes, %d errors.", tag, successfulRemovals, errorsEncountered))
-
-	// 3. Update UI if the currently displayed image was affected
-	// Check if the current image *had* the tag that was just removed
-	currentItem := a.getCurrentItem()
-	if currentItem != nil {
-		// Check if the current item's path was in the list we just processed
-		wasAffected := false
-		for _, path := range imagePaths {
-			if currentItem.Path == path {
-				wasAffected = true
-				break
-			}
-		}
-		if wasAffected {
-			a.addLogMessage(fmt.Sprintf("Current image %s affected by global tag removal.", filepath.Base(currentItem.Path)))
-			a.updateInfoText() // Refresh the info panel to show updated tags
-		}
+	// 2. Queue the removal of the tag from each image
+	ops := make([]tagOp, len(imagePaths))
+	for i, path := range imagePaths {
+		ops[i] = tagOp{path: path, tag: tag, add: false}
 	}
-
-	// 4. Return the first error encountered, if any
-	return firstError
+	a.submitTagJob(fmt.Sprintf("Removing tag '%s' from %d images", tag, len(imagePaths)), ops, onDone)
 }
 
-// _addTagsToDirectory is a helper to apply a list of tags to all images in a given directory.
-// It uses goroutines for concurrent database operations.
-func (a *App) _addTagsToDirectory(tagsToAdd []string, currentDir string,
-	wg *sync.WaitGroup, mu *sync.Mutex, firstError *error,
-	totalTagsAttempted *int, successfulAdditions *int, errorsEncountered *int, imagesProcessed *int, filesAffected map[string]bool) {
-
-	a.addLogMessage(fmt.Sprintf("Batch tagging directory: %s with [%s]", filepath.Base(currentDir), strings.Join(tagsToAdd, ", ")))
-
-	for _, imageItem := range a.images { // Iterate through the original full list
-		// Capture loop variables for the goroutine
-		itemPath := imageItem.Path
-		currentTagsToAdd := tagsToAdd // Capture for goroutine
-
-		itemDir := filepath.Dir(itemPath)
-		if itemDir == currentDir {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-
-				localTagsAttemptedOnThisImage := 0
-				localSuccessfulAdditionsOnThisImage := 0
-				localErrorsOnThisImage := 0
-				var localFirstErrorForThisImage error
-
-				for _, tag := range currentTagsToAdd {
-					localTagsAttemptedOnThisImage++
-					errAdd := a.tagDB.AddTag(itemPath, tag)
-					if errAdd != nil {
-						// Logged via addLogMessage by the calling function's summary
-						localErrorsOnThisImage++
-						if localFirstErrorForThisImage == nil {
-							localFirstErrorForThisImage = fmt.Errorf("failed to tag %s with '%s': %w", filepath.Base(itemPath), tag, errAdd)
-						}
-					} else {
-						localSuccessfulAdditionsOnThisImage++
-						filesAffected[itemPath] = true
-					}
-				}
-
-				mu.Lock()
-				(*imagesProcessed)++
-				*totalTagsAttempted += localTagsAttemptedOnThisImage
-				*successfulAdditions += localSuccessfulAdditionsOnThisImage
-				*errorsEncountered += localErrorsOnThisImage
-				if localFirstErrorForThisImage != nil && *firstError == nil {
-					*firstError = localFirstErrorForThisImage
-				}
-				mu.Unlock()
-			}()
+// imagesInDirectory returns the paths of all scanned images directly inside dir.
+func (a *App) imagesInDirectory(dir string) []string {
+	var paths []string
+	for _, item := range a.images { // Iterate through the original full list
+		if filepath.Dir(item.Path) == dir {
+			paths = append(paths, item.Path)
 		}
 	}
-	wg.Wait() // Wait for all goroutines to finish
-
-	a.addLogMessage(fmt.Sprintf("Batch tagging for [%s] in '%s' complete. Images processed: %d. Attempts: %d, Successes: %d, Errors: %d.",
-		strings.Join(tagsToAdd, ", "), filepath.Base(currentDir), *imagesProcessed, *totalTagsAttempted, *successfulAdditions, *errorsEncountered))
-}
-
-// _applyTagsToSingleImage applies a list of tags to a single image path.
-func (a *App) _applyTagsToSingleImage(imagePath string, tagsToAdd []string, filesAffected map[string]bool) (successfulAdditions int, errorsEncountered int, firstError error) {
-	a.addLogMessage(fmt.Sprintf("Applying tag(s) [%s] to %s", strings.Join(tagsToAdd, ", "), filepath.Base(imagePath)))
-	for _, tag := range tagsToAdd {
-		errAdd := a.tagDB.AddTag(imagePath, tag)
-		if errAdd != nil {
-			errorsEncountered++
-			if firstError == nil {
-				firstError = fmt.Errorf("failed to add tag '%s' to %s: %w", tag, filepath.Base(imagePath), errAdd)
-			}
-		} else {
-			successfulAdditions++
-			filesAffected[imagePath] = true
-		}
-	}
-	if imagePath == a.img.Path && successfulAdditions > 0 { // If current image was affected
-		fyne.Do(func() { a.updateInfoText() })
-	}
-	a.addLogMessage(fmt.Sprintf("Applied tags to %s. Successes: %d, Errors: %d", filepath.Base(imagePath), successfulAdditions, errorsEncountered))
-	return
+	return paths
 }
 
 // addTag shows a dialog to add a new tag to the current image
 func (a *App) addTag() {
+	if a.refuseInReadOnly("Add Tag") {
+		return
+	}
 	if a.img.Path == "" {
 		dialog.ShowInformation("Add Tag", "No image loaded to tag.", a.UI.MainWin) // Updated title
 		return
@@ -1332,7 +1889,7 @@ func (a *App) addTag() {
 		a.addLogMessage("Slideshow paused for tagging.")
 	}
 
-	currentTags, err := a.tagDB.GetTags(a.img.Path)
+	currentTags, err := a.tagDB.GetTags(a.ctx, a.img.Path)
 	if err != nil {
 		a.slideshowManager.ResumeAfterOperation() // Ensure resume on error
 		if !a.slideshowManager.IsPaused() {
@@ -1341,6 +1898,7 @@ func (a *App) addTag() {
 		dialog.ShowError(fmt.Errorf("failed to get current tags: %w", err), a.UI.MainWin)
 		return
 	}
+	currentTags = a.withPendingTags(a.img.Path, currentTags)
 
 	tagEntry := widget.NewEntry()
 	tagEntry.SetPlaceHolder("Enter tag(s) separated by commas...")
@@ -1352,13 +1910,28 @@ func (a *App) addTag() {
 
 	applyToAllCheck := widget.NewCheck("Apply tag(s) to all images in this directory", nil)
 	applyToAllCheck.SetChecked(true)
-
-	// Keep the rest of the addTag (formerly tagFile) function body the same...
-	dialog.ShowForm("Add Tag", "Add", "Cancel", []*widget.FormItem{
+	items := []*widget.FormItem{
 		widget.NewFormItem("", currentTagsLabel), // Display current tags
 		widget.NewFormItem("New Tag(s) (comma-separated)", tagEntry),
-		widget.NewFormItem("", applyToAllCheck), // --- NEW: Add checkbox to form ---
-	}, func(confirm bool) {
+	}
+	if chips := a.tagSetChips(tagEntry); chips != nil {
+		items = append(items, widget.NewFormItem("Tag sets", chips))
+	}
+	if chips := a.recentTagChips(currentTags, tagEntry); chips != nil {
+		items = append(items, widget.NewFormItem("Recently used", chips))
+	}
+	if chips := a.siblingTagChips(a.img.Path, currentTags, tagEntry); chips != nil {
+		items = append(items, widget.NewFormItem("Used in this folder", chips))
+	}
+	items = append(items, widget.NewFormItem("", applyToAllCheck))
+	stackCheck := a.stackTargetCheck("Apply tag(s) to all %d images of this stack")
+	if stackCheck != nil {
+		applyToAllCheck.SetChecked(!stackCheck.Checked) // A collapsed stack is the item being tagged
+		items = append(items, widget.NewFormItem("", stackCheck))
+	}
+	_, stackMembers := a.currentStack()
+
+	dialog.ShowForm("Add Tag", "Add", "Cancel", items, func(confirm bool) {
 
 		defer func() {
 			a.slideshowManager.ResumeAfterOperation()
@@ -1388,134 +1961,34 @@ func (a *App) addTag() {
 			return // No valid tags, defer handles resume
 		}
 
-		applyToAll := applyToAllCheck.Checked // --- NEW: Get checkbox state ---
-
-		var errAddOp error // Store the first error encountered for the operation
-		var statusMessage string
-		showMessage := false
-		var logMessage string
-		filesAffected := make(map[string]bool) // Track files that had tags successfully added
-		totalTagsAttempted := 0
-		successfulAdditions := 0
-		errorsEncountered := 0
-
-		// --- It correctly iterates through the 'tagsToAdd' slice ---
-		if applyToAll {
+		paths := []string{a.img.Path}
+		target := filepath.Base(a.img.Path)
+		if applyToAllCheck.Checked {
 			currentDir := filepath.Dir(a.img.Path)
-			var wg sync.WaitGroup
-			var mu sync.Mutex // Mutex to protect shared variables
-			imagesProcessed := 0
-			a.addLogMessage(fmt.Sprintf("Adding tag(s) [%s] to all images in %s...", strings.Join(tagsToAdd, ", "), filepath.Base(currentDir)))
-			a._addTagsToDirectory(tagsToAdd, currentDir, &wg, &mu, &errAddOp, &totalTagsAttempted, &successfulAdditions, &errorsEncountered, &imagesProcessed, filesAffected)
-
-			logMessage = fmt.Sprintf("Added tag(s) [%s] to %d images in %s. Successes: %d, Errors: %d",
-				strings.Join(tagsToAdd, ", "), imagesProcessed, filepath.Base(currentDir), successfulAdditions, errorsEncountered)
-
-			if errorsEncountered > 0 {
-				showMessage = true
-				statusMessage = fmt.Sprintf("%d tag(s) applied partially across %d images.\n%d errors occurred (see logs).", len(tagsToAdd), imagesProcessed, errorsEncountered)
-			} else if successfulAdditions > 0 { // Only show success if something was actually added
-				showMessage = true
-				statusMessage = fmt.Sprintf("%d tag(s) applied to %d images in %s.", len(tagsToAdd), len(filesAffected), filepath.Base(currentDir))
-			}
-		} else {
-			// Apply tags only to the current image
-			successfulAdditions, errorsEncountered, errAddOp = a._applyTagsToSingleImage(a.img.Path, tagsToAdd, filesAffected)
-			totalTagsAttempted = len(tagsToAdd) // All tags were attempted on the single image
-
-			logMessage = fmt.Sprintf("Tagging %s: %d attempts, %d successes, %d errors.", filepath.Base(a.img.Path), totalTagsAttempted, successfulAdditions, errorsEncountered)
-			if errorsEncountered > 0 {
-				statusMessage = fmt.Sprintf("%d tag(s) applied partially.\n%d errors occurred (see logs).", len(tagsToAdd), errorsEncountered)
-				showMessage = true // Show message for partial success on single image too
-			}
-			if successfulAdditions > 0 && errorsEncountered == 0 { // Only show success if something was actually added and no errors
-				showMessage = true
-				statusMessage = fmt.Sprintf("%d tag(s) applied to current image.", len(tagsToAdd))
-			}
+			paths = a.imagesInDirectory(currentDir)
+			target = fmt.Sprintf("%d images in %s", len(paths), filepath.Base(currentDir))
+		} else if stackCheck != nil && stackCheck.Checked {
+			paths = stackMembers
+			target = fmt.Sprintf("the stack of %d images", len(paths))
 		}
 
-		a.addLogMessage(logMessage)
-
-		// --- Common Post-Processing ---
-		if errAddOp != nil {
-			// Show the first error encountered
-			dialog.ShowError(errAddOp, a.UI.MainWin) // Simplified error message
-			a.addLogMessage(fmt.Sprintf("Error adding tags: %v", errAddOp))
-		} else {
-			// No critical error, logMessage already added by a.addLogMessage
-		}
-		if len(filesAffected) > 0 { // Only update info/refresh if something actually changed
-			if _, ok := filesAffected[a.img.Path]; ok { // If current image was affected
-				a.updateInfoText() // Update info panel for the current image
-			}
-			if a.refreshTagsFunc != nil {
-				// log.Println("Calling Tags tab refresh function.") // Developer log, not for UI
-				a.refreshTagsFunc()
-			} else {
-				a.addLogMessage("Warning: Tags tab refresh function not set.")
+		var ops []tagOp
+		for _, path := range paths {
+			for _, tag := range tagsToAdd {
+				ops = append(ops, tagOp{path: path, tag: tag, add: true})
 			}
 		}
-		if showMessage { // This is for partial success messages or full success
-			// dialog.ShowInformation("Tagging Status", statusMessage, a.UI.MainWin)
-			// Replace dialog with status bar message
-			a.addLogMessage(fmt.Sprintf("Tagging Status: %s", statusMessage))
-		}
+		a.rememberAppliedTags(tagsToAdd)
+		a.submitTagJob(fmt.Sprintf("Adding tag(s) [%s] to %s", strings.Join(tagsToAdd, ", "), target), ops, nil)
 	}, a.UI.MainWin)
 }
 
-// _removeTagFromSingleImage removes a tag from a single image path.
-func (a *App) _removeTagFromSingleImage(imagePath string, tagToRemove string) (errRemove error) {
-	a.addLogMessage(fmt.Sprintf("Removing tag '%s' from %s", tagToRemove, filepath.Base(imagePath)))
-	errRemove = a.tagDB.RemoveTag(imagePath, tagToRemove)
-	if errRemove == nil {
-		a.addLogMessage(fmt.Sprintf("Successfully removed tag '%s' from %s.", tagToRemove, filepath.Base(imagePath)))
-		if imagePath == a.img.Path { // If current image was affected
-			fyne.Do(func() { a.updateInfoText() })
-		}
-	} else {
-		a.addLogMessage(fmt.Sprintf("Error removing tag '%s' from %s: %v", tagToRemove, filepath.Base(imagePath), errRemove))
-	}
-	return
-}
-
-// _removeTagFromDirectory is a helper to remove a tag from all images in a given directory.
-func (a *App) _removeTagFromDirectory(tagToRemove string, currentDir string,
-	wg *sync.WaitGroup, mu *sync.Mutex, firstError *error,
-	imagesUntaggedCount *int, errorsEncountered *int) {
-
-	a.addLogMessage(fmt.Sprintf("Batch untagging directory: %s for tag [%s]", filepath.Base(currentDir), tagToRemove))
-
-	for _, imageItem := range a.images {
-		itemPath := imageItem.Path
-		itemDir := filepath.Dir(itemPath)
-
-		if itemDir == currentDir {
-			wg.Add(1)
-			go func(path string, tag string) { // Pass path and tag to goroutine
-				defer wg.Done()
-				errRemove := a.tagDB.RemoveTag(path, tag)
-				mu.Lock()
-				defer mu.Unlock()
-				if errRemove != nil {
-					(*errorsEncountered)++
-					if *firstError == nil {
-						*firstError = fmt.Errorf("failed to untag %s: %w", filepath.Base(path), errRemove)
-					}
-				} else {
-					(*imagesUntaggedCount)++
-				}
-			}(itemPath, tagToRemove) // Pass itemPath and tagToRemove
-		}
-	}
-	wg.Wait() // Wait for all goroutines to finish
-
-	a.addLogMessage(fmt.Sprintf("Batch untagging for [%s] in '%s' complete. Images untagged: %d, Errors: %d.",
-		tagToRemove, filepath.Base(currentDir), *imagesUntaggedCount, *errorsEncountered))
-}
-
 // removeTag shows a dialog to remove an existing tag from the current image,
 // with an option to remove it from all images in the same directory.
 func (a *App) removeTag() {
+	if a.refuseInReadOnly("Remove Tag") {
+		return
+	}
 	if a.img.Path == "" {
 		dialog.ShowInformation("Remove Tag", "No image loaded to remove tags from.", a.UI.MainWin)
 		return
@@ -1527,7 +2000,7 @@ func (a *App) removeTag() {
 	}
 
 	// 1. Get current tags for the image to populate the selector
-	currentTags, err := a.tagDB.GetTags(a.img.Path)
+	currentTags, err := a.tagDB.GetTags(a.ctx, a.img.Path)
 	if err != nil {
 		a.slideshowManager.ResumeAfterOperation()
 		if !a.slideshowManager.IsPaused() {
@@ -1536,6 +2009,7 @@ func (a *App) removeTag() {
 		dialog.ShowError(fmt.Errorf("failed to get current tags: %w", err), a.UI.MainWin)
 		return
 	}
+	currentTags = a.withPendingTags(a.img.Path, currentTags)
 
 	// 2. Check if there are any tags to remove
 	if len(currentTags) == 0 {
@@ -1556,15 +2030,19 @@ func (a *App) removeTag() {
 	tagSelector.SetSelected(currentTags[0])
 	selectedTag = currentTags[0] // Initialize selectedTag
 
-	// --- NEW: Checkbox for removing from all in directory ---
 	removeFromAllCheck := widget.NewCheck("Remove tag from all images in this directory", nil)
-	// --- End NEW ---
+	items := []*widget.FormItem{
+		widget.NewFormItem("Select Tag to Remove", tagSelector),
+		widget.NewFormItem("", removeFromAllCheck),
+	}
+	stackCheck := a.stackTargetCheck("Remove tag from all %d images of this stack")
+	if stackCheck != nil {
+		items = append(items, widget.NewFormItem("", stackCheck))
+	}
+	_, stackMembers := a.currentStack()
 
 	// 4. Show the removal dialog
-	dialog.ShowForm("Remove Tag", "Remove", "Cancel", []*widget.FormItem{
-		widget.NewFormItem("Select Tag to Remove", tagSelector),
-		widget.NewFormItem("", removeFromAllCheck), // --- NEW: Add checkbox to form ---
-	}, func(confirm bool) {
+	dialog.ShowForm("Remove Tag", "Remove", "Cancel", items, func(confirm bool) {
 		defer func() {
 			a.slideshowManager.ResumeAfterOperation()
 			if !a.slideshowManager.IsPaused() {
@@ -1575,56 +2053,21 @@ func (a *App) removeTag() {
 			return // User cancelled or somehow didn't select a tag
 		}
 
-		removeFromAll := removeFromAllCheck.Checked // --- NEW: Get checkbox state ---
-
-		var errRemoveOp error    // Use a local error variable for the operation
-		var statusMessage string // For success or partial success
-		//var logMessage string
-		imagesUntaggedCount := 0
-		errorsEncountered := 0
-
-		if removeFromAll {
-			// --- NEW: Logic to remove tag from all images in the directory ---
+		paths := []string{a.img.Path}
+		target := filepath.Base(a.img.Path)
+		if removeFromAllCheck.Checked {
 			currentDir := filepath.Dir(a.img.Path)
-			var wg sync.WaitGroup
-			var mu sync.Mutex
-			a._removeTagFromDirectory(selectedTag, currentDir, &wg, &mu, &errRemoveOp, &imagesUntaggedCount, &errorsEncountered)
-
-			// if imagesUntaggedCount > 0 || errorsEncountered > 0 { // Only log if something was attempted
-			// 	logMessage = fmt.Sprintf("Removed tag '%s'. Images untagged: %d in %s. Errors: %d.",
-			// 		selectedTag, imagesUntaggedCount, filepath.Base(currentDir), errorsEncountered)
-			// } else {
-			// 	logMessage = fmt.Sprintf("No images in directory %s found requiring removal of tag '%s'.", filepath.Base(currentDir), selectedTag)
-			// }
-			// Log message is now handled by _removeTagFromDirectory or _removeTagFromSingleImage
-
-			if errorsEncountered > 0 {
-				statusMessage = fmt.Sprintf("Tag '%s' removal attempted. %d images untagged.\n%d errors occurred (see logs).", selectedTag, imagesUntaggedCount, errorsEncountered)
-			} else if imagesUntaggedCount > 0 {
-				statusMessage = fmt.Sprintf("Tag '%s' removed from %d images in directory %s.", selectedTag, imagesUntaggedCount, filepath.Base(currentDir))
-			}
-		} else { // Remove only from the current image
-			errRemoveOp = a._removeTagFromSingleImage(a.img.Path, selectedTag)
-			if errRemoveOp == nil { // If successful
-				imagesUntaggedCount = 1
-				statusMessage = fmt.Sprintf("Tag '%s' removed from current image.", selectedTag)
-			}
+			paths = a.imagesInDirectory(currentDir)
+			target = fmt.Sprintf("%d images in %s", len(paths), filepath.Base(currentDir))
+		} else if stackCheck != nil && stackCheck.Checked {
+			paths = stackMembers
+			target = fmt.Sprintf("the stack of %d images", len(paths))
 		}
 
-		if errRemoveOp != nil {
-			dialog.ShowError(fmt.Errorf("failed to remove tag '%s': %w", selectedTag, errRemoveOp), a.UI.MainWin)
-			a.addLogMessage(fmt.Sprintf("Error removing tag '%s': %v", selectedTag, errRemoveOp))
-		} else {
-			if statusMessage != "" { // Show success or partial success summary
-				// dialog.ShowInformation("Tag Removal Status", statusMessage, a.UI.MainWin)
-				a.addLogMessage(fmt.Sprintf("Tag Removal Status: %s", statusMessage))
-			}
-			if a.refreshTagsFunc != nil && imagesUntaggedCount > 0 {
-				a.refreshTagsFunc()
-			}
-			if imagesUntaggedCount > 0 && (a.img.Path != "" && (removeFromAll || (!removeFromAll && selectedTag != ""))) { // Check if current image could have been affected
-				a.updateInfoText() // Refresh info text if current image might have changed
-			}
+		ops := make([]tagOp, len(paths))
+		for i, path := range paths {
+			ops[i] = tagOp{path: path, tag: selectedTag, add: false}
 		}
+		a.submitTagJob(fmt.Sprintf("Removing tag '%s' from %s", selectedTag, target), ops, nil)
 	}, a.UI.MainWin)
 }
@@ -0,0 +1,118 @@
+// Package ui Change history dialog over the tag database's audit log.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/tagging"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	maxAuditRows      = 1000 // Most recent changes listed; narrow the filter to see older ones
+	auditDialogWidth  = 760
+	auditDialogHeight = 480
+)
+
+// auditEvents reads the log for filter, leaving out changes to private images
+// while they are locked so the log doesn't reveal them.
+func (a *App) auditEvents(filter tagging.AuditFilter) ([]tagging.AuditEvent, error) {
+	events, err := a.tagDB.AuditLog(a.ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if hidden := a.lockedPrivatePaths(); len(hidden) > 0 {
+		events = slices.DeleteFunc(events, func(ev tagging.AuditEvent) bool {
+			return hidden[ev.Path] || (ev.Action == tagging.ActionRename && hidden[ev.Detail])
+		})
+	}
+	return events, nil
+}
+
+// showAuditLogDialog lists recorded tag changes, newest first, with filters
+// by tag and path and an export to CSV.
+func (a *App) showAuditLogDialog() {
+	var events []tagging.AuditEvent
+
+	tagEntry := widget.NewEntry()
+	tagEntry.SetPlaceHolder("Tag")
+	pathEntry := widget.NewEntry()
+	pathEntry.SetPlaceHolder("Any part of the path")
+	countLabel := widget.NewLabel("")
+
+	list := widget.NewList(
+		func() int { return len(events) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("2006-01-02 15:04:05  user  action  summary")
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			ev := events[len(events)-1-id] // Newest first
+			obj.(*widget.Label).SetText(fmt.Sprintf("%s  %s  %s  %s",
+				ev.Time.Format("2006-01-02 15:04:05"), ev.User, ev.Action, ev.Summary()))
+		},
+	)
+
+	filter := func() tagging.AuditFilter {
+		return tagging.AuditFilter{
+			Tag:  strings.ToLower(strings.TrimSpace(tagEntry.Text)),
+			Path: strings.TrimSpace(pathEntry.Text),
+		}
+	}
+	refresh := func() {
+		f := filter()
+		f.Limit = maxAuditRows
+		var err error
+		if events, err = a.auditEvents(f); err != nil {
+			countLabel.SetText(fmt.Sprintf("Error: %v", err))
+			events = nil
+		} else {
+			countLabel.SetText(fmt.Sprintf("%d change(s)", len(events)))
+		}
+		list.Refresh()
+	}
+	tagEntry.OnChanged = func(string) { refresh() }
+	pathEntry.OnChanged = func(string) { refresh() }
+
+	exportButton := widget.NewButton("Export CSV...", func() {
+		a.exportAuditCSV(filter())
+	})
+
+	filters := container.NewGridWithColumns(2, tagEntry, pathEntry)
+	content := container.NewBorder(filters, container.NewBorder(nil, nil, countLabel, exportButton), nil, nil, list)
+	d := dialog.NewCustom("Change History", "Close", content, a.UI.MainWin)
+	d.Resize(fyne.NewSize(auditDialogWidth, auditDialogHeight))
+	refresh()
+	d.Show()
+}
+
+// exportAuditCSV asks for a file and writes every change matching filter to it.
+func (a *App) exportAuditCSV(filter tagging.AuditFilter) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, a.UI.MainWin)
+			return
+		}
+		if writer == nil {
+			return // Cancelled
+		}
+		defer writer.Close()
+		events, err := a.auditEvents(filter)
+		if err == nil {
+			err = tagging.WriteAuditCSV(writer, events)
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("exporting change history: %w", err), a.UI.MainWin)
+			return
+		}
+		a.addLogMessage(fmt.Sprintf("Exported %d change(s) to %s", len(events), writer.URI().Path()))
+	}, a.UI.MainWin)
+	save.SetFileName("fyslide-history.csv")
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	save.Show()
+}
@@ -0,0 +1,53 @@
+// Package ui Auto-enhance preview: a non-destructive levels stretch of the displayed image (E).
+package ui
+
+import (
+	"fyslide/internal/enhance"
+	"image"
+
+	"fyne.io/fyne/v2"
+)
+
+// enhancedForDisplay returns shown with its levels stretched if the
+// auto-enhance preview is on, and shown itself otherwise.
+func enhancedForDisplay(shown image.Image, on bool) image.Image {
+	if !on || shown == nil {
+		return shown
+	}
+	enhanced, _ := enhance.AutoLevels(shown)
+	return enhanced
+}
+
+// toggleAutoEnhance switches the auto-enhance preview on or off and redraws
+// the current image accordingly, keeping zoom and pan. The preview stays on
+// for the following images until switched off. Files are never changed; File >
+// Export Resized Copies... can write enhanced copies.
+func (a *App) toggleAutoEnhance() {
+	a.autoEnhance = !a.autoEnhance
+	if a.autoEnhance {
+		a.addLogMessage("Auto-enhance preview on")
+	} else {
+		a.addLogMessage("Auto-enhance preview off")
+	}
+	a.updateStatusBar()
+	a.redrawDisplayed()
+}
+
+// redrawDisplayed runs the current image through the display stages in effect
+// now, auto-enhance and night dimming, and shows the result keeping zoom and pan.
+func (a *App) redrawDisplayed() {
+	shown, path, on, brightness := a.img.Shown, a.img.Path, a.autoEnhance, a.dimBrightness
+	if shown == nil {
+		return
+	}
+	go func() {
+		displayed := forDisplay(shown, on, brightness)
+		fyne.Do(func() {
+			// A newer image or another change in the meantime makes this result stale
+			if a.img.Path != path || a.autoEnhance != on || a.dimBrightness != brightness || a.loadingPath != "" {
+				return
+			}
+			a.zoomPanArea.ReplaceImage(displayed)
+		})
+	}()
+}
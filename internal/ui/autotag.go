@@ -0,0 +1,72 @@
+// Package ui Automatic tags derived from EXIF data.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/autotag"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// exifTagNamespaces returns the EXIF namespaces tagged after every scan,
+// ignoring any the preference names that no longer exist.
+func (a *App) exifTagNamespaces() []string {
+	var namespaces []string
+	for _, ns := range strings.Split(a.prefs().String(prefEXIFTagNamespaces), ",") {
+		if valid, err := autotag.ParseNamespaces(ns); err == nil {
+			namespaces = append(namespaces, valid...)
+		}
+	}
+	return namespaces
+}
+
+// hasNamespaces reports whether tags include a tag in each of namespaces.
+func hasNamespaces(tags, namespaces []string) bool {
+	for _, ns := range namespaces {
+		if !slices.ContainsFunc(tags, func(tag string) bool { return strings.HasPrefix(tag, ns+":") }) {
+			return false
+		}
+	}
+	return true
+}
+
+// autoApplyEXIFTags tags the scanned images from their EXIF data in the
+// namespaces enabled in Preferences. Images that already carry a tag in every
+// enabled namespace aren't read again. Runs on the scanning goroutine.
+func (a *App) autoApplyEXIFTags(hidden map[string]bool) {
+	namespaces := a.exifTagNamespaces()
+	if len(namespaces) == 0 || a.readOnly() {
+		return
+	}
+	var ops []tagOp
+	failed := 0
+	for _, item := range a.images {
+		if hidden[item.Path] {
+			continue
+		}
+		current, err := a.tagDB.GetTags(a.ctx, item.Path)
+		if err != nil || hasNamespaces(current, namespaces) {
+			continue
+		}
+		tags, err := autotag.EXIFTags(item.Path, namespaces)
+		if err != nil {
+			failed++
+			continue
+		}
+		for _, tag := range tags {
+			if !slices.Contains(current, tag) {
+				ops = append(ops, tagOp{path: item.Path, tag: tag, add: true})
+			}
+		}
+	}
+	fyne.Do(func() {
+		if failed > 0 {
+			a.addLogMessage(fmt.Sprintf("EXIF tags: could not read %d image(s).", failed))
+		}
+		if len(ops) > 0 {
+			a.submitTagJob(fmt.Sprintf("Adding EXIF tags to %d scanned image(s)", countImages(ops)), ops, nil)
+		}
+	})
+}
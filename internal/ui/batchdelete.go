@@ -0,0 +1,182 @@
+// Package ui Batch delete: deletes every image of the current filter after
+// showing their thumbnails and having the count typed in as confirmation.
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/service"
+	"image"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// batchDeleteThumbSize is the size of the thumbnails the batch delete previews.
+	batchDeleteThumbSize = 96
+	// batchDeleteWidth and batchDeleteHeight size the batch delete dialog.
+	batchDeleteWidth  = 760
+	batchDeleteHeight = 620
+)
+
+// showDeleteFilteredDialog previews every image of the current filter and
+// deletes them all once their number is typed in. Protected images are kept
+// unless their deletion is confirmed as well.
+func (a *App) showDeleteFilteredDialog() {
+	if a.refuseInReadOnly("Delete All in Filter") {
+		return
+	}
+	if !a.isFiltered || len(a.filteredImages) == 0 {
+		dialog.ShowInformation("Delete All in Filter", "Apply a filter first: this deletes the images it shows.", a.UI.MainWin)
+		return
+	}
+	paths := make([]string, len(a.filteredImages))
+	for i, item := range a.filteredImages {
+		paths[i] = item.Path
+	}
+	protection, err := a.service.Protection(a.ctx, paths)
+	if err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+		return
+	}
+	a.slideshowManager.Pause(true)
+
+	grid := widget.NewGridWrap(
+		func() int { return len(paths) },
+		func() fyne.CanvasObject {
+			thumb := canvas.NewImageFromImage(nil)
+			thumb.FillMode = canvas.ImageFillContain
+			thumb.SetMinSize(fyne.NewSize(batchDeleteThumbSize, batchDeleteThumbSize))
+			name := widget.NewLabel("")
+			name.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, name, nil, nil, thumb)
+		},
+		nil, // Set below; the update needs the grid to refresh items when thumbnails arrive
+	)
+	grid.UpdateItem = func(id widget.GridWrapItemID, obj fyne.CanvasObject) {
+		if id >= len(paths) {
+			return
+		}
+		path := paths[id]
+		cell := obj.(*fyne.Container)
+		thumb := cell.Objects[0].(*canvas.Image)
+		name := filepath.Base(path)
+		if tags, ok := protection[path]; ok {
+			name = "Protected (" + strings.Join(tags, ", ") + "): " + name
+		}
+		cell.Objects[1].(*widget.Label).SetText(name)
+		img, ok := a.thumbnailManager.Get(path, func(image.Image) {
+			fyne.Do(func() { grid.RefreshItem(id) })
+		})
+		if !ok {
+			img = nil
+		}
+		thumb.Image = img
+		thumb.Refresh()
+	}
+
+	summary := widget.NewLabel(fmt.Sprintf("The %d images of the filter %s will be deleted from disk along with their tags. %s",
+		len(paths), a.currentFilter.String(), a.deleteConsequence()))
+	summary.Wrapping = fyne.TextWrapWord
+	var overrideCheck *widget.Check
+	if len(protection) > 0 {
+		overrideCheck = widget.NewCheck(fmt.Sprintf("Also delete the %d protected images", len(protection)), nil)
+	}
+	confirmEntry := widget.NewEntry()
+	confirmEntry.SetPlaceHolder(fmt.Sprintf("Type %d to confirm", len(paths)))
+
+	var d *dialog.CustomDialog
+	deleteButton := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		d.Hide()
+		a.runDeleteFiltered(paths, overrideCheck != nil && overrideCheck.Checked)
+	})
+	deleteButton.Importance = widget.DangerImportance
+	deleteButton.Disable()
+	confirmEntry.OnChanged = func(text string) {
+		if strings.TrimSpace(text) == strconv.Itoa(len(paths)) {
+			deleteButton.Enable()
+		} else {
+			deleteButton.Disable()
+		}
+	}
+	cancelButton := widget.NewButton("Cancel", func() {
+		d.Hide()
+		a.slideshowManager.ResumeAfterOperation()
+	})
+
+	top := container.NewVBox(summary)
+	if overrideCheck != nil {
+		top.Add(overrideCheck)
+	}
+	bottom := container.NewVBox(confirmEntry, container.NewHBox(layout.NewSpacer(), cancelButton, deleteButton))
+	d = dialog.NewCustomWithoutButtons("Delete All in Filter", container.NewBorder(top, bottom, nil, nil, grid), a.UI.MainWin)
+	d.Resize(fyne.NewSize(batchDeleteWidth, batchDeleteHeight))
+	d.Show()
+	a.UI.MainWin.Canvas().Focus(confirmEntry)
+}
+
+// runDeleteFiltered deletes paths in the background behind a progress dialog
+// that can cancel it, keeping protected images unless force is set, then
+// drops the deleted images from the lists.
+func (a *App) runDeleteFiltered(paths []string, force bool) {
+	ctx, cancel := context.WithCancel(a.ctx)
+	bar := widget.NewProgressBar()
+	status := widget.NewLabel(fmt.Sprintf("Deleting %d images...", len(paths)))
+	progress := dialog.NewCustom("Delete All in Filter", "Cancel", container.NewVBox(status, bar), a.UI.MainWin)
+	progress.SetOnClosed(cancel)
+	progress.Resize(fyne.NewSize(searchDialogWidth, progress.MinSize().Height))
+	progress.Show()
+
+	a.goBackground(func() {
+		defer cancel()
+		deleted := make(map[string]bool)
+		done, kept, failed := 0, 0, 0
+		var firstErr error
+		result := a.service.DeleteImages(ctx, paths, force, func(path string, err error) {
+			done++
+			switch {
+			case errors.Is(err, service.ErrProtected):
+				kept++
+			case err != nil && !errors.Is(err, service.ErrTagCleanup):
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+			default:
+				deleted[path] = true
+			}
+			n := done
+			fyne.Do(func() {
+				bar.SetValue(float64(n) / float64(len(paths)))
+				status.SetText(fmt.Sprintf("%d of %d images", n, len(paths)))
+				if err != nil {
+					a.addLogMessage(fmt.Sprintf("Deleting %s: %v", filepath.Base(path), err))
+				}
+			})
+		})
+		fyne.Do(func() {
+			progress.Hide()
+			a.slideshowManager.ResumeAfterOperation()
+			summary := fmt.Sprintf("Deleted %d images, kept %d protected, %d failed.", len(deleted), kept, failed)
+			if result.Stopped != nil {
+				summary = fmt.Sprintf("Stopped after %d of %d images. %s", done, len(paths), summary)
+			}
+			a.addLogMessage(summary)
+			if firstErr != nil {
+				summary += fmt.Sprintf("\n\nFirst error: %v", firstErr)
+			}
+			a.removeDeleted(deleted)
+			dialog.ShowInformation("Delete All in Filter", summary, a.UI.MainWin)
+		})
+	})
+}
@@ -0,0 +1,189 @@
+// Package ui Named bookmarks of viewing positions, kept in the tag database.
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"fyslide/internal/query"
+	"fyslide/internal/tagging"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// buildBookmarksMenuItem creates the View > Bookmarks submenu.
+func (a *App) buildBookmarksMenuItem() *fyne.MenuItem {
+	item := fyne.NewMenuItem("Bookmarks", nil)
+	a.UI.bookmarksMenu = fyne.NewMenu("Bookmarks")
+	item.ChildMenu = a.UI.bookmarksMenu
+	a.refreshBookmarksMenu()
+	return item
+}
+
+// refreshBookmarksMenu lists the saved bookmarks under the fixed entries.
+func (a *App) refreshBookmarksMenu() {
+	if a.UI.bookmarksMenu == nil {
+		return
+	}
+	items := []*fyne.MenuItem{
+		fyne.NewMenuItem("Add Bookmark...", a.showAddBookmarkDialog),
+		fyne.NewMenuItem("Delete Bookmark...", a.showDeleteBookmarkDialog),
+		fyne.NewMenuItemSeparator(),
+	}
+	bookmarks, err := a.tagDB.Bookmarks(a.ctx)
+	if err != nil {
+		a.addLogMessage(fmt.Sprintf("Failed to load bookmarks: %v", err))
+	}
+	for _, b := range bookmarks {
+		items = append(items, fyne.NewMenuItem(b.Name, func() { a.openBookmark(b) }))
+	}
+	if len(bookmarks) == 0 {
+		none := fyne.NewMenuItem("(no bookmarks)", nil)
+		none.Disabled = true
+		items = append(items, none)
+	}
+	a.UI.bookmarksMenu.Items = items
+	if a.UI.MainWin != nil && a.UI.MainWin.MainMenu() != nil {
+		a.UI.MainWin.MainMenu().Refresh()
+	}
+}
+
+// showAddBookmarkDialog saves the current image, filter and order under a name.
+func (a *App) showAddBookmarkDialog() {
+	item := a.getCurrentItem()
+	if item == nil {
+		dialog.ShowInformation("Add Bookmark", "No image to bookmark.", a.UI.MainWin)
+		return
+	}
+	path, index := item.Path, a.index
+	filter := a.currentFilter
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("e.g. left off in 2019 vacation")
+	if a.isFiltered {
+		nameEntry.SetText(filter.String())
+	} else {
+		nameEntry.SetText(filepath.Base(filepath.Dir(path)))
+	}
+	nameEntry.Validator = func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("name must not be empty")
+		}
+		return nil
+	}
+	dialog.ShowForm("Add Bookmark", "Save", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+	}, func(confirm bool) {
+		if !confirm {
+			return
+		}
+		b := tagging.Bookmark{
+			Name:    strings.TrimSpace(nameEntry.Text),
+			Path:    path,
+			Index:   index,
+			Random:  a.random,
+			Created: time.Now(),
+		}
+		if !filter.IsEmpty() {
+			data, err := json.Marshal(filter)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("failed to save the filter: %w", err), a.UI.MainWin)
+				return
+			}
+			b.Filter = data
+		}
+		if err := a.tagDB.SaveBookmark(a.ctx, b); err != nil {
+			dialog.ShowError(err, a.UI.MainWin)
+			return
+		}
+		a.addLogMessage(fmt.Sprintf("Bookmarked %s as '%s'.", filepath.Base(path), b.Name))
+		a.refreshBookmarksMenu()
+	}, a.UI.MainWin)
+}
+
+// showDeleteBookmarkDialog lets the user pick a bookmark to delete.
+func (a *App) showDeleteBookmarkDialog() {
+	bookmarks, err := a.tagDB.Bookmarks(a.ctx)
+	if err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+		return
+	}
+	if len(bookmarks) == 0 {
+		dialog.ShowInformation("Delete Bookmark", "There are no bookmarks.", a.UI.MainWin)
+		return
+	}
+	var names []string
+	for _, b := range bookmarks {
+		names = append(names, b.Name)
+	}
+	selector := widget.NewSelect(names, nil)
+	selector.SetSelected(names[0])
+	dialog.ShowForm("Delete Bookmark", "Delete", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Bookmark", selector),
+	}, func(confirm bool) {
+		if !confirm || selector.Selected == "" {
+			return
+		}
+		if err := a.tagDB.DeleteBookmark(a.ctx, selector.Selected); err != nil {
+			dialog.ShowError(err, a.UI.MainWin)
+			return
+		}
+		a.addLogMessage(fmt.Sprintf("Deleted bookmark '%s'.", selector.Selected))
+		a.refreshBookmarksMenu()
+	}, a.UI.MainWin)
+}
+
+// openBookmark restores a bookmark's filter and playback order and shows its
+// image, or the image now at its position if the image is gone.
+func (a *App) openBookmark(b tagging.Bookmark) {
+	var c query.Criteria
+	if len(b.Filter) > 0 {
+		if err := json.Unmarshal(b.Filter, &c); err != nil {
+			dialog.ShowError(fmt.Errorf("bookmark '%s' has an unreadable filter: %w", b.Name, err), a.UI.MainWin)
+			return
+		}
+	}
+	if a.random != b.Random {
+		a.toggleRandom()
+	}
+	a.addLogMessage(fmt.Sprintf("Opening bookmark '%s'.", b.Name))
+	a.startPath, a.startIndex = b.Path, b.Index
+	switch {
+	case !c.IsEmpty():
+		a.applyCriteria(c) // Shows the start image once the filter is in place
+	case a.isFiltered:
+		a.clearFilter()
+	default:
+		a.isNavigatingHistory = false
+		a.showIndex(a.takeStartIndex())
+	}
+}
+
+// takeStartIndex returns the position of the image a bookmark asked to start
+// at in the current list, and forgets the request. Without one, the list
+// resumes where it was last left, or starts at 0.
+func (a *App) takeStartIndex() int {
+	path, index := a.startPath, a.startIndex
+	a.startPath, a.startIndex = "", 0
+	if path == "" {
+		pos, ok := a.filterResumePoint()
+		if !ok {
+			return 0
+		}
+		path, index = pos.Path, pos.Index
+	}
+	list := a.getCurrentList()
+	for i, item := range list {
+		if item.Path == path {
+			return i
+		}
+	}
+	if len(list) == 0 {
+		return 0
+	}
+	return max(0, min(index, len(list)-1))
+}
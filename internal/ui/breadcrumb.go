@@ -0,0 +1,78 @@
+// Package ui Breadcrumb bar: the current image's folder above the image, each
+// segment filtering the slideshow to that folder's subtree.
+package ui
+
+import (
+	"fyslide/internal/query"
+	"path/filepath"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// folderAncestors returns dir and the folders above it, root first.
+func folderAncestors(dir string) []string {
+	var folders []string
+	for dir = filepath.Clean(dir); ; dir = filepath.Dir(dir) {
+		folders = append(folders, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	slices.Reverse(folders)
+	return folders
+}
+
+// buildBreadcrumbBar creates the bar showing the current image's folder.
+func (a *App) buildBreadcrumbBar() fyne.CanvasObject {
+	a.UI.breadcrumbs = container.NewHBox()
+	copyButton := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), a.copyImagePath)
+	copyButton.Importance = widget.LowImportance
+	return container.NewBorder(nil, nil, nil, copyButton, container.NewHScroll(a.UI.breadcrumbs))
+}
+
+// updateBreadcrumbs shows the folder of path in the breadcrumb bar.
+func (a *App) updateBreadcrumbs(path string) {
+	if a.UI.breadcrumbs == nil {
+		return
+	}
+	dir := filepath.Dir(path)
+	if dir == a.UI.breadcrumbDir {
+		return
+	}
+	a.UI.breadcrumbDir = dir
+	var segments []fyne.CanvasObject
+	for i, folder := range folderAncestors(dir) {
+		if i > 0 {
+			segments = append(segments, widget.NewIcon(theme.NavigateNextIcon()))
+		}
+		name := filepath.Base(folder)
+		if filepath.Dir(folder) == folder {
+			name = folder // A root, e.g. / or C:\
+		}
+		button := widget.NewButton(name, func() { a.filterToFolder(folder) })
+		button.Importance = widget.LowImportance
+		segments = append(segments, button)
+	}
+	a.UI.breadcrumbs.Objects = segments
+	a.UI.breadcrumbs.Refresh()
+}
+
+// filterToFolder filters the slideshow to the images in folder and below it,
+// remembering folder among the recent ones.
+func (a *App) filterToFolder(folder string) {
+	a.rememberFolder(folder)
+	a.applyCriteria(query.Criteria{Folder: folder})
+}
+
+// copyImagePath copies the full path of the current image to the clipboard.
+func (a *App) copyImagePath() {
+	if a.img.Path == "" {
+		return
+	}
+	a.app.Clipboard().SetContent(a.img.Path)
+	a.addLogMessage("Copied " + a.img.Path)
+}
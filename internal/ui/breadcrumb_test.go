@@ -0,0 +1,19 @@
+package ui
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFolderAncestors(t *testing.T) {
+	root := filepath.FromSlash("/")
+	dir := filepath.Join(root, "photos", "2024", "trip")
+	want := []string{root, filepath.Join(root, "photos"), filepath.Join(root, "photos", "2024"), dir}
+	if got := folderAncestors(dir); !slices.Equal(got, want) {
+		t.Errorf("folderAncestors(%s) = %v, want %v", dir, got, want)
+	}
+	if got := folderAncestors(root); !slices.Equal(got, []string{root}) {
+		t.Errorf("folderAncestors(%s) = %v", root, got)
+	}
+}
@@ -0,0 +1,168 @@
+// Package ui Card import (File > Import from Card): copies the images of a
+// camera card into date folders of the library, tags them and adds them to
+// the session.
+package ui
+
+import (
+	"context"
+	"fmt"
+	"fyslide/internal/cardimport"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// folderField returns an entry for a folder with a button to browse for it.
+func (a *App) folderField(entry *widget.Entry) fyne.CanvasObject {
+	browseBtn := widget.NewButton("Browse...", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err == nil && uri != nil {
+				entry.SetText(uri.Path())
+			}
+		}, a.UI.MainWin)
+	})
+	return container.NewBorder(nil, nil, nil, browseBtn, entry)
+}
+
+// showImportCardDialog asks for the card, the library folder and the tags of
+// the imported images, then runs the import.
+func (a *App) showImportCardDialog() {
+	if a.refuseInReadOnly("Import from Card") {
+		return
+	}
+	a.slideshowManager.Pause(true)
+	prefs := a.prefs()
+	required := func(what string) func(string) error {
+		return func(text string) error {
+			if strings.TrimSpace(text) == "" {
+				return fmt.Errorf("choose %s", what)
+			}
+			return nil
+		}
+	}
+
+	cardEntry := widget.NewEntry()
+	cardEntry.SetText(prefs.String(prefCardFolder))
+	cardEntry.SetPlaceHolder("Mounted card, e.g. /media/me/EOS_DIGITAL")
+	cardEntry.Validator = required("the card")
+	libraryEntry := widget.NewEntry()
+	library := prefs.String(prefCardLibrary)
+	if library == "" && len(a.libraryRoots) > 0 {
+		library = a.libraryRoots[0]
+	}
+	libraryEntry.SetText(library)
+	libraryEntry.SetPlaceHolder("Images go to YYYY/MM/DD folders in here")
+	libraryEntry.Validator = required("a library folder")
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetText(prefs.String(prefCardTags))
+	tagsEntry.SetPlaceHolder("Tags for the imported images, comma-separated")
+	deleteCheck := widget.NewCheck("Delete from the card after verifying the copies", nil)
+
+	d := dialog.NewForm("Import from Card", "Import", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Card", a.folderField(cardEntry)),
+		widget.NewFormItem("Library folder", a.folderField(libraryEntry)),
+		widget.NewFormItem("Tags", tagsEntry),
+		widget.NewFormItem("", deleteCheck),
+	}, func(confirm bool) {
+		if !confirm {
+			a.slideshowManager.ResumeAfterOperation()
+			return
+		}
+		card := strings.TrimSpace(cardEntry.Text)
+		opts := cardimport.Options{Library: strings.TrimSpace(libraryEntry.Text), DeleteFromCard: deleteCheck.Checked}
+		var tags []string
+		for _, tag := range strings.Split(tagsEntry.Text, ",") {
+			if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		prefs.SetString(prefCardFolder, card)
+		prefs.SetString(prefCardLibrary, opts.Library)
+		prefs.SetString(prefCardTags, strings.Join(tags, ", "))
+		a.runCardImport(card, opts, tags)
+	}, a.UI.MainWin)
+	d.Resize(fyne.NewSize(searchDialogWidth, d.MinSize().Height))
+	d.Show()
+}
+
+// runCardImport imports the card's images in the background behind a
+// progress dialog that can cancel the import.
+func (a *App) runCardImport(card string, opts cardimport.Options, tags []string) {
+	ctx, cancel := context.WithCancel(a.ctx)
+	bar := widget.NewProgressBar()
+	status := widget.NewLabel("Looking for images on the card...")
+	progress := dialog.NewCustom("Importing", "Cancel", container.NewVBox(status, bar), a.UI.MainWin)
+	progress.SetOnClosed(cancel)
+	progress.Resize(fyne.NewSize(searchDialogWidth, progress.MinSize().Height))
+	progress.Show()
+
+	a.goBackground(func() {
+		defer cancel()
+		sources, err := cardimport.Find(card)
+		var results []cardimport.Result
+		if err == nil {
+			results, err = cardimport.Run(ctx, sources, opts, a.tagDB, func(done, total int, r cardimport.Result) {
+				fyne.Do(func() {
+					bar.SetValue(float64(done) / float64(total))
+					status.SetText(fmt.Sprintf("%d of %d images", done, total))
+					if r.Err != nil {
+						a.addLogMessage(fmt.Sprintf("Import of %s failed: %v", r.Source, r.Err))
+					}
+				})
+			})
+		}
+		fyne.Do(func() {
+			progress.Hide()
+			a.slideshowManager.ResumeAfterOperation()
+			if err != nil && len(results) == 0 {
+				a.addLogMessage(fmt.Sprintf("Card import failed: %v", err))
+				dialog.ShowError(err, a.UI.MainWin)
+				return
+			}
+			a.finishCardImport(sources, results, tags)
+		})
+	})
+}
+
+// finishCardImport adds the copied images to the session, tags them and
+// reports the outcome.
+func (a *App) finishCardImport(sources []string, results []cardimport.Result, tags []string) {
+	var ops []tagOp
+	copied, skipped, failed, deleted := 0, 0, 0, 0
+	var last string
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+		case r.Skipped:
+			skipped++
+		default:
+			copied++
+			if a.addToSession(r.Target) {
+				last = r.Target
+			}
+			for _, tag := range tags {
+				ops = append(ops, tagOp{path: r.Target, tag: tag, add: true})
+			}
+		}
+		if r.Deleted {
+			deleted++
+		}
+	}
+	summary := fmt.Sprintf("Copied %d images, skipped %d already imported, %d failed, %d deleted from the card.", copied, skipped, failed, deleted)
+	if len(results) < len(sources) {
+		summary = fmt.Sprintf("Import stopped after %d of %d images. %s", len(results), len(sources), summary)
+	}
+	a.addLogMessage(summary)
+	if len(ops) > 0 {
+		a.submitTagJob(fmt.Sprintf("Tagging %d imported image(s)", copied), ops, nil)
+	}
+	if last != "" {
+		a.jumpToPath(last)
+	}
+	dialog.ShowInformation("Import from Card", summary, a.UI.MainWin)
+}
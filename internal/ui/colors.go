@@ -0,0 +1,130 @@
+// Package ui Color tags and the similar-image views, backed by the image signature cache.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/imagesig"
+	"fyslide/internal/iosched"
+	"fyslide/internal/query"
+	"fyslide/internal/scan"
+	"image"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// maxSimilarResults caps the images shown by Find Similar and Find Similar Colors.
+const maxSimilarResults = 100
+
+// openSignatureCache opens the signature cache, or returns nil (compute every
+// signature) when it is unavailable, e.g. held by another instance.
+func (a *App) openSignatureCache() *imagesig.Cache {
+	cache, err := imagesig.OpenCache("")
+	if err != nil {
+		fyne.Do(func() { a.addLogMessage(fmt.Sprintf("Signature cache unavailable: %v", err)) })
+		return nil
+	}
+	cache.SetDecoder(func(path string) (image.Image, error) {
+		img, _, err := a.decoder.Decode(a.ctx, iosched.Maintenance, path)
+		return img, err
+	})
+	return cache
+}
+
+// findSimilarColors shows the library's images colored like the current one,
+// closest first.
+func (a *App) findSimilarColors() {
+	if a.img.Path == "" {
+		return
+	}
+	a.applyCriteria(query.Criteria{SimilarColorsTo: a.img.Path})
+}
+
+// findSimilar shows the library's images that look like the current one, such
+// as other shots of the same scene, closest first.
+func (a *App) findSimilar() {
+	if a.img.Path == "" {
+		return
+	}
+	a.applyCriteria(query.Criteria{SimilarTo: a.img.Path})
+}
+
+// rankBySignature returns the items that rank ranks as similar to the image
+// at target, closest first, and how many items could not be read. Runs off the
+// UI goroutine.
+func (a *App) rankBySignature(target string, items scan.FileItems,
+	rank func(imagesig.Signature, map[string]imagesig.Signature, int) []imagesig.Match) (scan.FileItems, int) {
+	cache := a.openSignatureCache()
+	if cache != nil {
+		defer cache.Close()
+	}
+	targetSig, err := cache.Get(target)
+	if err != nil {
+		fyne.Do(func() { a.addLogMessage(fmt.Sprintf("Find similar: %v", err)) })
+		return nil, 0
+	}
+	sigs := make(map[string]imagesig.Signature, len(items))
+	byPath := make(map[string]scan.FileItem, len(items))
+	unreadable := 0
+	for _, item := range items {
+		if a.ctx.Err() != nil {
+			return nil, 0 // The window closed; nobody waits for the ranking
+		}
+		sig, err := cache.Get(item.Path)
+		if err != nil {
+			unreadable++
+			continue
+		}
+		sigs[item.Path] = sig
+		byPath[item.Path] = item
+	}
+	var ranked scan.FileItems
+	for _, m := range rank(targetSig, sigs, maxSimilarResults) {
+		ranked = append(ranked, byPath[m.Path])
+	}
+	return ranked, unreadable
+}
+
+// autoApplyColorTags tags the scanned images with their broad colors when
+// enabled in Preferences. Images already carrying a color tag are skipped.
+// Runs on the scanning goroutine.
+func (a *App) autoApplyColorTags(hidden map[string]bool) {
+	if !a.prefs().Bool(prefColorTagsAuto) || a.readOnly() {
+		return
+	}
+	cache := a.openSignatureCache()
+	if cache != nil {
+		defer cache.Close()
+	}
+	var ops []tagOp
+	failed := 0
+	for _, item := range a.images {
+		if hidden[item.Path] {
+			continue
+		}
+		current, err := a.tagDB.GetTags(a.ctx, item.Path)
+		hasColorTag := slices.ContainsFunc(current, func(tag string) bool { return strings.HasPrefix(tag, imagesig.ColorTagPrefix) })
+		if err != nil || hasColorTag {
+			continue
+		}
+		sig, err := cache.Get(item.Path)
+		if err != nil {
+			failed++
+			continue
+		}
+		for _, tag := range sig.ColorTags() {
+			if !slices.Contains(current, tag) {
+				ops = append(ops, tagOp{path: item.Path, tag: tag, add: true})
+			}
+		}
+	}
+	fyne.Do(func() {
+		if failed > 0 {
+			a.addLogMessage(fmt.Sprintf("Color tags: could not read %d image(s).", failed))
+		}
+		if len(ops) > 0 {
+			a.submitTagJob(fmt.Sprintf("Adding color tags to %d scanned image(s)", countImages(ops)), ops, nil)
+		}
+	})
+}
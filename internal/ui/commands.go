@@ -0,0 +1,242 @@
+// Package ui Command registry: the viewer's actions by name, shared by the
+// menus, the toolbar and the command palette.
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// uiCommand is an action of the viewer. Menus and the toolbar run it without
+// arguments; the palette passes what was typed after its name.
+type uiCommand struct {
+	Name     string                  // Typed in the palette, e.g. "tag add"
+	Title    string                  // Menu label, e.g. "Add Tag"
+	Usage    string                  // Arguments the palette takes, e.g. "[tag, ...]"; empty for none
+	Mutating bool                    // Changes images or tags, so it is disabled in read-only mode
+	Run      func(args string) error // Without arguments, commands that take some usually ask for them
+}
+
+// commandRegistry holds the commands in the order they were added.
+type commandRegistry struct {
+	commands []*uiCommand
+	byName   map[string]*uiCommand
+}
+
+func newCommandRegistry() *commandRegistry {
+	return &commandRegistry{byName: make(map[string]*uiCommand)}
+}
+
+// add registers c. Names are unique.
+func (r *commandRegistry) add(c *uiCommand) {
+	if _, ok := r.byName[c.Name]; ok {
+		panic("duplicate command " + c.Name)
+	}
+	r.commands = append(r.commands, c)
+	r.byName[c.Name] = c
+}
+
+// get returns the command called name, which must be registered.
+func (r *commandRegistry) get(name string) *uiCommand {
+	c, ok := r.byName[name]
+	if !ok {
+		panic("unknown command " + name)
+	}
+	return c
+}
+
+// parse splits palette input into the command it starts with, the longest
+// name matching whole words, and its arguments. ok is false when the input
+// does not start with a command name.
+func (r *commandRegistry) parse(input string) (c *uiCommand, args string, ok bool) {
+	input = strings.Join(strings.Fields(input), " ")
+	lower := strings.ToLower(input)
+	for _, candidate := range r.commands {
+		if (lower == candidate.Name || strings.HasPrefix(lower, candidate.Name+" ")) && (c == nil || len(candidate.Name) > len(c.Name)) {
+			c = candidate
+		}
+	}
+	if c == nil {
+		return nil, "", false
+	}
+	return c, strings.TrimSpace(input[len(c.Name):]), true
+}
+
+// match returns the commands whose name or title contains the letters of
+// pattern in order, best matches first. An empty pattern matches all.
+func (r *commandRegistry) match(pattern string) []*uiCommand {
+	type scored struct {
+		c     *uiCommand
+		score int
+	}
+	var matches []scored
+	for _, c := range r.commands {
+		score, ok := fuzzyScore(pattern, c.Name)
+		if titleScore, titleOK := fuzzyScore(pattern, c.Title); titleOK && (!ok || titleScore < score) {
+			score, ok = titleScore, true
+		}
+		if ok {
+			matches = append(matches, scored{c, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(x, y scored) int { return x.score - y.score })
+	result := make([]*uiCommand, len(matches))
+	for i, m := range matches {
+		result[i] = m.c
+	}
+	return result
+}
+
+// fuzzyScore reports whether text contains the letters of pattern in order,
+// ignoring case and spaces, and how well: lower is better. Letters skipped
+// count against a match, except at the start of a word, so "tar" finds
+// "tag remove" before "transform".
+func fuzzyScore(pattern, text string) (score int, ok bool) {
+	text = strings.ToLower(text)
+	pos := 0
+	for _, r := range strings.ToLower(pattern) {
+		if r == ' ' {
+			continue
+		}
+		i := strings.IndexRune(text[pos:], r)
+		if i < 0 {
+			return 0, false
+		}
+		if start := pos + i; start > 0 && text[start-1] == ' ' {
+			score += min(i, 1) // A new word costs less than skipped letters
+		} else {
+			score += i * 2
+		}
+		pos += i + 1
+	}
+	return score, true
+}
+
+// runCommand runs c with args, refusing changes in read-only mode and
+// showing its error, if any.
+func (a *App) runCommand(c *uiCommand, args string) {
+	if c.Mutating && a.refuseInReadOnly(c.Title) {
+		return
+	}
+	if err := c.Run(args); err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+	}
+}
+
+// commandMenuItem creates a menu item running the command called name,
+// disabled in read-only mode if it changes anything.
+func (a *App) commandMenuItem(name string) *fyne.MenuItem {
+	c := a.commands.get(name)
+	item := fyne.NewMenuItem(c.Title, func() { a.runCommand(c, "") })
+	item.Disabled = c.Mutating && a.readOnly()
+	return item
+}
+
+// commandToolbarAction creates a toolbar action running the command called
+// name, disabled in read-only mode if it changes anything.
+func (a *App) commandToolbarAction(icon fyne.Resource, name string) *widget.ToolbarAction {
+	c := a.commands.get(name)
+	action := widget.NewToolbarAction(icon, func() { a.runCommand(c, "") })
+	if c.Mutating && a.readOnly() {
+		action.Disable()
+	}
+	return action
+}
+
+// noArgs adapts an action taking no arguments to uiCommand.Run.
+func noArgs(action func()) func(args string) error {
+	return func(args string) error {
+		if args != "" {
+			return fmt.Errorf("this command takes no arguments")
+		}
+		action()
+		return nil
+	}
+}
+
+// registerCommands fills the command registry. Menus and the toolbar are
+// built from it afterwards.
+func (a *App) registerCommands() {
+	a.commands = newCommandRegistry()
+	for _, c := range []*uiCommand{
+		// Navigation and playback
+		{Name: "next", Title: "Next Image", Run: noArgs(func() { a.direction = 1; a.nextImage() })},
+		{Name: "previous", Title: "Previous Image", Run: noArgs(a.ShowPreviousImage)},
+		{Name: "first", Title: "First Image", Run: noArgs(a.firstImage)},
+		{Name: "last", Title: "Last Image", Run: noArgs(a.lastImage)},
+		{Name: "folder next", Title: "Next Folder", Run: noArgs(func() { a.showFolder(1) })},
+		{Name: "folder previous", Title: "Previous Folder", Run: noArgs(func() { a.showFolder(-1) })},
+		{Name: "goto", Title: "Go to Image...", Usage: "<number|part of a file name>", Run: a.gotoCommand},
+		{Name: "play", Title: "Play/Pause Slideshow", Run: noArgs(a.togglePlay)},
+		{Name: "random", Title: "Random Order On/Off", Run: noArgs(a.toggleRandom)},
+		{Name: "reshuffle", Title: "Reshuffle Now", Run: noArgs(a.reshuffle)},
+		{Name: "sort", Title: "Sort Current List", Usage: "<" + strings.Join(sortKeys, "|") + "> [asc|desc]", Run: a.sortCommand},
+		// Tags
+		{Name: "tag add", Title: "Add Tag", Usage: "[tag, ...]", Mutating: true, Run: a.tagAddCommand},
+		{Name: "tag remove", Title: "Remove Tag", Usage: "[tag, ...]", Mutating: true, Run: a.tagRemoveCommand},
+		{Name: "tag reapply", Title: "Reapply Last Tags", Mutating: true, Run: noArgs(a.reapplyLastTags)},
+		{Name: "tag copy", Title: "Copy Tags", Run: noArgs(a.copyTags)},
+		{Name: "tag paste", Title: "Paste Tags", Mutating: true, Run: noArgs(a.pasteTags)},
+		{Name: "tag copy previous", Title: "Copy Tags from Previous Image", Mutating: true, Run: noArgs(a.copyTagsFromPrevious)},
+		{Name: "tag set", Title: "Apply Tag Set", Usage: "[name]", Mutating: true, Run: a.tagSetCommand},
+		{Name: "tag folder defaults", Title: "Apply Folder Default Tags", Mutating: true, Run: noArgs(func() { a.applyDirDefaults(false) })},
+		// Filtering and finding
+		{Name: "filter", Title: "Filter Images...", Usage: "[tag AND tag... | key=value, ...]", Run: a.filterCommand},
+		{Name: "filter folder", Title: "Filter by Folder...", Usage: "[folder]", Run: a.filterFolderCommand},
+		{Name: "filter clear", Title: "Clear Filter", Run: noArgs(a.clearFilter)},
+		{Name: "search", Title: "Search...", Run: noArgs(a.showSearchDialog)},
+		{Name: "timeline", Title: "Timeline...", Run: noArgs(a.showTimeline)},
+		{Name: "exif index", Title: "Index EXIF Data", Run: noArgs(a.indexEXIF)},
+		{Name: "similar", Title: "Find Similar Images", Run: noArgs(a.findSimilar)},
+		{Name: "similar colors", Title: "Find Similar Colors", Run: noArgs(a.findSimilarColors)},
+		{Name: "bookmark add", Title: "Add Bookmark...", Run: noArgs(a.showAddBookmarkDialog)},
+		{Name: "view link copy", Title: "Copy View Link", Run: noArgs(a.copyViewLink)},
+		{Name: "view link open", Title: "Open View Link...", Run: noArgs(a.showOpenViewLinkDialog)},
+		// Stacks
+		{Name: "stack mark", Title: "Mark/Unmark for Stack", Run: noArgs(a.toggleStackMark)},
+		{Name: "stack marked", Title: "Stack Marked Images", Mutating: true, Run: noArgs(a.stackMarked)},
+		{Name: "unstack", Title: "Unstack", Mutating: true, Run: noArgs(a.unstack)},
+		{Name: "stack bursts", Title: "Auto-Stack Bursts...", Mutating: true, Run: noArgs(a.showAutoStackDialog)},
+		{Name: "stack expand", Title: "Expand/Collapse Stack", Run: noArgs(a.toggleStackExpanded)},
+		// Files
+		{Name: "rename", Title: "Rename File...", Mutating: true, Run: noArgs(a.showRenameDialog)},
+		{Name: "edit date", Title: "Edit Date and Title...", Mutating: true, Run: noArgs(a.showEditEXIFDialog)},
+		{Name: "correct dates", Title: "Correct Dates...", Mutating: true, Run: noArgs(a.showCorrectDatesDialog)},
+		{Name: "transform", Title: "Transform Scans...", Mutating: true, Run: noArgs(a.showTransformDialog)},
+		{Name: "quality", Title: "Check Image Quality", Mutating: true, Run: noArgs(a.checkImageQuality)},
+		{Name: "ocr", Title: "Extract Text (OCR)", Mutating: true, Run: noArgs(a.extractText)},
+		{Name: "annotate", Title: "Annotate", Mutating: true, Run: noArgs(a.toggleAnnotating)},
+		{Name: "paste", Title: "Paste Image", Mutating: true, Run: noArgs(a.pasteImage)},
+		{Name: "edit external", Title: "Open in External Editor", Mutating: true, Run: noArgs(a.openInExternalEditor)},
+		{Name: "delete", Title: "Delete Image", Mutating: true, Run: noArgs(a.deleteFileCheck)},
+		{Name: "delete filtered", Title: "Delete All in Filter...", Mutating: true, Run: noArgs(a.showDeleteFilteredDialog)},
+		{Name: "trash", Title: "Trash...", Run: noArgs(a.showTrashDialog)},
+		{Name: "problem files", Title: "Problem Files...", Run: noArgs(a.showProblemFilesDialog)},
+		{Name: "export resized", Title: "Export Resized Copies...", Run: noArgs(a.showExportDialog)},
+		{Name: "export annotated", Title: "Export Annotated Copy...", Run: noArgs(a.exportAnnotatedCopy)},
+		{Name: "rescan", Title: "Rescan and Reconcile", Run: noArgs(a.rescanLibrary)},
+		{Name: "import card", Title: "Import from Card...", Mutating: true, Run: noArgs(a.showImportCardDialog)},
+		// Display
+		{Name: "full resolution", Title: "Load Full Resolution", Run: noArgs(a.loadFullResolution)},
+		{Name: "enhance", Title: "Auto-Enhance Preview", Run: noArgs(a.toggleAutoEnhance)},
+		{Name: "annotations", Title: "Show/Hide Annotations", Run: noArgs(a.toggleShowAnnotations)},
+		{Name: "loupe", Title: "Loupe", Run: noArgs(a.toggleLoupe)},
+		{Name: "thumbnails", Title: "Toggle Thumbnail Strip", Run: noArgs(a.toggleThumbStrip)},
+		{Name: "private unlock", Title: "Unlock Private Images...", Run: noArgs(a.showUnlockPrivateDialog)},
+		{Name: "private lock", Title: "Lock Private Images", Run: noArgs(a.lockPrivateImages)},
+		// Application
+		{Name: "history", Title: "Change History...", Run: noArgs(a.showAuditLogDialog)},
+		{Name: "usage", Title: "Usage Statistics...", Run: noArgs(a.showUsageDialog)},
+		{Name: "preferences", Title: "Preferences...", Run: noArgs(a.showPreferencesDialog)},
+		{Name: "shortcuts", Title: "Keyboard Shortucts", Run: noArgs(a.showShortcuts)},
+		{Name: "help", Title: "Help", Run: noArgs(a.showHelpDialog)},
+		{Name: "quit", Title: "Quit", Run: noArgs(a.quit)},
+	} {
+		a.commands.add(c)
+	}
+}
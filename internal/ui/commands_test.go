@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommandRegistry(t *testing.T) {
+	a := &App{}
+	a.registerCommands() // Panics on duplicate names
+	for _, c := range a.commands.commands {
+		if c.Title == "" || c.Run == nil {
+			t.Errorf("command %q lacks a title or an action", c.Name)
+		}
+	}
+
+	for _, tc := range []struct {
+		input, name, args string
+	}{
+		{"tag add beach", "tag add", "beach"},
+		{"  Tag   Add  beach, Sea ", "tag add", "beach, Sea"},
+		{"filter sunset AND 2023", "filter", "sunset AND 2023"},
+		{"filter clear", "filter clear", ""},
+		{"tag copy", "tag copy", ""},
+		{"tag copy previous", "tag copy previous", ""},
+		{"goto 1523", "goto", "1523"},
+		{"sort date desc", "sort", "date desc"},
+		{"tagadd beach", "", ""},
+		{"tag", "", ""},
+	} {
+		c, args, ok := a.commands.parse(tc.input)
+		switch {
+		case tc.name == "" && ok:
+			t.Errorf("parse(%q) = %q, want no command", tc.input, c.Name)
+		case tc.name != "" && (!ok || c.Name != tc.name || args != tc.args):
+			t.Errorf("parse(%q) = %v, %q, %v; want %q, %q", tc.input, c, args, ok, tc.name, tc.args)
+		}
+	}
+
+	names := func(cs []*uiCommand) []string {
+		var result []string
+		for _, c := range cs {
+			result = append(result, c.Name)
+		}
+		return result
+	}
+	if got := names(a.commands.match("tar")); len(got) == 0 || got[0] != "tag remove" || slices.Index(got, "transform") >= 0 && slices.Index(got, "transform") < slices.Index(got, "tag remove") {
+		t.Errorf("match(tar) = %v, want tag remove first", got)
+	}
+	if got := names(a.commands.match("Similar Colors")); len(got) == 0 || got[0] != "similar colors" {
+		t.Errorf("match(Similar Colors) = %v, want the title match first", got)
+	}
+	if got := a.commands.match("zzz"); len(got) != 0 {
+		t.Errorf("match(zzz) = %v, want nothing", names(got))
+	}
+	if got := a.commands.match(""); len(got) != len(a.commands.commands) {
+		t.Errorf("match(\"\") found %d of %d commands", len(got), len(a.commands.commands))
+	}
+}
+
+func TestParsePaletteFilter(t *testing.T) {
+	c, err := parsePaletteFilter("Sunset AND 2023 and beach, sunset")
+	if err != nil || !slices.Equal(c.Tags, []string{"sunset", "2023", "beach"}) {
+		t.Errorf("parsePaletteFilter = %+v, %v", c, err)
+	}
+	c, err = parsePaletteFilter("tag=sunset, from=2023-01-01")
+	if err != nil || !slices.Equal(c.Tags, []string{"sunset"}) || c.From.IsZero() {
+		t.Errorf("parsePaletteFilter of query terms = %+v, %v", c, err)
+	}
+	for _, bad := range []string{"sunset OR beach", "not beach", ", ,"} {
+		if _, err := parsePaletteFilter(bad); err == nil {
+			t.Errorf("parsePaletteFilter(%q) accepted it", bad)
+		}
+	}
+}
+
+func TestParseSortArgs(t *testing.T) {
+	if key, desc, err := parseSortArgs("Date DESC"); err != nil || key != "date" || !desc {
+		t.Errorf("parseSortArgs(Date DESC) = %q, %v, %v", key, desc, err)
+	}
+	if key, desc, err := parseSortArgs("name"); err != nil || key != "name" || desc {
+		t.Errorf("parseSortArgs(name) = %q, %v, %v", key, desc, err)
+	}
+	for _, bad := range []string{"", "colour", "date up", "date desc now"} {
+		if _, _, err := parseSortArgs(bad); err == nil {
+			t.Errorf("parseSortArgs(%q) accepted it", bad)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Package ui Settings from config.yaml and the environment, below command-line flags.
+package ui
+
+import (
+	"flag"
+	"fmt"
+	"fyslide/internal/config"
+	"fyslide/internal/deeplink"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// libraryRoots returns the folders to scan: the one named on the command
+// line, else the configured library roots, else the working directory. The
+// command line may name an image, or link to one with a fyslide:// link, to
+// scan its folder and show it first, or give a fyslide://view link, as an
+// argument or with -view, to scan its folders and reproduce the view. start
+// is what to show first, or nil for the usual shuffled slideshow.
+func libraryRoots(cfg *config.Config) (roots []string, start *deeplink.View, err error) {
+	arg := flag.Arg(0)
+	if *viewFlag != "" {
+		if arg != "" {
+			return nil, nil, fmt.Errorf("give either a folder or image, or -view, not both")
+		}
+		arg = *viewFlag
+	}
+	switch {
+	case deeplink.IsView(arg):
+		v, err := deeplink.ParseView(arg)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, root := range v.Roots {
+			if _, err := os.Stat(root); err != nil {
+				return nil, nil, fmt.Errorf("error while opening '%s': %w", root, err)
+			}
+		}
+		roots, start = slices.Clone(v.Roots), &v
+	case arg != "":
+		target, err := deeplink.Target(arg)
+		if err != nil {
+			return nil, nil, err
+		}
+		info, err := os.Stat(target)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while opening '%s': %w", target, err)
+		}
+		root := target
+		if !info.IsDir() {
+			root, start = filepath.Dir(target), &deeplink.View{Path: target}
+		}
+		roots = []string{root}
+	}
+	if len(roots) == 0 {
+		roots = append(roots, cfg.Library.Roots...)
+	}
+	if len(roots) == 0 {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while opening the directory: %w", err)
+		}
+		roots = []string{wd}
+	}
+	for i, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting absolute path of %s: %w", root, err)
+		}
+		roots[i] = abs
+	}
+	return roots, start, nil
+}
+
+// flagGiven reports whether the flag name was set on the command line.
+func flagGiven(name string) bool {
+	given := false
+	flag.Visit(func(f *flag.Flag) { given = given || f.Name == name })
+	return given
+}
+
+// applyConfigDefaults gives the playback flags not set on the command line
+// the values of the config file or the environment.
+func applyConfigDefaults(cfg *config.Config) {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["slideshow-interval"] && cfg.Slideshow.Interval > 0 {
+		*slideshowIntervalFlag = cfg.Slideshow.Interval
+	}
+	if !set["history-size"] && cfg.Slideshow.HistorySize != nil {
+		*historySizeFlag = *cfg.Slideshow.HistorySize
+	}
+	if !set["skip-count"] && cfg.Slideshow.SkipCount > 0 {
+		*skipCountFlag = cfg.Slideshow.SkipCount
+	}
+}
@@ -0,0 +1,138 @@
+// Package ui Copy and paste tags: the tags of an image, and optionally its
+// EXIF title, are stamped onto other images, such as the shots of a burst
+// following the one tagged first.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/metadata"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// copiedTags is what Copy Tags took from an image.
+type copiedTags struct {
+	source string
+	tags   []string
+	title  *string // EXIF title, if copying includes it and the source has one
+}
+
+// describe names what c holds, e.g. "[sunset, beach] and the title".
+func (c copiedTags) describe() string {
+	s := "[" + strings.Join(c.tags, ", ") + "]"
+	if c.title != nil {
+		s += " and the title"
+	}
+	return s
+}
+
+// readCopiedTags takes the tags of the image at path, including those still
+// being saved, and its EXIF title if Preferences include it.
+func (a *App) readCopiedTags(path string) (copiedTags, error) {
+	tags, err := a.tagDB.GetTags(a.ctx, path)
+	if err != nil {
+		return copiedTags{}, fmt.Errorf("failed to get the tags of %s: %w", filepath.Base(path), err)
+	}
+	c := copiedTags{source: path, tags: a.withPendingTags(path, tags)}
+	if a.prefs().Bool(prefCopyTitle) && metadata.EXIFSupported(path) {
+		if fields, err := metadata.ReadEXIF(path); err == nil && fields.Title != "" {
+			c.title = &fields.Title
+		}
+	}
+	return c, nil
+}
+
+// copyTags keeps the tags of the current image for Paste Tags, for
+// Ctrl+Shift+C.
+func (a *App) copyTags() {
+	if a.img.Path == "" {
+		dialog.ShowInformation("Copy Tags", "No image loaded to copy tags from.", a.UI.MainWin)
+		return
+	}
+	c, err := a.readCopiedTags(a.img.Path)
+	if err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+		return
+	}
+	if len(c.tags) == 0 && c.title == nil {
+		dialog.ShowInformation("Copy Tags", fmt.Sprintf("%s has no tags to copy.", filepath.Base(a.img.Path)), a.UI.MainWin)
+		return
+	}
+	a.copiedTags = &c
+	a.addLogMessage(fmt.Sprintf("Copied %s of %s.", c.describe(), filepath.Base(c.source)))
+}
+
+// pasteTags adds the tags taken by Copy Tags to the current image, for
+// Ctrl+Shift+V.
+func (a *App) pasteTags() {
+	if a.refuseInReadOnly("Paste Tags") {
+		return
+	}
+	if a.img.Path == "" {
+		dialog.ShowInformation("Paste Tags", "No image loaded to tag.", a.UI.MainWin)
+		return
+	}
+	if a.copiedTags == nil {
+		dialog.ShowInformation("Paste Tags", "No tags were copied yet. Edit > Copy Tags copies those of the current image.", a.UI.MainWin)
+		return
+	}
+	if err := a.stampTags("Pasting tag(s)", *a.copiedTags); err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+	}
+}
+
+// copyTagsFromPrevious adds the tags of the image before the current one in
+// the list to it, for Ctrl+Shift+D: after tagging the first shot of a burst,
+// each following shot takes them in one keystroke.
+func (a *App) copyTagsFromPrevious() {
+	if a.refuseInReadOnly("Copy Tags from Previous Image") {
+		return
+	}
+	list := a.getCurrentList()
+	if a.img.Path == "" || a.index <= 0 || a.index >= len(list) {
+		dialog.ShowInformation("Copy Tags from Previous Image", "There is no image before this one in the list.", a.UI.MainWin)
+		return
+	}
+	c, err := a.readCopiedTags(list[a.index-1].Path)
+	if err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+		return
+	}
+	if len(c.tags) == 0 && c.title == nil {
+		a.addLogMessage(fmt.Sprintf("%s has no tags to copy.", filepath.Base(c.source)))
+		return
+	}
+	if err := a.stampTags("Copying tag(s)", c); err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+	}
+}
+
+// stampTags adds the tags of c the current image lacks, keeping its others,
+// and sets its title to that of c if c holds one. verb starts the log
+// message, e.g. "Pasting tag(s)".
+func (a *App) stampTags(verb string, c copiedTags) error {
+	if len(c.tags) > 0 {
+		if err := a.addTagsToCurrentImage(verb, c.tags); err != nil {
+			return err
+		}
+	}
+	if c.title == nil {
+		return nil
+	}
+	path := a.img.Path
+	if !metadata.EXIFSupported(path) {
+		a.addLogMessage(fmt.Sprintf("The title was not copied: only JPEG files can store one, and %s is not one.", filepath.Base(path)))
+		return nil
+	}
+	if current, err := metadata.ReadEXIF(path); err == nil && current.Title == *c.title {
+		return nil
+	}
+	if err := a.service.SetEXIF(a.ctx, path, metadata.EXIFUpdate{Title: c.title}); err != nil {
+		return fmt.Errorf("failed to set the title of %s: %w", filepath.Base(path), err)
+	}
+	a.addLogMessage(fmt.Sprintf("Copied the title of %s to %s.", filepath.Base(c.source), filepath.Base(path)))
+	a.reloadEditedFile(path)
+	return nil
+}
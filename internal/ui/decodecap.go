@@ -0,0 +1,56 @@
+// Package ui Decode size cap: gigantic images are downscaled for display.
+package ui
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"strconv"
+)
+
+// decodeCapOff disables the decode size cap.
+const decodeCapOff = "Off"
+
+// decodeCapOptions lists the choices for the largest decoded edge, in pixels.
+var decodeCapOptions = []string{decodeCapOff, "2048", "4096", "8192"}
+
+// maxDecodeDimension returns the longest edge images are kept at after
+// decoding, or 0 to keep every image at full resolution.
+func (a *App) maxDecodeDimension() int {
+	n := a.prefs().IntWithFallback(prefMaxDecodeDimension, 0)
+	return max(n, 0)
+}
+
+// decodeCapLabel returns the decodeCapOptions entry of the current cap.
+func (a *App) decodeCapLabel() string {
+	if n := a.maxDecodeDimension(); n > 0 {
+		return strconv.Itoa(n)
+	}
+	return decodeCapOff
+}
+
+// capDecoded downscales img so that its longest edge is at most maxEdge
+// pixels. A maxEdge of 0 returns img unchanged.
+func capDecoded(img image.Image, maxEdge int) image.Image {
+	if maxEdge <= 0 {
+		return img
+	}
+	return scaleToFit(img, maxEdge)
+}
+
+// downscaled reports whether the displayed image is smaller than its file.
+func (i Img) downscaled() bool {
+	return i.OriginalImage != nil && i.OriginalImage.Bounds().Size() != i.FullSize
+}
+
+// loadFullResolution redisplays the current image without the decode size cap.
+func (a *App) loadFullResolution() {
+	if !a.img.downscaled() {
+		a.addLogMessage("The current image is already shown at full resolution.")
+		return
+	}
+	a.slideshowManager.Pause(false) // Pixel-peeping: don't move on underneath the user
+	a.fullResPath = a.img.Path
+	a.addLogMessage(fmt.Sprintf("Loading %s at full resolution (%dx%d).", filepath.Base(a.img.Path), a.img.FullSize.X, a.img.FullSize.Y))
+	a.showIndex(a.index)
+}
@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"image"
+	"testing"
+)
+
+func TestCapDecoded(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 8000, 2000))
+
+	if got := capDecoded(src, 0); got != src {
+		t.Error("capDecoded without a cap changed the image")
+	}
+	if got := capDecoded(src, 4096).Bounds().Size(); got != image.Pt(4096, 1024) {
+		t.Errorf("capDecoded to 4096 = %v, want 4096x1024", got)
+	}
+	if got := capDecoded(src, 8192); got != src {
+		t.Error("capDecoded enlarged or copied an image within the cap")
+	}
+
+	img := Img{OriginalImage: capDecoded(src, 4096), FullSize: src.Bounds().Size()}
+	if !img.downscaled() {
+		t.Error("downscaled = false for a capped image")
+	}
+	img.OriginalImage = src
+	if img.downscaled() {
+		t.Error("downscaled = true for a full resolution image")
+	}
+}
@@ -0,0 +1,144 @@
+// Package ui Per-folder default tags from .fyslide-tags files.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/dirtags"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// dirDefaultsOps turns planned changes into tag operations, leaving out hidden images.
+func dirDefaultsOps(changes []dirtags.Change, hidden map[string]bool) []tagOp {
+	var ops []tagOp
+	for _, change := range changes {
+		if hidden[change.Path] {
+			continue
+		}
+		for _, tag := range change.Tags {
+			ops = append(ops, tagOp{path: change.Path, tag: tag, add: true})
+		}
+	}
+	return ops
+}
+
+// countImages returns the number of distinct images ops touch.
+func countImages(ops []tagOp) int {
+	seen := make(map[string]bool)
+	for _, op := range ops {
+		seen[op.path] = true
+	}
+	return len(seen)
+}
+
+// buildDirDefaultsBanner creates the hidden banner offering a folder's default tags.
+func (a *App) buildDirDefaultsBanner() fyne.CanvasObject {
+	a.UI.dirDefaultsLabel = widget.NewLabel("")
+	a.UI.dirDefaultsLabel.Wrapping = fyne.TextWrapWord
+	apply := widget.NewButton("Apply", func() { a.applyDirDefaults(true) })
+	dismiss := widget.NewButton("Dismiss", func() {
+		a.dirDefaultsDismissed[a.dirDefaultsFolder] = true
+		a.UI.dirDefaultsBanner.Hide()
+	})
+	a.UI.dirDefaultsBanner = container.NewBorder(nil, nil, nil, container.NewHBox(apply, dismiss), a.UI.dirDefaultsLabel)
+	a.UI.dirDefaultsBanner.Hide()
+	return a.UI.dirDefaultsBanner
+}
+
+// checkDirDefaults shows the banner when the folder of the image on screen
+// declares default tags that some of its untagged images lack. Each folder is
+// checked once per visit; the folder is read off the UI goroutine.
+func (a *App) checkDirDefaults(path string) {
+	if a.UI.dirDefaultsBanner == nil || a.readOnly() {
+		return
+	}
+	folder := filepath.Dir(path)
+	if folder == a.dirDefaultsFolder {
+		return
+	}
+	a.dirDefaultsFolder = folder
+	a.UI.dirDefaultsBanner.Hide()
+	if a.dirDefaultsDismissed[folder] {
+		return
+	}
+	hidden := a.lockedPrivatePaths()
+	a.goBackground(func() {
+		defaults, err := dirtags.Load(folder)
+		if err != nil || len(defaults) == 0 {
+			return
+		}
+		changes, err := dirtags.Plan(a.ctx, folder, a.tagDB.GetTags, dirtags.Options{OnlyUntagged: true})
+		if a.ctx.Err() != nil {
+			return // The window closed while the folder was read
+		}
+		if err != nil {
+			fyne.Do(func() { a.addLogMessage(fmt.Sprintf("Folder default tags: %v", err)) })
+			return
+		}
+		ops := dirDefaultsOps(changes, hidden)
+		fyne.Do(func() {
+			if len(ops) == 0 || folder != a.dirDefaultsFolder {
+				return
+			}
+			a.UI.dirDefaultsLabel.SetText(fmt.Sprintf("This folder's default tags are %s. Apply them to its %d untagged image(s)?",
+				strings.Join(defaults, ", "), countImages(ops)))
+			a.UI.dirDefaultsBanner.Show()
+		})
+	})
+}
+
+// applyDirDefaults adds the default tags of the current image's folder to its
+// images: only the untagged ones when offered by the banner, all of them when
+// asked for from the menu.
+func (a *App) applyDirDefaults(onlyUntagged bool) {
+	if a.refuseInReadOnly("Apply Folder Default Tags") || a.img.Path == "" {
+		return
+	}
+	folder := filepath.Dir(a.img.Path)
+	changes, err := dirtags.Plan(a.ctx, folder, a.tagDB.GetTags, dirtags.Options{OnlyUntagged: onlyUntagged})
+	if err != nil {
+		a.addLogMessage(fmt.Sprintf("Folder default tags: %v", err))
+		return
+	}
+	if a.UI.dirDefaultsBanner != nil {
+		a.UI.dirDefaultsBanner.Hide()
+	}
+	ops := dirDefaultsOps(changes, a.lockedPrivatePaths())
+	if len(ops) == 0 {
+		a.addLogMessage(fmt.Sprintf("No images in %s are missing its default tags (see %s).", filepath.Base(folder), dirtags.FileName))
+		return
+	}
+	a.submitTagJob(fmt.Sprintf("Applying folder default tags to %d image(s)", countImages(ops)), ops, nil)
+}
+
+// autoApplyDirDefaults tags the scanned images with their folders' defaults
+// when enabled in Preferences. Runs on the scanning goroutine.
+func (a *App) autoApplyDirDefaults(hidden map[string]bool) {
+	if !a.prefs().Bool(prefDirDefaultsAuto) || a.readOnly() {
+		return
+	}
+	var folders []string
+	for _, item := range a.images {
+		folders = append(folders, filepath.Dir(item.Path))
+	}
+	slices.Sort(folders)
+	var ops []tagOp
+	for _, folder := range slices.Compact(folders) {
+		changes, err := dirtags.Plan(a.ctx, folder, a.tagDB.GetTags, dirtags.Options{})
+		if err != nil {
+			fyne.Do(func() { a.addLogMessage(fmt.Sprintf("Folder default tags: %v", err)) })
+			continue
+		}
+		ops = append(ops, dirDefaultsOps(changes, hidden)...)
+	}
+	if len(ops) > 0 {
+		fyne.Do(func() {
+			a.submitTagJob(fmt.Sprintf("Applying folder default tags to %d scanned image(s)", countImages(ops)), ops, nil)
+		})
+	}
+}
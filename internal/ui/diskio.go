@@ -0,0 +1,17 @@
+// Package ui Disk scheduling: reads for the displayed image come before
+// background work, which can be held to a throughput cap for slow disks.
+package ui
+
+// maxBackgroundIOLimit is the largest cap, in MB/s, accepted in Preferences.
+const maxBackgroundIOLimit = 1000
+
+// backgroundIOLimit returns the throughput cap of background disk reads in
+// MB/s, or 0 for none.
+func (a *App) backgroundIOLimit() int {
+	return max(a.prefs().IntWithFallback(prefBackgroundIOLimit, 0), 0)
+}
+
+// applyBackgroundIOLimit passes the cap preference on to the scheduler.
+func (a *App) applyBackgroundIOLimit() {
+	a.io.SetLimit(int64(a.backgroundIOLimit()) << 20)
+}
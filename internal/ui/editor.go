@@ -0,0 +1,193 @@
+// Package ui External editor integration with optional reload on save.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/scan"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+const (
+	// editorPollInterval is how often a watched file is checked for changes.
+	editorPollInterval = time.Second
+	// editorWatchTimeout stops watching a file the editor never saved.
+	editorWatchTimeout = 30 * time.Minute
+)
+
+// splitCommandLine splits a command template into arguments. Double or single
+// quotes group words containing spaces; there are no escape sequences.
+func splitCommandLine(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// buildEditorCommand expands the editor template for path. %f is replaced by the
+// file path; if the template has no %f the path is appended as the last argument.
+func buildEditorCommand(template, path string) ([]string, error) {
+	args, err := splitCommandLine(template)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no external editor configured")
+	}
+	substituted := false
+	for i, arg := range args {
+		if strings.Contains(arg, "%f") {
+			args[i] = strings.ReplaceAll(arg, "%f", path)
+			substituted = true
+		}
+	}
+	if !substituted {
+		args = append(args, path)
+	}
+	return args, nil
+}
+
+// openInExternalEditor launches the configured editor on the current image and,
+// if enabled, watches the file to reload it when the editor saves.
+func (a *App) openInExternalEditor() {
+	if a.refuseInReadOnly("External Editor") {
+		return
+	}
+	path := a.img.Path
+	if path == "" {
+		dialog.ShowInformation("External Editor", "No image loaded to edit.", a.UI.MainWin)
+		return
+	}
+	template := a.editorCommand()
+	if template == "" {
+		dialog.ShowInformation("External Editor", "No external editor configured. Set one in File > Preferences.", a.UI.MainWin)
+		return
+	}
+	args, err := buildEditorCommand(template, path)
+	if err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+		return
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		a.addLogMessage(fmt.Sprintf("Error starting editor %s: %v", args[0], err))
+		dialog.ShowError(fmt.Errorf("failed to start editor: %w", err), a.UI.MainWin)
+		return
+	}
+	go cmd.Wait() //nolint:errcheck // Reap the process; its exit status is irrelevant
+	a.slideshowManager.Pause(false)
+	a.updateStatusBar()
+	a.addLogMessage(fmt.Sprintf("Opened %s in %s.", filepath.Base(path), filepath.Base(args[0])))
+
+	if a.prefs().Bool(prefEditorWatch) {
+		a.watchEditedFile(path)
+	}
+}
+
+// watchEditedFile polls path and reloads it after each save until the watch times
+// out. Starting a new watch replaces the previous one.
+func (a *App) watchEditedFile(path string) {
+	if a.editorWatchStop != nil {
+		close(a.editorWatchStop)
+	}
+	stop := make(chan struct{})
+	a.editorWatchStop = stop
+
+	initial, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(editorPollInterval)
+		defer ticker.Stop()
+		deadline := time.After(editorWatchTimeout)
+		last := initial
+		changed := false
+		for {
+			select {
+			case <-stop:
+				return
+			case <-deadline:
+				return
+			case <-ticker.C:
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				continue // The editor may be replacing the file; try again
+			}
+			if info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				if changed { // Stable for one interval after a change: the save is complete
+					changed = false
+					fyne.Do(func() { a.reloadEditedFile(path) })
+				}
+				continue
+			}
+			last = info
+			changed = true
+		}
+	}()
+}
+
+// reloadEditedFile refreshes cached data for path and redisplays it if it's still shown.
+func (a *App) reloadEditedFile(path string) {
+	a.thumbnailManager.Forget(path)
+	if a.thumbStrip != nil {
+		for _, slot := range a.thumbStrip.slots {
+			if slot.path == path {
+				slot.path = "" // Force Update to fetch the new thumbnail
+			}
+		}
+		a.thumbStrip.Update()
+	}
+	item := a.getCurrentItem()
+	if item == nil || item.Path != path {
+		return
+	}
+	if info, err := os.Stat(path); err == nil { // Size and mtime shown in the info panel changed
+		for _, list := range []scan.FileItems{a.images, a.filteredImages} {
+			for i := range list {
+				if list[i].Path == path {
+					list[i].Info = info
+				}
+			}
+		}
+	}
+	a.addLogMessage(fmt.Sprintf("%s changed on disk; reloading.", filepath.Base(path)))
+	a.showIndex(a.index)
+}
@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildEditorCommand(t *testing.T) {
+	tests := []struct {
+		template string
+		want     []string
+	}{
+		{"gimp %f", []string{"gimp", "/pics/a b.jpg"}},
+		{"gimp", []string{"gimp", "/pics/a b.jpg"}},
+		{`"/opt/My Editor/edit" --open=%f -n`, []string{"/opt/My Editor/edit", "--open=/pics/a b.jpg", "-n"}},
+		{"  darktable   '%f'  ", []string{"darktable", "/pics/a b.jpg"}},
+	}
+	for _, tt := range tests {
+		got, err := buildEditorCommand(tt.template, "/pics/a b.jpg")
+		if err != nil {
+			t.Errorf("buildEditorCommand(%q) error: %v", tt.template, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("buildEditorCommand(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestBuildEditorCommandErrors(t *testing.T) {
+	for _, template := range []string{"", "   ", `gimp "%f`} {
+		if _, err := buildEditorCommand(template, "/a.jpg"); err == nil {
+			t.Errorf("buildEditorCommand(%q) should fail", template)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Package ui Event stream for home-automation integrations (--events).
+package ui
+
+import (
+	"fyslide/internal/events"
+	"log"
+
+	"fyne.io/fyne/v2"
+)
+
+// startEvents creates the event bus UI actions publish to and, with --events,
+// starts broadcasting it to WebSocket clients. Must run after init.
+func (a *App) startEvents(url string) {
+	a.events = events.NewBus()
+	a.stopHooks = events.RunHooks(a.events, a.config.HookCommands(), func(message string) {
+		fyne.Do(func() { a.addLogMessage(message) })
+	})
+	a.slideshowManager.SetOnPausedChanged(func(paused bool) {
+		ev := events.Event{Type: events.Resumed}
+		if paused {
+			ev.Type = events.Paused
+		}
+		a.events.Publish(ev)
+	})
+	a.watchFilterCounts()
+	if url == "" {
+		return
+	}
+	server, err := events.ListenWebSocket(url, a.events)
+	if err != nil {
+		log.Fatalf("Failed to start the event stream: %v", err)
+	}
+	a.eventServer = server
+	log.Printf("Broadcasting events on ws://%s", server.Addr())
+}
+
+// stopEvents disconnects the event stream's clients.
+func (a *App) stopEvents() {
+	if a.stopHooks != nil {
+		a.stopHooks()
+	}
+	if a.eventServer != nil {
+		if err := a.eventServer.Close(); err != nil {
+			log.Printf("Error closing event stream: %v", err)
+		}
+	}
+}
+
+// publishImageChanged announces the image now on screen.
+func (a *App) publishImageChanged() {
+	a.events.Publish(events.Event{
+		Type:  events.ImageChanged,
+		Path:  a.img.Path,
+		Index: a.index + 1,
+		Count: a.getCurrentImageCount(),
+	})
+}
+
+// publishFilterChanged announces the active filter, or that none is active.
+func (a *App) publishFilterChanged() {
+	ev := events.Event{Type: events.FilterChanged, Count: a.getCurrentImageCount()}
+	if a.isFiltered {
+		ev.Filter = a.currentFilter.String()
+	}
+	a.events.Publish(ev)
+}
+
+// publishTagChange announces a tag change the database accepted.
+func (a *App) publishTagChange(op tagOp) {
+	ev := events.Event{Type: events.TagRemoved, Path: op.path, Tag: op.tag}
+	if op.add {
+		ev.Type = events.TagAdded
+	}
+	a.events.Publish(ev)
+}
@@ -0,0 +1,431 @@
+// Package ui Editing EXIF metadata: the date taken and title of the current image,
+// and correcting the dates of many images shot with a wrong camera clock or time zone.
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/metadata"
+	"fyslide/internal/scan"
+	"math"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// exifDateLayout is how dates are shown and typed in the metadata dialogs.
+const exifDateLayout = "2006-01-02 15:04:05"
+
+// maxDateShiftHours bounds a date shift to a century either way.
+const maxDateShiftHours = 100 * 366 * 24
+
+// parseEXIFDate parses a date typed as exifDateLayout, in local time.
+func parseEXIFDate(text string) (time.Time, error) {
+	taken, err := time.ParseInLocation(exifDateLayout, strings.TrimSpace(text), time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be a date like 2006-01-02 15:04:05")
+	}
+	return taken, nil
+}
+
+// parseDateShift parses a shift typed in hours, such as -1 or 5.5.
+func parseDateShift(text string) (time.Duration, error) {
+	hours, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || hours == 0 || math.IsNaN(hours) || math.Abs(hours) > maxDateShiftHours {
+		return 0, fmt.Errorf("must be a number of hours other than 0, such as -1 or 5.5")
+	}
+	return time.Duration(hours * float64(time.Hour)).Round(time.Second), nil
+}
+
+// showEditEXIFDialog edits the EXIF date taken and title of the current image,
+// e.g. for scanned photos that have no date.
+func (a *App) showEditEXIFDialog() {
+	if a.refuseInReadOnly("Edit Date and Title") {
+		return
+	}
+	path := a.img.Path
+	if path == "" {
+		dialog.ShowInformation("Edit Date and Title", "No image loaded to edit.", a.UI.MainWin)
+		return
+	}
+	if !metadata.EXIFSupported(path) {
+		dialog.ShowInformation("Edit Date and Title", "Only JPEG files can store a date and title.", a.UI.MainWin)
+		return
+	}
+	current, err := metadata.ReadEXIF(path)
+	if err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+		return
+	}
+
+	a.slideshowManager.Pause(true)
+	dateEntry := widget.NewEntry()
+	dateEntry.SetPlaceHolder("YYYY-MM-DD HH:MM:SS")
+	if !current.Taken.IsZero() {
+		dateEntry.SetText(current.Taken.Format(exifDateLayout))
+	}
+	dateEntry.Validator = func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		_, err := parseEXIFDate(text)
+		return err
+	}
+	titleEntry := widget.NewEntry()
+	titleEntry.SetText(current.Title)
+	dateItem := widget.NewFormItem("Date taken", dateEntry)
+	dateItem.HintText = "Leave empty to keep the date as it is"
+
+	d := dialog.NewForm("Edit Date and Title", "Save", "Cancel", []*widget.FormItem{
+		dateItem,
+		widget.NewFormItem("Title", titleEntry),
+	}, func(confirm bool) {
+		defer a.slideshowManager.ResumeAfterOperation()
+		if !confirm {
+			return
+		}
+		var update metadata.EXIFUpdate
+		if text := strings.TrimSpace(dateEntry.Text); text != "" {
+			if taken, err := parseEXIFDate(text); err == nil && !taken.Equal(current.Taken) {
+				update.Taken = &taken
+			}
+		}
+		if title := strings.TrimSpace(titleEntry.Text); title != current.Title {
+			update.Title = &title
+		}
+		if update.Taken == nil && update.Title == nil {
+			return
+		}
+		if err := a.service.SetEXIF(a.ctx, path, update); err != nil {
+			a.addLogMessage(fmt.Sprintf("Error editing the metadata of %s: %v", filepath.Base(path), err))
+			dialog.ShowError(err, a.UI.MainWin)
+			return
+		}
+		a.addLogMessage(fmt.Sprintf("Saved the date and title of %s.", filepath.Base(path)))
+		a.reloadEditedFile(path)
+	}, a.UI.MainWin)
+	d.Resize(fyne.NewSize(searchDialogWidth, d.MinSize().Height))
+	d.Show()
+}
+
+// Images a date correction applies to.
+const (
+	dateScopeCurrent = "Current image"
+	dateScopeFolder  = "Current folder"
+	dateScopeList    = "Current list"
+)
+
+// Ways of correcting dates.
+const (
+	dateFixByOffset = "Shift by a number of hours"
+	dateFixByZone   = "Convert between time zones"
+)
+
+// datePreviewCount is how many images the Correct Dates dialog previews.
+const datePreviewCount = 10
+
+// zoneOffsetRe matches time zones given as offsets, such as UTC+2 or -05:30.
+var zoneOffsetRe = regexp.MustCompile(`^(?i:UTC|GMT)?([+-])(\d{1,2})(?::?(\d{2}))?$`)
+
+// parseZone parses a time zone typed as a name such as Europe/Paris, an
+// offset such as UTC+2, or Local, the default when text is empty.
+func parseZone(text string) (*time.Location, error) {
+	text = strings.TrimSpace(text)
+	switch strings.ToLower(text) {
+	case "", "local":
+		return time.Local, nil
+	case "utc", "gmt", "z":
+		return time.UTC, nil
+	}
+	if m := zoneOffsetRe.FindStringSubmatch(text); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3]) // 0 when left out
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("time zone offset %s is out of range", text)
+		}
+		offset := (hours*60 + minutes) * 60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(text, offset), nil
+	}
+	loc, err := time.LoadLocation(text)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q; use a name like Europe/Paris or an offset like UTC+2", text)
+	}
+	return loc, nil
+}
+
+// dateFixSources returns the JPEG images of scope, those whose dates can be corrected.
+func (a *App) dateFixSources(scope string) []string {
+	var paths []string
+	add := func(path string) {
+		if metadata.EXIFSupported(path) {
+			paths = append(paths, path)
+		}
+	}
+	switch scope {
+	case dateScopeCurrent:
+		if a.img.Path != "" {
+			add(a.img.Path)
+		}
+	case dateScopeFolder:
+		for _, path := range a.imagesInDirectory(filepath.Dir(a.img.Path)) {
+			add(path)
+		}
+	default:
+		for _, item := range a.getCurrentList() {
+			add(item.Path)
+		}
+	}
+	return paths
+}
+
+// showCorrectDatesDialog corrects the EXIF dates of the current image, its
+// folder or the current list, by a number of hours or from one time zone to
+// another, previewing the first images' dates before and after.
+func (a *App) showCorrectDatesDialog() {
+	if a.refuseInReadOnly("Correct Dates") {
+		return
+	}
+	if a.img.Path == "" {
+		dialog.ShowInformation("Correct Dates", "No image loaded.", a.UI.MainWin)
+		return
+	}
+	sources := make(map[string][]string)
+	var scopeLabels []string
+	for _, scope := range []string{dateScopeCurrent, dateScopeFolder, dateScopeList} {
+		paths := a.dateFixSources(scope)
+		label := fmt.Sprintf("%s (%d JPEG images)", scope, len(paths))
+		sources[label] = paths
+		scopeLabels = append(scopeLabels, label)
+	}
+
+	a.slideshowManager.Pause(true)
+	scopeRadio := widget.NewRadioGroup(scopeLabels, nil)
+	scopeRadio.Required = true
+	scopeRadio.SetSelected(scopeLabels[1])
+	modeRadio := widget.NewRadioGroup([]string{dateFixByOffset, dateFixByZone}, nil)
+	modeRadio.Required = true
+	modeRadio.SetSelected(dateFixByOffset)
+	hoursEntry := widget.NewEntry()
+	hoursEntry.SetPlaceHolder("e.g. -1 or 5.5")
+	fromEntry := widget.NewEntry()
+	fromEntry.SetPlaceHolder("Local, UTC+2 or Europe/Paris")
+	toEntry := widget.NewEntry()
+	toEntry.SetPlaceHolder("Local, UTC+2 or Europe/Paris")
+	sortCheck := widget.NewCheck("Sort the current list by date taken afterwards", nil)
+	preview := widget.NewLabel("")
+	preview.TextStyle.Monospace = true
+
+	// correction returns the chosen adjustment and its description.
+	correction := func() (metadata.DateAdjustment, string, error) {
+		if modeRadio.Selected == dateFixByZone {
+			from, err := parseZone(fromEntry.Text)
+			if err != nil {
+				return nil, "", err
+			}
+			to, err := parseZone(toEntry.Text)
+			if err != nil {
+				return nil, "", err
+			}
+			return metadata.ConvertZone(from, to), fmt.Sprintf("from %s to %s", from, to), nil
+		}
+		shift, err := parseDateShift(hoursEntry.Text)
+		if err != nil {
+			return nil, "", err
+		}
+		return metadata.ShiftDates(shift), "by " + shift.String(), nil
+	}
+
+	var cacheMu sync.Mutex
+	cache := make(map[string]metadata.EXIF) // Dates read for the preview
+	previewGen := 0
+	updatePreview := func() {
+		previewGen++
+		gen := previewGen
+		adjust, _, err := correction()
+		if err != nil {
+			preview.SetText(err.Error())
+			return
+		}
+		paths := sources[scopeRadio.Selected]
+		go func() {
+			var lines []string
+			for _, path := range paths[:min(len(paths), datePreviewCount)] {
+				cacheMu.Lock()
+				fields, ok := cache[path]
+				cacheMu.Unlock()
+				if !ok {
+					fields, _ = metadata.ReadEXIF(path) // Unreadable files show as undated
+					cacheMu.Lock()
+					cache[path] = fields
+					cacheMu.Unlock()
+				}
+				if fields.Taken.IsZero() {
+					lines = append(lines, fmt.Sprintf("%s: no date", filepath.Base(path)))
+					continue
+				}
+				lines = append(lines, fmt.Sprintf("%s: %s -> %s", filepath.Base(path),
+					fields.Taken.Format(exifDateLayout), adjust.Apply(fields.Taken).Format(exifDateLayout)))
+			}
+			if more := len(paths) - datePreviewCount; more > 0 {
+				lines = append(lines, fmt.Sprintf("... and %d more", more))
+			}
+			fyne.Do(func() {
+				if gen == previewGen {
+					preview.SetText(strings.Join(lines, "\n"))
+				}
+			})
+		}()
+	}
+	modeRadio.OnChanged = func(mode string) {
+		byZone := mode == dateFixByZone
+		for entry, enable := range map[*widget.Entry]bool{hoursEntry: !byZone, fromEntry: byZone, toEntry: byZone} {
+			if enable {
+				entry.Enable()
+			} else {
+				entry.Disable()
+			}
+		}
+		updatePreview()
+	}
+	modeRadio.OnChanged(modeRadio.Selected)
+	scopeRadio.OnChanged = func(string) { updatePreview() }
+	for _, entry := range []*widget.Entry{hoursEntry, fromEntry, toEntry} {
+		entry.OnChanged = func(string) { updatePreview() }
+	}
+
+	d := dialog.NewForm("Correct Dates", "Apply", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Images", scopeRadio),
+		widget.NewFormItem("Correction", modeRadio),
+		widget.NewFormItem("Hours", hoursEntry),
+		widget.NewFormItem("Camera time zone", fromEntry),
+		widget.NewFormItem("Actual time zone", toEntry),
+		widget.NewFormItem("", sortCheck),
+		widget.NewFormItem("Preview", preview),
+	}, func(confirm bool) {
+		if !confirm {
+			a.slideshowManager.ResumeAfterOperation()
+			return
+		}
+		adjust, desc, err := correction()
+		paths := sources[scopeRadio.Selected]
+		if err == nil && len(paths) == 0 {
+			err = errors.New("there are no JPEG images to correct")
+		}
+		if err != nil {
+			dialog.ShowError(err, a.UI.MainWin)
+			a.slideshowManager.ResumeAfterOperation()
+			return
+		}
+		a.runCorrectDates(paths, adjust, desc, sortCheck.Checked)
+	}, a.UI.MainWin)
+	d.Resize(fyne.NewSize(searchDialogWidth, d.MinSize().Height))
+	d.Show()
+}
+
+// runCorrectDates corrects the dates of paths in the background behind a
+// progress dialog that can cancel it, then sorts the current list by date
+// taken if sortAfter is set.
+func (a *App) runCorrectDates(paths []string, adjust metadata.DateAdjustment, desc string, sortAfter bool) {
+	ctx, cancel := context.WithCancel(a.ctx)
+	bar := widget.NewProgressBar()
+	status := widget.NewLabel(fmt.Sprintf("Correcting the dates of %d images...", len(paths)))
+	progress := dialog.NewCustom("Correcting Dates", "Cancel", container.NewVBox(status, bar), a.UI.MainWin)
+	progress.SetOnClosed(cancel)
+	progress.Resize(fyne.NewSize(searchDialogWidth, progress.MinSize().Height))
+	progress.Show()
+
+	list := slices.Clone(a.getCurrentList())
+	a.goBackground(func() {
+		defer cancel()
+		done, undated := 0, 0
+		result := a.service.AdjustEXIFDates(ctx, paths, adjust, func(path string, err error) {
+			done++
+			if errors.Is(err, metadata.ErrNoDate) {
+				undated++
+			}
+			fyne.Do(func() {
+				bar.SetValue(float64(done) / float64(len(paths)))
+				status.SetText(fmt.Sprintf("%d of %d images", done, len(paths)))
+				if err != nil && !errors.Is(err, metadata.ErrNoDate) {
+					a.addLogMessage(fmt.Sprintf("Correcting dates failed: %v", err))
+				}
+			})
+		})
+		var dates map[string]time.Time
+		if sortAfter && result.Stopped == nil {
+			fyne.Do(func() { status.SetText("Sorting by date taken...") })
+			dates = datesTaken(a.ctx, list)
+		}
+		fyne.Do(func() {
+			progress.Hide()
+			a.slideshowManager.ResumeAfterOperation()
+			summary := fmt.Sprintf("Corrected the dates of %d images %s, %d had no date, %d failed.",
+				result.Done, desc, undated, len(result.Errors)-undated)
+			if result.Stopped != nil {
+				summary = fmt.Sprintf("Stopped after %d of %d images. %s", done, len(paths), summary)
+			}
+			a.addLogMessage(summary)
+			dialog.ShowInformation("Correct Dates", summary, a.UI.MainWin)
+			if dates != nil {
+				a.sortByDateTaken(dates, false)
+			} else {
+				a.reloadEditedFile(a.img.Path)
+			}
+		})
+	})
+}
+
+// datesTaken returns the EXIF date taken of each image in list, or its
+// modification time if it has none. It stops early once ctx is cancelled.
+func datesTaken(ctx context.Context, list scan.FileItems) map[string]time.Time {
+	dates := make(map[string]time.Time, len(list))
+	for _, item := range list {
+		if ctx.Err() != nil {
+			break
+		}
+		var taken time.Time
+		if metadata.EXIFSupported(item.Path) {
+			fields, _ := metadata.ReadEXIF(item.Path)
+			taken = fields.Taken
+		}
+		if taken.IsZero() && item.Info != nil {
+			taken = item.Info.ModTime()
+		}
+		dates[item.Path] = taken
+	}
+	return dates
+}
+
+// sortByDateTaken orders the browsed list by the dates given, newest first
+// if desc, keeping images without one in place after the dated ones, and
+// stays on the current image.
+func (a *App) sortByDateTaken(dates map[string]time.Time, desc bool) {
+	a.sortBrowsedList(func(x, y scan.FileItem) int {
+		dx, okx := dates[x.Path]
+		dy, oky := dates[y.Path]
+		switch {
+		case okx && oky && desc:
+			return dy.Compare(dx)
+		case okx && oky:
+			return dx.Compare(dy)
+		case okx:
+			return -1
+		case oky:
+			return 1
+		}
+		return 0
+	}, "date taken")
+}
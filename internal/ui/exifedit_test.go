@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateShift(t *testing.T) {
+	for text, want := range map[string]time.Duration{
+		"1":     time.Hour,
+		" -1.5": -90 * time.Minute,
+		"+24":   24 * time.Hour,
+	} {
+		if got, err := parseDateShift(text); err != nil || got != want {
+			t.Errorf("parseDateShift(%q) = %v, %v; want %v", text, got, err, want)
+		}
+	}
+	for _, text := range []string{"", "0", "two", "NaN", "1e9"} {
+		if _, err := parseDateShift(text); err == nil {
+			t.Errorf("parseDateShift(%q) accepted", text)
+		}
+	}
+}
+
+func TestParseZone(t *testing.T) {
+	for text, want := range map[string]int{
+		"UTC+2":  2 * 3600,
+		"gmt-5":  -5 * 3600,
+		"+05:30": 5*3600 + 30*60,
+		"-0930":  -(9*3600 + 30*60),
+		"utc":    0,
+	} {
+		loc, err := parseZone(text)
+		if err != nil {
+			t.Errorf("parseZone(%q) failed: %v", text, err)
+			continue
+		}
+		if _, offset := time.Date(2024, 1, 1, 0, 0, 0, 0, loc).Zone(); offset != want {
+			t.Errorf("parseZone(%q) has offset %d, want %d", text, offset, want)
+		}
+	}
+	if loc, err := parseZone(""); err != nil || loc != time.Local {
+		t.Errorf("parseZone(\"\") = %v, %v; want Local", loc, err)
+	}
+	for _, text := range []string{"UTC+15", "+02:75", "Mars/Olympus"} {
+		if _, err := parseZone(text); err == nil {
+			t.Errorf("parseZone(%q) accepted", text)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package ui EXIF index: a background job that reads the date, camera, GPS
+// position and dimensions of every image into the database, so that filters
+// on them and the timeline needn't read the files.
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/exifindex"
+	"fyslide/internal/query"
+	"fyslide/internal/scan"
+	"fyslide/internal/tagging"
+	"maps"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// loadImageInfo loads the EXIF index kept from earlier sessions. Called from
+// the scanning goroutine.
+func (a *App) loadImageInfo() {
+	infos, err := a.tagDB.ImageInfos(a.ctx)
+	if err != nil {
+		fyne.Do(func() { a.addLogMessage(fmt.Sprintf("Failed to load the EXIF index: %v", err)) })
+		return
+	}
+	fyne.Do(func() { a.imageInfo = infos })
+}
+
+// mergeImageInfo adds records to the EXIF index. The map is replaced rather
+// than changed, since running filters hold the old one.
+func (a *App) mergeImageInfo(records map[string]tagging.ImageInfo) {
+	merged := maps.Clone(a.imageInfo)
+	if merged == nil {
+		merged = make(map[string]tagging.ImageInfo, len(records))
+	}
+	maps.Copy(merged, records)
+	a.imageInfo = merged
+}
+
+// indexEXIF starts indexing the images not yet indexed or changed since, in
+// the background with its progress in the status bar. While it runs, it
+// offers to stop it instead.
+func (a *App) indexEXIF() {
+	if a.indexCancel != nil {
+		dialog.ShowConfirm("Index EXIF Data", fmt.Sprintf("EXIF indexing is running (%s). Stop it?", a.indexStatus), func(stop bool) {
+			if stop && a.indexCancel != nil {
+				a.indexCancel()
+			}
+		}, a.UI.MainWin)
+		return
+	}
+	items := slices.Clone(a.images)
+	if len(items) == 0 {
+		dialog.ShowInformation("Index EXIF Data", "There are no images to index.", a.UI.MainWin)
+		return
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.indexCancel = cancel
+	a.indexStatus = "starting"
+	a.updateStatusBar()
+
+	a.goBackground(func() {
+		defer cancel()
+		read, lastPercent := 0, -1
+		records, err := exifindex.Update(ctx, a.tagDB, items, func(done, total int) {
+			read = done
+			if percent := done * 100 / total; percent != lastPercent {
+				lastPercent = percent
+				fyne.Do(func() {
+					a.indexStatus = fmt.Sprintf("%s of %s", formatNumberWithCommas(int64(done)), formatNumberWithCommas(int64(total)))
+					a.updateStatusBar()
+				})
+			}
+		})
+		fyne.Do(func() {
+			a.indexCancel, a.indexStatus = nil, ""
+			a.mergeImageInfo(records) // Whatever was read before a stop or failure is kept
+			a.updateStatusBar()
+			switch {
+			case errors.Is(err, context.Canceled):
+				a.addLogMessage(fmt.Sprintf("EXIF indexing stopped after reading %d images.", read))
+			case err != nil:
+				a.addLogMessage(fmt.Sprintf("Error indexing EXIF data: %v", err))
+				dialog.ShowError(err, a.UI.MainWin)
+			default:
+				a.addLogMessage(fmt.Sprintf("EXIF index up to date: read %d new or changed images, %d already indexed.", read, len(records)-read))
+			}
+		})
+	})
+}
+
+// indexedProperties returns the properties of item that c needs from its
+// EXIF index record, or false if the record is missing, outdated or lacks
+// them, so the file must be read.
+func indexedProperties(index map[string]tagging.ImageInfo, c query.Criteria, item scan.FileItem) (query.Properties, bool) {
+	record, ok := index[item.Path]
+	if !ok || item.Info == nil || !exifindex.Current(record, item.Info) {
+		return query.Properties{}, false
+	}
+	if c.NeedsDimensions() && record.Width == 0 {
+		return query.Properties{}, false // The header couldn't be read when indexing
+	}
+	return query.Properties{
+		Date:   record.Taken,
+		Width:  record.Width,
+		Height: record.Height,
+		Size:   record.Size,
+		Camera: record.Camera,
+	}, true
+}
@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"fyslide/internal/exifindex"
+	"fyslide/internal/query"
+	"fyslide/internal/scan"
+	"fyslide/internal/tagging"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIndexedProperties(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.jpg")
+	if err := os.WriteFile(path, []byte("jpeg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+	item := scan.NewFileItem(path, info)
+	taken := time.Date(2021, 3, 4, 5, 6, 7, 0, time.Local)
+	record := tagging.ImageInfo{Path: path, ModTime: info.ModTime(), Size: info.Size(), Taken: taken, Camera: "Pixel 7", Version: exifindex.Version}
+
+	index := map[string]tagging.ImageInfo{path: record}
+	props, ok := indexedProperties(index, query.Criteria{Camera: "pixel"}, item)
+	if !ok || !props.Date.Equal(taken) || props.Camera != "Pixel 7" || props.Size != info.Size() {
+		t.Errorf("indexedProperties = %+v, %v; want the record's properties", props, ok)
+	}
+	if _, ok := indexedProperties(index, query.Criteria{MinWidth: 100}, item); ok {
+		t.Error("A record without dimensions was used for a dimension filter")
+	}
+	record.Size++
+	if _, ok := indexedProperties(map[string]tagging.ImageInfo{path: record}, query.Criteria{Camera: "pixel"}, item); ok {
+		t.Error("A record of a changed file was used")
+	}
+	if _, ok := indexedProperties(nil, query.Criteria{Camera: "pixel"}, item); ok {
+		t.Error("A missing record was reported as found")
+	}
+}
@@ -0,0 +1,178 @@
+// Package ui Export of resized and converted copies (File > Export Resized Copies).
+package ui
+
+import (
+	"context"
+	"fmt"
+	"fyslide/internal/exporter"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Scopes of an export.
+const (
+	exportScopeCurrent = "Current image"
+	exportScopeList    = "Current list"
+	exportScopeAll     = "All images"
+)
+
+const maxExportDimension = 65535
+
+// exportSources returns the images an export of scope covers.
+func (a *App) exportSources(scope string) []string {
+	var items []string
+	switch scope {
+	case exportScopeCurrent:
+		if a.img.Path != "" {
+			items = append(items, a.img.Path)
+		}
+	case exportScopeList:
+		for _, item := range a.getCurrentList() {
+			items = append(items, item.Path)
+		}
+	default:
+		for _, item := range a.images {
+			items = append(items, item.Path)
+		}
+	}
+	return items
+}
+
+// showExportDialog asks which images to export where and how, then runs the export.
+func (a *App) showExportDialog() {
+	a.slideshowManager.Pause(true)
+	prefs := a.prefs()
+
+	listLabel := fmt.Sprintf("%s (%d images)", exportScopeList, a.getCurrentImageCount())
+	allLabel := fmt.Sprintf("%s (%d images)", exportScopeAll, len(a.images))
+	scopeRadio := widget.NewRadioGroup([]string{exportScopeCurrent, listLabel, allLabel}, nil)
+	scopeRadio.SetSelected(listLabel)
+
+	dirEntry := widget.NewEntry()
+	dirEntry.SetText(prefs.String(prefExportDir))
+	dirEntry.SetPlaceHolder("Folder the copies are written to")
+	dirEntry.Validator = func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("choose a target folder")
+		}
+		return nil
+	}
+	browseBtn := widget.NewButton("Browse...", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err == nil && uri != nil {
+				dirEntry.SetText(uri.Path())
+			}
+		}, a.UI.MainWin)
+	})
+
+	sizeEntry := widget.NewEntry()
+	sizeEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefExportMaxSize, 2048)))
+	sizeEntry.Validator = intRangeValidator(0, maxExportDimension)
+	qualityEntry := widget.NewEntry()
+	qualityEntry.SetText(strconv.Itoa(prefs.IntWithFallback(prefExportQuality, exporter.DefaultQuality)))
+	qualityEntry.Validator = intRangeValidator(1, 100)
+	formatSelect := widget.NewSelect(exporter.Formats, nil)
+	formatSelect.SetSelected(prefs.StringWithFallback(prefExportFormat, exporter.FormatKeep))
+	stripCheck := widget.NewCheck("Strip metadata (EXIF)", nil)
+	stripCheck.SetChecked(prefs.Bool(prefExportStripMetadata))
+	overwriteCheck := widget.NewCheck("Overwrite existing copies", nil)
+	levelsCheck := widget.NewCheck("Auto-enhance levels", nil)
+	levelsCheck.SetChecked(a.autoEnhance) // Export what the preview shows
+
+	d := dialog.NewForm("Export Resized Copies", "Export", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Images", scopeRadio),
+		widget.NewFormItem("Target folder", container.NewBorder(nil, nil, nil, browseBtn, dirEntry)),
+		widget.NewFormItem("Longest edge (px, 0 keeps)", sizeEntry),
+		widget.NewFormItem("JPEG quality", qualityEntry),
+		widget.NewFormItem("Format", formatSelect),
+		widget.NewFormItem("", stripCheck),
+		widget.NewFormItem("", levelsCheck),
+		widget.NewFormItem("", overwriteCheck),
+	}, func(confirm bool) {
+		if !confirm {
+			a.slideshowManager.ResumeAfterOperation()
+			return
+		}
+		scope := strings.SplitN(scopeRadio.Selected, " (", 2)[0]
+		opts := exporter.Options{
+			OutDir:        strings.TrimSpace(dirEntry.Text),
+			Format:        formatSelect.Selected,
+			StripMetadata: stripCheck.Checked,
+			AutoLevels:    levelsCheck.Checked,
+			Overwrite:     overwriteCheck.Checked,
+		}
+		opts.MaxDimension, _ = strconv.Atoi(sizeEntry.Text)
+		opts.Quality, _ = strconv.Atoi(qualityEntry.Text)
+		prefs.SetString(prefExportDir, opts.OutDir)
+		prefs.SetInt(prefExportMaxSize, opts.MaxDimension)
+		prefs.SetInt(prefExportQuality, opts.Quality)
+		prefs.SetString(prefExportFormat, opts.Format)
+		prefs.SetBool(prefExportStripMetadata, opts.StripMetadata)
+		a.runExport(a.exportSources(scope), opts)
+	}, a.UI.MainWin)
+	d.Resize(fyne.NewSize(searchDialogWidth, d.MinSize().Height))
+	d.Show()
+}
+
+// runExport exports sources in the background behind a progress dialog that
+// can cancel the export.
+func (a *App) runExport(sources []string, opts exporter.Options) {
+	if len(sources) == 0 {
+		dialog.ShowInformation("Export Resized Copies", "There are no images to export.", a.UI.MainWin)
+		a.slideshowManager.ResumeAfterOperation()
+		return
+	}
+	if err := opts.Validate(); err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+		a.slideshowManager.ResumeAfterOperation()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(a.ctx) // Also stops when the window closes
+	bar := widget.NewProgressBar()
+	status := widget.NewLabel(fmt.Sprintf("Exporting %d images...", len(sources)))
+	progress := dialog.NewCustom("Exporting", "Cancel", container.NewVBox(status, bar), a.UI.MainWin)
+	progress.SetOnClosed(cancel)
+	progress.Resize(fyne.NewSize(searchDialogWidth, progress.MinSize().Height))
+	progress.Show()
+
+	a.goBackground(func() {
+		defer cancel()
+		written, skipped, failed := 0, 0, 0
+		results, err := exporter.Run(ctx, sources, opts, func(done, total int, r exporter.Result) {
+			switch {
+			case r.Err != nil:
+				failed++
+			case r.Skipped:
+				skipped++
+			default:
+				written++
+			}
+			fyne.Do(func() {
+				bar.SetValue(float64(done) / float64(total))
+				status.SetText(fmt.Sprintf("%d of %d images", done, total))
+				if r.Err != nil {
+					a.addLogMessage(fmt.Sprintf("Export failed: %v", r.Err))
+				}
+			})
+		})
+		if a.ctx.Err() != nil {
+			return // The window closed; what was written stays
+		}
+		fyne.Do(func() {
+			progress.Hide()
+			a.slideshowManager.ResumeAfterOperation()
+			summary := fmt.Sprintf("Wrote %d copies to %s, skipped %d existing, %d failed.", written, opts.OutDir, skipped, failed)
+			if err != nil {
+				summary = fmt.Sprintf("Export stopped after %d of %d images. %s", len(results), len(sources), summary)
+			}
+			a.addLogMessage(summary)
+			dialog.ShowInformation("Export Resized Copies", summary, a.UI.MainWin)
+		})
+	})
+}
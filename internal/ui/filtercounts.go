@@ -0,0 +1,192 @@
+// Package ui Live image counts of the active filter, kept current from the
+// tag change events instead of being recomputed on every navigation.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/events"
+	"fyslide/internal/query"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// filterCountsBuffer is how many tag change events may wait for the counts;
+// large enough for a batch tagging job to finish without events being dropped.
+const filterCountsBuffer = 4096
+
+// filterCounts tracks which loaded images carry each tag of the active filter.
+type filterCounts struct {
+	tags   []string
+	tagged map[string]map[string]bool // Filter tag -> loaded images carrying it
+	loaded map[string]bool            // The loaded images, so newly tagged ones count only if browsed
+}
+
+// newFilterCounts counts the images of loaded among tagged, the images of
+// each filter tag as read from the database.
+func newFilterCounts(tags []string, tagged map[string][]string, loaded map[string]bool) *filterCounts {
+	fc := &filterCounts{tags: tags, tagged: make(map[string]map[string]bool, len(tags)), loaded: loaded}
+	for _, tag := range tags {
+		fc.tagged[tag] = make(map[string]bool)
+		for _, path := range tagged[tag] {
+			if loaded[path] {
+				fc.tagged[tag][path] = true
+			}
+		}
+	}
+	return fc
+}
+
+// count returns how many loaded images carry tag.
+func (fc *filterCounts) count(tag string) int {
+	return len(fc.tagged[tag])
+}
+
+// matching returns how many loaded images carry all the filter's tags.
+func (fc *filterCounts) matching() int {
+	if len(fc.tags) == 0 {
+		return 0
+	}
+	smallest := fc.tagged[fc.tags[0]]
+	for _, tag := range fc.tags[1:] {
+		if len(fc.tagged[tag]) < len(smallest) {
+			smallest = fc.tagged[tag]
+		}
+	}
+	n := 0
+	for path := range smallest {
+		if fc.hasAll(path) {
+			n++
+		}
+	}
+	return n
+}
+
+// hasAll reports whether path carries every tag of the filter.
+func (fc *filterCounts) hasAll(path string) bool {
+	for _, tag := range fc.tags {
+		if !fc.tagged[tag][path] {
+			return false
+		}
+	}
+	return true
+}
+
+// apply records a tag change event, reporting whether a count changed.
+func (fc *filterCounts) apply(ev events.Event) bool {
+	paths, ok := fc.tagged[ev.Tag]
+	if !ok {
+		return false
+	}
+	switch ev.Type {
+	case events.TagAdded:
+		if paths[ev.Path] || !fc.loaded[ev.Path] {
+			return false
+		}
+		paths[ev.Path] = true
+	case events.TagRemoved:
+		if !paths[ev.Path] {
+			return false
+		}
+		delete(paths, ev.Path)
+	default:
+		return false
+	}
+	return true
+}
+
+// load records an image added to the loaded images since the counts were made.
+func (fc *filterCounts) load(path string) {
+	fc.loaded[path] = true
+}
+
+// remove forgets images deleted or otherwise dropped from the loaded images.
+func (fc *filterCounts) remove(deleted map[string]bool) {
+	for path := range deleted {
+		delete(fc.loaded, path)
+	}
+	for _, paths := range fc.tagged {
+		for path := range deleted {
+			delete(paths, path)
+		}
+	}
+}
+
+// countFilterTags reads the images of each tag of c for the live counts.
+// Returns nil when c has no tags or the database can't be read, in which
+// case the status falls back to the length of the filtered list.
+func (a *App) countFilterTags(c query.Criteria) *filterCounts {
+	if len(c.Tags) == 0 {
+		return nil
+	}
+	tagged := make(map[string][]string, len(c.Tags))
+	for _, tag := range c.Tags {
+		paths, err := a.tagDB.GetImages(a.ctx, tag)
+		if err != nil {
+			a.addLogMessage(fmt.Sprintf("Filter counts unavailable: %v", err))
+			return nil
+		}
+		tagged[tag] = paths
+	}
+	loaded := make(map[string]bool, len(a.images))
+	for _, item := range a.images {
+		loaded[item.Path] = true
+	}
+	return newFilterCounts(c.Tags, tagged, loaded)
+}
+
+// watchFilterCounts keeps the filter counts current as tags are added and
+// removed, until the app stops. Must run after startEvents.
+func (a *App) watchFilterCounts() {
+	ch, unsubscribe := a.events.Subscribe(filterCountsBuffer)
+	a.goBackground(func() {
+		defer unsubscribe()
+		for {
+			select {
+			case <-a.ctx.Done():
+				return
+			case ev := <-ch:
+				if ev.Type != events.TagAdded && ev.Type != events.TagRemoved {
+					continue
+				}
+				fyne.Do(func() {
+					if a.filterCounts != nil && a.filterCounts.apply(ev) {
+						a.updateStatusBar()
+					}
+				})
+			}
+		}
+	})
+}
+
+// filterCount returns how many loaded images match the active filter, as
+// "342 of 18,204", or "" without a filter.
+func (a *App) filterCount() string {
+	if !a.isFiltered {
+		return ""
+	}
+	c := a.currentFilter
+	n := len(a.filteredImages)
+	if a.filterCounts != nil && c.Folder == "" && c.Text == "" && !c.HasPropertyFilters() {
+		n = a.filterCounts.matching() // Tags alone decide, so images tagged since count too
+	}
+	return formatNumberWithCommas(int64(n)) + " of " + formatNumberWithCommas(int64(len(a.images)))
+}
+
+// filterStatus describes the active filter with its live counts, e.g.
+// "beach — 342 of 18,204", naming each tag's count when there are several.
+// Returns "" without a filter.
+func (a *App) filterStatus() string {
+	if !a.isFiltered {
+		return ""
+	}
+	status := fmt.Sprintf("%s — %s", a.currentFilter, a.filterCount())
+	if fc := a.filterCounts; fc != nil && len(fc.tags) > 1 {
+		counts := make([]string, len(fc.tags))
+		for i, tag := range fc.tags {
+			counts[i] = fmt.Sprintf("%s %s", tag, formatNumberWithCommas(int64(fc.count(tag))))
+		}
+		status += " (" + strings.Join(counts, ", ") + ")"
+	}
+	return status
+}
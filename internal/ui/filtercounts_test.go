@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"fyslide/internal/events"
+	"testing"
+)
+
+func TestFilterCounts(t *testing.T) {
+	loaded := map[string]bool{"/a.jpg": true, "/b.jpg": true, "/c.jpg": true}
+	fc := newFilterCounts([]string{"beach", "sunset"}, map[string][]string{
+		"beach":  {"/a.jpg", "/b.jpg", "/elsewhere.jpg"},
+		"sunset": {"/b.jpg"},
+	}, loaded)
+	if fc.count("beach") != 2 || fc.count("sunset") != 1 || fc.matching() != 1 {
+		t.Fatalf("counts are beach %d, sunset %d, matching %d; want 2, 1, 1", fc.count("beach"), fc.count("sunset"), fc.matching())
+	}
+
+	if !fc.apply(events.Event{Type: events.TagAdded, Path: "/a.jpg", Tag: "sunset"}) || fc.matching() != 2 {
+		t.Errorf("adding sunset to /a.jpg must make it match, matching %d", fc.matching())
+	}
+	if fc.apply(events.Event{Type: events.TagAdded, Path: "/other.jpg", Tag: "beach"}) {
+		t.Error("images that aren't loaded must not be counted")
+	}
+	if fc.apply(events.Event{Type: events.TagAdded, Path: "/c.jpg", Tag: "forest"}) {
+		t.Error("tags outside the filter must not change the counts")
+	}
+	if !fc.apply(events.Event{Type: events.TagRemoved, Path: "/b.jpg", Tag: "beach"}) || fc.matching() != 1 {
+		t.Errorf("removing beach from /b.jpg must drop it, matching %d", fc.matching())
+	}
+
+	fc.remove(map[string]bool{"/a.jpg": true})
+	if fc.count("beach") != 0 || fc.count("sunset") != 1 || fc.matching() != 0 {
+		t.Errorf("after deleting /a.jpg counts are beach %d, sunset %d, matching %d; want 0, 1, 0", fc.count("beach"), fc.count("sunset"), fc.matching())
+	}
+	if fc.apply(events.Event{Type: events.TagAdded, Path: "/a.jpg", Tag: "beach"}) {
+		t.Error("deleted images must not be counted again")
+	}
+	fc.load("/other.jpg")
+	if !fc.apply(events.Event{Type: events.TagAdded, Path: "/other.jpg", Tag: "beach"}) || fc.count("beach") != 1 {
+		t.Errorf("images added since must be counted, beach %d", fc.count("beach"))
+	}
+}
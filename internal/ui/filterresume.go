@@ -0,0 +1,123 @@
+// Package ui Per-filter resume points: returning to a filter continues at the image
+// last shown in it rather than at its first image.
+package ui
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// maxFilterPositions bounds the filters whose position is kept, and saved;
+// the least recently left ones are dropped first.
+const maxFilterPositions = 100
+
+// filterPosition is where the user was in a filtered list: the image's path,
+// and its index in case that image no longer matches.
+type filterPosition struct {
+	Path  string `json:"path"`
+	Index int    `json:"index"`
+}
+
+// filterPositions holds the resume points of filters by signature, most
+// recently left last. The unfiltered list has the signature "".
+type filterPositions struct {
+	positions map[string]filterPosition
+	order     []string
+}
+
+func newFilterPositions() *filterPositions {
+	return &filterPositions{positions: make(map[string]filterPosition)}
+}
+
+// remember stores the position in the filter with signature sig.
+func (fp *filterPositions) remember(sig string, pos filterPosition) {
+	if i := slices.Index(fp.order, sig); i >= 0 {
+		fp.order = slices.Delete(fp.order, i, i+1)
+	}
+	fp.positions[sig] = pos
+	fp.order = append(fp.order, sig)
+	for len(fp.order) > maxFilterPositions {
+		delete(fp.positions, fp.order[0])
+		fp.order = fp.order[1:]
+	}
+}
+
+// lookup returns the position remembered for the filter with signature sig.
+func (fp *filterPositions) lookup(sig string) (filterPosition, bool) {
+	pos, ok := fp.positions[sig]
+	return pos, ok
+}
+
+// savedFilterPosition is a resume point as persisted, in order.
+type savedFilterPosition struct {
+	Filter string `json:"filter"`
+	filterPosition
+}
+
+// MarshalJSON encodes the positions oldest first, so loading them keeps their order.
+func (fp *filterPositions) MarshalJSON() ([]byte, error) {
+	saved := make([]savedFilterPosition, 0, len(fp.order))
+	for _, sig := range fp.order {
+		saved = append(saved, savedFilterPosition{Filter: sig, filterPosition: fp.positions[sig]})
+	}
+	return json.Marshal(saved)
+}
+
+// UnmarshalJSON adds positions encoded by MarshalJSON.
+func (fp *filterPositions) UnmarshalJSON(data []byte) error {
+	var saved []savedFilterPosition
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	for _, s := range saved {
+		fp.remember(s.Filter, s.filterPosition)
+	}
+	return nil
+}
+
+// currentFilterSignature returns the signature of the active filter, "" when
+// the full list is shown.
+func (a *App) currentFilterSignature() string {
+	if !a.isFiltered {
+		return ""
+	}
+	return a.currentFilter.Signature()
+}
+
+// rememberFilterPosition records the image shown as the resume point of the
+// active filter, before the filter changes.
+func (a *App) rememberFilterPosition() {
+	if a.filterPositions == nil {
+		return
+	}
+	if item := a.getCurrentItem(); item != nil {
+		a.filterPositions.remember(a.currentFilterSignature(), filterPosition{Path: item.Path, Index: a.index})
+	}
+}
+
+// filterResumePoint returns the remembered position in the active filter.
+func (a *App) filterResumePoint() (filterPosition, bool) {
+	if a.filterPositions == nil {
+		return filterPosition{}, false
+	}
+	return a.filterPositions.lookup(a.currentFilterSignature())
+}
+
+// loadFilterPositions restores the resume points of the previous session.
+func (a *App) loadFilterPositions() {
+	a.filterPositions = newFilterPositions()
+	if saved := a.prefs().String(prefFilterPositions); saved != "" {
+		if err := json.Unmarshal([]byte(saved), a.filterPositions); err != nil {
+			a.addLogMessage("Ignoring unreadable saved filter positions: " + err.Error())
+		}
+	}
+}
+
+// saveFilterPositions persists the resume points, including the current
+// filter's, for the next session.
+func (a *App) saveFilterPositions() {
+	a.rememberFilterPosition()
+	if data, err := json.Marshal(a.filterPositions); err == nil {
+		a.prefs().SetString(prefFilterPositions, string(data))
+	}
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"encoding/json"
+	"fyslide/internal/query"
+	"fyslide/internal/scan"
+	"testing"
+)
+
+func TestFilterResume(t *testing.T) {
+	a := &App{
+		images:          scan.FileItems{{Path: "/a"}, {Path: "/b"}, {Path: "/c"}, {Path: "/d"}},
+		filterPositions: newFilterPositions(),
+	}
+	cats := query.Criteria{Tags: []string{"cats"}}
+
+	// Leave the full list at /c, then a filter at its second image.
+	a.index = 2
+	a.rememberFilterPosition()
+	a.isFiltered, a.currentFilter = true, cats
+	a.filteredImages = scan.FileItems{{Path: "/b"}, {Path: "/d"}}
+	if got := a.takeStartIndex(); got != 0 {
+		t.Errorf("New filter starts at %d, want 0", got)
+	}
+	a.index = 1
+	a.rememberFilterPosition()
+
+	a.isFiltered, a.currentFilter, a.filteredImages = false, query.Criteria{}, nil
+	if got := a.takeStartIndex(); got != 2 {
+		t.Errorf("Full list resumes at %d, want 2", got)
+	}
+
+	// The filter resumes at /d even when the list around it changed.
+	data, err := json.Marshal(a.filterPositions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.filterPositions = newFilterPositions()
+	if err := json.Unmarshal(data, a.filterPositions); err != nil {
+		t.Fatal(err)
+	}
+	a.isFiltered, a.currentFilter = true, cats
+	a.filteredImages = scan.FileItems{{Path: "/a"}, {Path: "/b"}, {Path: "/d"}}
+	if got := a.takeStartIndex(); got != 2 {
+		t.Errorf("Filter resumes at %d, want 2 (/d)", got)
+	}
+}
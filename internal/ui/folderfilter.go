@@ -0,0 +1,168 @@
+// Package ui Filter by folder: picks one of the folders holding images, by
+// typing part of its path, to filter the slideshow to its subtree; the
+// folders filtered to last are offered again in View > Recent Folders.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/scan"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maxRecentFolders is how many of the folders filtered to last are remembered.
+const maxRecentFolders = 10
+
+// folderCount is a folder holding images and how many lie in it or below it.
+type folderCount struct {
+	dir   string
+	count int
+}
+
+// folderCounts returns the folders directly holding one of items, sorted by
+// path, each counting the images in its subtree as filtering to it shows.
+func folderCounts(items scan.FileItems) []folderCount {
+	direct := make(map[string]int)
+	for _, item := range items {
+		direct[filepath.Dir(item.Path)]++
+	}
+	total := make(map[string]int, len(direct))
+	for dir, n := range direct {
+		for _, folder := range folderAncestors(dir) {
+			if _, ok := direct[folder]; ok {
+				total[folder] += n
+			}
+		}
+	}
+	folders := make([]folderCount, 0, len(total))
+	for dir, n := range total {
+		folders = append(folders, folderCount{dir: dir, count: n})
+	}
+	slices.SortFunc(folders, func(x, y folderCount) int { return strings.Compare(x.dir, y.dir) })
+	return folders
+}
+
+// matchFolders returns the folders whose path contains every word of text,
+// ignoring case.
+func matchFolders(folders []folderCount, text string) []folderCount {
+	words := strings.Fields(strings.ToLower(text))
+	if len(words) == 0 {
+		return folders
+	}
+	var matched []folderCount
+	for _, f := range folders {
+		dir := strings.ToLower(f.dir)
+		if !slices.ContainsFunc(words, func(word string) bool { return !strings.Contains(dir, word) }) {
+			matched = append(matched, f)
+		}
+	}
+	return matched
+}
+
+// showFolderFilterDialog lists the folders holding browsed images with their
+// image counts, narrowed down by typing, and filters to the one picked.
+func (a *App) showFolderFilterDialog() {
+	all := folderCounts(a.images)
+	if len(all) == 0 {
+		dialog.ShowInformation("Filter by Folder", "No images are loaded.", a.UI.MainWin)
+		return
+	}
+	a.slideshowManager.Pause(true)
+
+	shown := all
+	status := widget.NewLabel("")
+	showStatus := func() {
+		status.SetText(fmt.Sprintf("%d of %d folders. Filtering to a folder includes its subfolders.", len(shown), len(all)))
+	}
+	showStatus()
+	list := widget.NewList(
+		func() int { return len(shown) },
+		func() fyne.CanvasObject {
+			dir := widget.NewLabel("")
+			dir.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, nil, widget.NewLabel(""), dir)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			row := obj.(*fyne.Container).Objects
+			row[0].(*widget.Label).SetText(shown[id].dir)
+			row[1].(*widget.Label).SetText(formatNumberWithCommas(int64(shown[id].count)))
+		},
+	)
+
+	queryEntry := widget.NewEntry()
+	queryEntry.SetPlaceHolder("Type part of a folder path...")
+	queryEntry.OnChanged = func(text string) {
+		shown = matchFolders(all, text)
+		showStatus()
+		list.UnselectAll()
+		list.Refresh()
+		list.ScrollToTop()
+	}
+
+	d := dialog.NewCustom("Filter by Folder", "Close", container.NewBorder(queryEntry, status, nil, nil, list), a.UI.MainWin)
+	list.OnSelected = func(id widget.ListItemID) {
+		if id >= len(shown) {
+			return
+		}
+		folder := shown[id].dir
+		d.Hide()
+		a.filterToFolder(folder)
+	}
+	queryEntry.OnSubmitted = func(string) {
+		if len(shown) == 1 {
+			list.Select(0)
+		}
+	}
+	d.SetOnClosed(a.slideshowManager.ResumeAfterOperation)
+	d.Resize(fyne.NewSize(searchDialogWidth, a.UI.MainWin.Canvas().Size().Height*0.8))
+	d.Show()
+	a.UI.MainWin.Canvas().Focus(queryEntry)
+}
+
+// rememberFolder moves folder to the front of the recently filtered folders.
+func (a *App) rememberFolder(folder string) {
+	a.prefs().SetStringList(prefRecentFolders, withRecent(a.prefs().StringList(prefRecentFolders), []string{folder}, maxRecentFolders))
+	a.refreshRecentFoldersMenu()
+}
+
+// buildRecentFoldersMenuItem creates the View > Recent Folders submenu.
+func (a *App) buildRecentFoldersMenuItem() *fyne.MenuItem {
+	item := fyne.NewMenuItem("Recent Folders", nil)
+	a.UI.recentFoldersMenu = fyne.NewMenu("Recent Folders")
+	item.ChildMenu = a.UI.recentFoldersMenu
+	a.refreshRecentFoldersMenu()
+	return item
+}
+
+// refreshRecentFoldersMenu lists the folders filtered to last, most recent
+// first.
+func (a *App) refreshRecentFoldersMenu() {
+	if a.UI.recentFoldersMenu == nil {
+		return
+	}
+	folders := a.prefs().StringList(prefRecentFolders)
+	items := make([]*fyne.MenuItem, 0, len(folders)+2)
+	for _, folder := range folders {
+		items = append(items, fyne.NewMenuItem(folder, func() { a.filterToFolder(folder) }))
+	}
+	if len(folders) == 0 {
+		none := fyne.NewMenuItem("(no folders yet)", nil)
+		none.Disabled = true
+		items = append(items, none)
+	} else {
+		items = append(items, fyne.NewMenuItemSeparator(), fyne.NewMenuItem("Clear Recent Folders", func() {
+			a.prefs().SetStringList(prefRecentFolders, nil)
+			a.refreshRecentFoldersMenu()
+		}))
+	}
+	a.UI.recentFoldersMenu.Items = items
+	if a.UI.MainWin != nil && a.UI.MainWin.MainMenu() != nil {
+		a.UI.MainWin.MainMenu().Refresh()
+	}
+}
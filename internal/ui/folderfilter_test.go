@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/scan"
+	"path/filepath"
+	"testing"
+)
+
+func TestFolderCounts(t *testing.T) {
+	var items scan.FileItems
+	for _, path := range []string{"/p/2023/a.jpg", "/p/2023/b.jpg", "/p/2023/trip/c.jpg", "/p/2024/d.jpg", "/p/2023-old/e.jpg"} {
+		items = append(items, scan.FileItem{Path: filepath.FromSlash(path)})
+	}
+	got := fmt.Sprint(folderCounts(items))
+	want := fmt.Sprint([]folderCount{
+		{filepath.FromSlash("/p/2023"), 3},
+		{filepath.FromSlash("/p/2023-old"), 1},
+		{filepath.FromSlash("/p/2023/trip"), 1},
+		{filepath.FromSlash("/p/2024"), 1},
+	})
+	if got != want {
+		t.Errorf("folderCounts = %s, want %s", got, want)
+	}
+
+	folders := folderCounts(items)
+	if got := matchFolders(folders, "TRIP 2023"); len(got) != 1 || got[0].dir != filepath.FromSlash("/p/2023/trip") {
+		t.Errorf("matchFolders(TRIP 2023) = %v", got)
+	}
+	if got := matchFolders(folders, "  "); len(got) != len(folders) {
+		t.Errorf("matchFolders of blank text = %v, want every folder", got)
+	}
+}
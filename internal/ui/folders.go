@@ -0,0 +1,80 @@
+// Package ui Folder navigation: jump to the next or previous folder in the current
+// order, and name the folder when playback crosses into another one.
+package ui
+
+import (
+	"fyslide/internal/scan"
+	"path/filepath"
+	"time"
+)
+
+// folderCaptionDuration is how long the folder name stays up after crossing
+// into another folder.
+const folderCaptionDuration = 2 * time.Second
+
+// folderStart returns the index of the first image of the folder after
+// (direction > 0) or before (direction < 0) that of list[from], counting each
+// run of consecutive images in a folder as one, and wrapping around at the
+// ends. It returns -1 if every image is in the same folder.
+func folderStart(list scan.FileItems, from, direction int) int {
+	if from < 0 || from >= len(list) {
+		return -1
+	}
+	dir := func(i int) string { return filepath.Dir(list[i].Path) }
+	// runStart returns the first index of the run containing i.
+	runStart := func(i int) int {
+		for i > 0 && dir(i-1) == dir(i) {
+			i--
+		}
+		return i
+	}
+
+	start := runStart(from)
+	var next int
+	if direction >= 0 {
+		next = from + 1
+		for next < len(list) && dir(next) == dir(from) {
+			next++
+		}
+		if next == len(list) {
+			next = 0
+		}
+	} else {
+		next = start - 1
+		if next < 0 {
+			next = len(list) - 1
+		}
+		next = runStart(next)
+	}
+	if next == start {
+		return -1 // Wrapped around to the same run
+	}
+	return next
+}
+
+// showFolder shows the first image of the next (direction > 0) or previous
+// folder in the current list.
+func (a *App) showFolder(direction int) {
+	index := folderStart(a.getCurrentList(), a.index, direction)
+	if index < 0 {
+		a.addLogMessage("All images are in the same folder.")
+		return
+	}
+	a.isNavigatingHistory = false
+	a.direction = 1
+	a.showIndex(index)
+}
+
+// noteFolderChange names the folder of path, just displayed, if it differs
+// from that of the image displayed before.
+func (a *App) noteFolderChange(path string) {
+	dir := filepath.Dir(path)
+	if a.lastFolder != "" && dir != a.lastFolder {
+		if a.prefs().Bool(prefFolderSummary) {
+			a.showFolderSummary(dir)
+		} else {
+			a.zoomPanArea.ShowCaption(filepath.Base(dir), "", folderCaptionDuration)
+		}
+	}
+	a.lastFolder = dir
+}
@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"fyslide/internal/scan"
+	"testing"
+)
+
+func TestFolderStart(t *testing.T) {
+	list := scan.FileItems{
+		{Path: "/p/a/1.jpg"}, {Path: "/p/a/2.jpg"},
+		{Path: "/p/b/1.jpg"},
+		{Path: "/p/c/1.jpg"}, {Path: "/p/c/2.jpg"}, {Path: "/p/c/3.jpg"},
+	}
+	tests := []struct {
+		from, direction, want int
+	}{
+		{0, 1, 2},
+		{1, 1, 2},
+		{2, 1, 3},
+		{4, 1, 0},  // Wraps to the first folder
+		{4, -1, 2}, // Previous folder's first image, not the current one's
+		{2, -1, 0},
+		{1, -1, 3}, // Wraps to the last folder's first image
+	}
+	for _, tt := range tests {
+		if got := folderStart(list, tt.from, tt.direction); got != tt.want {
+			t.Errorf("folderStart(from %d, direction %d) = %d, want %d", tt.from, tt.direction, got, tt.want)
+		}
+	}
+
+	single := scan.FileItems{{Path: "/p/a/1.jpg"}, {Path: "/p/a/2.jpg"}}
+	if got := folderStart(single, 1, 1); got != -1 {
+		t.Errorf("folderStart in a single folder = %d, want -1", got)
+	}
+}
@@ -0,0 +1,119 @@
+// Package ui Folder summary: on entering another folder, the caption naming
+// it also tells how many images it holds, when they were taken and the tags
+// used on them most.
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"fyslide/internal/scan"
+	"fyslide/internal/tagging"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	// folderSummaryDuration is how long a folder summary stays up, longer than
+	// the bare name as it takes longer to read.
+	folderSummaryDuration = 4 * time.Second
+	// maxSummaryTags is how many of the tags used most a folder summary lists.
+	maxSummaryTags = 5
+)
+
+// tagCount is a tag and the number of images carrying it.
+type tagCount struct {
+	tag   string
+	count int
+}
+
+// folderSummary describes the images directly in one folder.
+type folderSummary struct {
+	count       int
+	first, last time.Time  // Dates the images were taken, zero if none is known
+	topTags     []tagCount // Used most first, at most maxSummaryTags
+}
+
+// summarizeFolder summarizes items, the images of one folder. Dates come
+// from the EXIF index info where it has the image, else from the file's
+// modification time. tagsOf reads the tags of an image; images whose tags
+// can't be read count without them.
+func summarizeFolder(items scan.FileItems, info map[string]tagging.ImageInfo, tagsOf func(path string) ([]string, error)) folderSummary {
+	s := folderSummary{count: len(items)}
+	counts := make(map[string]int)
+	for _, item := range items {
+		taken := info[item.Path].Taken
+		if taken.IsZero() && item.Info != nil {
+			taken = item.Info.ModTime()
+		}
+		if !taken.IsZero() {
+			if s.first.IsZero() || taken.Before(s.first) {
+				s.first = taken
+			}
+			if taken.After(s.last) {
+				s.last = taken
+			}
+		}
+		if tags, err := tagsOf(item.Path); err == nil {
+			for _, tag := range tags {
+				counts[tag]++
+			}
+		}
+	}
+	for tag, n := range counts {
+		s.topTags = append(s.topTags, tagCount{tag: tag, count: n})
+	}
+	slices.SortFunc(s.topTags, func(x, y tagCount) int {
+		return cmp.Or(y.count-x.count, strings.Compare(x.tag, y.tag))
+	})
+	s.topTags = s.topTags[:min(len(s.topTags), maxSummaryTags)]
+	return s
+}
+
+// String describes s in one line, e.g.
+// "12 images · 3 May 2021 – 5 Jun 2021 · beach (8), sunset (3)".
+func (s folderSummary) String() string {
+	parts := []string{fmt.Sprintf("%s image(s)", formatNumberWithCommas(int64(s.count)))}
+	const layout = "2 Jan 2006"
+	switch {
+	case s.first.IsZero():
+	case s.first.Format(layout) == s.last.Format(layout):
+		parts = append(parts, s.first.Format(layout))
+	default:
+		parts = append(parts, s.first.Format(layout)+" – "+s.last.Format(layout))
+	}
+	if len(s.topTags) > 0 {
+		tags := make([]string, len(s.topTags))
+		for i, t := range s.topTags {
+			tags[i] = fmt.Sprintf("%s (%d)", t.tag, t.count)
+		}
+		parts = append(parts, strings.Join(tags, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// showFolderSummary names dir, just entered, with a summary of the browsed
+// images directly in it. The tags are read in the background; the summary is
+// dropped if another folder was entered meanwhile.
+func (a *App) showFolderSummary(dir string) {
+	var items scan.FileItems
+	for _, item := range a.images {
+		if filepath.Dir(item.Path) == dir {
+			items = append(items, item)
+		}
+	}
+	info := a.imageInfo
+	a.goBackground(func() {
+		s := summarizeFolder(items, info, func(path string) ([]string, error) {
+			return a.tagDB.GetTags(a.ctx, path)
+		})
+		fyne.Do(func() {
+			if a.lastFolder == dir {
+				a.zoomPanArea.ShowCaption(filepath.Base(dir), s.String(), folderSummaryDuration)
+			}
+		})
+	})
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"errors"
+	"fyslide/internal/scan"
+	"fyslide/internal/tagging"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSummarizeFolder(t *testing.T) {
+	items := scan.FileItems{{Path: "/p/a/1.jpg"}, {Path: "/p/a/2.jpg"}, {Path: "/p/a/3.jpg"}, {Path: "/p/a/4.jpg"}}
+	day := func(d int) time.Time { return time.Date(2021, time.May, d, 12, 0, 0, 0, time.Local) }
+	info := map[string]tagging.ImageInfo{
+		"/p/a/1.jpg": {Taken: day(5)},
+		"/p/a/2.jpg": {Taken: day(3)},
+		"/p/a/3.jpg": {Taken: day(20)},
+	}
+	tags := map[string][]string{
+		"/p/a/1.jpg": {"beach", "sunset"},
+		"/p/a/2.jpg": {"beach", "family"},
+		"/p/a/3.jpg": {"beach", "sunset", "dog"},
+	}
+	s := summarizeFolder(items, info, func(path string) ([]string, error) {
+		if path == "/p/a/4.jpg" {
+			return nil, errors.New("unreadable")
+		}
+		return tags[path], nil
+	})
+
+	if s.count != 4 || !s.first.Equal(day(3)) || !s.last.Equal(day(20)) {
+		t.Errorf("summary = %d images from %v to %v, want 4 from %v to %v", s.count, s.first, s.last, day(3), day(20))
+	}
+	want := []tagCount{{"beach", 3}, {"sunset", 2}, {"dog", 1}, {"family", 1}}
+	if !reflect.DeepEqual(s.topTags, want) {
+		t.Errorf("top tags = %v, want %v", s.topTags, want)
+	}
+	if got, want := s.String(), "4 image(s) · 3 May 2021 – 20 May 2021 · beach (3), sunset (2), dog (1), family (1)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	undated := summarizeFolder(items[3:], nil, func(string) ([]string, error) { return nil, nil })
+	if got, want := undated.String(), "1 image(s)"; got != want {
+		t.Errorf("String() without dates or tags = %q, want %q", got, want)
+	}
+}
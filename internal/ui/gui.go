@@ -73,21 +73,29 @@ func (a *App) buildToolbar() *widget.Toolbar {
 	a.UI.pauseAction = widget.NewToolbarAction(initialPauseIcon, a.togglePlay)
 	a.UI.showFullSizeAction = widget.NewToolbarAction(theme.ZoomInIcon(), a.handleShowFullSizeBtn)
 	a.UI.showFullSizeAction.Disable() // Initially disabled
+	a.UI.zoomFitAction = widget.NewToolbarAction(theme.ZoomFitIcon(), a.handleZoomToFitBtn)
+	a.UI.zoomFitAction.Disable() // Initially disabled
+	a.UI.quickFilter = newQuickFilterEntry(a)
+	a.UI.filterCount = widget.NewLabel("")
 
 	t := widget.NewToolbar(
-		widget.NewToolbarAction(theme.CancelIcon(), func() { a.app.Quit() }),
-		widget.NewToolbarAction(theme.MediaFastRewindIcon(), a.firstImage),
-		widget.NewToolbarAction(theme.MediaSkipPreviousIcon(), a.ShowPreviousImage),
+		a.commandToolbarAction(theme.CancelIcon(), "quit"),
+		a.commandToolbarAction(theme.MediaFastRewindIcon(), "first"),
+		a.commandToolbarAction(theme.MediaSkipPreviousIcon(), "previous"),
 		a.UI.pauseAction,
-		widget.NewToolbarAction(theme.MediaSkipNextIcon(), func() { a.direction = 1; a.nextImage() }), // Changed icon
-		widget.NewToolbarAction(theme.MediaFastForwardIcon(), a.lastImage),
-		widget.NewToolbarAction(theme.DocumentIcon(), a.addTag), // Changed from a.tagFile
-		widget.NewToolbarAction(theme.ContentRemoveIcon(), a.removeTag),
-		widget.NewToolbarAction(theme.DeleteIcon(), a.deleteFileCheck),
+		a.commandToolbarAction(theme.MediaSkipNextIcon(), "next"),
+		a.commandToolbarAction(theme.MediaFastForwardIcon(), "last"),
+		a.commandToolbarAction(theme.DocumentIcon(), "tag add"),
+		a.commandToolbarAction(theme.ContentRemoveIcon(), "tag remove"),
+		a.mutatingToolbarAction(theme.ContentAddIcon(), a.showTagSetsPopUp),
+		a.commandToolbarAction(theme.DeleteIcon(), "delete"),
 		a.UI.randomAction,
+		a.commandToolbarAction(theme.SearchIcon(), "similar"),
 		widget.NewToolbarSeparator(),
+		a.UI.zoomFitAction,
 		a.UI.showFullSizeAction,
 		widget.NewToolbarSpacer(),
+		&quickFilterToolbarItem{entry: a.UI.quickFilter, count: a.UI.filterCount},
 
 		widget.NewToolbarAction(theme.FileImageIcon(), func() { // Button for Image View
 			a.selectStackView(imageViewIndex) // Switch to image view
@@ -161,7 +169,7 @@ func (a *App) buildTagsTab() (fyne.CanvasObject, func()) {
 	loadAndFilterTagData := func() {
 		var err error
 		// a.tagDB.GetAllTags() now returns []tagging.TagWithCount, error
-		fetchedTagsWithCounts, err := a.tagDB.GetAllTags()
+		fetchedTagsWithCounts, err := a.tagDB.GetAllTags(a.ctx)
 		if err != nil {
 			a.addLogMessage(fmt.Sprintf("Error loading/refreshing tags: %v", err))
 			allTags = []tagListItem{}
@@ -213,20 +221,19 @@ func (a *App) buildTagsTab() (fyne.CanvasObject, func()) {
 				return
 			}
 
-			a.addLogMessage(fmt.Sprintf("User confirmed global removal of tag: %s", selectedTagForAction))
-			err := a.removeTagGlobally(selectedTagForAction) // Call the new global removal function
-
-			if err != nil {
-				// Error is already logged by removeTagGlobally (via addLogMessage) and shown in dialog
-				dialog.ShowError(fmt.Errorf("failed to globally remove tag '%s': %w", selectedTagForAction, err), a.UI.MainWin)
-			} else {
-				// Success message is logged by removeTagGlobally (via addLogMessage)
-				dialog.ShowInformation("Success", fmt.Sprintf("Tag '%s' removed globally.", selectedTagForAction), a.UI.MainWin)
+			tag := selectedTagForAction // The selection may change before the removal completes
+			a.addLogMessage(fmt.Sprintf("User confirmed global removal of tag: %s", tag))
+			a.removeTagGlobally(tag, func(err error) {
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("failed to globally remove tag '%s': %w", tag, err), a.UI.MainWin)
+					return
+				}
+				dialog.ShowInformation("Success", fmt.Sprintf("Tag '%s' removed globally.", tag), a.UI.MainWin)
 				// Refresh the list after successful removal
 				loadAndFilterTagData()
 				// Deselect and disable button after action
 				tagList.UnselectAll()
-			}
+			})
 		}, a.UI.MainWin)
 	})
 	removeButton.Disable() // Start disabled
@@ -258,7 +265,9 @@ func (a *App) buildTagsTab() (fyne.CanvasObject, func()) {
 		// No need to check for placeholder (Count == -1) as list only contains real tags now.
 		selectedItem := filteredDisplayData[id]
 		selectedTagForAction = selectedItem.Name // Store only the name for actions
-		removeButton.Enable()
+		if !a.readOnly() {
+			removeButton.Enable()
+		}
 		// log.Printf("Tag selected from list: %s (Count: %d)", selectedItem.Name, selectedItem.Count)
 		a.applyFilter(selectedItem.Name) // Apply filter using only the tag name
 		if a.UI.contentStack != nil {
@@ -295,25 +304,77 @@ FySlide is an image viewer with tagging capabilities.
 
 **Core Features:**
 *   **Image Viewing:** Navigate through images using toolbar buttons or keyboard shortcuts.
-    *   **Slideshow:** Automatically cycles through images. Play/Pause with the toolbar button or 'P'/Space.
+    *   **Slideshow:** Automatically cycles through images. Play/Pause with the toolbar button or 'P'/Space. Every image gets its full display time, also one reached by hand, so the slideshow never moves on a moment after you navigated. "Slideshow interval" and "Page Up/Down skips" in File > Preferences change the display time and the skip step from then on; a value given on the command line still wins at the next start.
+    *   **Adaptive Timing:** With "Show large images and panoramas longer" in File > Preferences, each image's display time grows with its resolution (beyond 12 megapixels) and with how much wider than 16:9 it is. The shortest and longest times are set in seconds next to the option.
     *   **Navigation:** Next/Previous, First/Last, Skip (PageUp/PageDown).
     *   **Random Mode:** Toggle random image display with the dice icon.
+    *   **Opening an Image:** Started with an image, as in fyslide photo.jpg, or by a fyslide://open?path=... link from another app, FySlide scans the image's folder and opens paused at it, browsing the folder in order. Run fyslide -register-links once to handle those links.
+    *   **Sharing a View:** View > Copy View Link copies a fyslide://view link holding the library folders, the active filter, random or ordered playback and the current image. View > Open View Link... in another window, or fyslide -view <link> when starting, shows the same images in the same order at the same image.
+    *   **Kiosk Mode:** Started with --kiosk, or kiosk: true under slideshow in config.yaml, FySlide runs fullscreen as a photo frame and switches the filter by the slideshow schedule of config.yaml, e.g. family photos in the mornings and landscapes in the evenings. The first entry active at the time applies; with none, all images are shown. A filter picked by hand stays until the next entry starts. Under night in the slideshow settings, the images are shown dimmed during night hours and the screen goes black, with the slideshow paused, during blank hours; both end by themselves.
 *   **Tagging:**
-    *   **Add Tags:** Assign tags to the current image or all images in the current directory.
+    *   **Add Tags:** Assign tags to the current image or all images in the current directory. Tags added recently, and tags already used on other images in the same folder, are offered as one-click suggestions. Edit > Reapply Last Tags (Ctrl+Shift+T) adds the tags added last to the current image in one keystroke.
+    *   **Copy and Paste Tags:** Edit > Copy Tags (Ctrl+Shift+C) keeps the tags of the current image, and Edit > Paste Tags (Ctrl+Shift+V) adds them to another. Edit > Copy Tags from Previous Image (Ctrl+Shift+D) adds the tags of the image before the current one in the list, so after tagging the first shot of a burst each following shot takes them in one keystroke. Tags the image already has stay. With "Copy the EXIF title along with tags" set in Preferences, the title of a JPEG is copied too.
+    *   **Tag Sets:** Named groups of tags, such as "beach trip" for beach, family and 2024, are defined in Edit > Tag Sets > Manage Tag Sets... and applied to the current image from that menu or the toolbar's tag set button, or picked in the Add Tag dialog. They are stored in the tag database; the CLI applies them with apply-set.
     *   **Remove Tags:** Remove tags from the current image or all images in the current directory.
     *   **Global Tag Removal:** Remove a specific tag from all images in the database (via Tags View).
+*   **Folder Default Tags:** A .fyslide-tags file in a folder lists tags (comma or line separated, '#' comments) for the images in it. A banner offers to apply them to the folder's untagged images, Edit > Apply Folder Default Tags applies them to all of its images, and Preferences can apply them automatically after every scan. The CLI's apply-dir-defaults does the same from the command line.
+*   **EXIF Tags:** Preferences can add namespaced tags from each scanned image's EXIF data, such as camera:PixelFold, lens:..., year:2024, month:2024-06 and iso:low/medium/high. Tick only the namespaces you want so the tag list stays manageable. The CLI's autotag exif adds them in bulk, and autotag filename derives tags from file names with regex rules.
+*   **Bookmarks:** View > Bookmarks > Add Bookmark... saves the current image together with the active filter and random/ordered playback under a name, such as "left off in 2019 vacation". Choosing the bookmark later restores all three; if the image is gone, the image now at its position is shown. Bookmarks are stored in the tag database, so each profile has its own.
+*   **Stacks:** Bursts and other series can be stacked so the slideshow shows them as one image, the stack's first. Press M (Edit > Mark/Unmark for Stack) on each image and then Edit > Stack Marked Images, or let Edit > Auto-Stack Bursts... stack images of the same folder taken within a few seconds of each other that look alike. X expands the current stack to step through its images and folds it back. While a stack is collapsed, Add Tag, Remove Tag and Delete offer to act on the whole stack. Edit > Unstack dissolves it. Membership is kept as stack:ID tags.
+*   **Find Similar:** The magnifier toolbar button (or View > Find Similar Images) shows up to 100 images of the library that look like the current one, such as other shots of the same scene, closest first, in the slideshow and thumbnail strip. It compares perceptual hashes, so brightness changes and resizing don't matter. Clear Filter returns to all images.
+*   **Colors:** View > Find Similar Colors shows up to 100 images of the library colored like the current one, closest first (Clear Filter returns to all images). Preferences can tag scanned images with their dominant colors (color:red, color:blue, ...) or color:bw; the CLI's autotag color does it in bulk. Color signatures are cached and only recomputed for changed files.
+*   **Image Quality:** Edit > Check Image Quality analyzes the images of the current list and tags the likely blurry ones quality:blurry and the badly exposed ones quality:underexposed or quality:overexposed. Flagged images get a warning badge in the thumbnail strip; filter by a quality tag to cull them, and remove the tag from images worth keeping. The CLI's analyze command does the same for a folder. These are heuristics, so check before deleting.
 *   **Filtering:**
-    *   Filter the displayed images by selecting a tag (via Menu > View > Filter by Tag... or by clicking a tag in the Tags View).
+    *   Filter the displayed images by tag, date range, camera, resolution, orientation or file size (via Menu > View > Filter Images... or by clicking a tag in the Tags View).
+    *   Type a tag in the toolbar's quick filter box to filter immediately; enter several comma-separated tags and press Enter to show only images carrying all of them. Escape clears the filter.
+    *   View > Filter by Folder... lists the folders holding images with how many images each has, including its subfolders. Type part of a path to narrow the list and pick a folder to show only the images in it and below it. The folders filtered to last, also by clicking the breadcrumbs, are offered again in View > Recent Folders.
     *   Clear the filter to see all images again.
-*   **Image Deletion:** Delete the currently viewed image (with confirmation).
+*   **Folder Summary:** When the slideshow enters another folder, its name is shown at the bottom of the image. With "Summarize a folder on entering it" set in Preferences, a second line adds how many images the folder holds, the dates they were taken and the tags used on them most. Dates come from the EXIF index where it has them, otherwise from the file times.
+*   **Search:** Find images by any part of their file name, folder path, tags or text read by OCR (Ctrl+F). Every word typed must match; pick a result to jump to it.
+*   **Command Palette:** Ctrl+K or View > Command Palette... runs any menu or toolbar command by typing part of its name; Up and Down pick among the matches and Enter runs the selected one. Commands take arguments, e.g. tag add beach, filter sunset AND 2023, goto 1523 or sort date desc.
+*   **Go to Image:** View > Go to Image... (Ctrl+G) takes an image number, counting from 1 in the current (filtered) list, or part of a file name. Matching names are listed as you type; Enter goes to the numbered image or the first match.
+*   **Timeline:** View > Timeline... groups the library by the date each image was taken (from its EXIF data, else the file's modification time) into years, months and days with their image counts. Select one to go to its first image or to show only the images of those dates. Dates are kept in the database, so only new and changed files are read again.
+*   **EXIF Index:** View > Index EXIF Data reads the date, camera, GPS position and dimensions of every new or changed image into the database in the background, with its progress in the status bar; choose it again to stop. Filters by date, camera or dimensions then use the index instead of reading each file.
+*   **Change History:** Every tag added or removed and every rename, delete and cleanup is recorded with who made it and when. Browse it via Menu > View > Change History..., filter by tag or path, and export it to CSV.
+*   **Usage Statistics:** FySlide counts the commands run, tags added and created, and images processed and viewed per day, in the tag database on this computer only; nothing is ever sent anywhere. See them via Menu > View > Usage Statistics... or with fyslide-cli usage-stats, and export them to CSV.
+*   **Private Images:** Once a PIN is set in Preferences, images carrying the private tag (default 'private') are hidden from browsing, filters and search. Unlock them with Menu > View > Unlock Private Images... and lock them again when done.
+*   **Rename:** Rename the current image in place (F2); its tags and history follow the new name.
+*   **Date and Title:** Edit > Edit Date and Title... writes the date taken and a title into a JPEG's EXIF data, e.g. for scanned photos. Edit > Correct Dates... shifts the dates of the current image, its folder or the current list by a number of hours, or converts them from the time zone the camera was set to into the actual one, previewing the first images' dates before and after. It can then sort the current list by the corrected dates.
+*   **External Editor:** Open the current image in the editor configured in Preferences (Ctrl+E), optionally reloading it when the editor saves.
+*   **Transform Scans:** Edit > Transform Scans... turns the current image, the images marked for stacking, its folder or the current list by a quarter or half turn, straightens a slight skew found from their straight edges, and crops the uniform borders a scanner leaves around film frames. The first image is shown before and after so the settings can be checked before they are applied. The files are rewritten in place, keeping the EXIF data of JPEGs. The CLI's transform command does the same for a folder.
+*   **Text (OCR):** Edit > Extract Text (OCR) reads the text of the images of the current list, such as scanned documents, with tesseract or the OCR engine set under ocr in config.yaml. Images are read again only after they change. The text is kept in the tag database, shown under Text in the info panel and found by Search (Ctrl+F) and by the filter's Text contains field. The CLI's ocr command does the same for a folder.
+*   **Loupe:** View > Loupe (L) adds a pane beside the image showing the point under the pointer at 100%, while the image stays fitted to the window, to check focus without losing the context. Scroll over the loupe to change its zoom for the current image.
+*   **Annotations:** Edit > Annotate (A) draws over the current image: pick Arrow, Box or Text and a color in the bar that appears, then drag over the image (click to place text). Undo and Clear remove annotations again, and Done or A ends annotating. Annotations are stored in the tag database, never in the file, and follow the image when it is renamed. View > Show/Hide Annotations toggles them, and File > Export Annotated Copy... (or Export Copy... in the bar) saves a full-resolution JPEG or PNG with them drawn in, e.g. to mark issues on scanned documents and photos.
+*   **Paste Image:** Edit > Paste Image (Ctrl+V) saves the image on the clipboard, such as a screenshot, as a PNG in the inbox folder set in Preferences (by default Inbox in the first library folder), shows it and opens the tag dialog. On Linux this needs wl-paste or xclip, on macOS pngpaste; a copied image file or data URL works everywhere.
+*   **Event Stream:** Start with --events ws://:8090 to broadcast image changes, pause/resume, tag changes and filter changes as JSON to connected WebSocket clients, e.g. for home automation.
+*   **Photo Frames (MQTT):** Start with --mqtt-broker tcp://broker:1883 to publish the current image and status under fyslide/<hostname> (or --mqtt-topic) and accept the commands next, previous, pause, play, toggle, "set-filter tag=holiday" and clear-filter on <topic>/command. Frames started with the same --mqtt-group also follow <group>/command.
+*   **Read-only Mode:** Start with --read-only to browse without any risk of changes: tagging, renaming, editing and deletion are disabled.
+*   **Image Deletion:** Delete the currently viewed image (with confirmation). Images tagged keep or favorite, or with the tags under delete: protected_tags in config.yaml, are protected: they are only deleted if "Delete protected images anyway" is ticked as well. Edit > Delete All in Filter... deletes every image the current filter shows, after previewing their thumbnails and having their number typed in, with a progress bar that can stop it.
+*   **Problem Files:** An image that fails to load or decode 3 times is quarantined: it is skipped from then on, also in later sessions and in random order, so the slideshow stops running into the same broken file. An image that loads fine again starts over. View > Problem Files... lists the images that failed with their last error; Retry shows one again, Remove deletes it (or forgets it if the file is gone).
+*   **Trash:** Deleted images are moved to the Trash folder next to the tag database, keeping their tags. View > Trash... lists them with their original path and deletion time, restores them to where they were with their tags, deletes them for good, or empties those deleted over a number of days ago. With delete: trash_days in config.yaml this happens at startup and daily; delete: trash: false deletes images straight away.
 *   **History:** Navigate back and forward through your viewing history.
 
 **User Interface:**
 *   **Toolbar:** Provides quick access to common actions.
 *   **Image View:** Displays the current image and an information panel (stats, tags).
+*   **Quick Preview:** JPEGs of 2 MB or more first show the small thumbnail embedded by the camera, or the strip's cached thumbnail, while the full image decodes. This helps most on slow network folders. Turn it off in File > Preferences.
+*   **Decode Size Cap:** "Largest decoded edge" in File > Preferences (Off, 2048, 4096 or 8192 pixels) downscales gigantic images right after decoding, saving memory. The Stats panel then names the size shown. View > Load Full Resolution reloads the current image with every pixel for close inspection.
+*   **Image Scaling:** File > Preferences chooses how the image is scaled. Choices are auto, nearest, bilinear, catmullrom and lanczos. Auto draws quickly (nearest neighbor) while you zoom or pan. Once you stop, it redraws sharply (Catmull-Rom), or with bilinear when more than 2 megapixels of the image are visible, to keep large photos responsive. "Show per-frame draw time" prints the mode and time of each frame in the top-left corner, to compare the modes on your machine.
+*   **Image Switch:** The previous image stays on screen until the next one is fully decoded, so the view never flashes blank between images; when you skip ahead quickly, only the image asked for last is shown. "Image switch" in File > Preferences swaps instantly (instant) or fades the next image in over the previous one (crossfade). Zooming or panning during a crossfade finishes it at once.
+*   **Export Resized Copies:** File > Export Resized Copies... writes copies of the current image, the current (filtered) list or all images to a folder. Copies can be scaled down to a longest edge, converted to JPEG, PNG or GIF, and have their EXIF data stripped. Several images are converted at once, and the export can be cancelled from its progress dialog. fyslide-cli export-resized does the same from the command line.
+*   **Import from Card:** File > Import from Card... copies the images of a mounted camera card into YYYY/MM/DD folders of the library by the date they were taken, verifies each copy by its hash, tags the copies, adds them to the session and can delete the originals from the card. Images imported before are skipped. fyslide-cli import-card does the same from the command line.
+*   **Rescan and Reconcile:** File > Rescan and Reconcile re-reads the library folders and applies what changed on disk since the scan: new images are added, images deleted or moved elsewhere are dropped, and images whose file changed get fresh thumbnails. The current image, position and filter are kept, and a summary tells how many images were added, removed and changed.
+*   **Auto-Enhance Preview:** 'E' or View > Auto-Enhance Preview stretches the levels of the displayed image from its histogram, so badly exposed scans and photos are easier to judge. The darkest and brightest half percent of the pixels become black and white. The file is never changed, and the preview stays on for the following images until 'E' is pressed again; the status bar shows "Auto-enhanced" meanwhile. To keep enhanced copies, check "Auto-enhance levels" in File > Export Resized Copies... (or use fyslide-cli export-resized --auto-levels).
+*   **Zoom Memory:** Going back to an image you had zoomed into restores the same zoom and pan instead of fitting it to the window. "Restore zoom on return" in File > Preferences restores it always, only when going back and forward through history, or never. "Keep across sessions" also remembers the views of the last 500 images between runs.
+*   **Offline Library:** If the disk or network share holding the images disconnects, the slideshow pauses and a banner says so instead of reporting every image as broken. Playback resumes by itself once the library is reachable again; Retry checks right away. fyslide-cli clean likewise keeps the tags of images it can't reach rather than treating them as deleted.
+*   **Zoom:** The status bar shows the current zoom ("Fit", "100%", ...). Use the Fit and 1:1 toolbar buttons to switch quickly.
+*   **Live Preferences:** Saved preferences take effect at once, without a restart. This covers the background, scaling, image switch, slideshow timing, skip step, disk read cap and thumbnail strip. It also applies when the preferences file is edited while FySlide runs, and the theme background follows a change of the theme.
+*   **Closing:** Closing the window first saves the tag changes still queued. It then stops scanning, indexing and other background work before it closes the tag database. It waits up to 5 seconds for all of this; the log names whatever was left unfinished.
+*   **Thumbnail Strip:** Shows the images around the current one; click a thumbnail to jump to it. Size and position (bottom, left, right) are set in File > Preferences, and 'T' collapses/expands it.
 *   **Tags View:** Lists all tags in the database, allows searching, global tag removal, and filtering by clicking a tag.
 *   **Status Bar:**
     *   Shows the current image path, count, and filter status.
+    *   While a filter is active, it and the count beside the quick filter show how many loaded images match, e.g. beach — 342 of 18,204, with the count of each tag when filtering by several. The counts follow tags being added or removed and images being deleted.
     *   Displays log messages (use up/down arrows next to the log to scroll through messages).
 *   **Info Panel:** Shows details about the current image, including its tags.
 
@@ -324,6 +385,7 @@ FySlide is an image viewer with tagging capabilities.
     *   Q: Quit.
     *   P or Space: Toggle Play/Pause.
     *   Delete: Delete current image.
+    *   F: Fit image to view. 1: Toggle between 100% and fit.
 `
 	dialog.ShowCustom("FySlide Help", "Close", widget.NewRichTextFromMarkdown(helpText), a.UI.MainWin)
 }
@@ -337,26 +399,85 @@ func (a *App) buildMainUI() fyne.CanvasObject {
 		a.UI.mainModKey = fyne.KeyModifierControl
 	}
 	//a.UI.ribbonBar = a.buildRibbon()
+	a.registerCommands()
 	a.UI.toolBar = a.buildToolbar()
 	// main menu
 	mainMenu := fyne.NewMainMenu(
-		fyne.NewMenu("File"),
+		fyne.NewMenu("File",
+			a.commandMenuItem("export resized"),
+			a.commandMenuItem("export annotated"),
+			a.commandMenuItem("import card"),
+			a.commandMenuItem("rescan"),
+			fyne.NewMenuItemSeparator(),
+			a.commandMenuItem("preferences"),
+		),
 		fyne.NewMenu("Edit",
-			fyne.NewMenuItem("Add Tag", a.addTag),
-			fyne.NewMenuItem("Remove Tag", a.removeTag),
+			a.commandMenuItem("tag add"),
+			a.commandMenuItem("tag reapply"),
+			a.commandMenuItem("tag copy"),
+			a.commandMenuItem("tag paste"),
+			a.commandMenuItem("tag copy previous"),
+			a.buildTagSetsMenuItem(),
+			a.commandMenuItem("tag remove"),
+			a.commandMenuItem("tag folder defaults"),
+			fyne.NewMenuItemSeparator(),
+			a.commandMenuItem("stack mark"),
+			a.commandMenuItem("stack marked"),
+			a.commandMenuItem("unstack"),
+			a.commandMenuItem("stack bursts"),
 			fyne.NewMenuItemSeparator(), // Optional separator
-			fyne.NewMenuItem("Delete Image", a.deleteFileCheck),
-			fyne.NewMenuItem("Keyboard Shortucts", a.showShortcuts),
+			a.commandMenuItem("rename"),
+			a.commandMenuItem("edit date"),
+			a.commandMenuItem("correct dates"),
+			a.commandMenuItem("transform"),
+			a.commandMenuItem("quality"),
+			a.commandMenuItem("ocr"),
+			a.commandMenuItem("annotate"),
+			a.commandMenuItem("paste"),
+			a.commandMenuItem("edit external"),
+			a.commandMenuItem("delete"),
+			a.commandMenuItem("delete filtered"),
+			a.commandMenuItem("shortcuts"),
 		),
 		fyne.NewMenu("View",
-			fyne.NewMenuItem("Next Image", func() { a.direction = 1; a.nextImage() }),
-			fyne.NewMenuItem("Previous Image", a.ShowPreviousImage),
-			fyne.NewMenuItemSeparator(),                              // NEW Separator
-			fyne.NewMenuItem("Filter by Tag...", a.showFilterDialog), // NEW Filter option
-
+			fyne.NewMenuItem("Command Palette...", a.showCommandPalette),
+			fyne.NewMenuItemSeparator(),
+			a.commandMenuItem("next"),
+			a.commandMenuItem("previous"),
+			a.commandMenuItem("folder next"),
+			a.commandMenuItem("folder previous"),
+			a.commandMenuItem("reshuffle"),
+			fyne.NewMenuItemSeparator(),
+			a.commandMenuItem("filter"),
+			a.commandMenuItem("filter folder"),
+			a.buildRecentFoldersMenuItem(),
+			a.commandMenuItem("search"),
+			a.commandMenuItem("goto"),
+			a.commandMenuItem("timeline"),
+			a.commandMenuItem("exif index"),
+			a.commandMenuItem("similar"),
+			a.commandMenuItem("similar colors"),
+			a.commandMenuItem("history"),
+			a.commandMenuItem("usage"),
+			a.commandMenuItem("trash"),
+			a.commandMenuItem("problem files"),
+			a.buildBookmarksMenuItem(),
+			a.commandMenuItem("view link copy"),
+			a.commandMenuItem("view link open"),
+			a.buildPlaybackMenuItem(),
+			fyne.NewMenuItemSeparator(),
+			a.commandMenuItem("private unlock"),
+			a.commandMenuItem("private lock"),
+			fyne.NewMenuItemSeparator(),
+			a.commandMenuItem("full resolution"),
+			a.commandMenuItem("enhance"),
+			a.commandMenuItem("annotations"),
+			a.commandMenuItem("loupe"),
+			a.commandMenuItem("thumbnails"),
+			a.commandMenuItem("stack expand"),
 		),
 		fyne.NewMenu("Help",
-			fyne.NewMenuItem("Help", a.showHelpDialog),
+			a.commandMenuItem("help"),
 			fyne.NewMenuItem("About", func() {
 				aboutDialog := NewAbout(&a.UI.MainWin, "About FySlide", resourceIconPng)
 				aboutDialog.Show()
@@ -370,8 +491,15 @@ func (a *App) buildMainUI() fyne.CanvasObject {
 	a.zoomPanArea = NewZoomPanArea(nil, func() { // Pass the interaction callback
 		a.slideshowManager.Pause(true)
 	})
-	// Set the callback for zoom/pan changes to update the toolbar action visibility
-	a.zoomPanArea.SetOnZoomPanChange(a.updateShowFullSizeButtonVisibility)
+	// Set the callback for zoom/pan changes to update the toolbar actions and zoom indicator
+	a.zoomPanArea.SetOnZoomPanChange(a.onZoomPanChanged)
+	a.loupe = NewZoomPanArea(nil, func() { a.slideshowManager.Pause(true) })
+	a.loupe.SetOnZoomPanChange(a.updateLoupeLabel)
+	a.applyBackgroundPreference()
+	a.applyScalingPreference()
+	a.applyTransitionPreference()
+	a.watchPreferences()
+	a.zoomPanArea.SetShowAnnotations(a.prefs().BoolWithFallback(prefShowAnnotations, true))
 
 	infoPanelContent := container.NewScroll(
 		container.NewVBox(
@@ -380,10 +508,10 @@ func (a *App) buildMainUI() fyne.CanvasObject {
 		),
 	)
 	a.UI.split = container.NewHSplit(
-		a.zoomPanArea,
+		a.buildImagePane(), // Zoom area with the thumbnail strip docked around it
 		infoPanelContent,
 	)
-	a.UI.split.SetOffset(initialSplitOffset)
+	a.UI.split.SetOffset(a.splitOffset())
 	a.UI.imageContentView = a.UI.split // Store the image view content
 
 	// --- Build Tags View Content ---
@@ -423,7 +551,10 @@ func (a *App) buildMainUI() fyne.CanvasObject {
 	a.UI.statusLogUpBtn.Disable()   // Initially disabled
 	a.UI.statusLogDownBtn.Disable() // Initially disabled
 
-	logScrollButtons := container.NewHBox(a.UI.statusLogUpBtn, a.UI.statusLogDownBtn)
+	a.UI.statusZoomLabel = widget.NewLabel("") // Filled in by onZoomPanChanged
+	a.UI.statusZoomLabel.TextStyle.Monospace = true
+
+	logScrollButtons := container.NewHBox(a.UI.statusZoomLabel, a.UI.statusLogUpBtn, a.UI.statusLogDownBtn)
 
 	a.UI.statusBar = container.NewBorder(
 		nil, nil, // top, bottom
@@ -438,7 +569,7 @@ func (a *App) buildMainUI() fyne.CanvasObject {
 	a.logUIManager.UpdateLogDisplay() // Call once to set initial button states based on (empty) log
 
 	return container.NewBorder(
-		a.UI.toolBar,   // top
+		container.NewVBox(a.UI.toolBar, a.buildOfflineBanner(), a.buildDirDefaultsBanner(), a.buildAnnotationBar()), // top
 		a.UI.statusBar, // bottom
 		nil,            // a.UI.explorer, // explorer left
 		nil,            // right
@@ -0,0 +1,135 @@
+// Package ui Go to Image dialog: jump by position or file name (Ctrl+G).
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/scan"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const maxJumpMatches = 200 // File name matches listed at once
+
+// parseJumpIndex turns a 1-based image number typed by the user into an
+// index of a list of count images. ok is false when text isn't a number, so
+// it should be taken as a file name instead.
+func parseJumpIndex(text string, count int) (index int, ok bool, err error) {
+	n, convErr := strconv.Atoi(strings.TrimSpace(text))
+	if convErr != nil {
+		return 0, false, nil
+	}
+	if count == 0 {
+		return 0, true, fmt.Errorf("there are no images to go to")
+	}
+	if n < 1 || n > count {
+		return 0, true, fmt.Errorf("image number must be between 1 and %d", count)
+	}
+	return n - 1, true, nil
+}
+
+// jumpNameMatches returns the indexes of the images in list whose file names
+// contain fragment, ignoring case, at most limit of them (0 means no limit).
+func jumpNameMatches(fragment string, list scan.FileItems, limit int) []int {
+	fragment = strings.ToLower(strings.TrimSpace(fragment))
+	if fragment == "" {
+		return nil
+	}
+	var matches []int
+	for i, item := range list {
+		if strings.Contains(strings.ToLower(filepath.Base(item.Path)), fragment) {
+			matches = append(matches, i)
+			if limit > 0 && len(matches) == limit {
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// showJumpToImageDialog asks for an image number or part of a file name and
+// shows that image of the current list.
+func (a *App) showJumpToImageDialog() {
+	count := a.getCurrentImageCount()
+	if count == 0 {
+		dialog.ShowInformation("Go to Image", "There are no images to go to.", a.UI.MainWin)
+		return
+	}
+	a.slideshowManager.Pause(true)
+
+	list := a.getCurrentList()
+	var matches []int
+	status := widget.NewLabel("")
+	results := widget.NewList(
+		func() int { return len(matches) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < len(matches) {
+				i := matches[id]
+				obj.(*widget.Label).SetText(fmt.Sprintf("%d. %s", i+1, filepath.Base(list[i].Path)))
+			}
+		},
+	)
+
+	var d dialog.Dialog
+	goTo := func(index int) {
+		d.Hide()
+		a.selectStackView(imageViewIndex)
+		a.jumpToIndex(index)
+	}
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(fmt.Sprintf("Image number (1-%d) or part of a file name", count))
+	entry.OnChanged = func(text string) {
+		matches = nil
+		index, isNumber, err := parseJumpIndex(text, count)
+		switch {
+		case isNumber && err != nil:
+			status.SetText(err.Error())
+		case isNumber:
+			status.SetText(fmt.Sprintf("Press Enter to go to %s.", filepath.Base(list[index].Path)))
+		case strings.TrimSpace(text) == "":
+			status.SetText(fmt.Sprintf("Type an image number from 1 to %d or part of a file name.", count))
+		default:
+			matches = jumpNameMatches(text, list, maxJumpMatches)
+			switch {
+			case len(matches) == 0:
+				status.SetText("No file names match.")
+			case len(matches) == maxJumpMatches:
+				status.SetText(fmt.Sprintf("Showing the first %d matches; Enter goes to the first.", maxJumpMatches))
+			default:
+				status.SetText(fmt.Sprintf("%d matching images; Enter goes to the first.", len(matches)))
+			}
+		}
+		results.UnselectAll()
+		results.Refresh()
+		results.ScrollToTop()
+	}
+	entry.OnSubmitted = func(text string) {
+		index, isNumber, err := parseJumpIndex(text, count)
+		switch {
+		case isNumber && err == nil:
+			goTo(index)
+		case !isNumber && len(matches) > 0:
+			goTo(matches[0])
+		}
+	}
+	results.OnSelected = func(id widget.ListItemID) {
+		if id < len(matches) {
+			goTo(matches[id])
+		}
+	}
+	entry.OnChanged("")
+
+	content := container.NewBorder(container.NewVBox(entry, status), nil, nil, nil, results)
+	d = dialog.NewCustom("Go to Image", "Close", content, a.UI.MainWin)
+	d.SetOnClosed(a.slideshowManager.ResumeAfterOperation)
+	d.Resize(fyne.NewSize(searchDialogWidth, a.UI.MainWin.Canvas().Size().Height*0.6))
+	d.Show()
+	a.UI.MainWin.Canvas().Focus(entry)
+}
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"fyslide/internal/scan"
+	"reflect"
+	"testing"
+)
+
+func TestParseJumpIndex(t *testing.T) {
+	tests := []struct {
+		text     string
+		count    int
+		want     int
+		isNumber bool
+		wantErr  bool
+	}{
+		{text: "1", count: 3, want: 0, isNumber: true},
+		{text: " 3 ", count: 3, want: 2, isNumber: true},
+		{text: "0", count: 3, isNumber: true, wantErr: true},
+		{text: "4", count: 3, isNumber: true, wantErr: true},
+		{text: "-1", count: 3, isNumber: true, wantErr: true},
+		{text: "1", count: 0, isNumber: true, wantErr: true},
+		{text: "img", count: 3},
+		{text: "", count: 3},
+	}
+	for _, tt := range tests {
+		got, isNumber, err := parseJumpIndex(tt.text, tt.count)
+		if isNumber != tt.isNumber || (err != nil) != tt.wantErr {
+			t.Errorf("parseJumpIndex(%q, %d) = number %v, err %v; want number %v, error %v", tt.text, tt.count, isNumber, err, tt.isNumber, tt.wantErr)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("parseJumpIndex(%q, %d) = %d, want %d", tt.text, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestJumpNameMatches(t *testing.T) {
+	list := scan.FileItems{
+		{Path: "/photos/beach/IMG_0001.jpg"},
+		{Path: "/photos/beach/sunset.png"},
+		{Path: "/photos/img/city.jpg"},
+		{Path: "/photos/IMG_0002.jpg"},
+	}
+
+	if got, want := jumpNameMatches("img", list, 0), []int{0, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("matches for img = %v, want %v (folder names must not match)", got, want)
+	}
+	if got, want := jumpNameMatches(" IMG_ ", list, 1), []int{0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("limited matches = %v, want %v", got, want)
+	}
+	if got := jumpNameMatches(" ", list, 0); got != nil {
+		t.Errorf("matches for blank fragment = %v, want nil", got)
+	}
+}
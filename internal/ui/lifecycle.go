@@ -0,0 +1,74 @@
+// Package ui Lifecycle: background work runs under the context of the App,
+// cancelled when the main window closes, and shutdown stops it in an order
+// that loses no tag changes before the tag database closes.
+package ui
+
+import (
+	"log"
+	"time"
+)
+
+// shutdownTimeout bounds how long closing the window waits for queued tag
+// changes and background work; whatever is still running then is abandoned.
+const shutdownTimeout = 5 * time.Second
+
+// goBackground runs work on a new goroutine that shutdown waits for before
+// closing the tag database. work must return soon after a.ctx is cancelled.
+func (a *App) goBackground(work func()) {
+	a.workers.Add(1)
+	go func() {
+		defer a.workers.Done()
+		work()
+	}()
+}
+
+// shutdown ends the session when the main window closes. The session state
+// is saved and the inputs that could start more work stop first; then the tag
+// changes still queued are written, background work is cancelled and waited
+// for, and the usage counters and tag database are flushed and closed last.
+func (a *App) shutdown() {
+	a.saveWindowState()
+	a.saveViews()
+	a.saveShuffle()
+	a.saveFilterPositions()
+	a.slideshowManager.Stop()
+	a.stopMQTT()
+	a.stopEvents()
+
+	deadline := time.Now().Add(shutdownTimeout)
+	stopped := a.stopTagWorker()
+	if !waitUntil(deadline, func() { <-stopped }) {
+		log.Printf("Shutdown: %d queued tag job(s) were not saved in time.", len(a.tagWorker.jobs))
+	}
+	a.stop() // Background work stops at its next check
+	if !waitUntil(deadline, a.workers.Wait) {
+		log.Println("Shutdown: background work did not stop in time.")
+	}
+	if !waitUntil(deadline, a.thumbnailManager.Wait) {
+		log.Println("Shutdown: thumbnails were still being cached.")
+	}
+	a.flushUsage()
+	log.Println("Closing tag database...")
+	if err := a.tagDB.Close(); err != nil {
+		log.Printf("Error closing tag database: %v", err)
+	}
+	a.UI.MainWin.Close()
+}
+
+// waitUntil calls wait and reports whether it returned before deadline. A
+// wait still blocked then is left to return on its own.
+func waitUntil(deadline time.Time, wait func()) bool {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wait()
+	}()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGoBackgroundIsWaitedFor(t *testing.T) {
+	a := &App{}
+	a.ctx, a.stop = context.WithCancel(context.Background())
+	var stopped atomic.Bool
+	a.goBackground(func() {
+		<-a.ctx.Done()
+		time.Sleep(10 * time.Millisecond) // Finishing up after the cancel
+		stopped.Store(true)
+	})
+
+	if waitUntil(time.Now().Add(20*time.Millisecond), a.workers.Wait) {
+		t.Fatal("the wait returned before the work was cancelled")
+	}
+	a.stop()
+	if !waitUntil(time.Now().Add(time.Second), a.workers.Wait) {
+		t.Fatal("the work did not stop after the cancel")
+	}
+	if !stopped.Load() {
+		t.Error("the wait returned before the work finished")
+	}
+}
@@ -0,0 +1,60 @@
+// Package ui Log file: the log panel and standard logger are kept on disk in the state directory.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/paths"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// maxLogFileSize is the size past which the log file is rotated at startup,
+// keeping a single previous generation.
+const maxLogFileSize = 4 << 20
+
+// fileLog writes the messages of the log panel to the log file; nil until
+// openLogFile succeeds.
+var fileLog *log.Logger
+
+// openLogFile appends the standard logger and the log panel to the log file in
+// paths.LogDir. Failing to open it only costs the file, so it is reported on
+// stderr and otherwise ignored. The returned func closes the file.
+func openLogFile() (closeLog func()) {
+	dir, err := paths.LogDir()
+	if err != nil {
+		log.Printf("Log file disabled: %v", err)
+		return func() {}
+	}
+	file, err := openRotated(filepath.Join(dir, paths.LogFileName))
+	if err != nil {
+		log.Printf("Log file disabled: %v", err)
+		return func() {}
+	}
+	log.SetOutput(io.MultiWriter(os.Stderr, file))
+	fileLog = log.New(file, "", log.LstdFlags)
+	return func() {
+		log.SetOutput(os.Stderr)
+		fileLog = nil
+		file.Close()
+	}
+}
+
+// openRotated opens path for appending, first moving it to path.1 if it grew
+// past maxLogFileSize.
+func openRotated(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("creating log directory: %w", err)
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > maxLogFileSize {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return nil, fmt.Errorf("rotating %s: %w", path, err)
+		}
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening log file: %w", err)
+	}
+	return file, nil
+}
@@ -0,0 +1,90 @@
+// Package ui Loupe: a pane beside the fitted image showing a 100% close-up of
+// the point under the pointer (L), to check focus without losing the context.
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// loupePaneWidth is the width of the loupe pane in Fyne units.
+const loupePaneWidth = 360
+
+// imageArea returns the image with the loupe pane beside it, built once and
+// kept across rebuilds of the image pane.
+func (a *App) imageArea() fyne.CanvasObject {
+	if a.UI.imageArea != nil {
+		return a.UI.imageArea
+	}
+	a.UI.loupeLabel = widget.NewLabel("")
+	a.UI.loupeLabel.Truncation = fyne.TextTruncateEllipsis
+	width := canvas.NewRectangle(nil)
+	width.SetMinSize(fyne.NewSize(loupePaneWidth, 0))
+	a.UI.loupePane = container.NewBorder(a.UI.loupeLabel, nil, nil, nil, container.NewStack(width, a.loupe))
+	a.UI.loupePane.Hide()
+	a.UI.imageArea = container.NewBorder(nil, nil, nil, a.UI.loupePane, a.zoomPanArea)
+	if a.prefs().Bool(prefShowLoupe) {
+		a.UI.loupePane.Show()
+	}
+	return a.UI.imageArea
+}
+
+// loupeShown reports whether the loupe pane is shown.
+func (a *App) loupeShown() bool {
+	return a.UI.loupePane != nil && a.UI.loupePane.Visible()
+}
+
+// toggleLoupe shows or hides the loupe pane.
+func (a *App) toggleLoupe() {
+	if a.UI.loupePane == nil {
+		return
+	}
+	show := !a.loupeShown()
+	a.prefs().SetBool(prefShowLoupe, show)
+	if show {
+		a.UI.loupePane.Show()
+		a.addLogMessage("Loupe on: point at the image to see it at 100%")
+	} else {
+		a.UI.loupePane.Hide()
+		a.addLogMessage("Loupe off")
+	}
+	a.UI.imageArea.Refresh()
+	a.linkLoupe()
+}
+
+// linkLoupe links the loupe to the image while its pane is shown, at 100% of
+// the file's pixels: an image decoded at a reduced size is magnified to make
+// up for it.
+func (a *App) linkLoupe() {
+	if a.zoomPanArea == nil {
+		return
+	}
+	if !a.loupeShown() {
+		a.zoomPanArea.LinkLoupe(nil, 0)
+		return
+	}
+	zoom := float32(1)
+	if a.img.downscaled() && a.pairedIndex < 0 {
+		zoom = float32(a.img.FullSize.X) / float32(a.img.OriginalImage.Bounds().Dx())
+	}
+	a.zoomPanArea.LinkLoupe(a.loupe, zoom)
+	a.updateLoupeLabel()
+}
+
+// updateLoupeLabel shows the loupe's zoom relative to the file's pixels.
+func (a *App) updateLoupeLabel() {
+	if a.UI.loupeLabel == nil || a.loupe == nil || a.loupe.originalImg == nil {
+		return
+	}
+	zoom := a.loupe.CurrentZoom()
+	label := "Loupe %.0f%%"
+	if a.img.downscaled() && a.pairedIndex < 0 {
+		zoom *= float32(a.img.OriginalImage.Bounds().Dx()) / float32(a.img.FullSize.X)
+		label += " of a reduced copy; View > Load Full Resolution shows every pixel"
+	}
+	a.UI.loupeLabel.SetText(fmt.Sprintf(label, zoom*100))
+}
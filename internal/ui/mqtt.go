@@ -0,0 +1,68 @@
+// Package ui Remote control of photo frames over MQTT (--mqtt-broker).
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/mqttlink"
+	"fyslide/internal/query"
+
+	"fyne.io/fyne/v2"
+)
+
+// startMQTT connects to the broker in the background, so a frame keeps showing
+// images while the broker is unreachable. Must run after startEvents.
+func (a *App) startMQTT(opts mqttlink.Options) {
+	if opts.Broker == "" {
+		return
+	}
+	logf := func(format string, args ...any) {
+		message := fmt.Sprintf(format, args...)
+		fyne.Do(func() { a.addLogMessage(message) })
+	}
+	go func() {
+		client, err := mqttlink.Connect(opts, a.events, func(cmd mqttlink.Command) {
+			fyne.Do(func() { a.runRemoteCommand(cmd) })
+		}, logf)
+		if err != nil {
+			logf("MQTT disabled: %v", err)
+			return
+		}
+		fyne.Do(func() {
+			a.mqttClient = client
+			a.addLogMessage(fmt.Sprintf("MQTT: connected, publishing to %s", client.Topic()))
+		})
+	}()
+}
+
+// stopMQTT announces the frame offline.
+func (a *App) stopMQTT() {
+	if a.mqttClient != nil {
+		a.mqttClient.Close()
+	}
+}
+
+// runRemoteCommand performs a command received over MQTT. Runs on the Fyne goroutine.
+func (a *App) runRemoteCommand(cmd mqttlink.Command) {
+	a.addLogMessage(fmt.Sprintf("MQTT: %s %v", cmd.Action, cmd.Tags))
+	switch cmd.Action {
+	case mqttlink.ActionNext:
+		a.direction = 1
+		a.nextImage()
+	case mqttlink.ActionPrevious:
+		a.ShowPreviousImage()
+	case mqttlink.ActionPause:
+		if !a.slideshowManager.IsPaused() {
+			a.togglePlay()
+		}
+	case mqttlink.ActionPlay:
+		if a.slideshowManager.IsPaused() {
+			a.togglePlay()
+		}
+	case mqttlink.ActionToggle:
+		a.togglePlay()
+	case mqttlink.ActionSetFilter:
+		a.applyCriteria(query.Criteria{Tags: cmd.Tags})
+	case mqttlink.ActionClearFilter:
+		a.clearFilter()
+	}
+}
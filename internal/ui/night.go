@@ -0,0 +1,84 @@
+// Package ui Night mode: in kiosk mode, dims the displayed images during the
+// night hours of config.yaml and blanks the screen during its blank hours.
+package ui
+
+import (
+	"fyslide/internal/config"
+	"fyslide/internal/enhance"
+	"image"
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+)
+
+// nightCheckInterval is how often kiosk mode checks whether night hours began
+// or ended.
+const nightCheckInterval = time.Minute
+
+// forDisplay runs shown through the display stages: the auto-enhance preview
+// if enhanceOn, then dimming to brightness unless it is 0 or 1.
+func forDisplay(shown image.Image, enhanceOn bool, brightness float64) image.Image {
+	displayed := enhancedForDisplay(shown, enhanceOn)
+	if displayed == nil || brightness <= 0 || brightness >= 1 {
+		return displayed
+	}
+	return enhance.Dim(displayed, brightness)
+}
+
+// followNight puts the display in the night state of the time now and again
+// whenever it changes, until the app stops. Without kiosk mode or night hours
+// it does nothing.
+func (a *App) followNight() {
+	if !a.kiosk || a.config == nil {
+		return
+	}
+	night := a.config.Slideshow.Night
+	if night.From == "" && night.BlankFrom == "" {
+		return
+	}
+	a.goBackground(func() {
+		ticker := time.NewTicker(nightCheckInterval)
+		defer ticker.Stop()
+		applied := config.Day // The display starts bright
+		for {
+			if state := night.StateAt(time.Now()); state != applied {
+				applied = state
+				fyne.Do(func() { a.setNightState(state, night.DimBrightness()) })
+			}
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// setNightState shows the images at full brightness, dimmed to brightness, or
+// blanks the screen and pauses the slideshow until the blank hours are over.
+func (a *App) setNightState(state config.NightState, brightness float64) {
+	switch state {
+	case config.Blank:
+		if a.blankedContent == nil {
+			a.addLogMessage("Night mode: blanking the display.")
+			a.slideshowManager.Pause(true)
+			a.blankedContent = a.UI.MainWin.Content()
+			a.UI.MainWin.SetContent(canvas.NewRectangle(color.Black))
+		}
+		return
+	case config.Dimmed:
+		a.addLogMessage("Night mode: dimming the display.")
+		a.dimBrightness = brightness
+	default:
+		a.addLogMessage("Night mode: back to full brightness.")
+		a.dimBrightness = 0
+	}
+	if a.blankedContent != nil {
+		a.UI.MainWin.SetContent(a.blankedContent)
+		a.blankedContent = nil
+		a.slideshowManager.ResumeAfterOperation()
+	}
+	a.redrawDisplayed()
+}
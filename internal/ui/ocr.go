@@ -0,0 +1,177 @@
+// Package ui OCR: reads the text of scanned documents with the engine of the
+// config file, keeps it in the tag database and makes it searchable.
+package ui
+
+import (
+	"context"
+	"fmt"
+	"fyslide/internal/config"
+	"fyslide/internal/ocr"
+	"fyslide/internal/query"
+	"fyslide/internal/scan"
+	"fyslide/internal/search"
+	"fyslide/internal/tagging"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maxInfoTextLen is how much of an image's text the info panel shows.
+const maxInfoTextLen = 2000
+
+// extractText reads the text of the images of the current list with OCR,
+// skipping those whose text was read since they last changed.
+func (a *App) extractText() {
+	if a.refuseInReadOnly("Extract Text") {
+		return
+	}
+	var settings config.OCR
+	if a.config != nil {
+		settings = a.config.OCR
+	}
+	engine, err := ocr.New(settings.Engine, settings.Language)
+	if err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+		return
+	}
+	stored, err := a.tagDB.OCRTexts(a.ctx)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("failed to load extracted text: %w", err), a.UI.MainWin)
+		return
+	}
+	var items []tagging.OCRText
+	for _, item := range a.getCurrentList() {
+		info := item.Info
+		if info == nil {
+			if info, err = os.Stat(item.Path); err != nil {
+				continue
+			}
+		}
+		if text, ok := stored[item.Path]; ok && text.Current(info.ModTime(), info.Size()) {
+			continue
+		}
+		items = append(items, tagging.OCRText{Path: item.Path, ModTime: info.ModTime(), Size: info.Size()})
+	}
+	if len(items) == 0 {
+		dialog.ShowInformation("Extract Text", "The text of every image in the list has been extracted already.", a.UI.MainWin)
+		return
+	}
+	a.slideshowManager.Pause(true)
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	bar := widget.NewProgressBar()
+	status := widget.NewLabel(fmt.Sprintf("Reading %d images...", len(items)))
+	progress := dialog.NewCustom("Extract Text", "Cancel", container.NewVBox(status, bar), a.UI.MainWin)
+	progress.SetOnClosed(cancel)
+	progress.Resize(fyne.NewSize(searchDialogWidth, progress.MinSize().Height))
+	progress.Show()
+
+	a.goBackground(func() {
+		defer cancel()
+		read, withText, failed := 0, 0, 0
+		var firstErr error
+		for i, item := range items {
+			if ctx.Err() != nil {
+				break
+			}
+			text, err := engine.Extract(ctx, item.Path)
+			if err == nil {
+				item.Text = text
+				err = a.tagDB.PutOCRText(ctx, []tagging.OCRText{item})
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			read++
+			if err != nil {
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+			} else if text != "" {
+				withText++
+			}
+			fyne.Do(func() {
+				bar.SetValue(float64(i+1) / float64(len(items)))
+				status.SetText(fmt.Sprintf("%d of %d images", i+1, len(items)))
+				if err != nil {
+					return
+				}
+				if a.searchIndex != nil {
+					a.searchIndex.SetText(item.Path, text)
+				}
+				if item.Path == a.img.Path {
+					a.updateInfoText()
+				}
+			})
+		}
+		fyne.Do(func() {
+			progress.Hide()
+			a.slideshowManager.ResumeAfterOperation()
+			summary := fmt.Sprintf("Read %d images: %d with text, %d failed.", read, withText, failed)
+			if read < len(items) {
+				summary = fmt.Sprintf("Text extraction stopped after %d of %d images. %s", read, len(items), summary)
+			}
+			a.addLogMessage(summary)
+			if firstErr != nil {
+				a.addLogMessage(fmt.Sprintf("First text extraction error: %v", firstErr))
+				summary += fmt.Sprintf("\n\nFirst error: %v", firstErr)
+			}
+			dialog.ShowInformation("Extract Text", summary, a.UI.MainWin)
+		})
+	})
+}
+
+// ocrTextSection returns the info panel section showing the text read from
+// the image at path, or "" if none was.
+func (a *App) ocrTextSection(path string) string {
+	text, ok, err := a.tagDB.OCRTextOf(a.ctx, path)
+	if err != nil {
+		a.addLogMessage(fmt.Sprintf("Error getting text of %s: %v", filepath.Base(path), err))
+		return ""
+	}
+	if !ok {
+		return ""
+	}
+	if text.Text == "" {
+		return "\n---\n## Text\n(none found)\n"
+	}
+	body := text.Text
+	if len(body) > maxInfoTextLen {
+		body = strings.ToValidUTF8(body[:maxInfoTextLen], "") + "\n…"
+	}
+	body = strings.ReplaceAll(body, "```", "'''") // Keep the code block closed
+	return "\n---\n## Text\n```\n" + body + "\n```\n"
+}
+
+// loadSearchTexts adds the text read from the indexed images to idx.
+func (a *App) loadSearchTexts(idx *search.Index) error {
+	texts, err := a.tagDB.OCRTexts(a.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load extracted text: %w", err)
+	}
+	for path, text := range texts {
+		idx.SetText(path, text.Text)
+	}
+	return nil
+}
+
+// filterByText keeps the items whose text, read by OCR, matches c.
+func (a *App) filterByText(items scan.FileItems, c query.Criteria) (scan.FileItems, error) {
+	texts, err := a.tagDB.OCRTexts(a.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load extracted text: %w", err)
+	}
+	var matched scan.FileItems
+	for _, item := range items {
+		if text, ok := texts[item.Path]; ok && c.MatchesText(text.Text) {
+			matched = append(matched, item)
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,104 @@
+// Package ui Offline library handling: a disconnected disk or share pauses playback behind a reconnect banner.
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"fyslide/internal/availability"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// offlinePollInterval is how often an unreachable library is checked for
+// coming back.
+const offlinePollInterval = 5 * time.Second
+
+var errLibraryOffline = errors.New("the disk or network share holding this image is not reachable")
+
+// buildOfflineBanner creates the hidden banner shown while the library is unreachable.
+func (a *App) buildOfflineBanner() fyne.CanvasObject {
+	a.UI.offlineLabel = widget.NewLabel("")
+	a.UI.offlineLabel.Wrapping = fyne.TextWrapWord
+	retry := widget.NewButton("Retry", func() {
+		select {
+		case a.offlineRetry <- struct{}{}:
+		default: // A check is already pending
+		}
+	})
+	a.UI.offlineBanner = container.NewBorder(nil, nil, nil, retry, a.UI.offlineLabel)
+	a.UI.offlineBanner.Hide()
+	return a.UI.offlineBanner
+}
+
+// locationUnreachable reports whether path failed to load because its disk or
+// share is gone rather than because of the file itself. It may block for up
+// to a few seconds, so it runs on the loading goroutine.
+func (a *App) locationUnreachable(path string) bool {
+	return availability.NewChecker(a.libraryRoots...).Check(path) == availability.Unreachable
+}
+
+// onLibraryOffline handles an image that couldn't load because the library
+// went offline: instead of logging an error per image and skipping on, it
+// pauses the slideshow once and shows the reconnect banner until the library
+// is back.
+func (a *App) onLibraryOffline(path string) {
+	a.onImageLoadState(path, imageLoadFailed)
+	a.img = Img{Path: path, EXIFData: make(map[string]string)}
+	a.zoomPanArea.ShowError(filepath.Base(path), errLibraryOffline)
+	a.updateInfoText()
+	if a.libraryOffline {
+		return
+	}
+	a.libraryOffline = true
+	a.slideshowManager.Pause(true)
+	root := filepath.Dir(path)
+	for _, libraryRoot := range a.libraryRoots {
+		if rel, err := filepath.Rel(libraryRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+			root = libraryRoot
+			break
+		}
+	}
+	a.UI.offlineLabel.SetText(fmt.Sprintf("The library at %s is not reachable. Reconnect the disk or network share; playback continues once it is back.", root))
+	a.UI.offlineBanner.Show()
+	a.addLogMessage(fmt.Sprintf("Library %s went offline; slideshow paused.", root))
+	a.offlineRetry = make(chan struct{}, 1)
+	retry := a.offlineRetry
+	a.goBackground(func() { a.watchLibrary(root, retry) })
+}
+
+// watchLibrary waits for root to become reachable again, checking every
+// offlinePollInterval and whenever retry receives, until the app stops.
+func (a *App) watchLibrary(root string, retry <-chan struct{}) {
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-time.After(offlinePollInterval):
+		case <-retry:
+		}
+		if availability.Reachable(root, availability.DefaultTimeout) {
+			fyne.Do(a.onLibraryOnline)
+			return
+		}
+	}
+}
+
+// onLibraryOnline hides the banner, reloads the current image and resumes
+// the slideshow if the outage paused it.
+func (a *App) onLibraryOnline() {
+	if !a.libraryOffline {
+		return
+	}
+	a.libraryOffline = false
+	a.UI.offlineBanner.Hide()
+	a.addLogMessage("Library is reachable again.")
+	if a.getCurrentImageCount() > 0 {
+		a.showIndex(a.index)
+	}
+	a.slideshowManager.ResumeAfterOperation()
+}
@@ -0,0 +1,128 @@
+// Package ui Opening the viewer at an image named on the command line or by a
+// fyslide:// link from another app, and sharing views as such links.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/deeplink"
+	"fyslide/internal/scan"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// registerLinks makes this program the handler of fyslide:// links, for
+// --register-links.
+func registerLinks() {
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	where, err := deeplink.Register(executable)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Registered %s to open %s:// links (%s)\n", executable, deeplink.Scheme, where)
+}
+
+// openStartView shows the start view, if any, and reports whether it did.
+// The slideshow is paused on the view's image, as it was opened to be looked
+// at. The scan may not have reached it if it timed out; the slideshow then
+// plays the view from its position.
+func (a *App) openStartView() bool {
+	v := a.startView
+	if v == nil {
+		return false
+	}
+	a.startView = nil // Only the first display
+	if v.Path != "" {
+		if slices.ContainsFunc(a.images, func(item scan.FileItem) bool { return item.Path == v.Path }) {
+			a.slideshowManager.Pause(false)
+		} else {
+			a.addLogMessage(fmt.Sprintf("%s was not found in %s; it is not a supported image, or is hidden.", filepath.Base(v.Path), filepath.Dir(v.Path)))
+		}
+	}
+	a.openView(*v)
+	return true
+}
+
+// currentView returns what is shown now, for Copy View Link.
+func (a *App) currentView() deeplink.View {
+	v := deeplink.View{Roots: a.libraryRoots, Shuffle: a.random}
+	if a.isFiltered {
+		v.Filter = a.currentFilter
+	}
+	if item := a.getCurrentItem(); item != nil {
+		v.Path, v.Index = item.Path, a.index
+	}
+	return v
+}
+
+// openView restores a view's filter and playback order and shows its image,
+// or the image now at its position if the image is gone, as openBookmark
+// does. A view of folders not scanned here is shown over this library.
+func (a *App) openView(v deeplink.View) {
+	var missing []string
+	for _, root := range v.Roots {
+		if !slices.Contains(a.libraryRoots, root) {
+			missing = append(missing, root)
+		}
+	}
+	if len(missing) > 0 {
+		a.addLogMessage(fmt.Sprintf("%s not scanned here; the view is shown over the folders that are.", strings.Join(missing, ", ")))
+	}
+	if a.random != v.Shuffle {
+		a.toggleRandom()
+	}
+	a.startPath, a.startIndex = v.Path, v.Index
+	switch {
+	case !v.Filter.IsEmpty():
+		a.applyCriteria(v.Filter) // Shows the start image once the filter is in place
+	case a.isFiltered:
+		a.clearFilter()
+	default:
+		a.isNavigatingHistory = false
+		a.showIndex(a.takeStartIndex())
+	}
+}
+
+// copyViewLink puts the fyslide://view link of the current view on the
+// clipboard, to reproduce it in another window or on another computer.
+func (a *App) copyViewLink() {
+	link := deeplink.ViewURL(a.currentView())
+	a.app.Clipboard().SetContent(link)
+	a.addLogMessage("Copied the link to this view: " + link)
+}
+
+// showOpenViewLinkDialog asks for a fyslide://view link, taken from the
+// clipboard when it holds one, and shows its view.
+func (a *App) showOpenViewLinkDialog() {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(deeplink.Scheme + "://view?...")
+	if text := a.app.Clipboard().Content(); deeplink.IsView(text) {
+		entry.SetText(strings.TrimSpace(text))
+	}
+	entry.Validator = func(text string) error {
+		_, err := deeplink.ParseView(text)
+		return err
+	}
+	dialog.ShowForm("Open View Link", "Open", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Link", entry),
+	}, func(confirm bool) {
+		if !confirm {
+			return
+		}
+		v, err := deeplink.ParseView(entry.Text)
+		if err != nil {
+			dialog.ShowError(err, a.UI.MainWin)
+			return
+		}
+		a.openView(v)
+	}, a.UI.MainWin)
+}
@@ -0,0 +1,346 @@
+// Package ui Orientation-aware slideshow: match the display orientation or pair portraits.
+package ui
+
+import (
+	"fyslide/internal/iosched"
+	"fyslide/internal/query"
+	"fyslide/internal/slideshow"
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"golang.org/x/image/draw"
+)
+
+// Orientation modes for slideshow playback.
+const (
+	OrientationModeOff   = "off"   // Show every image
+	OrientationModeMatch = "match" // Only show images matching the display orientation
+	OrientationModePair  = "pair"  // Pair portrait images side by side on a landscape display
+)
+
+const (
+	// maxOrientationProbe bounds how many images are examined when looking for a match,
+	// so a list with few matching images doesn't stall the slideshow tick.
+	maxOrientationProbe = 50
+	// pairGap is the space in pixels between two paired images.
+	pairGap = 16
+)
+
+// orientationCache remembers the orientation of images, collected when an image is
+// decoded for display or probed through its header.
+type orientationCache struct {
+	mu sync.Mutex
+	m  map[string]query.Orientation
+}
+
+func newOrientationCache() *orientationCache {
+	return &orientationCache{m: make(map[string]query.Orientation)}
+}
+
+// record stores the orientation implied by bounds.
+func (oc *orientationCache) record(path string, bounds image.Rectangle) {
+	oc.mu.Lock()
+	oc.m[path] = query.OrientationOf(bounds.Dx(), bounds.Dy())
+	oc.mu.Unlock()
+}
+
+// known returns the orientation of path if it was recorded or probed.
+func (oc *orientationCache) known(path string) (query.Orientation, bool) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	o, ok := oc.m[path]
+	return o, ok
+}
+
+// probe returns the orientation of path, reading only the image header on a
+// miss. It reads the file, so it must not run on the UI goroutine.
+func (oc *orientationCache) probe(path string) query.Orientation {
+	if o, ok := oc.known(path); ok {
+		return o
+	}
+
+	o := query.OrientationAny // Unknown; treated as matching anything
+	if file, err := os.Open(path); err == nil {
+		if cfg, _, err := image.DecodeConfig(file); err == nil {
+			o = query.OrientationOf(cfg.Width, cfg.Height)
+		}
+		file.Close()
+	}
+	oc.mu.Lock()
+	oc.m[path] = o
+	oc.mu.Unlock()
+	return o
+}
+
+// rename re-keys a cached orientation after its file was renamed.
+func (oc *orientationCache) rename(oldPath, newPath string) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	if o, ok := oc.m[oldPath]; ok {
+		delete(oc.m, oldPath)
+		oc.m[newPath] = o
+	}
+}
+
+// orientationMatches reports whether an image of orientation o suits a display of orientation display.
+func orientationMatches(o, display query.Orientation) bool {
+	return o == display || o == query.OrientationSquare || o == query.OrientationAny || display == query.OrientationAny
+}
+
+// knownOrientation returns the orientation of path without reading the file:
+// from the images decoded or probed so far, else from the EXIF index.
+func (a *App) knownOrientation(path string) (query.Orientation, bool) {
+	if o, ok := a.orientations.known(path); ok {
+		return o, true
+	}
+	if info, ok := a.imageInfo[path]; ok && info.Width > 0 && info.Height > 0 {
+		return query.OrientationOf(info.Width, info.Height), true
+	}
+	return "", false
+}
+
+// displayOrientation returns the orientation of the main window's canvas.
+func (a *App) displayOrientation() query.Orientation {
+	if a.UI.MainWin == nil {
+		return query.OrientationAny
+	}
+	size := a.UI.MainWin.Canvas().Size()
+	return query.OrientationOf(int(size.Width), int(size.Height))
+}
+
+// slideshowAdvance moves to the next image on a slideshow tick, honoring the
+// orientation mode. The candidates are chosen from the orientations known
+// already; if one isn't, their headers are read in the background and the
+// choice is shown when done, unless the user moved on meanwhile.
+func (a *App) slideshowAdvance() {
+	mode := a.orientationMode()
+	count := a.getCurrentImageCount()
+	if mode == OrientationModeOff || count < 2 || a.replayingHistory() {
+		a.nextImage()
+		return
+	}
+
+	display := a.displayOrientation()
+	if mode == OrientationModePair && display != query.OrientationLandscape {
+		a.nextImage() // Pairing only makes sense when two portraits fit side by side
+		return
+	}
+	if a.probingHeaders {
+		return // The image is shown once the probe is done
+	}
+
+	list := a.getCurrentList()
+	from := a.index
+	if a.pairedIndex >= 0 && a.pairedIndex < count {
+		from = a.pairedIndex // Continue after the partner shown alongside the current image
+	}
+	limit := min(count, maxOrientationProbe)
+	if mode == OrientationModePair {
+		limit = 1 + min(count-1, maxOrientationProbe) // The first candidate and a partner for it
+	}
+
+	// The candidates are drawn in playback order, remembering the position
+	// after each, so only those examined are used up once one is chosen.
+	start := a.playbackPosition()
+	var candidates []int
+	var paths []string
+	var positions []playbackPosition
+	for len(candidates) < limit {
+		candidate := a.nextSlideshowCandidate(from, count)
+		if candidate < 0 {
+			break // Stopped at the end
+		}
+		from = candidate
+		candidates = append(candidates, candidate)
+		paths = append(paths, list[candidate].Path)
+		positions = append(positions, a.playbackPosition())
+	}
+	a.setPlaybackPosition(start)
+
+	apply := func(pick orientationPick) {
+		if pick.examined > 0 {
+			a.setPlaybackPosition(positions[pick.examined-1])
+		}
+		if pick.next < 0 {
+			a.nextImage() // Nothing suitable nearby; fall back to normal playback
+			return
+		}
+		if pick.partner >= 0 {
+			a.pendingPairPath = paths[pick.partner]
+			a.pairedIndex = candidates[pick.partner]
+		}
+		a.showIndex(candidates[pick.next])
+	}
+	if pick, ok := pickByOrientation(mode, display, paths, a.knownOrientation); ok {
+		apply(pick)
+		return
+	}
+
+	a.probingHeaders = true
+	index := a.index
+	a.goBackground(func() {
+		pick, _ := pickByOrientation(mode, display, paths, func(path string) (query.Orientation, bool) {
+			if a.ctx.Err() != nil {
+				return query.OrientationAny, true // Closing; the choice is dropped anyway
+			}
+			return a.orientations.probe(path), true
+		})
+		fyne.Do(func() {
+			a.probingHeaders = false
+			if a.ctx.Err() != nil || a.index != index || a.slideshowManager.IsPaused() ||
+				a.getCurrentImageCount() != count || !slices.Equal(paths, a.listPaths(candidates)) {
+				return // Moved on meanwhile; the next tick chooses again from the probed orientations
+			}
+			apply(pick)
+		})
+	})
+}
+
+// orientationPick is the choice among slideshow candidates, as positions in
+// their list, -1 if none.
+type orientationPick struct {
+	next, partner int
+	examined      int // Candidates looked at, used up from the playback order
+}
+
+// pickByOrientation chooses among the images at paths, in playback order, the
+// first one suiting display in mode, and in pair mode a portrait partner for a
+// portrait. orientationOf returns the orientation of an image; if it doesn't
+// know one, the choice can't be made and known is false.
+func pickByOrientation(mode string, display query.Orientation, paths []string, orientationOf func(path string) (query.Orientation, bool)) (pick orientationPick, known bool) {
+	pick = orientationPick{next: -1, partner: -1}
+	for i, path := range paths[:min(len(paths), maxOrientationProbe)] {
+		pick.examined = i + 1
+		if mode == OrientationModePair {
+			pick.next = i
+			break
+		}
+		o, ok := orientationOf(path)
+		if !ok {
+			return pick, false
+		}
+		if orientationMatches(o, display) {
+			pick.next = i
+			break
+		}
+	}
+	if mode != OrientationModePair || pick.next < 0 {
+		return pick, true
+	}
+	o, ok := orientationOf(paths[pick.next])
+	if !ok {
+		return pick, false
+	}
+	if o != query.OrientationPortrait {
+		return pick, true
+	}
+	for i := pick.next + 1; i < len(paths); i++ {
+		pick.examined = i + 1
+		if paths[i] == paths[pick.next] {
+			continue
+		}
+		o, ok := orientationOf(paths[i])
+		if !ok {
+			return pick, false
+		}
+		if o == query.OrientationPortrait {
+			pick.partner = i
+			break
+		}
+	}
+	return pick, true
+}
+
+// playbackPosition is where sequential and random playback stand, enough
+// to draw candidates ahead and go back.
+type playbackPosition struct {
+	direction int
+	shuffle   slideshow.PermutationState
+}
+
+func (a *App) playbackPosition() playbackPosition {
+	p := playbackPosition{direction: a.direction}
+	if a.shuffle != nil {
+		p.shuffle = a.shuffle.State()
+	}
+	return p
+}
+
+func (a *App) setPlaybackPosition(p playbackPosition) {
+	a.direction = p.direction
+	if a.shuffle != nil {
+		a.shuffle.Restore(p.shuffle)
+	}
+}
+
+// listPaths returns the paths at indexes of the current list, "" for those
+// past its end.
+func (a *App) listPaths(indexes []int) []string {
+	list := a.getCurrentList()
+	paths := make([]string, len(indexes))
+	for i, index := range indexes {
+		if index < len(list) {
+			paths[i] = list[index].Path
+		}
+	}
+	return paths
+}
+
+// nextSlideshowCandidate returns the index following from in the current
+// playback order, turning around in ping-pong mode, or -1 at the end in
+// stop-at-end mode.
+func (a *App) nextSlideshowCandidate(from, count int) int {
+	if a.random {
+		return a.shuffle.Next(count)
+	}
+	lo, hi := a.playbackRange(a.getCurrentList())
+	next, dir, ok := nextPlaybackIndex(a.playbackMode(), from, a.direction, lo, hi)
+	if !ok {
+		return -1
+	}
+	a.direction = dir
+	return next
+}
+
+// showIndex displays the image at index without applying random selection again.
+func (a *App) showIndex(index int) {
+	wasRandom := a.random
+	a.random = false
+	a.isNavigatingHistory = false
+	a.index = index
+	a.loadAndDisplayCurrentImage()
+	a.random = wasRandom
+}
+
+// loadPairPartner decodes the image shown next to a portrait in pair mode. It
+// is read in the foreground, like the portrait.
+func (a *App) loadPairPartner(path string) (image.Image, error) {
+	img, _, err := a.decoder.Decode(a.ctx, iosched.Foreground, path)
+	return img, err
+}
+
+// composeSideBySide scales left and right to a common height and places them next
+// to each other with a transparent gap, so the view background shows between them.
+func composeSideBySide(left, right image.Image, gap int) *image.RGBA {
+	lb, rb := left.Bounds(), right.Bounds()
+	height := min(lb.Dy(), rb.Dy()) // Scale down to the shorter image rather than upscaling
+	if height < 1 {
+		height = 1
+	}
+	lw := max(1, lb.Dx()*height/max(1, lb.Dy()))
+	rw := max(1, rb.Dx()*height/max(1, rb.Dy()))
+
+	dst := image.NewRGBA(image.Rect(0, 0, lw+gap+rw, height))
+	draw.ApproxBiLinear.Scale(dst, image.Rect(0, 0, lw, height), left, lb, draw.Src, nil)
+	draw.ApproxBiLinear.Scale(dst, image.Rect(lw+gap, 0, lw+gap+rw, height), right, rb, draw.Src, nil)
+	return dst
+}
+
+// pairTitle describes a paired display for the window title.
+func pairTitle(path, partner string) string {
+	return path + " + " + filepath.Base(partner)
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fyslide/internal/query"
+	"image"
+	"testing"
+)
+
+func TestOrientationMatches(t *testing.T) {
+	tests := []struct {
+		img, display query.Orientation
+		want         bool
+	}{
+		{query.OrientationLandscape, query.OrientationLandscape, true},
+		{query.OrientationPortrait, query.OrientationLandscape, false},
+		{query.OrientationSquare, query.OrientationPortrait, true},
+		{query.OrientationAny, query.OrientationPortrait, true},
+		{query.OrientationPortrait, query.OrientationAny, true},
+	}
+	for _, tt := range tests {
+		if got := orientationMatches(tt.img, tt.display); got != tt.want {
+			t.Errorf("orientationMatches(%s, %s) = %v, want %v", tt.img, tt.display, got, tt.want)
+		}
+	}
+}
+
+func TestPickByOrientation(t *testing.T) {
+	orientations := map[string]query.Orientation{
+		"l1": query.OrientationLandscape, "p1": query.OrientationPortrait,
+		"p2": query.OrientationPortrait, "l2": query.OrientationLandscape,
+	}
+	known := func(path string) (query.Orientation, bool) {
+		o, ok := orientations[path]
+		return o, ok
+	}
+	tests := []struct {
+		name      string
+		mode      string
+		display   query.Orientation
+		paths     []string
+		want      orientationPick
+		wantKnown bool
+	}{
+		{"match skips to the first fitting", OrientationModeMatch, query.OrientationPortrait, []string{"l1", "l2", "p1", "unknown"}, orientationPick{next: 2, partner: -1, examined: 3}, true},
+		{"match stops at an unknown image", OrientationModeMatch, query.OrientationPortrait, []string{"l1", "unknown", "p1"}, orientationPick{next: -1, partner: -1, examined: 2}, false},
+		{"match finds none", OrientationModeMatch, query.OrientationPortrait, []string{"l1", "l2"}, orientationPick{next: -1, partner: -1, examined: 2}, true},
+		{"pair finds a partner", OrientationModePair, query.OrientationLandscape, []string{"p1", "p1", "l1", "p2"}, orientationPick{next: 0, partner: 3, examined: 4}, true},
+		{"pair leaves a landscape alone", OrientationModePair, query.OrientationLandscape, []string{"l1", "unknown"}, orientationPick{next: 0, partner: -1, examined: 1}, true},
+	}
+	for _, tt := range tests {
+		got, ok := pickByOrientation(tt.mode, tt.display, tt.paths, known)
+		if ok != tt.wantKnown || (ok && got != tt.want) {
+			t.Errorf("%s: pickByOrientation = %+v, %v, want %+v, %v", tt.name, got, ok, tt.want, tt.wantKnown)
+		}
+	}
+}
+
+func TestComposeSideBySide(t *testing.T) {
+	left := image.NewRGBA(image.Rect(0, 0, 300, 600))
+	right := image.NewRGBA(image.Rect(0, 0, 200, 300))
+
+	got := composeSideBySide(left, right, 10)
+	// Both are scaled to the shorter height (300): left becomes 150 wide, right stays 200.
+	if want := image.Rect(0, 0, 150+10+200, 300); got.Bounds() != want {
+		t.Errorf("composeSideBySide bounds = %v, want %v", got.Bounds(), want)
+	}
+}
@@ -0,0 +1,273 @@
+// Package ui Command palette (Ctrl+K): runs any registered command by typing
+// part of its name, with arguments, e.g. "tag add beach" or "goto 1523".
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/query"
+	"fyslide/internal/tagging"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	paletteWidth  = 520
+	paletteHeight = 400
+)
+
+// paletteEntry is the palette's input line. Up and Down move through the
+// matching commands and Escape closes the palette.
+type paletteEntry struct {
+	widget.Entry
+	onKey func(key fyne.KeyName) bool
+}
+
+func newPaletteEntry(onKey func(key fyne.KeyName) bool) *paletteEntry {
+	e := &paletteEntry{onKey: onKey}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+// TypedKey lets onKey handle the key first.
+func (e *paletteEntry) TypedKey(key *fyne.KeyEvent) {
+	if e.onKey(key.Name) {
+		return
+	}
+	e.Entry.TypedKey(key)
+}
+
+// showCommandPalette opens the palette over the main window. Typing filters
+// the commands by name or title; Enter runs the command the input starts
+// with, passing the rest as arguments, or else the selected one.
+func (a *App) showCommandPalette() {
+	var matches []*uiCommand
+	selected := 0
+	usage := widget.NewLabel("")
+	list := widget.NewList(
+		func() int { return len(matches) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, widget.NewLabel("folder previous"), nil, widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			row := obj.(*fyne.Container)
+			row.Objects[1].(*widget.Label).SetText(matches[id].Name)
+			row.Objects[0].(*widget.Label).SetText(matches[id].Title)
+		},
+	)
+
+	var popUp *widget.PopUp
+	var entry *paletteEntry
+	run := func() {
+		c, args, ok := a.commands.parse(entry.Text)
+		if !ok {
+			if selected >= len(matches) {
+				return
+			}
+			c, args = matches[selected], ""
+		}
+		popUp.Hide()
+		a.runCommand(c, args)
+	}
+	entry = newPaletteEntry(func(key fyne.KeyName) bool {
+		switch key {
+		case fyne.KeyDown, fyne.KeyUp:
+			if len(matches) > 0 {
+				step := 1
+				if key == fyne.KeyUp {
+					step = len(matches) - 1
+				}
+				list.Select((selected + step) % len(matches))
+			}
+		case fyne.KeyEscape:
+			popUp.Hide()
+		default:
+			return false
+		}
+		return true
+	})
+	entry.SetPlaceHolder("Type a command, e.g. tag add beach, filter sunset AND 2023, goto 1523")
+	entry.OnChanged = func(text string) {
+		if c, _, ok := a.commands.parse(text); ok {
+			matches = []*uiCommand{c}
+		} else {
+			matches = a.commands.match(text)
+		}
+		list.Refresh()
+		if len(matches) > 0 {
+			list.Select(0)
+		} else {
+			selected = 0
+			usage.SetText("No command matches.")
+		}
+	}
+	entry.OnSubmitted = func(string) { run() }
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		c := matches[id]
+		usage.SetText(strings.TrimSpace(c.Name + " " + c.Usage))
+		a.UI.MainWin.Canvas().Focus(entry)
+	}
+
+	content := container.NewBorder(container.NewVBox(entry, usage), nil, nil, nil, list)
+	popUp = widget.NewModalPopUp(content, a.UI.MainWin.Canvas())
+	popUp.Resize(fyne.NewSize(paletteWidth, paletteHeight))
+	entry.OnChanged("")
+	popUp.Show()
+	a.UI.MainWin.Canvas().Focus(entry)
+}
+
+// gotoCommand shows an image of the current list by its number or part of
+// its file name, or asks for one without arguments.
+func (a *App) gotoCommand(args string) error {
+	if args == "" {
+		a.showJumpToImageDialog()
+		return nil
+	}
+	list := a.getCurrentList()
+	index, isNumber, err := parseJumpIndex(args, len(list))
+	if err != nil {
+		return err
+	}
+	if !isNumber {
+		matches := jumpNameMatches(args, list, 1)
+		if len(matches) == 0 {
+			return fmt.Errorf("no file name contains %q", args)
+		}
+		index = matches[0]
+	}
+	a.selectStackView(imageViewIndex)
+	a.jumpToIndex(index)
+	return nil
+}
+
+// sortCommand orders the current list as parseSortArgs reads args.
+func (a *App) sortCommand(args string) error {
+	key, desc, err := parseSortArgs(args)
+	if err != nil {
+		return err
+	}
+	a.sortCurrentList(key, desc)
+	return nil
+}
+
+// tagAddCommand adds the comma-separated tags of args to the current image,
+// or opens the Add Tag dialog without arguments.
+func (a *App) tagAddCommand(args string) error {
+	if args == "" {
+		a.addTag()
+		return nil
+	}
+	if a.img.Path == "" {
+		return fmt.Errorf("no image loaded to tag")
+	}
+	tags := tagging.NormalizeTags(strings.Split(args, ","))
+	if len(tags) == 0 {
+		return fmt.Errorf("no valid tags entered")
+	}
+	return a.addTagsToCurrentImage("Adding tag(s)", tags)
+}
+
+// tagRemoveCommand removes the comma-separated tags of args from the current
+// image, or opens the Remove Tag dialog without arguments.
+func (a *App) tagRemoveCommand(args string) error {
+	if args == "" {
+		a.removeTag()
+		return nil
+	}
+	if a.img.Path == "" {
+		return fmt.Errorf("no image loaded to remove tags from")
+	}
+	current, err := a.tagDB.GetTags(a.ctx, a.img.Path)
+	if err != nil {
+		return fmt.Errorf("failed to get current tags: %w", err)
+	}
+	current = a.withPendingTags(a.img.Path, current)
+	var ops []tagOp
+	var removed []string
+	for _, tag := range tagging.NormalizeTags(strings.Split(args, ",")) {
+		if slices.Contains(current, tag) {
+			ops = append(ops, tagOp{path: a.img.Path, tag: tag})
+			removed = append(removed, tag)
+		}
+	}
+	if len(ops) == 0 {
+		return fmt.Errorf("%s has none of the tags %s", filepath.Base(a.img.Path), args)
+	}
+	a.submitTagJob(fmt.Sprintf("Removing tag(s) [%s] from %s", strings.Join(removed, ", "), filepath.Base(a.img.Path)), ops, nil)
+	return nil
+}
+
+// tagSetCommand applies the tag set named by args to the current image, or
+// opens the tag set dialog without arguments.
+func (a *App) tagSetCommand(args string) error {
+	if args == "" {
+		a.showTagSetsDialog()
+		return nil
+	}
+	set, err := a.tagDB.GetTagSet(a.ctx, args)
+	if err != nil {
+		return err
+	}
+	a.applyTagSet(set)
+	return nil
+}
+
+// filterCommand filters by the expression of args, as parsePaletteFilter
+// reads it, or opens the filter dialog without arguments.
+func (a *App) filterCommand(args string) error {
+	if args == "" {
+		a.showFilterDialog()
+		return nil
+	}
+	c, err := parsePaletteFilter(args)
+	if err != nil {
+		return err
+	}
+	a.applyCriteria(c)
+	return nil
+}
+
+// filterFolderCommand filters to the folder given, or asks for one.
+func (a *App) filterFolderCommand(args string) error {
+	if args == "" {
+		a.showFolderFilterDialog()
+		return nil
+	}
+	folder, err := filepath.Abs(args)
+	if err != nil {
+		return err
+	}
+	a.filterToFolder(folder)
+	return nil
+}
+
+// paletteAnd separates the tags of a palette filter.
+var paletteAnd = regexp.MustCompile(`(?i)\s+and\s+|\s*,\s*|\s*&&?\s*`)
+
+// parsePaletteFilter reads a palette filter: tags joined by AND, such as
+// "sunset AND 2023", or the key=value terms of query.Parse, such as
+// "tag=sunset, from=2023-01-01". Images must match every part; OR and NOT
+// are not supported.
+func parsePaletteFilter(text string) (query.Criteria, error) {
+	if strings.Contains(text, "=") {
+		return query.Parse(text)
+	}
+	var c query.Criteria
+	for _, part := range paletteAnd.Split(strings.TrimSpace(text), -1) {
+		lower := strings.ToLower(part)
+		if strings.Contains(" "+lower+" ", " or ") || strings.HasPrefix(lower, "not ") {
+			return query.Criteria{}, fmt.Errorf("only AND is supported between tags, e.g. sunset AND 2023")
+		}
+		c.Tags = append(c.Tags, part)
+	}
+	if c.Tags = tagging.NormalizeTags(c.Tags); len(c.Tags) == 0 {
+		return query.Criteria{}, fmt.Errorf("no tags to filter by")
+	}
+	return c, nil
+}
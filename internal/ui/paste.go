@@ -0,0 +1,217 @@
+// Package ui Paste image: saves the image on the clipboard, such as a
+// screenshot, into the inbox folder, adds it to the session and asks for its
+// tags.
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"fyslide/internal/scan"
+	"image"
+	"image/png"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// defaultInboxName is the inbox folder within the first library folder, used
+// until another inbox is set in Preferences.
+const defaultInboxName = "Inbox"
+
+// errNoClipboardImage reports a clipboard without an image on it.
+var errNoClipboardImage = errors.New("the clipboard holds no image")
+
+// clipboardImageCommands print the clipboard's image as PNG, for each system.
+// Fyne's clipboard only carries text, so images are read through the first
+// of these tools that is installed.
+var clipboardImageCommands = map[string][][]string{
+	"linux": {
+		{"wl-paste", "--no-newline", "--type", "image/png"},
+		{"xclip", "-selection", "clipboard", "-target", "image/png", "-out"},
+	},
+	"darwin": {{"pngpaste", "-"}},
+	"windows": {{"powershell", "-NoProfile", "-STA", "-Command",
+		"Add-Type -AssemblyName System.Windows.Forms,System.Drawing; $i = [Windows.Forms.Clipboard]::GetImage(); " +
+			"if ($i) { $m = New-Object IO.MemoryStream; $i.Save($m, [Drawing.Imaging.ImageFormat]::Png); " +
+			"$o = [Console]::OpenStandardOutput(); $o.Write($m.ToArray(), 0, $m.Length) }"}},
+}
+
+// inboxFolder returns the folder pasted images are saved to, or "" if none
+// is set and there is no library folder to put the default one in.
+func (a *App) inboxFolder() string {
+	if dir := strings.TrimSpace(a.prefs().String(prefInboxFolder)); dir != "" {
+		return dir
+	}
+	if len(a.libraryRoots) == 0 {
+		return ""
+	}
+	return filepath.Join(a.libraryRoots[0], defaultInboxName)
+}
+
+// pasteImage saves the clipboard's image into the inbox folder as PNG, shows
+// it and opens the tag dialog for it.
+func (a *App) pasteImage() {
+	if a.refuseInReadOnly("Paste Image") {
+		return
+	}
+	inbox := a.inboxFolder()
+	if inbox == "" {
+		dialog.ShowInformation("Paste Image", "No inbox folder is set. Choose one in File > Preferences.", a.UI.MainWin)
+		return
+	}
+	text := a.app.Clipboard().Content()
+	go func() {
+		img, err := clipboardImage(text)
+		var path string
+		if err == nil {
+			path, err = savePastedImage(inbox, img, time.Now())
+		}
+		fyne.Do(func() {
+			if err != nil {
+				a.addLogMessage(fmt.Sprintf("Paste failed: %v", err))
+				dialog.ShowError(err, a.UI.MainWin)
+				return
+			}
+			a.addLogMessage(fmt.Sprintf("Pasted image saved as %s.", path))
+			if !a.addToSession(path) {
+				return
+			}
+			a.jumpToPath(path)
+			a.addTag()
+		})
+	}()
+}
+
+// clipboardImage returns the image on the clipboard. text is the clipboard's
+// text, which may name an image file or hold a data URL, as some
+// applications copy images that way; otherwise the system's clipboard tool
+// is asked for image data.
+func clipboardImage(text string) (image.Image, error) {
+	if img, err := imageFromClipboardText(text); err == nil {
+		return img, nil
+	}
+	commands := clipboardImageCommands[runtime.GOOS]
+	var tried []string
+	for _, args := range commands {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		tried = append(tried, args[0])
+		out, err := exec.Command(args[0], args[1:]...).Output()
+		if err != nil || len(out) == 0 {
+			continue // Usually nothing of the requested type on the clipboard
+		}
+		if img, _, err := image.Decode(bytes.NewReader(out)); err == nil {
+			return img, nil
+		}
+	}
+	if len(tried) == 0 && len(commands) > 0 {
+		var names []string
+		for _, args := range commands {
+			names = append(names, args[0])
+		}
+		return nil, fmt.Errorf("%w; reading images from the clipboard needs %s installed", errNoClipboardImage, strings.Join(names, " or "))
+	}
+	return nil, errNoClipboardImage
+}
+
+// imageFromClipboardText decodes the image a clipboard text refers to: an
+// image file's path or file URL, or a base64 data URL.
+func imageFromClipboardText(text string) (image.Image, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.ContainsRune(text, '\n') {
+		return nil, errNoClipboardImage
+	}
+	if data, ok := strings.CutPrefix(text, "data:image/"); ok {
+		_, encoded, ok := strings.Cut(data, ";base64,")
+		if !ok {
+			return nil, errNoClipboardImage
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decoding pasted data URL: %w", err)
+		}
+		img, _, err := image.Decode(bytes.NewReader(raw))
+		return img, err
+	}
+	path := text
+	if u, err := url.Parse(text); err == nil && u.Scheme == "file" {
+		path = filepath.FromSlash(u.Path)
+	}
+	if !filepath.IsAbs(path) || !scan.IsImage(path) {
+		return nil, errNoClipboardImage
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// savePastedImage writes img into dir as a PNG named after when it was
+// pasted, creating dir if needed, and returns the file's path.
+func savePastedImage(dir string, img image.Image, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating inbox folder: %w", err)
+	}
+	base := "Pasted " + now.Format("2006-01-02 15-04-05")
+	for n := 1; ; n++ {
+		name := base + ".png"
+		if n > 1 {
+			name = fmt.Sprintf("%s (%d).png", base, n)
+		}
+		path := filepath.Join(dir, name)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := png.Encode(f, img); err != nil {
+			f.Close()
+			os.Remove(path)
+			return "", fmt.Errorf("writing %s: %w", path, err)
+		}
+		return path, f.Close()
+	}
+}
+
+// addToSession adds the new image file at path to the browsed images, in
+// path order, and reports whether it could.
+func (a *App) addToSession(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		a.addLogMessage(fmt.Sprintf("Cannot add %s: %v", filepath.Base(path), err))
+		return false
+	}
+	item := scan.NewFileItem(path, info)
+	i, found := slices.BinarySearchFunc(a.images, path, func(item scan.FileItem, path string) int {
+		return strings.Compare(item.Path, path)
+	})
+	if found {
+		a.images[i] = item
+	} else {
+		a.images = slices.Insert(a.images, i, item)
+	}
+	if a.filterCounts != nil {
+		a.filterCounts.load(path)
+	}
+	if a.searchIndex != nil {
+		a.searchIndex.Set(path, nil)
+	}
+	a.updateStatusBar()
+	return true
+}
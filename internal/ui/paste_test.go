@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestImageFromClipboardText(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "shot.png")
+	if err := os.WriteFile(path, encoded.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{
+		path,
+		"file://" + filepath.ToSlash(path),
+		"data:image/png;base64," + base64.StdEncoding.EncodeToString(encoded.Bytes()),
+	} {
+		img, err := imageFromClipboardText(text)
+		if err != nil || img.Bounds().Dx() != 3 {
+			t.Errorf("imageFromClipboardText(%.40q) = %v, %v; want the 3x2 image", text, img, err)
+		}
+	}
+	for _, text := range []string{"", "hello", "notes.txt", path + "\n" + path} {
+		if _, err := imageFromClipboardText(text); err == nil {
+			t.Errorf("imageFromClipboardText(%q) found an image", text)
+		}
+	}
+}
+
+func TestSavePastedImage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Inbox")
+	now := time.Date(2024, 6, 15, 9, 30, 0, 0, time.Local)
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	first, err := savePastedImage(dir, img, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := savePastedImage(dir, img, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(first) != "Pasted 2024-06-15 09-30-00.png" || filepath.Base(second) != "Pasted 2024-06-15 09-30-00 (2).png" {
+		t.Errorf("Saved as %s and %s", filepath.Base(first), filepath.Base(second))
+	}
+}
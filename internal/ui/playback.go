@@ -0,0 +1,189 @@
+// Package ui Playback modes: what happens at the end of the list, and the A-B loop
+// that keeps playback within a range of images.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/scan"
+	"path/filepath"
+	"slices"
+
+	"fyne.io/fyne/v2"
+)
+
+// Playback modes, applied when sequential playback reaches either end of the
+// list or of the A-B loop. Random mode ignores them.
+const (
+	PlaybackLoop     = "loop"     // Wrap around to the other end
+	PlaybackStop     = "stop"     // Stay on the last image and pause the slideshow
+	PlaybackPingPong = "pingpong" // Reverse direction
+)
+
+// playbackModeLabels names the playback modes in the View menu.
+var playbackModeLabels = map[string]string{
+	PlaybackLoop:     "Loop",
+	PlaybackStop:     "Stop at End",
+	PlaybackPingPong: "Ping-Pong",
+}
+
+// nextPlaybackIndex returns the index following from when moving in
+// direction (+1 or -1) through the images lo to hi, and the direction to keep
+// moving in. In PlaybackStop mode it returns false at the ends. From outside
+// lo to hi, playback enters the range at the end it moves towards first.
+func nextPlaybackIndex(mode string, from, direction, lo, hi int) (next, dir int, ok bool) {
+	if direction == 0 {
+		direction = 1
+	}
+	if from < lo || from > hi {
+		if direction > 0 {
+			return lo, direction, true
+		}
+		return hi, direction, true
+	}
+	if next = from + direction; next >= lo && next <= hi {
+		return next, direction, true
+	}
+	switch mode {
+	case PlaybackStop:
+		return from, direction, false
+	case PlaybackPingPong:
+		direction = -direction
+		if next = from + direction; next < lo || next > hi {
+			next = from // A range of a single image
+		}
+		return next, direction, true
+	}
+	if direction > 0 {
+		return lo, direction, true
+	}
+	return hi, direction, true
+}
+
+// playbackRange returns the first and last index sequential playback stays
+// within: the A-B loop if its points are in list, the whole list otherwise.
+// A lone A point plays from A to the end, a lone B point from the start to B.
+func (a *App) playbackRange(list scan.FileItems) (lo, hi int) {
+	lo, hi = 0, len(list)-1
+	indexOf := func(path string) int {
+		if path == "" {
+			return -1
+		}
+		return slices.IndexFunc(list, func(item scan.FileItem) bool { return item.Path == path })
+	}
+	if i := indexOf(a.loopA); i >= 0 {
+		lo = i
+	}
+	if i := indexOf(a.loopB); i >= 0 {
+		hi = i
+	}
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	return lo, hi
+}
+
+// stepPlayback moves a.index one image on in the playback order, returning
+// false if it stayed at the end in PlaybackStop mode.
+func (a *App) stepPlayback() bool {
+	list := a.getCurrentList()
+	if len(list) == 0 {
+		return false
+	}
+	lo, hi := a.playbackRange(list)
+	next, dir, ok := nextPlaybackIndex(a.playbackMode(), a.index, a.direction, lo, hi)
+	if !ok {
+		return false
+	}
+	a.index, a.direction = next, dir
+	return true
+}
+
+// onPlaybackEnd pauses the slideshow once playback reached the end in
+// PlaybackStop mode.
+func (a *App) onPlaybackEnd() {
+	if !a.slideshowManager.IsPaused() {
+		a.togglePlay()
+	}
+	a.addLogMessage("Reached the end; playback stopped.")
+}
+
+// setPlaybackMode switches the playback mode and remembers it.
+func (a *App) setPlaybackMode(mode string) {
+	a.prefs().SetString(prefPlaybackMode, mode)
+	a.refreshPlaybackMenu()
+}
+
+// setLoopPoint sets the A (start) or B (end) point of the A-B loop to the
+// current image.
+func (a *App) setLoopPoint(start bool) {
+	item := a.getCurrentItem()
+	if item == nil {
+		return
+	}
+	if start {
+		a.loopA = item.Path
+		a.addLogMessage(fmt.Sprintf("Loop start (A) set to %s", filepath.Base(item.Path)))
+	} else {
+		a.loopB = item.Path
+		a.addLogMessage(fmt.Sprintf("Loop end (B) set to %s", filepath.Base(item.Path)))
+	}
+	a.refreshPlaybackMenu()
+	a.updateStatusBar()
+}
+
+// clearLoopPoints ends the A-B loop.
+func (a *App) clearLoopPoints() {
+	a.loopA, a.loopB = "", ""
+	a.addLogMessage("A-B loop cleared")
+	a.refreshPlaybackMenu()
+	a.updateStatusBar()
+}
+
+// loopStatus describes the A-B loop for the status bar, or returns "" if
+// there is none.
+func (a *App) loopStatus() string {
+	switch {
+	case a.loopA != "" && a.loopB != "":
+		return "Loop A-B"
+	case a.loopA != "":
+		return "Loop from A"
+	case a.loopB != "":
+		return "Loop to B"
+	}
+	return ""
+}
+
+// buildPlaybackMenuItem creates the View > Playback submenu.
+func (a *App) buildPlaybackMenuItem() *fyne.MenuItem {
+	item := fyne.NewMenuItem("Playback", nil)
+	a.UI.playbackMenu = fyne.NewMenu("Playback")
+	item.ChildMenu = a.UI.playbackMenu
+	a.refreshPlaybackMenu()
+	return item
+}
+
+// refreshPlaybackMenu checks the current playback mode in the menu.
+func (a *App) refreshPlaybackMenu() {
+	if a.UI.playbackMenu == nil {
+		return
+	}
+	current := a.playbackMode()
+	var items []*fyne.MenuItem
+	for _, mode := range playbackModes {
+		item := fyne.NewMenuItem(playbackModeLabels[mode], func() { a.setPlaybackMode(mode) })
+		item.Checked = mode == current
+		items = append(items, item)
+	}
+	clearItem := fyne.NewMenuItem("Clear A-B Loop", a.clearLoopPoints)
+	clearItem.Disabled = a.loopA == "" && a.loopB == ""
+	items = append(items,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Set Loop Start (A)", func() { a.setLoopPoint(true) }),
+		fyne.NewMenuItem("Set Loop End (B)", func() { a.setLoopPoint(false) }),
+		clearItem,
+	)
+	a.UI.playbackMenu.Items = items
+	if a.UI.MainWin != nil && a.UI.MainWin.MainMenu() != nil {
+		a.UI.MainWin.MainMenu().Refresh()
+	}
+}
@@ -0,0 +1,34 @@
+package ui
+
+import "testing"
+
+func TestNextPlaybackIndex(t *testing.T) {
+	tests := []struct {
+		name              string
+		mode              string
+		from, direction   int
+		lo, hi            int
+		wantNext, wantDir int
+		wantOK            bool
+	}{
+		{"middle", PlaybackLoop, 3, 1, 0, 9, 4, 1, true},
+		{"loop wraps forward", PlaybackLoop, 9, 1, 0, 9, 0, 1, true},
+		{"loop wraps backward", PlaybackLoop, 0, -1, 0, 9, 9, -1, true},
+		{"stop at end", PlaybackStop, 9, 1, 0, 9, 9, 1, false},
+		{"stop at start going back", PlaybackStop, 0, -1, 0, 9, 0, -1, false},
+		{"ping-pong turns at end", PlaybackPingPong, 9, 1, 0, 9, 8, -1, true},
+		{"ping-pong turns at start", PlaybackPingPong, 0, -1, 0, 9, 1, 1, true},
+		{"ping-pong single image", PlaybackPingPong, 4, 1, 4, 4, 4, -1, true},
+		{"A-B loop wraps to A", PlaybackLoop, 6, 1, 2, 6, 2, 1, true},
+		{"A-B ping-pong turns at B", PlaybackPingPong, 6, 1, 2, 6, 5, -1, true},
+		{"outside A-B enters at A", PlaybackStop, 8, 1, 2, 6, 2, 1, true},
+		{"outside A-B backward enters at B", PlaybackLoop, 0, -1, 2, 6, 6, -1, true},
+		{"no direction moves forward", PlaybackLoop, 3, 0, 0, 9, 4, 1, true},
+	}
+	for _, tt := range tests {
+		next, dir, ok := nextPlaybackIndex(tt.mode, tt.from, tt.direction, tt.lo, tt.hi)
+		if next != tt.wantNext || dir != tt.wantDir || ok != tt.wantOK {
+			t.Errorf("%s: nextPlaybackIndex = %d, %d, %v; want %d, %d, %v", tt.name, next, dir, ok, tt.wantNext, tt.wantDir, tt.wantOK)
+		}
+	}
+}
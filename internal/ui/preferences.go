@@ -0,0 +1,251 @@
+// Package ui Preferences holds the keys and accessors for persisted user settings.
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Preference keys stored through the Fyne preferences API.
+const (
+	prefThumbStripSize      = "thumbstrip.size"         // Number of thumbnails shown in the strip window
+	prefThumbStripPosition  = "thumbstrip.position"     // Dock position of the strip (bottom, left, right)
+	prefThumbStripCollapsed = "thumbstrip.collapsed"    // Whether the strip is collapsed
+	prefPanStep             = "zoom.panstep"            // Pixels moved per arrow key press while zoomed
+	prefBackgroundMode      = "view.background"         // Background mode behind the image
+	prefBackgroundColor     = "view.backgroundcolor"    // Custom background color as #RRGGBB
+	prefLoadPreview         = "view.loadpreview"        // Show a low-resolution preview while large JPEGs decode
+	prefMaxDecodeDimension  = "view.maxdecode"          // Longest edge kept after decoding, 0 for full resolution
+	prefScalingMode         = "view.scaling"            // Interpolation used to scale the image
+	prefShowDrawTime        = "view.drawtime"           // Overlay the time taken to draw each frame
+	prefTransition          = "view.transition"         // How the view switches to the next image, see transitionModes
+	prefZoomMemory          = "view.zoommemory"         // When returning to an image restores its zoom and pan, see zoomMemoryOptions
+	prefZoomMemoryPersist   = "view.zoommemorysave"     // Keep remembered zoom and pan across sessions
+	prefZoomMemorySaved     = "view.zoommemoryviews"    // Remembered zoom and pan of the last session, as JSON
+	prefFilterPositions     = "session.filterpositions" // Image last shown in each filter, as JSON
+	prefExportDir           = "export.dir"              // Target folder of the last export
+	prefExportMaxSize       = "export.maxsize"          // Longest edge of exported copies, 0 keeps the size
+	prefExportQuality       = "export.quality"          // JPEG quality of exported copies
+	prefExportFormat        = "export.format"           // Format of exported copies, see exporter.Formats
+	prefExportStripMetadata = "export.stripmetadata"    // Leave EXIF data out of exported copies
+	prefCardFolder          = "cardimport.card"         // Card folder of the last card import
+	prefCardLibrary         = "cardimport.library"      // Library folder the last card import filed images into
+	prefCardTags            = "cardimport.tags"         // Tags given to the images of the last card import
+	prefSkipCorrupt         = "slideshow.skipcorrupt"   // Skip unreadable images during playback
+	prefTagCorrupt          = "slideshow.tagcorrupt"    // Tag skipped images as corrupt
+	prefOrientationMode     = "slideshow.orientation"   // Orientation-aware playback mode
+	prefPlaybackMode        = "slideshow.playback"      // What sequential playback does at the ends, see playbackModes
+	prefSlideshowInterval   = "slideshow.interval"      // Seconds per image set in Preferences, 0 keeps the command-line or config value
+	prefSkipCount           = "slideshow.skipcount"     // Images skipped with Page Up and Page Down set in Preferences, 0 keeps the command-line or config value
+	prefAdaptiveInterval    = "slideshow.adaptive"      // Show large images and panoramas longer
+	prefAdaptiveMinSeconds  = "slideshow.adaptivemin"   // Shortest display time in adaptive mode
+	prefAdaptiveMaxSeconds  = "slideshow.adaptivemax"   // Longest display time in adaptive mode
+	prefShuffleSeed         = "shuffle.seed"            // Fixed seed of the shuffled order, "" for a random one per session
+	prefShufflePersist      = "shuffle.persist"         // Continue the shuffled order in the next session
+	prefShuffleState        = "shuffle.state"           // Position in the shuffled order of the last session, as JSON
+	prefEditorCommand       = "editor.command"          // External editor command template, %f is the file
+	prefEditorWatch         = "editor.watch"            // Reload the image when the editor saves it
+	prefInboxFolder         = "paste.inbox"             // Folder pasted images are saved to, "" for Inbox in the first library folder
+	prefShowAnnotations     = "annotations.show"        // Draw annotations over the images
+	prefAnnotationColor     = "annotations.color"       // Name of the color new annotations are drawn in, see annotate.Palette
+	prefShowLoupe           = "loupe.show"              // Show the loupe pane beside the image
+	prefWindowStartMode     = "window.startmode"        // Fullscreen, windowed or restore last state
+	prefWindowWidth         = "window.width"            // Last windowed width in Fyne units
+	prefWindowHeight        = "window.height"           // Last windowed height in Fyne units
+	prefWindowFullscreen    = "window.fullscreen"       // Whether the window was fullscreen on exit
+	prefWindowSplitOffset   = "window.splitoffset"      // Offset of the image/info split
+	prefPrivateTag          = "private.tag"             // Tag hiding images until unlocked, "" disables hiding
+	prefPrivatePINHash      = "private.pinhash"         // Salted hash of the unlock PIN, see hashPIN
+	prefDirDefaultsAuto     = "dirdefaults.auto"        // Apply .fyslide-tags defaults after every scan
+	prefEXIFTagNamespaces   = "exiftags.namespaces"     // Comma-separated EXIF tag namespaces added after every scan
+	prefRecentTags          = "tags.recent"             // Tags added last, most recent first, see maxRecentTags
+	prefLastTags            = "tags.last"               // Tags added together last, for Reapply Last Tags
+	prefCopyTitle           = "tags.copytitle"          // Copy Tags takes the EXIF title along
+	prefFolderSummary       = "folders.summary"         // Entering a folder shows its image count, dates and top tags
+	prefRecentFolders       = "filter.recentfolders"    // Folders filtered to last, most recent first, see maxRecentFolders
+	prefColorTagsAuto       = "colortags.auto"          // Add color:* tags after every scan
+	prefBackgroundIOLimit   = "io.backgroundlimit"      // Throughput cap of background disk reads in MB/s, 0 for none
+)
+
+// Thumbnail strip dock positions.
+const (
+	thumbStripBottom = "bottom"
+	thumbStripLeft   = "left"
+	thumbStripRight  = "right"
+)
+
+const (
+	// DefaultThumbStripSize is the default number of thumbnails in the strip window.
+	DefaultThumbStripSize = 11
+	minThumbStripSize     = 3
+	maxThumbStripSize     = 31
+)
+
+const (
+	// DefaultPanStep is the default number of pixels panned per arrow key press.
+	DefaultPanStep = 50
+	minPanStep     = 1
+	maxPanStep     = 1000
+)
+
+// backgroundModes lists the valid image background modes in display order.
+var backgroundModes = []string{BackgroundTheme, BackgroundBlack, BackgroundCheckerboard, BackgroundCustom}
+
+const defaultBackgroundColor = "#202020"
+
+// orientationModes lists the valid slideshow orientation modes in display order.
+var orientationModes = []string{OrientationModeOff, OrientationModeMatch, OrientationModePair}
+
+// playbackModes lists the valid playback modes in display order.
+var playbackModes = []string{PlaybackLoop, PlaybackStop, PlaybackPingPong}
+
+// thumbStripPositions lists the valid dock positions in display order.
+var thumbStripPositions = []string{thumbStripBottom, thumbStripLeft, thumbStripRight}
+
+// thumbStripSize returns the configured strip window size, clamped to a sane odd range.
+func (a *App) thumbStripSize() int {
+	size := a.prefs().IntWithFallback(prefThumbStripSize, DefaultThumbStripSize)
+	return clampThumbStripSize(size)
+}
+
+// clampThumbStripSize keeps the window size within bounds and odd, so the
+// current image can always sit in the middle slot.
+func clampThumbStripSize(size int) int {
+	if size < minThumbStripSize {
+		size = minThumbStripSize
+	}
+	if size > maxThumbStripSize {
+		size = maxThumbStripSize
+	}
+	if size%2 == 0 {
+		size++
+	}
+	return size
+}
+
+// thumbStripPosition returns the configured dock position, defaulting to the bottom.
+func (a *App) thumbStripPosition() string {
+	pos := a.prefs().StringWithFallback(prefThumbStripPosition, thumbStripBottom)
+	for _, valid := range thumbStripPositions {
+		if pos == valid {
+			return pos
+		}
+	}
+	return thumbStripBottom
+}
+
+// panStep returns the configured keyboard pan step in pixels.
+func (a *App) panStep() int {
+	step := a.prefs().IntWithFallback(prefPanStep, DefaultPanStep)
+	if step < minPanStep || step > maxPanStep {
+		return DefaultPanStep
+	}
+	return step
+}
+
+// backgroundMode returns the configured image background mode.
+func (a *App) backgroundMode() string {
+	mode := a.prefs().StringWithFallback(prefBackgroundMode, BackgroundTheme)
+	for _, valid := range backgroundModes {
+		if mode == valid {
+			return mode
+		}
+	}
+	return BackgroundTheme
+}
+
+// backgroundColor returns the configured custom background color.
+func (a *App) backgroundColor() color.Color {
+	hex := a.prefs().StringWithFallback(prefBackgroundColor, defaultBackgroundColor)
+	c, err := parseHexColor(hex)
+	if err != nil {
+		c, _ = parseHexColor(defaultBackgroundColor)
+	}
+	return c
+}
+
+// applyBackgroundPreference pushes the background preferences to the image view and the loupe.
+func (a *App) applyBackgroundPreference() {
+	for _, zpa := range []*ZoomPanArea{a.zoomPanArea, a.loupe} {
+		if zpa != nil {
+			zpa.SetBackground(a.backgroundMode(), a.backgroundColor())
+		}
+	}
+}
+
+// scalingMode returns the configured image scaling mode.
+func (a *App) scalingMode() string {
+	mode := a.prefs().StringWithFallback(prefScalingMode, ScalingAuto)
+	for _, valid := range scalingModes {
+		if mode == valid {
+			return mode
+		}
+	}
+	return ScalingAuto
+}
+
+// applyScalingPreference pushes the scaling preferences to the image view and the loupe.
+func (a *App) applyScalingPreference() {
+	for _, zpa := range []*ZoomPanArea{a.zoomPanArea, a.loupe} {
+		if zpa != nil {
+			zpa.SetScaling(a.scalingMode(), a.prefs().Bool(prefShowDrawTime))
+		}
+	}
+}
+
+// transitionMode returns the saved image transition mode, TransitionInstant if unset or invalid.
+func (a *App) transitionMode() string {
+	mode := a.prefs().StringWithFallback(prefTransition, TransitionInstant)
+	if slices.Contains(transitionModes, mode) {
+		return mode
+	}
+	return TransitionInstant
+}
+
+// applyTransitionPreference pushes the transition mode to the image view.
+func (a *App) applyTransitionPreference() {
+	if a.zoomPanArea != nil {
+		a.zoomPanArea.SetTransition(a.transitionMode())
+	}
+}
+
+// parseHexColor parses a "#RRGGBB" (or "RRGGBB") string into an opaque color.
+func parseHexColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("color %q must have the form #RRGGBB", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("color %q is not valid hex: %w", s, err)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// orientationMode returns the configured orientation-aware playback mode.
+func (a *App) orientationMode() string {
+	mode := a.prefs().StringWithFallback(prefOrientationMode, OrientationModeOff)
+	for _, valid := range orientationModes {
+		if mode == valid {
+			return mode
+		}
+	}
+	return OrientationModeOff
+}
+
+// playbackMode returns the configured playback mode, PlaybackLoop by default.
+func (a *App) playbackMode() string {
+	mode := a.prefs().StringWithFallback(prefPlaybackMode, PlaybackLoop)
+	if slices.Contains(playbackModes, mode) {
+		return mode
+	}
+	return PlaybackLoop
+}
+
+// editorCommand returns the configured external editor command template.
+func (a *App) editorCommand() string {
+	return strings.TrimSpace(a.prefs().String(prefEditorCommand))
+}
@@ -0,0 +1,109 @@
+// Package ui Live preferences: each part of the viewer observes the
+// preferences it depends on and applies a change at once, whether it was made
+// in the Preferences dialog or by editing the preferences file.
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// prefObserver applies a group of preferences whenever one of them changes.
+type prefObserver struct {
+	keys  []string
+	apply func()
+	last  string // Values of keys when last applied, see prefValues
+}
+
+// prefWatcher calls the observers whose preferences changed. Fyne reports a
+// change of any preference without saying which.
+type prefWatcher struct {
+	prefs     fyne.Preferences
+	observers []*prefObserver
+	pending   atomic.Bool // A check is queued; the changes of one save are applied together
+}
+
+// newPrefWatcher creates a watcher of prefs and subscribes it to their changes.
+func newPrefWatcher(prefs fyne.Preferences) *prefWatcher {
+	w := &prefWatcher{prefs: prefs}
+	prefs.AddChangeListener(w.changed)
+	return w
+}
+
+// observe calls apply whenever one of keys changes from its current value.
+func (w *prefWatcher) observe(apply func(), keys ...string) {
+	w.observers = append(w.observers, &prefObserver{keys: keys, apply: apply, last: prefValues(w.prefs, keys)})
+}
+
+// changed queues a check, so the preferences a dialog saves one after the
+// other are applied once.
+func (w *prefWatcher) changed() {
+	if !w.pending.CompareAndSwap(false, true) {
+		return
+	}
+	fyne.Do(func() {
+		w.pending.Store(false)
+		w.check()
+	})
+}
+
+// check applies the observers whose preferences differ from those last applied.
+func (w *prefWatcher) check() {
+	for _, o := range w.observers {
+		if values := prefValues(w.prefs, o.keys); values != o.last {
+			o.last = values
+			o.apply()
+		}
+	}
+}
+
+// prefValues describes the values of keys. As Fyne has no untyped getter, it
+// reads each key as every type; the getters of the other types return zero.
+func prefValues(prefs fyne.Preferences, keys []string) string {
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%q %v %v %v %q;", prefs.String(key), prefs.Int(key), prefs.Float(key), prefs.Bool(key), prefs.StringList(key))
+	}
+	return b.String()
+}
+
+// watchPreferences makes the viewer follow changes of the preferences that
+// take effect while it runs, and of the theme.
+func (a *App) watchPreferences() {
+	w := newPrefWatcher(a.prefs())
+	w.observe(a.applyBackgroundPreference, prefBackgroundMode, prefBackgroundColor)
+	w.observe(a.applyScalingPreference, prefScalingMode, prefShowDrawTime)
+	w.observe(a.applyTransitionPreference, prefTransition)
+	w.observe(a.applySlideshowInterval, prefSlideshowInterval)
+	w.observe(a.applyAdaptiveIntervalPreference, prefAdaptiveInterval, prefAdaptiveMinSeconds, prefAdaptiveMaxSeconds)
+	w.observe(a.applySkipCount, prefSkipCount)
+	w.observe(a.applyBackgroundIOLimit, prefBackgroundIOLimit)
+	w.observe(func() {
+		a.addLogMessage("Thumbnail strip preferences updated.")
+		a.rebuildImagePane()
+	}, prefThumbStripSize, prefThumbStripPosition)
+	a.app.Settings().AddListener(func(fyne.Settings) {
+		fyne.Do(a.applyBackgroundPreference) // The theme background follows the theme
+	})
+}
+
+// applySlideshowInterval passes the display time saved in Preferences on to
+// the slideshow, which restarts the time of the image on screen with it.
+func (a *App) applySlideshowInterval() {
+	seconds := a.prefs().Float(prefSlideshowInterval)
+	if seconds <= 0 {
+		return // Keeps the command-line or config file interval
+	}
+	a.slideshowManager.SetInterval(time.Duration(seconds * float64(time.Second)))
+}
+
+// applySkipCount takes the Page Up and Page Down step saved in Preferences.
+func (a *App) applySkipCount() {
+	if n := a.prefs().Int(prefSkipCount); n > 0 {
+		a.skipCount = n
+	}
+}
@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestPrefWatcher(t *testing.T) {
+	prefs := test.NewTempApp(t).Preferences()
+	prefs.SetString(prefScalingMode, ScalingAuto)
+	w := newPrefWatcher(prefs)
+	scaling, strip := 0, 0
+	w.observe(func() { scaling++ }, prefScalingMode, prefShowDrawTime)
+	w.observe(func() { strip++ }, prefThumbStripSize, prefThumbStripPosition)
+
+	prefs.SetString(prefScalingMode, ScalingAuto) // Unchanged
+	prefs.SetString(prefExportDir, "/tmp")        // Observed by nobody
+	if scaling != 0 || strip != 0 {
+		t.Errorf("observers ran %d and %d times without a change of theirs", scaling, strip)
+	}
+	prefs.SetBool(prefShowDrawTime, true)
+	if scaling != 1 || strip != 0 {
+		t.Errorf("after a scaling change the observers ran %d and %d times, want 1 and 0", scaling, strip)
+	}
+	prefs.SetInt(prefThumbStripSize, 7)
+	prefs.SetString(prefThumbStripPosition, thumbStripLeft)
+	if scaling != 1 || strip != 2 {
+		t.Errorf("after two strip changes the observers ran %d and %d times, want 1 and 2", scaling, strip)
+	}
+	prefs.RemoveValue(prefThumbStripSize)
+	if strip != 3 {
+		t.Errorf("removing a strip preference ran its observer %d times in all, want 3", strip)
+	}
+}
@@ -0,0 +1,64 @@
+// Package ui Quick previews shown while large JPEGs are still decoding.
+package ui
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// previewMinBytes is the file size from which a JPEG gets a preview before its
+// full decode; smaller files decode about as fast as the preview would show.
+const previewMinBytes = 2 << 20
+
+// isJPEG reports whether path names a JPEG file, the format that carries
+// EXIF thumbnails.
+func isJPEG(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".jpg" || ext == ".jpeg"
+}
+
+// exifThumbnail decodes the thumbnail embedded in a JPEG's EXIF data.
+func exifThumbnail(x *exif.Exif) (image.Image, error) {
+	data, err := x.JpegThumbnail()
+	if err != nil {
+		return nil, err
+	}
+	return jpeg.Decode(bytes.NewReader(data))
+}
+
+// loadPreview returns a low-resolution stand-in for a large JPEG: the
+// thumbnail embedded in its EXIF data, which the EXIF parse has already read,
+// or else the thumbnail strip's cached one. Returns nil for other files or
+// when neither is available. Runs on the loading goroutine.
+func (a *App) loadPreview(file *os.File, x *exif.Exif) image.Image {
+	if !isJPEG(file.Name()) {
+		return nil
+	}
+	if info, err := file.Stat(); err != nil || info.Size() < previewMinBytes {
+		return nil
+	}
+	if x != nil {
+		if preview, err := exifThumbnail(x); err == nil {
+			return preview
+		}
+	}
+	if preview, ok := a.thumbnailManager.Cached(file.Name()); ok && preview != nil {
+		return preview
+	}
+	return nil
+}
+
+// showLoadPreview displays preview while path is still loading. The full
+// image replaces it once decoded; a newer load in the meantime discards it.
+func (a *App) showLoadPreview(path string, preview image.Image) {
+	if a.loadingPath != path {
+		return
+	}
+	a.zoomPanArea.SetImage(preview)
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPreviewFallsBackToCachedThumbnail(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, size int) *os.File {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Close() })
+		return f
+	}
+	large := write("large.jpg", previewMinBytes)
+	small := write("small.jpg", previewMinBytes-1)
+	png := write("large.png", previewMinBytes)
+
+	thumb := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	a := &App{thumbnailManager: NewThumbnailManager(10, DefaultThumbnailSize, nil)}
+	a.thumbnailManager.mu.Lock()
+	for _, f := range []*os.File{large, small, png} {
+		a.thumbnailManager.store(f.Name(), thumb)
+	}
+	a.thumbnailManager.mu.Unlock()
+
+	if got := a.loadPreview(large, nil); got != thumb {
+		t.Errorf("loadPreview of a large JPEG without EXIF = %v, want the cached thumbnail", got)
+	}
+	if got := a.loadPreview(small, nil); got != nil {
+		t.Error("loadPreview returned a preview for a small JPEG")
+	}
+	if got := a.loadPreview(png, nil); got != nil {
+		t.Error("loadPreview returned a preview for a PNG")
+	}
+}
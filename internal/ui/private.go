@@ -0,0 +1,208 @@
+// Package ui Private images: a tag that hides images until a PIN is entered.
+package ui
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"fyslide/internal/scan"
+	"fyslide/internal/tagging"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	pinSaltSize   = 16
+	pinHashRounds = 100000 // Slows down guessing a short PIN from a copied preferences file
+	minPINLength  = 4
+)
+
+// hashPIN derives the stored form of pin: the hex salt and the hex digest of
+// pinHashRounds iterations of SHA-256, separated by "$".
+func hashPIN(pin string, salt []byte) string {
+	sum := sha256.Sum256(append(slices.Clone(salt), pin...))
+	for range pinHashRounds {
+		sum = sha256.Sum256(append(sum[:], salt...))
+	}
+	return hex.EncodeToString(salt) + "$" + hex.EncodeToString(sum[:])
+}
+
+// newPINHash hashes pin with a fresh random salt.
+func newPINHash(pin string) (string, error) {
+	if len(pin) < minPINLength {
+		return "", fmt.Errorf("the PIN must have at least %d characters", minPINLength)
+	}
+	salt := make([]byte, pinSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generating PIN salt: %w", err)
+	}
+	return hashPIN(pin, salt), nil
+}
+
+// verifyPIN reports whether pin matches a hash produced by newPINHash.
+func verifyPIN(pin, stored string) bool {
+	saltHex, _, ok := strings.Cut(stored, "$")
+	if !ok {
+		return false
+	}
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashPIN(pin, salt)), []byte(stored)) == 1
+}
+
+// privateTag returns the tag marking private images, "" if the feature is off.
+func (a *App) privateTag() string {
+	return strings.TrimSpace(a.prefs().StringWithFallback(prefPrivateTag, tagging.PrivateTag))
+}
+
+// privatePINSet reports whether a PIN has been configured.
+func (a *App) privatePINSet() bool {
+	return a.prefs().String(prefPrivatePINHash) != ""
+}
+
+// privateLocked reports whether images carrying the private tag are hidden.
+// Nothing is hidden until a PIN has been set, since they could not be shown again.
+func (a *App) privateLocked() bool {
+	return !a.privateUnlocked && a.privateTag() != "" && a.privatePINSet()
+}
+
+// lockedPrivatePaths returns the paths to hide, or nil when nothing is hidden.
+func (a *App) lockedPrivatePaths() map[string]bool {
+	if !a.privateLocked() {
+		return nil
+	}
+	paths, err := a.tagDB.GetImages(a.ctx, a.privateTag())
+	if err != nil {
+		a.addLogMessage(fmt.Sprintf("Failed to read private images: %v", err))
+		return nil
+	}
+	hide := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		hide[path] = true
+	}
+	return hide
+}
+
+// hidePrivateImages moves images carrying the private tag out of the browsed
+// lists while private images are locked. Filters and search only see a.images,
+// so they leave them out as well.
+func (a *App) hidePrivateImages() {
+	hide := a.lockedPrivatePaths()
+	if len(hide) == 0 {
+		return
+	}
+	isHidden := func(item scan.FileItem) bool { return hide[item.Path] }
+	var visible scan.FileItems
+	for _, item := range a.images {
+		if isHidden(item) {
+			a.privateImages = append(a.privateImages, item)
+		} else {
+			visible = append(visible, item)
+		}
+	}
+	moved := len(a.images) - len(visible)
+	if moved == 0 {
+		return
+	}
+	a.images = visible
+	a.filteredImages = slices.DeleteFunc(a.filteredImages, isHidden)
+	if a.filterCounts != nil {
+		a.filterCounts.remove(hide)
+	}
+	for path := range hide {
+		a.historyManager.RemovePath(path)
+	}
+	a.searchIndex = nil // Rebuilt without them on the next search
+	a.addLogMessage(fmt.Sprintf("Hid %d private image(s).", moved))
+	a.reshowAfterListChange()
+}
+
+// showPrivateImages returns the hidden private images to the browsed lists.
+func (a *App) showPrivateImages() {
+	if len(a.privateImages) == 0 {
+		return
+	}
+	n := len(a.privateImages)
+	a.images = append(a.images, a.privateImages...)
+	a.privateImages = nil
+	slices.SortStableFunc(a.images, func(x, y scan.FileItem) int { return strings.Compare(x.Path, y.Path) })
+	a.searchIndex = nil
+	a.addLogMessage(fmt.Sprintf("Showing %d private image(s).", n))
+	if a.isFiltered {
+		a.applyCriteria(a.currentFilter) // Let matching private images into the filter
+		return
+	}
+	a.reshowAfterListChange()
+}
+
+// reshowAfterListChange keeps the current image selected after images were
+// added to or removed from the browsed lists, or moves to a neighbour if the
+// current image is gone.
+func (a *App) reshowAfterListChange() {
+	if a.isFiltered && len(a.filteredImages) == 0 {
+		a.clearFilter()
+		return
+	}
+	list := a.getCurrentList()
+	index := slices.IndexFunc(list, func(item scan.FileItem) bool { return item.Path == a.img.Path })
+	if index < 0 {
+		index = min(max(a.index, 0), len(list)-1)
+	}
+	a.showIndex(index)
+	a.updateStatusBar()
+}
+
+// showUnlockPrivateDialog asks for the PIN and shows private images if it matches.
+func (a *App) showUnlockPrivateDialog() {
+	if a.privateTag() == "" {
+		dialog.ShowInformation("Private Images", "No private tag is configured (see Preferences).", a.UI.MainWin)
+		return
+	}
+	if !a.privateLocked() {
+		dialog.ShowInformation("Private Images", "Private images are already shown.", a.UI.MainWin)
+		return
+	}
+	pinEntry := widget.NewPasswordEntry()
+	dialog.ShowForm("Unlock Private Images", "Unlock", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("PIN", pinEntry),
+	}, func(confirm bool) {
+		if !confirm {
+			return
+		}
+		if !verifyPIN(pinEntry.Text, a.prefs().String(prefPrivatePINHash)) {
+			a.addLogMessage("Private images: wrong PIN entered.")
+			dialog.ShowError(errors.New("wrong PIN"), a.UI.MainWin)
+			return
+		}
+		a.privateUnlocked = true
+		a.showPrivateImages()
+	}, a.UI.MainWin)
+}
+
+// lockPrivateImages hides private images again.
+func (a *App) lockPrivateImages() {
+	if !a.privateUnlocked {
+		return
+	}
+	a.privateUnlocked = false
+	a.hidePrivateImages()
+	a.addLogMessage("Private images locked.")
+}
+
+// hidesPrivateAfter reports whether ops tagged an image as private while
+// private images are locked, so it has to be hidden.
+func (a *App) hidesPrivateAfter(ops []tagOp) bool {
+	if !a.privateLocked() {
+		return false
+	}
+	tag := a.privateTag()
+	return slices.ContainsFunc(ops, func(op tagOp) bool { return op.add && op.tag == tag })
+}
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPINHash(t *testing.T) {
+	hash, err := newPINHash("2468")
+	if err != nil {
+		t.Fatalf("newPINHash failed: %v", err)
+	}
+	if strings.Contains(hash, "2468") {
+		t.Errorf("hash %q contains the PIN", hash)
+	}
+	if !verifyPIN("2468", hash) {
+		t.Error("verifyPIN rejected the right PIN")
+	}
+	for _, wrong := range []string{"", "2469", "24680"} {
+		if verifyPIN(wrong, hash) {
+			t.Errorf("verifyPIN accepted %q", wrong)
+		}
+	}
+	for _, broken := range []string{"", "nodollar", "zz$00"} {
+		if verifyPIN("2468", broken) {
+			t.Errorf("verifyPIN accepted stored value %q", broken)
+		}
+	}
+
+	again, err := newPINHash("2468")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == hash {
+		t.Error("hashing the same PIN twice gave the same value; the salt is not random")
+	}
+	if _, err := newPINHash("12"); err == nil {
+		t.Error("newPINHash accepted a PIN shorter than the minimum")
+	}
+}
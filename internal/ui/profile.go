@@ -0,0 +1,176 @@
+// Package ui Profiles: per-user tag databases and preferences (--profile).
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/profile"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// profilePreferences stores the settings of a named profile under its own key
+// prefix in the application's preferences, so profiles don't share them.
+type profilePreferences struct {
+	fyne.Preferences
+	prefix string
+}
+
+// preferencesFor returns the preferences of the named profile. The default
+// profile uses the plain keys it used before profiles existed.
+func preferencesFor(p fyne.Preferences, name string) fyne.Preferences {
+	if name == "" || name == profile.DefaultName {
+		return p
+	}
+	return &profilePreferences{Preferences: p, prefix: "profile." + name + "."}
+}
+
+func (p *profilePreferences) key(k string) string { return p.prefix + k }
+
+func (p *profilePreferences) Bool(k string) bool { return p.Preferences.Bool(p.key(k)) }
+func (p *profilePreferences) BoolWithFallback(k string, fallback bool) bool {
+	return p.Preferences.BoolWithFallback(p.key(k), fallback)
+}
+func (p *profilePreferences) SetBool(k string, v bool) { p.Preferences.SetBool(p.key(k), v) }
+func (p *profilePreferences) BoolList(k string) []bool { return p.Preferences.BoolList(p.key(k)) }
+func (p *profilePreferences) BoolListWithFallback(k string, fallback []bool) []bool {
+	return p.Preferences.BoolListWithFallback(p.key(k), fallback)
+}
+func (p *profilePreferences) SetBoolList(k string, v []bool) { p.Preferences.SetBoolList(p.key(k), v) }
+func (p *profilePreferences) Float(k string) float64         { return p.Preferences.Float(p.key(k)) }
+func (p *profilePreferences) FloatWithFallback(k string, fallback float64) float64 {
+	return p.Preferences.FloatWithFallback(p.key(k), fallback)
+}
+func (p *profilePreferences) SetFloat(k string, v float64) { p.Preferences.SetFloat(p.key(k), v) }
+func (p *profilePreferences) FloatList(k string) []float64 { return p.Preferences.FloatList(p.key(k)) }
+func (p *profilePreferences) FloatListWithFallback(k string, fallback []float64) []float64 {
+	return p.Preferences.FloatListWithFallback(p.key(k), fallback)
+}
+func (p *profilePreferences) SetFloatList(k string, v []float64) {
+	p.Preferences.SetFloatList(p.key(k), v)
+}
+func (p *profilePreferences) Int(k string) int { return p.Preferences.Int(p.key(k)) }
+func (p *profilePreferences) IntWithFallback(k string, fallback int) int {
+	return p.Preferences.IntWithFallback(p.key(k), fallback)
+}
+func (p *profilePreferences) SetInt(k string, v int) { p.Preferences.SetInt(p.key(k), v) }
+func (p *profilePreferences) IntList(k string) []int { return p.Preferences.IntList(p.key(k)) }
+func (p *profilePreferences) IntListWithFallback(k string, fallback []int) []int {
+	return p.Preferences.IntListWithFallback(p.key(k), fallback)
+}
+func (p *profilePreferences) SetIntList(k string, v []int) { p.Preferences.SetIntList(p.key(k), v) }
+func (p *profilePreferences) String(k string) string       { return p.Preferences.String(p.key(k)) }
+func (p *profilePreferences) StringWithFallback(k, fallback string) string {
+	return p.Preferences.StringWithFallback(p.key(k), fallback)
+}
+func (p *profilePreferences) SetString(k string, v string) { p.Preferences.SetString(p.key(k), v) }
+func (p *profilePreferences) StringList(k string) []string {
+	return p.Preferences.StringList(p.key(k))
+}
+func (p *profilePreferences) StringListWithFallback(k string, fallback []string) []string {
+	return p.Preferences.StringListWithFallback(p.key(k), fallback)
+}
+func (p *profilePreferences) SetStringList(k string, v []string) {
+	p.Preferences.SetStringList(p.key(k), v)
+}
+func (p *profilePreferences) RemoveValue(k string) { p.Preferences.RemoveValue(p.key(k)) }
+
+// prefs returns the preferences of the active profile. All settings must be
+// read and written through it rather than through a.app.Preferences().
+func (a *App) prefs() fyne.Preferences {
+	if a.preferences == nil {
+		a.preferences = preferencesFor(a.app.Preferences(), a.profile)
+	}
+	return a.preferences
+}
+
+// profileNeedsChoice reports whether the profile chooser should be shown at
+// startup: only when no profile was given and named profiles exist.
+func profileNeedsChoice() bool {
+	if *profileFlag != "" {
+		return false
+	}
+	names, err := profile.List()
+	return err == nil && len(names) > 1
+}
+
+// showProfileChooser opens a window listing the profiles, with a field to
+// create a new one. onChosen runs on the Fyne goroutine once a profile is picked.
+func showProfileChooser(fyneApp fyne.App, onChosen func(name string)) {
+	win := fyneApp.NewWindow("FySlide - Choose Profile")
+	win.SetIcon(resourceIconPng)
+
+	names, err := profile.List()
+	if err != nil {
+		names = []string{profile.DefaultName}
+	}
+	chosen := false
+	choose := func(name string) {
+		if _, err := profile.Create(name); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		chosen = true
+		onChosen(name)
+		win.Close()
+	}
+
+	selected := 0
+	list := widget.NewList(
+		func() int { return len(names) },
+		func() fyne.CanvasObject { return widget.NewLabel("profile") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) { obj.(*widget.Label).SetText(names[id]) },
+	)
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+	list.Select(selected)
+	openButton := widget.NewButton("Open", func() { choose(names[selected]) })
+	openButton.Importance = widget.HighImportance
+
+	newEntry := widget.NewEntry()
+	newEntry.SetPlaceHolder("New profile name")
+	newEntry.Validator = func(text string) error {
+		if text == "" {
+			return nil
+		}
+		return profile.ValidateName(text)
+	}
+	createButton := widget.NewButton("Create", func() {
+		name := strings.TrimSpace(newEntry.Text)
+		if err := profile.ValidateName(name); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		choose(name)
+	})
+	newEntry.OnSubmitted = func(string) { createButton.OnTapped() }
+
+	win.SetContent(container.NewBorder(
+		widget.NewLabel("Choose whose library to open:"),
+		container.NewVBox(
+			container.NewHBox(layout.NewSpacer(), openButton),
+			widget.NewSeparator(),
+			container.NewBorder(nil, nil, nil, createButton, newEntry),
+		),
+		nil, nil, list))
+	win.SetCloseIntercept(func() {
+		win.Close()
+		if !chosen {
+			fyneApp.Quit()
+		}
+	})
+	win.Resize(fyne.NewSize(360, 320))
+	win.CenterOnScreen()
+	win.Show()
+}
+
+// profileTitle returns the window title suffix naming a non-default profile.
+func (a *App) profileTitle() string {
+	if a.profile == "" || a.profile == profile.DefaultName {
+		return ""
+	}
+	return fmt.Sprintf(" [%s]", a.profile)
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestProfilePreferences(t *testing.T) {
+	base := test.NewTempApp(t).Preferences()
+	if got := preferencesFor(base, "default"); got != base {
+		t.Error("the default profile should use the application preferences unchanged")
+	}
+
+	work := preferencesFor(base, "work")
+	home := preferencesFor(base, "home")
+	work.SetString(prefEditorCommand, "gimp %f")
+	work.SetInt(prefPanStep, 10)
+	if got := home.String(prefEditorCommand); got != "" {
+		t.Errorf("profile home sees work's editor command %q", got)
+	}
+	if got := base.String(prefEditorCommand); got != "" {
+		t.Errorf("default profile sees work's editor command %q", got)
+	}
+	if got := work.IntWithFallback(prefPanStep, DefaultPanStep); got != 10 {
+		t.Errorf("work pan step = %d, want 10", got)
+	}
+	work.RemoveValue(prefPanStep)
+	if got := work.IntWithFallback(prefPanStep, DefaultPanStep); got != DefaultPanStep {
+		t.Errorf("work pan step after RemoveValue = %d, want the default", got)
+	}
+}
@@ -0,0 +1,155 @@
+// Package ui Image quality: flags images that are likely blurry or badly
+// exposed with quality tags, and badges them in the thumbnail strip, to speed
+// up culling.
+package ui
+
+import (
+	"context"
+	"fmt"
+	"fyslide/internal/imagesig"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// qualityTags lists every quality warning tag.
+var qualityTags = []string{imagesig.BlurryTag, imagesig.UnderexposedTag, imagesig.OverexposedTag}
+
+// loadQualityWarnings loads which images carry quality tags, for the badges.
+// Called from the scanning goroutine.
+func (a *App) loadQualityWarnings() {
+	warnings := make(map[string][]string)
+	for _, tag := range qualityTags {
+		paths, err := a.tagDB.GetImages(a.ctx, tag)
+		if err != nil {
+			fyne.Do(func() { a.addLogMessage(fmt.Sprintf("Failed to load quality warnings: %v", err)) })
+			return
+		}
+		for _, path := range paths {
+			warnings[path] = append(warnings[path], tag)
+		}
+	}
+	fyne.Do(func() {
+		a.qualityWarnings = warnings
+		a.refreshThumbStrip()
+	})
+}
+
+// applyQualityOps keeps the quality warnings in step with quality tags being
+// added or removed and reports whether any were.
+func (a *App) applyQualityOps(ops []tagOp) bool {
+	changed := false
+	for _, op := range ops {
+		if !slices.Contains(qualityTags, op.tag) {
+			continue
+		}
+		if a.qualityWarnings == nil {
+			a.qualityWarnings = make(map[string][]string)
+		}
+		current := a.qualityWarnings[op.path]
+		switch {
+		case op.add && !slices.Contains(current, op.tag):
+			a.qualityWarnings[op.path] = append(current, op.tag)
+		case !op.add:
+			if current = slices.DeleteFunc(current, func(tag string) bool { return tag == op.tag }); len(current) == 0 {
+				delete(a.qualityWarnings, op.path)
+			} else {
+				a.qualityWarnings[op.path] = current
+			}
+		}
+		changed = true
+	}
+	return changed
+}
+
+// qualityWarning describes the quality warnings of the image at path, e.g.
+// "blurry, overexposed", or returns "" if it has none.
+func (a *App) qualityWarning(path string) string {
+	var warnings []string
+	for _, tag := range a.qualityWarnings[path] {
+		warnings = append(warnings, strings.TrimPrefix(tag, imagesig.QualityTagPrefix))
+	}
+	return strings.Join(warnings, ", ")
+}
+
+// refreshThumbStrip redraws the strip's slots, e.g. after their badges changed.
+func (a *App) refreshThumbStrip() {
+	if a.thumbStrip == nil {
+		return
+	}
+	for _, slot := range a.thumbStrip.slots {
+		slot.setWarning(a.qualityWarning(slot.path) != "")
+	}
+}
+
+// checkImageQuality analyzes the images of the current list that carry no
+// quality tag yet and tags those likely blurry or badly exposed.
+func (a *App) checkImageQuality() {
+	if a.refuseInReadOnly("Check Image Quality") {
+		return
+	}
+	var items []string
+	for _, item := range a.getCurrentList() {
+		if len(a.qualityWarnings[item.Path]) == 0 {
+			items = append(items, item.Path)
+		}
+	}
+	if len(items) == 0 {
+		dialog.ShowInformation("Check Image Quality", "There are no images to check.", a.UI.MainWin)
+		return
+	}
+	a.slideshowManager.Pause(true)
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	bar := widget.NewProgressBar()
+	status := widget.NewLabel(fmt.Sprintf("Checking %d images...", len(items)))
+	progress := dialog.NewCustom("Check Image Quality", "Cancel", container.NewVBox(status, bar), a.UI.MainWin)
+	progress.SetOnClosed(cancel)
+	progress.Resize(fyne.NewSize(searchDialogWidth, progress.MinSize().Height))
+	progress.Show()
+
+	a.goBackground(func() {
+		defer cancel()
+		cache := a.openSignatureCache()
+		if cache != nil {
+			defer cache.Close()
+		}
+		var ops []tagOp
+		checked, failed := 0, 0
+		for i, path := range items {
+			if ctx.Err() != nil {
+				break
+			}
+			sig, err := cache.Get(path)
+			if err != nil {
+				failed++
+			} else {
+				for _, tag := range sig.QualityTags() {
+					ops = append(ops, tagOp{path: path, tag: tag, add: true})
+				}
+			}
+			checked++
+			fyne.Do(func() {
+				bar.SetValue(float64(i+1) / float64(len(items)))
+				status.SetText(fmt.Sprintf("%d of %d images", i+1, len(items)))
+			})
+		}
+		fyne.Do(func() {
+			progress.Hide()
+			a.slideshowManager.ResumeAfterOperation()
+			summary := fmt.Sprintf("Checked %d images: %d flagged, %d unreadable.", checked, countImages(ops), failed)
+			if checked < len(items) {
+				summary = fmt.Sprintf("Quality check stopped after %d of %d images. %s", checked, len(items), summary)
+			}
+			a.addLogMessage(summary)
+			if len(ops) > 0 {
+				a.submitTagJob(fmt.Sprintf("Adding quality tags to %d image(s)", countImages(ops)), ops, nil)
+			}
+			dialog.ShowInformation("Check Image Quality", summary, a.UI.MainWin)
+		})
+	})
+}
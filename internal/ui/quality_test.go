@@ -0,0 +1,27 @@
+package ui
+
+import (
+	"fyslide/internal/imagesig"
+	"testing"
+)
+
+func TestApplyQualityOps(t *testing.T) {
+	a := &App{}
+	if a.applyQualityOps([]tagOp{{path: "/p/a.jpg", tag: "cats", add: true}}) {
+		t.Error("An ordinary tag changed the quality warnings")
+	}
+	a.applyQualityOps([]tagOp{
+		{path: "/p/a.jpg", tag: imagesig.BlurryTag, add: true},
+		{path: "/p/a.jpg", tag: imagesig.OverexposedTag, add: true},
+		{path: "/p/b.jpg", tag: imagesig.UnderexposedTag, add: true},
+	})
+	if got, want := a.qualityWarning("/p/a.jpg"), "blurry, overexposed"; got != want {
+		t.Errorf("qualityWarning(a) = %q, want %q", got, want)
+	}
+	if !a.applyQualityOps([]tagOp{{path: "/p/b.jpg", tag: imagesig.UnderexposedTag}}) {
+		t.Error("Removing a quality tag reported no change")
+	}
+	if got := a.qualityWarning("/p/b.jpg"); got != "" || len(a.qualityWarnings) != 1 {
+		t.Errorf("After removing b's only warning, qualityWarning(b) = %q with %d images warned", got, len(a.qualityWarnings))
+	}
+}
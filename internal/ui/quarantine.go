@@ -0,0 +1,221 @@
+// Package ui Problem files: images that failed to load repeatedly are
+// quarantined, skipped in this and later sessions until they are retried.
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"fyslide/internal/scan"
+	"fyslide/internal/service"
+	"fyslide/internal/tagging"
+	"io/fs"
+	"path/filepath"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// problemFilesDialogHeight is the height of the Problem Files dialog.
+	problemFilesDialogHeight = 480
+	// problemTimeLayout formats the time of the last failure.
+	problemTimeLayout = "2006-01-02 15:04"
+)
+
+// quarantinedPaths reads the images that failed to load, remembering them to
+// clear their record once they load, and returns those quarantined.
+func (a *App) quarantinedPaths() map[string]bool {
+	failures, err := a.tagDB.LoadFailures(a.ctx)
+	if err != nil {
+		fyne.Do(func() { a.addLogMessage(fmt.Sprintf("Failed to read the problem files: %v", err)) })
+		return nil
+	}
+	a.loadFailures = make(map[string]bool, len(failures))
+	quarantined := make(map[string]bool)
+	for _, f := range failures {
+		a.loadFailures[f.Path] = true
+		if f.Quarantined() {
+			quarantined[f.Path] = true
+		}
+	}
+	return quarantined
+}
+
+// recordLoadFailure counts a failed load of path and quarantines the image
+// once it failed tagging.QuarantineAfter times, showing the next image
+// instead. Reports whether it was quarantined. Read-only mode records nothing.
+func (a *App) recordLoadFailure(path string, loadErr error) bool {
+	if a.readOnly() {
+		return false
+	}
+	f, err := a.tagDB.RecordLoadFailure(a.ctx, path, loadErr)
+	if err != nil {
+		a.addLogMessage(fmt.Sprintf("Failed to record the load failure of %s: %v", filepath.Base(path), err))
+		return false
+	}
+	if a.loadFailures == nil {
+		a.loadFailures = make(map[string]bool)
+	}
+	a.loadFailures[path] = true
+	if !f.Quarantined() {
+		return false
+	}
+	i := slices.IndexFunc(a.images, func(item scan.FileItem) bool { return item.Path == path })
+	if i < 0 {
+		return false
+	}
+	a.quarantinedImages = append(a.quarantinedImages, a.images[i])
+	a.images = slices.Delete(a.images, i, i+1)
+	isPath := func(item scan.FileItem) bool { return item.Path == path }
+	a.filteredImages = slices.DeleteFunc(a.filteredImages, isPath)
+	if a.filterCounts != nil {
+		a.filterCounts.remove(map[string]bool{path: true})
+	}
+	if a.historyManager != nil {
+		a.historyManager.RemovePath(path)
+	}
+	if a.searchIndex != nil {
+		a.searchIndex.Remove(path)
+	}
+	a.addLogMessage(fmt.Sprintf("Quarantined %s after %d failed loads; see View > Problem Files.", filepath.Base(path), f.Failures))
+	a.reshowAfterListChange()
+	return true
+}
+
+// clearLoadFailures forgets the failed loads of path once it loaded fine.
+func (a *App) clearLoadFailures(path string) {
+	if !a.loadFailures[path] || a.readOnly() {
+		return
+	}
+	if err := a.tagDB.ClearLoadFailures(a.ctx, path); err != nil {
+		a.addLogMessage(fmt.Sprintf("Failed to clear the load failures of %s: %v", filepath.Base(path), err))
+		return
+	}
+	delete(a.loadFailures, path)
+}
+
+// releaseQuarantined takes path out of quarantine, forgetting its failures,
+// and reports whether it was quarantined in this session's lists.
+func (a *App) releaseQuarantined(path string) (bool, error) {
+	if err := a.tagDB.ClearLoadFailures(a.ctx, path); err != nil {
+		return false, err
+	}
+	delete(a.loadFailures, path)
+	n := len(a.quarantinedImages)
+	a.quarantinedImages = slices.DeleteFunc(a.quarantinedImages, func(item scan.FileItem) bool { return item.Path == path })
+	return len(a.quarantinedImages) < n, nil
+}
+
+// retryProblemFile takes path out of quarantine and shows it, counting its
+// failures from zero again.
+func (a *App) retryProblemFile(path string) {
+	wasQuarantined, err := a.releaseQuarantined(path)
+	if err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+		return
+	}
+	if wasQuarantined && !a.addToSession(path) {
+		return
+	}
+	a.addLogMessage(fmt.Sprintf("Retrying %s.", filepath.Base(path)))
+	a.jumpToPath(path)
+}
+
+// removeProblemFile deletes the image at path, or just forgets it if the file
+// is already gone, and drops it from the problem files.
+func (a *App) removeProblemFile(path string) {
+	err := a.service.DeleteImage(a.ctx, path)
+	switch {
+	case err == nil, errors.Is(err, service.ErrTagCleanup):
+		a.addLogMessage(fmt.Sprintf("Deleted file: %s", path))
+	case errors.Is(err, fs.ErrNotExist):
+		a.addLogMessage(fmt.Sprintf("%s is already gone; forgetting it.", filepath.Base(path)))
+	default:
+		dialog.ShowError(err, a.UI.MainWin)
+		return
+	}
+	if _, err := a.releaseQuarantined(path); err != nil {
+		a.addLogMessage(fmt.Sprintf("Failed to clear the load failures of %s: %v", filepath.Base(path), err))
+	}
+	if slices.ContainsFunc(a.images, func(item scan.FileItem) bool { return item.Path == path }) {
+		a.removeDeleted(map[string]bool{path: true})
+	}
+}
+
+// showProblemFilesDialog lists the images that failed to load, quarantined
+// or not yet, with the last error, to retry or remove them.
+func (a *App) showProblemFilesDialog() {
+	var failures []tagging.LoadFailure
+	status := widget.NewLabel("")
+	selected := -1
+	list := widget.NewList(
+		func() int { return len(failures) },
+		func() fyne.CanvasObject {
+			name := widget.NewLabel("")
+			name.TextStyle.Bold = true
+			detail := widget.NewLabel("")
+			detail.Truncation = fyne.TextTruncateEllipsis
+			return container.NewVBox(name, detail)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			f := failures[id]
+			rows := obj.(*fyne.Container).Objects
+			name := filepath.Base(f.Path)
+			if f.Quarantined() {
+				name += " (quarantined)"
+			}
+			rows[0].(*widget.Label).SetText(name)
+			rows[1].(*widget.Label).SetText(fmt.Sprintf("%s  ·  failed %d time(s), last %s  ·  %s",
+				filepath.Dir(f.Path), f.Failures, f.LastFailed.Format(problemTimeLayout), f.LastError))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+	list.OnUnselected = func(widget.ListItemID) { selected = -1 }
+
+	refresh := func() {
+		var err error
+		if failures, err = a.tagDB.LoadFailures(a.ctx); err != nil {
+			a.addLogMessage(fmt.Sprintf("Failed to read the problem files: %v", err))
+		}
+		selected = -1
+		list.UnselectAll()
+		list.Refresh()
+		if len(failures) == 0 {
+			status.SetText("No images failed to load.")
+		} else {
+			status.SetText(fmt.Sprintf("%d image(s) failed to load; %d quarantined, which are skipped. Images are quarantined after %d failed loads.",
+				len(failures), len(a.quarantinedImages), tagging.QuarantineAfter))
+		}
+	}
+	refresh()
+
+	var d dialog.Dialog
+	retryButton := widget.NewButton("Retry", func() {
+		if a.refuseInReadOnly("Retry") || selected < 0 || selected >= len(failures) {
+			return
+		}
+		d.Hide()
+		a.retryProblemFile(failures[selected].Path)
+	})
+	removeButton := widget.NewButton("Remove", func() {
+		if a.refuseInReadOnly("Remove") || selected < 0 || selected >= len(failures) {
+			return
+		}
+		path := failures[selected].Path
+		dialog.ShowConfirm("Remove Problem File", fmt.Sprintf("Delete %s?\n%s", filepath.Base(path), a.deleteConsequence()), func(ok bool) {
+			if ok {
+				a.removeProblemFile(path)
+				refresh()
+			}
+		}, a.UI.MainWin)
+	})
+
+	a.slideshowManager.Pause(true)
+	d = dialog.NewCustom("Problem Files", "Close", container.NewBorder(status, container.NewHBox(retryButton, removeButton), nil, nil, list), a.UI.MainWin)
+	d.SetOnClosed(a.slideshowManager.ResumeAfterOperation)
+	d.Resize(fyne.NewSize(searchDialogWidth, problemFilesDialogHeight))
+	d.Show()
+}
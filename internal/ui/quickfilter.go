@@ -0,0 +1,189 @@
+// Package ui Quick tag filter entry shown in the toolbar.
+package ui
+
+import (
+	"fyslide/internal/query"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	quickFilterWidth          = 220                  // Width of the entry in the toolbar
+	quickFilterCountSample    = "000,000 of 000,000" // Widest count shown beside the entry
+	maxQuickFilterSuggestions = 10                   // Completions offered in the drop-down
+)
+
+// quickFilterEntry is a SelectEntry whose drop-down offers tag completions.
+// Escape clears both the text and the active filter.
+type quickFilterEntry struct {
+	widget.SelectEntry
+	app     *App
+	syncing bool     // Set while the text is updated to mirror the active filter
+	known   []string // Tag names completed against, loaded when the entry gains focus
+}
+
+func newQuickFilterEntry(a *App) *quickFilterEntry {
+	e := &quickFilterEntry{app: a}
+	e.ExtendBaseWidget(e)
+	e.SetOptions(nil)
+	e.SetPlaceHolder("Filter by tag...")
+	e.OnChanged = e.changed
+	e.OnSubmitted = e.submitted
+	return e
+}
+
+// TypedKey clears the filter on Escape and otherwise behaves like a normal entry.
+func (e *quickFilterEntry) TypedKey(key *fyne.KeyEvent) {
+	if key.Name == fyne.KeyEscape {
+		e.setTextSilently("")
+		e.app.clearFilter()
+		e.app.UI.MainWin.Canvas().Unfocus()
+		return
+	}
+	e.SelectEntry.TypedKey(key)
+}
+
+// FocusGained reloads the tag names in the background, so typing completes
+// against the tags as they are now without reading the database per key.
+func (e *quickFilterEntry) FocusGained() {
+	e.SelectEntry.FocusGained()
+	a := e.app
+	a.goBackground(func() {
+		allTags, err := a.tagDB.GetAllTags(a.ctx)
+		if err != nil {
+			fyne.Do(func() { a.addLogMessage("Quick filter: failed to load tags: " + err.Error()) })
+			return
+		}
+		known := make([]string, 0, len(allTags))
+		for _, t := range allTags {
+			known = append(known, t.Name)
+		}
+		fyne.Do(func() {
+			e.known = known
+			if strings.TrimSpace(e.Text) != "" {
+				e.changed(e.Text) // Typed before the names were loaded
+			}
+		})
+	})
+}
+
+// setTextSilently changes the text without triggering completion or filtering.
+func (e *quickFilterEntry) setTextSilently(text string) {
+	e.syncing = true
+	e.SetText(text)
+	e.syncing = false
+}
+
+// parseQuickFilter splits comma-separated input into normalized, de-duplicated tags.
+func parseQuickFilter(text string) []string {
+	var tags []string
+	for _, part := range strings.Split(text, ",") {
+		tag := strings.ToLower(strings.TrimSpace(part))
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// quickFilterSuggestions completes the last comma-separated segment of text
+// against known tag names. Each suggestion is the full entry text with that
+// segment completed, so picking one from the drop-down keeps earlier tags.
+func quickFilterSuggestions(text string, known []string) []string {
+	prefix := ""
+	last := text
+	if i := strings.LastIndex(text, ","); i >= 0 {
+		prefix = strings.TrimSpace(text[:i]) + ", "
+		last = text[i+1:]
+	}
+	last = strings.ToLower(strings.TrimSpace(last))
+
+	var suggestions []string
+	for _, name := range known {
+		if len(suggestions) == maxQuickFilterSuggestions {
+			break
+		}
+		if strings.HasPrefix(name, last) {
+			suggestions = append(suggestions, prefix+name)
+		}
+	}
+	return suggestions
+}
+
+// changed refreshes the completions and applies a single, exactly matching tag
+// straight away. Several tags are only applied on Enter.
+func (e *quickFilterEntry) changed(text string) {
+	if e.syncing {
+		return
+	}
+	known := e.known
+	if strings.TrimSpace(text) == "" {
+		e.SetOptions(nil)
+		e.app.clearFilter()
+		return
+	}
+	e.SetOptions(quickFilterSuggestions(text, known))
+
+	tags := parseQuickFilter(text)
+	if len(tags) == 1 && !strings.Contains(text, ",") && slices.Contains(known, tags[0]) {
+		e.app.applyQuickFilter(tags)
+	}
+}
+
+// submitted applies every tag in the entry with AND semantics.
+func (e *quickFilterEntry) submitted(text string) {
+	tags := parseQuickFilter(text)
+	if len(tags) == 0 {
+		e.app.clearFilter()
+		return
+	}
+	e.app.applyQuickFilter(tags)
+}
+
+// applyQuickFilter filters by tags unless exactly that filter is already active.
+func (a *App) applyQuickFilter(tags []string) {
+	if a.isFiltered && a.currentFilter.Folder == "" && !a.currentFilter.HasPropertyFilters() && slices.Equal(a.currentFilter.Tags, tags) {
+		return
+	}
+	a.applyCriteria(query.Criteria{Tags: tags})
+}
+
+// syncQuickFilter mirrors the active filter's tags in the quick filter entry.
+func (a *App) syncQuickFilter() {
+	if a.UI.quickFilter == nil {
+		return
+	}
+	text := strings.Join(a.currentFilter.Tags, ", ")
+	if strings.Join(parseQuickFilter(a.UI.quickFilter.Text), ", ") != text {
+		a.UI.quickFilter.setTextSilently(text)
+	}
+}
+
+// syncFilterCount shows the live count of the active filter beside the
+// quick filter entry.
+func (a *App) syncFilterCount() {
+	if a.UI.filterCount != nil {
+		a.UI.filterCount.SetText(a.filterCount())
+	}
+}
+
+// quickFilterToolbarItem places the quick filter entry in a widget.Toolbar,
+// after the count of images matching the active filter.
+type quickFilterToolbarItem struct {
+	entry *quickFilterEntry
+	count *widget.Label
+}
+
+// ToolbarObject implements widget.ToolbarItem.
+func (q *quickFilterToolbarItem) ToolbarObject() fyne.CanvasObject {
+	height := q.entry.MinSize().Height
+	countWidth := widget.NewLabel(quickFilterCountSample).MinSize().Width
+	q.count.Alignment = fyne.TextAlignTrailing
+	return container.NewHBox(
+		container.NewGridWrap(fyne.NewSize(countWidth, height), q.count),
+		container.NewGridWrap(fyne.NewSize(quickFilterWidth, height), q.entry))
+}
@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuickFilter(t *testing.T) {
+	got := parseQuickFilter(" Cats, dogs ,,cats,  ")
+	want := []string{"cats", "dogs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseQuickFilter = %v, want %v", got, want)
+	}
+	if got := parseQuickFilter(" , "); got != nil {
+		t.Errorf("parseQuickFilter of blank input = %v, want nil", got)
+	}
+}
+
+func TestQuickFilterSuggestions(t *testing.T) {
+	known := []string{"beach", "birds", "cats", "city"}
+
+	got := quickFilterSuggestions("c", known)
+	want := []string{"cats", "city"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("suggestions for single prefix = %v, want %v", got, want)
+	}
+
+	got = quickFilterSuggestions("cats,B", known)
+	want = []string{"cats, beach", "cats, birds"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("suggestions for last segment = %v, want %v", got, want)
+	}
+}
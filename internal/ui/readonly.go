@@ -0,0 +1,40 @@
+// Package ui Read-only browsing mode (--read-only).
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// readOnly reports whether the library was opened with --read-only. The service
+// refuses mutations on its own; the UI checks this to hide the affordances.
+func (a *App) readOnly() bool {
+	return a.service != nil && a.service.ReadOnly()
+}
+
+// refuseInReadOnly tells the user why action is unavailable and reports true
+// when the library is read-only.
+func (a *App) refuseInReadOnly(action string) bool {
+	if !a.readOnly() {
+		return false
+	}
+	dialog.ShowInformation(action, "FySlide was started with --read-only; images and tags can't be changed.", a.UI.MainWin)
+	return true
+}
+
+// mutatingMenuItem creates a menu item that is disabled in read-only mode.
+func (a *App) mutatingMenuItem(label string, action func()) *fyne.MenuItem {
+	item := fyne.NewMenuItem(label, action)
+	item.Disabled = a.readOnly()
+	return item
+}
+
+// mutatingToolbarAction creates a toolbar action that is disabled in read-only mode.
+func (a *App) mutatingToolbarAction(icon fyne.Resource, action func()) *widget.ToolbarAction {
+	item := widget.NewToolbarAction(icon, action)
+	if a.readOnly() {
+		item.Disable()
+	}
+	return item
+}
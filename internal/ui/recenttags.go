@@ -0,0 +1,84 @@
+// Package ui Recently used tags: the tags added last are offered again in the
+// Add Tag dialog, and Reapply Last Tags adds the last set to another image.
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// maxRecentTags is how many of the tags added last are remembered.
+const maxRecentTags = 12
+
+// withRecentTags returns recent with applied moved to its front, in their
+// order, keeping at most maxRecentTags.
+func withRecentTags(recent, applied []string) []string {
+	return withRecent(recent, applied, maxRecentTags)
+}
+
+// withRecent returns recent with used moved to its front, in their order,
+// keeping at most limit entries.
+func withRecent(recent, used []string, limit int) []string {
+	result := slices.Clone(used)
+	for _, entry := range recent {
+		if !slices.Contains(result, entry) {
+			result = append(result, entry)
+		}
+	}
+	return result[:min(len(result), limit)]
+}
+
+// rememberAppliedTags records tags as the set added last and moves them to
+// the front of the recently used tags.
+func (a *App) rememberAppliedTags(tags []string) {
+	a.prefs().SetStringList(prefLastTags, tags)
+	a.prefs().SetStringList(prefRecentTags, withRecentTags(a.prefs().StringList(prefRecentTags), tags))
+}
+
+// reapplyLastTags adds the tags added together last to the current image,
+// for Ctrl+Shift+T.
+func (a *App) reapplyLastTags() {
+	if a.refuseInReadOnly("Reapply Last Tags") {
+		return
+	}
+	if a.img.Path == "" {
+		dialog.ShowInformation("Reapply Last Tags", "No image loaded to tag.", a.UI.MainWin)
+		return
+	}
+	tags := a.prefs().StringList(prefLastTags)
+	if len(tags) == 0 {
+		dialog.ShowInformation("Reapply Last Tags", "No tags were added yet.", a.UI.MainWin)
+		return
+	}
+	if err := a.addTagsToCurrentImage("Reapplying tag(s)", tags); err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+	}
+}
+
+// addTagsToCurrentImage adds those of tags the current image lacks to it and
+// remembers them as the tags added last. verb starts the log message, e.g.
+// "Reapplying tag(s)".
+func (a *App) addTagsToCurrentImage(verb string, tags []string) error {
+	current, err := a.tagDB.GetTags(a.ctx, a.img.Path)
+	if err != nil {
+		return fmt.Errorf("failed to get current tags: %w", err)
+	}
+	current = a.withPendingTags(a.img.Path, current)
+	var ops []tagOp
+	for _, tag := range tags {
+		if !slices.Contains(current, tag) {
+			ops = append(ops, tagOp{path: a.img.Path, tag: tag, add: true})
+		}
+	}
+	if len(ops) == 0 {
+		a.addLogMessage(fmt.Sprintf("%s already has tag(s) [%s].", filepath.Base(a.img.Path), strings.Join(tags, ", ")))
+		return nil
+	}
+	a.rememberAppliedTags(tags)
+	a.submitTagJob(fmt.Sprintf("%s [%s] to %s", verb, strings.Join(tags, ", "), filepath.Base(a.img.Path)), ops, nil)
+	return nil
+}
@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestWithRecentTags(t *testing.T) {
+	got := withRecentTags([]string{"beach", "family", "2023"}, []string{"2024", "family"})
+	if want := []string{"2024", "family", "beach", "2023"}; !slices.Equal(got, want) {
+		t.Errorf("withRecentTags = %v, want %v", got, want)
+	}
+
+	var many []string
+	for i := range maxRecentTags + 5 {
+		many = append(many, fmt.Sprint("tag", i))
+	}
+	got = withRecentTags(many, []string{"new"})
+	if len(got) != maxRecentTags || got[0] != "new" || got[maxRecentTags-1] != many[maxRecentTags-2] {
+		t.Errorf("withRecentTags kept %v, want new and the %d most recent", got, maxRecentTags-1)
+	}
+}
@@ -0,0 +1,136 @@
+// Package ui Rescan and reconcile: re-walks the library and applies the files
+// added, removed or changed since the scan to the lists in place.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/scan"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// rescanLibrary re-walks the library folders, reading every directory, and
+// reconciles the image lists with what is on disk now, keeping the current
+// image and filter.
+func (a *App) rescanLibrary() {
+	if a.rescanning {
+		a.addLogMessage("A rescan is already running.")
+		return
+	}
+	if !a.scanDone.Load() {
+		dialog.ShowInformation("Rescan and Reconcile", "The library is still being scanned.", a.UI.MainWin)
+		return
+	}
+	a.rescanning = true
+	progress := dialog.NewCustomWithoutButtons("Rescanning Library", widget.NewProgressBarInfinite(), a.UI.MainWin)
+	progress.Show()
+	roots := a.libraryRoots
+	a.goBackground(func() {
+		logger := func(message string) { fyne.Do(func() { a.addLogMessage(message) }) }
+		cache, err := scan.OpenCache("")
+		if err != nil {
+			logger(fmt.Sprintf("Scan cache unavailable: %v", err))
+		} else {
+			defer cache.Close()
+		}
+		var fresh scan.FileItems
+		for _, root := range roots {
+			for item := range scan.RunCachedContext(a.ctx, root, cache, true, logger) {
+				if !a.config.Excluded(item.Path) {
+					fresh = append(fresh, item)
+				}
+			}
+		}
+		if a.ctx.Err() != nil {
+			return // The window closed while rescanning
+		}
+		fyne.Do(func() {
+			progress.Hide()
+			a.rescanning = false
+			a.reconcileImages(fresh)
+		})
+	})
+}
+
+// reconcileImages applies the difference between fresh, the images found on
+// disk, and the browsed images. Images kept out of the lists, private or
+// quarantined, stay out. The current image stays shown unless it is gone or
+// changed; an active filter loses the images removed but gains no new ones.
+func (a *App) reconcileImages(fresh scan.FileItems) {
+	onDisk := make(map[string]bool, len(fresh))
+	for _, item := range fresh {
+		onDisk[item.Path] = true
+	}
+	gone := func(item scan.FileItem) bool { return !onDisk[item.Path] }
+	a.privateImages = slices.DeleteFunc(a.privateImages, gone)
+	a.quarantinedImages = slices.DeleteFunc(a.quarantinedImages, gone)
+	kept := make(map[string]bool, len(a.privateImages)+len(a.quarantinedImages))
+	for _, item := range slices.Concat(a.privateImages, a.quarantinedImages) {
+		kept[item.Path] = true
+	}
+	visible := slices.DeleteFunc(fresh, func(item scan.FileItem) bool { return kept[item.Path] })
+	slices.SortStableFunc(visible, func(x, y scan.FileItem) int { return strings.Compare(x.Path, y.Path) })
+
+	diff := scan.Diff(a.images, visible)
+	if diff.IsEmpty() {
+		a.addLogMessage("Rescan: the image list is up to date.")
+		dialog.ShowInformation("Rescan and Reconcile", "No images were added, removed or changed.", a.UI.MainWin)
+		return
+	}
+
+	removed := make(map[string]bool, len(diff.Removed))
+	for _, item := range diff.Removed {
+		removed[item.Path] = true
+		if a.historyManager != nil {
+			a.historyManager.RemovePath(item.Path)
+		}
+		if a.stacks != nil {
+			a.stacks.Remove(item.Path)
+		}
+	}
+	changed := make(map[string]scan.FileItem, len(diff.Changed))
+	for _, item := range diff.Changed {
+		changed[item.Path] = item
+		a.thumbnailManager.Forget(item.Path)
+	}
+	a.images = visible
+	a.searchIndex = nil // Rebuilt from the new list on the next search
+	if a.isFiltered {
+		a.filteredImages = slices.DeleteFunc(a.filteredImages, func(item scan.FileItem) bool { return removed[item.Path] })
+		for i, item := range a.filteredImages {
+			if fresh, ok := changed[item.Path]; ok {
+				a.filteredImages[i] = fresh
+			}
+		}
+		if a.filterCounts != nil {
+			a.filterCounts.remove(removed)
+		}
+	}
+
+	summary := fmt.Sprintf("%d image(s) added, %d removed, %d changed.", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	a.addLogMessage("Rescan: " + summary)
+	if a.isFiltered && len(diff.Added) > 0 {
+		summary += "\nNew images join the filter when it is applied again."
+	}
+	a.keepPositionAfterRescan(changed[a.img.Path].Path != "")
+	dialog.ShowInformation("Rescan and Reconcile", summary, a.UI.MainWin)
+}
+
+// keepPositionAfterRescan finds the current image in the reconciled list
+// without reloading it, unless its file changed or it is gone.
+func (a *App) keepPositionAfterRescan(currentChanged bool) {
+	if !currentChanged && (!a.isFiltered || len(a.filteredImages) > 0) {
+		index := slices.IndexFunc(a.getCurrentList(), func(item scan.FileItem) bool { return item.Path == a.img.Path })
+		if index >= 0 {
+			a.index = index // Same image, perhaps at another position
+			a.updateThumbStrip()
+			a.updateStatusBar()
+			return
+		}
+	}
+	a.reshowAfterListChange()
+}
@@ -0,0 +1,102 @@
+// Package ui Renaming the current image from the viewer.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/scan"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showRenameDialog asks for a new file name for the current image and renames it in place.
+func (a *App) showRenameDialog() {
+	if a.refuseInReadOnly("Rename File") {
+		return
+	}
+	oldPath := a.img.Path
+	if oldPath == "" {
+		dialog.ShowInformation("Rename File", "No image loaded to rename.", a.UI.MainWin)
+		return
+	}
+	if a.tagWorker != nil && len(a.tagWorker.pending[oldPath]) > 0 {
+		dialog.ShowInformation("Rename File", "Tag changes for this image are still being saved. Try again in a moment.", a.UI.MainWin)
+		return
+	}
+
+	a.slideshowManager.Pause(true) // Pause for the rename operation
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(filepath.Base(oldPath))
+	nameEntry.Validator = func(name string) error {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+			return fmt.Errorf("name cannot be empty")
+		case strings.ContainsAny(name, `/\`):
+			return fmt.Errorf("name cannot contain path separators")
+		case !scan.IsImage(name):
+			return fmt.Errorf("name must keep a supported image extension")
+		}
+		return nil
+	}
+
+	dialog.ShowForm("Rename File", "Rename", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("New name", nameEntry),
+	}, func(confirm bool) {
+		defer a.slideshowManager.ResumeAfterOperation()
+		if !confirm {
+			return
+		}
+		newPath := filepath.Join(filepath.Dir(oldPath), strings.TrimSpace(nameEntry.Text))
+		if newPath == oldPath {
+			return
+		}
+		if err := a.service.RenameImage(a.ctx, oldPath, newPath); err != nil {
+			a.addLogMessage(fmt.Sprintf("Error renaming %s: %v", filepath.Base(oldPath), err))
+			dialog.ShowError(err, a.UI.MainWin)
+			return
+		}
+		a.addLogMessage(fmt.Sprintf("Renamed %s to %s.", filepath.Base(oldPath), filepath.Base(newPath)))
+		a.applyRename(oldPath, newPath)
+	}, a.UI.MainWin)
+}
+
+// applyRename updates every in-memory reference to a file that was renamed on disk.
+func (a *App) applyRename(oldPath, newPath string) {
+	info, _ := os.Stat(newPath) // nil on error; updateInfoText falls back to os.Stat
+	renameIn := func(list scan.FileItems) {
+		for i := range list {
+			if list[i].Path == oldPath {
+				list[i] = scan.NewFileItem(newPath, info)
+			}
+		}
+	}
+	renameIn(a.images)
+	renameIn(a.filteredImages)
+
+	if a.historyManager != nil {
+		a.historyManager.RenamePath(oldPath, newPath)
+	}
+	a.thumbnailManager.Rename(oldPath, newPath)
+	a.orientations.rename(oldPath, newPath)
+	if a.searchIndex != nil {
+		a.searchIndex.Rename(oldPath, newPath)
+	}
+	if a.thumbStrip != nil {
+		for _, slot := range a.thumbStrip.slots {
+			if slot.path == oldPath {
+				slot.path = newPath
+			}
+		}
+	}
+
+	if a.img.Path == oldPath {
+		a.img.Path = newPath
+		a.UI.MainWin.SetTitle(fmt.Sprintf("FySlide%s - %v", a.profileTitle(), newPath))
+	}
+	a.updateStatusBar()
+	a.updateInfoText()
+}
@@ -0,0 +1,69 @@
+// Package ui Interpolation used to scale the image in the zoom area.
+package ui
+
+import (
+	"math"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+// Scaling modes for drawing the zoomed image.
+const (
+	ScalingAuto       = "auto"       // Nearest neighbor while zooming or panning, Catmull-Rom when idle and affordable
+	ScalingNearest    = "nearest"    // Fastest, blocky when enlarged and shimmering when reduced
+	ScalingBilinear   = "bilinear"   // Fast and smooth, a little soft
+	ScalingCatmullRom = "catmullrom" // Sharp cubic interpolation
+	ScalingLanczos    = "lanczos"    // Sharpest, slowest; Lanczos with a support of 3
+)
+
+// scalingModes lists the valid scaling modes in display order.
+var scalingModes = []string{ScalingAuto, ScalingNearest, ScalingBilinear, ScalingCatmullRom, ScalingLanczos}
+
+// scalingIdleDelay is how long after the last zoom or pan the auto mode
+// redraws in high quality.
+const scalingIdleDelay = 250 * time.Millisecond
+
+// autoQualityMaxPixels is the most source pixels the auto mode scales with
+// Catmull-Rom. Larger visible areas, typically big photos shown whole, use
+// bilinear, which is an order of magnitude faster.
+const autoQualityMaxPixels = 2_000_000
+
+// lanczos3 is the Lanczos kernel with a support of 3, which x/image/draw
+// doesn't provide.
+var lanczos3 = &draw.Kernel{Support: 3, At: func(t float64) float64 {
+	if t < 0 {
+		t = -t
+	}
+	if t == 0 {
+		return 1
+	}
+	if t >= 3 {
+		return 0
+	}
+	x := math.Pi * t
+	return 3 * math.Sin(x) * math.Sin(x/3) / (x * x)
+}}
+
+// interpolatorFor returns the interpolator of a scaling mode. In auto mode it
+// depends on whether the user is zooming or panning right now and on how many
+// source pixels are visible.
+func interpolatorFor(mode string, interacting bool, visiblePixels int) draw.Interpolator {
+	switch mode {
+	case ScalingNearest:
+		return draw.NearestNeighbor
+	case ScalingBilinear:
+		return draw.ApproxBiLinear
+	case ScalingCatmullRom:
+		return draw.CatmullRom
+	case ScalingLanczos:
+		return lanczos3
+	}
+	switch {
+	case interacting:
+		return draw.NearestNeighbor
+	case visiblePixels > autoQualityMaxPixels:
+		return draw.ApproxBiLinear
+	}
+	return draw.CatmullRom
+}
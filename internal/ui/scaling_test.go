@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/image/draw"
+)
+
+func TestInterpolatorFor(t *testing.T) {
+	tests := []struct {
+		mode        string
+		interacting bool
+		pixels      int
+		want        draw.Interpolator
+	}{
+		{ScalingNearest, false, 100, draw.NearestNeighbor},
+		{ScalingBilinear, false, 100, draw.ApproxBiLinear},
+		{ScalingCatmullRom, true, 12_000_000, draw.CatmullRom},
+		{ScalingLanczos, true, 100, lanczos3},
+		{ScalingAuto, true, 100, draw.NearestNeighbor},
+		{ScalingAuto, false, 100, draw.CatmullRom},
+		{ScalingAuto, false, 12_000_000, draw.ApproxBiLinear},
+		{"", false, 100, draw.CatmullRom},
+	}
+	for _, tt := range tests {
+		if got := interpolatorFor(tt.mode, tt.interacting, tt.pixels); got != tt.want {
+			t.Errorf("interpolatorFor(%q, %v, %d) = %v, want %v", tt.mode, tt.interacting, tt.pixels, got, tt.want)
+		}
+	}
+}
+
+func TestLanczos3Kernel(t *testing.T) {
+	if got := lanczos3.At(0); got != 1 {
+		t.Errorf("lanczos3(0) = %v, want 1", got)
+	}
+	for _, x := range []float64{1, -2, 3, 4} {
+		if got := lanczos3.At(x); math.Abs(got) > 1e-12 {
+			t.Errorf("lanczos3(%v) = %v, want 0", x, got)
+		}
+	}
+	if lanczos3.At(0.5) != lanczos3.At(-0.5) {
+		t.Error("lanczos3 is not symmetric")
+	}
+}
@@ -0,0 +1,67 @@
+// Package ui Slideshow schedule: in kiosk mode, switches the filter with the
+// time of day as the slideshow schedule of config.yaml says.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/config"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// scheduleCheckInterval is how often kiosk mode checks for the next entry of
+// the schedule.
+const scheduleCheckInterval = time.Minute
+
+// followSchedule applies the schedule's active filter now and again whenever
+// another entry becomes active, until the app stops. A filter chosen by hand
+// stays until the next switch. Without kiosk mode or a schedule it does nothing.
+func (a *App) followSchedule() {
+	if !a.kiosk || a.config == nil || len(a.config.Slideshow.Schedule) == 0 {
+		return
+	}
+	schedule := a.config.Slideshow
+	a.goBackground(func() {
+		ticker := time.NewTicker(scheduleCheckInterval)
+		defer ticker.Stop()
+		applied, first := "", true
+		for {
+			entry, ok := schedule.ScheduledAt(time.Now())
+			key := ""
+			if ok {
+				key = entry.Name + "\x00" + entry.Filter // Entries needn't have distinct names
+			}
+			if first || key != applied {
+				applied, first = key, false
+				fyne.Do(func() { a.applyScheduleEntry(entry, ok) })
+			}
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// applyScheduleEntry shows the images matching the filter of entry, or all
+// images if no entry is active.
+func (a *App) applyScheduleEntry(entry config.ScheduleEntry, active bool) {
+	if !active {
+		a.addLogMessage("Schedule: no entry is active, showing all images.")
+		a.clearFilter()
+		return
+	}
+	c, err := entry.Criteria()
+	if err != nil { // Checked when the config was loaded
+		a.addLogMessage(fmt.Sprintf("Schedule: %s: %v", entry.Name, err))
+		return
+	}
+	a.addLogMessage(fmt.Sprintf("Schedule: switching to %s.", entry.Name))
+	if c.IsEmpty() {
+		a.clearFilter()
+		return
+	}
+	a.applyCriteria(c)
+}
@@ -0,0 +1,167 @@
+// Package ui Search across file names, directories, tags and OCR text (Ctrl+F).
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/search"
+	"image"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	maxSearchResults  = 500 // Results listed at once; refine the query to see others
+	searchThumbSize   = 48
+	searchDialogWidth = 640
+)
+
+// ensureSearchIndex builds the search index from the loaded images on first use.
+// Afterwards it is kept current by tag, rename and delete operations.
+func (a *App) ensureSearchIndex() error {
+	if a.searchIndex != nil {
+		return nil
+	}
+	imageTags, err := a.tagDB.GetAllImageTags(a.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to build search index: %w", err)
+	}
+	idx := search.New()
+	for _, item := range a.images {
+		idx.Set(item.Path, imageTags[item.Path])
+	}
+	if err := a.loadSearchTexts(idx); err != nil {
+		return fmt.Errorf("failed to build search index: %w", err)
+	}
+	a.searchIndex = idx
+	a.addLogMessage(fmt.Sprintf("Search index built for %d images.", idx.Len()))
+	return nil
+}
+
+// updateSearchIndexTags re-reads the tags of paths into the search index.
+func (a *App) updateSearchIndexTags(paths []string) {
+	if a.searchIndex == nil {
+		return
+	}
+	for _, path := range paths {
+		tags, err := a.tagDB.GetTags(a.ctx, path)
+		if err != nil {
+			a.addLogMessage(fmt.Sprintf("Search index: failed to read tags for %s: %v", filepath.Base(path), err))
+			continue
+		}
+		a.searchIndex.UpdateTags(path, tags)
+	}
+}
+
+// showSearchDialog opens the search panel listing images that match the query.
+func (a *App) showSearchDialog() {
+	if err := a.ensureSearchIndex(); err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+		return
+	}
+	a.slideshowManager.Pause(true)
+
+	var results []search.Result
+	status := widget.NewLabel("Type to search file names, folders, tags and text.")
+	list := widget.NewList(
+		func() int { return len(results) },
+		func() fyne.CanvasObject {
+			thumb := canvas.NewImageFromImage(nil)
+			thumb.FillMode = canvas.ImageFillContain
+			thumb.SetMinSize(fyne.NewSize(searchThumbSize, searchThumbSize))
+			name := widget.NewLabel("")
+			name.TextStyle.Bold = true
+			detail := widget.NewLabel("")
+			detail.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, thumb, nil, container.NewVBox(name, detail))
+		},
+		nil, // Set below; the update needs the list to refresh items when thumbnails arrive
+	)
+	list.UpdateItem = func(id widget.ListItemID, obj fyne.CanvasObject) {
+		if id >= len(results) {
+			return
+		}
+		r := results[id]
+		row := obj.(*fyne.Container)
+		text := row.Objects[0].(*fyne.Container)
+		thumb := row.Objects[1].(*canvas.Image)
+		text.Objects[0].(*widget.Label).SetText(filepath.Base(r.Path))
+		detail := filepath.Dir(r.Path)
+		if len(r.Tags) > 0 {
+			detail += "  ·  " + strings.Join(r.Tags, ", ")
+		}
+		if r.Snippet != "" {
+			detail += "  ·  \"" + r.Snippet + "\""
+		}
+		text.Objects[1].(*widget.Label).SetText(detail)
+
+		img, ok := a.thumbnailManager.Get(r.Path, func(image.Image) {
+			fyne.Do(func() { list.RefreshItem(id) })
+		})
+		if !ok {
+			img = nil
+		}
+		thumb.Image = img
+		thumb.Refresh()
+	}
+
+	queryEntry := widget.NewEntry()
+	queryEntry.SetPlaceHolder("Search file names, folders, tags and text...")
+	queryEntry.OnChanged = func(query string) {
+		results = a.searchIndex.Search(query, maxSearchResults)
+		switch {
+		case strings.TrimSpace(query) == "":
+			status.SetText("Type to search file names, folders, tags and text.")
+		case len(results) == maxSearchResults:
+			status.SetText(fmt.Sprintf("Showing the first %d matches; refine the search to narrow them down.", maxSearchResults))
+		default:
+			status.SetText(fmt.Sprintf("%d matching images.", len(results)))
+		}
+		list.UnselectAll()
+		list.Refresh()
+		list.ScrollToTop()
+	}
+
+	content := container.NewBorder(queryEntry, status, nil, nil, list)
+	d := dialog.NewCustom("Search", "Close", content, a.UI.MainWin)
+	list.OnSelected = func(id widget.ListItemID) {
+		if id >= len(results) {
+			return
+		}
+		path := results[id].Path
+		d.Hide()
+		a.jumpToPath(path)
+	}
+	d.SetOnClosed(a.slideshowManager.ResumeAfterOperation)
+	d.Resize(fyne.NewSize(searchDialogWidth, a.UI.MainWin.Canvas().Size().Height*0.8))
+	d.Show()
+	a.UI.MainWin.Canvas().Focus(queryEntry)
+}
+
+// jumpToPath shows the image at path, clearing the filter first if it hides the image.
+func (a *App) jumpToPath(path string) {
+	find := func() int {
+		for i, item := range a.getCurrentList() {
+			if item.Path == path {
+				return i
+			}
+		}
+		return -1
+	}
+	index := find()
+	if index < 0 && a.isFiltered {
+		a.clearFilter()
+		index = find()
+	}
+	if index < 0 {
+		a.addLogMessage(fmt.Sprintf("%s is no longer in the image list.", filepath.Base(path)))
+		return
+	}
+	a.selectStackView(imageViewIndex)
+	a.jumpToIndex(index)
+}
@@ -0,0 +1,329 @@
+// Package ui Preferences dialog for user-adjustable settings.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/autotag"
+	"fyslide/internal/dirtags"
+	"fyslide/internal/imagesig"
+	"fyslide/internal/tagging"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Limits of the slideshow settings accepted in Preferences.
+const (
+	minSlideshowSeconds = 0.1
+	maxSlideshowSeconds = 3600
+	maxSkipCount        = 10000
+)
+
+// showPreferencesDialog lets the user edit persisted settings; saving them
+// applies them at once, see watchPreferences.
+func (a *App) showPreferencesDialog() {
+	prefs := a.prefs()
+
+	var sizeOptions []string
+	for size := minThumbStripSize; size <= maxThumbStripSize; size += 2 {
+		sizeOptions = append(sizeOptions, strconv.Itoa(size))
+	}
+	stripSizeSelect := widget.NewSelect(sizeOptions, nil)
+	stripSizeSelect.SetSelected(strconv.Itoa(a.thumbStripSize()))
+
+	stripPositionSelect := widget.NewSelect(thumbStripPositions, nil)
+	stripPositionSelect.SetSelected(a.thumbStripPosition())
+
+	panStepEntry := widget.NewEntry()
+	panStepEntry.SetText(strconv.Itoa(a.panStep()))
+	panStepEntry.Validator = intRangeValidator(minPanStep, maxPanStep)
+
+	backgroundSelect := widget.NewSelect(backgroundModes, nil)
+	backgroundSelect.SetSelected(a.backgroundMode())
+	backgroundColorEntry := widget.NewEntry()
+	backgroundColorEntry.SetText(prefs.StringWithFallback(prefBackgroundColor, defaultBackgroundColor))
+	backgroundColorEntry.Validator = func(text string) error {
+		_, err := parseHexColor(text)
+		return err
+	}
+
+	scalingSelect := widget.NewSelect(scalingModes, nil)
+	scalingSelect.SetSelected(a.scalingMode())
+	drawTimeCheck := widget.NewCheck("Show per-frame draw time", nil)
+	drawTimeCheck.SetChecked(prefs.Bool(prefShowDrawTime))
+	transitionSelect := widget.NewSelect(transitionModes, nil)
+	transitionSelect.SetSelected(a.transitionMode())
+	zoomMemorySelect := widget.NewSelect(zoomMemoryOptions, nil)
+	zoomMemorySelect.SetSelected(a.zoomMemoryMode())
+	zoomMemorySaveCheck := widget.NewCheck("Keep across sessions", nil)
+	zoomMemorySaveCheck.SetChecked(prefs.Bool(prefZoomMemoryPersist))
+
+	decodeCapSelect := widget.NewSelect(decodeCapOptions, nil)
+	decodeCapSelect.SetSelected(a.decodeCapLabel())
+
+	ioLimitEntry := widget.NewEntry()
+	ioLimitEntry.SetText(strconv.Itoa(a.backgroundIOLimit()))
+	ioLimitEntry.Validator = intRangeValidator(0, maxBackgroundIOLimit)
+
+	loadPreviewCheck := widget.NewCheck("Show a quick preview while large JPEGs load", nil)
+	loadPreviewCheck.SetChecked(prefs.BoolWithFallback(prefLoadPreview, true))
+
+	skipCorruptCheck := widget.NewCheck("Skip unreadable images during slideshow", nil)
+	skipCorruptCheck.SetChecked(prefs.Bool(prefSkipCorrupt))
+	tagCorruptCheck := widget.NewCheck(fmt.Sprintf("Tag skipped images as '%s'", tagging.CorruptTag), nil)
+	tagCorruptCheck.SetChecked(prefs.Bool(prefTagCorrupt))
+
+	shuffleSeedEntry := widget.NewEntry()
+	shuffleSeedEntry.SetPlaceHolder("empty for a new order each session")
+	shuffleSeedEntry.SetText(prefs.String(prefShuffleSeed))
+	shuffleSeedEntry.Validator = shuffleSeedValidator
+	shufflePersistCheck := widget.NewCheck("Continue the shuffled order after a restart", nil)
+	shufflePersistCheck.SetChecked(prefs.Bool(prefShufflePersist))
+
+	playbackSelect := widget.NewSelect(playbackModes, nil)
+	playbackSelect.SetSelected(a.playbackMode())
+
+	orientationSelect := widget.NewSelect(orientationModes, nil)
+	orientationSelect.SetSelected(a.orientationMode())
+
+	intervalEntry := widget.NewEntry()
+	intervalEntry.SetText(strconv.FormatFloat(a.slideshowManager.Interval().Seconds(), 'f', -1, 64))
+	intervalEntry.Validator = func(text string) error {
+		seconds, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number of seconds")
+		}
+		if seconds < minSlideshowSeconds || seconds > maxSlideshowSeconds {
+			return fmt.Errorf("must be between %v and %v", minSlideshowSeconds, maxSlideshowSeconds)
+		}
+		return nil
+	}
+	skipCountEntry := widget.NewEntry()
+	skipCountEntry.SetText(strconv.Itoa(a.skipCount))
+	skipCountEntry.Validator = intRangeValidator(1, maxSkipCount)
+
+	adaptiveCheck := widget.NewCheck("Show large images and panoramas longer", nil)
+	adaptiveCheck.SetChecked(prefs.Bool(prefAdaptiveInterval))
+	bounds := a.adaptiveBounds()
+	adaptiveMinEntry := widget.NewEntry()
+	adaptiveMinEntry.SetText(strconv.Itoa(int(bounds.Min / time.Second)))
+	adaptiveMinEntry.Validator = intRangeValidator(minAdaptiveSeconds, maxAdaptiveSeconds)
+	adaptiveMaxEntry := widget.NewEntry()
+	adaptiveMaxEntry.SetText(strconv.Itoa(int(bounds.Max / time.Second)))
+	adaptiveMaxEntry.Validator = func(text string) error {
+		if err := intRangeValidator(minAdaptiveSeconds, maxAdaptiveSeconds)(text); err != nil {
+			return err
+		}
+		if lo, err := strconv.Atoi(adaptiveMinEntry.Text); err == nil {
+			if hi, _ := strconv.Atoi(text); hi < lo {
+				return fmt.Errorf("must not be below the shortest time")
+			}
+		}
+		return nil
+	}
+
+	editorEntry := widget.NewEntry()
+	editorEntry.SetPlaceHolder("e.g. gimp %f")
+	editorEntry.SetText(a.editorCommand())
+	editorEntry.Validator = func(text string) error {
+		_, err := splitCommandLine(text)
+		return err
+	}
+	editorWatchCheck := widget.NewCheck("Reload image when the editor saves it", nil)
+	editorWatchCheck.SetChecked(prefs.Bool(prefEditorWatch))
+	inboxEntry := widget.NewEntry()
+	inboxEntry.SetText(prefs.String(prefInboxFolder))
+	inboxEntry.SetPlaceHolder(a.inboxFolder())
+
+	dirDefaultsCheck := widget.NewCheck("Apply folder default tags ("+dirtags.FileName+") after scanning", nil)
+	dirDefaultsCheck.SetChecked(prefs.Bool(prefDirDefaultsAuto))
+	exifTagsCheck := widget.NewCheckGroup(autotag.Namespaces, nil)
+	exifTagsCheck.Horizontal = true
+	exifTagsCheck.SetSelected(a.exifTagNamespaces())
+	colorTagsCheck := widget.NewCheck("Add color tags ("+imagesig.ColorTagPrefix+"red, "+imagesig.BWTag+", ...) after scanning", nil)
+	colorTagsCheck.SetChecked(prefs.Bool(prefColorTagsAuto))
+	copyTitleCheck := widget.NewCheck("Copy the EXIF title along with tags", nil)
+	copyTitleCheck.SetChecked(prefs.Bool(prefCopyTitle))
+	folderSummaryCheck := widget.NewCheck("Summarize a folder on entering it (images, dates, top tags)", nil)
+	folderSummaryCheck.SetChecked(prefs.Bool(prefFolderSummary))
+
+	startModeSelect := widget.NewSelect(windowStartModes, nil)
+	startModeSelect.SetSelected(a.windowStartMode())
+
+	// The private image settings can only be changed while they are unlocked,
+	// otherwise anyone could replace the PIN.
+	privateTagEntry := widget.NewEntry()
+	privateTagEntry.SetText(a.privateTag())
+	privateTagEntry.SetPlaceHolder("empty disables private images")
+	pinEntry := widget.NewPasswordEntry()
+	pinEntry.SetPlaceHolder("leave empty to keep the current PIN")
+	pinEntry.Validator = func(text string) error {
+		if text != "" && len(text) < minPINLength {
+			return fmt.Errorf("must have at least %d characters", minPINLength)
+		}
+		return nil
+	}
+	pinConfirmEntry := widget.NewPasswordEntry()
+	pinConfirmEntry.Validator = func(text string) error {
+		if text != pinEntry.Text {
+			return fmt.Errorf("does not match the PIN")
+		}
+		return nil
+	}
+	if a.privateLocked() {
+		privateTagEntry.Disable()
+		pinEntry.Disable()
+		pinConfirmEntry.Disable()
+		pinEntry.SetPlaceHolder("unlock private images to change")
+	}
+
+	dialog.ShowForm("Preferences", "Save", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Thumbnail strip size", stripSizeSelect),
+		widget.NewFormItem("Thumbnail strip position", stripPositionSelect),
+		widget.NewFormItem("Keyboard pan step (px)", panStepEntry),
+		widget.NewFormItem("Image background", backgroundSelect),
+		widget.NewFormItem("Custom background color", backgroundColorEntry),
+		widget.NewFormItem("Image scaling", scalingSelect),
+		widget.NewFormItem("", drawTimeCheck),
+		widget.NewFormItem("Image switch", transitionSelect),
+		widget.NewFormItem("Restore zoom on return", zoomMemorySelect),
+		widget.NewFormItem("", zoomMemorySaveCheck),
+		widget.NewFormItem("", loadPreviewCheck),
+		widget.NewFormItem("Largest decoded edge (px)", decodeCapSelect),
+		widget.NewFormItem("Background disk reads (MB/s, 0 = no cap)", ioLimitEntry),
+		widget.NewFormItem("Shuffle seed", shuffleSeedEntry),
+		widget.NewFormItem("", shufflePersistCheck),
+		widget.NewFormItem("At the end of the list", playbackSelect),
+		widget.NewFormItem("Slideshow orientation", orientationSelect),
+		widget.NewFormItem("Slideshow interval (s)", intervalEntry),
+		widget.NewFormItem("Page Up/Down skips", skipCountEntry),
+		widget.NewFormItem("", adaptiveCheck),
+		widget.NewFormItem("Adaptive shortest (s)", adaptiveMinEntry),
+		widget.NewFormItem("Adaptive longest (s)", adaptiveMaxEntry),
+		widget.NewFormItem("", skipCorruptCheck),
+		widget.NewFormItem("", tagCorruptCheck),
+		widget.NewFormItem("", dirDefaultsCheck),
+		widget.NewFormItem("EXIF tags after scan", exifTagsCheck),
+		widget.NewFormItem("", colorTagsCheck),
+		widget.NewFormItem("", copyTitleCheck),
+		widget.NewFormItem("", folderSummaryCheck),
+		widget.NewFormItem("Start window", startModeSelect),
+		widget.NewFormItem("External editor", editorEntry),
+		widget.NewFormItem("", editorWatchCheck),
+		widget.NewFormItem("Paste inbox folder", inboxEntry),
+		widget.NewFormItem("Private tag", privateTagEntry),
+		widget.NewFormItem("Private PIN", pinEntry),
+		widget.NewFormItem("Confirm PIN", pinConfirmEntry),
+	}, func(confirm bool) {
+		if !confirm {
+			return
+		}
+
+		if step, err := strconv.Atoi(panStepEntry.Text); err == nil {
+			prefs.SetInt(prefPanStep, step)
+		}
+
+		prefs.SetBool(prefLoadPreview, loadPreviewCheck.Checked)
+		switch decodeCapSelect.Selected {
+		case decodeCapOff:
+			prefs.SetInt(prefMaxDecodeDimension, 0)
+		case "":
+		default:
+			if n, err := strconv.Atoi(decodeCapSelect.Selected); err == nil {
+				prefs.SetInt(prefMaxDecodeDimension, n)
+			}
+		}
+		if limit, err := strconv.Atoi(ioLimitEntry.Text); err == nil {
+			prefs.SetInt(prefBackgroundIOLimit, limit)
+		}
+		a.setShuffleSeed(shuffleSeedEntry.Text)
+		prefs.SetBool(prefShufflePersist, shufflePersistCheck.Checked)
+		prefs.SetBool(prefSkipCorrupt, skipCorruptCheck.Checked)
+		prefs.SetBool(prefTagCorrupt, tagCorruptCheck.Checked)
+		prefs.SetBool(prefDirDefaultsAuto, dirDefaultsCheck.Checked)
+		prefs.SetString(prefEXIFTagNamespaces, strings.Join(exifTagsCheck.Selected, ","))
+		prefs.SetBool(prefColorTagsAuto, colorTagsCheck.Checked)
+		prefs.SetBool(prefCopyTitle, copyTitleCheck.Checked)
+		prefs.SetBool(prefFolderSummary, folderSummaryCheck.Checked)
+		if startModeSelect.Selected != "" {
+			prefs.SetString(prefWindowStartMode, startModeSelect.Selected)
+		}
+		prefs.SetString(prefEditorCommand, strings.TrimSpace(editorEntry.Text))
+		prefs.SetBool(prefEditorWatch, editorWatchCheck.Checked)
+		prefs.SetString(prefInboxFolder, strings.TrimSpace(inboxEntry.Text))
+		if orientationSelect.Selected != "" {
+			prefs.SetString(prefOrientationMode, orientationSelect.Selected)
+		}
+		if playbackSelect.Selected != "" {
+			a.setPlaybackMode(playbackSelect.Selected)
+		}
+		if lo, err := strconv.Atoi(adaptiveMinEntry.Text); err == nil {
+			prefs.SetInt(prefAdaptiveMinSeconds, lo)
+		}
+		if hi, err := strconv.Atoi(adaptiveMaxEntry.Text); err == nil {
+			prefs.SetInt(prefAdaptiveMaxSeconds, hi)
+		}
+		prefs.SetBool(prefAdaptiveInterval, adaptiveCheck.Checked)
+		// Saved only when changed, so the command-line or config value stays in charge otherwise
+		if seconds, err := strconv.ParseFloat(intervalEntry.Text, 64); err == nil && seconds != a.slideshowManager.Interval().Seconds() {
+			prefs.SetFloat(prefSlideshowInterval, seconds)
+		}
+		if n, err := strconv.Atoi(skipCountEntry.Text); err == nil && n != a.skipCount {
+			prefs.SetInt(prefSkipCount, n)
+		}
+
+		if backgroundSelect.Selected != "" {
+			prefs.SetString(prefBackgroundMode, backgroundSelect.Selected)
+		}
+		prefs.SetString(prefBackgroundColor, backgroundColorEntry.Text)
+		if scalingSelect.Selected != "" {
+			prefs.SetString(prefScalingMode, scalingSelect.Selected)
+		}
+		prefs.SetBool(prefShowDrawTime, drawTimeCheck.Checked)
+		if transitionSelect.Selected != "" {
+			prefs.SetString(prefTransition, transitionSelect.Selected)
+		}
+		if zoomMemorySelect.Selected != "" {
+			prefs.SetString(prefZoomMemory, zoomMemorySelect.Selected)
+		}
+		prefs.SetBool(prefZoomMemoryPersist, zoomMemorySaveCheck.Checked)
+
+		if !privateTagEntry.Disabled() {
+			prefs.SetString(prefPrivateTag, strings.ToLower(strings.TrimSpace(privateTagEntry.Text)))
+			if pinEntry.Text != "" {
+				hash, err := newPINHash(pinEntry.Text)
+				if err != nil {
+					dialog.ShowError(err, a.UI.MainWin)
+				} else {
+					prefs.SetString(prefPrivatePINHash, hash)
+					a.addLogMessage("Private image PIN updated.")
+				}
+			}
+			a.hidePrivateImages() // A first PIN or a new tag starts hiding right away
+		}
+
+		if size, err := strconv.Atoi(stripSizeSelect.Selected); err == nil && size != a.thumbStripSize() {
+			prefs.SetInt(prefThumbStripSize, clampThumbStripSize(size))
+		}
+		if pos := stripPositionSelect.Selected; pos != "" && pos != a.thumbStripPosition() {
+			prefs.SetString(prefThumbStripPosition, pos)
+		}
+	}, a.UI.MainWin)
+}
+
+// intRangeValidator returns an entry validator accepting integers in [lo, hi].
+func intRangeValidator(lo, hi int) func(string) error {
+	return func(text string) error {
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return fmt.Errorf("must be a whole number")
+		}
+		if n < lo || n > hi {
+			return fmt.Errorf("must be between %d and %d", lo, hi)
+		}
+		return nil
+	}
+}
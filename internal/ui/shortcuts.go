@@ -14,30 +14,122 @@ func (a *App) buildKeyboardShortcuts() {
 	a.UI.MainWin.Canvas().AddShortcut(&desktop.CustomShortcut{
 		KeyName:  fyne.KeyQ,
 		Modifier: a.UI.mainModKey,
-	}, func(_ fyne.Shortcut) { a.app.Quit() })
+	}, func(_ fyne.Shortcut) { a.quit() })
+
+	// ctrl+e to open the current image in the external editor
+	a.UI.MainWin.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyE,
+		Modifier: a.UI.mainModKey,
+	}, func(_ fyne.Shortcut) { a.openInExternalEditor() })
+
+	// ctrl+f to search file names, folders and tags
+	a.UI.MainWin.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyF,
+		Modifier: a.UI.mainModKey,
+	}, func(_ fyne.Shortcut) { a.showSearchDialog() })
+
+	// ctrl+g to go to an image by number or file name
+	a.UI.MainWin.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyG,
+		Modifier: a.UI.mainModKey,
+	}, func(_ fyne.Shortcut) { a.showJumpToImageDialog() })
+
+	// ctrl+v to save the clipboard's image into the inbox folder and tag it
+	a.UI.MainWin.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyV,
+		Modifier: a.UI.mainModKey,
+	}, func(_ fyne.Shortcut) { a.pasteImage() })
+
+	// ctrl+k to run any command by name
+	a.UI.MainWin.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyK,
+		Modifier: a.UI.mainModKey,
+	}, func(_ fyne.Shortcut) { a.showCommandPalette() })
+
+	// ctrl+shift+t to add the tags added last to the current image
+	a.UI.MainWin.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyT,
+		Modifier: a.UI.mainModKey | fyne.KeyModifierShift,
+	}, func(_ fyne.Shortcut) { a.reapplyLastTags() })
+
+	// ctrl+shift+c, ctrl+shift+v and ctrl+shift+d to copy and paste tags, or
+	// copy those of the previous image
+	a.UI.MainWin.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyC,
+		Modifier: a.UI.mainModKey | fyne.KeyModifierShift,
+	}, func(_ fyne.Shortcut) { a.copyTags() })
+	a.UI.MainWin.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyV,
+		Modifier: a.UI.mainModKey | fyne.KeyModifierShift,
+	}, func(_ fyne.Shortcut) { a.pasteTags() })
+	a.UI.MainWin.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyD,
+		Modifier: a.UI.mainModKey | fyne.KeyModifierShift,
+	}, func(_ fyne.Shortcut) { a.copyTagsFromPrevious() })
+
+	// ctrl+right and ctrl+left to jump to the next or previous folder
+	a.UI.MainWin.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyRight,
+		Modifier: a.UI.mainModKey,
+	}, func(_ fyne.Shortcut) { a.showFolder(1) })
+	a.UI.MainWin.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyLeft,
+		Modifier: a.UI.mainModKey,
+	}, func(_ fyne.Shortcut) { a.showFolder(-1) })
 
 	a.UI.MainWin.Canvas().SetOnTypedKey(func(key *fyne.KeyEvent) {
 		switch key.Name {
-		// move forward/back within the current folder of images
+		// move forward/back within the current folder of images,
+		// or pan the image while it is zoomed beyond the view
 		case fyne.KeyRight:
+			if a.panZoomedImage(-1, 0) {
+				return
+			}
 			a.direction = 1
 			a.nextImage()
 		case fyne.KeyLeft:
+			if a.panZoomedImage(1, 0) {
+				return
+			}
 			a.ShowPreviousImage()
 		case fyne.KeyQ:
-			a.app.Quit()
+			a.quit()
 		case fyne.KeyP, fyne.KeySpace:
 			a.togglePlay()
-		case fyne.KeyPageUp, fyne.KeyUp:
+		case fyne.KeyUp:
+			if a.panZoomedImage(0, 1) {
+				return
+			}
+			a.skipImages(-a.skipCount)
+		case fyne.KeyDown:
+			if a.panZoomedImage(0, -1) {
+				return
+			}
+			a.skipImages(a.skipCount)
+		case fyne.KeyPageUp:
 			a.skipImages(-a.skipCount) // Use new skipImages method
-		case fyne.KeyPageDown, fyne.KeyDown:
+		case fyne.KeyPageDown:
 			a.skipImages(a.skipCount) // Use new skipImages method
 		case fyne.KeyHome:
 			a.firstImage()
 		case fyne.KeyEnd:
 			a.lastImage()
+		case fyne.KeyF2:
+			a.showRenameDialog()
 		case fyne.KeyDelete:
 			a.deleteFileCheck()
+		case fyne.KeyT:
+			a.toggleThumbStrip()
+		case fyne.KeyX:
+			a.toggleStackExpanded()
+		case fyne.KeyE:
+			a.toggleAutoEnhance()
+		case fyne.KeyM:
+			a.toggleStackMark()
+		case fyne.KeyA:
+			a.toggleAnnotating()
+		case fyne.KeyL:
+			a.toggleLoupe()
 		// close dialogs with esc key
 		case fyne.KeyEscape:
 			if len(a.UI.MainWin.Canvas().Overlays().List()) > 0 {
@@ -54,6 +146,14 @@ func (a *App) buildKeyboardShortcuts() {
 			if a.zoomPanArea != nil && a.UI.contentStack.Objects[imageViewIndex].Visible() {
 				a.zoomPanArea.Scrolled(&fyne.ScrollEvent{Scrolled: fyne.Delta{DY: -1}}) // Negative DY for zoom out
 			}
+		case fyne.KeyF: // Fit to view
+			if a.zoomPanArea != nil && a.UI.contentStack.Objects[imageViewIndex].Visible() {
+				a.handleZoomToFitBtn()
+			}
+		case fyne.Key1: // Toggle 100% / fit
+			if a.zoomPanArea != nil && a.UI.contentStack.Objects[imageViewIndex].Visible() {
+				a.toggleFullSizeFit()
+			}
 		case fyne.Key0, fyne.KeyInsert: // Reset zoom/pan
 			// Resetting zoom/pan might also warrant a pause, depending on desired behavior.
 			// If so, uncomment the line below.
@@ -67,6 +167,18 @@ func (a *App) buildKeyboardShortcuts() {
 	})
 }
 
+// panZoomedImage pans the image by one pan step in the given direction if it is
+// zoomed beyond the view. Returns false if the key should keep its navigation meaning.
+func (a *App) panZoomedImage(dirX, dirY float32) bool {
+	if a.zoomPanArea == nil || !a.UI.contentStack.Objects[imageViewIndex].Visible() || !a.zoomPanArea.IsOverflowing() {
+		return false
+	}
+	a.slideshowManager.Pause(true) // Panning means the user is inspecting this image
+	step := float32(a.panStep())
+	a.zoomPanArea.Pan(dirX*step, dirY*step)
+	return true
+}
+
 type shortcutDetail struct {
 	Description string
 	Shortcut    string
@@ -77,6 +189,8 @@ func (a *App) showShortcuts() {
 		{Description: "Quit Application", Shortcut: "Ctrl+Q"},
 		{Description: "Next Image", Shortcut: "Arrow Right"},
 		{Description: "Previous Image", Shortcut: "Arrow Left"},
+		{Description: "Next Folder", Shortcut: "Ctrl+Arrow Right"},
+		{Description: "Previous Folder", Shortcut: "Ctrl+Arrow Left"},
 		{Description: "Skip Images Back (Page Up)", Shortcut: "Page Up"},
 		{Description: "Skip Images Forward (Page Down)", Shortcut: "Page Down"},
 		{Description: "Skip Images Back (Arrow Up)", Shortcut: "Arrow Up"},
@@ -84,11 +198,31 @@ func (a *App) showShortcuts() {
 		{Description: "First Image", Shortcut: "Home"},
 		{Description: "Last Image", Shortcut: "End"},
 		{Description: "Toggle Play/Pause Slideshow", Shortcut: "P or Space"},
+		{Description: "Search Images", Shortcut: "Ctrl+F"},
+		{Description: "Command Palette", Shortcut: "Ctrl+K"},
+		{Description: "Go to Image", Shortcut: "Ctrl+G"},
+		{Description: "Expand/Collapse Stack", Shortcut: "X"},
+		{Description: "Auto-Enhance Preview On/Off", Shortcut: "E"},
+		{Description: "Mark/Unmark for Stack", Shortcut: "M"},
+		{Description: "Annotate On/Off", Shortcut: "A"},
+		{Description: "Loupe On/Off", Shortcut: "L"},
+		{Description: "Rename Current Image", Shortcut: "F2"},
+		{Description: "Open in External Editor", Shortcut: "Ctrl+E"},
+		{Description: "Paste Image", Shortcut: "Ctrl+V"},
+		{Description: "Reapply Last Tags", Shortcut: "Ctrl+Shift+T"},
+		{Description: "Copy Tags", Shortcut: "Ctrl+Shift+C"},
+		{Description: "Paste Tags", Shortcut: "Ctrl+Shift+V"},
+		{Description: "Copy Tags from Previous Image", Shortcut: "Ctrl+Shift+D"},
 		{Description: "Delete Current Image", Shortcut: "Delete"},
+		{Description: "Collapse/Expand Thumbnail Strip", Shortcut: "T"},
 		{Description: "Close Dialog/Overlay", Shortcut: "Esc"},
 		{Description: "Zoom In Image", Shortcut: "+"},
 		{Description: "Zoom Out Image", Shortcut: "-"},
 		{Description: "Reset Image Zoom/Pan", Shortcut: "0"},
+		{Description: "Pan Zoomed Image", Shortcut: "Arrow Keys (while zoomed)"},
+		{Description: "Fine Zoom", Shortcut: "Ctrl+Scroll"},
+		{Description: "Fit Image to View", Shortcut: "F"},
+		{Description: "Toggle 100% / Fit", Shortcut: "1"},
 	}
 
 	win := a.app.NewWindow("Keyboard Shortcuts")
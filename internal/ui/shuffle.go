@@ -0,0 +1,89 @@
+// Package ui Shuffle: random mode plays a seeded permutation of the images, whose
+// seed is shown in the status bar and can be fixed, reshuffled or kept across sessions.
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"fyslide/internal/slideshow"
+	"strconv"
+	"strings"
+)
+
+// initShuffle sets up the shuffled order: the sequence of the last session
+// if it is kept, otherwise that of the configured seed or of a random one.
+func (a *App) initShuffle() {
+	a.shuffle = slideshow.NewPermutationManager(a.shuffleSeedPref())
+	if !a.prefs().Bool(prefShufflePersist) {
+		return
+	}
+	if saved := a.prefs().String(prefShuffleState); saved != "" {
+		var state slideshow.PermutationState
+		if err := json.Unmarshal([]byte(saved), &state); err != nil {
+			a.addLogMessage("Ignoring unreadable saved shuffle order: " + err.Error())
+			return
+		}
+		a.shuffle.Restore(state)
+	}
+}
+
+// shuffleSeedPref returns the configured shuffle seed, or a random one if
+// none is set.
+func (a *App) shuffleSeedPref() int64 {
+	if seed, err := strconv.ParseInt(a.prefs().String(prefShuffleSeed), 10, 64); err == nil {
+		return seed
+	}
+	return slideshow.RandomSeed()
+}
+
+// saveShuffle persists the position in the shuffled order for the next
+// session, or clears a saved one when keeping it is off.
+func (a *App) saveShuffle() {
+	if !a.prefs().Bool(prefShufflePersist) {
+		a.prefs().RemoveValue(prefShuffleState)
+		return
+	}
+	if data, err := json.Marshal(a.shuffle.State()); err == nil {
+		a.prefs().SetString(prefShuffleState, string(data))
+	}
+}
+
+// setShuffleSeed applies the seed typed in Preferences: a number fixes the
+// seed and restarts the sequence if it changed, an empty text goes back to a
+// random seed per session.
+func (a *App) setShuffleSeed(text string) {
+	text = strings.TrimSpace(text)
+	if text == a.prefs().String(prefShuffleSeed) {
+		return
+	}
+	if text == "" {
+		a.prefs().RemoveValue(prefShuffleSeed)
+		return
+	}
+	seed, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return
+	}
+	a.prefs().SetString(prefShuffleSeed, text)
+	a.shuffle.Reseed(seed)
+	a.addLogMessage(fmt.Sprintf("Shuffle seed set to %d", seed))
+	a.updateStatusBar()
+}
+
+// reshuffle starts a new shuffled order from a random seed.
+func (a *App) reshuffle() {
+	seed := a.shuffle.Reshuffle()
+	a.addLogMessage(fmt.Sprintf("Reshuffled, seed %d", seed))
+	a.updateStatusBar()
+}
+
+// shuffleSeedValidator accepts an empty text or a whole number.
+func shuffleSeedValidator(text string) error {
+	if text = strings.TrimSpace(text); text == "" {
+		return nil
+	}
+	if _, err := strconv.ParseInt(text, 10, 64); err != nil {
+		return fmt.Errorf("must be a whole number")
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Package ui Sorting the browsed list by date taken, name, path, size or
+// modification time.
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"fyslide/internal/scan"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// sortKeys are the orders the sort command knows.
+var sortKeys = []string{"date", "name", "path", "size", "modified"}
+
+// parseSortArgs reads the arguments of the sort command: a key of sortKeys
+// and optionally asc or desc, ascending by default.
+func parseSortArgs(args string) (key string, desc bool, err error) {
+	fields := strings.Fields(strings.ToLower(args))
+	if len(fields) == 0 || len(fields) > 2 || !slices.Contains(sortKeys, fields[0]) {
+		return "", false, fmt.Errorf("sort by one of %s, optionally followed by asc or desc", strings.Join(sortKeys, ", "))
+	}
+	if len(fields) == 2 {
+		switch fields[1] {
+		case "asc":
+		case "desc":
+			desc = true
+		default:
+			return "", false, fmt.Errorf("unknown sort direction %q, want asc or desc", fields[1])
+		}
+	}
+	return fields[0], desc, nil
+}
+
+// sortCurrentList orders the browsed list by key, one of sortKeys. Dates
+// taken are read in the background first.
+func (a *App) sortCurrentList(key string, desc bool) {
+	var compare func(x, y scan.FileItem) int
+	switch key {
+	case "date":
+		list := slices.Clone(a.getCurrentList())
+		a.addLogMessage(fmt.Sprintf("Reading the dates taken of %d images...", len(list)))
+		a.goBackground(func() {
+			dates := datesTaken(a.ctx, list)
+			if a.ctx.Err() != nil {
+				return // The window closed while reading
+			}
+			fyne.Do(func() { a.sortByDateTaken(dates, desc) })
+		})
+		return
+	case "name":
+		compare = func(x, y scan.FileItem) int {
+			return strings.Compare(strings.ToLower(filepath.Base(x.Path)), strings.ToLower(filepath.Base(y.Path)))
+		}
+	case "path":
+		compare = func(x, y scan.FileItem) int { return strings.Compare(x.Path, y.Path) }
+	case "size":
+		compare = func(x, y scan.FileItem) int { return cmp.Compare(fileSize(x), fileSize(y)) }
+	case "modified":
+		compare = func(x, y scan.FileItem) int { return fileModTime(x).Compare(fileModTime(y)) }
+	default:
+		panic("unknown sort key " + key)
+	}
+	if desc {
+		ascending := compare
+		compare = func(x, y scan.FileItem) int { return ascending(y, x) }
+	}
+	a.sortBrowsedList(compare, key)
+}
+
+// sortBrowsedList orders the browsed list by compare and stays on the
+// current image. what names the order for the log.
+func (a *App) sortBrowsedList(compare func(x, y scan.FileItem) int, what string) {
+	list := a.images
+	if a.isFiltered {
+		list = a.filteredImages
+	}
+	slices.SortStableFunc(list, compare)
+	a.addLogMessage(fmt.Sprintf("Sorted the current list by %s.", what))
+	a.reshowAfterListChange()
+}
+
+func fileSize(item scan.FileItem) int64 {
+	if item.Info == nil {
+		return 0
+	}
+	return item.Info.Size()
+}
+
+func fileModTime(item scan.FileItem) time.Time {
+	if item.Info == nil {
+		return time.Time{}
+	}
+	return item.Info.ModTime()
+}
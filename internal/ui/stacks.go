@@ -0,0 +1,309 @@
+// Package ui Stacks of images, shown as one item until expanded.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/autotag"
+	"fyslide/internal/imagesig"
+	"fyslide/internal/stacks"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// loadStacks reads the stack tags from the database. Runs on the scanning goroutine.
+func (a *App) loadStacks() {
+	all, err := a.tagDB.GetAllTags(a.ctx)
+	if err != nil {
+		fyne.Do(func() { a.addLogMessage(fmt.Sprintf("Failed to load stacks: %v", err)) })
+		return
+	}
+	members := make(map[string][]string)
+	for _, tag := range all {
+		id, ok := stacks.ID(tag.Name)
+		if !ok {
+			continue
+		}
+		paths, err := a.tagDB.GetImages(a.ctx, tag.Name)
+		if err != nil {
+			continue
+		}
+		members[id] = paths
+	}
+	ix := stacks.NewIndex(members)
+	fyne.Do(func() {
+		a.stacks = ix
+		a.refocusCurrentImage()
+	})
+}
+
+// applyStackOps keeps the stack index in step with stack tags being added or
+// removed and reports whether any were.
+func (a *App) applyStackOps(ops []tagOp) bool {
+	changed := false
+	for _, op := range ops {
+		id, ok := stacks.ID(op.tag)
+		if !ok {
+			continue
+		}
+		if a.stacks == nil {
+			a.stacks = stacks.NewIndex(nil)
+		}
+		if op.add {
+			a.stacks.Add(op.path, id)
+		} else if a.stacks.StackOf(op.path) == id {
+			a.stacks.Remove(op.path)
+		}
+		changed = true
+	}
+	return changed
+}
+
+// refocusCurrentImage finds the current image again after stacks collapsed or
+// expanded. An image hidden in a collapsed stack gives way to the stack's cover.
+func (a *App) refocusCurrentImage() {
+	list := a.getCurrentList()
+	if a.img.Path == "" || len(list) == 0 {
+		return
+	}
+	for i, item := range list {
+		if item.Path == a.img.Path {
+			a.index = i
+			a.updateThumbStrip()
+			a.updateStatusBar()
+			return
+		}
+	}
+	members := a.stacks.Members(a.stacks.StackOf(a.img.Path))
+	for i, item := range list {
+		if slices.Contains(members, item.Path) {
+			a.showIndex(i)
+			return
+		}
+	}
+	a.showIndex(min(a.index, len(list)-1))
+}
+
+// currentStack returns the id and images of the current image's stack, or ""
+// and nil when it isn't stacked with other images.
+func (a *App) currentStack() (string, []string) {
+	id := a.stacks.StackOf(a.img.Path)
+	members := a.stacks.Members(id)
+	if len(members) < 2 {
+		return "", nil
+	}
+	return id, members
+}
+
+// stackCollapsed reports whether the current image stands for its whole stack.
+func (a *App) stackCollapsed() bool {
+	id, _ := a.currentStack()
+	return id != "" && !a.expandedStacks[id]
+}
+
+// stackStatus describes the current image's stack for the status bar.
+func (a *App) stackStatus() string {
+	var parts []string
+	if id, members := a.currentStack(); id != "" {
+		if a.expandedStacks[id] {
+			parts = append(parts, fmt.Sprintf("Stack of %d (X collapses)", len(members)))
+		} else {
+			parts = append(parts, fmt.Sprintf("Stack of %d (X expands)", len(members)))
+		}
+	}
+	if len(a.stackMarks) > 0 {
+		parts = append(parts, fmt.Sprintf("%d marked for stacking", len(a.stackMarks)))
+	}
+	return strings.Join(parts, " | ")
+}
+
+// toggleStackExpanded shows the current stack image by image, or folds it back.
+func (a *App) toggleStackExpanded() {
+	id, members := a.currentStack()
+	if id == "" {
+		a.addLogMessage("The current image is not part of a stack.")
+		return
+	}
+	if a.expandedStacks[id] {
+		delete(a.expandedStacks, id)
+		a.addLogMessage(fmt.Sprintf("Collapsed stack of %d images.", len(members)))
+	} else {
+		a.expandedStacks[id] = true
+		a.addLogMessage(fmt.Sprintf("Expanded stack of %d images.", len(members)))
+	}
+	a.refocusCurrentImage()
+}
+
+// toggleStackMark marks the current image for Stack Marked Images, or unmarks it.
+func (a *App) toggleStackMark() {
+	if a.img.Path == "" {
+		return
+	}
+	if i := slices.Index(a.stackMarks, a.img.Path); i >= 0 {
+		a.stackMarks = slices.Delete(a.stackMarks, i, i+1)
+	} else {
+		a.stackMarks = append(a.stackMarks, a.img.Path)
+	}
+	a.addLogMessage(fmt.Sprintf("%d image(s) marked for stacking.", len(a.stackMarks)))
+	a.updateStatusBar()
+}
+
+// stackOps returns the tag operations moving paths into stack id.
+func (a *App) stackOps(paths []string, id string) []tagOp {
+	var ops []tagOp
+	for _, path := range paths {
+		old := a.stacks.StackOf(path)
+		if old == id {
+			continue
+		}
+		if old != "" {
+			ops = append(ops, tagOp{path: path, tag: stacks.Tag(old), add: false})
+		}
+		ops = append(ops, tagOp{path: path, tag: stacks.Tag(id), add: true})
+	}
+	return ops
+}
+
+// stackMarked groups the marked images into one stack, joining the stack of
+// the first marked image that already is in one.
+func (a *App) stackMarked() {
+	if a.refuseInReadOnly("Stack Marked Images") {
+		return
+	}
+	if len(a.stackMarks) < 2 {
+		dialog.ShowInformation("Stack Marked Images", "Mark at least two images first (M marks the current image).", a.UI.MainWin)
+		return
+	}
+	id := ""
+	for _, path := range a.stackMarks {
+		if id = a.stacks.StackOf(path); id != "" {
+			break
+		}
+	}
+	if id == "" {
+		id = stacks.NewID()
+	}
+	ops := a.stackOps(a.stackMarks, id)
+	count := len(a.stackMarks)
+	a.stackMarks = nil
+	a.submitTagJob(fmt.Sprintf("Stacking %d images", count), ops, nil)
+}
+
+// unstack dissolves the current image's stack.
+func (a *App) unstack() {
+	if a.refuseInReadOnly("Unstack") {
+		return
+	}
+	id, members := a.currentStack()
+	if id == "" {
+		a.addLogMessage("The current image is not part of a stack.")
+		return
+	}
+	var ops []tagOp
+	for _, path := range members {
+		ops = append(ops, tagOp{path: path, tag: stacks.Tag(id), add: false})
+	}
+	delete(a.expandedStacks, id)
+	a.submitTagJob(fmt.Sprintf("Unstacking %d images", len(members)), ops, nil)
+}
+
+// showAutoStackDialog asks how to detect bursts and stacks them across the library.
+func (a *App) showAutoStackDialog() {
+	if a.refuseInReadOnly("Auto-Stack Bursts") {
+		return
+	}
+	gapEntry := widget.NewEntry()
+	gapEntry.SetText(strconv.Itoa(int(stacks.DefaultAutoOptions.MaxGap / time.Second)))
+	gapEntry.Validator = intRangeValidator(1, 3600)
+	similarCheck := widget.NewCheck("Only stack images that look alike", nil)
+	similarCheck.SetChecked(true)
+
+	dialog.ShowForm("Auto-Stack Bursts", "Stack", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Max seconds between shots", gapEntry),
+		widget.NewFormItem("", similarCheck),
+	}, func(confirm bool) {
+		if !confirm {
+			return
+		}
+		opts := stacks.DefaultAutoOptions
+		if gap, err := strconv.Atoi(gapEntry.Text); err == nil {
+			opts.MaxGap = time.Duration(gap) * time.Second
+		}
+		a.autoStack(opts, similarCheck.Checked)
+	}, a.UI.MainWin)
+}
+
+// autoStack stacks the loaded images that aren't stacked yet by capture time
+// and, when similar is set, by their perceptual hashes.
+func (a *App) autoStack(opts stacks.AutoOptions, similar bool) {
+	var items []string
+	for _, item := range a.images {
+		if a.stacks.StackOf(item.Path) == "" {
+			items = append(items, item.Path)
+		}
+	}
+	modTimes := make(map[string]time.Time, len(a.images))
+	for _, item := range a.images {
+		if item.Info != nil {
+			modTimes[item.Path] = item.Info.ModTime()
+		}
+	}
+
+	progress := dialog.NewCustomWithoutButtons("Finding Bursts", widget.NewProgressBarInfinite(), a.UI.MainWin)
+	progress.Show()
+	a.goBackground(func() {
+		var cache *imagesig.Cache
+		if similar {
+			if cache = a.openSignatureCache(); cache != nil {
+				defer cache.Close()
+			}
+		}
+		var candidates []stacks.Candidate
+		for _, path := range items {
+			if a.ctx.Err() != nil {
+				return // The window closed; no bursts are stacked
+			}
+			c := stacks.Candidate{Path: path, Taken: modTimes[path]}
+			if info, err := autotag.ReadEXIF(path); err == nil && !info.Taken.IsZero() {
+				c.Taken = info.Taken
+			}
+			if similar {
+				if sig, err := cache.Get(path); err == nil {
+					c.Sig = &sig
+				}
+			}
+			candidates = append(candidates, c)
+		}
+		groups := stacks.AutoGroup(candidates, opts)
+		fyne.Do(func() {
+			progress.Hide()
+			if len(groups) == 0 {
+				dialog.ShowInformation("Auto-Stack Bursts", "No bursts found among the unstacked images.", a.UI.MainWin)
+				return
+			}
+			var ops []tagOp
+			for _, group := range groups {
+				ops = append(ops, a.stackOps(group, stacks.NewID())...)
+			}
+			a.submitTagJob(fmt.Sprintf("Stacking %d bursts (%d images)", len(groups), countImages(ops)), ops, nil)
+		})
+	})
+}
+
+// stackTargetCheck returns a check offering to apply an operation to the
+// whole stack of the current image, ticked while the stack is collapsed, or
+// nil when the image isn't stacked.
+func (a *App) stackTargetCheck(format string) *widget.Check {
+	_, members := a.currentStack()
+	if members == nil {
+		return nil
+	}
+	check := widget.NewCheck(fmt.Sprintf(format, len(members)), nil)
+	check.SetChecked(a.stackCollapsed())
+	return check
+}
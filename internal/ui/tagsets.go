@@ -0,0 +1,174 @@
+// Package ui Tag sets: named groups of tags, kept in the tag database, applied
+// in one action from the Edit menu, the toolbar or the Add Tag dialog.
+package ui
+
+import (
+	"fmt"
+	"fyslide/internal/tagging"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	tagSetsDialogWidth  = 520
+	tagSetsDialogHeight = 420
+)
+
+// buildTagSetsMenuItem creates the Edit > Tag Sets submenu.
+func (a *App) buildTagSetsMenuItem() *fyne.MenuItem {
+	item := fyne.NewMenuItem("Tag Sets", nil)
+	a.UI.tagSetsMenu = fyne.NewMenu("Tag Sets")
+	item.ChildMenu = a.UI.tagSetsMenu
+	a.refreshTagSetsMenu()
+	return item
+}
+
+// refreshTagSetsMenu lists the saved tag sets under the fixed entries.
+func (a *App) refreshTagSetsMenu() {
+	if a.UI.tagSetsMenu == nil {
+		return
+	}
+	a.UI.tagSetsMenu.Items = append([]*fyne.MenuItem{
+		fyne.NewMenuItem("Manage Tag Sets...", a.showTagSetsDialog),
+		fyne.NewMenuItemSeparator(),
+	}, a.tagSetMenuItems()...)
+	if a.UI.MainWin != nil && a.UI.MainWin.MainMenu() != nil {
+		a.UI.MainWin.MainMenu().Refresh()
+	}
+}
+
+// tagSetMenuItems returns an item applying each tag set to the current image.
+func (a *App) tagSetMenuItems() []*fyne.MenuItem {
+	sets, err := a.tagDB.TagSets(a.ctx)
+	if err != nil {
+		a.addLogMessage(fmt.Sprintf("Failed to load tag sets: %v", err))
+	}
+	var items []*fyne.MenuItem
+	for _, set := range sets {
+		items = append(items, a.mutatingMenuItem(set.Name, func() { a.applyTagSet(set) }))
+	}
+	if len(sets) == 0 {
+		none := fyne.NewMenuItem("(no tag sets)", nil)
+		none.Disabled = true
+		items = append(items, none)
+	}
+	return items
+}
+
+// showTagSetsPopUp shows the tag sets below the toolbar, for its tag set button.
+func (a *App) showTagSetsPopUp() {
+	menu := fyne.NewMenu("", append(a.tagSetMenuItems(), fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Manage Tag Sets...", a.showTagSetsDialog))...)
+	widget.ShowPopUpMenuAtRelativePosition(menu, a.UI.MainWin.Canvas(),
+		fyne.NewPos(0, a.UI.toolBar.Size().Height), a.UI.toolBar)
+}
+
+// applyTagSet adds the tags of set to the current image.
+func (a *App) applyTagSet(set tagging.TagSet) {
+	if a.refuseInReadOnly("Apply Tag Set") {
+		return
+	}
+	if a.img.Path == "" {
+		dialog.ShowInformation("Apply Tag Set", "No image loaded to tag.", a.UI.MainWin)
+		return
+	}
+	if err := a.addTagsToCurrentImage(fmt.Sprintf("Applying tag set '%s'", set.Name), set.Tags); err != nil {
+		dialog.ShowError(err, a.UI.MainWin)
+	}
+}
+
+// tagSetChips returns a chip per tag set that adds its tags to entry, for
+// the Add Tag dialog, or nil when there are no tag sets.
+func (a *App) tagSetChips(entry *widget.Entry) fyne.CanvasObject {
+	sets, err := a.tagDB.TagSets(a.ctx)
+	if err != nil {
+		a.addLogMessage(fmt.Sprintf("Failed to load tag sets: %v", err))
+		return nil
+	}
+	var chips []fyne.CanvasObject
+	for _, set := range sets {
+		chip := widget.NewButton(set.Name, func() {
+			for _, tag := range set.Tags {
+				appendTagToEntry(entry, tag)
+			}
+		})
+		chip.Importance = widget.LowImportance
+		chips = append(chips, chip)
+	}
+	if len(chips) == 0 {
+		return nil
+	}
+	return container.NewGridWithColumns(tagSuggestionColumns, chips...)
+}
+
+// showTagSetsDialog lists the tag sets to edit, save as new or delete. A new
+// set starts with the current image's tags.
+func (a *App) showTagSetsDialog() {
+	var sets []tagging.TagSet
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("e.g. beach-trip")
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetPlaceHolder("Tags separated by commas, e.g. beach, family, 2024")
+	if a.img.Path != "" {
+		if tags, err := a.tagDB.GetTags(a.ctx, a.img.Path); err == nil {
+			tagsEntry.SetText(strings.Join(a.withPendingTags(a.img.Path, tags), ", "))
+		}
+	}
+
+	list := widget.NewList(
+		func() int { return len(sets) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(fmt.Sprintf("%s: %s", sets[id].Name, strings.Join(sets[id].Tags, ", ")))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		nameEntry.SetText(sets[id].Name)
+		tagsEntry.SetText(strings.Join(sets[id].Tags, ", "))
+	}
+	reload := func() {
+		var err error
+		if sets, err = a.tagDB.TagSets(a.ctx); err != nil {
+			dialog.ShowError(err, a.UI.MainWin)
+		}
+		list.UnselectAll()
+		list.Refresh()
+		a.refreshTagSetsMenu()
+	}
+
+	saveButton := widget.NewButton("Save", func() {
+		set := tagging.TagSet{Name: nameEntry.Text, Tags: strings.Split(tagsEntry.Text, ","), Created: time.Now()}
+		if err := a.tagDB.SaveTagSet(a.ctx, set); err != nil {
+			dialog.ShowError(err, a.UI.MainWin)
+			return
+		}
+		a.addLogMessage(fmt.Sprintf("Saved tag set '%s'.", strings.TrimSpace(set.Name)))
+		reload()
+	})
+	deleteButton := widget.NewButton("Delete", func() {
+		name := strings.TrimSpace(nameEntry.Text)
+		if err := a.tagDB.DeleteTagSet(a.ctx, name); err != nil {
+			dialog.ShowError(err, a.UI.MainWin)
+			return
+		}
+		a.addLogMessage(fmt.Sprintf("Deleted tag set '%s'.", name))
+		nameEntry.SetText("")
+		reload()
+	})
+	if a.readOnly() {
+		saveButton.Disable()
+		deleteButton.Disable()
+	}
+
+	form := widget.NewForm(widget.NewFormItem("Name", nameEntry), widget.NewFormItem("Tags", tagsEntry))
+	content := container.NewBorder(nil, container.NewVBox(form, container.NewHBox(saveButton, deleteButton)), nil, nil, list)
+	d := dialog.NewCustom("Tag Sets", "Close", content, a.UI.MainWin)
+	d.Resize(fyne.NewSize(tagSetsDialogWidth, tagSetsDialogHeight))
+	reload()
+	d.Show()
+}
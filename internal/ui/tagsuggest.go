@@ -0,0 +1,86 @@
+// Package ui Tag suggestions: tags of the other images in the current image's
+// folder and the tags added last, offered as one-click chips in the Add Tag
+// dialog.
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// maxTagSuggestions is how many of the folder's most used tags are offered.
+	maxTagSuggestions = 12
+	// tagSuggestionColumns is the number of chips per row.
+	tagSuggestionColumns = 4
+)
+
+// siblingTagChips returns a chip per tag used on the other images in path's
+// folder that path does not carry yet, most used first. Tapping a chip adds
+// its tag to entry. Returns nil when there is nothing to suggest.
+func (a *App) siblingTagChips(path string, current []string, entry *widget.Entry) fyne.CanvasObject {
+	siblingTags, err := a.service.SiblingTags(a.ctx, path)
+	if err != nil {
+		a.addLogMessage(fmt.Sprintf("Could not suggest tags: %v", err))
+		return nil
+	}
+	var tags, labels []string
+	for _, tc := range siblingTags {
+		tags = append(tags, tc.Tag)
+		labels = append(labels, fmt.Sprintf("%s (%d)", tc.Tag, tc.Images))
+	}
+	return tagChips(tags, labels, current, entry)
+}
+
+// recentTagChips returns a chip per tag added recently that path does not
+// carry yet, most recent first, or nil when there are none.
+func (a *App) recentTagChips(current []string, entry *widget.Entry) fyne.CanvasObject {
+	tags := a.prefs().StringList(prefRecentTags)
+	return tagChips(tags, tags, current, entry)
+}
+
+// tagChips returns a grid of up to maxTagSuggestions chips labelled by
+// labels for the tags not in current. Tapping a chip adds its tag to entry.
+// Returns nil when every tag is in current.
+func tagChips(tags, labels, current []string, entry *widget.Entry) fyne.CanvasObject {
+	var chips []fyne.CanvasObject
+	for i, tag := range tags {
+		if len(chips) == maxTagSuggestions {
+			break
+		}
+		if slices.Contains(current, tag) {
+			continue
+		}
+		var chip *widget.Button
+		chip = widget.NewButton(labels[i], func() {
+			appendTagToEntry(entry, tag)
+			chip.Disable()
+		})
+		chip.Importance = widget.LowImportance
+		chips = append(chips, chip)
+	}
+	if len(chips) == 0 {
+		return nil
+	}
+	return container.NewGridWithColumns(tagSuggestionColumns, chips...)
+}
+
+// appendTagToEntry adds tag to the comma-separated tags of entry, unless it
+// is already there.
+func appendTagToEntry(entry *widget.Entry, tag string) {
+	var tags []string
+	for _, t := range strings.Split(entry.Text, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	if slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+		return
+	}
+	entry.SetText(strings.Join(append(tags, tag), ", "))
+}
@@ -0,0 +1,237 @@
+// Package ui Background worker for tag mutations with optimistic UI updates.
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// tagJobQueueSize bounds the number of submitted but not yet started tag jobs.
+const tagJobQueueSize = 256
+
+// tagOp is a single tag mutation on one image.
+type tagOp struct {
+	path string
+	tag  string
+	add  bool // true adds the tag, false removes it
+}
+
+// tagJob is a batch of tag mutations applied together by the worker.
+type tagJob struct {
+	id     int
+	desc   string
+	ops    []tagOp
+	onDone func(err error) // Invoked on the Fyne goroutine once the job finished; may be nil
+}
+
+// tagJobResult reports the outcome of a tagJob.
+type tagJobResult struct {
+	succeeded int
+	failed    int
+	firstErr  error
+}
+
+// pendingTag is an optimistic tag change not yet confirmed by the database.
+type pendingTag struct {
+	add   bool
+	jobID int // Job that made the change; a later job on the same tag supersedes it
+}
+
+// tagWorker applies tag mutations on a background goroutine so slow disks don't
+// freeze the UI. Its bookkeeping fields are only touched on the Fyne goroutine.
+type tagWorker struct {
+	jobs     chan tagJob
+	done     chan struct{}                    // Closed once the jobs were all run after stopTagWorker
+	pending  map[string]map[string]pendingTag // image path -> tag -> pending change
+	inFlight int
+	nextID   int
+}
+
+// startTagWorker creates the worker and its goroutine.
+func (a *App) startTagWorker() {
+	a.tagWorker = &tagWorker{
+		jobs:    make(chan tagJob, tagJobQueueSize),
+		done:    make(chan struct{}),
+		pending: make(map[string]map[string]pendingTag),
+	}
+	w := a.tagWorker
+	go func() {
+		defer close(w.done)
+		for job := range w.jobs {
+			res := a.runTagJob(job)
+			fyne.Do(func() { a.finishTagJob(job, res) })
+		}
+	}()
+}
+
+// stopTagWorker lets the worker run the jobs queued, then end; the channel
+// returned closes once it did. Must be called on the Fyne goroutine, before
+// a.ctx is cancelled, which makes later submissions fail.
+func (a *App) stopTagWorker() <-chan struct{} {
+	close(a.tagWorker.jobs)
+	return a.tagWorker.done
+}
+
+// runTagJob applies a job's mutations to the tag database. Runs on the worker goroutine.
+func (a *App) runTagJob(job tagJob) tagJobResult {
+	var res tagJobResult
+	for _, op := range job.ops {
+		var err error
+		if op.add {
+			err = a.service.AddTag(a.ctx, op.path, op.tag)
+		} else {
+			err = a.service.RemoveTag(a.ctx, op.path, op.tag)
+		}
+		if err != nil {
+			res.failed++
+			if res.firstErr == nil {
+				res.firstErr = fmt.Errorf("tag '%s' on %s: %w", op.tag, filepath.Base(op.path), err)
+			}
+			continue
+		}
+		res.succeeded++
+		a.publishTagChange(op)
+	}
+	return res
+}
+
+// submitTagJob shows ops immediately as pending and queues them for the worker.
+// A full queue, as when the worker is stuck on a slow disk, refuses the job
+// rather than freeze the UI waiting for room. Must be called on the Fyne
+// goroutine.
+func (a *App) submitTagJob(desc string, ops []tagOp, onDone func(err error)) {
+	if len(ops) == 0 || a.ctx.Err() != nil {
+		if onDone != nil {
+			onDone(a.ctx.Err()) // The window closed and the worker stopped
+		}
+		return
+	}
+	w := a.tagWorker
+	if len(w.jobs) == cap(w.jobs) {
+		err := fmt.Errorf("%s: tag queue full, %d jobs are still waiting to be saved", desc, len(w.jobs))
+		a.addLogMessage(err.Error())
+		if onDone != nil {
+			onDone(err)
+		} else {
+			dialog.ShowError(err, a.UI.MainWin)
+		}
+		return
+	}
+	w.nextID++
+	job := tagJob{id: w.nextID, desc: desc, ops: ops, onDone: onDone}
+
+	for _, op := range ops {
+		if w.pending[op.path] == nil {
+			w.pending[op.path] = make(map[string]pendingTag)
+		}
+		w.pending[op.path][op.tag] = pendingTag{add: op.add, jobID: job.id}
+	}
+	w.inFlight++
+	a.addLogMessage(desc + "...")
+	a.refreshAfterTagChange(ops)
+
+	w.jobs <- job // Only the Fyne goroutine sends, so the room found above is still there
+}
+
+// finishTagJob drops the job's optimistic changes and refreshes the UI. Changes that
+// succeeded are now in the database; failed ones disappear, which rolls them back.
+func (a *App) finishTagJob(job tagJob, res tagJobResult) {
+	if a.ctx.Err() != nil {
+		return // Run during shutdown, with nothing left to show it
+	}
+	w := a.tagWorker
+	for _, op := range job.ops {
+		if p, ok := w.pending[op.path][op.tag]; ok && p.jobID == job.id {
+			delete(w.pending[op.path], op.tag)
+			if len(w.pending[op.path]) == 0 {
+				delete(w.pending, op.path)
+			}
+		}
+	}
+	w.inFlight--
+
+	if res.firstErr != nil {
+		a.addLogMessage(fmt.Sprintf("%s: %d succeeded, %d failed and were rolled back. First error: %v",
+			job.desc, res.succeeded, res.failed, res.firstErr))
+		if job.onDone == nil { // Otherwise the caller reports the error itself
+			dialog.ShowError(fmt.Errorf("%s failed for %d change(s): %w", job.desc, res.failed, res.firstErr), a.UI.MainWin)
+		}
+	} else {
+		a.addLogMessage(fmt.Sprintf("%s: done (%d change(s)).", job.desc, res.succeeded))
+	}
+	a.refreshAfterTagChange(job.ops)
+	if a.refreshTagsFunc != nil {
+		a.refreshTagsFunc()
+	}
+	if job.onDone != nil {
+		job.onDone(res.firstErr)
+	}
+}
+
+// refreshAfterTagChange updates the views and search index entries that show
+// tags of the affected images.
+func (a *App) refreshAfterTagChange(ops []tagOp) {
+	if a.searchIndex != nil {
+		seen := make(map[string]bool, len(ops))
+		var paths []string
+		for _, op := range ops {
+			if !seen[op.path] {
+				seen[op.path] = true
+				paths = append(paths, op.path)
+			}
+		}
+		a.updateSearchIndexTags(paths)
+	}
+	if a.hidesPrivateAfter(ops) {
+		a.hidePrivateImages()
+	}
+	if a.applyStackOps(ops) {
+		a.refocusCurrentImage()
+	}
+	if a.applyQualityOps(ops) {
+		a.refreshThumbStrip()
+	}
+	for _, op := range ops {
+		if op.path == a.img.Path {
+			a.updateInfoText()
+			break
+		}
+	}
+	a.updateStatusBar()
+}
+
+// pendingTagJobs returns the number of tag jobs not yet written to the database.
+func (a *App) pendingTagJobs() int {
+	if a.tagWorker == nil {
+		return 0
+	}
+	return a.tagWorker.inFlight
+}
+
+// withPendingTags overlays not-yet-saved changes on the tags read from the database.
+func (a *App) withPendingTags(path string, tags []string) []string {
+	if a.tagWorker == nil || len(a.tagWorker.pending[path]) == 0 {
+		return tags
+	}
+	set := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		set[t] = true
+	}
+	for tag, p := range a.tagWorker.pending[path] {
+		if p.add {
+			set[tag] = true
+		} else {
+			delete(set, tag)
+		}
+	}
+	merged := make([]string, 0, len(set))
+	for t := range set {
+		merged = append(merged, t)
+	}
+	sort.Strings(merged)
+	return merged
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSubmitTagJobQueueFull(t *testing.T) {
+	a := &App{tagWorker: &tagWorker{jobs: make(chan tagJob, 1), pending: make(map[string]map[string]pendingTag)}}
+	a.ctx, a.stop = context.WithCancel(context.Background())
+	defer a.stop()
+	a.tagWorker.jobs <- tagJob{id: 1} // The worker is stuck on it
+
+	var got error
+	a.submitTagJob("Tagging", []tagOp{{path: "/img/a.jpg", tag: "beach", add: true}}, func(err error) { got = err })
+	if got == nil || !strings.Contains(got.Error(), "tag queue full") {
+		t.Errorf("submitting to a full queue reported %v, want the queue full", got)
+	}
+	if len(a.tagWorker.pending) != 0 || a.tagWorker.inFlight != 0 {
+		t.Errorf("a refused job left pending %v, %d in flight", a.tagWorker.pending, a.tagWorker.inFlight)
+	}
+}
+
+func TestWithPendingTags(t *testing.T) {
+	a := &App{tagWorker: &tagWorker{pending: map[string]map[string]pendingTag{
+		"/img/a.jpg": {
+			"new":    {add: true, jobID: 1},
+			"old":    {add: false, jobID: 2},
+			"stable": {add: true, jobID: 3}, // Already in the DB; must not duplicate
+		},
+	}}}
+
+	got := a.withPendingTags("/img/a.jpg", []string{"old", "stable", "zebra"})
+	want := []string{"new", "stable", "zebra"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("withPendingTags = %v, want %v", got, want)
+	}
+
+	untouched := []string{"x"}
+	if got := a.withPendingTags("/img/b.jpg", untouched); !reflect.DeepEqual(got, untouched) {
+		t.Errorf("withPendingTags for unaffected path = %v, want %v", got, untouched)
+	}
+}
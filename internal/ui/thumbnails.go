@@ -0,0 +1,354 @@
+// Package ui ThumbnailManager generates and caches small previews of images.
+package ui
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"fyslide/internal/decode"
+	"fyslide/internal/iosched"
+	"image"
+	"image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"golang.org/x/image/draw"
+)
+
+const (
+	// DefaultThumbnailSize is the longest edge, in pixels, of generated thumbnails.
+	DefaultThumbnailSize = 96
+	// DefaultThumbnailCacheSize is the number of thumbnails kept in memory.
+	DefaultThumbnailCacheSize = 500
+	// maxConcurrentThumbnails bounds the decodes of a manager without a shared pool.
+	maxConcurrentThumbnails = 4
+	// thumbnailJPEGQuality is the quality of thumbnails written to the disk cache.
+	thumbnailJPEGQuality = 85
+)
+
+// ThumbnailManager decodes images in the background and keeps a bounded
+// in-memory cache of their thumbnails.
+type ThumbnailManager struct {
+	mu       sync.Mutex
+	cache    map[string]image.Image
+	order    []string                       // Insertion order, oldest first, used for eviction
+	pending  map[string][]func(image.Image) // Callbacks waiting on an in-flight generation
+	capacity int
+	size     int
+	decoder  *decode.Pool // Bounds the decodes running at once
+	diskDir  string       // On-disk cache of generated thumbnails, empty for none
+	logger   func(message string)
+	ctx      context.Context // Generations stop once it is cancelled
+	running  sync.WaitGroup  // Generations in progress
+}
+
+// NewThumbnailManager creates a ThumbnailManager holding up to capacity thumbnails
+// whose longest edge is size pixels. logger is optional.
+func NewThumbnailManager(capacity, size int, logger func(message string)) *ThumbnailManager {
+	if capacity <= 0 {
+		capacity = DefaultThumbnailCacheSize
+	}
+	if size <= 0 {
+		size = DefaultThumbnailSize
+	}
+	return &ThumbnailManager{
+		cache:    make(map[string]image.Image),
+		pending:  make(map[string][]func(image.Image)),
+		capacity: capacity,
+		size:     size,
+		decoder:  decode.NewPool(maxConcurrentThumbnails, nil),
+		logger:   logger,
+		ctx:      context.Background(),
+	}
+}
+
+// SetDiskCache keeps generated thumbnails in dir as well, so they survive
+// restarts. Entries are keyed by path, size and modification time, so edited
+// files get a fresh thumbnail. An empty dir disables the disk cache.
+func (tm *ThumbnailManager) SetDiskCache(dir string) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.diskDir = dir
+}
+
+// SetDecoder makes the manager decode images as iosched.Thumbnails on p,
+// shared with the viewer, which decodes the displayed image first.
+func (tm *ThumbnailManager) SetDecoder(p *decode.Pool) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.decoder = p
+}
+
+// SetContext stops the generations running and skips those started once ctx
+// is cancelled; their waiters get nil.
+func (tm *ThumbnailManager) SetContext(ctx context.Context) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.ctx = ctx
+}
+
+// Wait blocks until the generations in progress finished, so no thumbnail is
+// being written to the disk cache any more.
+func (tm *ThumbnailManager) Wait() {
+	tm.running.Wait()
+}
+
+// Get returns the cached thumbnail for path if present. Otherwise it starts a
+// background generation and returns false; onReady is invoked from the worker
+// goroutine once the thumbnail is available (with nil if generation failed).
+func (tm *ThumbnailManager) Get(path string, onReady func(image.Image)) (image.Image, bool) {
+	tm.mu.Lock()
+	if thumb, ok := tm.cache[path]; ok {
+		tm.mu.Unlock()
+		return thumb, true
+	}
+	waiters, inFlight := tm.pending[path]
+	if onReady != nil {
+		waiters = append(waiters, onReady)
+	}
+	tm.pending[path] = waiters
+	tm.mu.Unlock()
+
+	if !inFlight {
+		tm.running.Add(1)
+		go tm.generate(path)
+	}
+	return nil, false
+}
+
+// Cached returns the cached thumbnail for path without generating a missing one.
+func (tm *ThumbnailManager) Cached(path string) (image.Image, bool) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	thumb, ok := tm.cache[path]
+	return thumb, ok
+}
+
+// generate decodes path, scales it down and notifies any waiters.
+func (tm *ThumbnailManager) generate(path string) {
+	defer tm.running.Done()
+	thumb, err := tm.makeThumbnail(path)
+
+	if err != nil && tm.logger != nil && !errors.Is(err, context.Canceled) {
+		tm.logger(fmt.Sprintf("Thumbnail: %v", err))
+	}
+
+	tm.mu.Lock()
+	if thumb != nil {
+		tm.store(path, thumb)
+	}
+	waiters := tm.pending[path]
+	delete(tm.pending, path)
+	tm.mu.Unlock()
+
+	for _, cb := range waiters {
+		cb(thumb)
+	}
+}
+
+// store adds a thumbnail to the cache, evicting the oldest entries when full.
+// The caller must hold tm.mu.
+func (tm *ThumbnailManager) store(path string, thumb image.Image) {
+	if _, exists := tm.cache[path]; !exists {
+		tm.order = append(tm.order, path)
+	}
+	tm.cache[path] = thumb
+	for len(tm.order) > tm.capacity {
+		oldest := tm.order[0]
+		tm.order = tm.order[1:]
+		delete(tm.cache, oldest)
+	}
+}
+
+// Forget drops the cached thumbnail for path, e.g. after the file was edited.
+func (tm *ThumbnailManager) Forget(path string) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if _, ok := tm.cache[path]; !ok {
+		return
+	}
+	delete(tm.cache, path)
+	for i, p := range tm.order {
+		if p == path {
+			tm.order = append(tm.order[:i], tm.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Rename re-keys a cached thumbnail after its file was renamed.
+func (tm *ThumbnailManager) Rename(oldPath, newPath string) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	thumb, ok := tm.cache[oldPath]
+	if !ok {
+		return
+	}
+	delete(tm.cache, oldPath)
+	tm.cache[newPath] = thumb
+	for i, p := range tm.order {
+		if p == oldPath {
+			tm.order[i] = newPath
+			break
+		}
+	}
+}
+
+// makeThumbnail decodes the image at path and scales it to fit tm.size,
+// going through the disk cache when one is set.
+func (tm *ThumbnailManager) makeThumbnail(path string) (image.Image, error) {
+	tm.mu.Lock()
+	ctx, diskDir, decoder := tm.ctx, tm.diskDir, tm.decoder
+	tm.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var cached string
+	if diskDir != "" {
+		if info, err := os.Stat(path); err == nil {
+			cached = tm.diskCachePath(diskDir, path, info)
+			if thumb, _, err := decoder.Decode(ctx, iosched.Thumbnails, cached); err == nil {
+				return thumb, nil
+			}
+		}
+	}
+
+	thumb, ok := embeddedThumbnail(ctx, decoder, path, tm.size)
+	if !ok {
+		src, _, err := decoder.Decode(ctx, iosched.Thumbnails, path)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", path, err)
+		}
+		thumb = scaleToFit(src, tm.size)
+	}
+	if cached != "" {
+		if err := saveJPEG(cached, thumb); err != nil && tm.logger != nil {
+			tm.logger(fmt.Sprintf("Thumbnail cache: %v", err))
+		}
+	}
+	return thumb, nil
+}
+
+// embeddedThumbnail returns the thumbnail a JPEG at path carries in its EXIF
+// data, scaled to fit size. Reading it takes only the start of the file rather
+// than a decode of the whole image. Returns false for other files, and when
+// the thumbnail is missing, smaller than size or letterboxed to proportions
+// other than the image's, as some cameras do.
+func embeddedThumbnail(ctx context.Context, decoder *decode.Pool, path string, size int) (image.Image, bool) {
+	if !isJPEG(path) {
+		return nil, false
+	}
+	file, err := decoder.Open(ctx, iosched.Thumbnails, path)
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+	x, err := exif.Decode(file)
+	if err != nil {
+		return nil, false
+	}
+	thumb, err := exifThumbnail(x)
+	if err != nil {
+		return nil, false
+	}
+	b := thumb.Bounds()
+	if max(b.Dx(), b.Dy()) < size {
+		return nil, false
+	}
+	if w, h := exifDimensions(x); w > 0 && h > 0 && !sameProportions(b.Dx(), b.Dy(), w, h) {
+		return nil, false
+	}
+	return scaleToFit(thumb, size), true
+}
+
+// exifDimensions returns the pixel size of the image EXIF data x records, or
+// zeros if it records none.
+func exifDimensions(x *exif.Exif) (int, int) {
+	wTag, err := x.Get(exif.PixelXDimension)
+	if err != nil {
+		return 0, 0
+	}
+	hTag, err := x.Get(exif.PixelYDimension)
+	if err != nil {
+		return 0, 0
+	}
+	w, werr := wTag.Int(0)
+	h, herr := hTag.Int(0)
+	if werr != nil || herr != nil {
+		return 0, 0
+	}
+	return w, h
+}
+
+// thumbnailAspectTolerance is how far the aspect ratio of an embedded
+// thumbnail may be off the image's, as thumbnails round to whole pixels.
+const thumbnailAspectTolerance = 0.05
+
+// sameProportions reports whether w1 x h1 and w2 x h2 have about the same
+// aspect ratio, in either orientation.
+func sameProportions(w1, h1, w2, h2 int) bool {
+	a := float64(max(w1, h1)) / float64(min(w1, h1))
+	b := float64(max(w2, h2)) / float64(min(w2, h2))
+	return math.Abs(a-b) <= thumbnailAspectTolerance*b
+}
+
+// diskCachePath returns the disk cache file of the thumbnail of path.
+func (tm *ThumbnailManager) diskCachePath(dir, path string, info os.FileInfo) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%d\x00%d", path, info.Size(), info.ModTime().UnixNano(), tm.size)))
+	key := hex.EncodeToString(sum[:16])
+	return filepath.Join(dir, key[:2], key+".jpg")
+}
+
+// saveJPEG writes a thumbnail to the disk cache through a temporary file, so
+// concurrent readers never see a partial one.
+func saveJPEG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".thumb-*")
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := jpeg.Encode(tmp, img, &jpeg.Options{Quality: thumbnailJPEGQuality}); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// scaleToFit returns src scaled so that its longest edge is at most maxEdge pixels.
+func scaleToFit(src image.Image, maxEdge int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= 0 || h <= 0 {
+		return src
+	}
+	if w <= maxEdge && h <= maxEdge {
+		return src
+	}
+	if w >= h {
+		h = h * maxEdge / w
+		w = maxEdge
+	} else {
+		w = w * maxEdge / h
+		h = maxEdge
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestThumbnailDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "photo.png")
+	writePNG := func() {
+		file, err := os.Create(imgPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := png.Encode(file, image.NewRGBA(image.Rect(0, 0, 200, 100))); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+	}
+	writePNG()
+
+	cacheDir := filepath.Join(dir, "thumbnails")
+	tm := NewThumbnailManager(10, 50, nil)
+	tm.SetDiskCache(cacheDir)
+	thumb, err := tm.makeThumbnail(imgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := thumb.Bounds(); b.Dx() != 50 || b.Dy() != 25 {
+		t.Errorf("thumbnail is %v, want 50x25", b)
+	}
+	info, _ := os.Stat(imgPath)
+	cached := tm.diskCachePath(cacheDir, imgPath, info)
+	if _, err := os.Stat(cached); err != nil {
+		t.Fatalf("thumbnail was not written to the disk cache: %v", err)
+	}
+
+	// A fresh manager is served from disk, even once the source is unreadable
+	other := NewThumbnailManager(10, 50, nil)
+	other.SetDiskCache(cacheDir)
+	if err := os.Chmod(imgPath, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.makeThumbnail(imgPath); err != nil {
+		t.Errorf("cached thumbnail was not used: %v", err)
+	}
+	os.Chmod(imgPath, 0o644)
+
+	// Editing the file invalidates the entry
+	writePNG()
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(imgPath, later, later)
+	info, _ = os.Stat(imgPath)
+	if tm.diskCachePath(cacheDir, imgPath, info) == cached {
+		t.Error("a modified file must get a new cache entry")
+	}
+}
+
+func TestThumbnailContextCancelled(t *testing.T) {
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "photo.png")
+	file, err := os.Create(imgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, image.NewRGBA(image.Rect(0, 0, 200, 100))); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	tm := NewThumbnailManager(10, 50, nil)
+	cacheDir := filepath.Join(dir, "thumbnails")
+	tm.SetDiskCache(cacheDir)
+	ctx, cancel := context.WithCancel(context.Background())
+	tm.SetContext(ctx)
+	cancel()
+
+	var got image.Image = image.NewRGBA(image.Rect(0, 0, 1, 1))
+	if _, ok := tm.Get(imgPath, func(thumb image.Image) { got = thumb }); ok {
+		t.Fatal("nothing was cached yet")
+	}
+	tm.Wait()
+	if got != nil {
+		t.Error("a generation after the context was cancelled must give nil")
+	}
+	if _, ok := tm.Cached(imgPath); ok {
+		t.Error("no thumbnail may be cached after the context was cancelled")
+	}
+	if _, err := os.Stat(cacheDir); !os.IsNotExist(err) {
+		t.Errorf("nothing may be written to the disk cache, got %v", err)
+	}
+}
+
+// jpegWithEXIFThumbnail encodes a w x h JPEG filled with c whose EXIF data
+// carries thumb as its thumbnail.
+func jpegWithEXIFThumbnail(t *testing.T, w, h int, c color.Color, thumb []byte) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	var full bytes.Buffer
+	if err := jpeg.Encode(&full, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// TIFF header, an empty IFD0, and IFD1 pointing at the thumbnail
+	le := binary.LittleEndian
+	tiff := []byte("II*\x00")
+	tiff = le.AppendUint32(tiff, 8)
+	tiff = le.AppendUint16(tiff, 0)
+	tiff = le.AppendUint32(tiff, 14)
+	tiff = le.AppendUint16(tiff, 2)
+	for _, entry := range [][2]uint32{{0x0201, 44}, {0x0202, uint32(len(thumb))}} {
+		tiff = le.AppendUint16(tiff, uint16(entry[0]))
+		tiff = le.AppendUint16(tiff, 4) // LONG
+		tiff = le.AppendUint32(tiff, 1)
+		tiff = le.AppendUint32(tiff, entry[1])
+	}
+	tiff = le.AppendUint32(tiff, 0)
+	tiff = append(tiff, thumb...)
+
+	app1 := binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(2+6+len(tiff)))
+	app1 = append(append(app1, "Exif\x00\x00"...), tiff...)
+	return append(append(full.Bytes()[:2:2], app1...), full.Bytes()[2:]...)
+}
+
+func TestEmbeddedThumbnail(t *testing.T) {
+	var thumb bytes.Buffer
+	blue := image.NewRGBA(image.Rect(0, 0, 160, 80))
+	draw.Draw(blue, blue.Bounds(), image.NewUniform(color.RGBA{B: 255, A: 255}), image.Point{}, draw.Src)
+	if err := jpeg.Encode(&thumb, blue, nil); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "photo.jpg")
+	if err := os.WriteFile(path, jpegWithEXIFThumbnail(t, 400, 200, color.RGBA{R: 255, A: 255}, thumb.Bytes()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	isBlue := func(img image.Image) bool {
+		r, _, b, _ := img.At(img.Bounds().Dx()/2, img.Bounds().Dy()/2).RGBA()
+		return b > 0xC000 && r < 0x4000
+	}
+
+	got, err := NewThumbnailManager(10, 96, nil).makeThumbnail(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := got.Bounds(); b.Dx() != 96 || b.Dy() != 48 || !isBlue(got) {
+		t.Errorf("thumbnail is %v, blue %v; want the embedded one at 96x48", b, isBlue(got))
+	}
+
+	// The embedded thumbnail is too small for 200 pixels, so the image is decoded
+	got, err = NewThumbnailManager(10, 200, nil).makeThumbnail(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := got.Bounds(); b.Dx() != 200 || b.Dy() != 100 || isBlue(got) {
+		t.Errorf("thumbnail is %v, blue %v; want the decoded image at 200x100", b, isBlue(got))
+	}
+
+	if !sameProportions(160, 120, 3000, 4000) || sameProportions(160, 120, 6000, 4000) {
+		t.Error("sameProportions must allow rotation and refuse letterboxing")
+	}
+}
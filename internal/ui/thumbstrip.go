@@ -0,0 +1,255 @@
+// Package ui Thumbnail strip showing a window of images around the current one.
+package ui
+
+import (
+	"image"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const thumbSlotSize = 64 // Display size of a single thumbnail slot in the strip
+
+// thumbnailSlot is a tappable cell in the strip displaying one thumbnail.
+type thumbnailSlot struct {
+	widget.BaseWidget
+
+	image     *canvas.Image
+	highlight *canvas.Rectangle
+	warning   *widget.Icon // Badge of images with quality warnings
+	path      string       // Path currently assigned to this slot, "" if empty
+	index     int          // Index in the active list, -1 if empty
+	onTapped  func(index int)
+}
+
+func newThumbnailSlot(onTapped func(index int)) *thumbnailSlot {
+	slot := &thumbnailSlot{
+		image:     canvas.NewImageFromImage(nil),
+		highlight: canvas.NewRectangle(theme.Color(theme.ColorNameSelection)),
+		warning:   widget.NewIcon(theme.WarningIcon()),
+		index:     -1,
+		onTapped:  onTapped,
+	}
+	slot.image.FillMode = canvas.ImageFillContain
+	slot.image.SetMinSize(fyne.NewSize(thumbSlotSize, thumbSlotSize))
+	slot.highlight.Hide()
+	slot.warning.Hide()
+	slot.ExtendBaseWidget(slot)
+	return slot
+}
+
+// CreateRenderer is a Fyne lifecycle method.
+func (s *thumbnailSlot) CreateRenderer() fyne.WidgetRenderer {
+	badge := container.NewVBox(container.NewHBox(layout.NewSpacer(), s.warning))
+	return widget.NewSimpleRenderer(container.NewStack(s.highlight, container.NewPadded(s.image), badge))
+}
+
+// Tapped jumps to the image shown in this slot.
+func (s *thumbnailSlot) Tapped(_ *fyne.PointEvent) {
+	if s.index >= 0 && s.onTapped != nil {
+		s.onTapped(s.index)
+	}
+}
+
+// setImage replaces the displayed thumbnail.
+func (s *thumbnailSlot) setImage(img image.Image) {
+	s.image.Image = img
+	s.image.Refresh()
+}
+
+// setWarning shows or hides the quality warning badge.
+func (s *thumbnailSlot) setWarning(show bool) {
+	if show {
+		s.warning.Show()
+	} else {
+		s.warning.Hide()
+	}
+}
+
+// clear empties the slot so it shows nothing and ignores taps.
+func (s *thumbnailSlot) clear() {
+	s.path = ""
+	s.index = -1
+	s.highlight.Hide()
+	s.setWarning(false)
+	s.setImage(nil)
+}
+
+// thumbnailStrip shows a fixed-size window of thumbnails centered on the current image.
+type thumbnailStrip struct {
+	app       *App
+	slots     []*thumbnailSlot
+	slotBox   *fyne.Container
+	toggleBtn *widget.Button
+	content   *fyne.Container
+	position  string
+	collapsed bool
+}
+
+// newThumbnailStrip builds a strip with size slots docked at position.
+func newThumbnailStrip(a *App, size int, position string, collapsed bool) *thumbnailStrip {
+	ts := &thumbnailStrip{app: a, position: position}
+
+	vertical := position == thumbStripLeft || position == thumbStripRight
+	objects := make([]fyne.CanvasObject, size)
+	ts.slots = make([]*thumbnailSlot, size)
+	for i := range ts.slots {
+		ts.slots[i] = newThumbnailSlot(a.jumpToIndex)
+		objects[i] = ts.slots[i]
+	}
+	if vertical {
+		ts.slotBox = container.NewVBox(objects...)
+		ts.content = container.NewVBox()
+	} else {
+		ts.slotBox = container.NewHBox(objects...)
+		ts.content = container.NewHBox()
+	}
+
+	ts.toggleBtn = widget.NewButtonWithIcon("", theme.MenuDropDownIcon(), a.toggleThumbStrip)
+	ts.content.Add(ts.toggleBtn)
+	ts.content.Add(ts.slotBox)
+	ts.setCollapsed(collapsed)
+	return ts
+}
+
+// CanvasObject returns the object to place in the layout. The strip is centered
+// along its axis so a short list doesn't hug one edge.
+func (ts *thumbnailStrip) CanvasObject() fyne.CanvasObject {
+	return container.NewCenter(ts.content)
+}
+
+// setCollapsed hides or shows the thumbnails, leaving only the toggle button.
+func (ts *thumbnailStrip) setCollapsed(collapsed bool) {
+	ts.collapsed = collapsed
+	if collapsed {
+		ts.slotBox.Hide()
+		ts.toggleBtn.SetIcon(ts.expandIcon())
+	} else {
+		ts.slotBox.Show()
+		ts.toggleBtn.SetIcon(ts.collapseIcon())
+		ts.Update()
+	}
+	ts.content.Refresh()
+}
+
+// collapseIcon points towards the edge the strip is docked to.
+func (ts *thumbnailStrip) collapseIcon() fyne.Resource {
+	switch ts.position {
+	case thumbStripLeft:
+		return theme.NavigateBackIcon()
+	case thumbStripRight:
+		return theme.NavigateNextIcon()
+	default:
+		return theme.MenuDropDownIcon()
+	}
+}
+
+// expandIcon points away from the edge the strip is docked to.
+func (ts *thumbnailStrip) expandIcon() fyne.Resource {
+	switch ts.position {
+	case thumbStripLeft:
+		return theme.NavigateNextIcon()
+	case thumbStripRight:
+		return theme.NavigateBackIcon()
+	default:
+		return theme.MenuDropUpIcon()
+	}
+}
+
+// Update re-targets the slots to the window of images around the current index.
+// Must be called on the Fyne goroutine.
+func (ts *thumbnailStrip) Update() {
+	if ts.collapsed {
+		return // Nothing visible to update; expanding calls Update again
+	}
+	a := ts.app
+	list := a.getCurrentList()
+	half := len(ts.slots) / 2
+
+	for i, slot := range ts.slots {
+		idx := a.index - half + i
+		if idx < 0 || idx >= len(list) {
+			slot.clear()
+			continue
+		}
+		path := list[idx].Path
+		slot.index = idx
+		if idx == a.index {
+			slot.highlight.Show()
+		} else {
+			slot.highlight.Hide()
+		}
+		slot.setWarning(a.qualityWarning(path) != "")
+		if slot.path == path {
+			continue // Already showing (or waiting for) this thumbnail
+		}
+		slot.path = path
+		slot.setImage(nil)
+
+		target := slot // Capture for the callback
+		thumb, ok := a.thumbnailManager.Get(path, func(img image.Image) {
+			fyne.Do(func() {
+				if target.path == path { // Slot may have moved on while we were decoding
+					target.setImage(img)
+				}
+			})
+		})
+		if ok {
+			slot.setImage(thumb)
+		}
+	}
+	ts.slotBox.Refresh()
+}
+
+// jumpToIndex displays the image at index in the active list. Picking a specific
+// image is an explicit choice, so random selection is bypassed for this display.
+func (a *App) jumpToIndex(index int) {
+	if index < 0 || index >= a.getCurrentImageCount() {
+		return
+	}
+	a.addLogMessage("Jumping to " + filepath.Base(a.getCurrentList()[index].Path))
+	a.showIndex(index)
+}
+
+// toggleThumbStrip collapses or expands the strip and remembers the choice.
+func (a *App) toggleThumbStrip() {
+	if a.thumbStrip == nil {
+		return
+	}
+	collapsed := !a.thumbStrip.collapsed
+	a.thumbStrip.setCollapsed(collapsed)
+	a.prefs().SetBool(prefThumbStripCollapsed, collapsed)
+}
+
+// buildImagePane wraps the zoom area with the thumbnail strip docked at its configured position.
+func (a *App) buildImagePane() fyne.CanvasObject {
+	a.thumbStrip = newThumbnailStrip(a, a.thumbStripSize(), a.thumbStripPosition(),
+		a.prefs().Bool(prefThumbStripCollapsed))
+	strip := a.thumbStrip.CanvasObject()
+
+	if a.UI.breadcrumbBar == nil {
+		a.UI.breadcrumbBar = a.buildBreadcrumbBar()
+	}
+	switch a.thumbStrip.position {
+	case thumbStripLeft:
+		return container.NewBorder(a.UI.breadcrumbBar, nil, strip, nil, a.imageArea())
+	case thumbStripRight:
+		return container.NewBorder(a.UI.breadcrumbBar, nil, nil, strip, a.imageArea())
+	default:
+		return container.NewBorder(a.UI.breadcrumbBar, strip, nil, nil, a.imageArea())
+	}
+}
+
+// rebuildImagePane recreates the strip after its size or position preference changed.
+func (a *App) rebuildImagePane() {
+	if a.UI.split == nil {
+		return
+	}
+	a.UI.split.Leading = a.buildImagePane()
+	a.UI.split.Refresh()
+}
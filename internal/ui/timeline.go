@@ -0,0 +1,165 @@
+// Package ui Timeline: the library grouped by the date each image was taken, by
+// year, month and day, to jump to a date or filter the images to it.
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/exifindex"
+	"fyslide/internal/query"
+	"slices"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// timelineBucket is a year, month or day of the timeline.
+type timelineBucket struct {
+	label    string
+	from, to time.Time // First and last instant, inclusive as query.Criteria wants them
+	count    int
+	first    string    // Path of the earliest image
+	firstAt  time.Time // When it was taken
+	children []string  // Ids of the months of a year or the days of a month, in order
+}
+
+// buildTimeline groups images by the local date they were taken. It returns
+// the buckets by id ("2024", "2024-06" or "2024-06-15") and the ids of the
+// years, oldest first.
+func buildTimeline(dates map[string]time.Time) (map[string]*timelineBucket, []string) {
+	buckets := make(map[string]*timelineBucket)
+	var years []string
+	add := func(id, parent, label string, from, to time.Time, path string, taken time.Time) {
+		b := buckets[id]
+		if b == nil {
+			b = &timelineBucket{label: label, from: from, to: to.Add(-time.Nanosecond)}
+			buckets[id] = b
+			if parent == "" {
+				years = append(years, id)
+			} else {
+				buckets[parent].children = append(buckets[parent].children, id)
+			}
+		}
+		b.count++
+		if b.first == "" || taken.Before(b.firstAt) || (taken.Equal(b.firstAt) && path < b.first) {
+			b.first, b.firstAt = path, taken
+		}
+	}
+	for path, taken := range dates {
+		t := taken.In(time.Local)
+		year := time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+		month := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+		yearID, monthID, dayID := year.Format("2006"), month.Format("2006-01"), day.Format("2006-01-02")
+		add(yearID, "", yearID, year, year.AddDate(1, 0, 0), path, taken)
+		add(monthID, yearID, month.Format("January"), month, month.AddDate(0, 1, 0), path, taken)
+		add(dayID, monthID, day.Format("2 Mon"), day, day.AddDate(0, 0, 1), path, taken)
+	}
+	slices.Sort(years)
+	for _, b := range buckets {
+		slices.Sort(b.children) // Ids sort chronologically
+	}
+	return buckets, years
+}
+
+// showTimeline brings the date index up to date behind a progress dialog, then
+// shows the library's timeline.
+func (a *App) showTimeline() {
+	items := slices.Clone(a.images)
+	if len(items) == 0 {
+		dialog.ShowInformation("Timeline", "There are no images to show.", a.UI.MainWin)
+		return
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	bar := widget.NewProgressBar()
+	status := widget.NewLabel("Reading the dates of new and changed images...")
+	progress := dialog.NewCustom("Timeline", "Cancel", container.NewVBox(status, bar), a.UI.MainWin)
+	progress.SetOnClosed(cancel)
+	progress.Resize(fyne.NewSize(searchDialogWidth, progress.MinSize().Height))
+	progress.Show()
+
+	a.goBackground(func() {
+		defer cancel()
+		records, err := exifindex.Update(ctx, a.tagDB, items, func(done, total int) {
+			fyne.Do(func() {
+				bar.SetValue(float64(done) / float64(total))
+				status.SetText(fmt.Sprintf("Reading the dates of new and changed images: %d of %d", done, total))
+			})
+		})
+		dates := make(map[string]time.Time, len(records))
+		for path, info := range records {
+			dates[path] = info.Taken
+		}
+		fyne.Do(func() {
+			progress.Hide()
+			a.mergeImageInfo(records)
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			if err != nil {
+				a.addLogMessage(fmt.Sprintf("Error indexing image dates: %v", err))
+				dialog.ShowError(err, a.UI.MainWin)
+				return
+			}
+			a.showTimelineDialog(buildTimeline(dates))
+		})
+	})
+}
+
+// showTimelineDialog lists the years, months and days with their image
+// counts. The selected bucket can be jumped to or filtered to.
+func (a *App) showTimelineDialog(buckets map[string]*timelineBucket, years []string) {
+	a.slideshowManager.Pause(true)
+	var selected *timelineBucket
+	tree := widget.NewTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID {
+			if id == "" {
+				return years
+			}
+			if b := buckets[id]; b != nil {
+				return b.children
+			}
+			return nil
+		},
+		func(id widget.TreeNodeID) bool {
+			return id == "" || (buckets[id] != nil && len(buckets[id].children) > 0)
+		},
+		func(bool) fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TreeNodeID, _ bool, obj fyne.CanvasObject) {
+			if b := buckets[id]; b != nil {
+				obj.(*widget.Label).SetText(fmt.Sprintf("%s (%s)", b.label, formatNumberWithCommas(int64(b.count))))
+			}
+		},
+	)
+	jumpButton := widget.NewButton("Go to First Image", nil)
+	filterButton := widget.NewButton("Show Only These Dates", nil)
+	jumpButton.Disable()
+	filterButton.Disable()
+	tree.OnSelected = func(id widget.TreeNodeID) {
+		selected = buckets[id]
+		jumpButton.Enable()
+		filterButton.Enable()
+	}
+	if len(years) == 1 {
+		tree.OpenBranch(years[0])
+	}
+
+	d := dialog.NewCustom("Timeline", "Close", container.NewBorder(nil,
+		container.NewHBox(layout.NewSpacer(), jumpButton, filterButton), nil, nil, tree), a.UI.MainWin)
+	d.SetOnClosed(a.slideshowManager.ResumeAfterOperation)
+	jumpButton.OnTapped = func() {
+		d.Hide()
+		a.jumpToPath(selected.first)
+	}
+	filterButton.OnTapped = func() {
+		d.Hide()
+		a.applyCriteria(query.Criteria{From: selected.from, To: selected.to})
+	}
+	d.Resize(fyne.NewSize(searchDialogWidth, searchDialogWidth))
+	d.Show()
+}
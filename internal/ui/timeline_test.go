@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	at := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.Local)
+	}
+	buckets, years := buildTimeline(map[string]time.Time{
+		"/p/late.jpg":  at(2024, time.June, 15, 18),
+		"/p/early.jpg": at(2024, time.June, 15, 9),
+		"/p/july.jpg":  at(2024, time.July, 1, 12),
+		"/p/old.jpg":   at(2019, time.December, 31, 23),
+	})
+	if !slices.Equal(years, []string{"2019", "2024"}) {
+		t.Fatalf("years = %v", years)
+	}
+	y := buckets["2024"]
+	if y.count != 3 || y.first != "/p/early.jpg" || !slices.Equal(y.children, []string{"2024-06", "2024-07"}) {
+		t.Errorf("2024 = %+v", y)
+	}
+	day := buckets["2024-06-15"]
+	if day == nil || day.count != 2 || day.first != "/p/early.jpg" {
+		t.Fatalf("2024-06-15 = %+v", day)
+	}
+	if !day.from.Equal(at(2024, time.June, 15, 0)) || !day.to.Equal(at(2024, time.June, 16, 0).Add(-time.Nanosecond)) {
+		t.Errorf("2024-06-15 spans %v to %v", day.from, day.to)
+	}
+	if m := buckets["2019-12"]; m == nil || m.label != "December" || len(m.children) != 1 {
+		t.Errorf("2019-12 = %+v", m)
+	}
+}
@@ -0,0 +1,289 @@
+// Package ui Scan transforms: turns, deskews and crops batches of scanned
+// images such as film negatives, previewing the first one before and after.
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/exporter"
+	"fyslide/internal/iosched"
+	"fyslide/internal/transform"
+	"image"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// transformMarked is the scope of the images marked for stacking.
+const transformMarked = "Marked images"
+
+// Turns offered by the Transform Scans dialog, in degrees clockwise.
+var transformRotations = map[string]int{
+	"None":                 0,
+	"90° clockwise":        90,
+	"180°":                 180,
+	"90° counterclockwise": 270,
+}
+
+// transformRotationLabels lists the keys of transformRotations in display order.
+var transformRotationLabels = []string{"None", "90° clockwise", "180°", "90° counterclockwise"}
+
+const (
+	// transformPreviewSize is the longest edge of the copy the preview transforms.
+	transformPreviewSize = 1000
+	// transformPreviewMin is the smallest size of the before and after images.
+	transformPreviewMin = 220
+)
+
+// transformSources returns the JPEG and PNG images of scope, those that can be transformed.
+func (a *App) transformSources(scope string) []string {
+	var paths []string
+	add := func(path string) {
+		if transform.Supported(path) {
+			paths = append(paths, path)
+		}
+	}
+	switch scope {
+	case dateScopeCurrent:
+		if a.img.Path != "" {
+			add(a.img.Path)
+		}
+	case transformMarked:
+		for _, path := range a.stackMarks {
+			add(path)
+		}
+	case dateScopeFolder:
+		for _, path := range a.imagesInDirectory(filepath.Dir(a.img.Path)) {
+			add(path)
+		}
+	default:
+		for _, item := range a.getCurrentList() {
+			add(item.Path)
+		}
+	}
+	return paths
+}
+
+// showTransformDialog turns, deskews and crops the borders of the current
+// image, the marked images, its folder or the current list, showing the
+// first image before and after so the settings can be checked first.
+func (a *App) showTransformDialog() {
+	if a.refuseInReadOnly("Transform Scans") {
+		return
+	}
+	if a.img.Path == "" {
+		dialog.ShowInformation("Transform Scans", "No image loaded.", a.UI.MainWin)
+		return
+	}
+	sources := make(map[string][]string)
+	var scopeLabels []string
+	for _, scope := range []string{dateScopeCurrent, transformMarked, dateScopeFolder, dateScopeList} {
+		if scope == transformMarked && len(a.stackMarks) == 0 {
+			continue
+		}
+		paths := a.transformSources(scope)
+		label := fmt.Sprintf("%s (%d JPEG and PNG images)", scope, len(paths))
+		sources[label] = paths
+		scopeLabels = append(scopeLabels, label)
+	}
+
+	a.slideshowManager.Pause(true)
+	scopeRadio := widget.NewRadioGroup(scopeLabels, nil)
+	scopeRadio.Required = true
+	scopeRadio.SetSelected(scopeLabels[0])
+	rotateSelect := widget.NewSelect(transformRotationLabels, nil)
+	rotateSelect.SetSelected(transformRotationLabels[0])
+	deskewCheck := widget.NewCheck("Straighten a slight skew", nil)
+	cropCheck := widget.NewCheck("Crop uniform borders", nil)
+
+	before := canvas.NewImageFromImage(nil)
+	after := canvas.NewImageFromImage(nil)
+	for _, img := range []*canvas.Image{before, after} {
+		img.FillMode = canvas.ImageFillContain
+		img.SetMinSize(fyne.NewSize(transformPreviewMin, transformPreviewMin))
+	}
+	previewLabel := widget.NewLabel("")
+	previewLabel.Wrapping = fyne.TextWrapWord
+
+	options := func() (transform.Options, error) {
+		opts := transform.Options{
+			Rotate: transformRotations[rotateSelect.Selected],
+			Deskew: deskewCheck.Checked,
+			Crop:   cropCheck.Checked,
+		}
+		return opts, opts.Validate()
+	}
+
+	var sample image.Image // Reduced copy of the first image, decoded once
+	var samplePath string
+	var sampleSize image.Point
+	previewGen := 0
+	updatePreview := func() {
+		previewGen++
+		gen := previewGen
+		paths := sources[scopeRadio.Selected]
+		if len(paths) == 0 {
+			before.Image, after.Image = nil, nil
+			before.Refresh()
+			after.Refresh()
+			previewLabel.SetText("There are no JPEG or PNG images to transform.")
+			return
+		}
+		opts, optsErr := options()
+		path, img, size := paths[0], sample, sampleSize
+		go func() {
+			if path != samplePath {
+				decoded, _, err := a.decoder.Decode(a.ctx, iosched.Foreground, path)
+				if err != nil {
+					fyne.Do(func() {
+						if gen == previewGen {
+							previewLabel.SetText(fmt.Sprintf("Can't preview %s: %v", filepath.Base(path), err))
+						}
+					})
+					return
+				}
+				size = decoded.Bounds().Size()
+				img = exporter.Resize(decoded, transformPreviewSize)
+			}
+			result := img
+			text := fmt.Sprintf("%s: choose a transform.", filepath.Base(path))
+			if optsErr == nil {
+				var r transform.Result
+				result, r = transform.Apply(img, opts)
+				text = fmt.Sprintf("%s: %s", filepath.Base(path), transformSummary(r, img.Bounds().Size(), size))
+			}
+			fyne.Do(func() {
+				samplePath, sample, sampleSize = path, img, size
+				if gen != previewGen {
+					return
+				}
+				before.Image, after.Image = img, result
+				before.Refresh()
+				after.Refresh()
+				previewLabel.SetText(text)
+			})
+		}()
+	}
+	scopeRadio.OnChanged = func(string) { updatePreview() }
+	rotateSelect.OnChanged = func(string) { updatePreview() }
+	deskewCheck.OnChanged = func(bool) { updatePreview() }
+	cropCheck.OnChanged = func(bool) { updatePreview() }
+	updatePreview()
+
+	previewPane := container.NewBorder(nil, previewLabel, nil, nil,
+		container.NewGridWithColumns(2,
+			container.NewBorder(widget.NewLabel("Before"), nil, nil, nil, before),
+			container.NewBorder(widget.NewLabel("After"), nil, nil, nil, after)))
+	d := dialog.NewForm("Transform Scans", "Apply", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Images", scopeRadio),
+		widget.NewFormItem("Rotate", rotateSelect),
+		widget.NewFormItem("Deskew", deskewCheck),
+		widget.NewFormItem("Borders", cropCheck),
+		widget.NewFormItem("Preview", previewPane),
+	}, func(confirm bool) {
+		if !confirm {
+			a.slideshowManager.ResumeAfterOperation()
+			return
+		}
+		opts, err := options()
+		paths := sources[scopeRadio.Selected]
+		if err == nil && len(paths) == 0 {
+			err = errors.New("there are no JPEG or PNG images to transform")
+		}
+		if err != nil {
+			dialog.ShowError(err, a.UI.MainWin)
+			a.slideshowManager.ResumeAfterOperation()
+			return
+		}
+		a.runTransform(paths, opts)
+	}, a.UI.MainWin)
+	d.Resize(fyne.NewSize(searchDialogWidth, d.MinSize().Height))
+	d.Show()
+}
+
+// transformSummary describes r, found on a copy of size reduced from an
+// image of size full, in the full image's pixels.
+func transformSummary(r transform.Result, reduced, full image.Point) string {
+	if !r.Changed {
+		return "nothing to change."
+	}
+	var parts []string
+	if r.Skew != 0 {
+		parts = append(parts, fmt.Sprintf("skewed %.1f°, straightened", r.Skew))
+	}
+	if !r.Crop.Empty() {
+		scale := float64(max(full.X, full.Y)) / float64(max(1, reduced.X, reduced.Y))
+		parts = append(parts, fmt.Sprintf("borders cropped to about %dx%d", int(float64(r.Crop.Dx())*scale), int(float64(r.Crop.Dy())*scale)))
+	}
+	if len(parts) == 0 {
+		return "rotated."
+	}
+	return strings.Join(parts, ", ") + "."
+}
+
+// runTransform transforms paths in place in the background behind a
+// progress dialog that can cancel it, then redisplays the current image.
+func (a *App) runTransform(paths []string, opts transform.Options) {
+	ctx, cancel := context.WithCancel(a.ctx)
+	bar := widget.NewProgressBar()
+	status := widget.NewLabel(fmt.Sprintf("Transforming %d images...", len(paths)))
+	progress := dialog.NewCustom("Transform Scans", "Cancel", container.NewVBox(status, bar), a.UI.MainWin)
+	progress.SetOnClosed(cancel)
+	progress.Resize(fyne.NewSize(searchDialogWidth, progress.MinSize().Height))
+	progress.Show()
+
+	paths = slices.Clone(paths)
+	a.goBackground(func() {
+		defer cancel()
+		done, changed, failed := 0, 0, 0
+		var firstErr error
+		for i, path := range paths {
+			if ctx.Err() != nil {
+				break
+			}
+			r, err := transform.ApplyFile(path, opts, false)
+			done++
+			switch {
+			case err != nil:
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+			case r.Changed:
+				changed++
+				a.orientations.record(path, image.Rectangle{Max: r.Size})
+			}
+			fyne.Do(func() {
+				bar.SetValue(float64(i+1) / float64(len(paths)))
+				status.SetText(fmt.Sprintf("%d of %d images", i+1, len(paths)))
+				if err != nil {
+					a.addLogMessage(fmt.Sprintf("Transforming %s failed: %v", filepath.Base(path), err))
+				} else if r.Changed {
+					a.thumbnailManager.Forget(path)
+					a.addLogMessage(fmt.Sprintf("Transformed %s: %s.", filepath.Base(path), r))
+				}
+			})
+		}
+		fyne.Do(func() {
+			progress.Hide()
+			a.slideshowManager.ResumeAfterOperation()
+			summary := fmt.Sprintf("Transformed %d images, %d needed no change, %d failed.", changed, done-changed-failed, failed)
+			if done < len(paths) {
+				summary = fmt.Sprintf("Stopped after %d of %d images. %s", done, len(paths), summary)
+			}
+			a.addLogMessage(summary)
+			if firstErr != nil {
+				summary += fmt.Sprintf("\n\nFirst error: %v", firstErr)
+			}
+			dialog.ShowInformation("Transform Scans", summary, a.UI.MainWin)
+			a.reloadEditedFile(a.img.Path)
+		})
+	})
+}
@@ -0,0 +1,84 @@
+// Package ui How the zoom area switches from one image to the next.
+package ui
+
+import (
+	"image"
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"golang.org/x/image/draw"
+)
+
+// Transition modes for switching images. Either way the previous image stays
+// on screen until the next one is fully decoded.
+const (
+	TransitionInstant   = "instant"   // The next image replaces the previous frame at once
+	TransitionCrossfade = "crossfade" // The next image fades in over the previous frame
+)
+
+// transitionModes lists the valid transition modes in display order.
+var transitionModes = []string{TransitionInstant, TransitionCrossfade}
+
+// crossfadeDuration is how long the next image takes to fade in.
+const crossfadeDuration = 300 * time.Millisecond
+
+// SetTransition sets how the area switches to a new image, one of the
+// Transition* modes.
+func (zpa *ZoomPanArea) SetTransition(mode string) {
+	zpa.transition = mode
+	if mode != TransitionCrossfade {
+		zpa.endCrossfade()
+	}
+}
+
+// startCrossfade fades from the last frame drawn to the image just set.
+// Nothing fades in from an empty area or into one.
+func (zpa *ZoomPanArea) startCrossfade(next image.Image) {
+	from := zpa.frontFrame
+	zpa.endCrossfade()
+	if zpa.transition != TransitionCrossfade || from == nil || next == nil || zpa.originalImg == nil {
+		return
+	}
+	zpa.fadeFrom = from
+	zpa.fadeProgress = 0
+	zpa.fadeAnim = fyne.NewAnimation(crossfadeDuration, func(p float32) {
+		if zpa.fadeFrom != from {
+			return // Ended or superseded by a newer image
+		}
+		zpa.fadeProgress = p
+		if p >= 1 {
+			zpa.fadeFrom, zpa.fadeTo = nil, nil
+		}
+		zpa.Refresh()
+	})
+	zpa.fadeAnim.Start()
+}
+
+// endCrossfade shows the current image at once, e.g. when the user zooms or
+// pans while it is still fading in.
+func (zpa *ZoomPanArea) endCrossfade() {
+	if zpa.fadeAnim != nil {
+		zpa.fadeAnim.Stop()
+		zpa.fadeAnim = nil
+	}
+	zpa.fadeFrom, zpa.fadeTo = nil, nil
+}
+
+// crossfadeFrame returns the frame of a crossfade in progress: the previous
+// frame blended over frame, the fully drawn next image. frame is scaled only
+// once per crossfade; ok is false when no crossfade applies to a frame of
+// this size, such as after the window was resized.
+func (zpa *ZoomPanArea) crossfadeFrame(bounds image.Rectangle, frame func() *image.RGBA) (dst *image.RGBA, ok bool) {
+	if zpa.fadeFrom == nil || zpa.fadeFrom.Bounds() != bounds {
+		return nil, false
+	}
+	if zpa.fadeTo == nil || zpa.fadeTo.Bounds() != bounds {
+		zpa.fadeTo = frame()
+	}
+	dst = image.NewRGBA(bounds)
+	copy(dst.Pix, zpa.fadeTo.Pix)
+	remaining := color.Alpha{A: uint8(255 * (1 - min(max(zpa.fadeProgress, 0), 1)))}
+	draw.DrawMask(dst, bounds, zpa.fadeFrom, bounds.Min, image.NewUniform(remaining), image.Point{}, draw.Over)
+	return dst, true
+}
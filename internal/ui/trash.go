@@ -0,0 +1,216 @@
+// Package ui Trash: lists the deleted images kept in the trash, restores them
+// with their tags, and empties out those deleted long enough ago.
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/tagging"
+	"image"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// trashEmptyInterval is how often images deleted longer ago than
+	// delete.trash_days are emptied out of the trash.
+	trashEmptyInterval = 24 * time.Hour
+	// trashDialogHeight is the height of the trash view.
+	trashDialogHeight = 560
+	// trashTimeLayout formats deletion times in the trash view.
+	trashTimeLayout = "2006-01-02 15:04"
+)
+
+// deleteConsequence tells what deleting an image does, for confirmations.
+func (a *App) deleteConsequence() string {
+	if a.service.TrashDir() != "" {
+		return "Deleted images go to the trash; View > Trash... restores them."
+	}
+	return "This action can't be undone."
+}
+
+// scheduleTrashEmptying empties the images deleted more than days ago out of
+// the trash now and then every trashEmptyInterval, until the app stops.
+func (a *App) scheduleTrashEmptying(days int) {
+	if days <= 0 || a.service.TrashDir() == "" || a.readOnly() {
+		return
+	}
+	olderThan := time.Duration(days) * 24 * time.Hour
+	a.goBackground(func() {
+		ticker := time.NewTicker(trashEmptyInterval)
+		defer ticker.Stop()
+		for {
+			result := a.service.EmptyTrash(a.ctx, olderThan, nil)
+			if result.Done > 0 || result.Err() != nil {
+				fyne.Do(func() {
+					a.addLogMessage(fmt.Sprintf("Emptied %d image(s) deleted over %d days ago out of the trash.", result.Done, days))
+					if err := result.Err(); err != nil && !errors.Is(err, context.Canceled) {
+						a.addLogMessage(fmt.Sprintf("Emptying the trash: %v", err))
+					}
+				})
+			}
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// showTrashDialog lists the images in the trash with their original path,
+// deletion time and tags, to restore them or delete them for good.
+func (a *App) showTrashDialog() {
+	items, err := a.service.Trash(a.ctx)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("failed to read the trash: %w", err), a.UI.MainWin)
+		return
+	}
+	a.slideshowManager.Pause(true)
+
+	status := widget.NewLabel("")
+	selected := -1
+	list := widget.NewList(
+		func() int { return len(items) },
+		func() fyne.CanvasObject {
+			thumb := canvas.NewImageFromImage(nil)
+			thumb.FillMode = canvas.ImageFillContain
+			thumb.SetMinSize(fyne.NewSize(searchThumbSize, searchThumbSize))
+			name := widget.NewLabel("")
+			name.TextStyle.Bold = true
+			detail := widget.NewLabel("")
+			detail.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, thumb, nil, container.NewVBox(name, detail))
+		},
+		nil, // Set below; the update needs the list to refresh items when thumbnails arrive
+	)
+	list.UpdateItem = func(id widget.ListItemID, obj fyne.CanvasObject) {
+		if id >= len(items) {
+			return
+		}
+		item := items[id]
+		row := obj.(*fyne.Container)
+		text := row.Objects[0].(*fyne.Container)
+		thumb := row.Objects[1].(*canvas.Image)
+		text.Objects[0].(*widget.Label).SetText(filepath.Base(item.Path))
+		detail := fmt.Sprintf("%s  ·  deleted %s", filepath.Dir(item.Path), item.Deleted.Format(trashTimeLayout))
+		if len(item.Tags) > 0 {
+			detail += "  ·  " + strings.Join(item.Tags, ", ")
+		}
+		text.Objects[1].(*widget.Label).SetText(detail)
+
+		img, ok := a.thumbnailManager.Get(item.TrashPath, func(image.Image) {
+			fyne.Do(func() { list.RefreshItem(id) })
+		})
+		if !ok {
+			img = nil
+		}
+		thumb.Image = img
+		thumb.Refresh()
+	}
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+	list.OnUnselected = func(widget.ListItemID) { selected = -1 }
+
+	refresh := func() {
+		var err error
+		if items, err = a.service.Trash(a.ctx); err != nil {
+			a.addLogMessage(fmt.Sprintf("Failed to read the trash: %v", err))
+		}
+		selected = -1
+		list.UnselectAll()
+		list.Refresh()
+		switch {
+		case a.service.TrashDir() == "":
+			status.SetText(fmt.Sprintf("%d image(s). The trash is off; deleted images are gone for good.", len(items)))
+		case len(items) == 0:
+			status.SetText("The trash is empty.")
+		default:
+			status.SetText(fmt.Sprintf("%d image(s) in the trash.", len(items)))
+		}
+	}
+	refresh()
+
+	restoreButton := widget.NewButton("Restore", func() {
+		if a.refuseInReadOnly("Restore") || selected < 0 || selected >= len(items) {
+			return
+		}
+		item, err := a.service.RestoreImage(a.ctx, items[selected].ID)
+		if err != nil {
+			dialog.ShowError(err, a.UI.MainWin)
+			return
+		}
+		a.addLogMessage(fmt.Sprintf("Restored %s from the trash.", item.Path))
+		a.restoreToSession(item)
+		refresh()
+	})
+	purgeButton := widget.NewButton("Delete Permanently", func() {
+		if a.refuseInReadOnly("Delete Permanently") || selected < 0 || selected >= len(items) {
+			return
+		}
+		item := items[selected]
+		dialog.ShowConfirm("Delete Permanently", fmt.Sprintf("Delete %s for good?\nThis action can't be undone.", filepath.Base(item.Path)), func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := a.service.PurgeTrash(a.ctx, item.ID); err != nil {
+				dialog.ShowError(err, a.UI.MainWin)
+				return
+			}
+			a.thumbnailManager.Forget(item.TrashPath)
+			a.addLogMessage(fmt.Sprintf("Deleted %s for good.", item.Path))
+			refresh()
+		}, a.UI.MainWin)
+	})
+	daysEntry := widget.NewEntry()
+	daysEntry.SetText("30")
+	emptyButton := widget.NewButton("Empty", func() {
+		if a.refuseInReadOnly("Empty Trash") {
+			return
+		}
+		days, err := strconv.Atoi(strings.TrimSpace(daysEntry.Text))
+		if err != nil || days < 0 {
+			dialog.ShowError(fmt.Errorf("%q is not a number of days", daysEntry.Text), a.UI.MainWin)
+			return
+		}
+		dialog.ShowConfirm("Empty Trash", fmt.Sprintf("Delete the images deleted over %d days ago for good?\nThis action can't be undone.", days), func(ok bool) {
+			if !ok {
+				return
+			}
+			result := a.service.EmptyTrash(a.ctx, time.Duration(days)*24*time.Hour, func(path string, err error) {
+				if err != nil {
+					a.addLogMessage(fmt.Sprintf("Emptying %s out of the trash: %v", filepath.Base(path), err))
+				}
+			})
+			a.addLogMessage(fmt.Sprintf("Emptied %d image(s) out of the trash, %d failed.", result.Done, len(result.Errors)))
+			refresh()
+		}, a.UI.MainWin)
+	})
+
+	actions := container.NewHBox(restoreButton, purgeButton, widget.NewLabel("Empty images deleted over"),
+		container.NewGridWrap(fyne.NewSize(60, daysEntry.MinSize().Height), daysEntry), widget.NewLabel("days ago"), emptyButton)
+	d := dialog.NewCustom("Trash", "Close", container.NewBorder(status, actions, nil, nil, list), a.UI.MainWin)
+	d.SetOnClosed(a.slideshowManager.ResumeAfterOperation)
+	d.Resize(fyne.NewSize(searchDialogWidth, trashDialogHeight))
+	d.Show()
+}
+
+// restoreToSession puts the image of item, just restored, back into the
+// browsed images and the search index.
+func (a *App) restoreToSession(item tagging.TrashItem) {
+	a.thumbnailManager.Forget(item.TrashPath)
+	if !a.addToSession(item.Path) {
+		return
+	}
+	if a.searchIndex != nil {
+		a.searchIndex.Set(item.Path, item.Tags)
+	}
+}
@@ -0,0 +1,182 @@
+// Package ui Usage statistics: counts the images viewed in the tag database's
+// local usage counters and shows them with the commands run and tags added.
+package ui
+
+import (
+	"context"
+	"fmt"
+	"fyslide/internal/tagging"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// usageFlushInterval is how often the images viewed are added to the
+	// usage counters in the database, rather than writing on every image.
+	usageFlushInterval = 5 * time.Minute
+	// usageBarWidth is the width of the bar of the busiest day.
+	usageBarWidth     = 240
+	usageDialogWidth  = 640
+	usageDialogHeight = 520
+	// maxUsageCommands is how many of the most run commands the dialog lists.
+	maxUsageCommands = 5
+)
+
+// usagePeriods are the periods the statistics can be summarized over, the
+// default one first.
+var usagePeriods = []struct {
+	name string
+	days int
+}{{"30 days", 30}, {"7 days", 7}, {"90 days", 90}, {"Year", 365}}
+
+// countImageViewed notes that an image was shown, for the usage statistics.
+func (a *App) countImageViewed() {
+	a.imagesViewed.Add(1)
+}
+
+// flushUsage adds the images viewed since the last flush to the usage
+// counters. Read-only mode leaves the database alone.
+func (a *App) flushUsage() {
+	n := a.imagesViewed.Swap(0)
+	if n == 0 || a.readOnly() {
+		return
+	}
+	a.tagDB.CountUsage(context.Background(), map[string]int{tagging.UsageImagesViewed: int(n)})
+}
+
+// scheduleUsageFlush flushes the usage counters every usageFlushInterval
+// until the app stops; closing the window flushes the rest.
+func (a *App) scheduleUsageFlush() {
+	a.goBackground(func() {
+		ticker := time.NewTicker(usageFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-ticker.C:
+				a.flushUsage()
+			}
+		}
+	})
+}
+
+// showUsageDialog shows how FySlide was used over a selectable period: totals,
+// the commands run most and a bar per active day, with an export to CSV. The
+// statistics are kept in the tag database only.
+func (a *App) showUsageDialog() {
+	a.flushUsage() // Include this session's viewing
+	var days []tagging.UsageDay
+	busiest := 1
+
+	summary := widget.NewLabel("")
+	commands := widget.NewLabel("")
+	dayList := widget.NewList(
+		func() int { return len(days) },
+		func() fyne.CanvasObject {
+			bar := canvas.NewRectangle(theme.Color(theme.ColorNamePrimary))
+			return container.NewBorder(nil, nil, widget.NewLabel("2006-01-02"), widget.NewLabel("000 tags, 000 images"),
+				container.NewWithoutLayout(bar))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			day := days[len(days)-1-id] // Most recent first
+			row := obj.(*fyne.Container)
+			row.Objects[1].(*widget.Label).SetText(day.Day.Format("2006-01-02"))
+			row.Objects[2].(*widget.Label).SetText(fmt.Sprintf("%d tags, %d images",
+				day.Counts[tagging.UsageTagsAdded], day.Counts[tagging.UsageImagesProcessed]+day.Counts[tagging.UsageImagesViewed]))
+			bar := row.Objects[0].(*fyne.Container).Objects[0]
+			width := float32(usageBarWidth * activity(day.Counts) / busiest)
+			bar.Resize(fyne.NewSize(width, theme.TextSize()))
+			bar.Move(fyne.NewPos(0, theme.Padding()*2))
+		},
+	)
+
+	period := widget.NewSelect(nil, nil)
+	for _, p := range usagePeriods {
+		period.Options = append(period.Options, p.name)
+	}
+	since := func() time.Time {
+		n := usagePeriods[0].days
+		for _, p := range usagePeriods {
+			if p.name == period.Selected {
+				n = p.days
+			}
+		}
+		return time.Now().AddDate(0, 0, 1-n)
+	}
+	period.OnChanged = func(string) {
+		var err error
+		if days, err = a.tagDB.UsageSince(a.ctx, since()); err != nil {
+			summary.SetText(fmt.Sprintf("Error: %v", err))
+			days = nil
+		} else {
+			totals := tagging.UsageTotals(days)
+			summary.SetText(fmt.Sprintf("Active on %d day(s)\n%d tag(s) added, %d new\n%d image(s) processed by batch operations\n%d image(s) viewed",
+				len(days), totals[tagging.UsageTagsAdded], totals[tagging.UsageTagsCreated],
+				totals[tagging.UsageImagesProcessed], totals[tagging.UsageImagesViewed]))
+			text := "Commands run:"
+			for i, command := range tagging.UsageCommands(totals) {
+				if i == maxUsageCommands {
+					break
+				}
+				text += "\n" + command.Name + ": " + strconv.Itoa(command.Count)
+			}
+			commands.SetText(text)
+		}
+		busiest = 1
+		for _, day := range days {
+			busiest = max(busiest, activity(day.Counts))
+		}
+		dayList.Refresh()
+	}
+
+	exportButton := widget.NewButton("Export CSV...", func() {
+		a.exportUsageCSV(since())
+	})
+	top := container.NewVBox(container.NewBorder(nil, nil, widget.NewLabel("Last"), nil, period),
+		container.NewGridWithColumns(2, summary, commands))
+	content := container.NewBorder(top, container.NewBorder(nil, nil, widget.NewLabel("Kept on this computer only."), exportButton), nil, nil, dayList)
+	d := dialog.NewCustom("Usage Statistics", "Close", content, a.UI.MainWin)
+	d.Resize(fyne.NewSize(usageDialogWidth, usageDialogHeight))
+	period.SetSelected(usagePeriods[0].name)
+	d.Show()
+}
+
+// activity is the size of a day's bar: tags added plus images handled.
+func activity(counts map[string]int) int {
+	return counts[tagging.UsageTagsAdded] + counts[tagging.UsageImagesProcessed] + counts[tagging.UsageImagesViewed]
+}
+
+// exportUsageCSV asks for a file and writes the usage counters since since to it.
+func (a *App) exportUsageCSV(since time.Time) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, a.UI.MainWin)
+			return
+		}
+		if writer == nil {
+			return // Cancelled
+		}
+		defer writer.Close()
+		days, err := a.tagDB.UsageSince(a.ctx, since)
+		if err == nil {
+			err = tagging.WriteUsageCSV(writer, days)
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("exporting usage statistics: %w", err), a.UI.MainWin)
+			return
+		}
+		a.addLogMessage(fmt.Sprintf("Exported the usage of %d day(s) to %s", len(days), writer.URI().Path()))
+	}, a.UI.MainWin)
+	save.SetFileName("fyslide-usage.csv")
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	save.Show()
+}
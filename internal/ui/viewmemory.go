@@ -0,0 +1,186 @@
+// Package ui Per-image zoom and pan memory: returning to an image restores its view.
+package ui
+
+import (
+	"encoding/json"
+	"image"
+	"slices"
+
+	"fyne.io/fyne/v2"
+)
+
+// Choices of the zoom memory preference.
+const (
+	zoomMemoryAlways  = "Always"
+	zoomMemoryHistory = "Back/Forward only"
+	zoomMemoryOff     = "Off"
+)
+
+var zoomMemoryOptions = []string{zoomMemoryAlways, zoomMemoryHistory, zoomMemoryOff}
+
+// maxRememberedViews bounds the views kept, and saved, per session; the
+// least recently remembered ones are dropped first.
+const maxRememberedViews = 500
+
+// ZoomView is a zoom and pan state independent of the view's size: the zoom
+// and the image point at the center of the view, as fractions of the image.
+type ZoomView struct {
+	Zoom    float32 `json:"zoom"`
+	CenterX float32 `json:"cx"`
+	CenterY float32 `json:"cy"`
+	Width   int     `json:"w"` // Width of the image zoomed, to carry Zoom over to a differently downscaled decode
+}
+
+// zoomViewOf describes the view of an image of imgSize pixels shown at zoom
+// with its top-left corner at pan in a view of viewSize.
+func zoomViewOf(imgSize image.Point, viewSize fyne.Size, zoom float32, pan fyne.Position) ZoomView {
+	return ZoomView{
+		Zoom:    zoom,
+		CenterX: (viewSize.Width/2 - pan.X) / zoom / float32(imgSize.X),
+		CenterY: (viewSize.Height/2 - pan.Y) / zoom / float32(imgSize.Y),
+		Width:   imgSize.X,
+	}
+}
+
+// placement returns the zoom and pan showing v of an image of imgSize pixels
+// in a view of viewSize, with the zoom clamped to [minZoom, maxZoom].
+func (v ZoomView) placement(imgSize image.Point, viewSize fyne.Size, minZoom, maxZoom float32) (float32, fyne.Position) {
+	zoom := v.Zoom
+	if v.Width > 0 && imgSize.X != v.Width {
+		zoom *= float32(v.Width) / float32(imgSize.X)
+	}
+	zoom = min(max(zoom, minZoom), maxZoom)
+	return zoom, fyne.NewPos(
+		viewSize.Width/2-v.CenterX*float32(imgSize.X)*zoom,
+		viewSize.Height/2-v.CenterY*float32(imgSize.Y)*zoom,
+	)
+}
+
+// viewMemory holds the remembered views of images by path, most recent last.
+type viewMemory struct {
+	views map[string]ZoomView
+	order []string
+}
+
+func newViewMemory() *viewMemory {
+	return &viewMemory{views: make(map[string]ZoomView)}
+}
+
+// remember stores the view of path, dropping the oldest views beyond maxRememberedViews.
+func (m *viewMemory) remember(path string, v ZoomView) {
+	m.forget(path)
+	m.views[path] = v
+	m.order = append(m.order, path)
+	for len(m.order) > maxRememberedViews {
+		delete(m.views, m.order[0])
+		m.order = m.order[1:]
+	}
+}
+
+// forget drops the view of path, if any.
+func (m *viewMemory) forget(path string) {
+	if _, ok := m.views[path]; !ok {
+		return
+	}
+	delete(m.views, path)
+	if i := slices.Index(m.order, path); i >= 0 {
+		m.order = slices.Delete(m.order, i, i+1)
+	}
+}
+
+// lookup returns the remembered view of path.
+func (m *viewMemory) lookup(path string) (ZoomView, bool) {
+	v, ok := m.views[path]
+	return v, ok
+}
+
+// savedView is a remembered view as persisted, in order.
+type savedView struct {
+	Path string `json:"path"`
+	ZoomView
+}
+
+// MarshalJSON encodes the views oldest first, so loading them keeps their order.
+func (m *viewMemory) MarshalJSON() ([]byte, error) {
+	saved := make([]savedView, 0, len(m.order))
+	for _, path := range m.order {
+		saved = append(saved, savedView{Path: path, ZoomView: m.views[path]})
+	}
+	return json.Marshal(saved)
+}
+
+// UnmarshalJSON adds views encoded by MarshalJSON.
+func (m *viewMemory) UnmarshalJSON(data []byte) error {
+	var saved []savedView
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	for _, s := range saved {
+		m.remember(s.Path, s.ZoomView)
+	}
+	return nil
+}
+
+// rememberCurrentView records the zoom and pan of the image being left, or
+// forgets it if it is shown fitted. Paired displays and images still loading
+// are skipped, as the zoom area doesn't show a.img then.
+func (a *App) rememberCurrentView() {
+	if a.img.Path == "" || a.loadingPath != "" || a.pairedIndex >= 0 || a.zoomMemoryMode() == zoomMemoryOff {
+		return
+	}
+	if v, ok := a.zoomPanArea.View(); ok {
+		a.viewMemory.remember(a.img.Path, v)
+	} else {
+		a.viewMemory.forget(a.img.Path)
+	}
+}
+
+// restoreView applies the remembered view of path, just displayed, if the
+// zoom memory preference allows it for this kind of navigation.
+func (a *App) restoreView(path string, historyNav bool) {
+	switch a.zoomMemoryMode() {
+	case zoomMemoryOff:
+		return
+	case zoomMemoryHistory:
+		if !historyNav {
+			return
+		}
+	}
+	if v, ok := a.viewMemory.lookup(path); ok {
+		a.zoomPanArea.SetView(v)
+	}
+}
+
+// zoomMemoryMode returns the zoom memory preference, one of zoomMemoryOptions.
+func (a *App) zoomMemoryMode() string {
+	mode := a.prefs().StringWithFallback(prefZoomMemory, zoomMemoryAlways)
+	if !slices.Contains(zoomMemoryOptions, mode) {
+		return zoomMemoryAlways
+	}
+	return mode
+}
+
+// loadSavedViews restores the views saved by a previous session, if persisting them is on.
+func (a *App) loadSavedViews() {
+	if !a.prefs().Bool(prefZoomMemoryPersist) {
+		return
+	}
+	if saved := a.prefs().String(prefZoomMemorySaved); saved != "" {
+		if err := json.Unmarshal([]byte(saved), a.viewMemory); err != nil {
+			a.addLogMessage("Ignoring unreadable saved zoom views: " + err.Error())
+		}
+	}
+}
+
+// saveViews persists the remembered views for the next session, or clears
+// saved ones when persisting is off.
+func (a *App) saveViews() {
+	if !a.prefs().Bool(prefZoomMemoryPersist) || a.zoomMemoryMode() == zoomMemoryOff {
+		a.prefs().RemoveValue(prefZoomMemorySaved)
+		return
+	}
+	a.rememberCurrentView()
+	if data, err := json.Marshal(a.viewMemory); err == nil {
+		a.prefs().SetString(prefZoomMemorySaved, string(data))
+	}
+}
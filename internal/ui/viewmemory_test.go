@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"testing"
+
+	"fyne.io/fyne/v2"
+)
+
+func TestZoomViewRoundTrip(t *testing.T) {
+	img := image.Pt(4000, 3000)
+	pan := fyne.NewPos(-1200, -700)
+	v := zoomViewOf(img, fyne.NewSize(800, 600), 2, pan)
+
+	zoom, got := v.placement(img, fyne.NewSize(800, 600), defaultMinZoom, defaultMaxZoom)
+	if !approxEqual(zoom, 2) || !approxEqual(got.X, pan.X) || !approxEqual(got.Y, pan.Y) {
+		t.Errorf("placement in the same view = %v at %v, want 2 at %v", zoom, got, pan)
+	}
+
+	// A bigger window keeps the same image point centered
+	zoom, got = v.placement(img, fyne.NewSize(1000, 800), defaultMinZoom, defaultMaxZoom)
+	if !approxEqual(got.X, pan.X+100) || !approxEqual(got.Y, pan.Y+100) {
+		t.Errorf("placement in a bigger view = %v at %v, want it moved by half the growth", zoom, got)
+	}
+
+	// A decode at half the width doubles the zoom to show the same area
+	zoom, _ = v.placement(image.Pt(2000, 1500), fyne.NewSize(800, 600), defaultMinZoom, defaultMaxZoom)
+	if !approxEqual(zoom, 4) {
+		t.Errorf("zoom for a half size decode = %v, want 4", zoom)
+	}
+}
+
+func TestViewMemoryDropsOldestAndPersists(t *testing.T) {
+	m := newViewMemory()
+	for i := 0; i < maxRememberedViews+2; i++ {
+		m.remember(fmt.Sprintf("/p/%d.jpg", i), ZoomView{Zoom: float32(i)})
+	}
+	m.remember("/p/2.jpg", ZoomView{Zoom: 42}) // Re-remembering makes a view recent again
+	if _, ok := m.lookup("/p/0.jpg"); ok {
+		t.Error("oldest view was kept beyond the limit")
+	}
+	if len(m.views) != maxRememberedViews || len(m.order) != maxRememberedViews {
+		t.Errorf("kept %d views in order %d, want %d", len(m.views), len(m.order), maxRememberedViews)
+	}
+	m.forget("/p/5.jpg")
+	if _, ok := m.lookup("/p/5.jpg"); ok {
+		t.Error("forgotten view still found")
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := newViewMemory()
+	if err := json.Unmarshal(data, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := loaded.lookup("/p/2.jpg"); !ok || v.Zoom != 42 {
+		t.Errorf("loaded view = %+v, %v; want zoom 42", v, ok)
+	}
+	if last := loaded.order[len(loaded.order)-1]; last != "/p/2.jpg" {
+		t.Errorf("most recent loaded view = %s, want /p/2.jpg", last)
+	}
+}
@@ -0,0 +1,94 @@
+// Package ui Window state persistence between runs.
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+)
+
+// Startup window modes.
+const (
+	WindowStartFullscreen = "fullscreen" // Always start fullscreen (the original behavior)
+	WindowStartWindowed   = "windowed"   // Always start in a window of the last used size
+	WindowStartRestore    = "restore"    // Restore whatever state the window was closed in
+)
+
+// windowStartModes lists the valid startup modes in display order.
+var windowStartModes = []string{WindowStartFullscreen, WindowStartWindowed, WindowStartRestore}
+
+const (
+	defaultWindowWidth  = 1280
+	defaultWindowHeight = 800
+	minWindowSize       = 200 // Ignore saved sizes smaller than this; they are likely bogus
+)
+
+// windowStartMode returns the configured startup window mode.
+func (a *App) windowStartMode() string {
+	mode := a.prefs().StringWithFallback(prefWindowStartMode, WindowStartFullscreen)
+	for _, valid := range windowStartModes {
+		if mode == valid {
+			return mode
+		}
+	}
+	return WindowStartFullscreen
+}
+
+// splitOffset returns the saved offset of the image/info split.
+func (a *App) splitOffset() float64 {
+	offset := a.prefs().FloatWithFallback(prefWindowSplitOffset, initialSplitOffset)
+	if offset <= 0 || offset >= 1 {
+		return initialSplitOffset
+	}
+	return offset
+}
+
+// restoreWindowState applies the saved window size and fullscreen state according
+// to the startup mode. Sizes are stored in Fyne's device-independent units, so a
+// window restored on a monitor with a different scale keeps its apparent size.
+func (a *App) restoreWindowState() {
+	prefs := a.prefs()
+	mode := a.windowStartMode()
+	if mode == WindowStartFullscreen {
+		a.UI.MainWin.CenterOnScreen()
+		a.UI.MainWin.SetFullScreen(true)
+		return
+	}
+
+	width := prefs.FloatWithFallback(prefWindowWidth, defaultWindowWidth)
+	height := prefs.FloatWithFallback(prefWindowHeight, defaultWindowHeight)
+	if width < minWindowSize || height < minWindowSize {
+		width, height = defaultWindowWidth, defaultWindowHeight
+	}
+	a.UI.MainWin.Resize(fyne.NewSize(float32(width), float32(height)))
+	// Fyne does not expose the window position or maximized state, so the window
+	// is centered rather than placed where it was.
+	a.UI.MainWin.CenterOnScreen()
+	if mode == WindowStartRestore && prefs.Bool(prefWindowFullscreen) {
+		a.UI.MainWin.SetFullScreen(true)
+	}
+}
+
+// saveWindowState records the window size, fullscreen state and split offset.
+func (a *App) saveWindowState() {
+	if a.UI.MainWin == nil {
+		return
+	}
+	prefs := a.prefs()
+	fullscreen := a.UI.MainWin.FullScreen()
+	prefs.SetBool(prefWindowFullscreen, fullscreen)
+	if !fullscreen { // A fullscreen canvas is the screen size, not the window size
+		size := a.UI.MainWin.Canvas().Size()
+		if size.Width >= minWindowSize && size.Height >= minWindowSize {
+			prefs.SetFloat(prefWindowWidth, float64(size.Width))
+			prefs.SetFloat(prefWindowHeight, float64(size.Height))
+		}
+	}
+	if a.UI.split != nil {
+		prefs.SetFloat(prefWindowSplitOffset, a.UI.split.Offset)
+	}
+}
+
+// quit saves the window state and exits the application.
+func (a *App) quit() {
+	a.saveWindowState()
+	a.app.Quit()
+}
@@ -1,18 +1,28 @@
 package ui
 
 import (
+	"fmt"
+	"fyslide/internal/annotate"
 	"image"
+	"image/color"
+	"math"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"golang.org/x/image/draw"
 )
 
 const (
-	defaultMinZoom        float32 = 0.1  // Example: 10% zoom
-	defaultMaxZoom        float32 = 10.0 // Example: 1000% zoom
-	defaultZoomScrollStep float32 = 0.1  // Zoom step for scroll events
+	defaultMinZoom        float32 = 0.1   // Example: 10% zoom
+	defaultMaxZoom        float32 = 10.0  // Example: 1000% zoom
+	defaultZoomScrollStep float32 = 0.1   // Zoom step for scroll events
+	fineZoomScrollStep    float32 = 0.02  // Zoom step for scroll events with Ctrl held
+	zoomEpsilon           float32 = 0.001 // Tolerance for zoom factor comparisons
 )
 
 // ZoomPanArea is a custom widget for displaying an image with zoom and pan.
@@ -31,22 +41,115 @@ type ZoomPanArea struct {
 	isPanning    bool
 	lastMousePos fyne.Position
 
+	backgroundMode  string      // One of the Background* modes
+	backgroundColor color.Color // Used when backgroundMode is BackgroundCustom
+
+	scalingMode  string // One of the Scaling* modes
+	interacting  bool   // Zoomed or panned within scalingIdleDelay, for ScalingAuto
+	interactGen  int    // Incremented per interaction so stale idle redraws are ignored
+	showDrawTime bool   // Whether drawTimeText shows how long the last frame took
+	drawTimeText *canvas.Text
+
+	transition       string          // One of the Transition* modes
+	frontFrame       *image.RGBA     // Last frame drawn; a crossfade starts from it
+	fadeFrom, fadeTo *image.RGBA     // Frames of the crossfade in progress, see startCrossfade
+	fadeProgress     float32         // How far the crossfade got, from 0 to 1
+	fadeAnim         *fyne.Animation // Drives the crossfade; nil when none runs
+
+	// Overlays drawn on top of the raster
+	errorOverlay *fyne.Container // Placeholder shown when an image failed to load
+	errorName    *widget.Label
+	errorDetail  *widget.Label
+	spinner      *widget.Activity // Busy indicator for slow loads
+	caption      *fyne.Container  // Transient text at the bottom, see ShowCaption
+	captionText  *canvas.Text
+	captionNote  *canvas.Text // Smaller second line, hidden when empty
+	captionGen   int          // Incremented per ShowCaption so stale hides are ignored
+	loading      bool
+	loadingGen   int // Incremented per SetLoading(true) so stale delayed shows are ignored
+
+	annotations     []annotate.Shape     // Drawn over the image, see SetAnnotations
+	showAnnotations bool                 // Whether annotations are drawn outside annotate mode
+	annotateTool    string               // Kind of shape the mouse draws, "" when not annotating
+	annotateColor   string               // Color of the shapes drawn
+	draft           *annotate.Shape      // Shape being drawn, nil if none
+	onAnnotate      func(annotate.Shape) // Called with each shape drawn
+
+	loupe          *ZoomPanArea // Linked area showing a close-up of the point under the pointer, see LinkLoupe
+	loupeX, loupeY float64      // Point the loupe is centered on, as fractions of the image
+
 	OnInteraction   func() // Callback for when user interacts (scrolls, drags) - e.g., to pause slideshow
 	onZoomPanChange func() // Callback for when zoom or pan changes - e.g., to update UI elements
 }
 
+// loadingIndicatorDelay is how long a load must take before the spinner appears,
+// so that fast loads don't make it flicker.
+const loadingIndicatorDelay = 250 * time.Millisecond
+
+// Background modes for the canvas behind and around the image.
+const (
+	BackgroundTheme        = "theme"        // Theme background color (default)
+	BackgroundBlack        = "black"        // Solid black, for dark rooms
+	BackgroundCheckerboard = "checkerboard" // Checkerboard behind the image to reveal transparency
+	BackgroundCustom       = "custom"       // User-chosen solid color
+)
+
+const checkerSquareSize = 8 // Size in pixels of a checkerboard square
+
+var (
+	checkerLight = color.RGBA{R: 0x99, G: 0x99, B: 0x99, A: 0xff}
+	checkerDark  = color.RGBA{R: 0x66, G: 0x66, B: 0x66, A: 0xff}
+)
+
 // NewZoomPanArea creates a new ZoomPanArea widget.
 // The onInteraction func will be called when the user zooms or starts panning.
 func NewZoomPanArea(img image.Image, onInteraction func()) *ZoomPanArea {
 	zpa := &ZoomPanArea{
-		originalImg:   img,
-		zoomFactor:    1.0,
-		panOffset:     fyne.Position{},
-		minZoom:       defaultMinZoom,
-		maxZoom:       defaultMaxZoom,
-		OnInteraction: onInteraction,
+		originalImg:     img,
+		zoomFactor:      1.0,
+		panOffset:       fyne.Position{},
+		minZoom:         defaultMinZoom,
+		maxZoom:         defaultMaxZoom,
+		OnInteraction:   onInteraction,
+		backgroundMode:  BackgroundTheme,
+		scalingMode:     ScalingAuto,
+		transition:      TransitionInstant,
+		showAnnotations: true,
+		loupeX:          0.5,
+		loupeY:          0.5,
 	}
 	zpa.raster = canvas.NewRaster(zpa.draw)
+	zpa.drawTimeText = canvas.NewText("", theme.Color(theme.ColorNameForeground))
+	zpa.drawTimeText.TextStyle.Monospace = true
+	zpa.drawTimeText.Hide()
+
+	zpa.errorName = widget.NewLabelWithStyle("", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	zpa.errorDetail = widget.NewLabel("")
+	zpa.errorDetail.Alignment = fyne.TextAlignCenter
+	zpa.errorDetail.Wrapping = fyne.TextWrapWord
+	errorIcon := widget.NewIcon(theme.ErrorIcon())
+	zpa.errorOverlay = container.NewCenter(container.NewVBox(
+		container.NewCenter(container.NewGridWrap(fyne.NewSize(64, 64), errorIcon)),
+		zpa.errorName,
+		zpa.errorDetail,
+	))
+	zpa.errorOverlay.Hide()
+
+	zpa.spinner = widget.NewActivity()
+	zpa.spinner.Hide()
+
+	zpa.captionText = canvas.NewText("", color.White)
+	zpa.captionText.TextSize = theme.TextHeadingSize()
+	zpa.captionText.TextStyle.Bold = true
+	zpa.captionText.Alignment = fyne.TextAlignCenter
+	zpa.captionNote = canvas.NewText("", color.White)
+	zpa.captionNote.Alignment = fyne.TextAlignCenter
+	zpa.caption = container.NewStack(
+		canvas.NewRectangle(color.NRGBA{A: 0xa0}), // Keeps the text readable on any image
+		container.NewPadded(container.NewVBox(zpa.captionText, zpa.captionNote)),
+	)
+	zpa.caption.Hide()
+
 	zpa.ExtendBaseWidget(zpa)
 	if img != nil {
 		zpa.Reset() // Center the initial image
@@ -54,10 +157,264 @@ func NewZoomPanArea(img image.Image, onInteraction func()) *ZoomPanArea {
 	return zpa
 }
 
-// SetImage updates the image displayed by the widget.
+// SetImage updates the image displayed by the widget. The previous frame
+// stays on screen until the new image is drawn, crossfading into it in
+// TransitionCrossfade mode.
 func (zpa *ZoomPanArea) SetImage(img image.Image) {
+	zpa.startCrossfade(img)
 	zpa.originalImg = img
+	zpa.annotations, zpa.draft = nil, nil // They belong to the previous image
+	zpa.errorOverlay.Hide()
 	zpa.Reset() // Reset zoom/pan for the new image, this will also call onZoomPanChange
+	zpa.loupeX, zpa.loupeY = 0.5, 0.5
+	if zpa.loupe != nil {
+		zpa.loupe.SetImage(img)
+	}
+}
+
+// ReplaceImage swaps the displayed image for another rendering of it, such as
+// an enhanced one, keeping zoom and pan. An image of another size is shown as
+// SetImage would.
+func (zpa *ZoomPanArea) ReplaceImage(img image.Image) {
+	if zpa.originalImg == nil || img == nil || img.Bounds().Size() != zpa.originalImg.Bounds().Size() {
+		zpa.SetImage(img)
+		return
+	}
+	zpa.originalImg = img
+	zpa.fadeTo = nil // A crossfade in progress fades into the new rendering
+	zpa.Refresh()
+	if zpa.loupe != nil {
+		zpa.loupe.ReplaceImage(img)
+	}
+}
+
+// ShowError clears the image and shows a placeholder naming the file that
+// failed to load and why.
+func (zpa *ZoomPanArea) ShowError(fileName string, err error) {
+	zpa.SetImage(nil)
+	zpa.errorName.SetText(fileName)
+	if err != nil {
+		zpa.errorDetail.SetText(err.Error())
+	} else {
+		zpa.errorDetail.SetText("")
+	}
+	zpa.errorOverlay.Show()
+	zpa.Refresh()
+}
+
+// SetLoading shows or hides the busy indicator. The indicator only appears if
+// loading is still in progress after loadingIndicatorDelay.
+// Must be called on the Fyne goroutine.
+func (zpa *ZoomPanArea) SetLoading(loading bool) {
+	zpa.loading = loading
+	if !loading {
+		zpa.spinner.Stop()
+		zpa.spinner.Hide()
+		return
+	}
+	zpa.loadingGen++
+	gen := zpa.loadingGen
+	time.AfterFunc(loadingIndicatorDelay, func() {
+		fyne.Do(func() {
+			if zpa.loading && zpa.loadingGen == gen {
+				zpa.spinner.Show()
+				zpa.spinner.Start()
+			}
+		})
+	})
+}
+
+// ShowCaption shows text centered at the bottom of the area for d, replacing
+// any caption still shown. detail, if not empty, is shown below it in smaller
+// type.
+func (zpa *ZoomPanArea) ShowCaption(text, detail string, d time.Duration) {
+	zpa.captionText.Text = text
+	zpa.captionText.Refresh()
+	zpa.captionNote.Text = detail
+	zpa.captionNote.Refresh()
+	if detail == "" {
+		zpa.captionNote.Hide()
+	} else {
+		zpa.captionNote.Show()
+	}
+	zpa.placeCaption(zpa.Size())
+	zpa.caption.Show()
+	zpa.captionGen++
+	gen := zpa.captionGen
+	time.AfterFunc(d, func() {
+		fyne.Do(func() {
+			if zpa.captionGen == gen {
+				zpa.caption.Hide()
+			}
+		})
+	})
+}
+
+// placeCaption sizes the caption to its text and centers it near the bottom
+// of an area of size.
+func (zpa *ZoomPanArea) placeCaption(size fyne.Size) {
+	captionSize := zpa.caption.MinSize()
+	zpa.caption.Resize(captionSize)
+	zpa.caption.Move(fyne.NewPos((size.Width-captionSize.Width)/2, size.Height-captionSize.Height-theme.Padding()*8))
+}
+
+// SetBackground sets how the area behind and around the image is painted.
+// custom is only used with BackgroundCustom.
+func (zpa *ZoomPanArea) SetBackground(mode string, custom color.Color) {
+	zpa.backgroundMode = mode
+	zpa.backgroundColor = custom
+	zpa.Refresh()
+}
+
+// SetScaling sets the interpolation used when the image is scaled, one of the
+// Scaling* modes, and whether to show how long each frame takes to draw.
+func (zpa *ZoomPanArea) SetScaling(mode string, showDrawTime bool) {
+	zpa.scalingMode = mode
+	zpa.showDrawTime = showDrawTime
+	if showDrawTime {
+		zpa.drawTimeText.Show()
+	} else {
+		zpa.drawTimeText.Hide()
+	}
+	zpa.Refresh()
+}
+
+// SetAnnotations sets the annotations drawn over the current image.
+func (zpa *ZoomPanArea) SetAnnotations(shapes []annotate.Shape) {
+	zpa.annotations = shapes
+	zpa.Refresh()
+}
+
+// Annotations returns the annotations drawn over the current image.
+func (zpa *ZoomPanArea) Annotations() []annotate.Shape {
+	return zpa.annotations
+}
+
+// SetShowAnnotations sets whether annotations are drawn. They are always
+// drawn in annotate mode.
+func (zpa *ZoomPanArea) SetShowAnnotations(show bool) {
+	zpa.showAnnotations = show
+	zpa.Refresh()
+}
+
+// SetAnnotateTool switches to annotate mode, where dragging draws a shape of
+// kind (one of the annotate.Kind* kinds) in color instead of panning and a
+// click places text, calling onAnnotate with each shape. A kind of "" ends
+// annotate mode.
+func (zpa *ZoomPanArea) SetAnnotateTool(kind, color string, onAnnotate func(annotate.Shape)) {
+	zpa.annotateTool, zpa.annotateColor, zpa.onAnnotate = kind, color, onAnnotate
+	zpa.draft = nil
+	zpa.Refresh()
+}
+
+// imageFraction returns the point of the image under pos as fractions of
+// its width and height, clamped to the image.
+func (zpa *ZoomPanArea) imageFraction(pos fyne.Position) (float64, float64) {
+	b := zpa.originalImg.Bounds()
+	sx := float64((pos.X-zpa.panOffset.X)/zpa.zoomFactor) - float64(b.Min.X)
+	sy := float64((pos.Y-zpa.panOffset.Y)/zpa.zoomFactor) - float64(b.Min.Y)
+	return math.Max(0, math.Min(1, sx/float64(b.Dx()))), math.Max(0, math.Min(1, sy/float64(b.Dy())))
+}
+
+// finishDraft hands the shape being drawn to onAnnotate, unless it is a line
+// or box too small to have been meant.
+func (zpa *ZoomPanArea) finishDraft() {
+	s := *zpa.draft
+	zpa.draft = nil
+	zpa.Refresh()
+	if s.Kind == annotate.KindText {
+		s.X2, s.Y2 = 0, 0 // Text has only a position
+	} else {
+		b := zpa.originalImg.Bounds()
+		dx := math.Abs(s.X2-s.X1) * float64(b.Dx()) * float64(zpa.zoomFactor)
+		dy := math.Abs(s.Y2-s.Y1) * float64(b.Dy()) * float64(zpa.zoomFactor)
+		if dx < minAnnotationSize && dy < minAnnotationSize {
+			return
+		}
+	}
+	if zpa.onAnnotate != nil {
+		zpa.onAnnotate(s)
+	}
+}
+
+// minAnnotationSize is the screen size in pixels below which a drawn arrow or
+// box is taken to be a stray click.
+const minAnnotationSize = 4
+
+// markInteracting switches the auto scaling mode to fast drawing, and back to
+// high quality once no zoom or pan happened for scalingIdleDelay.
+func (zpa *ZoomPanArea) markInteracting() {
+	zpa.endCrossfade() // The crossfade's frames are of the previous view
+	if zpa.scalingMode != ScalingAuto {
+		return
+	}
+	zpa.interacting = true
+	zpa.interactGen++
+	gen := zpa.interactGen
+	time.AfterFunc(scalingIdleDelay, func() {
+		fyne.Do(func() {
+			if zpa.interactGen == gen {
+				zpa.interacting = false
+				zpa.Refresh()
+			}
+		})
+	})
+}
+
+// letterboxColor returns the solid color painted around the image.
+func (zpa *ZoomPanArea) letterboxColor() color.RGBA {
+	var c color.Color
+	switch zpa.backgroundMode {
+	case BackgroundBlack:
+		c = color.Black
+	case BackgroundCustom:
+		c = zpa.backgroundColor
+	}
+	if c == nil { // Theme mode, checkerboard letterbox, or no custom color set
+		c = theme.Color(theme.ColorNameBackground)
+	}
+	return color.RGBAModel.Convert(c).(color.RGBA)
+}
+
+// LinkLoupe makes loupe follow this area: it shows the same image at zoom,
+// centered on the point under the pointer, until LinkLoupe(nil, 0). The loupe
+// keeps a zoom the user picks on it until the image changes.
+func (zpa *ZoomPanArea) LinkLoupe(loupe *ZoomPanArea, zoom float32) {
+	zpa.loupe = loupe
+	if loupe == nil {
+		return
+	}
+	if loupe.originalImg != zpa.originalImg {
+		loupe.SetImage(zpa.originalImg)
+	}
+	loupe.CenterOn(zpa.loupeX, zpa.loupeY, zoom)
+}
+
+// CenterOn sets the zoom and pans so the point at the fractions (fx, fy) of
+// the image's width and height lies at the center of the view.
+func (zpa *ZoomPanArea) CenterOn(fx, fy float64, zoom float32) {
+	if zpa.originalImg == nil {
+		return
+	}
+	zpa.zoomFactor = min(max(zoom, zpa.minZoom), zpa.maxZoom)
+	b := zpa.originalImg.Bounds()
+	zpa.panOffset = fyne.NewPos(
+		zpa.Size().Width/2-zpa.zoomFactor*float32(float64(b.Min.X)+fx*float64(b.Dx())),
+		zpa.Size().Height/2-zpa.zoomFactor*float32(float64(b.Min.Y)+fy*float64(b.Dy())),
+	)
+	zpa.Refresh()
+	if zpa.onZoomPanChange != nil {
+		zpa.onZoomPanChange()
+	}
+}
+
+// followPointer centers the linked loupe on the point of the image under pos.
+func (zpa *ZoomPanArea) followPointer(pos fyne.Position) {
+	if zpa.loupe == nil || zpa.originalImg == nil {
+		return
+	}
+	zpa.loupeX, zpa.loupeY = zpa.imageFraction(pos)
+	zpa.loupe.CenterOn(zpa.loupeX, zpa.loupeY, zpa.loupe.zoomFactor)
 }
 
 // SetOnZoomPanChange sets a callback function to be invoked when zoom or pan changes.
@@ -69,29 +426,15 @@ func (zpa *ZoomPanArea) SetOnZoomPanChange(callback func()) {
 func (zpa *ZoomPanArea) Reset() {
 	zpa.panOffset = fyne.Position{} // Reset pan first
 
-	if zpa.originalImg != nil && zpa.Size().Width > 0 && zpa.Size().Height > 0 {
+	if fit := zpa.FitZoom(); fit > 0 {
 		imgBounds := zpa.originalImg.Bounds()
-		imgW := float32(imgBounds.Dx())
-		imgH := float32(imgBounds.Dy())
-		viewW := zpa.Size().Width
-		viewH := zpa.Size().Height
-
-		// Calculate zoom factor to fit the image within the view
-		// while maintaining aspect ratio.
-		zoomW := viewW / imgW
-		zoomH := viewH / imgH
-
-		// Use the smaller zoom factor to ensure the whole image fits
-		zpa.zoomFactor = zoomW
-		if zoomH < zoomW {
-			zpa.zoomFactor = zoomH
-		}
+		zpa.zoomFactor = fit
 
 		// Center the scaled image
-		scaledImgW := imgW * zpa.zoomFactor
-		scaledImgH := imgH * zpa.zoomFactor
-		zpa.panOffset.X = (viewW - scaledImgW) / 2
-		zpa.panOffset.Y = (viewH - scaledImgH) / 2
+		scaledImgW := float32(imgBounds.Dx()) * zpa.zoomFactor
+		scaledImgH := float32(imgBounds.Dy()) * zpa.zoomFactor
+		zpa.panOffset.X = (zpa.Size().Width - scaledImgW) / 2
+		zpa.panOffset.Y = (zpa.Size().Height - scaledImgH) / 2
 	} else {
 		// Default if no image or size is not ready (e.g. initial load before layout)
 		zpa.zoomFactor = 1.0
@@ -102,6 +445,75 @@ func (zpa *ZoomPanArea) Reset() {
 	}
 }
 
+// FitZoom returns the zoom factor at which the whole image fits the view while
+// maintaining its aspect ratio, or 0 if there is no image or the view has no size yet.
+func (zpa *ZoomPanArea) FitZoom() float32 {
+	if zpa.originalImg == nil || zpa.Size().Width <= 0 || zpa.Size().Height <= 0 {
+		return 0
+	}
+	imgBounds := zpa.originalImg.Bounds()
+	if imgBounds.Dx() <= 0 || imgBounds.Dy() <= 0 {
+		return 0
+	}
+	zoomW := zpa.Size().Width / float32(imgBounds.Dx())
+	zoomH := zpa.Size().Height / float32(imgBounds.Dy())
+
+	// Use the smaller zoom factor to ensure the whole image fits
+	if zoomH < zoomW {
+		return zoomH
+	}
+	return zoomW
+}
+
+// View returns the current zoom and pan, independent of the view's size. It
+// returns false when there is no image or it is shown fitted, the default view.
+func (zpa *ZoomPanArea) View() (ZoomView, bool) {
+	if zpa.originalImg == nil || zpa.zoomFactor <= 0 || zpa.IsFitted() {
+		return ZoomView{}, false
+	}
+	return zoomViewOf(zpa.originalImg.Bounds().Size(), zpa.Size(), zpa.zoomFactor, zpa.panOffset), true
+}
+
+// SetView restores a zoom and pan returned by View.
+func (zpa *ZoomPanArea) SetView(v ZoomView) {
+	if zpa.originalImg == nil || zpa.Size().Width <= 0 || zpa.Size().Height <= 0 {
+		return
+	}
+	zpa.zoomFactor, zpa.panOffset = v.placement(zpa.originalImg.Bounds().Size(), zpa.Size(), zpa.minZoom, zpa.maxZoom)
+	zpa.Refresh()
+	if zpa.onZoomPanChange != nil {
+		zpa.onZoomPanChange()
+	}
+}
+
+// IsFitted returns true if the current zoom matches the fit-to-view zoom.
+func (zpa *ZoomPanArea) IsFitted() bool {
+	fit := zpa.FitZoom()
+	if fit <= 0 {
+		return false
+	}
+	diff := zpa.zoomFactor - fit
+	return diff > -zoomEpsilon && diff < zoomEpsilon
+}
+
+// IsFullSize returns true if the image is displayed at 100%.
+func (zpa *ZoomPanArea) IsFullSize() bool {
+	diff := zpa.zoomFactor - 1.0
+	return zpa.originalImg != nil && diff > -zoomEpsilon && diff < zoomEpsilon
+}
+
+// ZoomLabel describes the current zoom for display, e.g. "Fit", "100%" or "240%".
+// It returns an empty string when no image is shown.
+func (zpa *ZoomPanArea) ZoomLabel() string {
+	if zpa.originalImg == nil {
+		return ""
+	}
+	if zpa.IsFitted() {
+		return "Fit"
+	}
+	return fmt.Sprintf("%.0f%%", zpa.zoomFactor*100)
+}
+
 // ShowFullSize sets the zoom to 100% (1.0) and centers the image.
 func (zpa *ZoomPanArea) ShowFullSize() {
 	if zpa.originalImg == nil {
@@ -134,115 +546,298 @@ func (zpa *ZoomPanArea) IsOriginalLargerThanView() bool {
 	return float32(imgBounds.Dx()) > zpa.Size().Width || float32(imgBounds.Dy()) > zpa.Size().Height
 }
 
-// draw is the rendering function for the canvas.Raster.
+// draw is the rendering function for the canvas.Raster. The frame it returns
+// is kept as the front frame a crossfade to the next image starts from.
 func (zpa *ZoomPanArea) draw(w, h int) image.Image {
-	if zpa.originalImg == nil || w <= 0 || h <= 0 {
+	if w <= 0 || h <= 0 {
 		return image.NewRGBA(image.Rect(0, 0, w, h)) // Return empty/transparent
 	}
+	dst, fading := zpa.crossfadeFrame(image.Rect(0, 0, w, h), func() *image.RGBA { return zpa.drawFrame(w, h) })
+	if !fading {
+		dst = zpa.drawFrame(w, h)
+	}
+	zpa.frontFrame = dst
+	return dst
+}
 
+// drawFrame renders the image at the current zoom and pan into a new w x h
+// frame, over the background and under the annotations.
+func (zpa *ZoomPanArea) drawFrame(w, h int) *image.RGBA {
 	dst := image.NewRGBA(image.Rect(0, 0, w, h))
-	srcBounds := zpa.originalImg.Bounds()
+	letterbox := zpa.letterboxColor()
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(letterbox), image.Point{}, draw.Src)
+	if zpa.originalImg == nil {
+		return dst
+	}
 
-	// Pre-calculate inverse zoom factor to avoid division in the loop.
-	// zpa.minZoom should prevent zpa.zoomFactor from being zero.
-	invZoomFactor := float32(1.0) / zpa.zoomFactor
-
-	// Transform defines how to map destination pixels to source pixels
-	// For each pixel (dx, dy) in dst, find corresponding (sx, sy) in src
-	for dy := 0; dy < h; dy++ {
-		for dx := 0; dx < w; dx++ {
-			// Screen point (dx, dy) to image point (sx, sy)
-			// Inverse of pan, then inverse of zoom
-			sx := (float32(dx) - zpa.panOffset.X) * invZoomFactor
-			sy := (float32(dy) - zpa.panOffset.Y) * invZoomFactor
-
-			// Check if the source point is within the original image bounds
-			if sx >= float32(srcBounds.Min.X) && sx < float32(srcBounds.Max.X) &&
-				sy >= float32(srcBounds.Min.Y) && sy < float32(srcBounds.Max.Y) {
-				dst.Set(dx, dy, zpa.originalImg.At(int(sx), int(sy)))
+	start := time.Now()
+	srcBounds := zpa.originalImg.Bounds()
+	zoom := float64(zpa.zoomFactor) // zpa.minZoom keeps this above zero
+	panX, panY := float64(zpa.panOffset.X), float64(zpa.panOffset.Y)
+
+	if zpa.backgroundMode == BackgroundCheckerboard {
+		// Only the image area is checkered; the letterbox stays solid
+		onScreen := image.Rect(
+			int(math.Floor(panX+zoom*float64(srcBounds.Min.X))), int(math.Floor(panY+zoom*float64(srcBounds.Min.Y))),
+			int(math.Ceil(panX+zoom*float64(srcBounds.Max.X))), int(math.Ceil(panY+zoom*float64(srcBounds.Max.Y))),
+		).Intersect(dst.Bounds())
+		for y := onScreen.Min.Y; y < onScreen.Max.Y; y++ {
+			for x := onScreen.Min.X; x < onScreen.Max.X; x++ {
+				dst.SetRGBA(x, y, checkerColor(x, y))
 			}
 		}
 	}
+
+	// Image point (sx, sy) lands on screen at zoom*(sx, sy) + pan. Only the
+	// visible part of the image is scaled: the separable Scale is far faster
+	// than an affine Transform, and its buffers grow with the destination.
+	if sr, dr, ok := visibleRects(srcBounds, dst.Bounds(), zoom, panX, panY); ok {
+		interpolatorFor(zpa.scalingMode, zpa.interacting, sr.Dx()*sr.Dy()).Scale(dst, dr, zpa.originalImg, sr, draw.Over, nil)
+	}
+	if shapes := zpa.annotations; zpa.showAnnotations || zpa.annotateTool != "" {
+		if zpa.draft != nil {
+			shapes = append(shapes[:len(shapes):len(shapes)], *zpa.draft)
+		}
+		onScreen := image.Rect(
+			int(math.Round(panX+zoom*float64(srcBounds.Min.X))), int(math.Round(panY+zoom*float64(srcBounds.Min.Y))),
+			int(math.Round(panX+zoom*float64(srcBounds.Max.X))), int(math.Round(panY+zoom*float64(srcBounds.Max.Y))),
+		)
+		annotate.Draw(dst, onScreen, shapes)
+	}
+
+	if zpa.showDrawTime {
+		label := fmt.Sprintf("%s %v", zpa.scalingMode, time.Since(start).Round(100*time.Microsecond))
+		if zpa.scalingMode == ScalingAuto && zpa.interacting {
+			label += " (fast)"
+		}
+		fyne.Do(func() {
+			zpa.drawTimeText.Text = label
+			zpa.drawTimeText.Refresh()
+		})
+	}
 	return dst
 }
 
+// visibleRects returns the part of the source image src that is on screen
+// within view at the given zoom and pan, and where it lands, rounded to whole
+// pixels. ok is false when none of the image is visible.
+func visibleRects(src, view image.Rectangle, zoom, panX, panY float64) (sr, dr image.Rectangle, ok bool) {
+	toSrcX := func(x int) float64 { return (float64(x) - panX) / zoom }
+	toSrcY := func(y int) float64 { return (float64(y) - panY) / zoom }
+	sr = image.Rect(
+		int(math.Floor(toSrcX(view.Min.X))), int(math.Floor(toSrcY(view.Min.Y))),
+		int(math.Ceil(toSrcX(view.Max.X))), int(math.Ceil(toSrcY(view.Max.Y))),
+	).Intersect(src)
+	if sr.Empty() {
+		return sr, dr, false
+	}
+	dr = image.Rect(
+		int(math.Round(panX+zoom*float64(sr.Min.X))), int(math.Round(panY+zoom*float64(sr.Min.Y))),
+		int(math.Round(panX+zoom*float64(sr.Max.X))), int(math.Round(panY+zoom*float64(sr.Max.Y))),
+	)
+	return sr, dr, !dr.Empty()
+}
+
+// checkerColor returns the checkerboard color for the screen pixel (x, y).
+func checkerColor(x, y int) color.RGBA {
+	if (x/checkerSquareSize+y/checkerSquareSize)%2 == 0 {
+		return checkerLight
+	}
+	return checkerDark
+}
+
 // CreateRenderer is a Fyne lifecycle method.
 func (zpa *ZoomPanArea) CreateRenderer() fyne.WidgetRenderer {
 	return &zoomPanAreaRenderer{zpa: zpa}
 }
 
-// Scrolled handles mouse wheel events for zooming.
+// Scrolled handles mouse wheel events for zooming. Holding Ctrl (or Cmd on macOS)
+// zooms in finer steps. The zoom is anchored at the cursor when the event carries a
+// usable position, otherwise at the center of the view.
 func (zpa *ZoomPanArea) Scrolled(ev *fyne.ScrollEvent) {
 	if zpa.OnInteraction != nil {
 		zpa.OnInteraction()
 	}
+	zpa.markInteracting()
 
-	viewWidth, viewHeight := zpa.Size().Width, zpa.Size().Height
-	// Use event position if available and reliable, otherwise center of view
-	// Fyne's ScrollEvent.Position is often (0,0), so centering is safer.
-	mouseX, mouseY := viewWidth/2, viewHeight/2 // Zoom towards center
+	step := defaultZoomScrollStep
+	if isZoomModifierHeld() {
+		step = fineZoomScrollStep
+	}
 
-	// Point in image space that was under the mouse/center
-	imgSpaceX := (mouseX - zpa.panOffset.X) / zpa.zoomFactor
-	imgSpaceY := (mouseY - zpa.panOffset.Y) / zpa.zoomFactor
+	anchor := zoomAnchor(ev.Position, zpa.Size())
+	newZoom := zoomStep(zpa.zoomFactor, ev.Scrolled.DY, step, zpa.minZoom, zpa.maxZoom)
 
-	// Apply zoom
-	if ev.Scrolled.DY < 0 { // Scroll up/away from user (content moves down) -> zoom out
-		zpa.zoomFactor /= (1.0 + defaultZoomScrollStep)
-	} else if ev.Scrolled.DY > 0 { // Scroll down/towards user (content moves up) -> zoom in
-		zpa.zoomFactor *= (1.0 + defaultZoomScrollStep)
-	}
+	// Adjust panOffset to keep the image point under the anchor in place
+	zpa.panOffset = anchoredPan(zpa.panOffset, anchor, zpa.zoomFactor, newZoom)
+	zpa.zoomFactor = newZoom
 
-	if zpa.zoomFactor < zpa.minZoom {
-		zpa.zoomFactor = zpa.minZoom
-	}
-	if zpa.zoomFactor > zpa.maxZoom {
-		zpa.zoomFactor = zpa.maxZoom
+	zpa.Refresh()
+	if zpa.onZoomPanChange != nil {
+		zpa.onZoomPanChange()
 	}
+}
 
-	// Adjust panOffset to keep the point (imgSpaceX, imgSpaceY) under the mouse/center
-	zpa.panOffset.X = mouseX - (imgSpaceX * zpa.zoomFactor)
-	zpa.panOffset.Y = mouseY - (imgSpaceY * zpa.zoomFactor)
-
+// Pan moves the image by (dx, dy) screen pixels.
+func (zpa *ZoomPanArea) Pan(dx, dy float32) {
+	if zpa.originalImg == nil {
+		return
+	}
+	zpa.panOffset = zpa.panOffset.AddXY(dx, dy)
+	zpa.markInteracting()
 	zpa.Refresh()
 	if zpa.onZoomPanChange != nil {
 		zpa.onZoomPanChange()
 	}
 }
 
-// MouseDown starts panning.
+// IsOverflowing returns true if the image at the current zoom extends beyond the view,
+// i.e. there is something to pan to.
+func (zpa *ZoomPanArea) IsOverflowing() bool {
+	if zpa.originalImg == nil {
+		return false
+	}
+	imgBounds := zpa.originalImg.Bounds()
+	return float32(imgBounds.Dx())*zpa.zoomFactor > zpa.Size().Width+zoomEpsilon ||
+		float32(imgBounds.Dy())*zpa.zoomFactor > zpa.Size().Height+zoomEpsilon
+}
+
+// isZoomModifierHeld reports whether the platform's main modifier is currently pressed.
+func isZoomModifierHeld() bool {
+	app := fyne.CurrentApp()
+	if app == nil {
+		return false
+	}
+	drv, ok := app.Driver().(desktop.Driver)
+	if !ok {
+		return false
+	}
+	mods := drv.CurrentKeyModifiers()
+	return mods&(fyne.KeyModifierControl|fyne.KeyModifierSuper) != 0
+}
+
+// --- Zoom coordinate math ---
+
+// zoomStep returns the zoom factor after a scroll of dy, multiplying or dividing
+// by (1 + step) and clamping to [minZoom, maxZoom].
+func zoomStep(current, dy, step, minZoom, maxZoom float32) float32 {
+	next := current
+	if dy < 0 { // Scroll up/away from user (content moves down) -> zoom out
+		next /= (1.0 + step)
+	} else if dy > 0 { // Scroll down/towards user (content moves up) -> zoom in
+		next *= (1.0 + step)
+	}
+	if next < minZoom {
+		next = minZoom
+	}
+	if next > maxZoom {
+		next = maxZoom
+	}
+	return next
+}
+
+// anchoredPan returns the pan offset that keeps the image point currently under
+// anchor at the same screen position when the zoom changes from oldZoom to newZoom.
+func anchoredPan(pan, anchor fyne.Position, oldZoom, newZoom float32) fyne.Position {
+	if oldZoom <= 0 {
+		return pan
+	}
+	// Point in image space under the anchor
+	imgX := (anchor.X - pan.X) / oldZoom
+	imgY := (anchor.Y - pan.Y) / oldZoom
+	return fyne.NewPos(anchor.X-imgX*newZoom, anchor.Y-imgY*newZoom)
+}
+
+// zoomAnchor picks the point to zoom around. Synthesized scroll events (keyboard
+// zoom) and some drivers report (0,0); those, and positions outside the view,
+// fall back to the view center.
+func zoomAnchor(pos fyne.Position, viewSize fyne.Size) fyne.Position {
+	center := fyne.NewPos(viewSize.Width/2, viewSize.Height/2)
+	if pos.IsZero() {
+		return center
+	}
+	if pos.X < 0 || pos.Y < 0 || pos.X > viewSize.Width || pos.Y > viewSize.Height {
+		return center
+	}
+	return pos
+}
+
+// MouseDown starts panning, or drawing a shape in annotate mode.
 func (zpa *ZoomPanArea) MouseDown(ev *desktop.MouseEvent) {
 	if zpa.OnInteraction != nil && ev.Button == desktop.MouseButtonPrimary {
 		zpa.OnInteraction()
 	}
+	if zpa.annotateTool != "" && zpa.originalImg != nil {
+		if ev.Button == desktop.MouseButtonPrimary {
+			x, y := zpa.imageFraction(ev.Position)
+			zpa.draft = &annotate.Shape{Kind: zpa.annotateTool, X1: x, Y1: y, X2: x, Y2: y, Color: zpa.annotateColor}
+		}
+		return
+	}
 	if ev.Button == desktop.MouseButtonPrimary { // Or check for a specific modifier if needed
 		zpa.isPanning = true
 		zpa.lastMousePos = ev.Position
 	}
 }
 
-// MouseUp stops panning.
+// MouseUp stops panning, or finishes the shape being drawn.
 func (zpa *ZoomPanArea) MouseUp(_ *desktop.MouseEvent) {
 	zpa.isPanning = false
+	if zpa.draft != nil {
+		zpa.finishDraft()
+	}
 }
 
-// Dragged handles mouse drag for panning.
+// MouseIn moves the linked loupe to the pointer.
+func (zpa *ZoomPanArea) MouseIn(ev *desktop.MouseEvent) {
+	zpa.followPointer(ev.Position)
+}
+
+// MouseMoved moves the linked loupe with the pointer.
+func (zpa *ZoomPanArea) MouseMoved(ev *desktop.MouseEvent) {
+	zpa.followPointer(ev.Position)
+}
+
+// MouseOut leaves the linked loupe where the pointer was last.
+func (zpa *ZoomPanArea) MouseOut() {}
+
+// Dragged handles mouse drag for panning, or for drawing in annotate mode.
 func (zpa *ZoomPanArea) Dragged(ev *fyne.DragEvent) {
+	zpa.followPointer(ev.Position)
+	if zpa.draft != nil {
+		if zpa.draft.Kind != annotate.KindText {
+			zpa.draft.X2, zpa.draft.Y2 = zpa.imageFraction(ev.Position)
+			zpa.Refresh()
+		}
+		return
+	}
 	if !zpa.isPanning {
 		return
 	}
 	delta := ev.Position.Subtract(zpa.lastMousePos)
 	zpa.panOffset = zpa.panOffset.Add(delta)
 	zpa.lastMousePos = ev.Position
+	zpa.markInteracting()
 	zpa.Refresh()
 	if zpa.onZoomPanChange != nil {
 		zpa.onZoomPanChange()
 	}
 }
 
-// DragEnd finalizes panning.
+// DragEnd finalizes panning, or the shape being drawn.
 func (zpa *ZoomPanArea) DragEnd() {
 	zpa.isPanning = false
+	if zpa.draft != nil {
+		zpa.finishDraft()
+	}
+}
+
+// Cursor shows a crosshair in annotate mode.
+func (zpa *ZoomPanArea) Cursor() desktop.Cursor {
+	if zpa.annotateTool != "" {
+		return desktop.CrosshairCursor
+	}
+	return desktop.DefaultCursor
 }
 
 // CurrentZoom returns the current zoom factor.
@@ -253,12 +848,34 @@ func (zpa *ZoomPanArea) CurrentZoom() float32 {
 // --- Renderer for ZoomPanArea ---
 type zoomPanAreaRenderer struct{ zpa *ZoomPanArea }
 
-func (r *zoomPanAreaRenderer) Layout(size fyne.Size)        { r.zpa.raster.Resize(size) }
-func (r *zoomPanAreaRenderer) MinSize() fyne.Size           { return fyne.NewSize(100, 100) } // Basic min size
-func (r *zoomPanAreaRenderer) Refresh()                     { canvas.Refresh(r.zpa.raster) }
-func (r *zoomPanAreaRenderer) Objects() []fyne.CanvasObject { return []fyne.CanvasObject{r.zpa.raster} }
-func (r *zoomPanAreaRenderer) Destroy()                     {}
+func (r *zoomPanAreaRenderer) Layout(size fyne.Size) {
+	r.zpa.raster.Resize(size)
+	r.zpa.errorOverlay.Resize(size)
+
+	// Spinner sits in the top-right corner, out of the way of the image
+	spinnerSize := r.zpa.spinner.MinSize()
+	pad := theme.Padding() * 4
+	r.zpa.spinner.Resize(spinnerSize)
+	r.zpa.spinner.Move(fyne.NewPos(size.Width-spinnerSize.Width-pad, pad))
+
+	// Draw time sits in the top-left corner
+	r.zpa.drawTimeText.Resize(r.zpa.drawTimeText.MinSize())
+	r.zpa.drawTimeText.Move(fyne.NewPos(pad, pad))
+
+	r.zpa.placeCaption(size)
+}
+func (r *zoomPanAreaRenderer) MinSize() fyne.Size { return fyne.NewSize(100, 100) } // Basic min size
+func (r *zoomPanAreaRenderer) Refresh() {
+	canvas.Refresh(r.zpa.raster)
+	r.zpa.errorOverlay.Refresh()
+}
+func (r *zoomPanAreaRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.zpa.raster, r.zpa.errorOverlay, r.zpa.spinner, r.zpa.drawTimeText, r.zpa.caption}
+}
+func (r *zoomPanAreaRenderer) Destroy() {}
 
 var _ fyne.Widget = (*ZoomPanArea)(nil)
 var _ fyne.Scrollable = (*ZoomPanArea)(nil)
 var _ fyne.Draggable = (*ZoomPanArea)(nil)
+var _ desktop.Cursorable = (*ZoomPanArea)(nil)
+var _ desktop.Hoverable = (*ZoomPanArea)(nil)
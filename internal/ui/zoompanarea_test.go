@@ -0,0 +1,306 @@
+package ui
+
+import (
+	"fyslide/internal/annotate"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
+)
+
+func approxEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-3
+}
+
+func TestZoomStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		current float32
+		dy      float32
+		step    float32
+		want    float32
+	}{
+		{"zoom in", 1.0, 1, 0.1, 1.1},
+		{"zoom out", 1.1, -1, 0.1, 1.0},
+		{"fine zoom in", 1.0, 1, 0.02, 1.02},
+		{"no scroll", 2.0, 0, 0.1, 2.0},
+		{"clamp max", 9.5, 1, 0.1, defaultMaxZoom},
+		{"clamp min", 0.105, -1, 0.1, defaultMinZoom},
+	}
+
+	for _, test := range tests {
+		got := zoomStep(test.current, test.dy, test.step, defaultMinZoom, defaultMaxZoom)
+		if !approxEqual(got, test.want) {
+			t.Errorf("%s: zoomStep(%v, %v, %v) = %v; want %v", test.name, test.current, test.dy, test.step, got, test.want)
+		}
+	}
+}
+
+func TestAnchoredPanKeepsAnchorFixed(t *testing.T) {
+	tests := []struct {
+		name    string
+		pan     fyne.Position
+		anchor  fyne.Position
+		oldZoom float32
+		newZoom float32
+	}{
+		{"center zoom in", fyne.NewPos(0, 0), fyne.NewPos(400, 300), 1.0, 2.0},
+		{"cursor zoom in", fyne.NewPos(-120, 35), fyne.NewPos(10, 590), 0.5, 0.55},
+		{"cursor zoom out", fyne.NewPos(50, 50), fyne.NewPos(700, 20), 3.0, 1.5},
+	}
+
+	for _, test := range tests {
+		// Image point under the anchor before the zoom
+		imgX := (test.anchor.X - test.pan.X) / test.oldZoom
+		imgY := (test.anchor.Y - test.pan.Y) / test.oldZoom
+
+		newPan := anchoredPan(test.pan, test.anchor, test.oldZoom, test.newZoom)
+
+		// The same image point must map back onto the anchor after the zoom
+		screenX := imgX*test.newZoom + newPan.X
+		screenY := imgY*test.newZoom + newPan.Y
+		if !approxEqual(screenX, test.anchor.X) || !approxEqual(screenY, test.anchor.Y) {
+			t.Errorf("%s: anchor moved to (%v, %v); want (%v, %v)", test.name, screenX, screenY, test.anchor.X, test.anchor.Y)
+		}
+	}
+}
+
+func TestAnchoredPanSameZoomIsNoop(t *testing.T) {
+	pan := fyne.NewPos(12, -34)
+	got := anchoredPan(pan, fyne.NewPos(100, 100), 1.5, 1.5)
+	if !approxEqual(got.X, pan.X) || !approxEqual(got.Y, pan.Y) {
+		t.Errorf("anchoredPan with unchanged zoom = %v; want %v", got, pan)
+	}
+}
+
+func TestZoomAnchor(t *testing.T) {
+	view := fyne.NewSize(800, 600)
+	center := fyne.NewPos(400, 300)
+
+	tests := []struct {
+		name string
+		pos  fyne.Position
+		want fyne.Position
+	}{
+		{"zero position falls back to center", fyne.NewPos(0, 0), center},
+		{"inside view uses cursor", fyne.NewPos(120, 80), fyne.NewPos(120, 80)},
+		{"outside view falls back to center", fyne.NewPos(900, 80), center},
+		{"negative falls back to center", fyne.NewPos(-5, 80), center},
+	}
+
+	for _, test := range tests {
+		got := zoomAnchor(test.pos, view)
+		if got != test.want {
+			t.Errorf("%s: zoomAnchor(%v) = %v; want %v", test.name, test.pos, got, test.want)
+		}
+	}
+}
+
+func TestDrawBlendsOverBackground(t *testing.T) {
+	under := color.RGBA{R: 0, G: 0, B: 200, A: 0xff}
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{R: 10, G: 20, B: 30, A: 0xff})
+	src.SetNRGBA(1, 0, color.NRGBA{})
+	src.SetNRGBA(2, 0, color.NRGBA{R: 200, G: 0, B: 0, A: 0x80})
+	zpa := &ZoomPanArea{
+		originalImg:     src,
+		zoomFactor:      1,
+		backgroundMode:  BackgroundCustom,
+		backgroundColor: under,
+		scalingMode:     ScalingNearest,
+	}
+
+	tests := []struct {
+		name string
+		x    int
+		want color.RGBA
+	}{
+		{"opaque replaces", 0, color.RGBA{R: 10, G: 20, B: 30, A: 0xff}},
+		{"transparent keeps background", 1, under},
+		{"half transparent mixes", 2, color.RGBA{R: 100, G: 0, B: 99, A: 0xff}},
+		{"letterbox outside the image", 3, under},
+	}
+
+	dst := zpa.draw(4, 1).(*image.RGBA)
+	for _, test := range tests {
+		got := dst.RGBAAt(test.x, 0)
+		if !channelsWithin(got, test.want, 1) {
+			t.Errorf("%s: pixel %d = %v; want %v", test.name, test.x, got, test.want)
+		}
+	}
+}
+
+// channelsWithin reports whether every channel of a and b differs by at most tolerance.
+func channelsWithin(a, b color.RGBA, tolerance int) bool {
+	for _, d := range []int{int(a.R) - int(b.R), int(a.G) - int(b.G), int(a.B) - int(b.B), int(a.A) - int(b.A)} {
+		if d < -tolerance || d > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAnnotateDrawsInImageFractions(t *testing.T) {
+	zpa := &ZoomPanArea{originalImg: image.NewGray(image.Rect(0, 0, 200, 100)), zoomFactor: 2, panOffset: fyne.NewPos(10, 20)}
+	var drawn []annotate.Shape
+	zpa.SetAnnotateTool(annotate.KindBox, "#ffffff", func(s annotate.Shape) { drawn = append(drawn, s) })
+	at := func(x, y float32) fyne.Position { return fyne.NewPos(10+2*x, 20+2*y) } // Screen position of image point (x, y)
+	drag := func(from, to fyne.Position) {
+		zpa.MouseDown(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: from}, Button: desktop.MouseButtonPrimary})
+		zpa.Dragged(&fyne.DragEvent{PointEvent: fyne.PointEvent{Position: to}})
+		zpa.DragEnd()
+		zpa.MouseUp(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: to}, Button: desktop.MouseButtonPrimary})
+	}
+
+	drag(at(50, 25), at(150, 500)) // Ends below the image
+	drag(at(60, 60), at(61, 60))   // A stray click
+	zpa.SetAnnotateTool(annotate.KindText, "#ffffff", func(s annotate.Shape) { drawn = append(drawn, s) })
+	drag(at(100, 50), at(100, 50))
+
+	want := []annotate.Shape{
+		{Kind: annotate.KindBox, X1: 0.25, Y1: 0.25, X2: 0.75, Y2: 1, Color: "#ffffff"},
+		{Kind: annotate.KindText, X1: 0.5, Y1: 0.5, Color: "#ffffff"},
+	}
+	if len(drawn) != len(want) {
+		t.Fatalf("drew %v, want %v", drawn, want)
+	}
+	for i := range want {
+		if drawn[i] != want[i] {
+			t.Errorf("shape %d = %+v, want %+v", i, drawn[i], want[i])
+		}
+	}
+	if zpa.isPanning || zpa.panOffset != fyne.NewPos(10, 20) {
+		t.Error("dragging in annotate mode panned the image")
+	}
+}
+
+func TestLoupeFollowsPointer(t *testing.T) {
+	test.NewTempApp(t)
+	zpa := NewZoomPanArea(nil, nil)
+	zpa.Resize(fyne.NewSize(400, 200))
+	loupe := NewZoomPanArea(nil, nil)
+	loupe.Resize(fyne.NewSize(100, 100))
+	img := image.NewGray(image.Rect(0, 0, 800, 400))
+	zpa.SetImage(img) // Fitted at 50%
+	zpa.LinkLoupe(loupe, 1)
+
+	if loupe.originalImg != img || loupe.CurrentZoom() != 1 {
+		t.Fatalf("linked loupe shows %v at %v, want the image at 100%%", loupe.originalImg != nil, loupe.CurrentZoom())
+	}
+	if want := fyne.NewPos(50-400, 50-200); loupe.panOffset != want { // Centered on the middle
+		t.Errorf("loupe pan = %v, want %v", loupe.panOffset, want)
+	}
+
+	zpa.MouseMoved(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(100, 50)}}) // Image point (200, 100)
+	if want := fyne.NewPos(50-200, 50-100); loupe.panOffset != want {
+		t.Errorf("loupe pan after moving = %v, want %v", loupe.panOffset, want)
+	}
+	if !zpa.IsFitted() {
+		t.Error("moving the pointer changed the main view")
+	}
+
+	zpa.LinkLoupe(nil, 0)
+	zpa.MouseMoved(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(0, 0)}})
+	if want := fyne.NewPos(50-200, 50-100); loupe.panOffset != want {
+		t.Error("unlinked loupe still follows the pointer")
+	}
+}
+
+func TestVisibleRects(t *testing.T) {
+	src := image.Rect(0, 0, 1000, 500)
+	view := image.Rect(0, 0, 200, 100)
+
+	sr, dr, ok := visibleRects(src, view, 0.2, 0, 0)
+	if !ok || sr != src || dr != image.Rect(0, 0, 200, 100) {
+		t.Errorf("fitted: sr %v, dr %v, ok %v; want the whole image on the whole view", sr, dr, ok)
+	}
+
+	// At 200% panned to the middle only a 100x50 source window is visible
+	sr, dr, ok = visibleRects(src, view, 2, -900, -450)
+	if !ok || sr != image.Rect(450, 225, 550, 275) || dr != view {
+		t.Errorf("zoomed in: sr %v, dr %v, ok %v; want (450,225)-(550,275) on the whole view", sr, dr, ok)
+	}
+
+	if _, _, ok := visibleRects(src, view, 1, 300, 0); ok {
+		t.Error("an image panned out of view is reported visible")
+	}
+}
+
+func BenchmarkDraw(b *testing.B) {
+	src := image.NewRGBA(image.Rect(0, 0, 4000, 3000))
+	for _, mode := range scalingModes {
+		b.Run(mode, func(b *testing.B) {
+			zpa := &ZoomPanArea{originalImg: src, zoomFactor: 0.3, backgroundMode: BackgroundBlack, scalingMode: mode}
+			for i := 0; i < b.N; i++ {
+				zpa.draw(1200, 900)
+			}
+		})
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	got, err := parseHexColor("#1a2B3c")
+	if err != nil {
+		t.Fatalf("parseHexColor returned error: %v", err)
+	}
+	if want := (color.RGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0xff}); got != want {
+		t.Errorf("parseHexColor = %v; want %v", got, want)
+	}
+
+	for _, bad := range []string{"", "#123", "#GGGGGG", "12345678"} {
+		if _, err := parseHexColor(bad); err == nil {
+			t.Errorf("parseHexColor(%q) succeeded; want error", bad)
+		}
+	}
+}
+
+func TestCrossfadeFrame(t *testing.T) {
+	red, blue := color.RGBA{R: 0xff, A: 0xff}, color.RGBA{B: 0xff, A: 0xff}
+	solid := func(c color.RGBA, w, h int) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+		return img
+	}
+	zpa := &ZoomPanArea{
+		originalImg:    solid(blue, 4, 2),
+		zoomFactor:     1,
+		backgroundMode: BackgroundBlack,
+		scalingMode:    ScalingNearest,
+		transition:     TransitionCrossfade,
+		fadeFrom:       solid(red, 4, 2),
+	}
+
+	halfway := zpa.draw(4, 2).(*image.RGBA).RGBAAt(1, 1)
+	if !channelsWithin(halfway, red, 1) {
+		t.Errorf("crossfade start = %v, want the previous frame %v", halfway, red)
+	}
+	zpa.fadeProgress = 0.5
+	if got := zpa.draw(4, 2).(*image.RGBA).RGBAAt(1, 1); !channelsWithin(got, color.RGBA{R: 0x80, B: 0x7f, A: 0xff}, 1) {
+		t.Errorf("crossfade halfway = %v, want an even mix", got)
+	}
+	if zpa.frontFrame == nil || zpa.frontFrame.RGBAAt(1, 1) == red {
+		t.Error("the blended frame must become the front frame")
+	}
+
+	// A resized view draws the next image directly
+	if got := zpa.draw(6, 3).(*image.RGBA).RGBAAt(1, 1); got != blue {
+		t.Errorf("resized frame = %v, want %v", got, blue)
+	}
+	zpa.endCrossfade()
+	if got := zpa.draw(4, 2).(*image.RGBA).RGBAAt(1, 1); got != blue {
+		t.Errorf("frame after the crossfade = %v, want %v", got, blue)
+	}
+
+	// Instant switching never fades
+	zpa.transition = TransitionInstant
+	zpa.startCrossfade(solid(red, 4, 2))
+	if zpa.fadeFrom != nil {
+		t.Error("instant mode started a crossfade")
+	}
+}
@@ -0,0 +1,257 @@
+// Package fyslide is the public Go API for programs that want to share
+// FySlide's tag database without importing its internal packages.
+//
+// The API follows semantic versioning, reported by APIVersion. Within a major
+// version, exported identifiers are only ever added: existing functions keep
+// their signatures and behavior, and fields are only appended to structs, so
+// callers should use keyed struct literals. Paths are made absolute and tags are
+// lower-cased exactly as the FySlide GUI and CLI do, so all three see the same data.
+package fyslide
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fyslide/internal/query"
+	"fyslide/internal/scan"
+	"fyslide/internal/service"
+	"fyslide/internal/tagging"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// APIVersion is the semantic version of this package's API.
+const APIVersion = "1.0.0"
+
+// ErrDestinationExists is wrapped by the error Rename returns when the target file already exists.
+var ErrDestinationExists = service.ErrDestinationExists
+
+// Options configure Open. The zero value opens the default database.
+type Options struct {
+	// DBDir is the directory holding the tag database. Empty selects the
+	// default profile's database used by the GUI and CLI, in the per-user
+	// FySlide data directory.
+	DBDir string
+	// Logger receives diagnostic messages. Nil discards them.
+	Logger func(message string)
+}
+
+// Image is an image file found by Scan.
+type Image struct {
+	Path    string // Absolute path
+	Size    int64  // Bytes
+	ModTime time.Time
+}
+
+// TagCount is a tag together with the number of images carrying it.
+type TagCount struct {
+	Name  string
+	Count int
+}
+
+// Orientation values accepted by Query.Orientation.
+const (
+	OrientationAny       = string(query.OrientationAny)
+	OrientationLandscape = string(query.OrientationLandscape)
+	OrientationPortrait  = string(query.OrientationPortrait)
+	OrientationSquare    = string(query.OrientationSquare)
+)
+
+// Query selects images. Zero-valued fields do not restrict the result.
+type Query struct {
+	Tags        []string  // Images must carry every one of these tags
+	From        time.Time // Inclusive lower bound on the EXIF capture date (modification time if absent)
+	To          time.Time // Inclusive upper bound on the same date
+	MinWidth    int       // Pixels
+	MinHeight   int       // Pixels
+	Orientation string    // One of the Orientation constants; "" means any
+	MinSize     int64     // Bytes
+	MaxSize     int64     // Bytes
+}
+
+// Library is an open tag database. It is safe for concurrent use, but only one
+// process may hold the database at a time.
+type Library struct {
+	tagDB   *tagging.TagDB
+	service *service.Service
+}
+
+// Open opens (creating if needed) the tag database described by opts.
+func Open(opts Options) (*Library, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = func(string) {}
+	}
+	tagDB, err := tagging.NewTagDB(opts.DBDir, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tag database: %w", err)
+	}
+	return &Library{tagDB: tagDB, service: service.New(tagDB)}, nil
+}
+
+// Close releases the database.
+func (l *Library) Close() error {
+	return l.tagDB.Close()
+}
+
+// Scan recursively lists the supported image files under dir. Unreadable
+// subdirectories are skipped and reported to logger, which may be nil.
+func Scan(dir string, logger func(message string)) ([]Image, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error getting absolute path for %s: %w", dir, err)
+	}
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot scan %s: %w", absDir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("cannot scan %s: not a directory", absDir)
+	}
+	if logger == nil {
+		logger = func(string) {}
+	}
+
+	var images []Image
+	for item := range scan.Run(absDir, logger) {
+		img := Image{Path: item.Path}
+		if item.Info != nil {
+			img.Size = item.Info.Size()
+			img.ModTime = item.Info.ModTime()
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// IsImage reports whether name has an extension FySlide treats as an image.
+func IsImage(name string) bool {
+	return scan.IsImage(name)
+}
+
+func absPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("error getting absolute path for %s: %w", path, err)
+	}
+	return abs, nil
+}
+
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", errors.New("tag cannot be empty")
+	}
+	return tag, nil
+}
+
+// AddTag tags the image at path.
+func (l *Library) AddTag(path, tag string) error {
+	abs, err := absPath(path)
+	if err != nil {
+		return err
+	}
+	if tag, err = normalizeTag(tag); err != nil {
+		return err
+	}
+	return l.tagDB.AddTag(context.Background(), abs, tag)
+}
+
+// RemoveTag removes tag from the image at path. Removing a tag the image does
+// not carry is not an error.
+func (l *Library) RemoveTag(path, tag string) error {
+	abs, err := absPath(path)
+	if err != nil {
+		return err
+	}
+	if tag, err = normalizeTag(tag); err != nil {
+		return err
+	}
+	return l.tagDB.RemoveTag(context.Background(), abs, tag)
+}
+
+// Tags returns the sorted tags of the image at path.
+func (l *Library) Tags(path string) ([]string, error) {
+	abs, err := absPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return l.tagDB.GetTags(context.Background(), abs)
+}
+
+// Images returns the sorted paths of the images carrying tag.
+func (l *Library) Images(tag string) ([]string, error) {
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	return l.tagDB.GetImages(context.Background(), tag)
+}
+
+// AllTags returns every tag in the database with its image count, sorted by name.
+func (l *Library) AllTags() ([]TagCount, error) {
+	tags, err := l.tagDB.GetAllTags(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]TagCount, len(tags))
+	for i, t := range tags {
+		counts[i] = TagCount{Name: t.Name, Count: t.Count}
+	}
+	return counts, nil
+}
+
+// Rename renames an image file and moves its tags with it. Rather than
+// overwrite another file it fails with an error wrapping ErrDestinationExists.
+func (l *Library) Rename(oldPath, newPath string) error {
+	return l.service.RenameImage(context.Background(), oldPath, newPath)
+}
+
+// Find returns the images from candidates that match q, in their original
+// order. Images whose properties cannot be read are left out.
+func (l *Library) Find(candidates []Image, q Query) ([]Image, error) {
+	c := query.Criteria{
+		From:        q.From,
+		To:          q.To,
+		MinWidth:    q.MinWidth,
+		MinHeight:   q.MinHeight,
+		Orientation: query.Orientation(q.Orientation),
+		MinSize:     q.MinSize,
+		MaxSize:     q.MaxSize,
+	}
+	for _, tag := range q.Tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		c.Tags = append(c.Tags, tag)
+	}
+
+	tagHits := make(map[string]int)
+	for _, tag := range c.Tags {
+		paths, err := l.tagDB.GetImages(context.Background(), tag)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range paths {
+			tagHits[p]++
+		}
+	}
+
+	var matches []Image
+	for _, img := range candidates {
+		if len(c.Tags) > 0 && tagHits[img.Path] != len(c.Tags) {
+			continue
+		}
+		if c.HasPropertyFilters() {
+			props, err := c.LoadProperties(img.Path, nil)
+			if err != nil || !c.Matches(props) {
+				continue
+			}
+		}
+		matches = append(matches, img)
+	}
+	return matches, nil
+}
@@ -0,0 +1,89 @@
+package fyslide
+
+import (
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writePNG(t *testing.T, path string, width, height int) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create %s: %v", path, err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("Failed to encode %s: %v", path, err)
+	}
+}
+
+func TestLibraryTaggingAndFind(t *testing.T) {
+	lib, err := Open(Options{DBDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer lib.Close()
+
+	dir := t.TempDir()
+	wide := filepath.Join(dir, "wide.png")
+	tall := filepath.Join(dir, "tall.png")
+	writePNG(t, wide, 40, 20)
+	writePNG(t, tall, 20, 40)
+
+	images, err := Scan(dir, nil)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("Scan found %d images, want 2", len(images))
+	}
+
+	for _, p := range []string{wide, tall} {
+		if err := lib.AddTag(p, " Trip "); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	if err := lib.AddTag(wide, "beach"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if tags, _ := lib.Tags(wide); !reflect.DeepEqual(tags, []string{"beach", "trip"}) {
+		t.Errorf("Tags = %v, want [beach trip]", tags)
+	}
+	if all, _ := lib.AllTags(); !reflect.DeepEqual(all, []TagCount{{Name: "beach", Count: 1}, {Name: "trip", Count: 2}}) {
+		t.Errorf("AllTags = %v", all)
+	}
+
+	tests := []struct {
+		name string
+		q    Query
+		want []string
+	}{
+		{"single tag", Query{Tags: []string{"TRIP"}}, []string{"tall.png", "wide.png"}},
+		{"all tags", Query{Tags: []string{"trip", "beach"}}, []string{"wide.png"}},
+		{"orientation", Query{Tags: []string{"trip"}, Orientation: OrientationPortrait}, []string{"tall.png"}},
+		{"no match", Query{Tags: []string{"missing"}}, nil},
+	}
+	for _, tt := range tests {
+		found, err := lib.Find(images, tt.q)
+		if err != nil {
+			t.Errorf("%s: Find failed: %v", tt.name, err)
+			continue
+		}
+		var names []string
+		for _, img := range found {
+			names = append(names, filepath.Base(img.Path))
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("%s: Find = %v, want %v", tt.name, names, tt.want)
+		}
+	}
+
+	if err := lib.Rename(tall, wide); !errors.Is(err, ErrDestinationExists) {
+		t.Errorf("Rename onto existing file: err = %v, want ErrDestinationExists", err)
+	}
+}